@@ -52,8 +52,20 @@ func init() {
 
 // main is the entry point of the application.
 // It parses command-line flags, loads configuration, and starts the appropriate
-// service based on the provided flags (login, codex-login, or server mode).
+// service based on the provided flags (login, codex-login, or server mode). The "healthcheck"
+// subcommand probes a running server and exits with its status, the "doctor" subcommand
+// self-tests the local installation, and "test run" executes declarative scenarios.
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "healthcheck" {
+		os.Exit(cmd.RunHealthcheck(os.Args[2:], DefaultConfigPath))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "doctor" {
+		os.Exit(cmd.RunDoctor(os.Args[2:], DefaultConfigPath))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "test" {
+		os.Exit(cmd.RunTest(os.Args[2:], DefaultConfigPath))
+	}
+
 	fmt.Printf("CLIProxyAPI Version: %s, Commit: %s, BuiltAt: %s\n", buildinfo.Version, buildinfo.Commit, buildinfo.BuildDate)
 
 	// Command-line flags to control the application's behavior.
@@ -68,6 +80,8 @@ func main() {
 	var projectID string
 	var vertexImport string
 	var vertexImportPrefix string
+	var geminiImport bool
+	var geminiImportFile string
 	var configPath string
 	var password string
 	var tuiMode bool
@@ -87,6 +101,8 @@ func main() {
 	flag.StringVar(&configPath, "config", DefaultConfigPath, "Configure File Path")
 	flag.StringVar(&vertexImport, "vertex-import", "", "Import Vertex service account key JSON file")
 	flag.StringVar(&vertexImportPrefix, "vertex-import-prefix", "", "Prefix for Vertex model namespacing (use with -vertex-import)")
+	flag.BoolVar(&geminiImport, "gemini-import", false, "Import an existing Gemini CLI login (oauth_creds.json)")
+	flag.StringVar(&geminiImportFile, "gemini-import-file", "", "Gemini CLI oauth_creds.json path or directory (use with -gemini-import, defaults to ~/.gemini)")
 	flag.StringVar(&password, "password", "", "")
 	flag.BoolVar(&tuiMode, "tui", false, "Start with terminal management UI")
 	flag.BoolVar(&standalone, "standalone", false, "In TUI mode, start an embedded local server")
@@ -147,6 +163,11 @@ func main() {
 		objectStoreBucket    string
 		objectStoreLocalPath string
 		objectStoreInst      *store.ObjectTokenStore
+		useSQLiteStore       bool
+		sqliteStorePath      string
+		sqliteStoreDriver    string
+		sqliteStoreLocalPath string
+		sqliteStoreInst      *store.SQLiteStore
 	)
 
 	wd, err := os.Getwd()
@@ -225,6 +246,16 @@ func main() {
 	if value, ok := lookupEnv("OBJECTSTORE_LOCAL_PATH", "objectstore_local_path"); ok {
 		objectStoreLocalPath = value
 	}
+	if value, ok := lookupEnv("SQLITESTORE_PATH", "sqlitestore_path"); ok {
+		useSQLiteStore = true
+		sqliteStorePath = value
+	}
+	if value, ok := lookupEnv("SQLITESTORE_DRIVER", "sqlitestore_driver"); ok {
+		sqliteStoreDriver = value
+	}
+	if value, ok := lookupEnv("SQLITESTORE_LOCAL_PATH", "sqlitestore_local_path"); ok {
+		sqliteStoreLocalPath = value
+	}
 
 	// Check for cloud deploy mode only on first execution
 	// Read env var name in uppercase: DEPLOY
@@ -234,7 +265,7 @@ func main() {
 	}
 
 	// Determine and load the configuration file.
-	// Prefer the Postgres store when configured, otherwise fallback to git or local files.
+	// Prefer the Postgres store when configured, then SQLite, object storage, git or local files.
 	var configFilePath string
 	if usePostgresStore {
 		if pgStoreLocalPath == "" {
@@ -266,6 +297,42 @@ func main() {
 			cfg.AuthDir = pgStoreInst.AuthDir()
 			log.Infof("postgres-backed token store enabled, workspace path: %s", pgStoreInst.WorkDir())
 		}
+	} else if useSQLiteStore {
+		if sqliteStoreLocalPath == "" {
+			if writableBase != "" {
+				sqliteStoreLocalPath = writableBase
+			} else {
+				sqliteStoreLocalPath = wd
+			}
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		sqliteStoreInst, err = store.NewSQLiteStore(ctx, store.SQLiteStoreConfig{
+			Path:     sqliteStorePath,
+			Driver:   sqliteStoreDriver,
+			SpoolDir: filepath.Join(sqliteStoreLocalPath, "sqlitestore"),
+		})
+		cancel()
+		if err != nil {
+			log.Errorf("failed to initialize sqlite token store: %v", err)
+			return
+		}
+		examplePath := filepath.Join(wd, "config.example.yaml")
+		ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
+		if errBootstrap := sqliteStoreInst.Bootstrap(ctx, examplePath); errBootstrap != nil {
+			cancel()
+			log.Errorf("failed to bootstrap sqlite-backed config: %v", errBootstrap)
+			return
+		}
+		cancel()
+		configFilePath = sqliteStoreInst.ConfigPath()
+		cfg, err = config.LoadConfigOptional(configFilePath, isCloudDeploy)
+		if err == nil {
+			if cfg == nil {
+				cfg = &config.Config{}
+			}
+			cfg.AuthDir = sqliteStoreInst.AuthDir()
+			log.Infof("sqlite-backed token store enabled, database: %s", sqliteStorePath)
+		}
 	} else if useObjectStore {
 		if objectStoreLocalPath == "" {
 			if writableBase != "" {
@@ -418,7 +485,10 @@ func main() {
 		}
 	}
 	usage.SetStatisticsEnabled(cfg.UsageStatisticsEnabled)
+	usage.SetAnonymizationEnabled(cfg.PrivacyMode)
+	logging.SetPrivacyMode(cfg.PrivacyMode)
 	coreauth.SetQuotaCooldownDisabled(cfg.DisableCooling)
+	registry.GetGlobalRegistry().SetModelMetadata(cfg.ModelMetadata)
 
 	if err = logging.ConfigureLogOutput(cfg); err != nil {
 		log.Errorf("failed to configure log output: %v", err)
@@ -448,6 +518,8 @@ func main() {
 	// Register the shared token store once so all components use the same persistence backend.
 	if usePostgresStore {
 		sdkAuth.RegisterTokenStore(pgStoreInst)
+	} else if useSQLiteStore {
+		sdkAuth.RegisterTokenStore(sqliteStoreInst)
 	} else if useObjectStore {
 		sdkAuth.RegisterTokenStore(objectStoreInst)
 	} else if useGitStore {
@@ -464,6 +536,9 @@ func main() {
 	if vertexImport != "" {
 		// Handle Vertex service account import
 		cmd.DoVertexImport(cfg, vertexImport, vertexImportPrefix)
+	} else if geminiImport {
+		// Handle Gemini CLI credential import
+		cmd.DoGeminiImport(cfg, geminiImportFile, projectID, options)
 	} else if login {
 		// Handle Google/Gemini login
 		cmd.DoLogin(cfg, projectID, options)
@@ -0,0 +1,7 @@
+//go:build sqlitestore
+
+package main
+
+// The pure-Go SQLite driver backs the SQLITESTORE_* token store. It is opt-in because of the size
+// of its dependency tree.
+import _ "modernc.org/sqlite"
@@ -0,0 +1,52 @@
+// Command translator-plugin is an example translator plugin for a proprietary upstream format
+// that takes {"model","conversation"} requests and answers with {"reply"}.
+//
+// Build it against the same module version as the proxy and reference it from config.yaml:
+//
+//	go build -buildmode=plugin -o acme.so ./examples/translator-plugin
+//
+//	translators:
+//	  plugins:
+//	    - path: "./acme.so"
+//	      format: "acme"
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// RegisterTranslators registers OpenAI Chat Completions <-> format translators.
+func RegisterTranslators(format translator.Format, registry *translator.Registry) error {
+	registry.Register(translator.FormatOpenAI, format, requestToAcme, translator.ResponseTransform{
+		NonStream: responseFromAcme,
+	})
+	return nil
+}
+
+func requestToAcme(model string, rawJSON []byte, _ bool) []byte {
+	out := []byte(`{"model":"","conversation":[]}`)
+	out, _ = sjson.SetBytes(out, "model", model)
+	for _, message := range gjson.GetBytes(rawJSON, "messages").Array() {
+		turn := []byte(`{"speaker":"","text":""}`)
+		turn, _ = sjson.SetBytes(turn, "speaker", message.Get("role").String())
+		turn, _ = sjson.SetBytes(turn, "text", message.Get("content").String())
+		out, _ = sjson.SetRawBytes(out, "conversation.-1", turn)
+	}
+	return out
+}
+
+func responseFromAcme(_ context.Context, model string, _, _, rawJSON []byte, _ *any) []byte {
+	out := []byte(`{"id":"chatcmpl-acme","object":"chat.completion","created":0,"model":"","choices":[{"index":0,"message":{"role":"assistant","content":""},"finish_reason":"stop"}]}`)
+	out, _ = sjson.SetBytes(out, "created", time.Now().Unix())
+	out, _ = sjson.SetBytes(out, "model", model)
+	out, _ = sjson.SetBytes(out, "choices.0.message.content", gjson.GetBytes(rawJSON, "reply").String())
+	return out
+}
+
+// main is unused; Go plugins are built from a main package.
+func main() {}
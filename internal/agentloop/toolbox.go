@@ -0,0 +1,290 @@
+// Package agentloop executes tools on behalf of the model for the server-side agent loop.
+// Tools are configured under agent-loop.tools and are either plain HTTP endpoints or tools
+// hosted on an MCP server reachable over streamable HTTP.
+package agentloop
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/buildinfo"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// ToolTypeHTTP POSTs the call arguments to a URL and returns the response body.
+	ToolTypeHTTP = "http"
+	// ToolTypeMCP calls a tool on an MCP server over the streamable HTTP transport.
+	ToolTypeMCP = "mcp"
+
+	defaultMaxIterations = 8
+	defaultToolTimeout   = 60 * time.Second
+	mcpProtocolVersion   = "2025-03-26"
+	maxToolResultBytes   = 1 << 20
+)
+
+// errMCPSessionExpired reports that an MCP server no longer knows the session of a request.
+var errMCPSessionExpired = errors.New("mcp session expired")
+
+// Toolbox holds the tools the proxy can execute during an agent loop.
+type Toolbox struct {
+	tools   map[string]config.AgentTool
+	order   []string
+	timeout time.Duration
+	client  *http.Client
+
+	// sessions caches the session established with each MCP server, keyed by mcpSessionKey, so
+	// later calls of the same request skip the initialize handshake.
+	mu       sync.Mutex
+	sessions map[string]string
+}
+
+// NewToolbox builds a toolbox from configuration. Tools without a name, URL or a supported
+// type are skipped.
+func NewToolbox(cfg config.AgentLoopConfig) *Toolbox {
+	box := &Toolbox{
+		tools:    make(map[string]config.AgentTool),
+		timeout:  defaultToolTimeout,
+		client:   &http.Client{},
+		sessions: make(map[string]string),
+	}
+	if cfg.ToolTimeoutSeconds > 0 {
+		box.timeout = time.Duration(cfg.ToolTimeoutSeconds) * time.Second
+	}
+	for _, tool := range cfg.Tools {
+		tool.Name = strings.TrimSpace(tool.Name)
+		tool.Type = strings.ToLower(strings.TrimSpace(tool.Type))
+		if tool.Name == "" || strings.TrimSpace(tool.URL) == "" {
+			continue
+		}
+		if tool.Type != ToolTypeHTTP && tool.Type != ToolTypeMCP {
+			log.Warnf("agent loop: skipping tool %s with unsupported type %q", tool.Name, tool.Type)
+			continue
+		}
+		if _, exists := box.tools[tool.Name]; exists {
+			continue
+		}
+		box.tools[tool.Name] = tool
+		box.order = append(box.order, tool.Name)
+	}
+	return box
+}
+
+// MaxIterations returns the configured loop limit.
+func MaxIterations(cfg config.AgentLoopConfig) int {
+	if cfg.MaxIterations > 0 {
+		return cfg.MaxIterations
+	}
+	return defaultMaxIterations
+}
+
+// Names returns the tool names in configuration order.
+func (b *Toolbox) Names() []string {
+	return append([]string(nil), b.order...)
+}
+
+// Has reports whether the toolbox can execute name.
+func (b *Toolbox) Has(name string) bool {
+	_, ok := b.tools[name]
+	return ok
+}
+
+// Definition returns the Chat Completions function tool definition for name.
+func (b *Toolbox) Definition(name string) string {
+	tool := b.tools[name]
+	def, _ := sjson.Set(`{"type":"function","function":{}}`, "function.name", tool.Name)
+	if tool.Description != "" {
+		def, _ = sjson.Set(def, "function.description", tool.Description)
+	}
+	parameters := []byte(`{"type":"object","properties":{}}`)
+	if len(tool.Parameters) > 0 {
+		if encoded, errMarshal := json.Marshal(tool.Parameters); errMarshal == nil {
+			parameters = encoded
+		}
+	}
+	def, _ = sjson.SetRaw(def, "function.parameters", string(parameters))
+	return def
+}
+
+// Call executes tool name with the JSON-encoded arguments produced by the model.
+func (b *Toolbox) Call(ctx context.Context, name, arguments string) (string, error) {
+	tool, ok := b.tools[name]
+	if !ok {
+		return "", fmt.Errorf("unknown tool %s", name)
+	}
+	if strings.TrimSpace(arguments) == "" {
+		arguments = "{}"
+	}
+	if !gjson.Valid(arguments) {
+		return "", fmt.Errorf("tool %s arguments are not valid JSON", name)
+	}
+	ctx, cancel := context.WithTimeout(ctx, b.timeout)
+	defer cancel()
+
+	if tool.Type == ToolTypeMCP {
+		return b.callMCP(ctx, tool, arguments)
+	}
+	body, _, errPost := b.post(ctx, tool, "", []byte(arguments))
+	if errPost != nil {
+		return "", errPost
+	}
+	return string(body), nil
+}
+
+// post sends a JSON request to the tool URL and returns the response body and headers.
+func (b *Toolbox) post(ctx context.Context, tool config.AgentTool, sessionID string, payload []byte) ([]byte, http.Header, error) {
+	req, errReq := http.NewRequestWithContext(ctx, http.MethodPost, tool.URL, bytes.NewReader(payload))
+	if errReq != nil {
+		return nil, nil, errReq
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if tool.Type == ToolTypeMCP {
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		if sessionID != "" {
+			req.Header.Set("Mcp-Session-Id", sessionID)
+		}
+	}
+	for key, value := range tool.Headers {
+		req.Header.Set(key, value)
+	}
+	resp, errDo := b.client.Do(req)
+	if errDo != nil {
+		return nil, nil, fmt.Errorf("tool %s request failed: %w", tool.Name, errDo)
+	}
+	defer func() {
+		if errClose := resp.Body.Close(); errClose != nil {
+			log.Errorf("agent loop: tool %s response body close error: %v", tool.Name, errClose)
+		}
+	}()
+	body, errRead := io.ReadAll(io.LimitReader(resp.Body, maxToolResultBytes))
+	if errRead != nil {
+		return nil, nil, fmt.Errorf("tool %s response read failed: %w", tool.Name, errRead)
+	}
+	if resp.StatusCode == http.StatusNotFound && sessionID != "" {
+		return nil, nil, fmt.Errorf("tool %s: %w", tool.Name, errMCPSessionExpired)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, nil, fmt.Errorf("tool %s returned status %d: %s", tool.Name, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		body = lastSSEData(body)
+	}
+	return body, resp.Header, nil
+}
+
+// callMCP sends a tools/call request to an MCP server, reusing the session the toolbox holds for
+// the server and performing the initialize handshake only when there is none or it has expired.
+func (b *Toolbox) callMCP(ctx context.Context, tool config.AgentTool, arguments string) (string, error) {
+	remoteName := strings.TrimSpace(tool.RemoteName)
+	if remoteName == "" {
+		remoteName = tool.Name
+	}
+	call, _ := sjson.Set(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{}}`, "params.name", remoteName)
+	call, _ = sjson.SetRaw(call, "params.arguments", arguments)
+
+	key := mcpSessionKey(tool)
+	b.mu.Lock()
+	sessionID, hasSession := b.sessions[key]
+	b.mu.Unlock()
+	if !hasSession {
+		var errInit error
+		if sessionID, errInit = b.initializeMCP(ctx, tool); errInit != nil {
+			return "", errInit
+		}
+		b.storeSession(key, sessionID)
+	}
+	result, _, errCall := b.post(ctx, tool, sessionID, []byte(call))
+	if hasSession && errors.Is(errCall, errMCPSessionExpired) {
+		var errInit error
+		if sessionID, errInit = b.initializeMCP(ctx, tool); errInit != nil {
+			return "", errInit
+		}
+		b.storeSession(key, sessionID)
+		result, _, errCall = b.post(ctx, tool, sessionID, []byte(call))
+	}
+	if errCall != nil {
+		return "", errCall
+	}
+	if errMsg := gjson.GetBytes(result, "error.message"); errMsg.Exists() {
+		return "", fmt.Errorf("tool %s failed: %s", tool.Name, errMsg.String())
+	}
+
+	var parts []string
+	for _, item := range gjson.GetBytes(result, "result.content").Array() {
+		if item.Get("type").String() == "text" {
+			parts = append(parts, item.Get("text").String())
+			continue
+		}
+		parts = append(parts, item.Raw)
+	}
+	text := strings.Join(parts, "\n")
+	if gjson.GetBytes(result, "result.isError").Bool() {
+		return "", fmt.Errorf("tool %s reported an error: %s", tool.Name, text)
+	}
+	return text, nil
+}
+
+// initializeMCP performs the initialize handshake with an MCP server and returns the session
+// it assigned, which is empty for servers that do not track sessions.
+func (b *Toolbox) initializeMCP(ctx context.Context, tool config.AgentTool) (string, error) {
+	initialize, _ := sjson.Set(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"capabilities":{},"clientInfo":{"name":"cli-proxy-api"}}}`, "params.protocolVersion", mcpProtocolVersion)
+	initialize, _ = sjson.Set(initialize, "params.clientInfo.version", buildinfo.Version)
+	initResult, headers, errInit := b.post(ctx, tool, "", []byte(initialize))
+	if errInit != nil {
+		return "", errInit
+	}
+	if errMsg := gjson.GetBytes(initResult, "error.message"); errMsg.Exists() {
+		return "", fmt.Errorf("tool %s initialize failed: %s", tool.Name, errMsg.String())
+	}
+	sessionID := headers.Get("Mcp-Session-Id")
+	if _, _, errNotify := b.post(ctx, tool, sessionID, []byte(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)); errNotify != nil {
+		return "", errNotify
+	}
+	return sessionID, nil
+}
+
+// storeSession records the session an MCP server assigned to this toolbox.
+func (b *Toolbox) storeSession(key, sessionID string) {
+	b.mu.Lock()
+	b.sessions[key] = sessionID
+	b.mu.Unlock()
+}
+
+// mcpSessionKey identifies an MCP server by its URL and the headers sent to it, so tools on
+// the same server with the same credentials share one session.
+func mcpSessionKey(tool config.AgentTool) string {
+	var b strings.Builder
+	b.WriteString(tool.URL)
+	for _, name := range slices.Sorted(maps.Keys(tool.Headers)) {
+		b.WriteString("\n" + name + ": " + tool.Headers[name])
+	}
+	return b.String()
+}
+
+// lastSSEData returns the payload of the last data line in an event stream body.
+func lastSSEData(body []byte) []byte {
+	var last []byte
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxToolResultBytes)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if data, ok := bytes.CutPrefix(line, []byte("data:")); ok {
+			last = append([]byte(nil), bytes.TrimSpace(data)...)
+		}
+	}
+	return last
+}
@@ -0,0 +1,138 @@
+package agentloop
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestToolboxCallHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("X-Token") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("results for " + gjson.GetBytes(body, "query").String()))
+	}))
+	defer server.Close()
+
+	box := NewToolbox(config.AgentLoopConfig{Tools: []config.AgentTool{
+		{Name: "websearch", Type: "HTTP", URL: server.URL, Headers: map[string]string{"X-Token": "secret"}},
+		{Name: "broken", Type: "grpc", URL: server.URL},
+	}})
+	if box.Has("broken") {
+		t.Fatal("tool with unsupported type should be skipped")
+	}
+	got, err := box.Call(context.Background(), "websearch", `{"query":"golang"}`)
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if got != "results for golang" {
+		t.Fatalf("result = %q", got)
+	}
+	if _, err = box.Call(context.Background(), "websearch", `{"query":`); err == nil {
+		t.Fatal("invalid arguments should fail")
+	}
+
+	def := box.Definition("websearch")
+	if gjson.Get(def, "function.name").String() != "websearch" || gjson.Get(def, "function.parameters.type").String() != "object" {
+		t.Fatalf("unexpected definition: %s", def)
+	}
+}
+
+func TestToolboxCallMCP(t *testing.T) {
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		method := gjson.GetBytes(body, "method").String()
+		methods = append(methods, method)
+		switch method {
+		case "initialize":
+			w.Header().Set("Mcp-Session-Id", "session-1")
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"protocolVersion":"2025-03-26"}}`))
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusAccepted)
+		case "tools/call":
+			if r.Header.Get("Mcp-Session-Id") != "session-1" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			name := gjson.GetBytes(body, "params.name").String()
+			path := gjson.GetBytes(body, "params.arguments.path").String()
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("event: message\ndata: {\"jsonrpc\":\"2.0\",\"id\":2,\"result\":{\"content\":[{\"type\":\"text\",\"text\":\"" + name + ":" + path + "\"}]}}\n\n"))
+		}
+	}))
+	defer server.Close()
+
+	box := NewToolbox(config.AgentLoopConfig{Tools: []config.AgentTool{
+		{Name: "read", Type: "mcp", URL: server.URL, RemoteName: "read_file"},
+	}})
+	got, err := box.Call(context.Background(), "read", `{"path":"a.txt"}`)
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if got != "read_file:a.txt" {
+		t.Fatalf("result = %q", got)
+	}
+	// A later call of the same request reuses the session instead of repeating the handshake.
+	if _, err = box.Call(context.Background(), "read", `{"path":"b.txt"}`); err != nil {
+		t.Fatalf("second Call: %v", err)
+	}
+	// Another request establishes its own session.
+	box = NewToolbox(config.AgentLoopConfig{Tools: []config.AgentTool{
+		{Name: "read", Type: "mcp", URL: server.URL, RemoteName: "read_file"},
+	}})
+	if _, err = box.Call(context.Background(), "read", `{"path":"c.txt"}`); err != nil {
+		t.Fatalf("third Call: %v", err)
+	}
+	if strings.Join(methods, ",") != "initialize,notifications/initialized,tools/call,tools/call,initialize,notifications/initialized,tools/call" {
+		t.Fatalf("unexpected MCP call sequence: %v", methods)
+	}
+}
+
+func TestToolboxCallMCPReinitializesExpiredSession(t *testing.T) {
+	var methods []string
+	session := "session-1"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		method := gjson.GetBytes(body, "method").String()
+		methods = append(methods, method)
+		switch method {
+		case "initialize":
+			w.Header().Set("Mcp-Session-Id", session)
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{}}`))
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusAccepted)
+		case "tools/call":
+			if r.Header.Get("Mcp-Session-Id") != session {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":2,"result":{"content":[{"type":"text","text":"ok"}]}}`))
+		}
+	}))
+	defer server.Close()
+
+	box := NewToolbox(config.AgentLoopConfig{Tools: []config.AgentTool{{Name: "ping", Type: "mcp", URL: server.URL}}})
+	if _, err := box.Call(context.Background(), "ping", `{}`); err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	session = "session-2"
+	got, err := box.Call(context.Background(), "ping", `{}`)
+	if err != nil || got != "ok" {
+		t.Fatalf("Call after expiry = %q, %v", got, err)
+	}
+	want := "initialize,notifications/initialized,tools/call,tools/call,initialize,notifications/initialized,tools/call"
+	if strings.Join(methods, ",") != want {
+		t.Fatalf("unexpected MCP call sequence: %v", methods)
+	}
+}
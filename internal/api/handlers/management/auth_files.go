@@ -412,6 +412,12 @@ func (h *Handler) buildAuthFileEntry(auth *coreauth.Auth) gin.H {
 	if !auth.NextRetryAfter.IsZero() {
 		entry["next_retry_after"] = auth.NextRetryAfter
 	}
+	if auth.IsQuarantined() {
+		entry["quarantined"] = true
+		if at, ok := auth.Metadata[coreauth.QuarantinedAtMetadataKey].(string); ok {
+			entry["quarantined_at"] = at
+		}
+	}
 	if path != "" {
 		entry["path"] = path
 		entry["source"] = "file"
@@ -1107,6 +1113,7 @@ func (h *Handler) PatchAuthFileStatus(c *gin.Context) {
 	} else {
 		targetAuth.Status = coreauth.StatusActive
 		targetAuth.StatusMessage = ""
+		targetAuth.ClearQuarantine()
 	}
 	targetAuth.UpdatedAt = time.Now()
 
@@ -0,0 +1,48 @@
+package management
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// GetCircuitBreakers returns the circuit breakers tracked per credential and per provider.
+// Optional query parameter: state (closed, open or half-open).
+func (h *Handler) GetCircuitBreakers(c *gin.Context) {
+	enabled := h.cfg != nil && h.cfg.CircuitBreaker.Enable
+	if h.authManager == nil {
+		c.JSON(http.StatusOK, gin.H{"enabled": enabled, "breakers": []coreauth.BreakerStatus{}})
+		return
+	}
+	state := coreauth.BreakerState(strings.ToLower(strings.TrimSpace(c.Query("state"))))
+	breakers := make([]coreauth.BreakerStatus, 0)
+	for _, status := range h.authManager.CircuitBreakerStatuses() {
+		if state != "" && status.State != state {
+			continue
+		}
+		breakers = append(breakers, status)
+	}
+	c.JSON(http.StatusOK, gin.H{"enabled": enabled, "breakers": breakers})
+}
+
+// DeleteCircuitBreaker closes one circuit breaker so its credential or provider rejoins rotation.
+// Query parameters: scope (auth or provider) and key (auth ID or provider name).
+func (h *Handler) DeleteCircuitBreaker(c *gin.Context) {
+	scope := strings.ToLower(strings.TrimSpace(c.Query("scope")))
+	key := strings.TrimSpace(c.Query("key"))
+	if scope != coreauth.BreakerScopeAuth && scope != coreauth.BreakerScopeProvider {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "scope must be auth or provider"})
+		return
+	}
+	if key == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing key"})
+		return
+	}
+	if h.authManager == nil || !h.authManager.ResetCircuitBreaker(scope, key) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "circuit breaker not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
@@ -0,0 +1,26 @@
+package management
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// GetConcurrency returns the in-flight and queued upstream requests of each credential that
+// has been subject to the concurrency limit.
+func (h *Handler) GetConcurrency(c *gin.Context) {
+	maxInFlight := 0
+	if h.cfg != nil {
+		maxInFlight = h.cfg.ConcurrencyLimit.MaxInFlight
+	}
+	limiters := make([]coreauth.ConcurrencyStatus, 0)
+	if h.authManager != nil {
+		limiters = append(limiters, h.authManager.ConcurrencyStatuses()...)
+	}
+	queued := 0
+	for _, status := range limiters {
+		queued += status.Queued
+	}
+	c.JSON(http.StatusOK, gin.H{"max-in-flight": maxInFlight, "queued": queued, "credentials": limiters})
+}
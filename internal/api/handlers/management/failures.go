@@ -0,0 +1,63 @@
+package management
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/diagnostics"
+)
+
+// GetFailures returns the most recent failed requests, newest first.
+// Optional query parameters: limit (maximum entries), provider, model and status (exact matches).
+func (h *Handler) GetFailures(c *gin.Context) {
+	limit := 0
+	if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+		parsed, errAtoi := strconv.Atoi(raw)
+		if errAtoi != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+		limit = parsed
+	}
+	provider := strings.ToLower(strings.TrimSpace(c.Query("provider")))
+	model := strings.TrimSpace(c.Query("model"))
+	status := 0
+	if raw := strings.TrimSpace(c.Query("status")); raw != "" {
+		parsed, errAtoi := strconv.Atoi(raw)
+		if errAtoi != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status"})
+			return
+		}
+		status = parsed
+	}
+
+	store := diagnostics.Default()
+	failures := make([]diagnostics.Failure, 0)
+	for _, failure := range store.List(0) {
+		if provider != "" && failure.Provider != provider {
+			continue
+		}
+		if model != "" && failure.Model != model {
+			continue
+		}
+		if status != 0 && failure.StatusCode != status {
+			continue
+		}
+		failures = append(failures, failure)
+		if limit > 0 && len(failures) >= limit {
+			break
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":  store.Enabled(),
+		"failures": failures,
+	})
+}
+
+// DeleteFailures clears the failure diagnostics store.
+func (h *Handler) DeleteFailures(c *gin.Context) {
+	diagnostics.Default().Clear()
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
@@ -0,0 +1,36 @@
+package management
+
+import (
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/lifecycle"
+)
+
+// defaultLeakGrace is how long a tracked goroutine may keep running after its request
+// context was cancelled before it is reported as a leak.
+const defaultLeakGrace = 30 * time.Second
+
+// GetGoroutines returns the tracked stream goroutines and those that outlived their request.
+// Optional query parameter: grace_seconds (time allowed after cancellation, default 30).
+func (h *Handler) GetGoroutines(c *gin.Context) {
+	grace := defaultLeakGrace
+	if raw := strings.TrimSpace(c.Query("grace_seconds")); raw != "" {
+		parsed, errAtoi := strconv.Atoi(raw)
+		if errAtoi != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid grace_seconds"})
+			return
+		}
+		grace = time.Duration(parsed) * time.Second
+	}
+	tracker := lifecycle.Default()
+	c.JSON(http.StatusOK, gin.H{
+		"goroutines": runtime.NumGoroutine(),
+		"tracked":    tracker.Snapshot(),
+		"leaks":      tracker.Leaks(grace),
+	})
+}
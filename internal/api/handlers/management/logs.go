@@ -2,6 +2,7 @@ package management
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"math"
 	"net/http"
@@ -302,23 +303,77 @@ func (h *Handler) GetRequestLogByID(c *gin.Context) {
 	if requestID == "" {
 		requestID = strings.TrimSpace(c.Query("id"))
 	}
-	if requestID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing request ID"})
+	fullPath, matchedFile, status, errResolve := resolveRequestLogByID(dir, requestID)
+	if errResolve != nil {
+		c.JSON(status, gin.H{"error": errResolve.Error()})
 		return
 	}
-	if strings.ContainsAny(requestID, "/\\") {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request ID"})
+
+	c.FileAttachment(fullPath, matchedFile)
+}
+
+// GetRequestTranscript assembles the request log for a request ID into a single transcript
+// (inbound request, translated upstream requests, streamed chunks and final usage).
+// The format query parameter selects "json" (default) or "markdown".
+func (h *Handler) GetRequestTranscript(c *gin.Context) {
+	if h == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "handler unavailable"})
+		return
+	}
+	if h.cfg == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "configuration unavailable"})
+		return
+	}
+
+	dir := h.logDirectory()
+	if strings.TrimSpace(dir) == "" {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "log directory not configured"})
+		return
+	}
+
+	format := strings.ToLower(strings.TrimSpace(c.DefaultQuery("format", "json")))
+	if format != "json" && format != "markdown" && format != "md" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or markdown"})
+		return
+	}
+
+	requestID := strings.TrimSpace(c.Param("id"))
+	fullPath, _, status, errResolve := resolveRequestLogByID(dir, requestID)
+	if errResolve != nil {
+		c.JSON(status, gin.H{"error": errResolve.Error()})
+		return
+	}
+	content, errRead := os.ReadFile(fullPath)
+	if errRead != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to read log content: %v", errRead)})
 		return
 	}
 
+	transcript := logging.ParseRequestTranscript(requestID, content)
+	if format == "json" {
+		c.JSON(http.StatusOK, transcript)
+		return
+	}
+	c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(transcript.Markdown()))
+}
+
+// resolveRequestLogByID locates the request log file for requestID inside dir.
+// The ID is matched against the suffix of log file names (format: *-{requestID}.log).
+// On failure it returns the HTTP status to report along with the error.
+func resolveRequestLogByID(dir, requestID string) (string, string, int, error) {
+	if requestID == "" {
+		return "", "", http.StatusBadRequest, errors.New("missing request ID")
+	}
+	if strings.ContainsAny(requestID, "/\\") {
+		return "", "", http.StatusBadRequest, errors.New("invalid request ID")
+	}
+
 	entries, err := os.ReadDir(dir)
 	if err != nil {
 		if os.IsNotExist(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "log directory not found"})
-			return
+			return "", "", http.StatusNotFound, errors.New("log directory not found")
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to list log directory: %v", err)})
-		return
+		return "", "", http.StatusInternalServerError, fmt.Errorf("failed to list log directory: %v", err)
 	}
 
 	suffix := "-" + requestID + ".log"
@@ -335,37 +390,31 @@ func (h *Handler) GetRequestLogByID(c *gin.Context) {
 	}
 
 	if matchedFile == "" {
-		c.JSON(http.StatusNotFound, gin.H{"error": "log file not found for the given request ID"})
-		return
+		return "", "", http.StatusNotFound, errors.New("log file not found for the given request ID")
 	}
 
 	dirAbs, errAbs := filepath.Abs(dir)
 	if errAbs != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to resolve log directory: %v", errAbs)})
-		return
+		return "", "", http.StatusInternalServerError, fmt.Errorf("failed to resolve log directory: %v", errAbs)
 	}
 	fullPath := filepath.Clean(filepath.Join(dirAbs, matchedFile))
 	prefix := dirAbs + string(os.PathSeparator)
 	if !strings.HasPrefix(fullPath, prefix) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid log file path"})
-		return
+		return "", "", http.StatusBadRequest, errors.New("invalid log file path")
 	}
 
 	info, errStat := os.Stat(fullPath)
 	if errStat != nil {
 		if os.IsNotExist(errStat) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "log file not found"})
-			return
+			return "", "", http.StatusNotFound, errors.New("log file not found")
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to read log file: %v", errStat)})
-		return
+		return "", "", http.StatusInternalServerError, fmt.Errorf("failed to read log file: %v", errStat)
 	}
 	if info.IsDir() {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid log file"})
-		return
+		return "", "", http.StatusBadRequest, errors.New("invalid log file")
 	}
 
-	c.FileAttachment(fullPath, matchedFile)
+	return fullPath, matchedFile, http.StatusOK, nil
 }
 
 // DownloadRequestErrorLog downloads a specific error request log file by name.
@@ -0,0 +1,137 @@
+package management
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/replay"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+)
+
+// replayJobs holds the differential replay jobs started through the management API.
+var replayJobs = replay.NewManager()
+
+type replayComparisonRequest struct {
+	RequestIDs  []string        `json:"request_ids"`
+	Targets     []replay.Target `json:"targets"`
+	Concurrency int             `json:"concurrency"`
+}
+
+// PostReplayComparison starts a job that replays captured requests (by request log ID)
+// against two targets and compares latency, tokens, response text and tool calls.
+// Requests are replayed non-streaming; request logging must have captured them.
+func (h *Handler) PostReplayComparison(c *gin.Context) {
+	if h == nil || h.cfg == nil || h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "replay unavailable"})
+		return
+	}
+	var body replayComparisonRequest
+	if errBind := c.ShouldBindJSON(&body); errBind != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+		return
+	}
+	if len(body.Targets) != 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "exactly two targets are required"})
+		return
+	}
+	for i := range body.Targets {
+		body.Targets[i].Model = strings.TrimSpace(body.Targets[i].Model)
+		body.Targets[i].AuthID = strings.TrimSpace(body.Targets[i].AuthID)
+		if body.Targets[i].Model == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "target model is required"})
+			return
+		}
+	}
+	ids := make([]string, 0, len(body.RequestIDs))
+	seen := make(map[string]struct{}, len(body.RequestIDs))
+	for _, id := range body.RequestIDs {
+		id = strings.TrimSpace(id)
+		if _, dup := seen[id]; id == "" || dup {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "request_ids is required"})
+		return
+	}
+	if len(ids) > replay.MaxRequestsPerJob {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "too many request_ids"})
+		return
+	}
+
+	dir := h.logDirectory()
+	if strings.TrimSpace(dir) == "" {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "log directory not configured"})
+		return
+	}
+	requests := make([]replay.CapturedRequest, 0, len(ids))
+	loadErrors := make(map[string]string)
+	for _, id := range ids {
+		fullPath, _, _, errResolve := resolveRequestLogByID(dir, id)
+		if errResolve != nil {
+			loadErrors[id] = errResolve.Error()
+			continue
+		}
+		content, errRead := os.ReadFile(fullPath)
+		if errRead != nil {
+			loadErrors[id] = "failed to read log content"
+			continue
+		}
+		req, errLoad := replay.LoadCapturedRequest(id, content)
+		if errLoad != nil {
+			loadErrors[id] = errLoad.Error()
+			continue
+		}
+		requests = append(requests, req)
+	}
+
+	jobID := replayJobs.Start(requests, loadErrors, [2]replay.Target{body.Targets[0], body.Targets[1]}, body.Concurrency, h.replayExecutor())
+	c.JSON(http.StatusAccepted, gin.H{"id": jobID, "total": len(ids)})
+}
+
+// ListReplayComparisons returns all retained replay comparison jobs without per-request details.
+func (h *Handler) ListReplayComparisons(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"jobs": replayJobs.List()})
+}
+
+// GetReplayComparison returns a replay comparison job with its per-request report.
+func (h *Handler) GetReplayComparison(c *gin.Context) {
+	job, ok := replayJobs.Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// DeleteReplayComparison cancels a running replay comparison job and discards its results.
+func (h *Handler) DeleteReplayComparison(c *gin.Context) {
+	if !replayJobs.Delete(c.Param("id")) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// replayExecutor executes replayed requests through the regular routing and translation
+// pipeline, pinning the target credential when one is given.
+func (h *Handler) replayExecutor() replay.ExecuteFunc {
+	base := handlers.NewBaseAPIHandlers(&h.cfg.SDKConfig, h.authManager)
+	return func(ctx context.Context, format, model, authID string, body []byte) ([]byte, int, error) {
+		ctx = handlers.WithPinnedAuthID(ctx, authID)
+		resp, _, errMsg := base.ExecuteWithAuthManager(ctx, format, model, body, "")
+		if errMsg != nil {
+			status := errMsg.StatusCode
+			if status == 0 {
+				status = http.StatusInternalServerError
+			}
+			return nil, status, errMsg.Error
+		}
+		return resp, http.StatusOK, nil
+	}
+}
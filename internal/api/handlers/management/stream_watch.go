@@ -0,0 +1,82 @@
+package management
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/streamwatch"
+	log "github.com/sirupsen/logrus"
+)
+
+// streamWatchWriteTimeout bounds a single websocket write to a watcher.
+const streamWatchWriteTimeout = 10 * time.Second
+
+// streamWatchUpgrader keeps the default same-origin check so browser pages on other origins
+// cannot attach to request content.
+var streamWatchUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+}
+
+// WatchRequestStream upgrades to a websocket and streams the upstream and downstream chunks
+// of one in-flight request as JSON events, redacted according to stream-watch settings.
+// The request does not need to have started yet; the feed ends with a done event when it
+// finishes, or when the client closes the socket.
+func (h *Handler) WatchRequestStream(c *gin.Context) {
+	requestID := strings.TrimSpace(c.Param("id"))
+	if requestID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "request id is required"})
+		return
+	}
+	conn, errUpgrade := streamWatchUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if errUpgrade != nil {
+		return
+	}
+	defer func() {
+		if errClose := conn.Close(); errClose != nil {
+			log.Debugf("stream watch: close websocket: %v", errClose)
+		}
+	}()
+
+	sub := streamwatch.Default().Subscribe(requestID)
+	defer sub.Close()
+
+	// Watchers only listen; reading detects when the client goes away.
+	clientGone := make(chan struct{})
+	go func() {
+		defer close(clientGone)
+		for {
+			if _, _, errRead := conn.ReadMessage(); errRead != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-clientGone:
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				writeStreamWatchClose(conn)
+				return
+			}
+			_ = conn.SetWriteDeadline(time.Now().Add(streamWatchWriteTimeout))
+			if errWrite := conn.WriteJSON(event); errWrite != nil {
+				return
+			}
+			if event.Done {
+				writeStreamWatchClose(conn)
+				return
+			}
+		}
+	}
+}
+
+func writeStreamWatchClose(conn *websocket.Conn) {
+	message := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "request finished")
+	_ = conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(time.Second))
+}
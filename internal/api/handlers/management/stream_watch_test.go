@@ -0,0 +1,64 @@
+package management
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/streamwatch"
+)
+
+func TestWatchRequestStreamForwardsChunks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/request-stream/:id", (&Handler{}).WatchRequestStream)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	conn, _, errDial := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/request-stream/watch-req", nil)
+	if errDial != nil {
+		t.Fatalf("dial: %v", errDial)
+	}
+	defer func() { _ = conn.Close() }()
+
+	// The subscription is registered after the upgrade; publish until the watcher sees a chunk.
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	received := make(chan streamwatch.Event, 1)
+	go func() {
+		var event streamwatch.Event
+		if errRead := conn.ReadJSON(&event); errRead == nil {
+			received <- event
+		}
+	}()
+	var first streamwatch.Event
+	for waiting := true; waiting; {
+		streamwatch.Publish("watch-req", streamwatch.Downstream, []byte("data: {}"))
+		select {
+		case first = <-received:
+			waiting = false
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	if first.Direction != streamwatch.Downstream || first.Data != "data: {}" {
+		t.Fatalf("first event = %+v", first)
+	}
+
+	streamwatch.Finish("watch-req")
+	sawDone := false
+	for {
+		var event streamwatch.Event
+		if errRead := conn.ReadJSON(&event); errRead != nil {
+			if !websocket.IsCloseError(errRead, websocket.CloseNormalClosure) {
+				t.Fatalf("expected normal close, got %v", errRead)
+			}
+			break
+		}
+		sawDone = sawDone || event.Done
+	}
+	if !sawDone {
+		t.Fatal("expected a done event before the socket closed")
+	}
+}
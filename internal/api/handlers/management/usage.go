@@ -3,9 +3,11 @@ package management
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 )
 
@@ -18,10 +20,11 @@ func (h *Handler) GetUsageStatistics(c *gin.Context) {
 
 	// Transform the internal snapshot to the required external response format
 	response := gin.H{
-		"total_requests": snapshot.TotalRequests,
-		"total_tokens":   snapshot.TotalTokens,
-		"success_count":  snapshot.SuccessCount,
-		"failure_count":  snapshot.FailureCount,
+		"total_requests":    snapshot.TotalRequests,
+		"total_tokens":      snapshot.TotalTokens,
+		"success_count":     snapshot.SuccessCount,
+		"failure_count":     snapshot.FailureCount,
+		"failures_by_class": nonNilCounts(snapshot.FailuresByClass),
 	}
 
 	apis := make(map[string]interface{})
@@ -31,11 +34,12 @@ func (h *Handler) GetUsageStatistics(c *gin.Context) {
 			details := make([]gin.H, 0, len(modelSnap.Details))
 			for _, detail := range modelSnap.Details {
 				details = append(details, gin.H{
-					"timestamp":  detail.Timestamp.UTC().Format("2006-01-02T15:04:05.000Z"),
-					"source":     detail.Source,
-					"auth_index": detail.AuthIndex,
-					"tokens":     detail.Tokens,
-					"failed":     detail.Failed,
+					"timestamp":     detail.Timestamp.UTC().Format("2006-01-02T15:04:05.000Z"),
+					"source":        detail.Source,
+					"auth_index":    detail.AuthIndex,
+					"tokens":        detail.Tokens,
+					"failed":        detail.Failed,
+					"failure_class": detail.FailureClass,
 				})
 			}
 			models[modelName] = gin.H{
@@ -45,9 +49,10 @@ func (h *Handler) GetUsageStatistics(c *gin.Context) {
 			}
 		}
 		apis[apiName] = gin.H{
-			"total_requests": apiSnap.TotalRequests,
-			"total_tokens":   apiSnap.TotalTokens,
-			"models":         models,
+			"total_requests":    apiSnap.TotalRequests,
+			"total_tokens":      apiSnap.TotalTokens,
+			"failures_by_class": nonNilCounts(apiSnap.FailuresByClass),
+			"models":            models,
 		}
 	}
 	response["apis"] = apis
@@ -55,6 +60,85 @@ func (h *Handler) GetUsageStatistics(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// nonNilCounts returns counts, or an empty map so the JSON field is always an object.
+func nonNilCounts(counts map[string]int64) map[string]int64 {
+	if counts == nil {
+		return map[string]int64{}
+	}
+	return counts
+}
+
+// GetUsageTimeSeries returns request counts and token totals bucketed by interval.
+// Query parameters: interval (5m, 1h or 1d; default 1h), start and end (RFC 3339),
+// and optional api, model, source and auth_index filters (exact matches).
+func (h *Handler) GetUsageTimeSeries(c *gin.Context) {
+	filter := usage.TimeSeriesFilter{
+		Interval:  strings.TrimSpace(c.DefaultQuery("interval", "1h")),
+		APIKey:    strings.TrimSpace(c.Query("api")),
+		Model:     strings.TrimSpace(c.Query("model")),
+		Source:    strings.TrimSpace(c.Query("source")),
+		AuthIndex: strings.TrimSpace(c.Query("auth_index")),
+	}
+	for _, param := range []struct {
+		name   string
+		target *time.Time
+	}{{"start", &filter.Start}, {"end", &filter.End}} {
+		raw := strings.TrimSpace(c.Query(param.name))
+		if raw == "" {
+			continue
+		}
+		parsed, errParse := time.Parse(time.RFC3339, raw)
+		if errParse != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + param.name})
+			return
+		}
+		*param.target = parsed
+	}
+
+	var stats *usage.RequestStatistics
+	if h != nil {
+		stats = h.usageStats
+	}
+	series, err := stats.TimeSeries(filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, series)
+}
+
+// GetUsageCost prices the retained successful requests per API key and model using the
+// model-metadata pricing, and reports how much prompt caching saved compared to billing the
+// cached input at the regular input price. Query parameters: start and end (RFC 3339) and
+// optional api and model filters (exact matches).
+func (h *Handler) GetUsageCost(c *gin.Context) {
+	filter := usage.CostFilter{
+		APIKey: strings.TrimSpace(c.Query("api")),
+		Model:  strings.TrimSpace(c.Query("model")),
+	}
+	for _, param := range []struct {
+		name   string
+		target *time.Time
+	}{{"start", &filter.Start}, {"end", &filter.End}} {
+		raw := strings.TrimSpace(c.Query(param.name))
+		if raw == "" {
+			continue
+		}
+		parsed, errParse := time.Parse(time.RFC3339, raw)
+		if errParse != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + param.name})
+			return
+		}
+		*param.target = parsed
+	}
+
+	var stats *usage.RequestStatistics
+	if h != nil {
+		stats = h.usageStats
+	}
+	c.JSON(http.StatusOK, stats.CostReport(filter, registry.GetGlobalRegistry().ModelPricing))
+}
+
 // ExportUsageStatistics returns a complete usage snapshot for backup/migration.
 func (h *Handler) ExportUsageStatistics(c *gin.Context) {
 	var snapshot usage.StatisticsSnapshot
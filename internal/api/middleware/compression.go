@@ -0,0 +1,261 @@
+// Package middleware provides HTTP middleware components for the CLI Proxy API server.
+// This file contains the compression middleware that decompresses gzip/deflate request
+// bodies and compresses responses according to the client's Accept-Encoding header.
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+const defaultMaxDecompressedRequestBytes int64 = 32 << 20 // 32 MiB
+
+var errDecompressedBodyTooLarge = errors.New("decompressed request body exceeds the size limit")
+
+// CompressionMiddleware creates a Gin middleware that transparently decompresses request bodies
+// sent with Content-Encoding gzip or deflate, and compresses responses with gzip or deflate when
+// enabled and accepted by the client. The settings are read per request so configuration
+// reloads take effect immediately.
+func CompressionMiddleware(settings func() config.CompressionConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var cfg config.CompressionConfig
+		if settings != nil {
+			cfg = settings()
+		}
+
+		if status, err := decompressRequestBody(c.Request, maxDecompressedRequestBytes(cfg)); err != nil {
+			c.AbortWithStatusJSON(status, gin.H{
+				"error": gin.H{
+					"message": err.Error(),
+					"type":    "invalid_request_error",
+				},
+			})
+			return
+		}
+
+		if !cfg.Enable || !shouldCompressResponse(c.Request) {
+			c.Next()
+			return
+		}
+		encoding := negotiateResponseEncoding(c.GetHeader("Accept-Encoding"))
+		if encoding == "" {
+			c.Next()
+			return
+		}
+
+		writer := &compressResponseWriter{
+			ResponseWriter: c.Writer,
+			encoding:       encoding,
+			streaming:      cfg.Streaming,
+		}
+		c.Writer = writer
+		defer writer.close()
+		c.Next()
+	}
+}
+
+func maxDecompressedRequestBytes(cfg config.CompressionConfig) int64 {
+	if cfg.MaxRequestBodyMB > 0 {
+		return int64(cfg.MaxRequestBodyMB) << 20
+	}
+	return defaultMaxDecompressedRequestBytes
+}
+
+// decompressRequestBody replaces a compressed request body with its decoded content.
+// It returns the HTTP status to reply with when the body cannot be accepted.
+func decompressRequestBody(req *http.Request, limit int64) (int, error) {
+	if req == nil || req.Body == nil {
+		return 0, nil
+	}
+	encoding := strings.ToLower(strings.TrimSpace(req.Header.Get("Content-Encoding")))
+	if encoding == "" || encoding == "identity" {
+		return 0, nil
+	}
+
+	var reader io.Reader
+	switch encoding {
+	case "gzip", "x-gzip":
+		gz, errGzip := gzip.NewReader(req.Body)
+		if errGzip != nil {
+			return http.StatusBadRequest, fmt.Errorf("invalid gzip request body: %w", errGzip)
+		}
+		defer func() { _ = gz.Close() }()
+		reader = gz
+	case "deflate":
+		zr, errDeflate := newDeflateReader(req.Body)
+		if errDeflate != nil {
+			return http.StatusBadRequest, fmt.Errorf("invalid deflate request body: %w", errDeflate)
+		}
+		defer func() { _ = zr.Close() }()
+		reader = zr
+	default:
+		return http.StatusUnsupportedMediaType, fmt.Errorf("unsupported request Content-Encoding %q", encoding)
+	}
+
+	body, errRead := io.ReadAll(io.LimitReader(reader, limit+1))
+	if errRead != nil {
+		return http.StatusBadRequest, fmt.Errorf("failed to decompress request body: %w", errRead)
+	}
+	if int64(len(body)) > limit {
+		return http.StatusRequestEntityTooLarge, errDecompressedBodyTooLarge
+	}
+	_ = req.Body.Close()
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.Header.Del("Content-Encoding")
+	req.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return 0, nil
+}
+
+// newDeflateReader accepts both zlib-wrapped deflate (as specified by HTTP) and the raw
+// deflate streams some clients send instead.
+func newDeflateReader(body io.Reader) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(body)
+	header, errPeek := buffered.Peek(2)
+	if errPeek != nil {
+		return nil, errPeek
+	}
+	if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(buffered)
+	}
+	return flate.NewReader(buffered), nil
+}
+
+func shouldCompressResponse(req *http.Request) bool {
+	if req == nil || req.Method == http.MethodHead {
+		return false
+	}
+	// Websocket upgrades hijack the connection and must not be wrapped.
+	return req.Header.Get("Upgrade") == ""
+}
+
+// negotiateResponseEncoding picks gzip or deflate from an Accept-Encoding header, preferring
+// gzip when both are acceptable. It returns an empty string when neither is accepted.
+func negotiateResponseEncoding(acceptEncoding string) string {
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.EqualFold(strings.TrimSpace(key), "q") {
+				if parsed, errParse := strconv.ParseFloat(strings.TrimSpace(value), 64); errParse == nil {
+					quality = parsed
+				}
+			}
+		}
+		accepted[name] = quality > 0
+	}
+	for _, encoding := range []string{"gzip", "deflate"} {
+		if enabled, ok := accepted[encoding]; ok {
+			if enabled {
+				return encoding
+			}
+			continue
+		}
+		if accepted["*"] {
+			return encoding
+		}
+	}
+	return ""
+}
+
+// compressingWriter is implemented by both gzip.Writer and flate.Writer.
+type compressingWriter interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// compressResponseWriter compresses the response body once the handler has committed to a
+// response. The decision is deferred until the first write or flush so that the handler's
+// status code and Content-Type are known.
+type compressResponseWriter struct {
+	gin.ResponseWriter
+	encoding  string
+	streaming bool
+	decided   bool
+	writer    compressingWriter
+}
+
+func (w *compressResponseWriter) decide() {
+	if w.decided {
+		return
+	}
+	w.decided = true
+
+	status := w.ResponseWriter.Status()
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		return
+	}
+	header := w.ResponseWriter.Header()
+	if header.Get("Content-Encoding") != "" {
+		return
+	}
+	contentType := strings.ToLower(header.Get("Content-Type"))
+	if strings.HasPrefix(contentType, "text/event-stream") && !w.streaming {
+		return
+	}
+	for _, prefix := range []string{"image/", "audio/", "video/", "application/zip", "application/gzip"} {
+		if strings.HasPrefix(contentType, prefix) {
+			return
+		}
+	}
+
+	header.Del("Content-Length")
+	header.Set("Content-Encoding", w.encoding)
+	header.Add("Vary", "Accept-Encoding")
+	if w.encoding == "gzip" {
+		w.writer = gzip.NewWriter(w.ResponseWriter)
+		return
+	}
+	w.writer, _ = flate.NewWriter(w.ResponseWriter, flate.DefaultCompression)
+}
+
+// Write compresses data when compression applies, otherwise writes it unchanged.
+func (w *compressResponseWriter) Write(data []byte) (int, error) {
+	w.decide()
+	if w.writer == nil {
+		return w.ResponseWriter.Write(data)
+	}
+	w.ResponseWriter.WriteHeaderNow()
+	return w.writer.Write(data)
+}
+
+// WriteString compresses s when compression applies, otherwise writes it unchanged.
+func (w *compressResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush pushes any buffered compressed data to the client, which keeps SSE events timely.
+func (w *compressResponseWriter) Flush() {
+	w.decide()
+	if w.writer != nil {
+		_ = w.writer.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *compressResponseWriter) close() {
+	if w.writer == nil {
+		return
+	}
+	_ = w.writer.Close()
+	w.writer = nil
+}
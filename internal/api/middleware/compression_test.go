@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func newCompressionRouter(cfg config.CompressionConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(CompressionMiddleware(func() config.CompressionConfig { return cfg }))
+	router.POST("/echo", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.Data(http.StatusOK, "application/json", body)
+	})
+	router.GET("/stream", func(c *gin.Context) {
+		c.Header("Content-Type", "text/event-stream")
+		_, _ = c.Writer.Write([]byte("data: hello\n\n"))
+		c.Writer.Flush()
+	})
+	return router
+}
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		t.Fatalf("gzip write: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("gzip close: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestCompressionMiddlewareDecompressesRequests(t *testing.T) {
+	router := newCompressionRouter(config.CompressionConfig{})
+	payload := []byte(`{"model":"gpt-5"}`)
+
+	req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewReader(gzipBytes(t, payload)))
+	req.Header.Set("Content-Encoding", "gzip")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != http.StatusOK || resp.Body.String() != string(payload) {
+		t.Fatalf("gzip: status = %d, body = %q", resp.Code, resp.Body.String())
+	}
+	if resp.Header().Get("Content-Encoding") != "" {
+		t.Fatal("response should not be compressed when compression is disabled")
+	}
+
+	var deflated bytes.Buffer
+	zw := zlib.NewWriter(&deflated)
+	_, _ = zw.Write(payload)
+	_ = zw.Close()
+	req = httptest.NewRequest(http.MethodPost, "/echo", &deflated)
+	req.Header.Set("Content-Encoding", "deflate")
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != http.StatusOK || resp.Body.String() != string(payload) {
+		t.Fatalf("deflate: status = %d, body = %q", resp.Code, resp.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader("x"))
+	req.Header.Set("Content-Encoding", "br")
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("unsupported encoding status = %d, want 415", resp.Code)
+	}
+}
+
+func TestCompressionMiddlewareRejectsDecompressionBombs(t *testing.T) {
+	router := newCompressionRouter(config.CompressionConfig{MaxRequestBodyMB: 1})
+	bomb := gzipBytes(t, bytes.Repeat([]byte("a"), 2<<20))
+
+	req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewReader(bomb))
+	req.Header.Set("Content-Encoding", "gzip")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413", resp.Code)
+	}
+}
+
+func TestCompressionMiddlewareCompressesResponses(t *testing.T) {
+	router := newCompressionRouter(config.CompressionConfig{Enable: true})
+	payload := strings.Repeat(`{"content":"hello"}`, 50)
+
+	req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(payload))
+	req.Header.Set("Accept-Encoding", "br;q=1, gzip;q=0.8")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", resp.Header().Get("Content-Encoding"))
+	}
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatalf("gzip reader: %v", err)
+	}
+	decoded, _ := io.ReadAll(gz)
+	if string(decoded) != payload {
+		t.Fatalf("decoded body = %q", decoded)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/stream", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Header().Get("Content-Encoding") != "" || resp.Body.String() != "data: hello\n\n" {
+		t.Fatalf("SSE should stay uncompressed by default: %q", resp.Body.String())
+	}
+
+	router = newCompressionRouter(config.CompressionConfig{Enable: true, Streaming: true})
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("SSE Content-Encoding = %q, want gzip", resp.Header().Get("Content-Encoding"))
+	}
+}
+
+func TestNegotiateResponseEncoding(t *testing.T) {
+	cases := map[string]string{
+		"":                      "",
+		"gzip, deflate":         "gzip",
+		"deflate":               "deflate",
+		"gzip;q=0, deflate":     "deflate",
+		"*":                     "gzip",
+		"gzip;q=0, *":           "deflate",
+		"br, identity":          "",
+		"GZIP;Q=0.5":            "gzip",
+		"deflate;q=0, gzip;q=0": "",
+	}
+	for header, want := range cases {
+		if got := negotiateResponseEncoding(header); got != want {
+			t.Errorf("negotiateResponseEncoding(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
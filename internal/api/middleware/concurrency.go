@@ -0,0 +1,196 @@
+// Package middleware provides HTTP middleware components for the CLI Proxy API server.
+// This file contains the concurrency limiter that caps in-flight API requests globally
+// and per client API key.
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+const (
+	defaultConcurrencyQueueTimeout = 30 * time.Second
+	defaultConcurrencyRetryAfter   = 1
+)
+
+// ConcurrencyLimiter tracks in-flight requests and enforces the limits from ConcurrencyConfig.
+// It is safe for concurrent use; limits can be replaced at runtime with Update while requests
+// are in flight.
+type ConcurrencyLimiter struct {
+	mu           sync.Mutex
+	cfg          config.ConcurrencyConfig
+	perKeyLimits map[string]int
+	active       int
+	activeByKey  map[string]int
+	queued       int
+	// released is closed and replaced whenever a slot frees up or the limits change, waking queued requests.
+	released chan struct{}
+}
+
+// NewConcurrencyLimiter creates a limiter using the concurrency settings and per-key policy
+// overrides from cfg.
+func NewConcurrencyLimiter(cfg *config.Config) *ConcurrencyLimiter {
+	l := &ConcurrencyLimiter{
+		activeByKey: make(map[string]int),
+		released:    make(chan struct{}),
+	}
+	l.Update(cfg)
+	return l
+}
+
+// Update replaces the limits with those from cfg. In-flight requests keep their slots.
+func (l *ConcurrencyLimiter) Update(cfg *config.Config) {
+	perKey := make(map[string]int)
+	var settings config.ConcurrencyConfig
+	if cfg != nil {
+		settings = cfg.Concurrency
+		for _, policy := range cfg.APIKeyPolicies {
+			if policy.APIKey != "" && policy.MaxConcurrentRequests > 0 {
+				perKey[policy.APIKey] = policy.MaxConcurrentRequests
+			}
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cfg = settings
+	l.perKeyLimits = perKey
+	l.wakeLocked()
+}
+
+// Middleware returns a Gin handler that holds a slot for the whole request, including
+// streamed responses. GET requests (model listings and websocket upgrades) are not limited.
+func (l *ConcurrencyLimiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodGet {
+			c.Next()
+			return
+		}
+		apiKey := c.GetString("apiKey")
+		release, ok := l.acquire(c.Request.Context(), apiKey)
+		if !ok {
+			c.Header("Retry-After", strconv.Itoa(l.retryAfterSeconds()))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error": gin.H{
+					"message": "too many concurrent requests, please retry later",
+					"type":    "rate_limit_error",
+					"code":    "concurrency_limit_exceeded",
+				},
+			})
+			return
+		}
+		defer release()
+		c.Next()
+	}
+}
+
+// acquire waits for a slot for apiKey. It returns false when the queue is full, the queue
+// timeout elapses, or ctx is cancelled.
+func (l *ConcurrencyLimiter) acquire(ctx context.Context, apiKey string) (func(), bool) {
+	l.mu.Lock()
+	if l.availableLocked(apiKey) {
+		l.takeLocked(apiKey)
+		l.mu.Unlock()
+		return l.releaser(apiKey), true
+	}
+	if l.queued >= l.cfg.MaxQueue {
+		l.mu.Unlock()
+		return nil, false
+	}
+	timeout := defaultConcurrencyQueueTimeout
+	if l.cfg.QueueTimeoutSeconds > 0 {
+		timeout = time.Duration(l.cfg.QueueTimeoutSeconds) * time.Second
+	}
+	l.queued++
+	wake := l.released
+	l.mu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case <-wake:
+			l.mu.Lock()
+			if l.availableLocked(apiKey) {
+				l.queued--
+				l.takeLocked(apiKey)
+				l.mu.Unlock()
+				return l.releaser(apiKey), true
+			}
+			wake = l.released
+			l.mu.Unlock()
+		case <-timer.C:
+			l.leaveQueue()
+			return nil, false
+		case <-ctx.Done():
+			l.leaveQueue()
+			return nil, false
+		}
+	}
+}
+
+func (l *ConcurrencyLimiter) availableLocked(apiKey string) bool {
+	if l.cfg.MaxRequests > 0 && l.active >= l.cfg.MaxRequests {
+		return false
+	}
+	if apiKey == "" {
+		return true
+	}
+	limit := l.cfg.PerKeyMaxRequests
+	if override, ok := l.perKeyLimits[apiKey]; ok {
+		limit = override
+	}
+	return limit <= 0 || l.activeByKey[apiKey] < limit
+}
+
+func (l *ConcurrencyLimiter) takeLocked(apiKey string) {
+	l.active++
+	if apiKey != "" {
+		l.activeByKey[apiKey]++
+	}
+}
+
+func (l *ConcurrencyLimiter) releaser(apiKey string) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.active--
+			if apiKey != "" {
+				if l.activeByKey[apiKey] <= 1 {
+					delete(l.activeByKey, apiKey)
+				} else {
+					l.activeByKey[apiKey]--
+				}
+			}
+			l.wakeLocked()
+		})
+	}
+}
+
+func (l *ConcurrencyLimiter) leaveQueue() {
+	l.mu.Lock()
+	l.queued--
+	l.mu.Unlock()
+}
+
+func (l *ConcurrencyLimiter) wakeLocked() {
+	close(l.released)
+	l.released = make(chan struct{})
+}
+
+func (l *ConcurrencyLimiter) retryAfterSeconds() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.cfg.RetryAfterSeconds > 0 {
+		return l.cfg.RetryAfterSeconds
+	}
+	return defaultConcurrencyRetryAfter
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestConcurrencyLimiterPerKeyLimitAndOverride(t *testing.T) {
+	cfg := &config.Config{Concurrency: config.ConcurrencyConfig{PerKeyMaxRequests: 1}}
+	cfg.APIKeyPolicies = []config.APIKeyPolicy{{APIKey: "vip", MaxConcurrentRequests: 2}}
+	limiter := NewConcurrencyLimiter(cfg)
+	ctx := context.Background()
+
+	releaseA, ok := limiter.acquire(ctx, "key-a")
+	if !ok {
+		t.Fatal("first request for key-a should be admitted")
+	}
+	if _, ok = limiter.acquire(ctx, "key-a"); ok {
+		t.Fatal("second request for key-a should be rejected without a queue")
+	}
+	if _, ok = limiter.acquire(ctx, "key-b"); !ok {
+		t.Fatal("other keys should not be affected by key-a")
+	}
+	for i := 0; i < 2; i++ {
+		if _, ok = limiter.acquire(ctx, "vip"); !ok {
+			t.Fatalf("vip request %d should be admitted by the policy override", i+1)
+		}
+	}
+	releaseA()
+	if _, ok = limiter.acquire(ctx, "key-a"); !ok {
+		t.Fatal("key-a should be admitted after release")
+	}
+}
+
+func TestConcurrencyLimiterQueuesUntilRelease(t *testing.T) {
+	limiter := NewConcurrencyLimiter(&config.Config{Concurrency: config.ConcurrencyConfig{MaxRequests: 1, MaxQueue: 1}})
+	ctx := context.Background()
+
+	release, _ := limiter.acquire(ctx, "")
+	admitted := make(chan bool, 1)
+	go func() {
+		_, ok := limiter.acquire(ctx, "")
+		admitted <- ok
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		limiter.mu.Lock()
+		queued := limiter.queued
+		limiter.mu.Unlock()
+		if queued == 1 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if _, ok := limiter.acquire(ctx, ""); ok {
+		t.Fatal("request should be rejected when the queue is full")
+	}
+	release()
+	select {
+	case ok := <-admitted:
+		if !ok {
+			t.Fatal("queued request should be admitted after release")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("queued request was not woken")
+	}
+}
+
+func TestConcurrencyLimiterMiddlewareRejectsWithRetryAfter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limiter := NewConcurrencyLimiter(&config.Config{Concurrency: config.ConcurrencyConfig{MaxRequests: 1, RetryAfterSeconds: 5}})
+	hold, _ := limiter.acquire(context.Background(), "")
+	defer hold()
+
+	router := gin.New()
+	router.Use(limiter.Middleware())
+	router.POST("/v1/chat/completions", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/v1/models", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader("{}")))
+	if resp.Code != http.StatusTooManyRequests || resp.Header().Get("Retry-After") != "5" {
+		t.Fatalf("status = %d, Retry-After = %q", resp.Code, resp.Header().Get("Retry-After"))
+	}
+
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/v1/models", nil))
+	if resp.Code != http.StatusOK {
+		t.Fatalf("GET requests should not be limited, status = %d", resp.Code)
+	}
+}
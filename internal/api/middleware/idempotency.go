@@ -0,0 +1,304 @@
+// Package middleware provides HTTP middleware components for the CLI Proxy API server.
+// This file contains the Idempotency-Key cache that replays the original response to
+// clients repeating a request within the configured window.
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+)
+
+const (
+	// IdempotencyKeyHeader is the request header carrying the client-chosen idempotency key.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses served from the idempotency cache.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	defaultIdempotencyTTL              = 10 * time.Minute
+	defaultIdempotencyMaxEntries       = 1000
+	defaultIdempotencyMaxResponseBytes = 8 << 20
+	maxIdempotencyKeyLength            = 255
+)
+
+// IdempotencyCache stores completed responses by Idempotency-Key so that retried requests
+// receive the original response instead of running a second generation. It is safe for
+// concurrent use; settings can be replaced at runtime with Update.
+type IdempotencyCache struct {
+	mu      sync.Mutex
+	cfg     config.IdempotencyConfig
+	entries map[string]*idempotencyEntry
+}
+
+type idempotencyEntry struct {
+	fingerprint [sha256.Size]byte
+	// done is closed when the original request finishes, whether or not it was stored.
+	done      chan struct{}
+	completed bool
+	status    int
+	header    http.Header
+	body      []byte
+	createdAt time.Time
+	expiresAt time.Time
+}
+
+// NewIdempotencyCache creates a cache using the idempotency settings from cfg.
+func NewIdempotencyCache(cfg *config.Config) *IdempotencyCache {
+	c := &IdempotencyCache{entries: make(map[string]*idempotencyEntry)}
+	c.Update(cfg)
+	return c
+}
+
+// Update replaces the settings with those from cfg. Disabling the cache drops stored responses.
+func (c *IdempotencyCache) Update(cfg *config.Config) {
+	var settings config.IdempotencyConfig
+	if cfg != nil {
+		settings = cfg.Idempotency
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cfg = settings
+	if !settings.Enable {
+		for key, entry := range c.entries {
+			if entry.completed {
+				delete(c.entries, key)
+			}
+		}
+	}
+}
+
+// Middleware returns a Gin handler that replays stored responses for POST requests carrying
+// an Idempotency-Key header. Keys are scoped to the client API key and request path; reusing
+// a key with a different body is rejected with 422. Only successful, fully delivered
+// responses are stored, so failed requests, including streams that broke off with an error
+// event, can be retried normally.
+func (c *IdempotencyCache) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := strings.TrimSpace(ctx.GetHeader(IdempotencyKeyHeader))
+		if key == "" || ctx.Request.Method != http.MethodPost || !c.enabled() {
+			ctx.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			abortIdempotency(ctx, http.StatusBadRequest, "idempotency_key_invalid", "Idempotency-Key header must be at most 255 characters")
+			return
+		}
+
+		var body []byte
+		if ctx.Request.Body != nil {
+			data, errRead := io.ReadAll(ctx.Request.Body)
+			if errRead != nil {
+				abortIdempotency(ctx, http.StatusBadRequest, "invalid_request_body", "failed to read request body")
+				return
+			}
+			body = data
+			ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		fingerprint := sha256.Sum256(body)
+		cacheKey := ctx.GetString("apiKey") + "\x00" + ctx.Request.URL.Path + "\x00" + key
+
+		for {
+			entry, owner, conflict := c.begin(cacheKey, fingerprint)
+			if conflict {
+				abortIdempotency(ctx, http.StatusUnprocessableEntity, "idempotency_key_reused", "Idempotency-Key was already used with a different request body")
+				return
+			}
+			if owner {
+				c.serveOriginal(ctx, cacheKey, entry)
+				return
+			}
+			select {
+			case <-entry.done:
+			case <-ctx.Request.Context().Done():
+				ctx.Abort()
+				return
+			}
+			if c.replay(ctx, entry) {
+				return
+			}
+			// The original request was not stored (it failed); run this one instead.
+		}
+	}
+}
+
+func (c *IdempotencyCache) enabled() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cfg.Enable
+}
+
+// begin returns the entry for key. owner is true when the caller must execute the request and
+// publish its result; conflict is true when the key is bound to a different request body.
+func (c *IdempotencyCache) begin(key string, fingerprint [sha256.Size]byte) (entry *idempotencyEntry, owner bool, conflict bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if existing, ok := c.entries[key]; ok {
+		if !existing.completed || now.Before(existing.expiresAt) {
+			if existing.fingerprint != fingerprint {
+				return nil, false, true
+			}
+			return existing, false, false
+		}
+		delete(c.entries, key)
+	}
+	c.evictLocked(now)
+	entry = &idempotencyEntry{fingerprint: fingerprint, done: make(chan struct{}), createdAt: now}
+	c.entries[key] = entry
+	return entry, true, false
+}
+
+// serveOriginal runs the request while capturing its response and publishes the outcome.
+func (c *IdempotencyCache) serveOriginal(ctx *gin.Context, key string, entry *idempotencyEntry) {
+	writer := &idempotencyResponseWriter{ResponseWriter: ctx.Writer, limit: c.maxResponseBytes()}
+	ctx.Writer = writer
+	defer func() {
+		ctx.Writer = writer.ResponseWriter
+		status := writer.Status()
+		store := !writer.overflow && status >= 200 && status < 300 && ctx.Request.Context().Err() == nil &&
+			!ctx.GetBool(handlers.StreamErrorContextKey)
+
+		c.mu.Lock()
+		if store && c.cfg.Enable {
+			entry.completed = true
+			entry.status = status
+			entry.header = replayableHeader(writer.Header())
+			entry.body = writer.buf.Bytes()
+			entry.expiresAt = time.Now().Add(c.ttlLocked())
+		} else if c.entries[key] == entry {
+			delete(c.entries, key)
+		}
+		close(entry.done)
+		c.mu.Unlock()
+	}()
+	ctx.Next()
+}
+
+// replay writes a stored response. It returns false when entry holds no stored response.
+func (c *IdempotencyCache) replay(ctx *gin.Context, entry *idempotencyEntry) bool {
+	c.mu.Lock()
+	completed := entry.completed
+	c.mu.Unlock()
+	if !completed {
+		return false
+	}
+	header := ctx.Writer.Header()
+	for name, values := range entry.header {
+		header[name] = append([]string(nil), values...)
+	}
+	header.Set(IdempotentReplayedHeader, "true")
+	ctx.Status(entry.status)
+	_, _ = ctx.Writer.Write(entry.body)
+	ctx.Abort()
+	return true
+}
+
+// evictLocked removes expired responses and, when the cache is full, the oldest stored ones.
+func (c *IdempotencyCache) evictLocked(now time.Time) {
+	limit := c.cfg.MaxEntries
+	if limit <= 0 {
+		limit = defaultIdempotencyMaxEntries
+	}
+	if len(c.entries) < limit {
+		return
+	}
+	for key, entry := range c.entries {
+		if entry.completed && !now.Before(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+	for len(c.entries) >= limit {
+		oldestKey := ""
+		var oldest *idempotencyEntry
+		for key, entry := range c.entries {
+			if !entry.completed {
+				continue
+			}
+			if oldest == nil || entry.createdAt.Before(oldest.createdAt) {
+				oldestKey, oldest = key, entry
+			}
+		}
+		if oldest == nil {
+			return
+		}
+		delete(c.entries, oldestKey)
+	}
+}
+
+func (c *IdempotencyCache) ttlLocked() time.Duration {
+	if c.cfg.TTLSeconds > 0 {
+		return time.Duration(c.cfg.TTLSeconds) * time.Second
+	}
+	return defaultIdempotencyTTL
+}
+
+func (c *IdempotencyCache) maxResponseBytes() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cfg.MaxResponseBytes > 0 {
+		return c.cfg.MaxResponseBytes
+	}
+	return defaultIdempotencyMaxResponseBytes
+}
+
+// replayableHeader copies the response headers, leaving out those that describe the
+// transfer rather than the content; outer middleware sets them again on replay.
+func replayableHeader(src http.Header) http.Header {
+	out := src.Clone()
+	for _, name := range []string{"Content-Encoding", "Content-Length", "Date", "Vary"} {
+		out.Del(name)
+	}
+	return out
+}
+
+func abortIdempotency(ctx *gin.Context, status int, code, message string) {
+	ctx.AbortWithStatusJSON(status, gin.H{
+		"error": gin.H{
+			"message": message,
+			"type":    "invalid_request_error",
+			"code":    code,
+		},
+	})
+}
+
+// idempotencyResponseWriter tees the response body into a bounded buffer.
+type idempotencyResponseWriter struct {
+	gin.ResponseWriter
+	buf      bytes.Buffer
+	limit    int
+	overflow bool
+}
+
+func (w *idempotencyResponseWriter) capture(size int) bool {
+	if w.overflow {
+		return false
+	}
+	if w.buf.Len()+size > w.limit {
+		w.overflow = true
+		w.buf = bytes.Buffer{}
+		return false
+	}
+	return true
+}
+
+func (w *idempotencyResponseWriter) Write(data []byte) (int, error) {
+	if w.capture(len(data)) {
+		w.buf.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *idempotencyResponseWriter) WriteString(s string) (int, error) {
+	if w.capture(len(s)) {
+		w.buf.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
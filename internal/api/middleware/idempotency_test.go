@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+)
+
+func newIdempotencyTestEngine(cache *IdempotencyCache, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Set("apiKey", c.GetHeader("X-Test-Key"))
+		c.Next()
+	}, cache.Middleware())
+	engine.POST("/v1/chat/completions", handler)
+	return engine
+}
+
+func idempotentPost(engine *gin.Engine, key, apiKey, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	req.Header.Set("X-Test-Key", apiKey)
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyReplaysStreamedResponse(t *testing.T) {
+	cache := NewIdempotencyCache(&config.Config{Idempotency: config.IdempotencyConfig{Enable: true}})
+	var calls atomic.Int32
+	engine := newIdempotencyTestEngine(cache, func(c *gin.Context) {
+		n := calls.Add(1)
+		c.Header("Content-Type", "text/event-stream")
+		c.Status(http.StatusOK)
+		for i := 0; i < 3; i++ {
+			_, _ = c.Writer.WriteString("data: chunk\n\n")
+			c.Writer.Flush()
+		}
+		_, _ = c.Writer.WriteString(strings.Repeat("x", int(n)))
+	})
+
+	first := idempotentPost(engine, "k1", "client", `{"model":"m"}`)
+	second := idempotentPost(engine, "k1", "client", `{"model":"m"}`)
+	if calls.Load() != 1 {
+		t.Fatalf("handler calls = %d, want 1", calls.Load())
+	}
+	if second.Body.String() != first.Body.String() {
+		t.Fatalf("replayed body = %q, want %q", second.Body.String(), first.Body.String())
+	}
+	if second.Header().Get(IdempotentReplayedHeader) != "true" || second.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("replay headers = %v", second.Header())
+	}
+
+	idempotentPost(engine, "k1", "other-client", `{"model":"m"}`)
+	idempotentPost(engine, "", "client", `{"model":"m"}`)
+	if calls.Load() != 3 {
+		t.Fatalf("handler calls = %d, want 3 (keys are scoped per client and optional)", calls.Load())
+	}
+
+	conflict := idempotentPost(engine, "k1", "client", `{"model":"other"}`)
+	if conflict.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("reused key with different body: status = %d, want 422", conflict.Code)
+	}
+}
+
+func TestIdempotencyDoesNotStoreFailures(t *testing.T) {
+	cache := NewIdempotencyCache(&config.Config{Idempotency: config.IdempotencyConfig{Enable: true}})
+	var calls atomic.Int32
+	engine := newIdempotencyTestEngine(cache, func(c *gin.Context) {
+		if calls.Add(1) == 1 {
+			c.JSON(http.StatusBadGateway, gin.H{"error": "upstream"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+
+	if rec := idempotentPost(engine, "k", "", `{}`); rec.Code != http.StatusBadGateway {
+		t.Fatalf("first status = %d", rec.Code)
+	}
+	if rec := idempotentPost(engine, "k", "", `{}`); rec.Code != http.StatusOK || rec.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Fatalf("retry after failure should execute again: status = %d headers = %v", rec.Code, rec.Header())
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("handler calls = %d, want 2", calls.Load())
+	}
+}
+
+func TestIdempotencyDoesNotStoreFailedStreams(t *testing.T) {
+	cache := NewIdempotencyCache(&config.Config{Idempotency: config.IdempotencyConfig{Enable: true}})
+	var calls atomic.Int32
+	engine := newIdempotencyTestEngine(cache, func(c *gin.Context) {
+		c.Header("Content-Type", "text/event-stream")
+		c.Status(http.StatusOK)
+		_, _ = c.Writer.WriteString("data: chunk\n\n")
+		if calls.Add(1) == 1 {
+			_, _ = c.Writer.WriteString("event: error\ndata: {\"error\":\"upstream\"}\n\n")
+			c.Set(handlers.StreamErrorContextKey, true)
+			return
+		}
+		_, _ = c.Writer.WriteString("data: [DONE]\n\n")
+	})
+
+	idempotentPost(engine, "k", "", `{}`)
+	rec := idempotentPost(engine, "k", "", `{}`)
+	if rec.Header().Get(IdempotentReplayedHeader) != "" || strings.Contains(rec.Body.String(), "event: error") {
+		t.Fatalf("stream that failed mid-flight was replayed: %q", rec.Body.String())
+	}
+	if replay := idempotentPost(engine, "k", "", `{}`); replay.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Fatalf("completed stream should be stored, headers = %v", replay.Header())
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("handler calls = %d, want 2", calls.Load())
+	}
+}
+
+func TestIdempotencyConcurrentRetryWaitsForOriginal(t *testing.T) {
+	cache := NewIdempotencyCache(&config.Config{Idempotency: config.IdempotencyConfig{Enable: true}})
+	var calls atomic.Int32
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	engine := newIdempotencyTestEngine(cache, func(c *gin.Context) {
+		calls.Add(1)
+		close(started)
+		<-finish
+		c.String(http.StatusOK, "done")
+	})
+
+	firstDone := make(chan *httptest.ResponseRecorder, 1)
+	go func() { firstDone <- idempotentPost(engine, "k", "", `{}`) }()
+	<-started
+	secondDone := make(chan *httptest.ResponseRecorder, 1)
+	go func() { secondDone <- idempotentPost(engine, "k", "", `{}`) }()
+
+	select {
+	case <-secondDone:
+		t.Fatal("retry completed before the original request")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(finish)
+	<-firstDone
+	second := <-secondDone
+	if second.Body.String() != "done" || second.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Fatalf("retry body = %q headers = %v", second.Body.String(), second.Header())
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("handler calls = %d, want 1", calls.Load())
+	}
+}
+
+func TestIdempotencyDisabledPassesThrough(t *testing.T) {
+	cache := NewIdempotencyCache(&config.Config{})
+	var calls atomic.Int32
+	engine := newIdempotencyTestEngine(cache, func(c *gin.Context) {
+		calls.Add(1)
+		c.String(http.StatusOK, "ok")
+	})
+	idempotentPost(engine, "k", "", `{}`)
+	idempotentPost(engine, "k", "", `{}`)
+	if calls.Load() != 2 {
+		t.Fatalf("handler calls = %d, want 2 when disabled", calls.Load())
+	}
+}
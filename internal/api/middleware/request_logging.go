@@ -124,6 +124,7 @@ func captureRequestInfo(c *gin.Context, captureBody bool) (*RequestInfo, error)
 	for key, values := range c.Request.Header {
 		headers[key] = values
 	}
+	logging.RedactClientHeaders(headers)
 
 	// Capture request body
 	var body []byte
@@ -28,9 +28,13 @@ import (
 	ampmodule "github.com/router-for-me/CLIProxyAPI/v6/internal/api/modules/amp"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/daemon"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/diagnostics"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/managementasset"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/redisqueue"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/streamwatch"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
@@ -173,6 +177,21 @@ type Server struct {
 	wsAuthChanged func(bool, bool)
 	wsAuthEnabled atomic.Bool
 
+	// serving reports whether the listener is accepting connections.
+	serving atomic.Bool
+	// ready is closed the first time the listener starts accepting connections.
+	ready     chan struct{}
+	readyOnce sync.Once
+
+	// compression holds the current body compression settings for hot reload.
+	compression *atomic.Pointer[config.CompressionConfig]
+
+	// concurrency enforces the global and per-key in-flight request limits on API routes.
+	concurrency *middleware.ConcurrencyLimiter
+
+	// idempotency replays stored responses for requests repeating an Idempotency-Key.
+	idempotency *middleware.IdempotencyCache
+
 	// management handler
 	mgmt *managementHandlers.Handler
 
@@ -231,6 +250,12 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 		engine.Use(mw)
 	}
 
+	// Add compression middleware ahead of request logging so logs record decoded bodies.
+	compression := &atomic.Pointer[config.CompressionConfig]{}
+	compressionCfg := cfg.Compression
+	compression.Store(&compressionCfg)
+	engine.Use(middleware.CompressionMiddleware(func() config.CompressionConfig { return *compression.Load() }))
+
 	// Add request logging middleware (positioned after recovery, before auth)
 	// Resolve logs directory relative to the configuration file directory.
 	var requestLogger logging.RequestLogger
@@ -269,6 +294,10 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 		currentPath:         wd,
 		envManagementSecret: envManagementSecret,
 		wsRoutes:            make(map[string]struct{}),
+		compression:         compression,
+		concurrency:         middleware.NewConcurrencyLimiter(cfg),
+		idempotency:         middleware.NewIdempotencyCache(cfg),
+		ready:               make(chan struct{}),
 	}
 	s.wsAuthEnabled.Store(cfg.WebsocketAuth)
 	// Save initial YAML snapshot
@@ -280,6 +309,8 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	managementasset.SetCurrentConfig(cfg)
 	auth.SetQuotaCooldownDisabled(cfg.DisableCooling)
 	applySignatureCacheConfig(nil, cfg)
+	diagnostics.Default().Configure(cfg.FailureDiagnostics, filepath.Dir(configFilePath))
+	streamwatch.Default().Configure(cfg.StreamWatch)
 	// Initialize management handler
 	s.mgmt = managementHandlers.NewHandler(cfg, configFilePath, authManager)
 	if optionState.localPassword != "" {
@@ -328,12 +359,36 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	// Create HTTP server
 	s.server = &http.Server{
 		Addr:    fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
-		Handler: engine,
+		Handler: pingHandler(engine),
 	}
 
 	return s
 }
 
+// PingPath is the unauthenticated liveness probe path served ahead of the Gin engine.
+const PingPath = "/ping"
+
+var (
+	pingBody        = []byte("pong")
+	pingContentType = []string{"text/plain; charset=utf-8"}
+)
+
+// pingHandler answers liveness probes before they reach the Gin engine so that
+// probes bypass logging, authentication and usage accounting entirely.
+func pingHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != PingPath || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header()["Content-Type"] = pingContentType
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(pingBody)
+		}
+	})
+}
+
 // setupRoutes configures the API routes for the server.
 // It defines the endpoints and associates them with their respective handlers.
 func (s *Server) setupRoutes() {
@@ -357,10 +412,11 @@ func (s *Server) setupRoutes() {
 
 	// OpenAI compatible API routes
 	v1 := s.engine.Group("/v1")
-	v1.Use(AuthMiddleware(s.accessManager))
+	v1.Use(AuthMiddleware(s.accessManager), s.idempotency.Middleware(), s.concurrency.Middleware())
 	{
 		v1.GET("/models", s.unifiedModelsHandler(openaiHandlers, claudeCodeHandlers))
 		v1.POST("/chat/completions", openaiHandlers.ChatCompletions)
+		v1.POST("/agent/chat/completions", openaiHandlers.AgentChatCompletions)
 		v1.POST("/completions", openaiHandlers.Completions)
 		v1.POST("/images/generations", openaiHandlers.ImagesGenerations)
 		v1.POST("/images/edits", openaiHandlers.ImagesEdits)
@@ -373,7 +429,7 @@ func (s *Server) setupRoutes() {
 
 	// Codex CLI direct route aliases (chatgpt_base_url compatible)
 	codexDirect := s.engine.Group("/backend-api/codex")
-	codexDirect.Use(AuthMiddleware(s.accessManager))
+	codexDirect.Use(AuthMiddleware(s.accessManager), s.idempotency.Middleware(), s.concurrency.Middleware())
 	{
 		codexDirect.GET("/responses", openaiResponsesHandlers.ResponsesWebsocket)
 		codexDirect.POST("/responses", openaiResponsesHandlers.Responses)
@@ -382,7 +438,7 @@ func (s *Server) setupRoutes() {
 
 	// Gemini compatible API routes
 	v1beta := s.engine.Group("/v1beta")
-	v1beta.Use(AuthMiddleware(s.accessManager))
+	v1beta.Use(AuthMiddleware(s.accessManager), s.idempotency.Middleware(), s.concurrency.Middleware())
 	{
 		v1beta.GET("/models", geminiHandlers.GeminiModels)
 		v1beta.POST("/models/*action", geminiHandlers.GeminiHandler)
@@ -516,6 +572,8 @@ func (s *Server) registerManagementRoutes() {
 	{
 		mgmt.GET("/usage", s.mgmt.GetUsageStatistics)
 		mgmt.GET("/usage/export", s.mgmt.ExportUsageStatistics)
+		mgmt.GET("/usage/timeseries", s.mgmt.GetUsageTimeSeries)
+		mgmt.GET("/usage/cost", s.mgmt.GetUsageCost)
 		mgmt.POST("/usage/import", s.mgmt.ImportUsageStatistics)
 		mgmt.GET("/config", s.mgmt.GetConfig)
 		mgmt.GET("/config.yaml", s.mgmt.GetConfigYAML)
@@ -575,6 +633,18 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/request-logs/:name/content", s.mgmt.ReadRequestLogContent)
 		mgmt.GET("/request-logs/:name", s.mgmt.DownloadRequestLog)
 		mgmt.GET("/request-log-by-id/:id", s.mgmt.GetRequestLogByID)
+		mgmt.GET("/request-transcript/:id", s.mgmt.GetRequestTranscript)
+		mgmt.GET("/request-stream/:id", s.mgmt.WatchRequestStream)
+		mgmt.GET("/failures", s.mgmt.GetFailures)
+		mgmt.DELETE("/failures", s.mgmt.DeleteFailures)
+		mgmt.GET("/circuit-breakers", s.mgmt.GetCircuitBreakers)
+		mgmt.DELETE("/circuit-breakers", s.mgmt.DeleteCircuitBreaker)
+		mgmt.GET("/concurrency", s.mgmt.GetConcurrency)
+		mgmt.GET("/goroutines", s.mgmt.GetGoroutines)
+		mgmt.GET("/replay-comparisons", s.mgmt.ListReplayComparisons)
+		mgmt.POST("/replay-comparisons", s.mgmt.PostReplayComparison)
+		mgmt.GET("/replay-comparisons/:id", s.mgmt.GetReplayComparison)
+		mgmt.DELETE("/replay-comparisons/:id", s.mgmt.DeleteReplayComparison)
 		mgmt.GET("/request-log", s.mgmt.GetRequestLog)
 		mgmt.PUT("/request-log", s.mgmt.PutRequestLog)
 		mgmt.PATCH("/request-log", s.mgmt.PutRequestLog)
@@ -819,9 +889,19 @@ func (s *Server) Start() error {
 	}
 
 	addr := s.server.Addr
-	listener, errListen := net.Listen("tcp", addr)
-	if errListen != nil {
-		return fmt.Errorf("failed to start HTTP server: %v", errListen)
+	listener, errActivation := daemon.ActivationListener()
+	if errActivation != nil {
+		return fmt.Errorf("failed to start HTTP server: %v", errActivation)
+	}
+	if listener != nil {
+		addr = listener.Addr().String()
+		log.Infof("using systemd activation socket %s", addr)
+	} else {
+		var errListen error
+		listener, errListen = net.Listen("tcp", addr)
+		if errListen != nil {
+			return fmt.Errorf("failed to start HTTP server: %v", errListen)
+		}
 	}
 
 	useTLS := s.cfg != nil && s.cfg.TLS.Enable
@@ -859,6 +939,9 @@ func (s *Server) Start() error {
 	httpListener := newMuxListener(listener.Addr(), 1024)
 	s.muxBaseListener = listener
 	s.muxHTTPListener = httpListener
+	s.serving.Store(true)
+	defer s.serving.Store(false)
+	s.readyOnce.Do(func() { close(s.ready) })
 
 	httpErrCh := make(chan error, 1)
 	acceptErrCh := make(chan error, 1)
@@ -912,6 +995,16 @@ func (s *Server) Start() error {
 	}
 }
 
+// Healthy reports whether the server is currently accepting connections.
+func (s *Server) Healthy() bool {
+	return s != nil && s.serving.Load()
+}
+
+// Ready returns a channel that is closed once the server has started accepting connections.
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
+
 // Stop gracefully shuts down the API server without interrupting any
 // active connections.
 //
@@ -922,6 +1015,7 @@ func (s *Server) Start() error {
 //   - error: An error if the server fails to stop
 func (s *Server) Stop(ctx context.Context) error {
 	log.Debug("Stopping API server...")
+	s.serving.Store(false)
 
 	if s.keepAliveEnabled {
 		select {
@@ -1013,6 +1107,20 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 		usage.SetStatisticsEnabled(cfg.UsageStatisticsEnabled)
 	}
 
+	if oldCfg == nil || oldCfg.PrivacyMode != cfg.PrivacyMode {
+		if cfg.PrivacyMode {
+			if errKey := usage.LoadAnonymizationKey(cfg.AuthDir); errKey != nil {
+				log.WithError(errKey).Warn("failed to load anonymization key; anonymized identifiers will change on restart")
+			}
+		}
+		usage.SetAnonymizationEnabled(cfg.PrivacyMode)
+		logging.SetPrivacyMode(cfg.PrivacyMode)
+	}
+
+	if oldCfg == nil || !reflect.DeepEqual(oldCfg.ModelMetadata, cfg.ModelMetadata) {
+		registry.GetGlobalRegistry().SetModelMetadata(cfg.ModelMetadata)
+	}
+
 	if s.requestLogger != nil && (oldCfg == nil || oldCfg.ErrorLogsMaxFiles != cfg.ErrorLogsMaxFiles) {
 		if setter, ok := s.requestLogger.(interface{ SetErrorLogsMaxFiles(int) }); ok {
 			setter.SetErrorLogsMaxFiles(cfg.ErrorLogsMaxFiles)
@@ -1028,6 +1136,8 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 	}
 
 	applySignatureCacheConfig(oldCfg, cfg)
+	diagnostics.Default().Configure(cfg.FailureDiagnostics, filepath.Dir(s.configFilePath))
+	streamwatch.Default().Configure(cfg.StreamWatch)
 
 	if s.handlers != nil && s.handlers.AuthManager != nil {
 		s.handlers.AuthManager.SetRetryConfig(cfg.RequestRetry, time.Duration(cfg.MaxRetryInterval)*time.Second, cfg.MaxRetryCredentials)
@@ -1074,6 +1184,10 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 	s.applyAccessConfig(oldCfg, cfg)
 	s.cfg = cfg
 	s.wsAuthEnabled.Store(cfg.WebsocketAuth)
+	compressionCfg := cfg.Compression
+	s.compression.Store(&compressionCfg)
+	s.concurrency.Update(cfg)
+	s.idempotency.Update(cfg)
 	if oldCfg != nil && s.wsAuthChanged != nil && oldCfg.WebsocketAuth != cfg.WebsocketAuth {
 		s.wsAuthChanged(oldCfg.WebsocketAuth, cfg.WebsocketAuth)
 	}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -84,6 +85,28 @@ func TestHealthz(t *testing.T) {
 	})
 }
 
+func TestPing(t *testing.T) {
+	server := newTestServer(t)
+
+	req := httptest.NewRequest(http.MethodGet, PingPath, nil)
+	rr := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: got %d want %d", rr.Code, http.StatusOK)
+	}
+	if rr.Body.String() != "pong" {
+		t.Fatalf("unexpected body: got %q want %q", rr.Body.String(), "pong")
+	}
+
+	// The probe is answered ahead of the Gin engine, which has no /ping route.
+	engineRR := httptest.NewRecorder()
+	server.engine.ServeHTTP(engineRR, req)
+	if engineRR.Code != http.StatusNotFound {
+		t.Fatalf("expected engine to not serve %s, got status %d", PingPath, engineRR.Code)
+	}
+}
+
 func TestAmpProviderModelRoutes(t *testing.T) {
 	testCases := []struct {
 		name         string
@@ -248,3 +271,34 @@ func TestDefaultRequestLoggerFactory_UsesResolvedLogDirectory(t *testing.T) {
 		}
 	}
 }
+
+func TestServerReadyAfterListening(t *testing.T) {
+	server := newTestServer(t)
+	select {
+	case <-server.Ready():
+		t.Fatal("Ready closed before Start")
+	default:
+	}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- server.Start() }()
+	select {
+	case <-server.Ready():
+	case errStart := <-errCh:
+		t.Fatalf("Start returned before ready: %v", errStart)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not become ready")
+	}
+	if !server.Healthy() {
+		t.Fatal("Healthy = false after Ready")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if errStop := server.Stop(ctx); errStop != nil {
+		t.Fatalf("Stop: %v", errStop)
+	}
+	if errStart := <-errCh; errStart != nil {
+		t.Fatalf("Start: %v", errStart)
+	}
+}
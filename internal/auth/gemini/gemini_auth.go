@@ -78,24 +78,12 @@ func (g *GeminiAuth) GetAuthenticatedClient(ctx context.Context, ts *GeminiToken
 	}
 	callbackURL := fmt.Sprintf("http://localhost:%d/oauth2callback", callbackPort)
 
-	transport, _, errBuild := proxyutil.BuildHTTPTransport(cfg.ProxyURL)
-	if errBuild != nil {
-		log.Errorf("%v", errBuild)
-	} else if transport != nil {
-		proxyClient := &http.Client{Transport: transport}
-		ctx = context.WithValue(ctx, oauth2.HTTPClient, proxyClient)
-	}
+	ctx = withProxyClient(ctx, cfg)
 
 	var err error
 
 	// Configure the OAuth2 client.
-	conf := &oauth2.Config{
-		ClientID:     ClientID,
-		ClientSecret: ClientSecret,
-		RedirectURL:  callbackURL, // This will be used by the local server.
-		Scopes:       Scopes,
-		Endpoint:     google.Endpoint,
-	}
+	conf := oauthConfig(callbackURL)
 
 	var token *oauth2.Token
 
@@ -125,6 +113,33 @@ func (g *GeminiAuth) GetAuthenticatedClient(ctx context.Context, ts *GeminiToken
 	return conf.Client(ctx, token), nil
 }
 
+// withProxyClient attaches an HTTP client honoring the configured proxy to ctx so the OAuth2
+// library uses it for token exchange and refresh.
+func withProxyClient(ctx context.Context, cfg *config.Config) context.Context {
+	if cfg == nil {
+		return ctx
+	}
+	transport, _, errBuild := proxyutil.BuildHTTPTransport(cfg.ProxyURL)
+	if errBuild != nil {
+		log.Errorf("%v", errBuild)
+	} else if transport != nil {
+		proxyClient := &http.Client{Transport: transport}
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, proxyClient)
+	}
+	return ctx
+}
+
+// oauthConfig returns the OAuth2 configuration of the Gemini CLI client.
+func oauthConfig(redirectURL string) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     ClientID,
+		ClientSecret: ClientSecret,
+		RedirectURL:  redirectURL, // This will be used by the local server.
+		Scopes:       Scopes,
+		Endpoint:     google.Endpoint,
+	}
+}
+
 // createTokenStorage creates a new GeminiTokenStorage object. It fetches the user's email
 // using the provided token and populates the storage structure.
 //
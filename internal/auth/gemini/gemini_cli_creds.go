@@ -0,0 +1,97 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"golang.org/x/oauth2"
+)
+
+// CLICredentialsFileName is the file the Gemini CLI writes its OAuth credentials to.
+const CLICredentialsFileName = "oauth_creds.json"
+
+// cliCredentials mirrors the oauth_creds.json layout written by the Gemini CLI.
+type cliCredentials struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	Scope        string `json:"scope"`
+	IDToken      string `json:"id_token"`
+	// ExpiryDate is the access token expiry in Unix milliseconds.
+	ExpiryDate int64 `json:"expiry_date"`
+}
+
+// ResolveCLICredentialsPath returns the oauth_creds.json path to import. An empty path selects
+// the Gemini CLI default (~/.gemini/oauth_creds.json) and a directory selects the file inside it.
+func ResolveCLICredentialsPath(path string) (string, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		home, errHome := os.UserHomeDir()
+		if errHome != nil {
+			return "", fmt.Errorf("resolve home directory: %w", errHome)
+		}
+		return filepath.Join(home, ".gemini", CLICredentialsFileName), nil
+	}
+	if strings.HasPrefix(path, "~") {
+		home, errHome := os.UserHomeDir()
+		if errHome != nil {
+			return "", fmt.Errorf("resolve home directory: %w", errHome)
+		}
+		path = filepath.Join(home, strings.TrimPrefix(path, "~"))
+	}
+	if info, errStat := os.Stat(path); errStat == nil && info.IsDir() {
+		path = filepath.Join(path, CLICredentialsFileName)
+	}
+	return path, nil
+}
+
+// ParseCLICredentials converts the content of a Gemini CLI oauth_creds.json file into an OAuth2
+// token. The refresh token is required because the access token alone expires within an hour.
+func ParseCLICredentials(data []byte) (*oauth2.Token, error) {
+	var creds cliCredentials
+	if errUnmarshal := json.Unmarshal(data, &creds); errUnmarshal != nil {
+		return nil, fmt.Errorf("invalid oauth credentials json: %w", errUnmarshal)
+	}
+	if strings.TrimSpace(creds.RefreshToken) == "" {
+		return nil, fmt.Errorf("oauth credentials have no refresh_token")
+	}
+	token := &oauth2.Token{
+		AccessToken:  strings.TrimSpace(creds.AccessToken),
+		RefreshToken: strings.TrimSpace(creds.RefreshToken),
+		TokenType:    strings.TrimSpace(creds.TokenType),
+	}
+	if token.TokenType == "" {
+		token.TokenType = "Bearer"
+	}
+	if creds.ExpiryDate > 0 {
+		token.Expiry = time.UnixMilli(creds.ExpiryDate)
+	}
+	if creds.IDToken != "" {
+		token = token.WithExtra(map[string]any{"id_token": creds.IDToken})
+	}
+	return token, nil
+}
+
+// ImportCLICredentials turns Gemini CLI oauth_creds.json content into token storage. The Gemini
+// CLI uses the same OAuth client as this proxy, so its refresh token keeps working here. The
+// access token is refreshed when expired and the account email is looked up; the project is
+// left empty for the caller to select.
+func (g *GeminiAuth) ImportCLICredentials(ctx context.Context, cfg *config.Config, data []byte) (*GeminiTokenStorage, error) {
+	token, errParse := ParseCLICredentials(data)
+	if errParse != nil {
+		return nil, errParse
+	}
+	ctx = withProxyClient(ctx, cfg)
+	conf := oauthConfig("")
+	refreshed, errRefresh := conf.TokenSource(ctx, token).Token()
+	if errRefresh != nil {
+		return nil, fmt.Errorf("refresh imported token: %w", errRefresh)
+	}
+	return g.createTokenStorage(ctx, conf, refreshed, "")
+}
@@ -0,0 +1,76 @@
+package gemini
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseCLICredentials(t *testing.T) {
+	data := []byte(`{
+		"access_token": "ya29.access",
+		"refresh_token": "1//refresh",
+		"scope": "https://www.googleapis.com/auth/cloud-platform openid",
+		"token_type": "Bearer",
+		"id_token": "eyJ.id",
+		"expiry_date": 1760000000000
+	}`)
+
+	token, err := ParseCLICredentials(data)
+	if err != nil {
+		t.Fatalf("ParseCLICredentials() error = %v", err)
+	}
+	if token.AccessToken != "ya29.access" || token.RefreshToken != "1//refresh" || token.TokenType != "Bearer" {
+		t.Fatalf("unexpected token: %+v", token)
+	}
+	if want := time.UnixMilli(1760000000000); !token.Expiry.Equal(want) {
+		t.Fatalf("Expiry = %v, want %v", token.Expiry, want)
+	}
+	if idToken, _ := token.Extra("id_token").(string); idToken != "eyJ.id" {
+		t.Fatalf("id_token = %q, want eyJ.id", idToken)
+	}
+}
+
+func TestParseCLICredentialsRequiresRefreshToken(t *testing.T) {
+	if _, err := ParseCLICredentials([]byte(`{"access_token":"ya29.access"}`)); err == nil {
+		t.Fatal("expected error for credentials without refresh_token")
+	}
+	if _, err := ParseCLICredentials([]byte(`not json`)); err == nil {
+		t.Fatal("expected error for invalid json")
+	}
+}
+
+func TestResolveCLICredentialsPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	got, err := ResolveCLICredentialsPath("")
+	if err != nil {
+		t.Fatalf("ResolveCLICredentialsPath(\"\") error = %v", err)
+	}
+	if want := filepath.Join(home, ".gemini", CLICredentialsFileName); got != want {
+		t.Fatalf("default path = %q, want %q", got, want)
+	}
+
+	dir := filepath.Join(home, "creds")
+	if err = os.Mkdir(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	got, err = ResolveCLICredentialsPath(dir)
+	if err != nil {
+		t.Fatalf("ResolveCLICredentialsPath(dir) error = %v", err)
+	}
+	if want := filepath.Join(dir, CLICredentialsFileName); got != want {
+		t.Fatalf("directory path = %q, want %q", got, want)
+	}
+
+	file := filepath.Join(dir, "custom.json")
+	got, err = ResolveCLICredentialsPath(file)
+	if err != nil {
+		t.Fatalf("ResolveCLICredentialsPath(file) error = %v", err)
+	}
+	if got != file {
+		t.Fatalf("file path = %q, want %q", got, file)
+	}
+}
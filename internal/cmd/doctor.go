@@ -0,0 +1,347 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor/helps"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+const (
+	// doctorProbeTimeout bounds network checks and credential refresh probes.
+	doctorProbeTimeout = 15 * time.Second
+	// doctorMinFreeDiskBytes is the free space below which the log directory check fails.
+	doctorMinFreeDiskBytes = 100 << 20
+	// doctorLowFreeDiskBytes is the free space below which the log directory check warns.
+	doctorLowFreeDiskBytes = 1 << 30
+)
+
+// doctorStatus is the outcome of a single doctor check.
+type doctorStatus int
+
+const (
+	doctorPass doctorStatus = iota
+	doctorWarn
+	doctorFail
+)
+
+// doctorResult is one line of the doctor report.
+type doctorResult struct {
+	status doctorStatus
+	name   string
+	detail string
+}
+
+// doctorReport collects check results and renders them.
+type doctorReport struct {
+	out     io.Writer
+	color   bool
+	results []doctorResult
+}
+
+func (r *doctorReport) add(status doctorStatus, name, format string, args ...any) {
+	result := doctorResult{status: status, name: name, detail: fmt.Sprintf(format, args...)}
+	r.results = append(r.results, result)
+
+	label := map[doctorStatus]string{doctorPass: "PASS", doctorWarn: "WARN", doctorFail: "FAIL"}[status]
+	if r.color {
+		colorCode := map[doctorStatus]string{doctorPass: "32", doctorWarn: "33", doctorFail: "31"}[status]
+		label = "\x1b[" + colorCode + "m" + label + "\x1b[0m"
+	}
+	_, _ = fmt.Fprintf(r.out, "[%s] %-12s %s\n", label, result.name, result.detail)
+}
+
+func (r *doctorReport) count(status doctorStatus) int {
+	n := 0
+	for _, result := range r.results {
+		if result.status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// RunDoctor performs a self-test of the local installation and prints a report. It checks
+// configuration validity, auth credentials, tokenizer availability, free disk space for logs,
+// listen port availability and proxy reachability. The returned exit code is 0 when no check
+// failed, 1 when at least one check failed and 2 on invalid arguments, so it can gate
+// provisioning scripts.
+//
+// Parameters:
+//   - args: Command-line arguments following the doctor subcommand
+//   - defaultConfigPath: The configuration file checked when -config is not given
+//
+// Returns:
+//   - int: The process exit code
+func RunDoctor(args []string, defaultConfigPath string) int {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	configPath := fs.String("config", defaultConfigPath, "Configure File Path")
+	probe := fs.Bool("probe", false, "Refresh OAuth credentials against the provider to verify they are still valid")
+	noColor := fs.Bool("no-color", false, "Disable colored output")
+	if errParse := fs.Parse(args); errParse != nil {
+		return 2
+	}
+
+	report := &doctorReport{out: os.Stdout, color: !*noColor && isTerminal(os.Stdout)}
+	path := strings.TrimSpace(*configPath)
+	if path == "" {
+		path = "config.yaml"
+	}
+
+	cfg := doctorCheckConfig(report, path)
+	if cfg != nil {
+		doctorCheckAuths(report, cfg, *probe)
+		doctorCheckPort(report, cfg)
+		doctorCheckProxy(report, cfg)
+	}
+	doctorCheckTokenizer(report)
+	doctorCheckLogDisk(report, cfg)
+
+	failed := report.count(doctorFail)
+	_, _ = fmt.Fprintf(report.out, "\n%d passed, %d warnings, %d failed\n", report.count(doctorPass), report.count(doctorWarn), failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+func doctorCheckConfig(report *doctorReport, path string) *config.Config {
+	if _, errStat := os.Stat(path); errStat != nil {
+		report.add(doctorFail, "config", "cannot read %s: %v", path, errStat)
+		return nil
+	}
+	cfg, errLoad := config.LoadConfig(path)
+	if errLoad != nil {
+		report.add(doctorFail, "config", "%s is invalid: %v", path, errLoad)
+		return nil
+	}
+	if cfg.Port <= 0 || cfg.Port > 65535 {
+		report.add(doctorFail, "config", "port %d is out of range", cfg.Port)
+		return cfg
+	}
+	if cfg.TLS.Enable {
+		for _, file := range []string{cfg.TLS.Cert, cfg.TLS.Key} {
+			if _, errStat := os.Stat(file); errStat != nil {
+				report.add(doctorFail, "config", "TLS file %q is not readable: %v", file, errStat)
+				return cfg
+			}
+		}
+	}
+	if len(cfg.APIKeys) == 0 {
+		report.add(doctorWarn, "config", "%s loaded, but no api-keys are configured", path)
+		return cfg
+	}
+	report.add(doctorPass, "config", "%s loaded", path)
+	return cfg
+}
+
+func doctorCheckAuths(report *doctorReport, cfg *config.Config, probe bool) {
+	authDir, errResolve := util.ResolveAuthDir(cfg.AuthDir)
+	if errResolve != nil {
+		report.add(doctorFail, "auth", "cannot resolve auth-dir %q: %v", cfg.AuthDir, errResolve)
+		return
+	}
+	if _, errStat := os.Stat(authDir); errStat != nil {
+		report.add(doctorWarn, "auth", "auth-dir %s does not exist yet", authDir)
+		return
+	}
+	store := sdkAuth.NewFileTokenStore()
+	store.SetBaseDir(authDir)
+	ctx, cancel := context.WithTimeout(context.Background(), doctorProbeTimeout)
+	auths, errList := store.List(ctx)
+	cancel()
+	if errList != nil {
+		report.add(doctorFail, "auth", "cannot list credentials in %s: %v", authDir, errList)
+		return
+	}
+	apiKeyEntries := len(cfg.GeminiKey) + len(cfg.ClaudeKey) + len(cfg.CodexKey) + len(cfg.VertexCompatAPIKey) + len(cfg.OpenAICompatibility)
+	if len(auths) == 0 && apiKeyEntries == 0 {
+		report.add(doctorWarn, "auth", "no credentials found in %s and no provider API keys configured", authDir)
+		return
+	}
+	report.add(doctorPass, "auth", "%d credential files in %s, %d provider API key entries", len(auths), authDir, apiKeyEntries)
+
+	now := time.Now()
+	for _, auth := range auths {
+		if auth == nil || auth.Disabled {
+			continue
+		}
+		name := "auth:" + auth.ID
+		refreshable := hasRefreshToken(auth.Metadata)
+		if probe && refreshable {
+			doctorProbeAuth(report, cfg, store, name, auth)
+			continue
+		}
+		expiry, hasExpiry := auth.ExpirationTime()
+		switch {
+		case !hasExpiry || expiry.After(now):
+			report.add(doctorPass, name, "%s credential is current", auth.Provider)
+		case refreshable:
+			report.add(doctorWarn, name, "%s access token expired at %s; it will be refreshed on use", auth.Provider, expiry.Format(time.RFC3339))
+		default:
+			report.add(doctorFail, name, "%s credential expired at %s and cannot be refreshed", auth.Provider, expiry.Format(time.RFC3339))
+		}
+	}
+}
+
+// doctorProbeAuth refreshes a credential with its provider and persists the new tokens, since
+// some providers rotate refresh tokens on use.
+func doctorProbeAuth(report *doctorReport, cfg *config.Config, store *sdkAuth.FileTokenStore, name string, auth *coreauth.Auth) {
+	refresher := doctorRefresher(cfg, auth.Provider)
+	if refresher == nil {
+		report.add(doctorWarn, name, "live probe is not supported for %s credentials", auth.Provider)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), doctorProbeTimeout)
+	defer cancel()
+	refreshed, errRefresh := refresher.Refresh(ctx, auth.Clone())
+	if errRefresh != nil {
+		report.add(doctorFail, name, "%s refresh failed: %v", auth.Provider, errRefresh)
+		return
+	}
+	if refreshed != nil {
+		if _, errSave := store.Save(ctx, refreshed); errSave != nil {
+			report.add(doctorFail, name, "%s refreshed but saving the new tokens failed: %v", auth.Provider, errSave)
+			return
+		}
+	}
+	report.add(doctorPass, name, "%s credential refreshed successfully", auth.Provider)
+}
+
+func doctorRefresher(cfg *config.Config, provider string) interface {
+	Refresh(context.Context, *coreauth.Auth) (*coreauth.Auth, error)
+} {
+	switch strings.ToLower(provider) {
+	case "claude":
+		return executor.NewClaudeExecutor(cfg)
+	case "codex":
+		return executor.NewCodexExecutor(cfg)
+	case "gemini", "gemini-cli":
+		return executor.NewGeminiCLIExecutor(cfg)
+	case "antigravity":
+		return executor.NewAntigravityExecutor(cfg)
+	case "kimi":
+		return executor.NewKimiExecutor(cfg)
+	default:
+		return nil
+	}
+}
+
+func hasRefreshToken(metadata map[string]any) bool {
+	if token, ok := metadata["refresh_token"].(string); ok && strings.TrimSpace(token) != "" {
+		return true
+	}
+	if nested, ok := metadata["token"].(map[string]any); ok {
+		if token, okToken := nested["refresh_token"].(string); okToken && strings.TrimSpace(token) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func doctorCheckTokenizer(report *doctorReport) {
+	enc, errTokenizer := helps.TokenizerForModel("gpt-4o")
+	if errTokenizer != nil {
+		report.add(doctorFail, "tokenizer", "cannot load tokenizer: %v", errTokenizer)
+		return
+	}
+	count, errCount := enc.Count("doctor self-test")
+	if errCount != nil || count <= 0 {
+		report.add(doctorFail, "tokenizer", "tokenizer returned %d tokens: %v", count, errCount)
+		return
+	}
+	report.add(doctorPass, "tokenizer", "tokenizer available")
+}
+
+func doctorCheckLogDisk(report *doctorReport, cfg *config.Config) {
+	logDir := logging.ResolveLogDirectory(cfg)
+	dir := logDir
+	// Measure the nearest existing parent when the directory has not been created yet.
+	for {
+		if _, errStat := os.Stat(dir); errStat == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	free, errFree := freeDiskBytes(dir)
+	if errFree != nil {
+		report.add(doctorWarn, "disk", "cannot determine free space for %s: %v", logDir, errFree)
+		return
+	}
+	switch {
+	case free < doctorMinFreeDiskBytes:
+		report.add(doctorFail, "disk", "only %d MB free for logs in %s", free>>20, logDir)
+	case free < doctorLowFreeDiskBytes:
+		report.add(doctorWarn, "disk", "%d MB free for logs in %s", free>>20, logDir)
+	default:
+		report.add(doctorPass, "disk", "%d MB free for logs in %s", free>>20, logDir)
+	}
+}
+
+func doctorCheckPort(report *doctorReport, cfg *config.Config) {
+	address := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	listener, errListen := net.Listen("tcp", address)
+	if errListen != nil {
+		report.add(doctorWarn, "port", "%s is not available (is the server already running?): %v", address, errListen)
+		return
+	}
+	if errClose := listener.Close(); errClose != nil {
+		report.add(doctorWarn, "port", "%s is available but the test listener did not close: %v", address, errClose)
+		return
+	}
+	report.add(doctorPass, "port", "%s is available", address)
+}
+
+func doctorCheckProxy(report *doctorReport, cfg *config.Config) {
+	proxyURL := strings.TrimSpace(cfg.ProxyURL)
+	if proxyURL == "" {
+		report.add(doctorPass, "proxy", "no outbound proxy configured")
+		return
+	}
+	parsed, errParse := url.Parse(proxyURL)
+	if errParse != nil || parsed.Host == "" {
+		report.add(doctorFail, "proxy", "proxy-url is not a valid URL")
+		return
+	}
+	host := parsed.Host
+	if parsed.Port() == "" {
+		defaultPort := map[string]string{"http": "80", "https": "443", "socks5": "1080", "socks5h": "1080"}[strings.ToLower(parsed.Scheme)]
+		if defaultPort == "" {
+			report.add(doctorFail, "proxy", "unsupported proxy scheme %q", parsed.Scheme)
+			return
+		}
+		host = net.JoinHostPort(parsed.Hostname(), defaultPort)
+	}
+	conn, errDial := net.DialTimeout("tcp", host, doctorProbeTimeout)
+	if errDial != nil {
+		report.add(doctorFail, "proxy", "cannot reach proxy %s: %v", host, errDial)
+		return
+	}
+	_ = conn.Close()
+	report.add(doctorPass, "proxy", "proxy %s is reachable", host)
+}
+
+// isTerminal reports whether f is attached to a character device.
+func isTerminal(f *os.File) bool {
+	info, errStat := f.Stat()
+	return errStat == nil && info.Mode()&os.ModeCharDevice != 0
+}
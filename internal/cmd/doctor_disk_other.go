@@ -0,0 +1,14 @@
+//go:build !windows
+
+package cmd
+
+import "golang.org/x/sys/unix"
+
+// freeDiskBytes returns the space available to unprivileged users on the filesystem holding path.
+func freeDiskBytes(path string) (uint64, error) {
+	var stat unix.Statfs_t
+	if errStat := unix.Statfs(path, &stat); errStat != nil {
+		return 0, errStat
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package cmd
+
+import "golang.org/x/sys/windows"
+
+// freeDiskBytes returns the space available to the current user on the volume holding path.
+func freeDiskBytes(path string) (uint64, error) {
+	pathPtr, errPtr := windows.UTF16PtrFromString(path)
+	if errPtr != nil {
+		return 0, errPtr
+	}
+	var freeBytes uint64
+	if errSpace := windows.GetDiskFreeSpaceEx(pathPtr, &freeBytes, nil, nil); errSpace != nil {
+		return 0, errSpace
+	}
+	return freeBytes, nil
+}
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func newTestDoctorReport() (*doctorReport, *bytes.Buffer) {
+	var out bytes.Buffer
+	return &doctorReport{out: &out}, &out
+}
+
+func lastDoctorStatus(t *testing.T, report *doctorReport, name string) doctorStatus {
+	t.Helper()
+	for i := len(report.results) - 1; i >= 0; i-- {
+		if report.results[i].name == name {
+			return report.results[i].status
+		}
+	}
+	t.Fatalf("no %q result in %+v", name, report.results)
+	return doctorFail
+}
+
+func writeDoctorFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if errWrite := os.WriteFile(path, []byte(content), 0o600); errWrite != nil {
+		t.Fatal(errWrite)
+	}
+	return path
+}
+
+func TestDoctorCheckConfig(t *testing.T) {
+	dir := t.TempDir()
+	cases := []struct {
+		name    string
+		content string
+		want    doctorStatus
+	}{
+		{"valid", "port: 8317\napi-keys: [\"k1\"]\n", doctorPass},
+		{"no api keys", "port: 8317\n", doctorWarn},
+		{"port out of range", "port: 70000\napi-keys: [\"k1\"]\n", doctorFail},
+		{"invalid yaml", "port: [\n", doctorFail},
+		{"missing tls file", "port: 8317\ntls:\n  enable: true\n  cert: /nonexistent/cert.pem\n  key: /nonexistent/key.pem\n", doctorFail},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := writeDoctorFile(t, dir, strings.ReplaceAll(tc.name, " ", "-")+".yaml", tc.content)
+			report, _ := newTestDoctorReport()
+			doctorCheckConfig(report, path)
+			if got := lastDoctorStatus(t, report, "config"); got != tc.want {
+				t.Fatalf("status = %d, want %d (%+v)", got, tc.want, report.results)
+			}
+		})
+	}
+
+	report, _ := newTestDoctorReport()
+	if cfg := doctorCheckConfig(report, filepath.Join(dir, "missing.yaml")); cfg != nil || lastDoctorStatus(t, report, "config") != doctorFail {
+		t.Fatalf("missing config: cfg = %v, results = %+v", cfg, report.results)
+	}
+}
+
+func TestDoctorCheckAuthsReportsExpiry(t *testing.T) {
+	authDir := t.TempDir()
+	past := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	future := time.Now().Add(time.Hour).Format(time.RFC3339)
+	writeDoctorFile(t, authDir, "current.json", `{"type":"claude","access_token":"a","expired":"`+future+`"}`)
+	writeDoctorFile(t, authDir, "refreshable.json", `{"type":"codex","access_token":"a","refresh_token":"r","expired":"`+past+`"}`)
+	writeDoctorFile(t, authDir, "dead.json", `{"type":"kimi","access_token":"a","expired":"`+past+`"}`)
+
+	report, _ := newTestDoctorReport()
+	doctorCheckAuths(report, &config.Config{AuthDir: authDir}, false)
+	if got := lastDoctorStatus(t, report, "auth"); got != doctorPass {
+		t.Fatalf("auth summary = %d, results %+v", got, report.results)
+	}
+	want := map[string]doctorStatus{"current.json": doctorPass, "refreshable.json": doctorWarn, "dead.json": doctorFail}
+	for id, status := range want {
+		if got := lastDoctorStatus(t, report, "auth:"+id); got != status {
+			t.Errorf("%s = %d, want %d", id, got, status)
+		}
+	}
+
+	report, _ = newTestDoctorReport()
+	doctorCheckAuths(report, &config.Config{AuthDir: filepath.Join(authDir, "missing")}, false)
+	if got := lastDoctorStatus(t, report, "auth"); got != doctorWarn {
+		t.Fatalf("missing auth-dir = %d, want warn", got)
+	}
+}
+
+func TestHasRefreshToken(t *testing.T) {
+	cases := []struct {
+		metadata map[string]any
+		want     bool
+	}{
+		{map[string]any{"refresh_token": "r"}, true},
+		{map[string]any{"token": map[string]any{"refresh_token": "r"}}, true},
+		{map[string]any{"refresh_token": "  "}, false},
+		{nil, false},
+	}
+	for _, tc := range cases {
+		if got := hasRefreshToken(tc.metadata); got != tc.want {
+			t.Errorf("hasRefreshToken(%v) = %v, want %v", tc.metadata, got, tc.want)
+		}
+	}
+}
+
+func TestDoctorCheckPort(t *testing.T) {
+	listener, errListen := net.Listen("tcp", "127.0.0.1:0")
+	if errListen != nil {
+		t.Fatal(errListen)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	cfg := &config.Config{Host: "127.0.0.1", Port: port}
+
+	report, _ := newTestDoctorReport()
+	doctorCheckPort(report, cfg)
+	if got := lastDoctorStatus(t, report, "port"); got != doctorWarn {
+		t.Fatalf("busy port = %d, want warn", got)
+	}
+	_ = listener.Close()
+	doctorCheckPort(report, cfg)
+	if got := lastDoctorStatus(t, report, "port"); got != doctorPass {
+		t.Fatalf("free port = %d, want pass", got)
+	}
+}
+
+func TestDoctorCheckProxy(t *testing.T) {
+	listener, errListen := net.Listen("tcp", "127.0.0.1:0")
+	if errListen != nil {
+		t.Fatal(errListen)
+	}
+	defer func() { _ = listener.Close() }()
+	reachable := "http://127.0.0.1:" + strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+
+	cases := []struct {
+		proxy string
+		want  doctorStatus
+	}{
+		{"", doctorPass},
+		{reachable, doctorPass},
+		{"://bad", doctorFail},
+		{"ftp://proxy.invalid", doctorFail},
+	}
+	for _, tc := range cases {
+		report, _ := newTestDoctorReport()
+		doctorCheckProxy(report, &config.Config{SDKConfig: config.SDKConfig{ProxyURL: tc.proxy}})
+		if got := lastDoctorStatus(t, report, "proxy"); got != tc.want {
+			t.Errorf("proxy %q = %d, want %d (%+v)", tc.proxy, got, tc.want, report.results)
+		}
+	}
+}
+
+func TestDoctorCheckTokenizerAndDisk(t *testing.T) {
+	report, _ := newTestDoctorReport()
+	doctorCheckTokenizer(report)
+	if got := lastDoctorStatus(t, report, "tokenizer"); got != doctorPass {
+		t.Fatalf("tokenizer = %d, results %+v", got, report.results)
+	}
+
+	free, errFree := freeDiskBytes(t.TempDir())
+	if errFree != nil || free == 0 {
+		t.Fatalf("freeDiskBytes = %d, %v", free, errFree)
+	}
+	doctorCheckLogDisk(report, nil)
+	lastDoctorStatus(t, report, "disk")
+	if detail := report.results[len(report.results)-1].detail; strings.Contains(detail, "cannot determine") {
+		t.Fatalf("disk check could not measure free space: %s", detail)
+	}
+}
+
+func TestDoctorReportRendersAndCounts(t *testing.T) {
+	report, out := newTestDoctorReport()
+	report.add(doctorPass, "a", "ok")
+	report.add(doctorWarn, "b", "hmm %d", 1)
+	report.add(doctorFail, "c", "bad")
+	if report.count(doctorPass) != 1 || report.count(doctorWarn) != 1 || report.count(doctorFail) != 1 {
+		t.Fatalf("counts = %+v", report.results)
+	}
+	if !strings.Contains(out.String(), "[WARN] b            hmm 1") {
+		t.Fatalf("output = %q", out.String())
+	}
+	if code := RunDoctor([]string{"-unknown-flag"}, ""); code != 2 {
+		t.Fatalf("invalid flag exit code = %d, want 2", code)
+	}
+}
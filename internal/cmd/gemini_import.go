@@ -0,0 +1,70 @@
+// Package cmd contains CLI helpers. This file implements importing the OAuth credentials of an
+// existing Gemini CLI (Code Assist) login so its quota can be pooled behind the proxy.
+package cmd
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/auth/gemini"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// DoGeminiImport imports a Gemini CLI oauth_creds.json file as a "gemini" credential. The token
+// is refreshed if needed, then the project is selected and activated exactly like -login does.
+//
+// Parameters:
+//   - cfg: The application configuration
+//   - credsPath: Path to oauth_creds.json or its directory; empty selects ~/.gemini
+//   - projectID: Optional Google Cloud project ID for Gemini services
+//   - options: Login options including prompts
+func DoGeminiImport(cfg *config.Config, credsPath string, projectID string, options *LoginOptions) {
+	if options == nil {
+		options = &LoginOptions{}
+	}
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+
+	ctx := context.Background()
+
+	promptFn := options.Prompt
+	if promptFn == nil {
+		promptFn = defaultProjectPrompt()
+	}
+
+	path, errPath := gemini.ResolveCLICredentialsPath(credsPath)
+	if errPath != nil {
+		log.Errorf("gemini-import: %v", errPath)
+		return
+	}
+	data, errRead := os.ReadFile(path)
+	if errRead != nil {
+		log.Errorf("gemini-import: read file failed: %v", errRead)
+		return
+	}
+
+	geminiAuth := gemini.NewGeminiAuth()
+	storage, errImport := geminiAuth.ImportCLICredentials(ctx, cfg, data)
+	if errImport != nil {
+		log.Errorf("gemini-import: %v", errImport)
+		return
+	}
+	httpClient, errClient := geminiAuth.GetAuthenticatedClient(ctx, storage, cfg, nil)
+	if errClient != nil {
+		log.Errorf("gemini-import: %v", errClient)
+		return
+	}
+
+	log.Infof("Imported Gemini CLI credentials from %s", path)
+
+	record := &cliproxyauth.Auth{
+		Provider: "gemini",
+		Storage:  storage,
+		Metadata: map[string]any{},
+	}
+	completeGeminiLogin(ctx, cfg, record, storage, httpClient, strings.TrimSpace(projectID), promptFn)
+}
@@ -98,6 +98,13 @@ func DoLogin(cfg *config.Config, projectID string, options *LoginOptions) {
 
 	log.Info("Authentication successful.")
 
+	completeGeminiLogin(ctx, cfg, record, storage, httpClient, trimmedProjectID, promptFn)
+}
+
+// completeGeminiLogin selects and activates the Code Assist projects for an authenticated Gemini
+// account, verifies the Cloud AI API is enabled, and saves the credential to the token store.
+// trimmedProjectID preselects the projects; promptFn asks the user otherwise.
+func completeGeminiLogin(ctx context.Context, cfg *config.Config, record *cliproxyauth.Auth, storage *gemini.GeminiTokenStorage, httpClient *http.Client, trimmedProjectID string, promptFn func(string) (string, error)) {
 	var activatedProjects []string
 
 	useGoogleOne := false
@@ -12,28 +12,48 @@ import (
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/daemon"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy"
 	log "github.com/sirupsen/logrus"
 )
 
+// windowsServiceName is the name registered with the Windows service control manager.
+const windowsServiceName = "CLIProxyAPI"
+
 // StartService builds and runs the proxy service using the exported SDK.
 // It creates a new proxy service instance, sets up signal handling for graceful shutdown,
-// and starts the service with the provided configuration.
+// and starts the service with the provided configuration. When launched by the Windows
+// service control manager, the service lifecycle is driven by SCM stop requests instead.
 //
 // Parameters:
 //   - cfg: The application configuration
 //   - configPath: The path to the configuration file
 //   - localPassword: Optional password accepted for local management requests
 func StartService(cfg *config.Config, configPath string, localPassword string) {
+	handled, errService := daemon.RunWindowsService(windowsServiceName, func(ctx context.Context) {
+		runService(ctx, cfg, configPath, localPassword)
+	})
+	if errService != nil {
+		log.Errorf("failed to run as windows service: %v", errService)
+	}
+	if handled {
+		return
+	}
+
+	ctxSignal, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	runService(ctxSignal, cfg, configPath, localPassword)
+}
+
+// runService builds the proxy service and blocks until ctx is cancelled or the service exits.
+func runService(ctxSignal context.Context, cfg *config.Config, configPath string, localPassword string) {
 	builder := cliproxy.NewBuilder().
 		WithConfig(cfg).
 		WithConfigPath(configPath).
 		WithLocalManagementPassword(localPassword)
 
-	ctxSignal, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
-	defer cancel()
-
 	runCtx := ctxSignal
 	if localPassword != "" {
 		var keepAliveCancel context.CancelFunc
@@ -44,6 +64,12 @@ func StartService(cfg *config.Config, configPath string, localPassword string) {
 		}))
 	}
 
+	if cfg.PrivacyMode {
+		if errKey := usage.LoadAnonymizationKey(cfg.AuthDir); errKey != nil {
+			log.WithError(errKey).Warn("failed to load anonymization key; anonymized identifiers will change on restart")
+		}
+	}
+
 	statsPath := usage.StatsFilePath(cfg.AuthDir)
 	if cfg.UsageStatisticsEnabled && cfg.UsageStatisticsPersistEnabled {
 		if statsPath == "" {
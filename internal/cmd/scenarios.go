@@ -0,0 +1,421 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/api"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// scenarioDefaultTimeout bounds a single scenario request.
+	scenarioDefaultTimeout = 60 * time.Second
+	// scenarioStartupTimeout bounds how long the standalone server may take to answer /ping.
+	scenarioStartupTimeout = 15 * time.Second
+	// scenarioDefaultPrompt is sent when a scenario specifies neither prompt nor request.
+	scenarioDefaultPrompt = "Reply with the single word: pong"
+)
+
+// ScenarioFile is the document read by "test run".
+type ScenarioFile struct {
+	// BaseURL is the proxy to test; it defaults to the configured host and port.
+	BaseURL string `yaml:"base-url,omitempty"`
+	// APIKey authenticates requests; it defaults to the first configured api-key.
+	APIKey    string     `yaml:"api-key,omitempty"`
+	Scenarios []Scenario `yaml:"scenarios"`
+}
+
+// Scenario is one request sent to the proxy together with the expectations on its response.
+type Scenario struct {
+	Name string `yaml:"name"`
+	// Dialect selects the client API: openai, openai-response, claude or gemini.
+	Dialect string `yaml:"dialect"`
+	Model   string `yaml:"model"`
+	Stream  bool   `yaml:"stream,omitempty"`
+	// Prompt builds a single user message when Request is empty.
+	Prompt string `yaml:"prompt,omitempty"`
+	// Request is the request body; model and stream are filled in when absent.
+	Request map[string]any    `yaml:"request,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Expect  ScenarioExpect    `yaml:"expect,omitempty"`
+}
+
+// ScenarioExpect lists the assertions checked against a scenario response.
+type ScenarioExpect struct {
+	// Status is the expected HTTP status; it defaults to 200.
+	Status int `yaml:"status,omitempty"`
+	// Model is the model reported in the response body.
+	Model string `yaml:"model,omitempty"`
+	// Provider is the upstream provider reported by the X-CLIProxy-Provider header, which
+	// requires response-metadata-headers on the tested instance.
+	Provider    string   `yaml:"provider,omitempty"`
+	Contains    []string `yaml:"contains,omitempty"`
+	NotContains []string `yaml:"not-contains,omitempty"`
+}
+
+// RunTest dispatches the "test" subcommand. "test run <scenarios.yaml>" executes declarative
+// end-to-end scenarios against a running instance, or against an embedded server started
+// from the configuration with -standalone, and returns 0 when every scenario passed, 1 when
+// at least one failed and 2 on invalid arguments.
+//
+// Parameters:
+//   - args: Command-line arguments following the test subcommand
+//   - defaultConfigPath: The configuration file used when -config is not given
+//
+// Returns:
+//   - int: The process exit code
+func RunTest(args []string, defaultConfigPath string) int {
+	if len(args) == 0 || args[0] != "run" {
+		fmt.Fprintln(os.Stderr, "usage: test run [flags] <scenarios.yaml>")
+		return 2
+	}
+	fs := flag.NewFlagSet("test run", flag.ContinueOnError)
+	configPath := fs.String("config", defaultConfigPath, "Configure File Path")
+	baseURL := fs.String("url", "", "Proxy base URL (overrides base-url and the configured address)")
+	apiKey := fs.String("api-key", "", "API key used for requests (overrides api-key and the configured keys)")
+	standalone := fs.Bool("standalone", false, "Start an embedded server from the configuration instead of targeting a running instance")
+	timeout := fs.Duration("timeout", scenarioDefaultTimeout, "Timeout for each scenario request")
+	noColor := fs.Bool("no-color", false, "Disable colored output")
+	if errParse := fs.Parse(args[1:]); errParse != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: test run [flags] <scenarios.yaml>")
+		return 2
+	}
+
+	file, errLoad := loadScenarioFile(fs.Arg(0))
+	if errLoad != nil {
+		fmt.Fprintf(os.Stderr, "test: %v\n", errLoad)
+		return 2
+	}
+
+	path := strings.TrimSpace(*configPath)
+	if path == "" {
+		path = "config.yaml"
+	}
+	var cfg *config.Config
+	if _, errStat := os.Stat(path); errStat == nil {
+		loaded, errCfg := config.LoadConfigOptional(path, false)
+		if errCfg != nil {
+			fmt.Fprintf(os.Stderr, "test: failed to load config: %v\n", errCfg)
+			return 2
+		}
+		cfg = loaded
+	} else if *standalone {
+		fmt.Fprintf(os.Stderr, "test: -standalone requires a readable config: %v\n", errStat)
+		return 2
+	}
+
+	target := strings.TrimSpace(*baseURL)
+	if *standalone {
+		stop, addr, errStart := startScenarioServer(cfg, path)
+		if errStart != nil {
+			fmt.Fprintf(os.Stderr, "test: %v\n", errStart)
+			return 1
+		}
+		defer stop()
+		target = addr
+	}
+	if target == "" {
+		target = strings.TrimSpace(file.BaseURL)
+	}
+	if target == "" {
+		target = strings.TrimSuffix(healthcheckURL(cfg), api.PingPath)
+	}
+	key := strings.TrimSpace(*apiKey)
+	if key == "" {
+		key = strings.TrimSpace(file.APIKey)
+	}
+	if key == "" && cfg != nil && len(cfg.APIKeys) > 0 {
+		key = cfg.APIKeys[0]
+	}
+
+	runner := &scenarioRunner{
+		baseURL: strings.TrimRight(target, "/"),
+		apiKey:  key,
+		client: &http.Client{
+			Timeout: *timeout,
+			Transport: &http.Transport{
+				// Scenarios usually target a local listener with a self-signed certificate.
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		},
+	}
+	report := &doctorReport{out: os.Stdout, color: !*noColor && isTerminal(os.Stdout)}
+	for i, scenario := range file.Scenarios {
+		name := strings.TrimSpace(scenario.Name)
+		if name == "" {
+			name = "scenario-" + strconv.Itoa(i+1)
+		}
+		if problems := runner.run(scenario); len(problems) > 0 {
+			report.add(doctorFail, name, "%s", strings.Join(problems, "; "))
+		} else {
+			report.add(doctorPass, name, "%s %s", scenario.Dialect, scenario.Model)
+		}
+	}
+
+	failed := report.count(doctorFail)
+	_, _ = fmt.Fprintf(report.out, "\n%d passed, %d failed\n", report.count(doctorPass), failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// loadScenarioFile reads and validates a scenario document.
+func loadScenarioFile(path string) (*ScenarioFile, error) {
+	data, errRead := os.ReadFile(path)
+	if errRead != nil {
+		return nil, fmt.Errorf("read scenarios: %w", errRead)
+	}
+	var file ScenarioFile
+	if errUnmarshal := yaml.Unmarshal(data, &file); errUnmarshal != nil {
+		return nil, fmt.Errorf("parse scenarios: %w", errUnmarshal)
+	}
+	if len(file.Scenarios) == 0 {
+		return nil, fmt.Errorf("%s defines no scenarios", path)
+	}
+	for i := range file.Scenarios {
+		scenario := &file.Scenarios[i]
+		scenario.Dialect = strings.ToLower(strings.TrimSpace(scenario.Dialect))
+		if scenario.Dialect == "" {
+			scenario.Dialect = "openai"
+		}
+		if _, ok := scenarioEndpoints[scenario.Dialect]; !ok {
+			return nil, fmt.Errorf("scenario %d: unsupported dialect %q", i+1, scenario.Dialect)
+		}
+		if strings.TrimSpace(scenario.Model) == "" {
+			return nil, fmt.Errorf("scenario %d: model is required", i+1)
+		}
+	}
+	return &file, nil
+}
+
+// startScenarioServer starts the proxy in the background on a free loopback port with
+// response metadata headers enabled, and waits until it answers /ping.
+func startScenarioServer(cfg *config.Config, configPath string) (stop func(), baseURL string, err error) {
+	listener, errListen := net.Listen("tcp", "127.0.0.1:0")
+	if errListen != nil {
+		return nil, "", fmt.Errorf("reserve a port for the standalone server: %w", errListen)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	if errClose := listener.Close(); errClose != nil {
+		return nil, "", fmt.Errorf("release reserved port: %w", errClose)
+	}
+
+	cfg.Host = "127.0.0.1"
+	cfg.Port = port
+	cfg.TLS.Enable = false
+	cfg.ResponseMetadataHeaders = true
+	cancel, done := StartServiceBackground(cfg, configPath, "")
+	stop = func() {
+		cancel()
+		<-done
+	}
+
+	baseURL = "http://" + net.JoinHostPort(cfg.Host, strconv.Itoa(port))
+	client := &http.Client{Timeout: time.Second}
+	deadline := time.Now().Add(scenarioStartupTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-done:
+			return nil, "", fmt.Errorf("standalone server exited during startup")
+		default:
+		}
+		resp, errGet := client.Get(baseURL + api.PingPath)
+		if errGet == nil {
+			_ = resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return stop, baseURL, nil
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	stop()
+	return nil, "", fmt.Errorf("standalone server did not become ready within %s", scenarioStartupTimeout)
+}
+
+// scenarioEndpoint describes how a dialect is addressed. inBody reports whether the model
+// and stream flag travel in the request body rather than in the path.
+type scenarioEndpoint struct {
+	path   func(model string, stream bool) string
+	body   func(prompt string) map[string]any
+	inBody bool
+}
+
+var scenarioEndpoints = map[string]scenarioEndpoint{
+	"openai": {
+		path: func(string, bool) string { return "/v1/chat/completions" },
+		body: func(prompt string) map[string]any {
+			return map[string]any{"messages": []any{map[string]any{"role": "user", "content": prompt}}}
+		},
+		inBody: true,
+	},
+	"openai-response": {
+		path:   func(string, bool) string { return "/v1/responses" },
+		body:   func(prompt string) map[string]any { return map[string]any{"input": prompt} },
+		inBody: true,
+	},
+	"claude": {
+		path: func(string, bool) string { return "/v1/messages" },
+		body: func(prompt string) map[string]any {
+			return map[string]any{"max_tokens": 256, "messages": []any{map[string]any{"role": "user", "content": prompt}}}
+		},
+		inBody: true,
+	},
+	"gemini": {
+		path: func(model string, stream bool) string {
+			if stream {
+				return "/v1beta/models/" + url.PathEscape(model) + ":streamGenerateContent?alt=sse"
+			}
+			return "/v1beta/models/" + url.PathEscape(model) + ":generateContent"
+		},
+		body: func(prompt string) map[string]any {
+			return map[string]any{"contents": []any{map[string]any{"role": "user", "parts": []any{map[string]any{"text": prompt}}}}}
+		},
+	},
+}
+
+// scenarioRunner sends scenarios to one proxy instance.
+type scenarioRunner struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// run executes one scenario and returns the failed expectations.
+func (r *scenarioRunner) run(scenario Scenario) []string {
+	endpoint := scenarioEndpoints[scenario.Dialect]
+	body := scenario.Request
+	if len(body) == 0 {
+		prompt := scenario.Prompt
+		if strings.TrimSpace(prompt) == "" {
+			prompt = scenarioDefaultPrompt
+		}
+		body = endpoint.body(prompt)
+	}
+	if endpoint.inBody {
+		if _, ok := body["model"]; !ok {
+			body["model"] = scenario.Model
+		}
+		if _, ok := body["stream"]; !ok && scenario.Stream {
+			body["stream"] = true
+		}
+	}
+	payload, errMarshal := json.Marshal(body)
+	if errMarshal != nil {
+		return []string{fmt.Sprintf("encode request: %v", errMarshal)}
+	}
+
+	req, errReq := http.NewRequestWithContext(context.Background(), http.MethodPost, r.baseURL+endpoint.path(scenario.Model, scenario.Stream), bytes.NewReader(payload))
+	if errReq != nil {
+		return []string{fmt.Sprintf("build request: %v", errReq)}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+r.apiKey)
+	}
+	for key, value := range scenario.Headers {
+		req.Header.Set(key, value)
+	}
+	resp, errDo := r.client.Do(req)
+	if errDo != nil {
+		return []string{fmt.Sprintf("request failed: %v", errDo)}
+	}
+	defer func() {
+		if errClose := resp.Body.Close(); errClose != nil {
+			log.Debugf("test: failed to close response body: %v", errClose)
+		}
+	}()
+	data, errRead := io.ReadAll(resp.Body)
+	if errRead != nil {
+		return []string{fmt.Sprintf("read response: %v", errRead)}
+	}
+	return checkScenario(scenario.Expect, resp.StatusCode, resp.Header, data)
+}
+
+// checkScenario compares a response against the scenario expectations.
+func checkScenario(expect ScenarioExpect, status int, header http.Header, body []byte) []string {
+	var problems []string
+	wantStatus := expect.Status
+	if wantStatus == 0 {
+		wantStatus = http.StatusOK
+	}
+	if status != wantStatus {
+		problems = append(problems, fmt.Sprintf("status %d, want %d: %s", status, wantStatus, truncateScenarioBody(body)))
+	}
+	if want := strings.TrimSpace(expect.Model); want != "" {
+		if got := responseModel(body); got != want {
+			problems = append(problems, fmt.Sprintf("model %q, want %q", got, want))
+		}
+	}
+	if want := strings.ToLower(strings.TrimSpace(expect.Provider)); want != "" {
+		got := header.Get(handlers.ResponseProviderHeader)
+		switch {
+		case got == "":
+			problems = append(problems, "provider header missing; enable response-metadata-headers on the tested instance")
+		case got != want:
+			problems = append(problems, fmt.Sprintf("provider %q, want %q", got, want))
+		}
+	}
+	for _, needle := range expect.Contains {
+		if !bytes.Contains(body, []byte(needle)) {
+			problems = append(problems, fmt.Sprintf("response does not contain %q", needle))
+		}
+	}
+	for _, needle := range expect.NotContains {
+		if bytes.Contains(body, []byte(needle)) {
+			problems = append(problems, fmt.Sprintf("response contains %q", needle))
+		}
+	}
+	return problems
+}
+
+// responseModel returns the first model name reported in a JSON or SSE response body.
+func responseModel(body []byte) string {
+	payloads := [][]byte{body}
+	if !gjson.ValidBytes(body) {
+		payloads = payloads[:0]
+		for _, line := range bytes.Split(body, []byte("\n")) {
+			line = bytes.TrimSpace(line)
+			if after, ok := bytes.CutPrefix(line, []byte("data:")); ok {
+				payloads = append(payloads, bytes.TrimSpace(after))
+			}
+		}
+	}
+	for _, payload := range payloads {
+		for _, path := range []string{"model", "message.model", "response.model", "modelVersion"} {
+			if model := gjson.GetBytes(payload, path).String(); model != "" {
+				return model
+			}
+		}
+	}
+	return ""
+}
+
+func truncateScenarioBody(body []byte) string {
+	const limit = 200
+	text := strings.TrimSpace(string(body))
+	if len(text) > limit {
+		return text[:limit] + "..."
+	}
+	return text
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestScenarioRunnerChecksExpectations(t *testing.T) {
+	var gotBody map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" || r.Header.Get("Authorization") != "Bearer k1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(data, &gotBody)
+		w.Header().Set("X-CLIProxy-Provider", "claude")
+		_, _ = io.WriteString(w, "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"model\":\"claude-x\"}}\n\n"+
+			"event: content_block_start\ndata: {\"type\":\"content_block_start\",\"content_block\":{\"type\":\"tool_use\"}}\n\n")
+	}))
+	defer server.Close()
+
+	runner := &scenarioRunner{baseURL: server.URL, apiKey: "k1", client: server.Client()}
+	scenario := Scenario{
+		Dialect: "claude",
+		Model:   "claude-x",
+		Stream:  true,
+		Expect:  ScenarioExpect{Model: "claude-x", Provider: "claude", Contains: []string{"tool_use"}},
+	}
+	if problems := runner.run(scenario); len(problems) != 0 {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+	if gotBody["model"] != "claude-x" || gotBody["stream"] != true || gotBody["max_tokens"] == nil {
+		t.Fatalf("request body = %v", gotBody)
+	}
+
+	scenario.Expect = ScenarioExpect{Provider: "codex", NotContains: []string{"tool_use"}, Model: "other"}
+	problems := runner.run(scenario)
+	if len(problems) != 3 {
+		t.Fatalf("problems = %v, want provider, model and not-contains failures", problems)
+	}
+	if !strings.Contains(strings.Join(problems, ";"), `provider "claude", want "codex"`) {
+		t.Fatalf("problems = %v", problems)
+	}
+}
+
+func TestResponseModelReadsJSONAndSSE(t *testing.T) {
+	if got := responseModel([]byte(`{"modelVersion":"gemini-2.5-pro"}`)); got != "gemini-2.5-pro" {
+		t.Fatalf("gemini model = %q", got)
+	}
+	if got := responseModel([]byte("data: {\"type\":\"response.created\",\"response\":{\"model\":\"gpt-5\"}}\n\ndata: [DONE]\n")); got != "gpt-5" {
+		t.Fatalf("responses model = %q", got)
+	}
+}
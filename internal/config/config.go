@@ -10,10 +10,12 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"regexp"
 	"strings"
 	"syscall"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/routingscript"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/crypto/bcrypt"
 	"gopkg.in/yaml.v3"
@@ -36,6 +38,24 @@ type Config struct {
 	// TLS config controls HTTPS server settings.
 	TLS TLSConfig `yaml:"tls" json:"tls"`
 
+	// Compression controls gzip/deflate handling of request and response bodies.
+	Compression CompressionConfig `yaml:"compression,omitempty" json:"compression,omitempty"`
+
+	// Concurrency caps the number of in-flight API requests server-wide and per client API key.
+	Concurrency ConcurrencyConfig `yaml:"concurrency,omitempty" json:"concurrency,omitempty"`
+
+	// Idempotency replays the stored response for requests repeating an Idempotency-Key header.
+	Idempotency IdempotencyConfig `yaml:"idempotency,omitempty" json:"idempotency,omitempty"`
+
+	// FailureDiagnostics keeps the most recent failed requests for inspection via the management API.
+	FailureDiagnostics FailureDiagnosticsConfig `yaml:"failure-diagnostics,omitempty" json:"failure-diagnostics,omitempty"`
+
+	// CredentialQuarantine disables credentials whose upstream errors look like a ban or account flag.
+	CredentialQuarantine CredentialQuarantineConfig `yaml:"credential-quarantine,omitempty" json:"credential-quarantine,omitempty"`
+
+	// StreamWatch controls the live per-request chunk feed served by the management API.
+	StreamWatch StreamWatchConfig `yaml:"stream-watch,omitempty" json:"stream-watch,omitempty"`
+
 	// RemoteManagement nests management-related options under 'remote-management'.
 	RemoteManagement RemoteManagement `yaml:"remote-management" json:"-"`
 
@@ -72,6 +92,9 @@ type Config struct {
 	// UsageStatisticsDetailRetentionDays controls how many days of detailed request information to retain in persistence.
 	// Details older than this threshold are stripped during save. When <= 0, defaults to 30 days.
 	UsageStatisticsDetailRetentionDays int `yaml:"usage-statistics-detail-retention-days" json:"usage-statistics-detail-retention-days"`
+	// PrivacyMode hashes client API keys and credential sources in usage statistics and omits
+	// client IP addresses and User-Agent headers from access and request logs.
+	PrivacyMode bool `yaml:"privacy-mode,omitempty" json:"privacy-mode,omitempty"`
 
 	// DisableCooling disables quota cooldown scheduling when true.
 	DisableCooling bool `yaml:"disable-cooling" json:"disable-cooling"`
@@ -87,6 +110,33 @@ type Config struct {
 	MaxRetryCredentials int `yaml:"max-retry-credentials" json:"max-retry-credentials"`
 	// MaxRetryInterval defines the maximum wait time in seconds before retrying a cooled-down credential.
 	MaxRetryInterval int `yaml:"max-retry-interval" json:"max-retry-interval"`
+	// UpstreamRetry retries transient upstream failures on the same credential before a
+	// response reaches the client.
+	UpstreamRetry UpstreamRetryConfig `yaml:"upstream-retry,omitempty" json:"upstream-retry,omitempty"`
+	// Timeouts bounds how long executors wait to connect to an upstream, for its first response
+	// bytes and for the whole request.
+	Timeouts TimeoutConfig `yaml:"timeouts,omitempty" json:"timeouts,omitempty"`
+	// HTTPPool tunes the connection pools shared by executors for each provider, proxy and auth.
+	HTTPPool HTTPPoolConfig `yaml:"http-pool,omitempty" json:"http-pool,omitempty"`
+	// UpstreamCompression advertises gzip and brotli to upstreams for non-streaming requests and
+	// decompresses the responses before executors read them.
+	UpstreamCompression bool `yaml:"upstream-compression,omitempty" json:"upstream-compression,omitempty"`
+	// Interceptors attach registered request/response interceptors to the upstream calls of
+	// matching providers, in list order.
+	Interceptors []InterceptorConfig `yaml:"interceptors,omitempty" json:"interceptors,omitempty"`
+
+	// CircuitBreaker removes credentials, and whole providers, from rotation after consecutive
+	// upstream failures and probes them again after a cooldown.
+	CircuitBreaker CircuitBreakerConfig `yaml:"circuit-breaker,omitempty" json:"circuit-breaker,omitempty"`
+
+	// ResponseMode overrides, per provider, whether requests are sent upstream as streams.
+	ResponseMode ResponseModeConfig `yaml:"response-mode,omitempty" json:"response-mode,omitempty"`
+
+	// StreamResume opts providers into re-issuing streams that break off on a transient error.
+	StreamResume StreamResumeConfig `yaml:"stream-resume,omitempty" json:"stream-resume,omitempty"`
+
+	// ConcurrencyLimit caps the upstream requests each credential has in flight.
+	ConcurrencyLimit ConcurrencyLimitConfig `yaml:"concurrency-limit,omitempty" json:"concurrency-limit,omitempty"`
 
 	// QuotaExceeded defines the behavior when a quota is exceeded.
 	QuotaExceeded QuotaExceeded `yaml:"quota-exceeded" json:"quota-exceeded"`
@@ -128,6 +178,23 @@ type Config struct {
 	// Used for services that use Vertex AI-style paths but with simple API key authentication.
 	VertexCompatAPIKey []VertexCompatKey `yaml:"vertex-api-key" json:"vertex-api-key"`
 
+	// VertexCredentials defines Vertex AI projects reached with a service account key file or
+	// Application Default Credentials (including workload identity).
+	VertexCredentials []VertexCredential `yaml:"vertex,omitempty" json:"vertex,omitempty"`
+
+	// AzureOpenAIKey defines Azure OpenAI resources, whose models are served by named deployments.
+	AzureOpenAIKey []AzureOpenAIKey `yaml:"azure-openai,omitempty" json:"azure-openai,omitempty"`
+
+	// BedrockKey defines AWS Bedrock credentials for Anthropic and other Bedrock-hosted models.
+	BedrockKey []BedrockKey `yaml:"bedrock,omitempty" json:"bedrock,omitempty"`
+
+	// OllamaKey defines Ollama servers, reached through their native chat and generate APIs.
+	OllamaKey []OllamaKey `yaml:"ollama,omitempty" json:"ollama,omitempty"`
+
+	// MockProvider defines offline providers that generate deterministic or scripted responses
+	// for testing client integrations, translators, and failover without upstream calls.
+	MockProvider []MockProvider `yaml:"mock-provider,omitempty" json:"mock-provider,omitempty"`
+
 	// AmpCode contains Amp CLI upstream configuration, management restrictions, and model mappings.
 	AmpCode AmpCode `yaml:"ampcode" json:"ampcode"`
 
@@ -142,9 +209,25 @@ type Config struct {
 	// gemini-api-key, codex-api-key, claude-api-key, openai-compatibility, vertex-api-key, and ampcode.
 	OAuthModelAlias map[string][]OAuthModelAlias `yaml:"oauth-model-alias,omitempty" json:"oauth-model-alias,omitempty"`
 
+	// ModelMetadata adds context window, output limit, modality and pricing details to the
+	// model listings, overriding values discovered from providers.
+	ModelMetadata []registry.ModelMetadata `yaml:"model-metadata,omitempty" json:"model-metadata,omitempty"`
+
 	// Payload defines default and override rules for provider payload parameters.
 	Payload PayloadConfig `yaml:"payload" json:"payload"`
 
+	// Translators declares runtime adjustments to the translator registry.
+	Translators TranslatorConfig `yaml:"translators,omitempty" json:"translators,omitempty"`
+
+	// HistoryNormalization repairs common conversation history mistakes before requests are sent upstream.
+	HistoryNormalization HistoryNormalizationConfig `yaml:"history-normalization,omitempty" json:"history-normalization,omitempty"`
+
+	// CountTokens selects how count_tokens requests are answered for each provider.
+	CountTokens CountTokensConfig `yaml:"count-tokens,omitempty" json:"count-tokens,omitempty"`
+
+	// ImageInlining downloads remote image URLs and sends them as base64 to providers that need it.
+	ImageInlining ImageInliningConfig `yaml:"image-inlining,omitempty" json:"image-inlining,omitempty"`
+
 	legacyMigrationPending bool `yaml:"-" json:"-"`
 }
 
@@ -181,6 +264,224 @@ type TLSConfig struct {
 	Key string `yaml:"key" json:"key"`
 }
 
+// CompressionConfig holds HTTP body compression settings.
+// Compressed request bodies (Content-Encoding: gzip or deflate) are always accepted.
+type CompressionConfig struct {
+	// Enable compresses non-streaming responses when the client sends a matching Accept-Encoding.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Streaming also compresses text/event-stream responses, flushing after every event.
+	Streaming bool `yaml:"streaming,omitempty" json:"streaming,omitempty"`
+	// MaxRequestBodyMB caps the decompressed size of a compressed request body.
+	// Larger bodies are rejected with 413. <= 0 uses the default of 32 MB.
+	MaxRequestBodyMB int `yaml:"max-request-body-mb,omitempty" json:"max-request-body-mb,omitempty"`
+}
+
+// ConcurrencyConfig limits how many API requests may be processed at the same time.
+// Requests over a limit wait in a bounded queue and are rejected with 429 and Retry-After
+// when the queue is full or the wait times out.
+type ConcurrencyConfig struct {
+	// MaxRequests caps in-flight requests across all clients. <= 0 disables the global cap.
+	MaxRequests int `yaml:"max-requests,omitempty" json:"max-requests,omitempty"`
+	// PerKeyMaxRequests caps in-flight requests for each client API key. It can be overridden
+	// with api-key-policies[].max-concurrent-requests. <= 0 disables the per-key cap.
+	PerKeyMaxRequests int `yaml:"per-key-max-requests,omitempty" json:"per-key-max-requests,omitempty"`
+	// MaxQueue is the number of requests allowed to wait for a free slot. <= 0 rejects immediately.
+	MaxQueue int `yaml:"max-queue,omitempty" json:"max-queue,omitempty"`
+	// QueueTimeoutSeconds bounds how long a queued request waits. <= 0 uses the default of 30 seconds.
+	QueueTimeoutSeconds int `yaml:"queue-timeout-seconds,omitempty" json:"queue-timeout-seconds,omitempty"`
+	// RetryAfterSeconds is the Retry-After value sent with 429 responses. <= 0 uses the default of 1 second.
+	RetryAfterSeconds int `yaml:"retry-after-seconds,omitempty" json:"retry-after-seconds,omitempty"`
+}
+
+// IdempotencyConfig controls request de-duplication by Idempotency-Key header.
+type IdempotencyConfig struct {
+	// Enable turns on Idempotency-Key handling for API requests.
+	Enable bool `yaml:"enable" json:"enable"`
+	// TTLSeconds is how long a completed response can be replayed. <= 0 uses the default of 600 seconds.
+	TTLSeconds int `yaml:"ttl-seconds,omitempty" json:"ttl-seconds,omitempty"`
+	// MaxEntries caps the number of stored responses. <= 0 uses the default of 1000.
+	MaxEntries int `yaml:"max-entries,omitempty" json:"max-entries,omitempty"`
+	// MaxResponseBytes caps the size of a stored response; larger responses are not replayed.
+	// <= 0 uses the default of 8 MiB.
+	MaxResponseBytes int `yaml:"max-response-bytes,omitempty" json:"max-response-bytes,omitempty"`
+}
+
+// FailureDiagnosticsConfig controls the bounded store of recent request failures.
+type FailureDiagnosticsConfig struct {
+	// Enable records failed requests with their upstream status, error excerpt, credential
+	// and translation path.
+	Enable bool `yaml:"enable" json:"enable"`
+	// MaxEntries caps how many failures are retained. <= 0 uses the default of 200.
+	MaxEntries int `yaml:"max-entries,omitempty" json:"max-entries,omitempty"`
+	// PersistFile optionally mirrors the store to a JSON lines file so it survives restarts.
+	// Relative paths are resolved against the configuration file directory.
+	PersistFile string `yaml:"persist-file,omitempty" json:"persist-file,omitempty"`
+}
+
+// StreamWatchConfig controls the live chunk feed that management clients can attach to for a
+// single in-flight request.
+type StreamWatchConfig struct {
+	// RedactContent replaces message text, tool arguments and other free-form strings in
+	// watched chunks with their length, keeping only the event structure. Privacy mode
+	// always redacts.
+	RedactContent bool `yaml:"redact-content" json:"redact-content"`
+	// BufferSize is how many chunks may queue for a slow watcher before chunks are dropped.
+	// <= 0 uses the default of 256.
+	BufferSize int `yaml:"buffer-size,omitempty" json:"buffer-size,omitempty"`
+}
+
+// CredentialQuarantineConfig controls automatic quarantine of credentials that appear to be
+// banned, suspended or challenged by the upstream provider.
+type CredentialQuarantineConfig struct {
+	// Enable turns on quarantine detection for failed upstream requests.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Patterns lists the detection rules. When empty, built-in patterns are used.
+	Patterns []QuarantinePattern `yaml:"patterns,omitempty" json:"patterns,omitempty"`
+}
+
+// QuarantinePattern matches an upstream error that should quarantine the credential.
+type QuarantinePattern struct {
+	// Provider restricts the pattern to one provider (e.g. "claude", "codex"). Empty matches all.
+	Provider string `yaml:"provider,omitempty" json:"provider,omitempty"`
+	// StatusCodes lists the HTTP statuses the pattern applies to. Empty matches 401 and 403.
+	StatusCodes []int `yaml:"status-codes,omitempty" json:"status-codes,omitempty"`
+	// Match is a case-insensitive regular expression applied to the upstream error body.
+	Match string `yaml:"match" json:"match"`
+}
+
+// UpstreamRetryConfig controls how executors retry transient upstream failures: network errors
+// and retryable statuses are retried with exponential backoff and jitter, honoring Retry-After.
+type UpstreamRetryConfig struct {
+	// MaxAttempts is the total number of attempts for one upstream request. <= 1 disables retries.
+	MaxAttempts int `yaml:"max-attempts,omitempty" json:"max-attempts,omitempty"`
+	// InitialBackoffMS is the delay before the first retry; later delays double. <= 0 uses the
+	// default of 500 ms.
+	InitialBackoffMS int `yaml:"initial-backoff-ms,omitempty" json:"initial-backoff-ms,omitempty"`
+	// MaxBackoffMS caps each delay. A Retry-After beyond it is not waited for and the response is
+	// returned as is. <= 0 uses the default of 10000 ms.
+	MaxBackoffMS int `yaml:"max-backoff-ms,omitempty" json:"max-backoff-ms,omitempty"`
+	// Jitter randomizes each backoff delay by up to this fraction of it (0-1). <= 0 uses the
+	// default of 0.2.
+	Jitter float64 `yaml:"jitter,omitempty" json:"jitter,omitempty"`
+	// RetryableStatusCodes lists the upstream statuses to retry. Empty uses 408, 429, 500, 502,
+	// 503 and 504.
+	RetryableStatusCodes []int `yaml:"retryable-status-codes,omitempty" json:"retryable-status-codes,omitempty"`
+}
+
+// TimeoutConfig holds the upstream timeouts applied by executors, with optional per-provider
+// overrides. Clients may override them per request with the X-CLIProxy-Timeout header.
+type TimeoutConfig struct {
+	TimeoutSettings `yaml:",inline"`
+	// Providers overrides the settings by provider identifier (e.g. "claude", "codex", "ollama"
+	// or an openai-compatibility provider name). Unset fields keep the global value.
+	Providers map[string]TimeoutSettings `yaml:"providers,omitempty" json:"providers,omitempty"`
+}
+
+// TimeoutSettings lists the timeout phases of an upstream request. <= 0 disables a phase.
+type TimeoutSettings struct {
+	// ConnectSeconds bounds establishing the connection, including the TLS handshake.
+	ConnectSeconds int `yaml:"connect-seconds,omitempty" json:"connect-seconds,omitempty"`
+	// FirstByteSeconds bounds the wait for the response headers once the request is sent.
+	FirstByteSeconds int `yaml:"first-byte-seconds,omitempty" json:"first-byte-seconds,omitempty"`
+	// TotalSeconds bounds the whole request, including reading a streamed response.
+	TotalSeconds int `yaml:"total-seconds,omitempty" json:"total-seconds,omitempty"`
+}
+
+// HTTPPoolConfig tunes the pooled upstream transports. Executors reuse one transport per
+// provider, proxy and auth, so connections and TLS sessions survive across requests.
+type HTTPPoolConfig struct {
+	// MaxIdleConns caps the idle connections kept by one pooled transport. <= 0 uses 256.
+	MaxIdleConns int `yaml:"max-idle-conns,omitempty" json:"max-idle-conns,omitempty"`
+	// MaxIdleConnsPerHost caps the idle connections kept per upstream host. <= 0 uses 32.
+	MaxIdleConnsPerHost int `yaml:"max-idle-conns-per-host,omitempty" json:"max-idle-conns-per-host,omitempty"`
+	// IdleConnTimeoutSeconds closes connections idle for longer. <= 0 uses 90.
+	IdleConnTimeoutSeconds int `yaml:"idle-conn-timeout-seconds,omitempty" json:"idle-conn-timeout-seconds,omitempty"`
+}
+
+// InterceptorConfig attaches one interceptor to the upstream calls of a provider.
+type InterceptorConfig struct {
+	// Provider matches a provider identifier (e.g. "claude") or an openai-compatibility name;
+	// "*" matches every provider.
+	Provider string `yaml:"provider" json:"provider"`
+	// Name is the registered interceptor name, such as "set-headers" or "patch-json".
+	Name string `yaml:"name" json:"name"`
+	// Options configure the interceptor; their meaning depends on Name.
+	Options map[string]string `yaml:"options,omitempty" json:"options,omitempty"`
+}
+
+// CircuitBreakerConfig controls the circuit breakers kept per credential and per provider.
+// Network errors, timeouts, 408 and 5xx responses count as failures; a success closes the breaker.
+type CircuitBreakerConfig struct {
+	// Enable turns the circuit breakers on.
+	Enable bool `yaml:"enable" json:"enable"`
+	// FailureThreshold is the number of consecutive failures that opens a credential's breaker.
+	// <= 0 uses the default of 5.
+	FailureThreshold int `yaml:"failure-threshold,omitempty" json:"failure-threshold,omitempty"`
+	// ProviderFailureThreshold is the number of consecutive failures across all credentials of a
+	// provider that opens the provider's breaker. <= 0 disables provider breakers.
+	ProviderFailureThreshold int `yaml:"provider-failure-threshold,omitempty" json:"provider-failure-threshold,omitempty"`
+	// CooldownSeconds is how long an open breaker keeps traffic away before a single probe
+	// request is let through. <= 0 uses the default of 30.
+	CooldownSeconds int `yaml:"cooldown-seconds,omitempty" json:"cooldown-seconds,omitempty"`
+	// MaxCooldownSeconds caps the cooldown, which doubles each time a probe fails. <= 0 uses the
+	// default of 600.
+	MaxCooldownSeconds int `yaml:"max-cooldown-seconds,omitempty" json:"max-cooldown-seconds,omitempty"`
+}
+
+// ResponseModeConfig adapts the upstream response mode for providers that only behave well in
+// one mode. Entries match a provider identifier (e.g. "claude") or an openai-compatibility name.
+type ResponseModeConfig struct {
+	// ForceStream lists providers whose non-streaming requests are sent upstream as streams and
+	// aggregated into a single response before reaching the client.
+	ForceStream []string `yaml:"force-stream,omitempty" json:"force-stream,omitempty"`
+
+	// ForceNonStream lists providers whose streaming requests are sent upstream as non-streaming;
+	// the client stream is synthesized from the full response.
+	ForceNonStream []string `yaml:"force-non-stream,omitempty" json:"force-non-stream,omitempty"`
+
+	// StreamChunkSize is the maximum number of characters per synthesized text delta.
+	// Zero or negative sends each text in a single delta.
+	StreamChunkSize int `yaml:"stream-chunk-size,omitempty" json:"stream-chunk-size,omitempty"`
+
+	// StreamChunkIntervalMS is the pause, in milliseconds, between synthesized stream chunks.
+	StreamChunkIntervalMS int `yaml:"stream-chunk-interval-ms,omitempty" json:"stream-chunk-interval-ms,omitempty"`
+}
+
+// StreamResumeConfig re-issues upstream streams that fail with a connection reset, timeout or
+// 5xx error. Entries match a provider identifier (e.g. "claude") or an openai-compatibility name.
+type StreamResumeConfig struct {
+	// Retry lists providers whose streams are re-issued on the same credential when they fail
+	// transiently before any payload reached the client. It is scoped per provider, unlike the
+	// global streaming.bootstrap-retries.
+	Retry []string `yaml:"retry,omitempty" json:"retry,omitempty"`
+
+	// Continue lists providers whose streams are also resumed after text was forwarded: the
+	// request is re-issued with that text as an assistant prefix and the new stream is spliced
+	// onto the old one. It applies to OpenAI chat and Claude clients; other streams are retried
+	// only before the first payload.
+	Continue []string `yaml:"continue,omitempty" json:"continue,omitempty"`
+
+	// MaxAttempts bounds the re-issued requests per stream. Zero or negative uses the default of 2.
+	MaxAttempts int `yaml:"max-attempts,omitempty" json:"max-attempts,omitempty"`
+}
+
+// ConcurrencyLimitConfig caps in-flight upstream requests per credential for accounts that
+// throttle concurrent use. Requests beyond the cap queue for a free slot.
+type ConcurrencyLimitConfig struct {
+	// MaxInFlight is the number of concurrent upstream requests, streams included, allowed per
+	// credential. Zero or negative disables the limit. An auth can override it with the
+	// attribute max_in_flight.
+	MaxInFlight int `yaml:"max-in-flight,omitempty" json:"max-in-flight,omitempty"`
+
+	// MaxQueue bounds the requests waiting for a slot per credential; further requests move on to
+	// another credential at once. Zero or negative leaves the queue unbounded.
+	MaxQueue int `yaml:"max-queue,omitempty" json:"max-queue,omitempty"`
+
+	// QueueTimeoutSeconds is how long a request waits for a slot before it moves on to another
+	// credential. Zero or negative uses the default of 30.
+	QueueTimeoutSeconds int `yaml:"queue-timeout-seconds,omitempty" json:"queue-timeout-seconds,omitempty"`
+}
+
 // PprofConfig holds pprof HTTP server settings.
 type PprofConfig struct {
 	// Enable toggles the pprof HTTP debug server.
@@ -355,6 +656,144 @@ type PayloadModelRule struct {
 	Protocol string `yaml:"protocol" json:"protocol"`
 }
 
+// MockProvider configures one offline mock provider credential.
+type MockProvider struct {
+	// Name identifies the mock credential; it must be unique across entries.
+	Name string `yaml:"name" json:"name"`
+
+	// Prefix optionally namespaces the mock models (e.g., "mock/echo").
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// Models lists the model IDs served by this mock provider.
+	Models []string `yaml:"models" json:"models"`
+
+	// LatencyMs delays the first byte of every response.
+	LatencyMs int `yaml:"latency-ms,omitempty" json:"latency-ms,omitempty"`
+
+	// TokensPerSecond throttles streamed output; <= 0 streams as fast as possible.
+	TokensPerSecond int `yaml:"tokens-per-second,omitempty" json:"tokens-per-second,omitempty"`
+
+	// Responses lists canned replies served in rotation. When empty, the last user
+	// message is echoed back.
+	Responses []string `yaml:"responses,omitempty" json:"responses,omitempty"`
+
+	// ToolCalls lists canned tool calls returned instead of text when non-empty.
+	ToolCalls []MockToolCall `yaml:"tool-calls,omitempty" json:"tool-calls,omitempty"`
+
+	// FailEvery makes every Nth request fail with ErrorStatus; <= 0 disables error injection.
+	FailEvery int `yaml:"fail-every,omitempty" json:"fail-every,omitempty"`
+
+	// ErrorStatus is the HTTP status returned for injected failures. Defaults to 500.
+	ErrorStatus int `yaml:"error-status,omitempty" json:"error-status,omitempty"`
+
+	// MalformedEvery makes every Nth streamed response include a malformed chunk; <= 0 disables it.
+	MalformedEvery int `yaml:"malformed-every,omitempty" json:"malformed-every,omitempty"`
+}
+
+// MockToolCall describes a canned tool call returned by a mock provider.
+type MockToolCall struct {
+	// Name is the tool name.
+	Name string `yaml:"name" json:"name"`
+	// Arguments is the JSON-encoded argument object.
+	Arguments string `yaml:"arguments,omitempty" json:"arguments,omitempty"`
+}
+
+// CountTokensConfig selects between provider count endpoints and the local tokenizer estimate.
+type CountTokensConfig struct {
+	// Providers maps a provider (e.g., "claude", "gemini", "vertex") to "upstream", which calls
+	// the provider's count endpoint, or "local", which uses the local tokenizer estimate.
+	// Providers with a count endpoint default to "upstream"; the others always count locally.
+	Providers map[string]string `yaml:"providers,omitempty" json:"providers,omitempty"`
+	// FallbackToLocal answers with the local estimate when an upstream count fails.
+	FallbackToLocal bool `yaml:"fallback-to-local,omitempty" json:"fallback-to-local,omitempty"`
+}
+
+// ImageInliningConfig controls the request-side step that downloads remote images and inlines
+// them as base64 before translation, for providers that do not fetch image URLs themselves.
+type ImageInliningConfig struct {
+	// Enabled turns the step on.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Providers lists the providers (executor identifiers or openai-compatibility names) whose
+	// requests get their images inlined. Defaults to claude.
+	Providers []string `yaml:"providers,omitempty" json:"providers,omitempty"`
+	// MaxBytes caps the size of one downloaded image. Defaults to 5 MiB.
+	MaxBytes int64 `yaml:"max-bytes,omitempty" json:"max-bytes,omitempty"`
+	// AllowedTypes lists the accepted image media types. Defaults to PNG, JPEG, GIF and WebP.
+	AllowedTypes []string `yaml:"allowed-types,omitempty" json:"allowed-types,omitempty"`
+	// TimeoutSeconds bounds each download. Defaults to 10.
+	TimeoutSeconds int `yaml:"timeout-seconds,omitempty" json:"timeout-seconds,omitempty"`
+	// AllowPrivateNetworks permits downloads from loopback, private and link-local addresses,
+	// which are refused by default to prevent server-side request forgery.
+	AllowPrivateNetworks bool `yaml:"allow-private-networks,omitempty" json:"allow-private-networks,omitempty"`
+}
+
+// TranslatorConfig declares translator pairs and overrides that are applied at runtime.
+type TranslatorConfig struct {
+	// Disabled lists registered translator pairs to switch off; matching requests are passed through.
+	Disabled []TranslatorPair `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+	// Dialects declares additional target formats derived from a built-in translator.
+	Dialects []TranslatorDialect `yaml:"dialects,omitempty" json:"dialects,omitempty"`
+	// MissingPair selects how requests without a registered translator are handled: empty
+	// forwards them unchanged, "passthrough" forwards them only between formats sharing a
+	// schema and "reject" fails them.
+	MissingPair string `yaml:"missing-pair,omitempty" json:"missing-pair,omitempty"`
+	// Plugins lists Go plugins loaded at startup that register translators for custom formats.
+	Plugins []TranslatorPlugin `yaml:"plugins,omitempty" json:"plugins,omitempty"`
+}
+
+// TranslatorPlugin declares a Go plugin that registers translators for a custom format.
+type TranslatorPlugin struct {
+	// Path is the plugin file built with -buildmode=plugin.
+	Path string `yaml:"path" json:"path"`
+	// Format is the target format name the plugin registers its translators under.
+	Format string `yaml:"format" json:"format"`
+}
+
+// TranslatorPair identifies a translator direction between two formats.
+type TranslatorPair struct {
+	// From is the client-facing source format (e.g., "claude").
+	From string `yaml:"from" json:"from"`
+	// To is the upstream target format (e.g., "openai").
+	To string `yaml:"to" json:"to"`
+}
+
+// TranslatorDialect declares a new target format that reuses the translator registered
+// for From -> Base and rewrites the translated request for an upstream variant.
+type TranslatorDialect struct {
+	// From restricts the dialect to one source format; empty or "*" applies to every source.
+	From string `yaml:"from,omitempty" json:"from,omitempty"`
+	// To is the name of the new target format referenced by providers.
+	To string `yaml:"to" json:"to"`
+	// Base is the built-in target format whose translators are reused (e.g., "openai").
+	Base string `yaml:"base" json:"base"`
+	// Rename moves values between JSON paths in the translated request.
+	Rename map[string]string `yaml:"rename,omitempty" json:"rename,omitempty"`
+	// Delete removes JSON paths from the translated request.
+	Delete []string `yaml:"delete,omitempty" json:"delete,omitempty"`
+	// Set writes values at JSON paths in the translated request.
+	Set map[string]any `yaml:"set,omitempty" json:"set,omitempty"`
+}
+
+// HistoryNormalizationConfig controls the repair pass applied to translated conversation history.
+type HistoryNormalizationConfig struct {
+	// Enabled turns on the repair pass using the built-in strictness rules of each target protocol.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Protocols overrides the built-in rules per target protocol
+	// (e.g., "claude", "openai", "gemini", "codex", "antigravity").
+	Protocols map[string]HistoryNormalizationRule `yaml:"protocols,omitempty" json:"protocols,omitempty"`
+}
+
+// HistoryNormalizationRule selects the repairs applied for one target protocol.
+// Unset fields fall back to the protocol's built-in strictness rules.
+type HistoryNormalizationRule struct {
+	// DropOrphanToolResults removes tool results that have no matching earlier tool call.
+	DropOrphanToolResults *bool `yaml:"drop-orphan-tool-results,omitempty" json:"drop-orphan-tool-results,omitempty"`
+	// MergeConsecutiveRoles merges adjacent messages that share the same role.
+	MergeConsecutiveRoles *bool `yaml:"merge-consecutive-roles,omitempty" json:"merge-consecutive-roles,omitempty"`
+	// DropEmptyContent removes messages whose content is empty.
+	DropEmptyContent *bool `yaml:"drop-empty-content,omitempty" json:"drop-empty-content,omitempty"`
+}
+
 // CloakConfig configures request cloaking for non-Claude-Code clients.
 // Cloaking disguises API requests to appear as originating from the official Claude Code CLI.
 type CloakConfig struct {
@@ -395,6 +834,10 @@ type ClaudeKey struct {
 	// If empty, the default Claude API URL will be used.
 	BaseURL string `yaml:"base-url" json:"base-url"`
 
+	// FallbackBaseURLs lists endpoints tried in order when BaseURL fails with a connect error or
+	// a 5xx response.
+	FallbackBaseURLs []string `yaml:"fallback-base-urls,omitempty" json:"fallback-base-urls,omitempty"`
+
 	// ProxyURL overrides the global proxy setting for this API key if provided.
 	ProxyURL string `yaml:"proxy-url" json:"proxy-url"`
 
@@ -448,6 +891,10 @@ type CodexKey struct {
 	// If empty, the default Codex API URL will be used.
 	BaseURL string `yaml:"base-url" json:"base-url"`
 
+	// FallbackBaseURLs lists endpoints tried in order when BaseURL fails with a connect error or
+	// a 5xx response.
+	FallbackBaseURLs []string `yaml:"fallback-base-urls,omitempty" json:"fallback-base-urls,omitempty"`
+
 	// Websockets enables the Responses API websocket transport for this credential.
 	Websockets bool `yaml:"websockets,omitempty" json:"websockets,omitempty"`
 
@@ -479,6 +926,161 @@ type CodexModel struct {
 func (m CodexModel) GetName() string  { return m.Name }
 func (m CodexModel) GetAlias() string { return m.Alias }
 
+// AzureOpenAIKey represents the configuration of an Azure OpenAI resource and its API key.
+type AzureOpenAIKey struct {
+	// APIKey is the key of the Azure OpenAI resource, sent in the api-key header.
+	APIKey string `yaml:"api-key" json:"api-key"`
+
+	// Priority controls selection preference when multiple credentials match.
+	// Higher values are preferred; defaults to 0.
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+
+	// Prefix optionally namespaces models for this credential (e.g., "teamA/gpt-4o").
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// BaseURL is the endpoint of the resource, e.g. "https://my-resource.openai.azure.com".
+	BaseURL string `yaml:"base-url" json:"base-url"`
+
+	// APIVersion is the api-version query parameter sent with every request.
+	// Defaults to "2024-10-21".
+	APIVersion string `yaml:"api-version,omitempty" json:"api-version,omitempty"`
+
+	// ProxyURL overrides the global proxy setting for this API key if provided.
+	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// Models maps client-visible model names to the deployments that serve them.
+	Models []AzureOpenAIModel `yaml:"models" json:"models"`
+
+	// Headers optionally adds extra HTTP headers for requests sent with this key.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+}
+
+func (k AzureOpenAIKey) GetAPIKey() string  { return k.APIKey }
+func (k AzureOpenAIKey) GetBaseURL() string { return k.BaseURL }
+
+// AzureOpenAIModel maps a client-visible model name to an Azure OpenAI deployment.
+type AzureOpenAIModel struct {
+	// Deployment is the name of the deployment requests are sent to.
+	Deployment string `yaml:"deployment" json:"deployment"`
+
+	// Alias is the model name clients use, usually the deployed model such as "gpt-4o".
+	// It also selects the tokenizer for local token counting.
+	Alias string `yaml:"alias" json:"alias"`
+}
+
+func (m AzureOpenAIModel) GetName() string  { return m.Deployment }
+func (m AzureOpenAIModel) GetAlias() string { return m.Alias }
+
+// BedrockKey represents AWS credentials for the Bedrock runtime in one region. Requests are
+// signed with SigV4 using the access key pair, or sent with a Bedrock API key as bearer token.
+type BedrockKey struct {
+	// AccessKeyID and SecretAccessKey are the IAM credentials used for SigV4 signing.
+	AccessKeyID     string `yaml:"access-key-id,omitempty" json:"access-key-id,omitempty"`
+	SecretAccessKey string `yaml:"secret-access-key,omitempty" json:"secret-access-key,omitempty"`
+
+	// SessionToken is the optional session token of temporary credentials.
+	SessionToken string `yaml:"session-token,omitempty" json:"session-token,omitempty"`
+
+	// APIKey is a Bedrock API key, used instead of the access key pair when set.
+	APIKey string `yaml:"api-key,omitempty" json:"api-key,omitempty"`
+
+	// Region is the AWS region of the Bedrock runtime, e.g. "us-east-1".
+	Region string `yaml:"region" json:"region"`
+
+	// BaseURL optionally overrides the regional endpoint, e.g. for VPC endpoints.
+	BaseURL string `yaml:"base-url,omitempty" json:"base-url,omitempty"`
+
+	// Priority controls selection preference when multiple credentials match.
+	// Higher values are preferred; defaults to 0.
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+
+	// Prefix optionally namespaces models for this credential (e.g., "bedrock/claude-sonnet-4").
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// ProxyURL overrides the global proxy setting for this credential if provided.
+	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// Models maps client-visible model names to Bedrock model or inference profile IDs.
+	Models []BedrockModel `yaml:"models" json:"models"`
+
+	// Headers optionally adds extra HTTP headers for requests sent with this credential.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+}
+
+// GetAPIKey returns the identity of the credential: the API key, or the access key ID.
+func (k BedrockKey) GetAPIKey() string {
+	if k.APIKey != "" {
+		return k.APIKey
+	}
+	return k.AccessKeyID
+}
+func (k BedrockKey) GetBaseURL() string { return k.BaseURL }
+
+// BedrockModel maps a client-visible model name to a Bedrock model ID.
+type BedrockModel struct {
+	// Name is the Bedrock model or inference profile ID, e.g.
+	// "us.anthropic.claude-sonnet-4-20250514-v1:0" or "meta.llama3-3-70b-instruct-v1:0".
+	Name string `yaml:"name" json:"name"`
+
+	// Alias is the model name clients use.
+	Alias string `yaml:"alias" json:"alias"`
+}
+
+func (m BedrockModel) GetName() string  { return m.Name }
+func (m BedrockModel) GetAlias() string { return m.Alias }
+
+// DefaultOllamaBaseURL is the address of a local Ollama server.
+const DefaultOllamaBaseURL = "http://localhost:11434"
+
+// OllamaKey represents an Ollama server. Requests use its native /api/chat endpoint, or
+// /api/generate for models configured with the generate endpoint.
+type OllamaKey struct {
+	// APIKey is an optional bearer token, for servers behind an authenticating proxy.
+	APIKey string `yaml:"api-key,omitempty" json:"api-key,omitempty"`
+
+	// Priority controls selection preference when multiple credentials match.
+	// Higher values are preferred; defaults to 0.
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+
+	// Prefix optionally namespaces models for this server (e.g., "local/llama3.2").
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// BaseURL is the address of the server. Defaults to "http://localhost:11434".
+	BaseURL string `yaml:"base-url,omitempty" json:"base-url,omitempty"`
+
+	// AutoPull pulls a model the server does not have yet and retries the request, instead
+	// of failing it.
+	AutoPull bool `yaml:"auto-pull,omitempty" json:"auto-pull,omitempty"`
+
+	// ProxyURL overrides the global proxy setting for this server if provided.
+	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// Models lists the Ollama models served and the names clients use for them.
+	Models []OllamaModel `yaml:"models" json:"models"`
+
+	// Headers optionally adds extra HTTP headers for requests sent to this server.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+}
+
+func (k OllamaKey) GetAPIKey() string  { return k.APIKey }
+func (k OllamaKey) GetBaseURL() string { return k.BaseURL }
+
+// OllamaModel maps a client-visible model name to an Ollama model.
+type OllamaModel struct {
+	// Name is the Ollama model, e.g. "llama3.2" or "qwen3:8b".
+	Name string `yaml:"name" json:"name"`
+
+	// Alias is the model name clients use. Defaults to Name.
+	Alias string `yaml:"alias,omitempty" json:"alias,omitempty"`
+
+	// Endpoint selects the Ollama API: "chat" (default) or "generate", which sends the
+	// conversation as a single prompt for models without a chat template.
+	Endpoint string `yaml:"endpoint,omitempty" json:"endpoint,omitempty"`
+}
+
+func (m OllamaModel) GetName() string  { return m.Name }
+func (m OllamaModel) GetAlias() string { return m.Alias }
+
 // GeminiKey represents the configuration for a Gemini API key,
 // including optional overrides for upstream base URL, proxy routing, and headers.
 type GeminiKey struct {
@@ -495,6 +1097,10 @@ type GeminiKey struct {
 	// BaseURL optionally overrides the Gemini API endpoint.
 	BaseURL string `yaml:"base-url,omitempty" json:"base-url,omitempty"`
 
+	// FallbackBaseURLs lists endpoints tried in order when BaseURL fails with a connect error or
+	// a 5xx response.
+	FallbackBaseURLs []string `yaml:"fallback-base-urls,omitempty" json:"fallback-base-urls,omitempty"`
+
 	// ProxyURL optionally overrides the global proxy for this API key.
 	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
 
@@ -542,6 +1148,24 @@ type OpenAICompatibility struct {
 	// BaseURL is the base URL for the external OpenAI-compatible API endpoint.
 	BaseURL string `yaml:"base-url" json:"base-url"`
 
+	// FallbackBaseURLs lists endpoints tried in order when BaseURL fails with a connect error or
+	// a 5xx response.
+	FallbackBaseURLs []string `yaml:"fallback-base-urls,omitempty" json:"fallback-base-urls,omitempty"`
+
+	// ChatPath overrides the path appended to BaseURL for Chat Completions requests
+	// (default "/chat/completions"). "{model}" is replaced with the upstream model name, for
+	// deployment-style APIs such as "/openai/deployments/{model}/chat/completions?api-version=...".
+	ChatPath string `yaml:"chat-path,omitempty" json:"chat-path,omitempty"`
+
+	// CompactPath overrides the path appended to BaseURL for compact Responses requests
+	// (default "/responses/compact"). It accepts the same "{model}" placeholder as ChatPath.
+	CompactPath string `yaml:"compact-path,omitempty" json:"compact-path,omitempty"`
+
+	// AuthStyle selects how API keys are sent: "bearer" (default) as an Authorization bearer
+	// token, "none" to send no key, or the name of a header that carries the raw key, such as
+	// "api-key" or "x-api-key".
+	AuthStyle string `yaml:"auth-style,omitempty" json:"auth-style,omitempty"`
+
 	// APIKeyEntries defines API keys with optional per-key proxy configuration.
 	APIKeyEntries []OpenAICompatibilityAPIKey `yaml:"api-key-entries,omitempty" json:"api-key-entries,omitempty"`
 
@@ -550,6 +1174,24 @@ type OpenAICompatibility struct {
 
 	// Headers optionally adds extra HTTP headers for requests sent to this provider.
 	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// Translator optionally selects a dialect declared under translators.dialects, or a format
+	// registered by a translator plugin,
+	// instead of the plain "openai" format.
+	Translator string `yaml:"translator,omitempty" json:"translator,omitempty"`
+
+	// ToolResultImages controls how images inside tool results are sent to this provider:
+	// "inline" (default) keeps image parts in the tool message, "user-message" moves them
+	// into a user message after the tool results, and "placeholder" replaces them with text.
+	ToolResultImages string `yaml:"tool-result-images,omitempty" json:"tool-result-images,omitempty"`
+
+	// ToolResultImagePlaceholder overrides the text used in place of tool result images.
+	ToolResultImagePlaceholder string `yaml:"tool-result-image-placeholder,omitempty" json:"tool-result-image-placeholder,omitempty"`
+
+	// PromptCache controls cache_control markers for providers that support prompt caching:
+	// empty (default) drops markers translated from Claude requests, "passthrough" forwards
+	// them, and "auto" also marks large system prompts of requests that carry no markers.
+	PromptCache string `yaml:"prompt-cache,omitempty" json:"prompt-cache,omitempty"`
 }
 
 // OpenAICompatibilityAPIKey represents an API key configuration with optional proxy setting.
@@ -695,9 +1337,21 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Sanitize Vertex-compatible API keys.
 	cfg.SanitizeVertexCompatKeys()
 
+	// Sanitize Vertex credentials: drop entries without a project.
+	cfg.SanitizeVertexCredentials()
+
 	// Sanitize Codex keys: drop entries without base-url
 	cfg.SanitizeCodexKeys()
 
+	// Sanitize Azure OpenAI keys: drop entries without base-url
+	cfg.SanitizeAzureOpenAIKeys()
+
+	// Sanitize Bedrock keys: drop entries without region or credentials
+	cfg.SanitizeBedrockKeys()
+
+	// Sanitize Ollama keys: default the base-url of local servers
+	cfg.SanitizeOllamaKeys()
+
 	// Sanitize Codex header defaults.
 	cfg.SanitizeCodexHeaderDefaults()
 
@@ -719,6 +1373,15 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Validate raw payload rules and drop invalid entries.
 	cfg.SanitizePayloadRules()
 
+	// Compile routing script expressions and drop invalid rules.
+	cfg.SanitizeRoutingScripts()
+
+	// Validate credential quarantine patterns and drop invalid entries.
+	cfg.SanitizeCredentialQuarantine()
+
+	// Normalize model metadata overrides.
+	cfg.SanitizeModelMetadata()
+
 	// NOTE: Legacy migration persistence is intentionally disabled together with
 	// startup legacy migration to keep startup read-only for config.yaml.
 	// Re-enable the block below if automatic startup migration is needed again.
@@ -793,6 +1456,79 @@ func payloadRawString(value any) ([]byte, bool) {
 	}
 }
 
+// SanitizeRoutingScripts drops routing-scripts rules whose expression does not compile
+// or which specify no action, and caches the compiled expression of the others.
+func (cfg *Config) SanitizeRoutingScripts() {
+	if cfg == nil || len(cfg.RoutingScripts) == 0 {
+		return
+	}
+	out := make([]RoutingScript, 0, len(cfg.RoutingScripts))
+	for i := range cfg.RoutingScripts {
+		rule := cfg.RoutingScripts[i]
+		rule.When = strings.TrimSpace(rule.When)
+		rule.Model = strings.TrimSpace(rule.Model)
+		rule.Provider = strings.ToLower(strings.TrimSpace(rule.Provider))
+		rule.AuthLabel = strings.TrimSpace(rule.AuthLabel)
+		fields := log.Fields{"rule_index": i + 1, "name": rule.Name}
+		if rule.Model == "" && rule.Provider == "" && rule.AuthLabel == "" && rule.Reject == "" {
+			log.WithFields(fields).Warn("routing script dropped: no action configured")
+			continue
+		}
+		program, errCompile := routingscript.Compile(rule.When)
+		if errCompile != nil {
+			log.WithFields(fields).Warnf("routing script dropped: %v", errCompile)
+			continue
+		}
+		rule.program = program
+		out = append(out, rule)
+	}
+	cfg.RoutingScripts = out
+}
+
+// SanitizeCredentialQuarantine normalizes quarantine patterns and drops entries whose
+// expression is empty or does not compile.
+func (cfg *Config) SanitizeCredentialQuarantine() {
+	if cfg == nil || len(cfg.CredentialQuarantine.Patterns) == 0 {
+		return
+	}
+	patterns := cfg.CredentialQuarantine.Patterns
+	out := make([]QuarantinePattern, 0, len(patterns))
+	for i := range patterns {
+		pattern := patterns[i]
+		pattern.Provider = strings.ToLower(strings.TrimSpace(pattern.Provider))
+		pattern.Match = strings.TrimSpace(pattern.Match)
+		fields := log.Fields{"pattern_index": i + 1, "provider": pattern.Provider}
+		if pattern.Match == "" {
+			log.WithFields(fields).Warn("credential quarantine pattern dropped: empty match")
+			continue
+		}
+		if _, errCompile := regexp.Compile("(?i)" + pattern.Match); errCompile != nil {
+			log.WithFields(fields).Warnf("credential quarantine pattern dropped: %v", errCompile)
+			continue
+		}
+		out = append(out, pattern)
+	}
+	cfg.CredentialQuarantine.Patterns = out
+}
+
+// SanitizeModelMetadata trims model metadata entries and drops those without a model.
+func (cfg *Config) SanitizeModelMetadata() {
+	if cfg == nil || len(cfg.ModelMetadata) == 0 {
+		return
+	}
+	out := make([]registry.ModelMetadata, 0, len(cfg.ModelMetadata))
+	for i := range cfg.ModelMetadata {
+		entry := cfg.ModelMetadata[i]
+		entry.Model = strings.TrimSpace(entry.Model)
+		if entry.Model == "" {
+			log.WithField("entry_index", i+1).Warn("model metadata entry dropped: missing model")
+			continue
+		}
+		out = append(out, entry)
+	}
+	cfg.ModelMetadata = out
+}
+
 // SanitizeCodexHeaderDefaults trims surrounding whitespace from the
 // configured Codex header fallback values.
 func (cfg *Config) SanitizeCodexHeaderDefaults() {
@@ -868,6 +1604,9 @@ func (cfg *Config) SanitizeOpenAICompatibility() {
 		e.Name = strings.TrimSpace(e.Name)
 		e.Prefix = normalizeModelPrefix(e.Prefix)
 		e.BaseURL = strings.TrimSpace(e.BaseURL)
+		e.ChatPath = strings.TrimSpace(e.ChatPath)
+		e.CompactPath = strings.TrimSpace(e.CompactPath)
+		e.AuthStyle = strings.TrimSpace(e.AuthStyle)
 		e.Headers = NormalizeHeaders(e.Headers)
 		if e.BaseURL == "" {
 			// Skip providers with no base-url; treated as removed
@@ -899,6 +1638,76 @@ func (cfg *Config) SanitizeCodexKeys() {
 	cfg.CodexKey = out
 }
 
+// SanitizeAzureOpenAIKeys removes Azure OpenAI entries missing a BaseURL.
+// It trims whitespace and preserves order for remaining entries.
+func (cfg *Config) SanitizeAzureOpenAIKeys() {
+	if cfg == nil || len(cfg.AzureOpenAIKey) == 0 {
+		return
+	}
+	out := make([]AzureOpenAIKey, 0, len(cfg.AzureOpenAIKey))
+	for i := range cfg.AzureOpenAIKey {
+		e := cfg.AzureOpenAIKey[i]
+		e.APIKey = strings.TrimSpace(e.APIKey)
+		e.Prefix = normalizeModelPrefix(e.Prefix)
+		e.BaseURL = strings.TrimSpace(e.BaseURL)
+		e.APIVersion = strings.TrimSpace(e.APIVersion)
+		e.Headers = NormalizeHeaders(e.Headers)
+		if e.BaseURL == "" {
+			continue
+		}
+		out = append(out, e)
+	}
+	cfg.AzureOpenAIKey = out
+}
+
+// SanitizeBedrockKeys removes Bedrock entries missing a region or credentials.
+// It trims whitespace and preserves order for remaining entries.
+func (cfg *Config) SanitizeBedrockKeys() {
+	if cfg == nil || len(cfg.BedrockKey) == 0 {
+		return
+	}
+	out := make([]BedrockKey, 0, len(cfg.BedrockKey))
+	for i := range cfg.BedrockKey {
+		e := cfg.BedrockKey[i]
+		e.AccessKeyID = strings.TrimSpace(e.AccessKeyID)
+		e.SecretAccessKey = strings.TrimSpace(e.SecretAccessKey)
+		e.SessionToken = strings.TrimSpace(e.SessionToken)
+		e.APIKey = strings.TrimSpace(e.APIKey)
+		e.Region = strings.TrimSpace(e.Region)
+		e.BaseURL = strings.TrimSpace(e.BaseURL)
+		e.Prefix = normalizeModelPrefix(e.Prefix)
+		e.Headers = NormalizeHeaders(e.Headers)
+		if e.Region == "" || (e.APIKey == "" && (e.AccessKeyID == "" || e.SecretAccessKey == "")) {
+			continue
+		}
+		out = append(out, e)
+	}
+	cfg.BedrockKey = out
+}
+
+// SanitizeOllamaKeys trims Ollama entries and defaults their BaseURL to the local server.
+func (cfg *Config) SanitizeOllamaKeys() {
+	if cfg == nil || len(cfg.OllamaKey) == 0 {
+		return
+	}
+	for i := range cfg.OllamaKey {
+		e := &cfg.OllamaKey[i]
+		e.APIKey = strings.TrimSpace(e.APIKey)
+		e.Prefix = normalizeModelPrefix(e.Prefix)
+		e.BaseURL = strings.TrimSuffix(strings.TrimSpace(e.BaseURL), "/")
+		if e.BaseURL == "" {
+			e.BaseURL = DefaultOllamaBaseURL
+		}
+		e.Headers = NormalizeHeaders(e.Headers)
+		for j := range e.Models {
+			model := &e.Models[j]
+			model.Name = strings.TrimSpace(model.Name)
+			model.Alias = strings.TrimSpace(model.Alias)
+			model.Endpoint = strings.ToLower(strings.TrimSpace(model.Endpoint))
+		}
+	}
+}
+
 // SanitizeClaudeKeys normalizes headers for Claude credentials.
 func (cfg *Config) SanitizeClaudeKeys() {
 	if cfg == nil || len(cfg.ClaudeKey) == 0 {
@@ -0,0 +1,23 @@
+package config
+
+import "testing"
+
+func TestSanitizeRoutingScripts_CompilesOnce(t *testing.T) {
+	cfg := &Config{SDKConfig: SDKConfig{RoutingScripts: []RoutingScript{
+		{Name: "ok", When: ` model startsWith "claude-" `, Model: "gemini-2.5-pro"},
+		{Name: "broken", When: `model >`, Model: "x"},
+		{Name: "no-action", When: `stream`},
+	}}}
+
+	cfg.SanitizeRoutingScripts()
+
+	if len(cfg.RoutingScripts) != 1 || cfg.RoutingScripts[0].Name != "ok" {
+		t.Fatalf("expected only the valid rule to remain, got %+v", cfg.RoutingScripts)
+	}
+	rule := &cfg.RoutingScripts[0]
+	first, errFirst := rule.Program()
+	second, errSecond := rule.Program()
+	if errFirst != nil || errSecond != nil || first == nil || first != second {
+		t.Fatalf("expected the cached program to be reused, got %p %v / %p %v", first, errFirst, second, errSecond)
+	}
+}
@@ -4,6 +4,8 @@
 // debug settings, proxy configuration, and API keys.
 package config
 
+import "github.com/router-for-me/CLIProxyAPI/v6/internal/routingscript"
+
 // SDKConfig represents the application's configuration, loaded from a YAML file.
 type SDKConfig struct {
 	// ProxyURL is the URL of an optional proxy server to use for outbound requests.
@@ -40,16 +42,106 @@ type SDKConfig struct {
 	// APIKeys is a list of keys for authenticating clients to this proxy server.
 	APIKeys []string `yaml:"api-keys" json:"api-keys"`
 
+	// APIKeyPolicies restricts the models and request parameters available to individual client API keys.
+	APIKeyPolicies []APIKeyPolicy `yaml:"api-key-policies,omitempty" json:"api-key-policies,omitempty"`
+
+	// AllowRoutingPinHeaders lets clients pin a request to a provider or credential with the
+	// X-CLIProxy-Provider and X-CLIProxy-Auth-Label headers, bypassing normal routing.
+	// Only enable it when every client holding an API key is trusted. Default is false.
+	AllowRoutingPinHeaders bool `yaml:"allow-routing-pin-headers,omitempty" json:"allow-routing-pin-headers,omitempty"`
+
+	// RoutingScripts are evaluated in order for every request; the first rule whose expression
+	// matches rewrites the model, restricts the provider, pins a credential or rejects the request.
+	RoutingScripts []RoutingScript `yaml:"routing-scripts,omitempty" json:"routing-scripts,omitempty"`
+
+	// RequestValidation enables schema validation of inbound payloads before translation;
+	// malformed requests are then rejected with a 400 in the caller's dialect. Default is false.
+	RequestValidation bool `yaml:"request-validation,omitempty" json:"request-validation,omitempty"`
+
 	// PassthroughHeaders controls whether upstream response headers are forwarded to downstream clients.
 	// Default is false (disabled).
 	PassthroughHeaders bool `yaml:"passthrough-headers" json:"passthrough-headers"`
 
+	// ResponseMetadataHeaders adds X-CLIProxy-* headers to proxied responses describing the provider,
+	// credential hash, upstream latency, retry count, translation path and request ID.
+	// Default is false (disabled).
+	ResponseMetadataHeaders bool `yaml:"response-metadata-headers,omitempty" json:"response-metadata-headers,omitempty"`
+
 	// Streaming configures server-side streaming behavior (keep-alives and safe bootstrap retries).
 	Streaming StreamingConfig `yaml:"streaming" json:"streaming"`
 
 	// NonStreamKeepAliveInterval controls how often blank lines are emitted for non-streaming responses.
 	// <= 0 disables keep-alives. Value is in seconds.
 	NonStreamKeepAliveInterval int `yaml:"nonstream-keepalive-interval,omitempty" json:"nonstream-keepalive-interval,omitempty"`
+
+	// AgentLoop configures the server-side tool loop exposed at /v1/agent/chat/completions.
+	AgentLoop AgentLoopConfig `yaml:"agent-loop,omitempty" json:"agent-loop,omitempty"`
+}
+
+// RoutingScript is a routing rule guarded by an expression evaluated per request.
+// See package internal/routingscript for the expression language.
+type RoutingScript struct {
+	// Name identifies the rule in error messages.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+
+	// When is the boolean expression that selects requests, e.g. `tokens > 100000 && model startsWith "claude-"`.
+	When string `yaml:"when" json:"when"`
+
+	// Model replaces the requested model when set.
+	Model string `yaml:"model,omitempty" json:"model,omitempty"`
+
+	// Provider restricts routing to a single provider (e.g., "gemini").
+	Provider string `yaml:"provider,omitempty" json:"provider,omitempty"`
+
+	// AuthLabel pins the request to the credential with this label or auth ID.
+	AuthLabel string `yaml:"auth-label,omitempty" json:"auth-label,omitempty"`
+
+	// Reject denies matching requests with HTTP 403 and this message.
+	Reject string `yaml:"reject,omitempty" json:"reject,omitempty"`
+
+	// program is the compiled When expression, set by SanitizeRoutingScripts.
+	program *routingscript.Program
+}
+
+// Program returns the compiled When expression. Rules loaded with the configuration are
+// compiled once by SanitizeRoutingScripts; rules built in code are compiled on demand.
+func (r *RoutingScript) Program() (*routingscript.Program, error) {
+	if r.program != nil {
+		return r.program, nil
+	}
+	return routingscript.Compile(r.When)
+}
+
+// APIKeyPolicy describes the restrictions applied to requests authenticated with a client API key.
+type APIKeyPolicy struct {
+	// APIKey is the client API key the policy applies to.
+	APIKey string `yaml:"api-key" json:"api-key"`
+
+	// AllowedModels lists model names or wildcard patterns (e.g., "gpt-*") the key may call.
+	// An empty list allows every model.
+	AllowedModels []string `yaml:"allowed-models,omitempty" json:"allowed-models,omitempty"`
+
+	// MaxTemperature caps the sampling temperature. Nil leaves temperature unrestricted.
+	MaxTemperature *float64 `yaml:"max-temperature,omitempty" json:"max-temperature,omitempty"`
+
+	// MaxTokens caps the requested output token limit. <= 0 leaves it unrestricted.
+	MaxTokens int64 `yaml:"max-tokens,omitempty" json:"max-tokens,omitempty"`
+
+	// DisallowTools rejects requests that declare tools or functions.
+	DisallowTools bool `yaml:"disallow-tools,omitempty" json:"disallow-tools,omitempty"`
+
+	// StreamTokensPerSecond caps the rate at which streamed output is delivered to this key,
+	// overriding streaming.tokens-per-second. <= 0 falls back to the global setting.
+	StreamTokensPerSecond float64 `yaml:"stream-tokens-per-second,omitempty" json:"stream-tokens-per-second,omitempty"`
+
+	// MaxConcurrentRequests caps in-flight requests for this key, overriding
+	// concurrency.per-key-max-requests. <= 0 falls back to the global setting.
+	MaxConcurrentRequests int `yaml:"max-concurrent-requests,omitempty" json:"max-concurrent-requests,omitempty"`
+
+	// ReasoningFormat controls how model thinking is returned to OpenAI Chat Completions clients:
+	// "think-tags" inlines it into content wrapped in <think> tags, "strip" removes it.
+	// Empty keeps the separate reasoning_content field.
+	ReasoningFormat string `yaml:"reasoning-format,omitempty" json:"reasoning-format,omitempty"`
 }
 
 // StreamingConfig holds server streaming behavior configuration.
@@ -62,4 +154,64 @@ type StreamingConfig struct {
 	// to allow auth rotation / transient recovery.
 	// <= 0 disables bootstrap retries. Default is 0.
 	BootstrapRetries int `yaml:"bootstrap-retries,omitempty" json:"bootstrap-retries,omitempty"`
+
+	// TokensPerSecond smooths streamed output with a token bucket so that clients receive at most
+	// this many estimated tokens per second. <= 0 disables throttling. Default is 0.
+	TokensPerSecond float64 `yaml:"tokens-per-second,omitempty" json:"tokens-per-second,omitempty"`
+
+	// IdleTimeoutSeconds aborts an upstream stream that produces no data for this many seconds
+	// after its first chunk, returning a timeout error to the client. The wait for the first
+	// chunk is not counted. <= 0 disables idle detection. Default is 0.
+	IdleTimeoutSeconds int `yaml:"idle-timeout-seconds,omitempty" json:"idle-timeout-seconds,omitempty"`
+
+	// FirstChunkTimeoutSeconds aborts an upstream stream whose first chunk does not arrive within
+	// this many seconds. Leave it unset for models with a long time to first token.
+	// <= 0 disables the limit. Default is 0.
+	FirstChunkTimeoutSeconds int `yaml:"first-chunk-timeout-seconds,omitempty" json:"first-chunk-timeout-seconds,omitempty"`
+
+	// IdleRetries controls how many times a stream aborted by either timeout may be retried on
+	// another credential, excluding the one that stalled. Retries only happen while no payload
+	// has been forwarded to the client. Default is 0.
+	IdleRetries int `yaml:"idle-retries,omitempty" json:"idle-retries,omitempty"`
+}
+
+// AgentLoopConfig configures the server-side agent loop. When the model calls tools the proxy
+// can execute, the loop runs them, appends the results and re-invokes the model.
+type AgentLoopConfig struct {
+	// Enabled exposes the /v1/agent/chat/completions endpoint.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// MaxIterations caps the number of model invocations per request. <= 0 uses the default of 8.
+	MaxIterations int `yaml:"max-iterations,omitempty" json:"max-iterations,omitempty"`
+
+	// ToolTimeoutSeconds bounds each tool execution. <= 0 uses the default of 60 seconds.
+	ToolTimeoutSeconds int `yaml:"tool-timeout-seconds,omitempty" json:"tool-timeout-seconds,omitempty"`
+
+	// Tools lists the tools the proxy executes on behalf of the model.
+	Tools []AgentTool `yaml:"tools,omitempty" json:"tools,omitempty"`
+}
+
+// AgentTool describes a tool executed by the proxy during the agent loop.
+type AgentTool struct {
+	// Name is the function name advertised to the model.
+	Name string `yaml:"name" json:"name"`
+
+	// Description is advertised to the model alongside the name.
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+
+	// Type selects how the tool is executed: "http" POSTs the call arguments as JSON to URL and
+	// returns the response body; "mcp" calls a tool on an MCP server over streamable HTTP.
+	Type string `yaml:"type" json:"type"`
+
+	// URL is the HTTP endpoint or MCP server URL.
+	URL string `yaml:"url" json:"url"`
+
+	// Headers are added to every request sent to the tool.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// Parameters is the JSON schema of the tool arguments. Defaults to an empty object schema.
+	Parameters map[string]any `yaml:"parameters,omitempty" json:"parameters,omitempty"`
+
+	// RemoteName is the tool name on the MCP server when it differs from Name.
+	RemoteName string `yaml:"remote-name,omitempty" json:"remote-name,omitempty"`
 }
@@ -96,3 +96,79 @@ func (cfg *Config) SanitizeVertexCompatKeys() {
 	}
 	cfg.VertexCompatAPIKey = out
 }
+
+// VertexCredential represents a Vertex AI project reached with Google OAuth credentials rather
+// than an API key. Gemini models are served from publishers/google and Claude models from
+// publishers/anthropic in the configured region.
+type VertexCredential struct {
+	// ProjectID is the Google Cloud project that hosts the Vertex AI endpoints.
+	ProjectID string `yaml:"project-id" json:"project-id"`
+
+	// Location is the Vertex AI region, e.g. "us-central1" or "us-east5", or "global" for the
+	// global endpoint. Defaults to "us-central1".
+	Location string `yaml:"location,omitempty" json:"location,omitempty"`
+
+	// CredentialsFile is the path of a service account key or workload identity federation
+	// (external_account) JSON file. When empty, Application Default Credentials are used, which
+	// covers GKE workload identity and the metadata server of Google Cloud runtimes.
+	CredentialsFile string `yaml:"credentials-file,omitempty" json:"credentials-file,omitempty"`
+
+	// Priority controls selection preference when multiple credentials match.
+	// Higher values are preferred; defaults to 0.
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+
+	// Prefix optionally namespaces model aliases for this credential (e.g., "teamA/gemini-2.5-pro").
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// ProxyURL optionally overrides the global proxy for this credential.
+	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// Headers optionally adds extra HTTP headers for requests sent with this credential.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// Models optionally replaces the built-in Vertex Gemini models. Claude models must be listed
+	// here with their Vertex IDs, e.g. "claude-sonnet-4@20250514".
+	Models []VertexCompatModel `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// ExcludedModels lists model IDs that should be excluded for this credential.
+	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+}
+
+// SanitizeVertexCredentials normalizes Vertex credentials and drops entries without a project.
+func (cfg *Config) SanitizeVertexCredentials() {
+	if cfg == nil || len(cfg.VertexCredentials) == 0 {
+		return
+	}
+	out := make([]VertexCredential, 0, len(cfg.VertexCredentials))
+	for i := range cfg.VertexCredentials {
+		entry := cfg.VertexCredentials[i]
+		entry.ProjectID = strings.TrimSpace(entry.ProjectID)
+		if entry.ProjectID == "" {
+			continue
+		}
+		entry.Location = strings.TrimSpace(entry.Location)
+		if entry.Location == "" {
+			entry.Location = "us-central1"
+		}
+		entry.CredentialsFile = strings.TrimSpace(entry.CredentialsFile)
+		entry.Prefix = normalizeModelPrefix(entry.Prefix)
+		entry.ProxyURL = strings.TrimSpace(entry.ProxyURL)
+		entry.Headers = NormalizeHeaders(entry.Headers)
+		entry.ExcludedModels = NormalizeExcludedModels(entry.ExcludedModels)
+		models := make([]VertexCompatModel, 0, len(entry.Models))
+		for _, model := range entry.Models {
+			model.Alias = strings.TrimSpace(model.Alias)
+			model.Name = strings.TrimSpace(model.Name)
+			if model.Name == "" {
+				continue
+			}
+			if model.Alias == "" {
+				model.Alias = model.Name
+			}
+			models = append(models, model)
+		}
+		entry.Models = models
+		out = append(out, entry)
+	}
+	cfg.VertexCredentials = out
+}
@@ -0,0 +1,56 @@
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// listenFDsStart is the first file descriptor passed by systemd socket activation.
+const listenFDsStart = 3
+
+// ActivationListener returns the listener handed over by systemd socket activation.
+// It returns a nil listener without error when the process was not socket activated,
+// in which case callers should bind their own address.
+func ActivationListener() (net.Listener, error) {
+	fdsRaw := strings.TrimSpace(os.Getenv("LISTEN_FDS"))
+	if fdsRaw == "" {
+		return nil, nil
+	}
+	if pidRaw := strings.TrimSpace(os.Getenv("LISTEN_PID")); pidRaw != "" {
+		pid, errPid := strconv.Atoi(pidRaw)
+		if errPid != nil || pid != os.Getpid() {
+			return nil, nil
+		}
+	}
+	count, errCount := strconv.Atoi(fdsRaw)
+	if errCount != nil || count < 1 {
+		return nil, nil
+	}
+
+	// Prevent child processes from inheriting the activation state.
+	_ = os.Unsetenv("LISTEN_PID")
+	_ = os.Unsetenv("LISTEN_FDS")
+	_ = os.Unsetenv("LISTEN_FDNAMES")
+
+	if count > 1 {
+		log.Warnf("daemon: received %d activation sockets, only the first one is used", count)
+	}
+
+	file := os.NewFile(uintptr(listenFDsStart), "LISTEN_FD_3")
+	if file == nil {
+		return nil, fmt.Errorf("daemon: activation socket fd %d is invalid", listenFDsStart)
+	}
+	listener, errListener := net.FileListener(file)
+	if errClose := file.Close(); errClose != nil {
+		log.Debugf("daemon: failed to close activation socket file: %v", errClose)
+	}
+	if errListener != nil {
+		return nil, fmt.Errorf("daemon: activation socket: %w", errListener)
+	}
+	return listener, nil
+}
@@ -0,0 +1,105 @@
+// Package daemon integrates the proxy with host init systems.
+// It implements the systemd sd_notify protocol (readiness, watchdog and stopping
+// notifications), systemd socket activation, and a Windows service wrapper so the
+// server can be supervised natively on each platform.
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// NotifyReady tells the service manager that startup has finished.
+	NotifyReady = "READY=1"
+	// NotifyStopping tells the service manager that shutdown has begun.
+	NotifyStopping = "STOPPING=1"
+	// NotifyWatchdog keeps the service manager watchdog from firing.
+	NotifyWatchdog = "WATCHDOG=1"
+)
+
+// Notify sends a state string to the systemd notification socket named by NOTIFY_SOCKET.
+// It returns false without error when the process is not supervised by systemd.
+func Notify(state string) (bool, error) {
+	socketPath := strings.TrimSpace(os.Getenv("NOTIFY_SOCKET"))
+	if socketPath == "" {
+		return false, nil
+	}
+	// Abstract namespace sockets are announced with a leading '@'.
+	if strings.HasPrefix(socketPath, "@") {
+		socketPath = "\x00" + socketPath[1:]
+	}
+
+	conn, errDial := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	if errDial != nil {
+		return false, fmt.Errorf("daemon: dial notify socket: %w", errDial)
+	}
+	defer func() {
+		if errClose := conn.Close(); errClose != nil {
+			log.Debugf("daemon: failed to close notify socket: %v", errClose)
+		}
+	}()
+
+	if _, errWrite := conn.Write([]byte(state)); errWrite != nil {
+		return false, fmt.Errorf("daemon: write notify socket: %w", errWrite)
+	}
+	return true, nil
+}
+
+// WatchdogInterval returns the watchdog timeout configured by systemd through
+// WATCHDOG_USEC, or zero when the watchdog is disabled for this process.
+func WatchdogInterval() time.Duration {
+	usecRaw := strings.TrimSpace(os.Getenv("WATCHDOG_USEC"))
+	if usecRaw == "" {
+		return 0
+	}
+	if pidRaw := strings.TrimSpace(os.Getenv("WATCHDOG_PID")); pidRaw != "" {
+		pid, errPid := strconv.Atoi(pidRaw)
+		if errPid != nil || pid != os.Getpid() {
+			return 0
+		}
+	}
+	usec, errParse := strconv.ParseInt(usecRaw, 10, 64)
+	if errParse != nil || usec <= 0 {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond
+}
+
+// StartWatchdog pings the systemd watchdog at half the configured timeout for as long
+// as ctx is alive. Pings are skipped while healthy reports false so that a wedged
+// server is restarted by the service manager. It is a no-op when no watchdog is configured.
+func StartWatchdog(ctx context.Context, healthy func() bool) {
+	timeout := WatchdogInterval()
+	if timeout <= 0 {
+		return
+	}
+	interval := timeout / 2
+	log.Debugf("daemon: systemd watchdog enabled (interval=%s)", interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if healthy != nil && !healthy() {
+					log.Warn("daemon: health check failed, skipping watchdog ping")
+					continue
+				}
+				if _, errNotify := Notify(NotifyWatchdog); errNotify != nil {
+					log.Debugf("daemon: watchdog ping failed: %v", errNotify)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,80 @@
+package daemon
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestNotifyWithoutSocket(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+
+	sent, err := Notify(NotifyReady)
+	if err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if sent {
+		t.Fatal("Notify() sent = true, want false without NOTIFY_SOCKET")
+	}
+}
+
+func TestNotifySendsState(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unixgram sockets are not supported on windows")
+	}
+	socketPath := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("ListenUnixgram() error = %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	t.Setenv("NOTIFY_SOCKET", socketPath)
+
+	sent, err := Notify(NotifyReady)
+	if err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if !sent {
+		t.Fatal("Notify() sent = false, want true")
+	}
+
+	buf := make([]byte, 64)
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if got := string(buf[:n]); got != NotifyReady {
+		t.Fatalf("received %q, want %q", got, NotifyReady)
+	}
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	t.Setenv("WATCHDOG_USEC", "4000000")
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+	if got := WatchdogInterval(); got != 4*time.Second {
+		t.Fatalf("WatchdogInterval() = %s, want 4s", got)
+	}
+
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()+1))
+	if got := WatchdogInterval(); got != 0 {
+		t.Fatalf("WatchdogInterval() for foreign pid = %s, want 0", got)
+	}
+}
+
+func TestActivationListenerIgnoresForeignPID(t *testing.T) {
+	t.Setenv("LISTEN_FDS", "1")
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+
+	listener, err := ActivationListener()
+	if err != nil {
+		t.Fatalf("ActivationListener() error = %v", err)
+	}
+	if listener != nil {
+		t.Fatal("ActivationListener() returned a listener for a foreign pid")
+	}
+}
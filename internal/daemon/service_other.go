@@ -0,0 +1,10 @@
+//go:build !windows
+
+package daemon
+
+import "context"
+
+// RunWindowsService reports false on non-Windows platforms.
+func RunWindowsService(_ string, _ func(ctx context.Context)) (bool, error) {
+	return false, nil
+}
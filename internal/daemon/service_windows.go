@@ -0,0 +1,65 @@
+//go:build windows
+
+package daemon
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/sys/windows/svc"
+)
+
+// RunWindowsService runs fn under the Windows service control manager when the
+// process was started as a service. It returns false when running interactively,
+// leaving the caller to start fn itself.
+func RunWindowsService(name string, fn func(ctx context.Context)) (bool, error) {
+	isService, errDetect := svc.IsWindowsService()
+	if errDetect != nil {
+		return false, fmt.Errorf("daemon: detect windows service: %w", errDetect)
+	}
+	if !isService {
+		return false, nil
+	}
+	if errRun := svc.Run(name, &windowsHandler{run: fn}); errRun != nil {
+		return true, fmt.Errorf("daemon: run windows service: %w", errRun)
+	}
+	return true, nil
+}
+
+type windowsHandler struct {
+	run func(ctx context.Context)
+}
+
+// Execute implements svc.Handler.
+func (h *windowsHandler) Execute(_ []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	const accepted = svc.AcceptStop | svc.AcceptShutdown
+
+	status <- svc.Status{State: svc.StartPending}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.run(ctx)
+	}()
+
+	status <- svc.Status{State: svc.Running, Accepts: accepted}
+	for {
+		select {
+		case <-done:
+			status <- svc.Status{State: svc.StopPending}
+			return false, 0
+		case req := <-requests:
+			switch req.Cmd {
+			case svc.Interrogate:
+				status <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+				cancel()
+				<-done
+				return false, 0
+			}
+		}
+	}
+}
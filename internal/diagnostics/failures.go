@@ -0,0 +1,235 @@
+// Package diagnostics keeps a bounded history of failed requests so transient failures can be
+// investigated after the fact without enabling full request logging.
+package diagnostics
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultMaxEntries = 200
+	maxErrorExcerpt   = 4096
+)
+
+// Failure describes one failed request.
+type Failure struct {
+	Timestamp   time.Time `json:"timestamp"`
+	RequestID   string    `json:"request_id,omitempty"`
+	Method      string    `json:"method,omitempty"`
+	Path        string    `json:"path,omitempty"`
+	HandlerType string    `json:"handler_type,omitempty"`
+	Model       string    `json:"model,omitempty"`
+	Stream      bool      `json:"stream"`
+	Provider    string    `json:"provider,omitempty"`
+	AuthID      string    `json:"auth_id,omitempty"`
+	AuthIndex   string    `json:"auth_index,omitempty"`
+	AuthLabel   string    `json:"auth_label,omitempty"`
+	Translation string    `json:"translation,omitempty"`
+	Attempts    int       `json:"attempts"`
+	StatusCode  int       `json:"status_code"`
+	Error       string    `json:"error"`
+}
+
+// Store is a bounded, optionally file-backed, history of failures. The oldest entries are
+// discarded once the configured capacity is reached.
+type Store struct {
+	mu          sync.Mutex
+	enabled     bool
+	maxEntries  int
+	entries     []Failure
+	persistPath string
+	// persistedLines counts the lines in the persist file so it can be compacted once it grows
+	// well past maxEntries.
+	persistedLines int
+}
+
+// NewStore creates a disabled store. Call Configure to enable it.
+func NewStore() *Store {
+	return &Store{maxEntries: defaultMaxEntries}
+}
+
+var defaultStore = NewStore()
+
+// Default returns the shared failure store.
+func Default() *Store { return defaultStore }
+
+// RecordFailure adds f to the shared store when failure diagnostics are enabled.
+func RecordFailure(f Failure) { defaultStore.Record(f) }
+
+// Enabled reports whether the shared store is recording failures.
+func Enabled() bool { return defaultStore.Enabled() }
+
+// Configure applies cfg to the store. baseDir resolves a relative persist file. When the
+// persist file changes, previously persisted failures are loaded from it.
+func (s *Store) Configure(cfg config.FailureDiagnosticsConfig, baseDir string) {
+	maxEntries := cfg.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultMaxEntries
+	}
+	persistPath := strings.TrimSpace(cfg.PersistFile)
+	if persistPath != "" && !filepath.IsAbs(persistPath) && baseDir != "" {
+		persistPath = filepath.Join(baseDir, persistPath)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enabled = cfg.Enable
+	s.maxEntries = maxEntries
+	if !cfg.Enable {
+		s.entries = nil
+		s.persistPath = ""
+		s.persistedLines = 0
+		return
+	}
+	if persistPath != s.persistPath {
+		s.persistPath = persistPath
+		s.persistedLines = 0
+		if persistPath != "" {
+			s.loadLocked()
+		}
+	}
+	s.trimLocked()
+}
+
+// Enabled reports whether the store is recording failures.
+func (s *Store) Enabled() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enabled
+}
+
+// Record adds a failure. It is a no-op while the store is disabled.
+func (s *Store) Record(f Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.enabled {
+		return
+	}
+	if f.Timestamp.IsZero() {
+		f.Timestamp = time.Now()
+	}
+	if len(f.Error) > maxErrorExcerpt {
+		f.Error = f.Error[:maxErrorExcerpt] + "...(truncated)"
+	}
+	s.entries = append(s.entries, f)
+	s.trimLocked()
+	s.appendLocked(f)
+}
+
+// List returns up to limit failures, newest first. limit <= 0 returns every entry.
+func (s *Store) List(limit int) []Failure {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.entries)
+	if limit > 0 && limit < n {
+		n = limit
+	}
+	out := make([]Failure, 0, n)
+	for i := len(s.entries) - 1; i >= 0 && len(out) < n; i-- {
+		out = append(out, s.entries[i])
+	}
+	return out
+}
+
+// Clear removes every failure, including persisted ones.
+func (s *Store) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = nil
+	if s.persistPath != "" {
+		s.rewriteLocked()
+	}
+}
+
+func (s *Store) trimLocked() {
+	if excess := len(s.entries) - s.maxEntries; excess > 0 {
+		s.entries = append([]Failure(nil), s.entries[excess:]...)
+	}
+}
+
+func (s *Store) loadLocked() {
+	data, errRead := os.ReadFile(s.persistPath)
+	if errRead != nil {
+		if !os.IsNotExist(errRead) {
+			log.Warnf("failure diagnostics: failed to read %s: %v", s.persistPath, errRead)
+		}
+		return
+	}
+	var loaded []Failure
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		s.persistedLines++
+		var f Failure
+		if errUnmarshal := json.Unmarshal(line, &f); errUnmarshal == nil {
+			loaded = append(loaded, f)
+		}
+	}
+	s.entries = loaded
+}
+
+func (s *Store) appendLocked(f Failure) {
+	if s.persistPath == "" {
+		return
+	}
+	if s.persistedLines >= 2*s.maxEntries {
+		s.rewriteLocked()
+		return
+	}
+	line, errMarshal := json.Marshal(f)
+	if errMarshal != nil {
+		return
+	}
+	file, errOpen := os.OpenFile(s.persistPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if errOpen != nil {
+		log.Warnf("failure diagnostics: failed to open %s: %v", s.persistPath, errOpen)
+		return
+	}
+	defer func() {
+		if errClose := file.Close(); errClose != nil {
+			log.Warnf("failure diagnostics: failed to close %s: %v", s.persistPath, errClose)
+		}
+	}()
+	if _, errWrite := file.Write(append(line, '\n')); errWrite != nil {
+		log.Warnf("failure diagnostics: failed to write %s: %v", s.persistPath, errWrite)
+		return
+	}
+	s.persistedLines++
+}
+
+// rewriteLocked replaces the persist file with the current entries.
+func (s *Store) rewriteLocked() {
+	var buf bytes.Buffer
+	for _, f := range s.entries {
+		line, errMarshal := json.Marshal(f)
+		if errMarshal != nil {
+			continue
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	tmp := s.persistPath + ".tmp"
+	if errWrite := os.WriteFile(tmp, buf.Bytes(), 0o600); errWrite != nil {
+		log.Warnf("failure diagnostics: failed to write %s: %v", tmp, errWrite)
+		return
+	}
+	if errRename := os.Rename(tmp, s.persistPath); errRename != nil {
+		log.Warnf("failure diagnostics: failed to replace %s: %v", s.persistPath, errRename)
+		return
+	}
+	s.persistedLines = len(s.entries)
+}
@@ -0,0 +1,71 @@
+package diagnostics
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestStoreKeepsNewestEntries(t *testing.T) {
+	store := NewStore()
+	store.Record(Failure{Model: "ignored"})
+	if len(store.List(0)) != 0 {
+		t.Fatal("disabled store should not record failures")
+	}
+
+	store.Configure(config.FailureDiagnosticsConfig{Enable: true, MaxEntries: 2}, "")
+	for _, model := range []string{"a", "b", "c"} {
+		store.Record(Failure{Model: model, Error: strings.Repeat("x", maxErrorExcerpt+10)})
+	}
+	got := store.List(0)
+	if len(got) != 2 || got[0].Model != "c" || got[1].Model != "b" {
+		t.Fatalf("unexpected entries: %+v", got)
+	}
+	if !strings.HasSuffix(got[0].Error, "...(truncated)") || got[0].Timestamp.IsZero() {
+		t.Fatalf("error excerpt not truncated or timestamp missing: %+v", got[0])
+	}
+	if len(store.List(1)) != 1 {
+		t.Fatal("limit not applied")
+	}
+}
+
+func TestStorePersistsAcrossRestarts(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.FailureDiagnosticsConfig{Enable: true, MaxEntries: 2, PersistFile: "failures.jsonl"}
+
+	store := NewStore()
+	store.Configure(cfg, dir)
+	for i := 0; i < 6; i++ {
+		store.Record(Failure{Model: string(rune('a' + i)), StatusCode: 500})
+	}
+
+	restarted := NewStore()
+	restarted.Configure(cfg, dir)
+	got := restarted.List(0)
+	if len(got) != 2 || got[0].Model != "f" || got[1].Model != "e" {
+		t.Fatalf("unexpected entries after reload: %+v", got)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "failures.jsonl"))
+	if err != nil {
+		t.Fatalf("read persist file: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines > 2*cfg.MaxEntries {
+		t.Fatalf("persist file was not compacted: %d lines", lines)
+	}
+
+	restarted.Clear()
+	if len(loadStore(t, cfg, dir).List(0)) != 0 {
+		t.Fatal("Clear should remove persisted failures")
+	}
+}
+
+func loadStore(t *testing.T, cfg config.FailureDiagnosticsConfig, dir string) *Store {
+	t.Helper()
+	store := NewStore()
+	store.Configure(cfg, dir)
+	return store
+}
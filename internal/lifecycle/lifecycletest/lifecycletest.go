@@ -0,0 +1,47 @@
+// Package lifecycletest provides test helpers that fail tests whose tracked goroutines
+// outlive them.
+package lifecycletest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/lifecycle"
+)
+
+// DefaultTimeout is how long VerifyNone waits for tracked goroutines to exit.
+const DefaultTimeout = 2 * time.Second
+
+// VerifyNone fails tb if goroutines started through the default tracker after this call
+// are still running when the test finishes. Call it at the start of the test.
+func VerifyNone(tb testing.TB) {
+	tb.Helper()
+	tracker := lifecycle.Default()
+	after := tracker.LastID()
+	tb.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+		defer cancel()
+		if remaining := tracker.Wait(ctx, after); len(remaining) > 0 {
+			tb.Errorf("%d tracked goroutine(s) outlived the test:\n%s", len(remaining), describe(remaining))
+		}
+	})
+}
+
+func describe(tasks []lifecycle.Task) string {
+	var b strings.Builder
+	now := time.Now()
+	for _, task := range tasks {
+		fmt.Fprintf(&b, "  #%d %s started %s ago", task.ID, task.Name, now.Sub(task.StartedAt).Round(time.Millisecond))
+		if task.RequestID != "" {
+			fmt.Fprintf(&b, " request=%s", task.RequestID)
+		}
+		if task.CancelledAt != nil {
+			fmt.Fprintf(&b, " cancelled %s ago", now.Sub(*task.CancelledAt).Round(time.Millisecond))
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
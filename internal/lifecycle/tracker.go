@@ -0,0 +1,143 @@
+// Package lifecycle tracks goroutines spawned on behalf of a request so that streams which
+// outlive their request context can be detected and reported.
+package lifecycle
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+)
+
+// Task describes a tracked goroutine.
+type Task struct {
+	ID          uint64     `json:"id"`
+	Name        string     `json:"name"`
+	RequestID   string     `json:"request_id,omitempty"`
+	StartedAt   time.Time  `json:"started_at"`
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+}
+
+// Tracker records the goroutines started through Go until they return.
+type Tracker struct {
+	mu    sync.Mutex
+	next  uint64
+	tasks map[uint64]*Task
+	done  chan struct{}
+}
+
+// NewTracker returns an empty tracker.
+func NewTracker() *Tracker {
+	return &Tracker{tasks: make(map[uint64]*Task), done: make(chan struct{})}
+}
+
+var defaultTracker = NewTracker()
+
+// Default returns the process-wide tracker used by the package-level helpers.
+func Default() *Tracker {
+	return defaultTracker
+}
+
+// Go runs fn in a goroutine tracked by the default tracker.
+func Go(ctx context.Context, name string, fn func()) {
+	defaultTracker.Go(ctx, name, fn)
+}
+
+// Go runs fn in a new goroutine and tracks it until fn returns. The time ctx is cancelled
+// is recorded so goroutines that keep running afterwards can be reported as leaks.
+func (t *Tracker) Go(ctx context.Context, name string, fn func()) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	t.mu.Lock()
+	t.next++
+	task := &Task{
+		ID:        t.next,
+		Name:      name,
+		RequestID: logging.GetRequestID(ctx),
+		StartedAt: time.Now(),
+	}
+	t.tasks[task.ID] = task
+	t.mu.Unlock()
+
+	stop := context.AfterFunc(ctx, func() {
+		now := time.Now()
+		t.mu.Lock()
+		task.CancelledAt = &now
+		t.mu.Unlock()
+	})
+	go func() {
+		defer t.finish(task.ID, stop)
+		fn()
+	}()
+}
+
+func (t *Tracker) finish(id uint64, stop func() bool) {
+	stop()
+	t.mu.Lock()
+	delete(t.tasks, id)
+	done := t.done
+	t.done = make(chan struct{})
+	t.mu.Unlock()
+	close(done)
+}
+
+// Snapshot returns the running tasks ordered by start time.
+func (t *Tracker) Snapshot() []Task {
+	return t.filter(func(*Task) bool { return true })
+}
+
+// Since returns the running tasks with an ID greater than after. Together with LastID it
+// scopes a check to the goroutines started by one test or request.
+func (t *Tracker) Since(after uint64) []Task {
+	return t.filter(func(task *Task) bool { return task.ID > after })
+}
+
+// LastID returns the ID assigned to the most recently started task.
+func (t *Tracker) LastID() uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.next
+}
+
+// Leaks returns the tasks still running more than grace after their context was cancelled.
+func (t *Tracker) Leaks(grace time.Duration) []Task {
+	cutoff := time.Now().Add(-grace)
+	return t.filter(func(task *Task) bool {
+		return task.CancelledAt != nil && task.CancelledAt.Before(cutoff)
+	})
+}
+
+// Wait blocks until no task newer than after is running or ctx is done, and returns the
+// tasks that are still running.
+func (t *Tracker) Wait(ctx context.Context, after uint64) []Task {
+	for {
+		t.mu.Lock()
+		done := t.done
+		t.mu.Unlock()
+		remaining := t.Since(after)
+		if len(remaining) == 0 {
+			return nil
+		}
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return remaining
+		}
+	}
+}
+
+func (t *Tracker) filter(keep func(*Task) bool) []Task {
+	t.mu.Lock()
+	out := make([]Task, 0, len(t.tasks))
+	for _, task := range t.tasks {
+		if keep(task) {
+			out = append(out, *task)
+		}
+	}
+	t.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
@@ -0,0 +1,50 @@
+package lifecycle
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+)
+
+func TestTrackerReportsTasksOutlivingCancellation(t *testing.T) {
+	tracker := NewTracker()
+	ctx, cancel := context.WithCancel(logging.WithRequestID(context.Background(), "req-1"))
+	release := make(chan struct{})
+	tracker.Go(ctx, "test.stream", func() { <-release })
+	tracker.Go(context.Background(), "test.quick", func() {})
+
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), time.Second)
+	defer waitCancel()
+	running := tracker.Wait(waitCtx, 1)
+	if len(running) != 0 {
+		t.Fatalf("quick task still running: %+v", running)
+	}
+	snapshot := tracker.Snapshot()
+	if len(snapshot) != 1 || snapshot[0].Name != "test.stream" || snapshot[0].RequestID != "req-1" {
+		t.Fatalf("snapshot = %+v", snapshot)
+	}
+	if leaks := tracker.Leaks(0); len(leaks) != 0 {
+		t.Fatalf("uncancelled task reported as leak: %+v", leaks)
+	}
+
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for len(tracker.Leaks(0)) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if leaks := tracker.Leaks(0); len(leaks) != 1 || leaks[0].CancelledAt == nil {
+		t.Fatalf("leaks = %+v", leaks)
+	}
+	if leaks := tracker.Leaks(time.Hour); len(leaks) != 0 {
+		t.Fatalf("task within grace reported as leak: %+v", leaks)
+	}
+
+	close(release)
+	waitCtx2, waitCancel2 := context.WithTimeout(context.Background(), time.Second)
+	defer waitCancel2()
+	if remaining := tracker.Wait(waitCtx2, 0); len(remaining) != 0 {
+		t.Fatalf("remaining = %+v", remaining)
+	}
+}
@@ -75,6 +75,9 @@ func GinLogrusLogger() gin.HandlerFunc {
 
 		statusCode := c.Writer.Status()
 		clientIP := c.ClientIP()
+		if PrivacyModeEnabled() {
+			clientIP = "-"
+		}
 		method := c.Request.Method
 		errorMessage := c.Errors.ByType(gin.ErrorTypePrivate).String()
 
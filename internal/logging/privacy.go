@@ -0,0 +1,35 @@
+package logging
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+var privacyMode atomic.Bool
+
+// privacyHeaders lists request headers that identify the client and are dropped from
+// logs while privacy mode is enabled.
+var privacyHeaders = []string{
+	"User-Agent",
+	"X-Forwarded-For",
+	"X-Real-Ip",
+	"Forwarded",
+	"Cf-Connecting-Ip",
+	"True-Client-Ip",
+}
+
+// SetPrivacyMode toggles omission of client IP addresses and User-Agent headers from logs.
+func SetPrivacyMode(enabled bool) { privacyMode.Store(enabled) }
+
+// PrivacyModeEnabled reports whether client-identifying data is omitted from logs.
+func PrivacyModeEnabled() bool { return privacyMode.Load() }
+
+// RedactClientHeaders removes client-identifying headers from headers when privacy mode is enabled.
+func RedactClientHeaders(headers http.Header) {
+	if !privacyMode.Load() {
+		return
+	}
+	for _, key := range privacyHeaders {
+		headers.Del(key)
+	}
+}
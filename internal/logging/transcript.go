@@ -0,0 +1,343 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// RequestTranscript is a structured view of a request log file, assembled for sharing in bug
+// reports: the inbound request, every translated upstream attempt, the chunks returned to
+// the client and the final token usage.
+type RequestTranscript struct {
+	RequestID string             `json:"request_id"`
+	Info      map[string]string  `json:"info"`
+	Request   TranscriptMessage  `json:"request"`
+	Upstream  []UpstreamExchange `json:"upstream,omitempty"`
+	Errors    []string           `json:"errors,omitempty"`
+	Response  TranscriptMessage  `json:"response"`
+	Usage     json.RawMessage    `json:"usage,omitempty"`
+}
+
+// TranscriptMessage holds one side of an HTTP exchange. Streamed bodies are split into chunks.
+type TranscriptMessage struct {
+	Status  int               `json:"status,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+	Chunks  []string          `json:"chunks,omitempty"`
+}
+
+// UpstreamExchange describes one attempt against an upstream provider.
+type UpstreamExchange struct {
+	Attempt  int               `json:"attempt"`
+	URL      string            `json:"url,omitempty"`
+	Method   string            `json:"method,omitempty"`
+	Auth     string            `json:"auth,omitempty"`
+	Request  TranscriptMessage `json:"request"`
+	Response TranscriptMessage `json:"response"`
+	Error    string            `json:"error,omitempty"`
+}
+
+var transcriptSectionPattern = regexp.MustCompile(`^=== (.+?) ===$`)
+
+type transcriptSection struct {
+	name  string
+	lines []string
+}
+
+// ParseRequestTranscript builds a transcript from the content of a request log file.
+func ParseRequestTranscript(requestID string, content []byte) *RequestTranscript {
+	transcript := &RequestTranscript{RequestID: requestID, Info: map[string]string{}}
+	upstream := map[int]*UpstreamExchange{}
+	var order []int
+	exchange := func(index int) *UpstreamExchange {
+		if existing, ok := upstream[index]; ok {
+			return existing
+		}
+		created := &UpstreamExchange{Attempt: index}
+		upstream[index] = created
+		order = append(order, index)
+		return created
+	}
+
+	for _, section := range splitTranscriptSections(string(content)) {
+		switch {
+		case section.name == "REQUEST INFO":
+			for key, value := range parseTranscriptFields(section.lines) {
+				transcript.Info[key] = value
+			}
+		case section.name == "HEADERS":
+			transcript.Request.Headers = parseTranscriptFields(section.lines)
+		case section.name == "REQUEST BODY":
+			transcript.Request.Body = transcriptBody(strings.Join(section.lines, "\n"))
+		case strings.HasPrefix(section.name, "API REQUEST"):
+			item := exchange(transcriptAttemptIndex(section.name))
+			fields, headers, body := parseTranscriptAttempt(section.lines)
+			item.URL = fields["Upstream URL"]
+			item.Method = fields["HTTP Method"]
+			item.Auth = fields["Auth"]
+			item.Request.Headers = headers
+			item.Request.Body = transcriptBody(body)
+		case strings.HasPrefix(section.name, "API RESPONSE"):
+			item := exchange(transcriptAttemptIndex(section.name))
+			fields, headers, body := parseTranscriptAttempt(section.lines)
+			item.Response.Status, _ = strconv.Atoi(fields["Status"])
+			item.Response.Headers = headers
+			item.Error = fields["Error"]
+			setTranscriptPayload(&item.Response, body)
+		case section.name == "API ERROR RESPONSE":
+			transcript.Errors = append(transcript.Errors, strings.TrimSpace(strings.Join(section.lines, "\n")))
+		case section.name == "RESPONSE":
+			parseTranscriptResponse(&transcript.Response, section.lines)
+		}
+	}
+
+	for _, index := range order {
+		transcript.Upstream = append(transcript.Upstream, *upstream[index])
+	}
+	transcript.Usage = findTranscriptUsage(transcript)
+	return transcript
+}
+
+func splitTranscriptSections(content string) []transcriptSection {
+	var sections []transcriptSection
+	var current *transcriptSection
+	for _, line := range strings.Split(content, "\n") {
+		if match := transcriptSectionPattern.FindStringSubmatch(strings.TrimRight(line, "\r")); match != nil {
+			sections = append(sections, transcriptSection{name: match[1]})
+			current = &sections[len(sections)-1]
+			continue
+		}
+		if current != nil {
+			current.lines = append(current.lines, strings.TrimRight(line, "\r"))
+		}
+	}
+	return sections
+}
+
+// parseTranscriptFields reads "Key: value" lines, stopping at the first blank line.
+func parseTranscriptFields(lines []string) map[string]string {
+	fields := map[string]string{}
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			break
+		}
+		if key, value, ok := strings.Cut(line, ": "); ok {
+			fields[key] = value
+		}
+	}
+	return fields
+}
+
+// parseTranscriptAttempt reads an upstream attempt section: leading fields, an optional
+// "Headers:" block and everything after "Body:".
+func parseTranscriptAttempt(lines []string) (map[string]string, map[string]string, string) {
+	fields := map[string]string{}
+	headers := map[string]string{}
+	inHeaders := false
+	for i, line := range lines {
+		switch {
+		case line == "Body:":
+			return fields, headers, strings.Join(lines[i+1:], "\n")
+		case line == "Headers:":
+			inHeaders = true
+		case strings.TrimSpace(line) == "":
+			inHeaders = false
+		default:
+			key, value, ok := strings.Cut(line, ": ")
+			if !ok {
+				key, value, ok = strings.Cut(line, ":")
+			}
+			if !ok {
+				continue
+			}
+			if inHeaders {
+				headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+			} else {
+				fields[key] = strings.TrimSpace(value)
+			}
+		}
+	}
+	return fields, headers, ""
+}
+
+func parseTranscriptResponse(message *TranscriptMessage, lines []string) {
+	message.Headers = map[string]string{}
+	bodyStart := len(lines)
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			bodyStart = i + 1
+			break
+		}
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			continue
+		}
+		if key == "Status" {
+			message.Status, _ = strconv.Atoi(value)
+			continue
+		}
+		message.Headers[key] = value
+	}
+	if bodyStart < len(lines) {
+		setTranscriptPayload(message, strings.Join(lines[bodyStart:], "\n"))
+	}
+}
+
+// setTranscriptPayload stores a body, splitting server-sent event streams into chunks.
+func setTranscriptPayload(message *TranscriptMessage, body string) {
+	body = strings.TrimSpace(body)
+	if body == "" || body == "<empty>" {
+		return
+	}
+	if !strings.HasPrefix(body, "data:") && !strings.HasPrefix(body, "event:") {
+		message.Body = transcriptBody(body)
+		return
+	}
+	for _, chunk := range strings.Split(body, "\n\n") {
+		if chunk = strings.TrimSpace(chunk); chunk != "" {
+			message.Chunks = append(message.Chunks, chunk)
+		}
+	}
+}
+
+// transcriptBody keeps JSON bodies as structured JSON and wraps anything else as a string.
+func transcriptBody(body string) json.RawMessage {
+	body = strings.TrimSpace(body)
+	if body == "" || body == "<empty>" {
+		return nil
+	}
+	if json.Valid([]byte(body)) {
+		return json.RawMessage(body)
+	}
+	encoded, _ := json.Marshal(body)
+	return encoded
+}
+
+func transcriptAttemptIndex(name string) int {
+	fields := strings.Fields(name)
+	if len(fields) == 0 {
+		return 1
+	}
+	if index, errAtoi := strconv.Atoi(fields[len(fields)-1]); errAtoi == nil {
+		return index
+	}
+	return 1
+}
+
+// transcriptUsagePaths lists where each dialect reports token usage.
+var transcriptUsagePaths = []string{"usage", "usageMetadata", "response.usage", "message.usage", "response.usageMetadata"}
+
+// findTranscriptUsage returns the last usage object reported to the client, falling back
+// to the upstream responses.
+func findTranscriptUsage(transcript *RequestTranscript) json.RawMessage {
+	candidates := [][]string{transcriptPayloads(transcript.Response)}
+	for i := len(transcript.Upstream) - 1; i >= 0; i-- {
+		candidates = append(candidates, transcriptPayloads(transcript.Upstream[i].Response))
+	}
+	for _, payloads := range candidates {
+		for i := len(payloads) - 1; i >= 0; i-- {
+			for _, path := range transcriptUsagePaths {
+				if usage := gjson.Get(payloads[i], path); usage.IsObject() {
+					return json.RawMessage(usage.Raw)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// transcriptPayloads returns the JSON documents carried by a message body or its SSE chunks.
+func transcriptPayloads(message TranscriptMessage) []string {
+	var payloads []string
+	if len(message.Body) > 0 {
+		payloads = append(payloads, string(message.Body))
+	}
+	for _, chunk := range message.Chunks {
+		for _, line := range strings.Split(chunk, "\n") {
+			data, ok := strings.CutPrefix(strings.TrimSpace(line), "data:")
+			if !ok {
+				continue
+			}
+			if data = strings.TrimSpace(data); gjson.Valid(data) {
+				payloads = append(payloads, data)
+			}
+		}
+	}
+	return payloads
+}
+
+// Markdown renders the transcript as a markdown document.
+func (t *RequestTranscript) Markdown() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Request transcript %s\n\n", t.RequestID)
+	for _, key := range []string{"URL", "Method", "Timestamp", "Version", "Downstream Transport", "Upstream Transport"} {
+		if value := t.Info[key]; value != "" {
+			fmt.Fprintf(&sb, "- **%s:** %s\n", key, value)
+		}
+	}
+
+	sb.WriteString("\n## Inbound request\n\n")
+	writeTranscriptMessage(&sb, t.Request)
+
+	for _, exchange := range t.Upstream {
+		fmt.Fprintf(&sb, "## Upstream attempt %d\n\n", exchange.Attempt)
+		if exchange.URL != "" {
+			fmt.Fprintf(&sb, "- **URL:** %s %s\n", exchange.Method, exchange.URL)
+		}
+		if exchange.Auth != "" {
+			fmt.Fprintf(&sb, "- **Auth:** %s\n", exchange.Auth)
+		}
+		if exchange.Error != "" {
+			fmt.Fprintf(&sb, "- **Error:** %s\n", exchange.Error)
+		}
+		sb.WriteString("\n### Translated request\n\n")
+		writeTranscriptMessage(&sb, exchange.Request)
+		sb.WriteString("### Upstream response\n\n")
+		writeTranscriptMessage(&sb, exchange.Response)
+	}
+
+	for _, errText := range t.Errors {
+		sb.WriteString("## Error\n\n")
+		writeTranscriptCode(&sb, "", errText)
+	}
+
+	sb.WriteString("## Response\n\n")
+	writeTranscriptMessage(&sb, t.Response)
+
+	if len(t.Usage) > 0 {
+		sb.WriteString("## Usage\n\n")
+		writeTranscriptCode(&sb, "json", string(t.Usage))
+	}
+	return sb.String()
+}
+
+func writeTranscriptMessage(sb *strings.Builder, message TranscriptMessage) {
+	if message.Status > 0 {
+		fmt.Fprintf(sb, "- **Status:** %d\n\n", message.Status)
+	}
+	if len(message.Body) > 0 {
+		var text string
+		if errUnmarshal := json.Unmarshal(message.Body, &text); errUnmarshal == nil {
+			writeTranscriptCode(sb, "", text)
+		} else {
+			writeTranscriptCode(sb, "json", string(message.Body))
+		}
+	}
+	if len(message.Chunks) > 0 {
+		fmt.Fprintf(sb, "%d streamed chunks:\n\n", len(message.Chunks))
+		writeTranscriptCode(sb, "", strings.Join(message.Chunks, "\n\n"))
+	}
+}
+
+func writeTranscriptCode(sb *strings.Builder, language, body string) {
+	fence := "```"
+	for strings.Contains(body, fence) {
+		fence += "`"
+	}
+	fmt.Fprintf(sb, "%s%s\n%s\n%s\n\n", fence, language, strings.TrimRight(body, "\n"), fence)
+}
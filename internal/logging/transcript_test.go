@@ -0,0 +1,94 @@
+package logging
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+const sampleTranscriptLog = `=== REQUEST INFO ===
+Version: dev
+URL: /v1/chat/completions
+Method: POST
+Timestamp: 2026-01-02T03:04:05Z
+
+=== HEADERS ===
+Content-Type: application/json
+
+=== REQUEST BODY ===
+{"model":"claude-sonnet","stream":true,"messages":[{"role":"user","content":"hi"}]}
+
+=== API REQUEST 1 ===
+Timestamp: 2026-01-02T03:04:05Z
+Upstream URL: https://api.anthropic.com/v1/messages
+HTTP Method: POST
+Auth: claude-1
+
+Headers:
+Content-Type: application/json
+
+Body:
+{"model":"claude-sonnet","stream":true,"messages":[{"role":"user","content":[{"type":"text","text":"hi"}]}]}
+
+=== API RESPONSE 1 ===
+Timestamp: 2026-01-02T03:04:06Z
+
+Status: 200
+Headers:
+Content-Type: text/event-stream
+
+Body:
+event: message_start
+data: {"type":"message_start","message":{"usage":{"input_tokens":3,"output_tokens":1}}}
+
+event: message_delta
+data: {"type":"message_delta","usage":{"output_tokens":5}}
+
+=== RESPONSE ===
+Status: 200
+Content-Type: text/event-stream
+
+data: {"choices":[{"delta":{"content":"Hello"}}]}
+
+data: {"choices":[],"usage":{"prompt_tokens":3,"completion_tokens":5,"total_tokens":8}}
+
+data: [DONE]
+`
+
+func TestParseRequestTranscript(t *testing.T) {
+	transcript := ParseRequestTranscript("abc123", []byte(sampleTranscriptLog))
+
+	if transcript.Info["URL"] != "/v1/chat/completions" || transcript.Info["Method"] != "POST" {
+		t.Fatalf("unexpected info: %v", transcript.Info)
+	}
+	if got := gjson.GetBytes(transcript.Request.Body, "model").String(); got != "claude-sonnet" {
+		t.Fatalf("request body model = %q", got)
+	}
+	if len(transcript.Upstream) != 1 {
+		t.Fatalf("upstream attempts = %d, want 1", len(transcript.Upstream))
+	}
+	attempt := transcript.Upstream[0]
+	if attempt.URL != "https://api.anthropic.com/v1/messages" || attempt.Auth != "claude-1" || attempt.Response.Status != 200 {
+		t.Fatalf("unexpected attempt: %+v", attempt)
+	}
+	if got := gjson.GetBytes(attempt.Request.Body, "messages.0.content.0.type").String(); got != "text" {
+		t.Fatalf("translated request body not parsed: %s", attempt.Request.Body)
+	}
+	if len(attempt.Response.Chunks) != 2 {
+		t.Fatalf("upstream chunks = %d, want 2", len(attempt.Response.Chunks))
+	}
+	if transcript.Response.Status != 200 || len(transcript.Response.Chunks) != 3 {
+		t.Fatalf("unexpected response: %+v", transcript.Response)
+	}
+	if got := gjson.GetBytes(transcript.Usage, "total_tokens").Int(); got != 8 {
+		t.Fatalf("usage = %s, want downstream usage", transcript.Usage)
+	}
+
+	markdown := transcript.Markdown()
+	for _, want := range []string{"# Request transcript abc123", "## Upstream attempt 1", "3 streamed chunks", "## Usage"} {
+		if !strings.Contains(markdown, want) {
+			t.Fatalf("markdown missing %q:\n%s", want, markdown)
+		}
+	}
+}
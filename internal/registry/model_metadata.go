@@ -0,0 +1,225 @@
+package registry
+
+import (
+	"sort"
+	"strings"
+)
+
+// ModelMetadata overrides or supplements the capabilities reported for models in the
+// model listings. Entries come from the model-metadata configuration section.
+type ModelMetadata struct {
+	// Model is the model ID the entry applies to; '*' matches any sequence of characters.
+	Model string `yaml:"model" json:"model"`
+	// ContextLength is the context window size in tokens.
+	ContextLength int `yaml:"context-length,omitempty" json:"context-length,omitempty"`
+	// MaxOutputTokens is the maximum number of generated tokens.
+	MaxOutputTokens int `yaml:"max-output-tokens,omitempty" json:"max-output-tokens,omitempty"`
+	// InputModalities lists accepted input types (e.g. "text", "image", "audio").
+	InputModalities []string `yaml:"input-modalities,omitempty" json:"input-modalities,omitempty"`
+	// OutputModalities lists produced output types (e.g. "text", "image").
+	OutputModalities []string `yaml:"output-modalities,omitempty" json:"output-modalities,omitempty"`
+	// Pricing holds the model price in USD per million tokens.
+	Pricing *ModelPricing `yaml:"pricing,omitempty" json:"pricing,omitempty"`
+}
+
+// ModelPricing is the price of a model in USD per million tokens.
+type ModelPricing struct {
+	Input      float64 `yaml:"input,omitempty" json:"input,omitempty"`
+	Output     float64 `yaml:"output,omitempty" json:"output,omitempty"`
+	CacheRead  float64 `yaml:"cache-read,omitempty" json:"cache-read,omitempty"`
+	CacheWrite float64 `yaml:"cache-write,omitempty" json:"cache-write,omitempty"`
+}
+
+// modelDetails is the merged view of a model's capabilities used by the listings.
+type modelDetails struct {
+	contextLength    int
+	maxOutputTokens  int
+	inputModalities  []string
+	outputModalities []string
+	pricing          *ModelPricing
+	providers        []string
+}
+
+// SetModelMetadata replaces the configured model metadata overrides. Earlier entries take
+// precedence when several match the same model.
+func (r *ModelRegistry) SetModelMetadata(entries []ModelMetadata) {
+	cloned := make([]ModelMetadata, 0, len(entries))
+	for _, entry := range entries {
+		if strings.TrimSpace(entry.Model) == "" {
+			continue
+		}
+		entry.InputModalities = append([]string(nil), entry.InputModalities...)
+		entry.OutputModalities = append([]string(nil), entry.OutputModalities...)
+		if entry.Pricing != nil {
+			pricing := *entry.Pricing
+			entry.Pricing = &pricing
+		}
+		cloned = append(cloned, entry)
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.modelMetadata = cloned
+	r.invalidateAvailableModelsCacheLocked()
+}
+
+// ModelPricing returns the configured price of modelID, or nil when no model-metadata entry
+// with pricing matches it.
+func (r *ModelRegistry) ModelPricing(modelID string) *ModelPricing {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	for i := range r.modelMetadata {
+		entry := &r.modelMetadata[i]
+		if !metadataPatternMatches(entry.Model, modelID) {
+			continue
+		}
+		if entry.Pricing == nil {
+			return nil
+		}
+		pricing := *entry.Pricing
+		return &pricing
+	}
+	return nil
+}
+
+// resolveModelDetailsLocked merges discovered model info with configured overrides.
+func (r *ModelRegistry) resolveModelDetailsLocked(registration *ModelRegistration) modelDetails {
+	info := registration.Info
+	var details modelDetails
+	for provider, count := range registration.Providers {
+		if count > 0 {
+			details.providers = append(details.providers, provider)
+		}
+	}
+	sort.Strings(details.providers)
+
+	// Providers may report different subsets of the limits; fill gaps from each in turn.
+	sources := []*ModelInfo{info}
+	for _, provider := range details.providers {
+		if providerInfo := registration.InfoByProvider[provider]; providerInfo != nil && providerInfo != info {
+			sources = append(sources, providerInfo)
+		}
+	}
+	for _, source := range sources {
+		if details.contextLength <= 0 {
+			details.contextLength = max(source.ContextLength, 0)
+			if details.contextLength == 0 {
+				details.contextLength = max(source.InputTokenLimit, 0)
+			}
+		}
+		if details.maxOutputTokens <= 0 {
+			details.maxOutputTokens = max(source.MaxCompletionTokens, 0)
+			if details.maxOutputTokens == 0 {
+				details.maxOutputTokens = max(source.OutputTokenLimit, 0)
+			}
+		}
+		if len(details.inputModalities) == 0 {
+			details.inputModalities = lowerModalities(source.SupportedInputModalities)
+		}
+		if len(details.outputModalities) == 0 {
+			details.outputModalities = lowerModalities(source.SupportedOutputModalities)
+		}
+	}
+
+	for i := range r.modelMetadata {
+		entry := &r.modelMetadata[i]
+		if !metadataPatternMatches(entry.Model, info.ID) {
+			continue
+		}
+		if entry.ContextLength > 0 {
+			details.contextLength = entry.ContextLength
+		}
+		if entry.MaxOutputTokens > 0 {
+			details.maxOutputTokens = entry.MaxOutputTokens
+		}
+		if len(entry.InputModalities) > 0 {
+			details.inputModalities = lowerModalities(entry.InputModalities)
+		}
+		if len(entry.OutputModalities) > 0 {
+			details.outputModalities = lowerModalities(entry.OutputModalities)
+		}
+		if entry.Pricing != nil {
+			pricing := *entry.Pricing
+			details.pricing = &pricing
+		}
+		break
+	}
+	return details
+}
+
+// apply adds the details to an OpenAI or Anthropic style listing entry using the
+// given field names for the token limits.
+func (d modelDetails) apply(result map[string]any, contextKey, maxOutputKey string) {
+	if d.contextLength > 0 && contextKey != "" {
+		result[contextKey] = d.contextLength
+	}
+	if d.maxOutputTokens > 0 && maxOutputKey != "" {
+		result[maxOutputKey] = d.maxOutputTokens
+	}
+	if len(d.inputModalities) > 0 {
+		result["input_modalities"] = append([]string(nil), d.inputModalities...)
+	}
+	if len(d.outputModalities) > 0 {
+		result["output_modalities"] = append([]string(nil), d.outputModalities...)
+	}
+	d.applyCommon(result)
+}
+
+// applyCommon adds pricing and serving providers to a listing entry.
+func (d modelDetails) applyCommon(result map[string]any) {
+	if d.pricing != nil {
+		pricing := map[string]any{"currency": "USD", "unit": "1M tokens"}
+		if d.pricing.Input > 0 {
+			pricing["input"] = d.pricing.Input
+		}
+		if d.pricing.Output > 0 {
+			pricing["output"] = d.pricing.Output
+		}
+		if d.pricing.CacheRead > 0 {
+			pricing["cache_read"] = d.pricing.CacheRead
+		}
+		if d.pricing.CacheWrite > 0 {
+			pricing["cache_write"] = d.pricing.CacheWrite
+		}
+		result["pricing"] = pricing
+	}
+	if len(d.providers) > 0 {
+		result["providers"] = append([]string(nil), d.providers...)
+	}
+}
+
+func lowerModalities(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.ToLower(strings.TrimSpace(value)); value != "" {
+			out = append(out, value)
+		}
+	}
+	return out
+}
+
+// metadataPatternMatches reports whether model matches pattern case-insensitively, where '*'
+// matches any sequence of characters.
+func metadataPatternMatches(pattern, model string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	model = strings.ToLower(model)
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == model
+	}
+	if !strings.HasPrefix(model, parts[0]) {
+		return false
+	}
+	model = model[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(model, part)
+		if idx < 0 {
+			return false
+		}
+		model = model[idx+len(part):]
+	}
+	return strings.HasSuffix(model, last)
+}
@@ -0,0 +1,99 @@
+package registry
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetAvailableModelsIncludesMergedMetadata(t *testing.T) {
+	r := newTestModelRegistry()
+	r.RegisterClient("client-1", "codex", []*ModelInfo{{ID: "gpt-x", OwnedBy: "openai", ContextLength: 1000, MaxCompletionTokens: 100}})
+	r.RegisterClient("client-2", "openai-compat", []*ModelInfo{{ID: "gpt-x", OwnedBy: "openai"}})
+	r.RegisterClient("client-3", "gemini", []*ModelInfo{{ID: "gemini-y", InputTokenLimit: 2000, OutputTokenLimit: 200, SupportedInputModalities: []string{"TEXT", "IMAGE"}}})
+
+	models := r.GetAvailableModels("openai")
+	byID := make(map[string]map[string]any, len(models))
+	for _, model := range models {
+		byID[model["id"].(string)] = model
+	}
+	if got := byID["gpt-x"]["providers"]; !reflect.DeepEqual(got, []string{"codex", "openai-compat"}) {
+		t.Fatalf("providers = %v", got)
+	}
+	if got := byID["gemini-y"]["context_length"]; got != 2000 {
+		t.Fatalf("context_length from gemini limits = %v, want 2000", got)
+	}
+	if got := byID["gemini-y"]["input_modalities"]; !reflect.DeepEqual(got, []string{"text", "image"}) {
+		t.Fatalf("input_modalities = %v", got)
+	}
+
+	r.SetModelMetadata([]ModelMetadata{
+		{Model: "GPT-*", ContextLength: 5000, Pricing: &ModelPricing{Input: 1.5, Output: 6}},
+		{Model: "gpt-x", ContextLength: 1},
+	})
+	claudeModels := r.GetAvailableModels("claude")
+	var gpt map[string]any
+	for _, model := range claudeModels {
+		if model["id"] == "gpt-x" {
+			gpt = model
+		}
+	}
+	if gpt == nil {
+		t.Fatal("gpt-x missing from claude listing")
+	}
+	if gpt["max_input_tokens"] != 5000 || gpt["max_tokens"] != 100 {
+		t.Fatalf("claude limits = %v / %v, want first matching override and discovered output limit", gpt["max_input_tokens"], gpt["max_tokens"])
+	}
+	pricing, ok := gpt["pricing"].(map[string]any)
+	if !ok || pricing["input"] != 1.5 || pricing["output"] != 6.0 || pricing["currency"] != "USD" {
+		t.Fatalf("pricing = %v", gpt["pricing"])
+	}
+
+	for _, model := range r.GetAvailableModels("gemini") {
+		if model["name"] == "gemini-y" {
+			if got := model["supportedInputModalities"]; !reflect.DeepEqual(got, []string{"TEXT", "IMAGE"}) {
+				t.Fatalf("gemini supportedInputModalities = %v", got)
+			}
+			return
+		}
+	}
+	t.Fatal("gemini-y missing from gemini listing")
+}
+
+func TestMetadataPatternMatches(t *testing.T) {
+	cases := []struct {
+		pattern, model string
+		want           bool
+	}{
+		{"gpt-5", "gpt-5", true},
+		{"gpt-5", "gpt-5-mini", false},
+		{"gpt-5*", "GPT-5-mini", true},
+		{"*-pro", "gemini-2.5-pro", true},
+		{"gemini-*-pro", "gemini-2.5-flash", false},
+		{"a*b*b", "ab", false},
+		{"*", "anything", true},
+	}
+	for _, tc := range cases {
+		if got := metadataPatternMatches(tc.pattern, tc.model); got != tc.want {
+			t.Errorf("metadataPatternMatches(%q, %q) = %v, want %v", tc.pattern, tc.model, got, tc.want)
+		}
+	}
+}
+
+func TestModelPricingUsesFirstMatchingEntry(t *testing.T) {
+	r := newTestModelRegistry()
+	r.SetModelMetadata([]ModelMetadata{
+		{Model: "claude-*", Pricing: &ModelPricing{Input: 3, Output: 15, CacheRead: 0.3}},
+		{Model: "gpt-x", ContextLength: 1000},
+		{Model: "*", Pricing: &ModelPricing{Input: 1}},
+	})
+
+	if got := r.ModelPricing("Claude-Sonnet"); got == nil || got.CacheRead != 0.3 {
+		t.Fatalf("ModelPricing(claude) = %+v, want cache-read 0.3", got)
+	}
+	if got := r.ModelPricing("gpt-x"); got != nil {
+		t.Fatalf("ModelPricing(gpt-x) = %+v, want nil from first matching entry", got)
+	}
+	if got := r.ModelPricing("other"); got == nil || got.Input != 1 {
+		t.Fatalf("ModelPricing(other) = %+v, want wildcard pricing", got)
+	}
+}
@@ -125,6 +125,8 @@ type ModelRegistry struct {
 	availableModelsCache map[string]availableModelsCacheEntry
 	// hook is an optional callback sink for model registration changes
 	hook ModelRegistryHook
+	// modelMetadata holds configured capability and pricing overrides for the listings.
+	modelMetadata []ModelMetadata
 }
 
 // Global model registry instance
@@ -824,6 +826,7 @@ func (r *ModelRegistry) buildAvailableModelsLocked(handlerType string, now time.
 		if effectiveClients > 0 || (availableClients > 0 && (expiredClients > 0 || cooldownSuspended > 0) && otherSuspended == 0) {
 			model := r.convertModelToMap(registration.Info, handlerType)
 			if model != nil {
+				r.addModelDetails(model, registration, handlerType)
 				models = append(models, model)
 			}
 		}
@@ -1212,6 +1215,45 @@ func (r *ModelRegistry) convertModelToMap(model *ModelInfo, handlerType string)
 	}
 }
 
+// addModelDetails adds the merged context window, output limit, modalities, pricing and
+// serving providers to a listing entry in the handler's format.
+func (r *ModelRegistry) addModelDetails(result map[string]any, registration *ModelRegistration, handlerType string) {
+	if registration == nil || registration.Info == nil {
+		return
+	}
+	details := r.resolveModelDetailsLocked(registration)
+	switch handlerType {
+	case "openai":
+		details.apply(result, "context_length", "max_completion_tokens")
+	case "claude":
+		details.apply(result, "max_input_tokens", "max_tokens")
+	case "gemini":
+		if details.contextLength > 0 {
+			result["inputTokenLimit"] = details.contextLength
+		}
+		if details.maxOutputTokens > 0 {
+			result["outputTokenLimit"] = details.maxOutputTokens
+		}
+		if len(details.inputModalities) > 0 {
+			result["supportedInputModalities"] = geminiModalities(details.inputModalities)
+		}
+		if len(details.outputModalities) > 0 {
+			result["supportedOutputModalities"] = geminiModalities(details.outputModalities)
+		}
+		details.applyCommon(result)
+	default:
+		details.apply(result, "context_length", "max_completion_tokens")
+	}
+}
+
+func geminiModalities(values []string) []string {
+	out := make([]string, len(values))
+	for i, value := range values {
+		out[i] = strings.ToUpper(value)
+	}
+	return out
+}
+
 // CleanupExpiredQuotas removes expired quota tracking entries
 func (r *ModelRegistry) CleanupExpiredQuotas() {
 	r.mutex.Lock()
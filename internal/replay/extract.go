@@ -0,0 +1,166 @@
+package replay
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// maxDiffLines bounds the number of lines per side fed into the text diff.
+const maxDiffLines = 400
+
+// ToolCall is a tool invocation extracted from a response.
+type ToolCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments,omitempty"`
+}
+
+// responseSummary is the comparable content of a response in its client format.
+type responseSummary struct {
+	text         string
+	toolCalls    []ToolCall
+	inputTokens  int64
+	outputTokens int64
+}
+
+// summarizeResponse extracts text, tool calls and token usage from a response body.
+func summarizeResponse(format string, body []byte) responseSummary {
+	root := gjson.ParseBytes(body)
+	var out responseSummary
+	var text strings.Builder
+	switch format {
+	case "claude":
+		root.Get("content").ForEach(func(_, block gjson.Result) bool {
+			switch block.Get("type").String() {
+			case "text":
+				text.WriteString(block.Get("text").String())
+			case "tool_use":
+				out.toolCalls = append(out.toolCalls, ToolCall{Name: block.Get("name").String(), Arguments: compactJSON(block.Get("input"))})
+			}
+			return true
+		})
+		out.inputTokens = root.Get("usage.input_tokens").Int()
+		out.outputTokens = root.Get("usage.output_tokens").Int()
+	case "openai-response":
+		root.Get("output").ForEach(func(_, item gjson.Result) bool {
+			switch item.Get("type").String() {
+			case "message":
+				item.Get("content").ForEach(func(_, part gjson.Result) bool {
+					if part.Get("type").String() == "output_text" {
+						text.WriteString(part.Get("text").String())
+					}
+					return true
+				})
+			case "function_call":
+				out.toolCalls = append(out.toolCalls, ToolCall{Name: item.Get("name").String(), Arguments: compactJSONString(item.Get("arguments").String())})
+			}
+			return true
+		})
+		out.inputTokens = root.Get("usage.input_tokens").Int()
+		out.outputTokens = root.Get("usage.output_tokens").Int()
+	case "gemini":
+		root.Get("candidates.0.content.parts").ForEach(func(_, part gjson.Result) bool {
+			if call := part.Get("functionCall"); call.Exists() {
+				out.toolCalls = append(out.toolCalls, ToolCall{Name: call.Get("name").String(), Arguments: compactJSON(call.Get("args"))})
+			} else if !part.Get("thought").Bool() {
+				text.WriteString(part.Get("text").String())
+			}
+			return true
+		})
+		out.inputTokens = root.Get("usageMetadata.promptTokenCount").Int()
+		out.outputTokens = root.Get("usageMetadata.candidatesTokenCount").Int()
+	default:
+		message := root.Get("choices.0.message")
+		text.WriteString(message.Get("content").String())
+		message.Get("tool_calls").ForEach(func(_, call gjson.Result) bool {
+			out.toolCalls = append(out.toolCalls, ToolCall{Name: call.Get("function.name").String(), Arguments: compactJSONString(call.Get("function.arguments").String())})
+			return true
+		})
+		out.inputTokens = root.Get("usage.prompt_tokens").Int()
+		out.outputTokens = root.Get("usage.completion_tokens").Int()
+	}
+	out.text = text.String()
+	return out
+}
+
+func compactJSON(value gjson.Result) string {
+	if !value.Exists() {
+		return ""
+	}
+	return compactJSONString(value.Raw)
+}
+
+func compactJSONString(raw string) string {
+	if raw == "" || !gjson.Valid(raw) {
+		return raw
+	}
+	return gjson.Get(raw, "@ugly").Raw
+}
+
+// toolCallDivergence describes the first difference between two tool call sequences,
+// or returns "" when they match.
+func toolCallDivergence(a, b []ToolCall) string {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i].Name != b[i].Name {
+			return fmt.Sprintf("call %d: %s vs %s", i+1, a[i].Name, b[i].Name)
+		}
+		if a[i].Arguments != b[i].Arguments {
+			return fmt.Sprintf("call %d (%s): arguments differ", i+1, a[i].Name)
+		}
+	}
+	if len(a) != len(b) {
+		return fmt.Sprintf("%d vs %d tool calls", len(a), len(b))
+	}
+	return ""
+}
+
+// lineDiff returns a line-oriented diff of a and b: unchanged lines are prefixed with
+// "  ", removed lines with "- " and added lines with "+ ".
+func lineDiff(a, b string) string {
+	left := strings.Split(a, "\n")
+	right := strings.Split(b, "\n")
+	truncated := false
+	if len(left) > maxDiffLines {
+		left, truncated = left[:maxDiffLines], true
+	}
+	if len(right) > maxDiffLines {
+		right, truncated = right[:maxDiffLines], true
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of left[i:] and right[j:].
+	lcs := make([][]int, len(left)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(right)+1)
+	}
+	for i := len(left) - 1; i >= 0; i-- {
+		for j := len(right) - 1; j >= 0; j-- {
+			if left[i] == right[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out strings.Builder
+	i, j := 0, 0
+	for i < len(left) || j < len(right) {
+		switch {
+		case i < len(left) && j < len(right) && left[i] == right[j]:
+			out.WriteString("  " + left[i] + "\n")
+			i++
+			j++
+		case i < len(left) && (j == len(right) || lcs[i+1][j] >= lcs[i][j+1]):
+			out.WriteString("- " + left[i] + "\n")
+			i++
+		default:
+			out.WriteString("+ " + right[j] + "\n")
+			j++
+		}
+	}
+	if truncated {
+		out.WriteString("... (diff truncated)\n")
+	}
+	return out.String()
+}
@@ -0,0 +1,337 @@
+package replay
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// MaxRequestsPerJob bounds the number of captured requests in one comparison job.
+	MaxRequestsPerJob = 200
+	// maxConcurrency bounds how many captured requests are replayed in parallel.
+	maxConcurrency = 8
+	// maxRetainedJobs bounds the number of jobs kept in memory; the oldest finished jobs are evicted.
+	maxRetainedJobs = 20
+)
+
+// Job states.
+const (
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusCancelled = "cancelled"
+)
+
+// ExecuteFunc runs a non-streaming request in format against model, optionally pinned to
+// authID, and returns the response body or the HTTP status and error of the failure.
+type ExecuteFunc func(ctx context.Context, format, model, authID string, body []byte) ([]byte, int, error)
+
+// Target is one side of a comparison.
+type Target struct {
+	Model string `json:"model"`
+	// AuthID optionally pins the target to a credential (auth ID), selecting its provider.
+	AuthID string `json:"auth_id,omitempty"`
+}
+
+// Result is the outcome of replaying one request against one target.
+type Result struct {
+	Target       Target     `json:"target"`
+	Status       int        `json:"status"`
+	LatencyMs    int64      `json:"latency_ms"`
+	InputTokens  int64      `json:"input_tokens"`
+	OutputTokens int64      `json:"output_tokens"`
+	Text         string     `json:"text,omitempty"`
+	ToolCalls    []ToolCall `json:"tool_calls,omitempty"`
+	Error        string     `json:"error,omitempty"`
+}
+
+// Comparison holds the results of one captured request against both targets.
+type Comparison struct {
+	RequestID     string   `json:"request_id"`
+	Format        string   `json:"format"`
+	OriginalModel string   `json:"original_model,omitempty"`
+	Results       []Result `json:"results"`
+	TextIdentical bool     `json:"text_identical"`
+	TextDiff      string   `json:"text_diff,omitempty"`
+	// ToolCallDivergence describes the first tool call difference, if any.
+	ToolCallDivergence string `json:"tool_call_divergence,omitempty"`
+	Error              string `json:"error,omitempty"`
+}
+
+// TargetSummary aggregates the results of one target across a job.
+type TargetSummary struct {
+	Target            Target `json:"target"`
+	Requests          int    `json:"requests"`
+	Errors            int    `json:"errors"`
+	AvgLatencyMs      int64  `json:"avg_latency_ms"`
+	P50LatencyMs      int64  `json:"p50_latency_ms"`
+	TotalInputTokens  int64  `json:"total_input_tokens"`
+	TotalOutputTokens int64  `json:"total_output_tokens"`
+}
+
+// Summary aggregates a finished or running job.
+type Summary struct {
+	Targets             []TargetSummary `json:"targets"`
+	Compared            int             `json:"compared"`
+	IdenticalText       int             `json:"identical_text"`
+	ToolCallDivergences int             `json:"tool_call_divergences"`
+}
+
+// Job is an asynchronous comparison of captured requests against two targets.
+type Job struct {
+	ID          string       `json:"id"`
+	Status      string       `json:"status"`
+	CreatedAt   time.Time    `json:"created_at"`
+	FinishedAt  *time.Time   `json:"finished_at,omitempty"`
+	Targets     []Target     `json:"targets"`
+	Total       int          `json:"total"`
+	Done        int          `json:"done"`
+	Summary     Summary      `json:"summary"`
+	Comparisons []Comparison `json:"comparisons,omitempty"`
+
+	cancel context.CancelFunc
+}
+
+// Manager runs and retains comparison jobs.
+type Manager struct {
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+// NewManager creates an empty job manager.
+func NewManager() *Manager {
+	return &Manager{jobs: make(map[string]*Job)}
+}
+
+// Start launches a job that replays requests against both targets and returns its ID.
+// loadErrors maps request IDs whose logs could not be loaded to the reason; they are
+// reported as failed comparisons.
+func (m *Manager) Start(requests []CapturedRequest, loadErrors map[string]string, targets [2]Target, concurrency int, execute ExecuteFunc) string {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	concurrency = min(concurrency, maxConcurrency)
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &Job{
+		ID:          newJobID(),
+		Status:      StatusRunning,
+		CreatedAt:   time.Now().UTC(),
+		Targets:     targets[:],
+		Total:       len(requests) + len(loadErrors),
+		Comparisons: make([]Comparison, 0, len(requests)+len(loadErrors)),
+		cancel:      cancel,
+	}
+	for id, msg := range loadErrors {
+		job.Comparisons = append(job.Comparisons, Comparison{RequestID: id, Error: msg})
+		job.Done++
+	}
+
+	m.mu.Lock()
+	m.evictLocked()
+	m.jobs[job.ID] = job
+	m.mu.Unlock()
+
+	go m.run(ctx, job, requests, targets, concurrency, execute)
+	return job.ID
+}
+
+func (m *Manager) run(ctx context.Context, job *Job, requests []CapturedRequest, targets [2]Target, concurrency int, execute ExecuteFunc) {
+	queue := make(chan CapturedRequest)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for req := range queue {
+				comparison := compare(ctx, req, targets, execute)
+				m.mu.Lock()
+				job.Comparisons = append(job.Comparisons, comparison)
+				job.Done++
+				m.mu.Unlock()
+			}
+		}()
+	}
+feed:
+	for _, req := range requests {
+		select {
+		case <-ctx.Done():
+			break feed
+		case queue <- req:
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	finished := time.Now().UTC()
+	job.FinishedAt = &finished
+	if ctx.Err() != nil {
+		job.Status = StatusCancelled
+	} else {
+		job.Status = StatusCompleted
+	}
+	job.cancel()
+}
+
+// compare replays req against both targets concurrently.
+func compare(ctx context.Context, req CapturedRequest, targets [2]Target, execute ExecuteFunc) Comparison {
+	comparison := Comparison{RequestID: req.RequestID, Format: req.Format, OriginalModel: req.Model, Results: make([]Result, len(targets))}
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := Result{Target: target}
+			start := time.Now()
+			body, status, err := execute(ctx, req.Format, target.Model, target.AuthID, req.payloadFor(target.Model))
+			result.LatencyMs = time.Since(start).Milliseconds()
+			result.Status = status
+			if err != nil {
+				result.Error = err.Error()
+			} else {
+				summary := summarizeResponse(req.Format, body)
+				result.Text = summary.text
+				result.ToolCalls = summary.toolCalls
+				result.InputTokens = summary.inputTokens
+				result.OutputTokens = summary.outputTokens
+			}
+			comparison.Results[i] = result
+		}()
+	}
+	wg.Wait()
+
+	left, right := comparison.Results[0], comparison.Results[1]
+	if left.Error != "" || right.Error != "" {
+		return comparison
+	}
+	comparison.TextIdentical = left.Text == right.Text
+	if !comparison.TextIdentical {
+		comparison.TextDiff = lineDiff(left.Text, right.Text)
+	}
+	comparison.ToolCallDivergence = toolCallDivergence(left.ToolCalls, right.ToolCalls)
+	return comparison
+}
+
+// Get returns a snapshot of the job with its comparisons and an up-to-date summary.
+func (m *Manager) Get(id string) (Job, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	snapshot := *job
+	snapshot.Comparisons = append([]Comparison(nil), job.Comparisons...)
+	sort.Slice(snapshot.Comparisons, func(i, j int) bool {
+		return snapshot.Comparisons[i].RequestID < snapshot.Comparisons[j].RequestID
+	})
+	snapshot.Summary = summarize(job.Targets, snapshot.Comparisons)
+	return snapshot, true
+}
+
+// List returns all retained jobs, newest first, without per-request comparisons.
+func (m *Manager) List() []Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	jobs := make([]Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		snapshot := *job
+		snapshot.Summary = summarize(job.Targets, job.Comparisons)
+		snapshot.Comparisons = nil
+		jobs = append(jobs, snapshot)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].CreatedAt.After(jobs[j].CreatedAt) })
+	return jobs
+}
+
+// Delete cancels a running job and removes it. It reports whether the job existed.
+func (m *Manager) Delete(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	if !ok {
+		return false
+	}
+	job.cancel()
+	delete(m.jobs, id)
+	return true
+}
+
+// evictLocked drops the oldest finished jobs so that a new job fits within maxRetainedJobs.
+func (m *Manager) evictLocked() {
+	for len(m.jobs) >= maxRetainedJobs {
+		var oldest *Job
+		for _, job := range m.jobs {
+			if job.Status == StatusRunning {
+				continue
+			}
+			if oldest == nil || job.CreatedAt.Before(oldest.CreatedAt) {
+				oldest = job
+			}
+		}
+		if oldest == nil {
+			return
+		}
+		delete(m.jobs, oldest.ID)
+	}
+}
+
+func summarize(targets []Target, comparisons []Comparison) Summary {
+	summary := Summary{Targets: make([]TargetSummary, len(targets))}
+	latencies := make([][]int64, len(targets))
+	for i, target := range targets {
+		summary.Targets[i].Target = target
+	}
+	for _, comparison := range comparisons {
+		if len(comparison.Results) != len(targets) {
+			continue
+		}
+		bothOK := true
+		for i, result := range comparison.Results {
+			item := &summary.Targets[i]
+			item.Requests++
+			if result.Error != "" {
+				item.Errors++
+				bothOK = false
+				continue
+			}
+			latencies[i] = append(latencies[i], result.LatencyMs)
+			item.TotalInputTokens += result.InputTokens
+			item.TotalOutputTokens += result.OutputTokens
+		}
+		if !bothOK {
+			continue
+		}
+		summary.Compared++
+		if comparison.TextIdentical {
+			summary.IdenticalText++
+		}
+		if comparison.ToolCallDivergence != "" {
+			summary.ToolCallDivergences++
+		}
+	}
+	for i, values := range latencies {
+		if len(values) == 0 {
+			continue
+		}
+		var total int64
+		for _, v := range values {
+			total += v
+		}
+		sorted := append([]int64(nil), values...)
+		sort.Slice(sorted, func(a, b int) bool { return sorted[a] < sorted[b] })
+		summary.Targets[i].AvgLatencyMs = total / int64(len(values))
+		summary.Targets[i].P50LatencyMs = sorted[len(sorted)/2]
+	}
+	return summary
+}
+
+func newJobID() string {
+	var buf [8]byte
+	_, _ = rand.Read(buf[:])
+	return hex.EncodeToString(buf[:])
+}
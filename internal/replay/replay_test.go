@@ -0,0 +1,100 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+const capturedLog = `=== REQUEST INFO ===
+Version: dev
+URL: /v1/chat/completions
+Method: POST
+Timestamp: 2026-03-20T12:00:00Z
+
+=== HEADERS ===
+Content-Type: application/json
+
+=== REQUEST BODY ===
+{"model":"claude-sonnet","stream":true,"messages":[{"role":"user","content":"hi"}]}
+
+=== RESPONSE ===
+Status: 200
+
+data: {"choices":[]}
+`
+
+func TestLoadCapturedRequest(t *testing.T) {
+	req, err := LoadCapturedRequest("abc", []byte(capturedLog))
+	if err != nil {
+		t.Fatalf("LoadCapturedRequest: %v", err)
+	}
+	if req.Format != "openai" || req.Model != "claude-sonnet" {
+		t.Fatalf("unexpected request: %+v", req)
+	}
+	payload := req.payloadFor("gpt-5")
+	if gjson.GetBytes(payload, "model").String() != "gpt-5" || gjson.GetBytes(payload, "stream").Exists() {
+		t.Fatalf("payload not prepared for replay: %s", payload)
+	}
+
+	if _, err = LoadCapturedRequest("bad", []byte(strings.Replace(capturedLog, "/v1/chat/completions", "/v0/management/config", 1))); err == nil {
+		t.Fatal("non-model endpoints must not be replayable")
+	}
+}
+
+func TestManagerComparesTargets(t *testing.T) {
+	req, err := LoadCapturedRequest("abc", []byte(capturedLog))
+	if err != nil {
+		t.Fatalf("LoadCapturedRequest: %v", err)
+	}
+	execute := func(_ context.Context, format, model, authID string, body []byte) ([]byte, int, error) {
+		if format != "openai" || gjson.GetBytes(body, "model").String() != model {
+			return nil, http.StatusBadRequest, errors.New("unexpected request")
+		}
+		if model == "a" {
+			return []byte(`{"choices":[{"message":{"content":"hello\nworld","tool_calls":[{"function":{"name":"search","arguments":"{\"q\": 1}"}}]}}],"usage":{"prompt_tokens":3,"completion_tokens":5}}`), http.StatusOK, nil
+		}
+		return []byte(`{"choices":[{"message":{"content":"hello\nthere","tool_calls":[{"function":{"name":"fetch","arguments":"{}"}}]}}],"usage":{"prompt_tokens":4,"completion_tokens":6}}`), http.StatusOK, nil
+	}
+
+	manager := NewManager()
+	id := manager.Start([]CapturedRequest{req}, map[string]string{"missing": "log file not found"}, [2]Target{{Model: "a"}, {Model: "b"}}, 2, execute)
+
+	var job Job
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		job, _ = manager.Get(id)
+		if job.Status != StatusRunning || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if job.Status != StatusCompleted || job.Done != 2 || len(job.Comparisons) != 2 {
+		t.Fatalf("unexpected job: %+v", job)
+	}
+	comparison := job.Comparisons[0]
+	if comparison.RequestID != "abc" {
+		comparison = job.Comparisons[1]
+	}
+	if comparison.TextIdentical || !strings.Contains(comparison.TextDiff, "- world\n+ there\n") {
+		t.Fatalf("unexpected diff: %q", comparison.TextDiff)
+	}
+	if comparison.ToolCallDivergence != "call 1: search vs fetch" {
+		t.Fatalf("tool call divergence = %q", comparison.ToolCallDivergence)
+	}
+	if job.Summary.Compared != 1 || job.Summary.ToolCallDivergences != 1 || job.Summary.Targets[1].TotalOutputTokens != 6 {
+		t.Fatalf("unexpected summary: %+v", job.Summary)
+	}
+	if got := comparison.Results[0].ToolCalls[0].Arguments; got != `{"q":1}` {
+		t.Fatalf("arguments not normalized: %q", got)
+	}
+
+	if !manager.Delete(id) || len(manager.List()) != 0 {
+		t.Fatal("Delete should remove the job")
+	}
+}
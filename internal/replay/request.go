@@ -0,0 +1,84 @@
+// Package replay re-executes requests captured in request logs against alternative
+// models or credentials and compares the outcomes side by side.
+package replay
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// CapturedRequest is an inbound request recovered from a request log.
+type CapturedRequest struct {
+	RequestID string
+	// Format is the handler type of the original endpoint ("openai", "claude", ...).
+	Format string
+	// Model is the model requested by the original client.
+	Model string
+	Body  []byte
+}
+
+// LoadCapturedRequest parses a request log file and extracts the inbound request.
+func LoadCapturedRequest(requestID string, content []byte) (CapturedRequest, error) {
+	transcript := logging.ParseRequestTranscript(requestID, content)
+	req := CapturedRequest{RequestID: requestID}
+
+	rawURL := transcript.Info["URL"]
+	path := rawURL
+	if parsed, errParse := url.Parse(rawURL); errParse == nil {
+		path = parsed.Path
+	}
+	switch {
+	case strings.HasPrefix(path, "/v1/chat/completions"):
+		req.Format = "openai"
+	case strings.HasPrefix(path, "/v1/messages"):
+		req.Format = "claude"
+	case strings.HasPrefix(path, "/v1/responses"):
+		req.Format = "openai-response"
+	case strings.HasPrefix(path, "/v1beta/models/"):
+		req.Format = "gemini"
+		action := strings.TrimPrefix(path, "/v1beta/models/")
+		if model, _, ok := strings.Cut(action, ":"); ok {
+			req.Model = model
+		}
+	default:
+		return req, fmt.Errorf("request %s: endpoint %q cannot be replayed", requestID, rawURL)
+	}
+
+	body := bytes.TrimSpace(transcript.Request.Body)
+	if len(body) == 0 || !gjson.ValidBytes(body) || !gjson.ParseBytes(body).IsObject() {
+		return req, fmt.Errorf("request %s: log does not contain a JSON request body", requestID)
+	}
+	req.Body = body
+	if req.Model == "" {
+		req.Model = gjson.GetBytes(body, "model").String()
+	}
+	return req, nil
+}
+
+// payloadFor returns the request body prepared for a non-streaming call to model.
+func (r CapturedRequest) payloadFor(model string) []byte {
+	body := r.Body
+	if r.Format == "gemini" {
+		return body
+	}
+	if updated, errSet := sjson.SetBytes(body, "model", model); errSet == nil {
+		body = updated
+	}
+	if gjson.GetBytes(body, "stream").Exists() {
+		if updated, errDelete := sjson.DeleteBytes(body, "stream"); errDelete == nil {
+			body = updated
+		}
+	}
+	if gjson.GetBytes(body, "stream_options").Exists() {
+		if updated, errDelete := sjson.DeleteBytes(body, "stream_options"); errDelete == nil {
+			body = updated
+		}
+	}
+	return body
+}
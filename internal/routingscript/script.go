@@ -0,0 +1,109 @@
+// Package routingscript compiles the expressions of routing-scripts rules with expr
+// (github.com/expr-lang/expr). An expression is evaluated per request against its model,
+// format, headers, estimated token count and the local time, and must yield a boolean.
+//
+// Variables: model, format, api_key, stream, tokens, hour, minute, weekday.
+// Functions: header(name) and the expr builtins such as lower, upper and len. Strings are
+// compared with the expr operators contains, startsWith, endsWith and matches (regexp).
+package routingscript
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/ast"
+	"github.com/expr-lang/expr/vm"
+)
+
+// Request holds the per-request values visible to an expression.
+type Request struct {
+	Model   string
+	Format  string
+	APIKey  string
+	Stream  bool
+	Tokens  int
+	Time    time.Time
+	Headers http.Header
+}
+
+// env is the expression environment built from a Request.
+type env struct {
+	Model   string              `expr:"model"`
+	Format  string              `expr:"format"`
+	APIKey  string              `expr:"api_key"`
+	Stream  bool                `expr:"stream"`
+	Tokens  int                 `expr:"tokens"`
+	Hour    int                 `expr:"hour"`
+	Minute  int                 `expr:"minute"`
+	Weekday string              `expr:"weekday"`
+	Header  func(string) string `expr:"header"`
+}
+
+// Program is a compiled expression. It is safe for concurrent use.
+type Program struct {
+	source     string
+	program    *vm.Program
+	usesTokens bool
+}
+
+// Compile parses source and type-checks it against the request variables.
+func Compile(source string) (*Program, error) {
+	if strings.TrimSpace(source) == "" {
+		return nil, fmt.Errorf("empty expression")
+	}
+	program, err := expr.Compile(source, expr.Env(env{}), expr.AsBool())
+	if err != nil {
+		return nil, err
+	}
+	root := program.Node()
+	visitor := &identifierVisitor{name: "tokens"}
+	ast.Walk(&root, visitor)
+	return &Program{source: source, program: program, usesTokens: visitor.found}, nil
+}
+
+// Eval evaluates the program against req and reports whether it matched.
+func (p *Program) Eval(req Request) (bool, error) {
+	if p == nil {
+		return false, fmt.Errorf("routingscript: nil program")
+	}
+	if req.Time.IsZero() {
+		req.Time = time.Now()
+	}
+	out, err := expr.Run(p.program, env{
+		Model:   req.Model,
+		Format:  req.Format,
+		APIKey:  req.APIKey,
+		Stream:  req.Stream,
+		Tokens:  req.Tokens,
+		Hour:    req.Time.Hour(),
+		Minute:  req.Time.Minute(),
+		Weekday: strings.ToLower(req.Time.Weekday().String()),
+		Header:  req.Headers.Get,
+	})
+	if err != nil {
+		return false, err
+	}
+	matched, _ := out.(bool)
+	return matched, nil
+}
+
+// UsesTokens reports whether the expression reads the estimated token count, which callers
+// only need to compute for such expressions.
+func (p *Program) UsesTokens() bool { return p != nil && p.usesTokens }
+
+// String returns the expression source.
+func (p *Program) String() string { return p.source }
+
+type identifierVisitor struct {
+	name  string
+	found bool
+}
+
+func (v *identifierVisitor) Visit(node *ast.Node) {
+	if ident, ok := (*node).(*ast.IdentifierNode); ok && ident.Value == v.name {
+		v.found = true
+	}
+}
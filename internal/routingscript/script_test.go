@@ -0,0 +1,78 @@
+package routingscript
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestEval(t *testing.T) {
+	req := Request{
+		Model:   "claude-sonnet-4-5",
+		Format:  "openai",
+		Stream:  true,
+		Tokens:  120000,
+		Time:    time.Date(2026, 3, 21, 23, 30, 0, 0, time.UTC), // Saturday
+		Headers: http.Header{"X-Team": []string{"Research"}},
+	}
+	cases := map[string]bool{
+		`tokens > 100_000 && model startsWith "claude-"`:               true,
+		`stream && !(hour >= 9 && hour < 18)`:                          true,
+		`weekday == "saturday" || weekday == "sunday"`:                 true,
+		`lower(header("x-team")) == 'research'`:                        true,
+		`header("X-Missing") != ""`:                                    false,
+		`model matches "^claude-(opus|sonnet)" and format == "openai"`: true,
+		`tokens % 7 == 6 && tokens / 1000 == 120`:                      true,
+		`model contains "opus" || len(model) < 5`:                      false,
+		`-tokens < 0 && "a" + "b" == "ab"`:                             true,
+	}
+	for source, want := range cases {
+		program, err := Compile(source)
+		if err != nil {
+			t.Fatalf("Compile(%q): %v", source, err)
+		}
+		got, err := program.Eval(req)
+		if err != nil {
+			t.Fatalf("Eval(%q): %v", source, err)
+		}
+		if got != want {
+			t.Errorf("Eval(%q) = %v, want %v", source, got, want)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, source := range []string{
+		``,
+		`tokens >`,
+		`unknown_var == 1`,
+		`nope(model)`,
+		`header()`,
+		`(model == "a"`,
+		`model == "a`,
+		`model matches "("`,
+		`model`,
+		`model > 1`,
+		`tokens && stream`,
+	} {
+		if _, err := Compile(source); err == nil {
+			t.Errorf("Compile(%q) succeeded, want error", source)
+		}
+	}
+}
+
+func TestUsesTokens(t *testing.T) {
+	for source, want := range map[string]bool{
+		`tokens > 1000`:                      true,
+		`stream || (hour < 6 && tokens > 1)`: true,
+		`model == "tokens"`:                  false,
+	} {
+		program, err := Compile(source)
+		if err != nil {
+			t.Fatalf("Compile(%q): %v", source, err)
+		}
+		if got := program.UsesTokens(); got != want {
+			t.Errorf("UsesTokens(%q) = %v, want %v", source, got, want)
+		}
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/lifecycle"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor/helps"
@@ -184,60 +185,24 @@ func NewAntigravityExecutor(cfg *config.Config) *AntigravityExecutor {
 	return &AntigravityExecutor{cfg: cfg}
 }
 
-// antigravityTransport is a singleton HTTP/1.1 transport shared by all Antigravity requests.
-// It is initialized once via antigravityTransportOnce to avoid leaking a new connection pool
-// (and the goroutines managing it) on every request.
-var (
-	antigravityTransport     *http.Transport
-	antigravityTransportOnce sync.Once
-)
-
-func cloneTransportWithHTTP11(base *http.Transport) *http.Transport {
-	if base == nil {
-		return nil
-	}
-
-	clone := base.Clone()
-	clone.ForceAttemptHTTP2 = false
+// forceHTTP11 disables HTTP/2 on transport and advertises only HTTP/1.1 in the ALPN handshake.
+func forceHTTP11(transport *http.Transport) {
+	transport.ForceAttemptHTTP2 = false
 	// Wipe TLSNextProto to prevent implicit HTTP/2 upgrade.
-	clone.TLSNextProto = make(map[string]func(authority string, c *tls.Conn) http.RoundTripper)
-	if clone.TLSClientConfig == nil {
-		clone.TLSClientConfig = &tls.Config{}
+	transport.TLSNextProto = make(map[string]func(authority string, c *tls.Conn) http.RoundTripper)
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
 	} else {
-		clone.TLSClientConfig = clone.TLSClientConfig.Clone()
+		transport.TLSClientConfig = transport.TLSClientConfig.Clone()
 	}
-	// Actively advertise only HTTP/1.1 in the ALPN handshake.
-	clone.TLSClientConfig.NextProtos = []string{"http/1.1"}
-	return clone
-}
-
-// initAntigravityTransport creates the shared HTTP/1.1 transport exactly once.
-func initAntigravityTransport() {
-	base, ok := http.DefaultTransport.(*http.Transport)
-	if !ok {
-		base = &http.Transport{}
-	}
-	antigravityTransport = cloneTransportWithHTTP11(base)
+	transport.TLSClientConfig.NextProtos = []string{"http/1.1"}
 }
 
 // newAntigravityHTTPClient creates an HTTP client specifically for Antigravity,
 // enforcing HTTP/1.1 by disabling HTTP/2 to perfectly mimic Node.js https defaults.
-// The underlying Transport is a singleton to avoid leaking connection pools.
+// The underlying HTTP/1.1 transport is pooled to avoid leaking connection pools.
 func newAntigravityHTTPClient(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, timeout time.Duration) *http.Client {
-	antigravityTransportOnce.Do(initAntigravityTransport)
-
-	client := helps.NewProxyAwareHTTPClient(ctx, cfg, auth, timeout)
-	// If no transport is set, use the shared HTTP/1.1 transport.
-	if client.Transport == nil {
-		client.Transport = antigravityTransport
-		return client
-	}
-
-	// Preserve proxy settings from proxy-aware transports while forcing HTTP/1.1.
-	if transport, ok := client.Transport.(*http.Transport); ok {
-		client.Transport = cloneTransportWithHTTP11(transport)
-	}
-	return client
+	return helps.NewPooledHTTPClient(ctx, cfg, auth, timeout, "http1.1", forceHTTP11)
 }
 
 func validateAntigravityRequestSignatures(from sdktranslator.Format, rawJSON []byte) ([]byte, error) {
@@ -493,6 +458,9 @@ func (e *AntigravityExecutor) Execute(ctx context.Context, auth *cliproxyauth.Au
 	defer reporter.TrackFailure(ctx, &err)
 
 	from := opts.SourceFormat
+	if req.Payload, err = helps.InlineImageURLs(ctx, e.cfg, e.Identifier(), from, req.Payload); err != nil {
+		return resp, err
+	}
 	to := sdktranslator.FromString("antigravity")
 
 	originalPayloadSource := req.Payload
@@ -522,6 +490,16 @@ func (e *AntigravityExecutor) Execute(ctx context.Context, auth *cliproxyauth.Au
 
 	requestedModel := helps.PayloadRequestedModel(opts, req.Model)
 	translated = helps.ApplyPayloadConfigWithRoot(e.cfg, baseModel, "antigravity", "request", translated, originalTranslated, requestedModel)
+	if err = helps.CheckAttachmentSupport(from, to, req.Payload); err != nil {
+		return resp, err
+	}
+	if err = helps.CheckLogprobsSupport(from, to, req.Payload); err != nil {
+		return resp, err
+	}
+	if err = helps.CheckTranslatorPair(ctx, from, to); err != nil {
+		return resp, err
+	}
+	translated = helps.ApplySamplingCapabilities(ctx, from, to, req.Payload, translated)
 
 	useCredits := cliproxyauth.AntigravityCreditsRequested(ctx) && antigravityCreditsRetryEnabled(e.cfg)
 
@@ -690,6 +668,9 @@ func (e *AntigravityExecutor) executeClaudeNonStream(ctx context.Context, auth *
 	defer reporter.TrackFailure(ctx, &err)
 
 	from := opts.SourceFormat
+	if req.Payload, err = helps.InlineImageURLs(ctx, e.cfg, e.Identifier(), from, req.Payload); err != nil {
+		return resp, err
+	}
 	to := sdktranslator.FromString("antigravity")
 
 	originalPayloadSource := req.Payload
@@ -719,6 +700,16 @@ func (e *AntigravityExecutor) executeClaudeNonStream(ctx context.Context, auth *
 
 	requestedModel := helps.PayloadRequestedModel(opts, req.Model)
 	translated = helps.ApplyPayloadConfigWithRoot(e.cfg, baseModel, "antigravity", "request", translated, originalTranslated, requestedModel)
+	if err = helps.CheckAttachmentSupport(from, to, req.Payload); err != nil {
+		return resp, err
+	}
+	if err = helps.CheckLogprobsSupport(from, to, req.Payload); err != nil {
+		return resp, err
+	}
+	if err = helps.CheckTranslatorPair(ctx, from, to); err != nil {
+		return resp, err
+	}
+	translated = helps.ApplySamplingCapabilities(ctx, from, to, req.Payload, translated)
 
 	useCredits := cliproxyauth.AntigravityCreditsRequested(ctx) && antigravityCreditsRetryEnabled(e.cfg)
 
@@ -866,7 +857,8 @@ attemptLoop:
 				clearAntigravityCreditsFailureState(auth)
 			}
 			out := make(chan cliproxyexecutor.StreamChunk)
-			go func(resp *http.Response) {
+			lifecycle.Go(ctx, "antigravity.stream", func() {
+				resp := httpResp
 				defer close(out)
 				defer func() {
 					if errClose := resp.Body.Close(); errClose != nil {
@@ -896,12 +888,12 @@ attemptLoop:
 				}
 				if errScan := scanner.Err(); errScan != nil {
 					helps.RecordAPIResponseError(ctx, e.cfg, errScan)
-					reporter.PublishFailure(ctx)
+					reporter.PublishFailure(ctx, errScan)
 					out <- cliproxyexecutor.StreamChunk{Err: errScan}
 				} else {
 					reporter.EnsurePublished(ctx)
 				}
-			}(httpResp)
+			})
 
 			var buffer bytes.Buffer
 			for chunk := range out {
@@ -1149,6 +1141,9 @@ func (e *AntigravityExecutor) ExecuteStream(ctx context.Context, auth *cliproxya
 	defer reporter.TrackFailure(ctx, &err)
 
 	from := opts.SourceFormat
+	if req.Payload, err = helps.InlineImageURLs(ctx, e.cfg, e.Identifier(), from, req.Payload); err != nil {
+		return nil, err
+	}
 	to := sdktranslator.FromString("antigravity")
 
 	originalPayloadSource := req.Payload
@@ -1179,6 +1174,16 @@ func (e *AntigravityExecutor) ExecuteStream(ctx context.Context, auth *cliproxya
 
 	requestedModel := helps.PayloadRequestedModel(opts, req.Model)
 	translated = helps.ApplyPayloadConfigWithRoot(e.cfg, baseModel, "antigravity", "request", translated, originalTranslated, requestedModel)
+	if err = helps.CheckAttachmentSupport(from, to, req.Payload); err != nil {
+		return nil, err
+	}
+	if err = helps.CheckLogprobsSupport(from, to, req.Payload); err != nil {
+		return nil, err
+	}
+	if err = helps.CheckTranslatorPair(ctx, from, to); err != nil {
+		return nil, err
+	}
+	translated = helps.ApplySamplingCapabilities(ctx, from, to, req.Payload, translated)
 
 	useCredits := cliproxyauth.AntigravityCreditsRequested(ctx) && antigravityCreditsRetryEnabled(e.cfg)
 
@@ -1325,7 +1330,8 @@ attemptLoop:
 				clearAntigravityCreditsFailureState(auth)
 			}
 			out := make(chan cliproxyexecutor.StreamChunk)
-			go func(resp *http.Response) {
+			lifecycle.Go(ctx, "antigravity.stream", func() {
+				resp := httpResp
 				defer close(out)
 				defer func() {
 					if errClose := resp.Body.Close(); errClose != nil {
@@ -1335,6 +1341,7 @@ attemptLoop:
 				scanner := bufio.NewScanner(resp.Body)
 				scanner.Buffer(nil, streamScannerBuffer)
 				var param any
+				usageEmulator := helps.NewStreamUsageEmulator(from, baseModel, req.Payload)
 				for scanner.Scan() {
 					line := scanner.Bytes()
 					helps.AppendAPIResponseChunk(ctx, e.cfg, line)
@@ -1354,21 +1361,26 @@ attemptLoop:
 
 					chunks := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, translated, bytes.Clone(payload), &param)
 					for i := range chunks {
+						usageEmulator.Observe(chunks[i])
 						out <- cliproxyexecutor.StreamChunk{Payload: chunks[i]}
 					}
 				}
 				tail := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, translated, []byte("[DONE]"), &param)
 				for i := range tail {
+					usageEmulator.Observe(tail[i])
 					out <- cliproxyexecutor.StreamChunk{Payload: tail[i]}
 				}
 				if errScan := scanner.Err(); errScan != nil {
 					helps.RecordAPIResponseError(ctx, e.cfg, errScan)
-					reporter.PublishFailure(ctx)
+					reporter.PublishFailure(ctx, errScan)
 					out <- cliproxyexecutor.StreamChunk{Err: errScan}
 				} else {
+					if usageChunk := usageEmulator.Finish(); usageChunk != nil {
+						out <- cliproxyexecutor.StreamChunk{Payload: usageChunk}
+					}
 					reporter.EnsurePublished(ctx)
 				}
-			}(httpResp)
+			})
 			return &cliproxyexecutor.StreamResult{Headers: httpResp.Header.Clone(), Chunks: out}, nil
 		}
 
@@ -1398,8 +1410,16 @@ func (e *AntigravityExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Au
 	return updated, nil
 }
 
-// CountTokens counts tokens for the given request using the Antigravity API.
+// CountTokens counts tokens for the given request using the Antigravity API, or the local
+// estimate when configured under count-tokens.
 func (e *AntigravityExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return helps.CountTokens(ctx, e.cfg, e.Identifier(), req, opts, func() (cliproxyexecutor.Response, error) {
+		return e.countTokensUpstream(ctx, auth, req, opts)
+	})
+}
+
+// countTokensUpstream counts tokens with the Antigravity count endpoint.
+func (e *AntigravityExecutor) countTokensUpstream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
 	baseModel := thinking.ParseSuffix(req.Model).ModelName
 
 	from := opts.SourceFormat
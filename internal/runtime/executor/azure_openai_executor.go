@@ -0,0 +1,83 @@
+package executor
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// defaultAzureOpenAIAPIVersion is the api-version sent when a credential does not set one.
+const defaultAzureOpenAIAPIVersion = "2024-10-21"
+
+// AzureOpenAIExecutor executes requests against Azure OpenAI resources. Azure speaks the OpenAI
+// Chat Completions protocol, but addresses models by deployment in the URL, requires an
+// api-version query parameter and authenticates with an api-key header.
+type AzureOpenAIExecutor struct {
+	*OpenAICompatExecutor
+}
+
+// NewAzureOpenAIExecutor creates an executor for Azure OpenAI credentials.
+func NewAzureOpenAIExecutor(cfg *config.Config) *AzureOpenAIExecutor {
+	compat := NewOpenAICompatExecutor("azure-openai", cfg)
+	compat.buildURL = azureOpenAIURL
+	compat.applyAuth = func(header http.Header, _ *cliproxyauth.Auth, apiKey string) {
+		if apiKey != "" {
+			header.Set("api-key", apiKey)
+		}
+	}
+	return &AzureOpenAIExecutor{OpenAICompatExecutor: compat}
+}
+
+// CountTokens counts locally with the tokenizer of the model the deployment serves, since
+// deployment names rarely identify it.
+func (e *AzureOpenAIExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	if alias := e.deploymentAlias(auth, thinking.ParseSuffix(req.Model).ModelName); alias != "" {
+		req.Model = alias
+	}
+	return e.OpenAICompatExecutor.CountTokens(ctx, auth, req, opts)
+}
+
+// deploymentAlias returns the client model name configured for deployment.
+func (e *AzureOpenAIExecutor) deploymentAlias(auth *cliproxyauth.Auth, deployment string) string {
+	if e.cfg == nil || auth == nil || deployment == "" {
+		return ""
+	}
+	baseURL, apiKey := e.resolveCredentials(auth)
+	for i := range e.cfg.AzureOpenAIKey {
+		entry := &e.cfg.AzureOpenAIKey[i]
+		if !strings.EqualFold(strings.TrimSpace(entry.BaseURL), baseURL) || strings.TrimSpace(entry.APIKey) != apiKey {
+			continue
+		}
+		for _, model := range entry.Models {
+			if strings.EqualFold(strings.TrimSpace(model.Deployment), deployment) {
+				return strings.TrimSpace(model.Alias)
+			}
+		}
+	}
+	return ""
+}
+
+// azureOpenAIURL returns the Azure OpenAI URL of an endpoint: Chat Completions requests go to
+// the deployment named by model, other endpoints to the resource, all with the api-version of
+// the credential.
+func azureOpenAIURL(auth *cliproxyauth.Auth, baseURL, defaultPath, model string) string {
+	version := ""
+	if auth != nil && auth.Attributes != nil {
+		version = strings.TrimSpace(auth.Attributes["api_version"])
+	}
+	if version == "" {
+		version = defaultAzureOpenAIAPIVersion
+	}
+	base := strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/openai")
+	path := "/openai" + defaultPath
+	if defaultPath == "/chat/completions" {
+		path = "/openai/deployments/" + url.PathEscape(model) + defaultPath
+	}
+	return base + path + "?" + url.Values{"api-version": {version}}.Encode()
+}
@@ -0,0 +1,100 @@
+package executor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestAzureOpenAIExecutorDeploymentURLAndAuth(t *testing.T) {
+	var gotURIs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotURIs = append(gotURIs, r.URL.RequestURI())
+		if r.Header.Get("api-key") != "secret" || r.Header.Get("Authorization") != "" {
+			http.Error(w, "bad auth", http.StatusUnauthorized)
+			return
+		}
+		if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: {\"id\":\"c1\",\"object\":\"chat.completion.chunk\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"hi\"}}]}\n\ndata: [DONE]\n\n"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"c1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	executor := NewAzureOpenAIExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{Provider: "azure-openai", Attributes: map[string]string{
+		"base_url":    server.URL + "/openai/",
+		"api_key":     "secret",
+		"api_version": "2025-01-01-preview",
+	}}
+	req := cliproxyexecutor.Request{
+		Model:   "prod-gpt-4o",
+		Payload: []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`),
+	}
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")}
+
+	resp, err := executor.Execute(context.Background(), auth, req, opts)
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if got := gjson.GetBytes(resp.Payload, "choices.0.message.content").String(); got != "ok" {
+		t.Fatalf("content = %q: %s", got, resp.Payload)
+	}
+
+	stream, err := executor.ExecuteStream(context.Background(), auth, req, opts)
+	if err != nil {
+		t.Fatalf("ExecuteStream error: %v", err)
+	}
+	var streamed strings.Builder
+	for chunk := range stream.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("stream error: %v", chunk.Err)
+		}
+		streamed.Write(chunk.Payload)
+	}
+	if !strings.Contains(streamed.String(), `"content":"hi"`) {
+		t.Fatalf("stream missing content: %s", streamed.String())
+	}
+
+	want := "/openai/deployments/prod-gpt-4o/chat/completions?api-version=2025-01-01-preview"
+	if len(gotURIs) != 2 || gotURIs[0] != want || gotURIs[1] != want {
+		t.Fatalf("request URIs = %q, want %q twice", gotURIs, want)
+	}
+}
+
+func TestAzureOpenAIExecutorCountTokensUsesDeploymentAlias(t *testing.T) {
+	cfg := &config.Config{AzureOpenAIKey: []config.AzureOpenAIKey{{
+		APIKey:  "secret",
+		BaseURL: "https://res.openai.azure.com",
+		Models:  []config.AzureOpenAIModel{{Deployment: "prod-chat", Alias: "gpt-4o"}},
+	}}}
+	executor := NewAzureOpenAIExecutor(cfg)
+	auth := &cliproxyauth.Auth{Provider: "azure-openai", Attributes: map[string]string{
+		"base_url": "https://res.openai.azure.com",
+		"api_key":  "secret",
+	}}
+	if alias := executor.deploymentAlias(auth, "prod-chat"); alias != "gpt-4o" {
+		t.Fatalf("deploymentAlias = %q, want gpt-4o", alias)
+	}
+	resp, err := executor.CountTokens(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "prod-chat",
+		Payload: []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"hello there"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")})
+	if err != nil {
+		t.Fatalf("CountTokens error: %v", err)
+	}
+	if got := gjson.GetBytes(resp.Payload, "usage.prompt_tokens").Int(); got <= 0 {
+		t.Fatalf("prompt_tokens = %d: %s", got, resp.Payload)
+	}
+}
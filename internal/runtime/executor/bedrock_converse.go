@@ -0,0 +1,400 @@
+package executor
+
+import (
+	"encoding/base64"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// claudeToConverseRequest converts a Claude Messages request into a Bedrock Converse request.
+// Thinking blocks are dropped, since Converse only accepts them back from the model that wrote
+// them, and remote images are skipped because Converse takes image bytes only.
+func claudeToConverseRequest(body []byte) []byte {
+	root := gjson.ParseBytes(body)
+	out := []byte(`{"messages":[]}`)
+
+	system := root.Get("system")
+	if system.Type == gjson.String && system.String() != "" {
+		out, _ = sjson.SetBytes(out, "system.-1", map[string]string{"text": system.String()})
+	} else if system.IsArray() {
+		for _, block := range system.Array() {
+			if text := block.Get("text").String(); block.Get("type").String() == "text" && text != "" {
+				out, _ = sjson.SetBytes(out, "system.-1", map[string]string{"text": text})
+			}
+		}
+	}
+
+	lastRole := ""
+	messageIndex := -1
+	documents := 0
+	for _, message := range root.Get("messages").Array() {
+		role := message.Get("role").String()
+		if role != "user" && role != "assistant" {
+			continue
+		}
+		blocks := converseContentBlocks(message.Get("content"), &documents)
+		if len(blocks) == 0 {
+			continue
+		}
+		// Converse requires alternating roles, so consecutive turns of one role are merged.
+		if role != lastRole {
+			messageIndex++
+			out, _ = sjson.SetRawBytes(out, "messages.-1", []byte(`{"role":"`+role+`","content":[]}`))
+			lastRole = role
+		}
+		for _, block := range blocks {
+			out, _ = sjson.SetRawBytes(out, "messages."+strconv.Itoa(messageIndex)+".content.-1", block)
+		}
+	}
+
+	if v := root.Get("max_tokens"); v.Exists() {
+		out, _ = sjson.SetBytes(out, "inferenceConfig.maxTokens", v.Int())
+	}
+	if v := root.Get("temperature"); v.Exists() {
+		out, _ = sjson.SetBytes(out, "inferenceConfig.temperature", v.Float())
+	}
+	if v := root.Get("top_p"); v.Exists() {
+		out, _ = sjson.SetBytes(out, "inferenceConfig.topP", v.Float())
+	}
+	if v := root.Get("stop_sequences"); v.IsArray() && len(v.Array()) > 0 {
+		out, _ = sjson.SetRawBytes(out, "inferenceConfig.stopSequences", []byte(v.Raw))
+	}
+
+	toolChoice := root.Get("tool_choice.type").String()
+	if tools := root.Get("tools"); tools.IsArray() && toolChoice != "none" {
+		for _, tool := range tools.Array() {
+			name := tool.Get("name").String()
+			if name == "" {
+				continue
+			}
+			spec := []byte(`{"toolSpec":{}}`)
+			spec, _ = sjson.SetBytes(spec, "toolSpec.name", name)
+			if description := tool.Get("description").String(); description != "" {
+				spec, _ = sjson.SetBytes(spec, "toolSpec.description", description)
+			}
+			schema := tool.Get("input_schema").Raw
+			if schema == "" {
+				schema = `{"type":"object","properties":{}}`
+			}
+			spec, _ = sjson.SetRawBytes(spec, "toolSpec.inputSchema.json", []byte(schema))
+			out, _ = sjson.SetRawBytes(out, "toolConfig.tools.-1", spec)
+		}
+		switch toolChoice {
+		case "auto":
+			out, _ = sjson.SetRawBytes(out, "toolConfig.toolChoice", []byte(`{"auto":{}}`))
+		case "any":
+			out, _ = sjson.SetRawBytes(out, "toolConfig.toolChoice", []byte(`{"any":{}}`))
+		case "tool":
+			out, _ = sjson.SetBytes(out, "toolConfig.toolChoice.tool.name", root.Get("tool_choice.name").String())
+		}
+	}
+	return out
+}
+
+// converseContentBlocks converts the content of a Claude message into Converse content blocks.
+// documents counts the documents of the request, so generated document names stay unique.
+func converseContentBlocks(content gjson.Result, documents *int) [][]byte {
+	if content.Type == gjson.String {
+		if content.String() == "" {
+			return nil
+		}
+		block, _ := sjson.SetBytes([]byte(`{}`), "text", content.String())
+		return [][]byte{block}
+	}
+	var blocks [][]byte
+	for _, part := range content.Array() {
+		switch part.Get("type").String() {
+		case "text":
+			if text := part.Get("text").String(); text != "" {
+				block, _ := sjson.SetBytes([]byte(`{}`), "text", text)
+				blocks = append(blocks, block)
+			}
+		case "image":
+			if block := converseImageBlock(part); block != nil {
+				blocks = append(blocks, block)
+			}
+		case "document":
+			*documents++
+			if block := converseDocumentBlock(part, *documents); block != nil {
+				blocks = append(blocks, block)
+			}
+		case "tool_use":
+			block := []byte(`{"toolUse":{}}`)
+			block, _ = sjson.SetBytes(block, "toolUse.toolUseId", part.Get("id").String())
+			block, _ = sjson.SetBytes(block, "toolUse.name", part.Get("name").String())
+			input := part.Get("input").Raw
+			if input == "" {
+				input = "{}"
+			}
+			block, _ = sjson.SetRawBytes(block, "toolUse.input", []byte(input))
+			blocks = append(blocks, block)
+		case "tool_result":
+			block := []byte(`{"toolResult":{"content":[]}}`)
+			block, _ = sjson.SetBytes(block, "toolResult.toolUseId", part.Get("tool_use_id").String())
+			for _, inner := range converseToolResultContent(part.Get("content")) {
+				block, _ = sjson.SetRawBytes(block, "toolResult.content.-1", inner)
+			}
+			if part.Get("is_error").Bool() {
+				block, _ = sjson.SetBytes(block, "toolResult.status", "error")
+			}
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks
+}
+
+// converseToolResultContent converts the content of a Claude tool_result block. Converse rejects
+// empty results, so an empty one is sent as empty text.
+func converseToolResultContent(content gjson.Result) [][]byte {
+	var blocks [][]byte
+	if content.Type == gjson.String {
+		block, _ := sjson.SetBytes([]byte(`{}`), "text", content.String())
+		return [][]byte{block}
+	}
+	for _, part := range content.Array() {
+		switch part.Get("type").String() {
+		case "text":
+			block, _ := sjson.SetBytes([]byte(`{}`), "text", part.Get("text").String())
+			blocks = append(blocks, block)
+		case "image":
+			if block := converseImageBlock(part); block != nil {
+				blocks = append(blocks, block)
+			}
+		}
+	}
+	if len(blocks) == 0 {
+		blocks = append(blocks, []byte(`{"text":""}`))
+	}
+	return blocks
+}
+
+// converseImageBlock converts a base64 Claude image block.
+func converseImageBlock(part gjson.Result) []byte {
+	source := part.Get("source")
+	if source.Get("type").String() != "base64" {
+		return nil
+	}
+	format := strings.TrimPrefix(strings.ToLower(source.Get("media_type").String()), "image/")
+	if format == "jpg" {
+		format = "jpeg"
+	}
+	block := []byte(`{"image":{}}`)
+	block, _ = sjson.SetBytes(block, "image.format", format)
+	block, _ = sjson.SetBytes(block, "image.source.bytes", source.Get("data").String())
+	return block
+}
+
+// converseDocumentBlock converts a base64 PDF or plain text Claude document block. Converse
+// requires a document name, so unnamed documents are numbered.
+func converseDocumentBlock(part gjson.Result, n int) []byte {
+	source := part.Get("source")
+	var format, data string
+	switch source.Get("type").String() {
+	case "base64":
+		format = "pdf"
+		if mediaType := source.Get("media_type").String(); mediaType != "" && mediaType != "application/pdf" {
+			return nil
+		}
+		data = source.Get("data").String()
+	case "text":
+		format = "txt"
+		data = base64.StdEncoding.EncodeToString([]byte(source.Get("data").String()))
+	default:
+		return nil
+	}
+	name := part.Get("title").String()
+	if name == "" {
+		name = "document-" + strconv.Itoa(n)
+	}
+	block := []byte(`{"document":{}}`)
+	block, _ = sjson.SetBytes(block, "document.format", format)
+	block, _ = sjson.SetBytes(block, "document.name", name)
+	block, _ = sjson.SetBytes(block, "document.source.bytes", data)
+	return block
+}
+
+// converseToClaudeResponse converts a Bedrock Converse response into a Claude message.
+func converseToClaudeResponse(data []byte, id, model string) []byte {
+	root := gjson.ParseBytes(data)
+	out := []byte(`{"type":"message","role":"assistant","content":[],"stop_sequence":null}`)
+	out, _ = sjson.SetBytes(out, "id", id)
+	out, _ = sjson.SetBytes(out, "model", model)
+	for _, block := range root.Get("output.message.content").Array() {
+		switch {
+		case block.Get("text").Exists():
+			part, _ := sjson.SetBytes([]byte(`{"type":"text"}`), "text", block.Get("text").String())
+			out, _ = sjson.SetRawBytes(out, "content.-1", part)
+		case block.Get("toolUse").Exists():
+			part := []byte(`{"type":"tool_use"}`)
+			part, _ = sjson.SetBytes(part, "id", block.Get("toolUse.toolUseId").String())
+			part, _ = sjson.SetBytes(part, "name", block.Get("toolUse.name").String())
+			input := block.Get("toolUse.input").Raw
+			if input == "" {
+				input = "{}"
+			}
+			part, _ = sjson.SetRawBytes(part, "input", []byte(input))
+			out, _ = sjson.SetRawBytes(out, "content.-1", part)
+		case block.Get("reasoningContent.reasoningText").Exists():
+			part, _ := sjson.SetBytes([]byte(`{"type":"thinking"}`), "thinking", block.Get("reasoningContent.reasoningText.text").String())
+			part, _ = sjson.SetBytes(part, "signature", block.Get("reasoningContent.reasoningText.signature").String())
+			out, _ = sjson.SetRawBytes(out, "content.-1", part)
+		}
+	}
+	out, _ = sjson.SetBytes(out, "stop_reason", converseStopReason(root.Get("stopReason").String()))
+	out, _ = sjson.SetRawBytes(out, "usage", converseUsage(root.Get("usage")))
+	return out
+}
+
+// converseStreamConverter converts the events of a Bedrock ConverseStream response into Claude
+// Messages stream events. Converse reports usage in a metadata event after messageStop, so the
+// message_delta carrying the stop reason is held back until usage is known.
+type converseStreamConverter struct {
+	id    string
+	model string
+
+	started    bool
+	blocks     map[int]int
+	openBlocks map[int]bool
+	nextBlock  int
+	stopReason string
+	finished   bool
+}
+
+func newConverseStreamConverter(id, model string) *converseStreamConverter {
+	return &converseStreamConverter{id: id, model: model, blocks: make(map[int]int), openBlocks: make(map[int]bool)}
+}
+
+// convert returns the Claude events for one ConverseStream event.
+func (c *converseStreamConverter) convert(eventType string, payload []byte) [][]byte {
+	if c.finished {
+		return nil
+	}
+	event := gjson.ParseBytes(payload)
+	var events [][]byte
+	if !c.started {
+		c.started = true
+		start := []byte(`{"type":"message_start","message":{"type":"message","role":"assistant","content":[],"stop_reason":null,"stop_sequence":null,"usage":{"input_tokens":0,"output_tokens":0}}}`)
+		start, _ = sjson.SetBytes(start, "message.id", c.id)
+		start, _ = sjson.SetBytes(start, "message.model", c.model)
+		events = append(events, start)
+	}
+	switch eventType {
+	case "contentBlockStart":
+		index := int(event.Get("contentBlockIndex").Int())
+		if toolUse := event.Get("start.toolUse"); toolUse.Exists() {
+			block := []byte(`{"type":"tool_use","input":{}}`)
+			block, _ = sjson.SetBytes(block, "id", toolUse.Get("toolUseId").String())
+			block, _ = sjson.SetBytes(block, "name", toolUse.Get("name").String())
+			events = append(events, c.startBlock(index, block))
+		}
+	case "contentBlockDelta":
+		index := int(event.Get("contentBlockIndex").Int())
+		delta := event.Get("delta")
+		switch {
+		case delta.Get("text").Exists():
+			if !c.openBlocks[index] {
+				events = append(events, c.startBlock(index, []byte(`{"type":"text","text":""}`)))
+			}
+			events = append(events, c.delta(index, "text_delta", "text", delta.Get("text").String()))
+		case delta.Get("toolUse").Exists():
+			if c.openBlocks[index] {
+				events = append(events, c.delta(index, "input_json_delta", "partial_json", delta.Get("toolUse.input").String()))
+			}
+		case delta.Get("reasoningContent").Exists():
+			if !c.openBlocks[index] {
+				events = append(events, c.startBlock(index, []byte(`{"type":"thinking","thinking":""}`)))
+			}
+			if text := delta.Get("reasoningContent.text"); text.Exists() {
+				events = append(events, c.delta(index, "thinking_delta", "thinking", text.String()))
+			}
+			if signature := delta.Get("reasoningContent.signature"); signature.Exists() {
+				events = append(events, c.delta(index, "signature_delta", "signature", signature.String()))
+			}
+		}
+	case "contentBlockStop":
+		index := int(event.Get("contentBlockIndex").Int())
+		if c.openBlocks[index] {
+			delete(c.openBlocks, index)
+			stop, _ := sjson.SetBytes([]byte(`{"type":"content_block_stop"}`), "index", c.blocks[index])
+			events = append(events, stop)
+		}
+	case "messageStop":
+		c.stopReason = converseStopReason(event.Get("stopReason").String())
+	case "metadata":
+		events = append(events, c.finish(event.Get("usage"))...)
+	}
+	return events
+}
+
+// finish returns the closing events of the message. It is called with the metadata usage, or
+// with an empty result when the stream ends without one.
+func (c *converseStreamConverter) finish(usage gjson.Result) [][]byte {
+	if c.finished || !c.started {
+		return nil
+	}
+	c.finished = true
+	var events [][]byte
+	for _, index := range slices.Sorted(maps.Keys(c.openBlocks)) {
+		stop, _ := sjson.SetBytes([]byte(`{"type":"content_block_stop"}`), "index", c.blocks[index])
+		events = append(events, stop)
+	}
+	stopReason := c.stopReason
+	if stopReason == "" {
+		stopReason = "end_turn"
+	}
+	delta := []byte(`{"type":"message_delta","delta":{"stop_sequence":null}}`)
+	delta, _ = sjson.SetBytes(delta, "delta.stop_reason", stopReason)
+	delta, _ = sjson.SetRawBytes(delta, "usage", converseUsage(usage))
+	return append(events, delta, []byte(`{"type":"message_stop"}`))
+}
+
+// startBlock assigns the next Claude content block index to a Converse block and returns its
+// content_block_start event.
+func (c *converseStreamConverter) startBlock(index int, block []byte) []byte {
+	c.blocks[index] = c.nextBlock
+	c.nextBlock++
+	c.openBlocks[index] = true
+	event := []byte(`{"type":"content_block_start"}`)
+	event, _ = sjson.SetBytes(event, "index", c.blocks[index])
+	event, _ = sjson.SetRawBytes(event, "content_block", block)
+	return event
+}
+
+func (c *converseStreamConverter) delta(index int, deltaType, field, value string) []byte {
+	event := []byte(`{"type":"content_block_delta"}`)
+	event, _ = sjson.SetBytes(event, "index", c.blocks[index])
+	event, _ = sjson.SetBytes(event, "delta.type", deltaType)
+	event, _ = sjson.SetBytes(event, "delta."+field, value)
+	return event
+}
+
+// converseStopReason maps a Converse stop reason to its Claude equivalent.
+func converseStopReason(reason string) string {
+	switch reason {
+	case "":
+		return "end_turn"
+	case "guardrail_intervened", "content_filtered":
+		return "refusal"
+	default:
+		return reason
+	}
+}
+
+// converseUsage converts Converse token usage into a Claude usage object.
+func converseUsage(usage gjson.Result) []byte {
+	out := []byte(`{}`)
+	out, _ = sjson.SetBytes(out, "input_tokens", usage.Get("inputTokens").Int())
+	out, _ = sjson.SetBytes(out, "output_tokens", usage.Get("outputTokens").Int())
+	if v := usage.Get("cacheReadInputTokens"); v.Exists() {
+		out, _ = sjson.SetBytes(out, "cache_read_input_tokens", v.Int())
+	}
+	if v := usage.Get("cacheWriteInputTokens"); v.Exists() {
+		out, _ = sjson.SetBytes(out, "cache_creation_input_tokens", v.Int())
+	}
+	return out
+}
@@ -0,0 +1,506 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/lifecycle"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor/helps"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// bedrockAnthropicVersion is the anthropic_version Bedrock requires in Claude request bodies.
+	bedrockAnthropicVersion = "bedrock-2023-05-31"
+	// bedrockSigningService is the SigV4 service name of the Bedrock runtime.
+	bedrockSigningService = "bedrock"
+	defaultBedrockRegion  = "us-east-1"
+)
+
+// BedrockExecutor executes requests against the AWS Bedrock runtime. Requests are translated
+// to the Claude Messages format first: Anthropic models receive it through InvokeModel, other
+// models such as Llama through the Converse API, whose responses are converted back into Claude
+// messages so every client format reuses the Claude response translators.
+type BedrockExecutor struct {
+	cfg *config.Config
+}
+
+// NewBedrockExecutor creates an executor for AWS Bedrock credentials.
+func NewBedrockExecutor(cfg *config.Config) *BedrockExecutor { return &BedrockExecutor{cfg: cfg} }
+
+func (e *BedrockExecutor) Identifier() string { return "bedrock" }
+
+// bedrockCredentials are the resolved credentials of a Bedrock auth. Requests are signed with
+// SigV4 when a secret access key is present and sent with apiKey as bearer token otherwise.
+type bedrockCredentials struct {
+	apiKey  string
+	aws     helps.AWSCredentials
+	region  string
+	baseURL string
+}
+
+func bedrockCreds(auth *cliproxyauth.Auth) bedrockCredentials {
+	var creds bedrockCredentials
+	if auth != nil && auth.Attributes != nil {
+		creds.apiKey = strings.TrimSpace(auth.Attributes["api_key"])
+		creds.region = strings.TrimSpace(auth.Attributes["region"])
+		creds.baseURL = strings.TrimSpace(auth.Attributes["base_url"])
+		if secret := strings.TrimSpace(auth.Attributes["secret_access_key"]); secret != "" {
+			creds.aws = helps.AWSCredentials{
+				AccessKeyID:     creds.apiKey,
+				SecretAccessKey: secret,
+				SessionToken:    strings.TrimSpace(auth.Attributes["session_token"]),
+			}
+		}
+	}
+	if creds.region == "" {
+		creds.region = defaultBedrockRegion
+	}
+	if creds.baseURL == "" {
+		creds.baseURL = "https://bedrock-runtime." + creds.region + ".amazonaws.com"
+	}
+	creds.baseURL = strings.TrimSuffix(creds.baseURL, "/")
+	return creds
+}
+
+// authorize signs req or sets its bearer token. body must be the exact request body.
+func (c bedrockCredentials) authorize(req *http.Request, body []byte) {
+	if c.aws.SecretAccessKey != "" {
+		helps.SignAWSRequest(req, body, c.aws, c.region, bedrockSigningService, time.Now())
+		return
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+}
+
+// PrepareRequest signs the outgoing HTTP request with the Bedrock credentials.
+func (e *BedrockExecutor) PrepareRequest(req *http.Request, auth *cliproxyauth.Auth) error {
+	if req == nil {
+		return nil
+	}
+	var body []byte
+	if req.GetBody != nil {
+		rc, errBody := req.GetBody()
+		if errBody != nil {
+			return errBody
+		}
+		data, errRead := io.ReadAll(rc)
+		_ = rc.Close()
+		if errRead != nil {
+			return errRead
+		}
+		body = data
+	}
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	util.ApplyCustomHeadersFromAttrs(req, attrs)
+	bedrockCreds(auth).authorize(req, body)
+	return nil
+}
+
+// HttpRequest signs the request with the Bedrock credentials and executes it.
+func (e *BedrockExecutor) HttpRequest(ctx context.Context, auth *cliproxyauth.Auth, req *http.Request) (*http.Response, error) {
+	if req == nil {
+		return nil, fmt.Errorf("bedrock executor: request is nil")
+	}
+	if ctx == nil {
+		ctx = req.Context()
+	}
+	httpReq := req.WithContext(ctx)
+	if err := e.PrepareRequest(httpReq, auth); err != nil {
+		return nil, err
+	}
+	httpClient := helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	return httpClient.Do(httpReq)
+}
+
+func (e *BedrockExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	if opts.Alt == "responses/compact" {
+		return resp, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
+	}
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := helps.NewUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+	from := opts.SourceFormat
+	if req.Payload, err = helps.InlineImageURLs(ctx, e.cfg, e.Identifier(), from, req.Payload); err != nil {
+		return resp, err
+	}
+	to := sdktranslator.FromString("claude")
+	// The Claude response translators read stream events, so only Claude clients get a
+	// non-streaming upstream request.
+	stream := from != to
+	body, err := translateClaudeRequest(ctx, e.cfg, e.Identifier(), req, opts, baseModel, stream)
+	if err != nil {
+		return resp, err
+	}
+
+	httpResp, converse, err := e.send(ctx, auth, baseModel, body, stream)
+	if err != nil {
+		return resp, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("response body close error: %v", errClose)
+		}
+	}()
+	messageID := bedrockMessageID(httpResp.Header)
+
+	var data []byte
+	if stream {
+		err = readBedrockStream(httpResp.Body, converse, messageID, baseModel, func(event []byte) {
+			helps.AppendAPIResponseChunk(ctx, e.cfg, event)
+			line := append([]byte("data: "), event...)
+			if detail, ok := helps.ParseClaudeStreamUsage(line); ok {
+				reporter.Publish(ctx, detail)
+			}
+			data = append(data, "event: "+gjson.GetBytes(event, "type").String()+"\n"...)
+			data = append(data, line...)
+			data = append(data, "\n\n"...)
+		})
+	} else {
+		data, err = io.ReadAll(httpResp.Body)
+		if err == nil {
+			helps.AppendAPIResponseChunk(ctx, e.cfg, data)
+			if converse {
+				data = converseToClaudeResponse(data, messageID, baseModel)
+			}
+			reporter.Publish(ctx, helps.ParseClaudeUsage(data))
+		}
+	}
+	if err != nil {
+		helps.RecordAPIResponseError(ctx, e.cfg, err)
+		return resp, err
+	}
+
+	var param any
+	out := sdktranslator.TranslateNonStream(
+		ctx,
+		to,
+		from,
+		req.Model,
+		opts.OriginalRequest,
+		body,
+		data,
+		&param,
+	)
+	resp = cliproxyexecutor.Response{Payload: out, Headers: httpResp.Header.Clone()}
+	return resp, nil
+}
+
+func (e *BedrockExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (_ *cliproxyexecutor.StreamResult, err error) {
+	if opts.Alt == "responses/compact" {
+		return nil, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
+	}
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := helps.NewUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+	from := opts.SourceFormat
+	if req.Payload, err = helps.InlineImageURLs(ctx, e.cfg, e.Identifier(), from, req.Payload); err != nil {
+		return nil, err
+	}
+	to := sdktranslator.FromString("claude")
+	body, err := translateClaudeRequest(ctx, e.cfg, e.Identifier(), req, opts, baseModel, true)
+	if err != nil {
+		return nil, err
+	}
+
+	httpResp, converse, err := e.send(ctx, auth, baseModel, body, true)
+	if err != nil {
+		return nil, err
+	}
+	messageID := bedrockMessageID(httpResp.Header)
+	out := make(chan cliproxyexecutor.StreamChunk)
+	lifecycle.Go(ctx, "bedrock.stream", func() {
+		defer close(out)
+		defer func() {
+			if errClose := httpResp.Body.Close(); errClose != nil {
+				log.Errorf("response body close error: %v", errClose)
+			}
+		}()
+
+		var param any
+		usageEmulator := helps.NewStreamUsageEmulator(from, baseModel, req.Payload)
+		errStream := readBedrockStream(httpResp.Body, converse, messageID, baseModel, func(event []byte) {
+			helps.AppendAPIResponseChunk(ctx, e.cfg, event)
+			line := append([]byte("data: "), event...)
+			if detail, ok := helps.ParseClaudeStreamUsage(line); ok {
+				reporter.Publish(ctx, detail)
+			}
+			// Claude clients receive the events as server-sent events without translation.
+			if from == to {
+				chunk := fmt.Appendf(nil, "event: %s\n%s\n\n", gjson.GetBytes(event, "type").String(), line)
+				out <- cliproxyexecutor.StreamChunk{Payload: chunk}
+				return
+			}
+			chunks := sdktranslator.TranslateStream(
+				ctx,
+				to,
+				from,
+				req.Model,
+				opts.OriginalRequest,
+				body,
+				line,
+				&param,
+			)
+			for i := range chunks {
+				usageEmulator.Observe(chunks[i])
+				out <- cliproxyexecutor.StreamChunk{Payload: chunks[i]}
+			}
+		})
+		if errStream != nil {
+			helps.RecordAPIResponseError(ctx, e.cfg, errStream)
+			reporter.PublishFailure(ctx, errStream)
+			out <- cliproxyexecutor.StreamChunk{Err: errStream}
+		} else if from != to {
+			if usageChunk := usageEmulator.Finish(); usageChunk != nil {
+				out <- cliproxyexecutor.StreamChunk{Payload: usageChunk}
+			}
+		}
+	})
+	return &cliproxyexecutor.StreamResult{Headers: httpResp.Header.Clone(), Chunks: out}, nil
+}
+
+// CountTokens counts locally: Bedrock's CountTokens API covers only some Anthropic models.
+func (e *BedrockExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return helps.EstimateTokenCount(ctx, req, opts)
+}
+
+// Refresh is a no-op: Bedrock credentials are static.
+func (e *BedrockExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
+	log.Debugf("bedrock executor: refresh called")
+	_ = ctx
+	return auth, nil
+}
+
+// translateClaudeRequest translates the client request into a Claude Messages request for
+// baseModel, for cloud providers that host Claude models under their own model IDs.
+func translateClaudeRequest(ctx context.Context, cfg *config.Config, provider string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, baseModel string, stream bool) ([]byte, error) {
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("claude")
+	originalPayload := req.Payload
+	if len(opts.OriginalRequest) > 0 {
+		originalPayload = opts.OriginalRequest
+	}
+	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, stream)
+	body := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, stream)
+	body, _ = sjson.SetBytes(body, "model", baseModel)
+
+	body, err := thinking.ApplyThinking(body, req.Model, from.String(), to.String(), provider)
+	if err != nil {
+		return nil, err
+	}
+
+	requestedModel := helps.PayloadRequestedModel(opts, req.Model)
+	body = helps.ApplyPayloadConfigWithRoot(cfg, baseModel, to.String(), "", body, originalTranslated, requestedModel)
+	if err = helps.CheckAttachmentSupport(from, to, req.Payload); err != nil {
+		return nil, err
+	}
+	if err = helps.CheckLogprobsSupport(from, to, req.Payload); err != nil {
+		return nil, err
+	}
+	if err = helps.CheckTranslatorPair(ctx, from, to); err != nil {
+		return nil, err
+	}
+	body = helps.ApplySamplingCapabilities(ctx, from, to, req.Payload, body)
+	// Provider model IDs are not in the Claude model registry, but max_tokens is still
+	// required.
+	if !gjson.GetBytes(body, "max_tokens").Exists() {
+		body, _ = sjson.SetBytes(body, "max_tokens", defaultModelMaxTokens)
+	}
+	body = disableThinkingIfToolChoiceForced(body)
+	body = normalizeClaudeTemperatureForThinking(body)
+	return body, nil
+}
+
+// send posts a Claude Messages request to model, through InvokeModel for Anthropic models and
+// through Converse otherwise, and returns the successful response. converse reports which API
+// answered.
+func (e *BedrockExecutor) send(ctx context.Context, auth *cliproxyauth.Auth, model string, body []byte, stream bool) (httpResp *http.Response, converse bool, err error) {
+	creds := bedrockCreds(auth)
+	converse = !isBedrockAnthropicModel(model)
+	var payload []byte
+	var action string
+	if converse {
+		payload = claudeToConverseRequest(body)
+		action = "converse"
+		if stream {
+			action = "converse-stream"
+		}
+	} else {
+		payload = bedrockInvokeBody(body)
+		action = "invoke"
+		if stream {
+			action = "invoke-with-response-stream"
+		}
+	}
+
+	url := creds.baseURL + "/model/" + helps.AWSURIEncode(model) + "/" + action
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, converse, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if stream {
+		httpReq.Header.Set("Accept", "application/vnd.amazon.eventstream")
+	} else {
+		httpReq.Header.Set("Accept", "application/json")
+	}
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	util.ApplyCustomHeadersFromAttrs(httpReq, attrs)
+	creds.authorize(httpReq, payload)
+
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	helps.RecordAPIRequest(ctx, e.cfg, helps.UpstreamRequestLog{
+		URL:       url,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      payload,
+		Provider:  e.Identifier(),
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpClient := helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpResp, err = helps.DoWithRetry(ctx, e.cfg, httpClient, httpReq)
+	if err != nil {
+		helps.RecordAPIResponseError(ctx, e.cfg, err)
+		return nil, converse, err
+	}
+	helps.RecordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		b, readErr := io.ReadAll(httpResp.Body)
+		if readErr != nil {
+			helps.RecordAPIResponseError(ctx, e.cfg, readErr)
+			b = []byte(fmt.Sprintf("failed to read error response body: %v", readErr))
+		}
+		helps.AppendAPIResponseChunk(ctx, e.cfg, b)
+		helps.LogWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, helps.SummarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("response body close error: %v", errClose)
+		}
+		return nil, converse, statusErr{code: httpResp.StatusCode, msg: string(b)}
+	}
+	return httpResp, converse, nil
+}
+
+// isBedrockAnthropicModel reports whether a Bedrock model ID, including cross-region inference
+// profiles such as "us.anthropic.claude-sonnet-4-20250514-v1:0", names an Anthropic model.
+// Other IDs, including inference profile ARNs, use Converse, which serves every model.
+func isBedrockAnthropicModel(model string) bool {
+	return strings.Contains(strings.ToLower(model), "anthropic.")
+}
+
+// bedrockInvokeBody adapts a Claude Messages request to InvokeModel: the model goes in the URL,
+// streaming is chosen by the endpoint and betas move to anthropic_beta.
+func bedrockInvokeBody(body []byte) []byte {
+	betas, body := extractAndRemoveBetas(body)
+	body, _ = sjson.DeleteBytes(body, "model")
+	body, _ = sjson.DeleteBytes(body, "stream")
+	body, _ = sjson.DeleteBytes(body, "metadata")
+	body, _ = sjson.SetBytes(body, "anthropic_version", bedrockAnthropicVersion)
+	if len(betas) > 0 {
+		body, _ = sjson.SetBytes(body, "anthropic_beta", betas)
+	}
+	return body
+}
+
+// bedrockMessageID returns the Claude message ID of a Converse response, derived from the
+// Bedrock request ID.
+func bedrockMessageID(header http.Header) string {
+	if requestID := strings.TrimSpace(header.Get("X-Amzn-Requestid")); requestID != "" {
+		return "msg_bdrk_" + requestID
+	}
+	return "msg_bdrk_" + uuid.NewString()
+}
+
+// readBedrockStream decodes a Bedrock response event stream and calls emit with each event as
+// a Claude Messages stream event. InvokeModel streams carry Claude events base64 encoded in
+// chunk events; ConverseStream events are converted.
+func readBedrockStream(body io.Reader, converse bool, messageID, model string, emit func(event []byte)) error {
+	reader := helps.NewAWSEventStreamReader(body)
+	converter := newConverseStreamConverter(messageID, model)
+	for {
+		msg, err := reader.Next()
+		if err == io.EOF {
+			if converse {
+				for _, event := range converter.finish(gjson.Result{}) {
+					emit(event)
+				}
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if msg.MessageType() != "event" {
+			return bedrockStreamError(msg)
+		}
+		if converse {
+			for _, event := range converter.convert(msg.EventType(), msg.Payload) {
+				emit(event)
+			}
+			continue
+		}
+		if msg.EventType() != "chunk" {
+			continue
+		}
+		event, errDecode := base64.StdEncoding.DecodeString(gjson.GetBytes(msg.Payload, "bytes").String())
+		if errDecode != nil {
+			return fmt.Errorf("bedrock executor: decode stream chunk: %w", errDecode)
+		}
+		emit(event)
+	}
+}
+
+// bedrockStreamError converts an exception message of a Bedrock event stream into an error
+// with the HTTP status Bedrock uses for the exception outside of streams.
+func bedrockStreamError(msg helps.AWSEventStreamMessage) error {
+	code := http.StatusInternalServerError
+	switch strings.ToLower(msg.ExceptionType()) {
+	case "throttlingexception":
+		code = http.StatusTooManyRequests
+	case "validationexception":
+		code = http.StatusBadRequest
+	case "modeltimeoutexception":
+		code = http.StatusRequestTimeout
+	case "modelstreamerrorexception":
+		code = http.StatusFailedDependency
+	case "serviceunavailableexception":
+		code = http.StatusServiceUnavailable
+	}
+	message := gjson.GetBytes(msg.Payload, "message").String()
+	if message == "" {
+		message = string(msg.Payload)
+	}
+	return statusErr{code: code, msg: fmt.Sprintf("%s: %s", msg.ExceptionType(), message)}
+}
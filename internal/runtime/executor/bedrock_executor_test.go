@@ -0,0 +1,229 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestBedrockExecutorInvokeModel(t *testing.T) {
+	var gotPaths []string
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPaths = append(gotPaths, r.URL.EscapedPath())
+		gotBody, _ = io.ReadAll(r.Body)
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(auth, "/eu-west-1/bedrock/aws4_request") {
+			http.Error(w, "bad auth: "+auth, http.StatusForbidden)
+			return
+		}
+		if r.Header.Get("X-Amz-Security-Token") != "session" {
+			http.Error(w, "missing session token", http.StatusForbidden)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/invoke-with-response-stream") {
+			w.Header().Set("Content-Type", "application/vnd.amazon.eventstream")
+			for _, event := range []string{
+				`{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"m","content":[],"stop_reason":null,"usage":{"input_tokens":3,"output_tokens":0}}}`,
+				`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+				`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"hi"}}`,
+				`{"type":"content_block_stop","index":0}`,
+				`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":1}}`,
+				`{"type":"message_stop"}`,
+			} {
+				payload := `{"bytes":"` + base64.StdEncoding.EncodeToString([]byte(event)) + `"}`
+				_, _ = w.Write(encodeEventStreamMessage(map[string]string{":message-type": "event", ":event-type": "chunk"}, payload))
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","model":"m","content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn","usage":{"input_tokens":3,"output_tokens":1}}`))
+	}))
+	defer server.Close()
+
+	executor := NewBedrockExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{Provider: "bedrock", Attributes: map[string]string{
+		"api_key":           "AKID",
+		"secret_access_key": "secret",
+		"session_token":     "session",
+		"region":            "eu-west-1",
+		"base_url":          server.URL,
+	}}
+	req := cliproxyexecutor.Request{
+		Model:   "eu.anthropic.claude-sonnet-4-20250514-v1:0",
+		Payload: []byte(`{"model":"claude-sonnet-4","max_tokens":64,"messages":[{"role":"user","content":"hi"}]}`),
+	}
+
+	resp, err := executor.Execute(context.Background(), auth, req, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("claude")})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if got := gjson.GetBytes(resp.Payload, "content.0.text").String(); got != "ok" {
+		t.Fatalf("content = %q: %s", got, resp.Payload)
+	}
+	if gjson.GetBytes(gotBody, "model").Exists() || gjson.GetBytes(gotBody, "anthropic_version").String() != bedrockAnthropicVersion {
+		t.Fatalf("invoke body not adapted: %s", gotBody)
+	}
+
+	// OpenAI clients are served from the response stream.
+	resp, err = executor.Execute(context.Background(), auth, req, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")})
+	if err != nil {
+		t.Fatalf("Execute (openai) error: %v", err)
+	}
+	if got := gjson.GetBytes(resp.Payload, "choices.0.message.content").String(); got != "hi" {
+		t.Fatalf("openai content = %q: %s", got, resp.Payload)
+	}
+
+	want := "/model/eu.anthropic.claude-sonnet-4-20250514-v1%3A0/"
+	if len(gotPaths) != 2 || gotPaths[0] != want+"invoke" || gotPaths[1] != want+"invoke-with-response-stream" {
+		t.Fatalf("request paths = %q", gotPaths)
+	}
+}
+
+func TestBedrockExecutorConverseStream(t *testing.T) {
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		if r.URL.Path != "/model/meta.llama3-3-70b-instruct-v1:0/converse-stream" || r.Header.Get("Authorization") != "Bearer bedrock-key" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("X-Amzn-Requestid", "req-1")
+		for _, m := range []struct{ event, payload string }{
+			{"messageStart", `{"role":"assistant"}`},
+			{"contentBlockDelta", `{"contentBlockIndex":0,"delta":{"text":"Hel"}}`},
+			{"contentBlockDelta", `{"contentBlockIndex":0,"delta":{"text":"lo"}}`},
+			{"contentBlockStop", `{"contentBlockIndex":0}`},
+			{"contentBlockStart", `{"contentBlockIndex":1,"start":{"toolUse":{"toolUseId":"tu_1","name":"lookup"}}}`},
+			{"contentBlockDelta", `{"contentBlockIndex":1,"delta":{"toolUse":{"input":"{\"q\":1}"}}}`},
+			{"contentBlockStop", `{"contentBlockIndex":1}`},
+			{"messageStop", `{"stopReason":"tool_use"}`},
+			{"metadata", `{"usage":{"inputTokens":5,"outputTokens":7,"totalTokens":12}}`},
+		} {
+			_, _ = w.Write(encodeEventStreamMessage(map[string]string{":message-type": "event", ":event-type": m.event}, m.payload))
+		}
+	}))
+	defer server.Close()
+
+	executor := NewBedrockExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{Provider: "bedrock", Attributes: map[string]string{"api_key": "bedrock-key", "region": "us-east-1", "base_url": server.URL}}
+	req := cliproxyexecutor.Request{
+		Model: "meta.llama3-3-70b-instruct-v1:0",
+		Payload: []byte(`{"model":"llama","max_tokens":64,"system":"be brief","stream":true,` +
+			`"tools":[{"name":"lookup","input_schema":{"type":"object"}}],"messages":[{"role":"user","content":"hi"}]}`),
+	}
+	stream, err := executor.ExecuteStream(context.Background(), auth, req, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("claude")})
+	if err != nil {
+		t.Fatalf("ExecuteStream error: %v", err)
+	}
+	var streamed strings.Builder
+	for chunk := range stream.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("stream error: %v", chunk.Err)
+		}
+		streamed.Write(chunk.Payload)
+	}
+	got := streamed.String()
+	for _, want := range []string{
+		`"id":"msg_bdrk_req-1"`,
+		`"delta":{"type":"text_delta","text":"Hel"}`,
+		`"content_block":{"type":"tool_use","input":{},"id":"tu_1","name":"lookup"}`,
+		`"partial_json":"{\"q\":1}"`,
+		`"stop_reason":"tool_use"`,
+		`"usage":{"input_tokens":5,"output_tokens":7}`,
+		"event: message_stop\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("stream missing %s:\n%s", want, got)
+		}
+	}
+	if gjson.GetBytes(gotBody, "system.0.text").String() != "be brief" || gjson.GetBytes(gotBody, "toolConfig.tools.0.toolSpec.name").String() != "lookup" {
+		t.Fatalf("converse request = %s", gotBody)
+	}
+}
+
+func TestBedrockExecutorStreamException(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(encodeEventStreamMessage(map[string]string{":message-type": "exception", ":exception-type": "throttlingException"}, `{"message":"Too many requests"}`))
+	}))
+	defer server.Close()
+
+	executor := NewBedrockExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{Provider: "bedrock", Attributes: map[string]string{"api_key": "k", "base_url": server.URL}}
+	req := cliproxyexecutor.Request{Model: "anthropic.claude-3-haiku-20240307-v1:0", Payload: []byte(`{"messages":[{"role":"user","content":"hi"}]}`)}
+	stream, err := executor.ExecuteStream(context.Background(), auth, req, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("claude")})
+	if err != nil {
+		t.Fatalf("ExecuteStream error: %v", err)
+	}
+	var streamErr error
+	for chunk := range stream.Chunks {
+		if chunk.Err != nil {
+			streamErr = chunk.Err
+		}
+	}
+	status, ok := streamErr.(statusErr)
+	if !ok || status.StatusCode() != http.StatusTooManyRequests {
+		t.Fatalf("stream error = %v, want 429 statusErr", streamErr)
+	}
+}
+
+func TestClaudeToConverseRequest(t *testing.T) {
+	body := []byte(`{"max_tokens":10,"temperature":0.5,"stop_sequences":["END"],"tool_choice":{"type":"tool","name":"f"},
+		"tools":[{"name":"f","description":"d","input_schema":{"type":"object"}}],
+		"messages":[
+			{"role":"user","content":[{"type":"text","text":"look"},{"type":"image","source":{"type":"base64","media_type":"image/png","data":"AAA="}}]},
+			{"role":"assistant","content":[{"type":"thinking","thinking":"hm"},{"type":"tool_use","id":"t1","name":"f","input":{"a":1}}]},
+			{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"done","is_error":true}]},
+			{"role":"user","content":"thanks"}]}`)
+	out := gjson.ParseBytes(claudeToConverseRequest(body))
+	checks := map[string]string{
+		"inferenceConfig.maxTokens":               "10",
+		"inferenceConfig.stopSequences.0":         "END",
+		"toolConfig.toolChoice.tool.name":         "f",
+		"toolConfig.tools.0.toolSpec.description": "d",
+		"messages.0.content.1.image.format":       "png",
+		"messages.1.content.#":                    "1",
+		"messages.1.content.0.toolUse.input.a":    "1",
+		"messages.2.content.0.toolResult.status":  "error",
+		"messages.2.content.1.text":               "thanks",
+		"messages.#":                              "3",
+	}
+	for path, want := range checks {
+		if got := out.Get(path).String(); got != want {
+			t.Errorf("%s = %q, want %q\n%s", path, got, want, out.Raw)
+		}
+	}
+}
+
+// encodeEventStreamMessage encodes one AWS event-stream message with string headers.
+func encodeEventStreamMessage(headers map[string]string, payload string) []byte {
+	var h bytes.Buffer
+	for name, value := range headers {
+		h.WriteByte(byte(len(name)))
+		h.WriteString(name)
+		h.WriteByte(7)
+		_ = binary.Write(&h, binary.BigEndian, uint16(len(value)))
+		h.WriteString(value)
+	}
+	total := 12 + h.Len() + len(payload) + 4
+	out := make([]byte, 12, total)
+	binary.BigEndian.PutUint32(out[0:4], uint32(total))
+	binary.BigEndian.PutUint32(out[4:8], uint32(h.Len()))
+	binary.BigEndian.PutUint32(out[8:12], crc32.ChecksumIEEE(out[:8]))
+	out = append(out, h.Bytes()...)
+	out = append(out, payload...)
+	return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(out))
+}
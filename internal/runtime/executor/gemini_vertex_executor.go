@@ -7,15 +7,20 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"strings"
+	"sync"
 	"time"
 
 	vertexauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/vertex"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/lifecycle"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor/helps"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
@@ -32,6 +37,17 @@ import (
 const (
 	// vertexAPIVersion aligns with current public Vertex Generative AI API.
 	vertexAPIVersion = "v1"
+	// vertexOAuthScope is the OAuth scope of access tokens for Vertex AI.
+	vertexOAuthScope = "https://www.googleapis.com/auth/cloud-platform"
+	// vertexTokenRefreshLead is how long before expiry Refresh renews an access token.
+	vertexTokenRefreshLead = 5 * time.Minute
+)
+
+// vertexTokenSources caches an OAuth token source per credential, so access tokens are reused
+// until they expire instead of being minted for every request.
+var (
+	vertexTokenSourcesMu sync.Mutex
+	vertexTokenSources   = make(map[string]oauth2.TokenSource)
 )
 
 // isImagenModel checks if the model name is an Imagen image generation model.
@@ -247,6 +263,9 @@ func (e *GeminiVertexExecutor) Execute(ctx context.Context, auth *cliproxyauth.A
 		if errCreds != nil {
 			return resp, errCreds
 		}
+		if isVertexClaudeModel(thinking.ParseSuffix(req.Model).ModelName) {
+			return e.executeClaudeWithServiceAccount(ctx, auth, req, opts, projectID, location, saJSON)
+		}
 		return e.executeWithServiceAccount(ctx, auth, req, opts, projectID, location, saJSON)
 	}
 
@@ -268,6 +287,9 @@ func (e *GeminiVertexExecutor) ExecuteStream(ctx context.Context, auth *cliproxy
 		if errCreds != nil {
 			return nil, errCreds
 		}
+		if isVertexClaudeModel(thinking.ParseSuffix(req.Model).ModelName) {
+			return e.executeClaudeStreamWithServiceAccount(ctx, auth, req, opts, projectID, location, saJSON)
+		}
 		return e.executeStreamWithServiceAccount(ctx, auth, req, opts, projectID, location, saJSON)
 	}
 
@@ -275,8 +297,16 @@ func (e *GeminiVertexExecutor) ExecuteStream(ctx context.Context, auth *cliproxy
 	return e.executeStreamWithAPIKey(ctx, auth, req, opts, apiKey, baseURL)
 }
 
-// CountTokens counts tokens for the given request using the Vertex AI API.
+// CountTokens counts tokens for the given request using the Vertex AI API, or the local
+// estimate when configured under count-tokens.
 func (e *GeminiVertexExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return helps.CountTokens(ctx, e.cfg, e.Identifier(), req, opts, func() (cliproxyexecutor.Response, error) {
+		return e.countTokensUpstream(ctx, auth, req, opts)
+	})
+}
+
+// countTokensUpstream counts tokens with the Vertex AI count endpoint.
+func (e *GeminiVertexExecutor) countTokensUpstream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
 	// Try API key authentication first
 	apiKey, baseURL := vertexAPICreds(auth)
 
@@ -286,6 +316,10 @@ func (e *GeminiVertexExecutor) CountTokens(ctx context.Context, auth *cliproxyau
 		if errCreds != nil {
 			return cliproxyexecutor.Response{}, errCreds
 		}
+		// Claude models have no countTokens method on Vertex AI.
+		if isVertexClaudeModel(thinking.ParseSuffix(req.Model).ModelName) {
+			return helps.EstimateTokenCount(ctx, req, opts)
+		}
 		return e.countTokensWithServiceAccount(ctx, auth, req, opts, projectID, location, saJSON)
 	}
 
@@ -293,11 +327,48 @@ func (e *GeminiVertexExecutor) CountTokens(ctx context.Context, auth *cliproxyau
 	return e.countTokensWithAPIKey(ctx, auth, req, opts, apiKey, baseURL)
 }
 
-// Refresh refreshes the authentication credentials (no-op for Vertex).
-func (e *GeminiVertexExecutor) Refresh(_ context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
+// Refresh mints a new OAuth access token for service account and workload identity credentials
+// and records its expiry, so the token is renewed before it expires. API key credentials are
+// returned unchanged.
+func (e *GeminiVertexExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
+	if auth == nil {
+		return auth, nil
+	}
+	if apiKey, _ := vertexAPICreds(auth); apiKey != "" {
+		return auth, nil
+	}
+	_, _, saJSON, errCreds := vertexCreds(auth)
+	if errCreds != nil {
+		return nil, errCreds
+	}
+	ts, errSource := vertexTokenSource(ctx, e.cfg, auth, saJSON, true)
+	if errSource != nil {
+		return nil, errSource
+	}
+	tok, errTok := ts.Token()
+	if errTok != nil {
+		return nil, fmt.Errorf("vertex executor: refresh access token failed: %w", errTok)
+	}
+	if _, ok := auth.Runtime.(vertexTokenState); ok || auth.Runtime == nil {
+		auth.Runtime = vertexTokenState{expiry: tok.Expiry}
+	}
 	return auth, nil
 }
 
+// vertexTokenState is the runtime state of a Vertex OAuth credential: the expiry of the access
+// token minted by the last refresh.
+type vertexTokenState struct {
+	expiry time.Time
+}
+
+// ShouldRefresh renews the access token shortly before it expires.
+func (s vertexTokenState) ShouldRefresh(now time.Time, _ *cliproxyauth.Auth) bool {
+	if s.expiry.IsZero() {
+		return false
+	}
+	return !now.Before(s.expiry.Add(-vertexTokenRefreshLead))
+}
+
 // executeWithServiceAccount handles authentication using service account credentials.
 // This method contains the original service account authentication logic.
 func (e *GeminiVertexExecutor) executeWithServiceAccount(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, projectID, location string, saJSON []byte) (resp cliproxyexecutor.Response, err error) {
@@ -318,6 +389,9 @@ func (e *GeminiVertexExecutor) executeWithServiceAccount(ctx context.Context, au
 	} else {
 		// Standard Gemini translation flow
 		from := opts.SourceFormat
+		if req.Payload, err = helps.InlineImageURLs(ctx, e.cfg, e.Identifier(), from, req.Payload); err != nil {
+			return resp, err
+		}
 		to := sdktranslator.FromString("gemini")
 
 		originalPayloadSource := req.Payload
@@ -336,6 +410,16 @@ func (e *GeminiVertexExecutor) executeWithServiceAccount(ctx context.Context, au
 		body = fixGeminiImageAspectRatio(baseModel, body)
 		requestedModel := helps.PayloadRequestedModel(opts, req.Model)
 		body = helps.ApplyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", body, originalTranslated, requestedModel)
+		if err = helps.CheckAttachmentSupport(from, to, req.Payload); err != nil {
+			return resp, err
+		}
+		if err = helps.CheckLogprobsSupport(from, to, req.Payload); err != nil {
+			return resp, err
+		}
+		if err = helps.CheckTranslatorPair(ctx, from, to); err != nil {
+			return resp, err
+		}
+		body = helps.ApplySamplingCapabilities(ctx, from, to, req.Payload, body)
 		body, _ = sjson.SetBytes(body, "model", baseModel)
 	}
 
@@ -389,7 +473,7 @@ func (e *GeminiVertexExecutor) executeWithServiceAccount(ctx context.Context, au
 	})
 
 	httpClient := helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
-	httpResp, errDo := httpClient.Do(httpReq)
+	httpResp, errDo := helps.DoWithRetry(ctx, e.cfg, httpClient, httpReq)
 	if errDo != nil {
 		helps.RecordAPIResponseError(ctx, e.cfg, errDo)
 		return resp, errDo
@@ -438,6 +522,9 @@ func (e *GeminiVertexExecutor) executeWithAPIKey(ctx context.Context, auth *clip
 	defer reporter.TrackFailure(ctx, &err)
 
 	from := opts.SourceFormat
+	if req.Payload, err = helps.InlineImageURLs(ctx, e.cfg, e.Identifier(), from, req.Payload); err != nil {
+		return resp, err
+	}
 	to := sdktranslator.FromString("gemini")
 
 	originalPayloadSource := req.Payload
@@ -456,6 +543,16 @@ func (e *GeminiVertexExecutor) executeWithAPIKey(ctx context.Context, auth *clip
 	body = fixGeminiImageAspectRatio(baseModel, body)
 	requestedModel := helps.PayloadRequestedModel(opts, req.Model)
 	body = helps.ApplyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", body, originalTranslated, requestedModel)
+	if err = helps.CheckAttachmentSupport(from, to, req.Payload); err != nil {
+		return resp, err
+	}
+	if err = helps.CheckLogprobsSupport(from, to, req.Payload); err != nil {
+		return resp, err
+	}
+	if err = helps.CheckTranslatorPair(ctx, from, to); err != nil {
+		return resp, err
+	}
+	body = helps.ApplySamplingCapabilities(ctx, from, to, req.Payload, body)
 	body, _ = sjson.SetBytes(body, "model", baseModel)
 
 	action := getVertexAction(baseModel, false)
@@ -509,7 +606,7 @@ func (e *GeminiVertexExecutor) executeWithAPIKey(ctx context.Context, auth *clip
 	})
 
 	httpClient := helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
-	httpResp, errDo := httpClient.Do(httpReq)
+	httpResp, errDo := helps.DoWithRetry(ctx, e.cfg, httpClient, httpReq)
 	if errDo != nil {
 		helps.RecordAPIResponseError(ctx, e.cfg, errDo)
 		return resp, errDo
@@ -548,6 +645,9 @@ func (e *GeminiVertexExecutor) executeStreamWithServiceAccount(ctx context.Conte
 	defer reporter.TrackFailure(ctx, &err)
 
 	from := opts.SourceFormat
+	if req.Payload, err = helps.InlineImageURLs(ctx, e.cfg, e.Identifier(), from, req.Payload); err != nil {
+		return nil, err
+	}
 	to := sdktranslator.FromString("gemini")
 
 	originalPayloadSource := req.Payload
@@ -566,6 +666,16 @@ func (e *GeminiVertexExecutor) executeStreamWithServiceAccount(ctx context.Conte
 	body = fixGeminiImageAspectRatio(baseModel, body)
 	requestedModel := helps.PayloadRequestedModel(opts, req.Model)
 	body = helps.ApplyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", body, originalTranslated, requestedModel)
+	if err = helps.CheckAttachmentSupport(from, to, req.Payload); err != nil {
+		return nil, err
+	}
+	if err = helps.CheckLogprobsSupport(from, to, req.Payload); err != nil {
+		return nil, err
+	}
+	if err = helps.CheckTranslatorPair(ctx, from, to); err != nil {
+		return nil, err
+	}
+	body = helps.ApplySamplingCapabilities(ctx, from, to, req.Payload, body)
 	body, _ = sjson.SetBytes(body, "model", baseModel)
 
 	action := getVertexAction(baseModel, true)
@@ -618,7 +728,7 @@ func (e *GeminiVertexExecutor) executeStreamWithServiceAccount(ctx context.Conte
 	})
 
 	httpClient := helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
-	httpResp, errDo := httpClient.Do(httpReq)
+	httpResp, errDo := helps.DoWithRetry(ctx, e.cfg, httpClient, httpReq)
 	if errDo != nil {
 		helps.RecordAPIResponseError(ctx, e.cfg, errDo)
 		return nil, errDo
@@ -635,7 +745,7 @@ func (e *GeminiVertexExecutor) executeStreamWithServiceAccount(ctx context.Conte
 	}
 
 	out := make(chan cliproxyexecutor.StreamChunk)
-	go func() {
+	lifecycle.Go(ctx, "vertex.stream", func() {
 		defer close(out)
 		defer func() {
 			if errClose := httpResp.Body.Close(); errClose != nil {
@@ -645,6 +755,7 @@ func (e *GeminiVertexExecutor) executeStreamWithServiceAccount(ctx context.Conte
 		scanner := bufio.NewScanner(httpResp.Body)
 		scanner.Buffer(nil, streamScannerBuffer)
 		var param any
+		usageEmulator := helps.NewStreamUsageEmulator(from, baseModel, req.Payload)
 		for scanner.Scan() {
 			line := scanner.Bytes()
 			helps.AppendAPIResponseChunk(ctx, e.cfg, line)
@@ -653,19 +764,23 @@ func (e *GeminiVertexExecutor) executeStreamWithServiceAccount(ctx context.Conte
 			}
 			lines := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, body, bytes.Clone(line), &param)
 			for i := range lines {
+				usageEmulator.Observe(lines[i])
 				out <- cliproxyexecutor.StreamChunk{Payload: lines[i]}
 			}
 		}
 		lines := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, body, []byte("[DONE]"), &param)
 		for i := range lines {
+			usageEmulator.Observe(lines[i])
 			out <- cliproxyexecutor.StreamChunk{Payload: lines[i]}
 		}
 		if errScan := scanner.Err(); errScan != nil {
 			helps.RecordAPIResponseError(ctx, e.cfg, errScan)
-			reporter.PublishFailure(ctx)
+			reporter.PublishFailure(ctx, errScan)
 			out <- cliproxyexecutor.StreamChunk{Err: errScan}
+		} else if usageChunk := usageEmulator.Finish(); usageChunk != nil {
+			out <- cliproxyexecutor.StreamChunk{Payload: usageChunk}
 		}
-	}()
+	})
 	return &cliproxyexecutor.StreamResult{Headers: httpResp.Header.Clone(), Chunks: out}, nil
 }
 
@@ -677,6 +792,9 @@ func (e *GeminiVertexExecutor) executeStreamWithAPIKey(ctx context.Context, auth
 	defer reporter.TrackFailure(ctx, &err)
 
 	from := opts.SourceFormat
+	if req.Payload, err = helps.InlineImageURLs(ctx, e.cfg, e.Identifier(), from, req.Payload); err != nil {
+		return nil, err
+	}
 	to := sdktranslator.FromString("gemini")
 
 	originalPayloadSource := req.Payload
@@ -695,6 +813,16 @@ func (e *GeminiVertexExecutor) executeStreamWithAPIKey(ctx context.Context, auth
 	body = fixGeminiImageAspectRatio(baseModel, body)
 	requestedModel := helps.PayloadRequestedModel(opts, req.Model)
 	body = helps.ApplyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", body, originalTranslated, requestedModel)
+	if err = helps.CheckAttachmentSupport(from, to, req.Payload); err != nil {
+		return nil, err
+	}
+	if err = helps.CheckLogprobsSupport(from, to, req.Payload); err != nil {
+		return nil, err
+	}
+	if err = helps.CheckTranslatorPair(ctx, from, to); err != nil {
+		return nil, err
+	}
+	body = helps.ApplySamplingCapabilities(ctx, from, to, req.Payload, body)
 	body, _ = sjson.SetBytes(body, "model", baseModel)
 
 	action := getVertexAction(baseModel, true)
@@ -747,7 +875,7 @@ func (e *GeminiVertexExecutor) executeStreamWithAPIKey(ctx context.Context, auth
 	})
 
 	httpClient := helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
-	httpResp, errDo := httpClient.Do(httpReq)
+	httpResp, errDo := helps.DoWithRetry(ctx, e.cfg, httpClient, httpReq)
 	if errDo != nil {
 		helps.RecordAPIResponseError(ctx, e.cfg, errDo)
 		return nil, errDo
@@ -764,7 +892,7 @@ func (e *GeminiVertexExecutor) executeStreamWithAPIKey(ctx context.Context, auth
 	}
 
 	out := make(chan cliproxyexecutor.StreamChunk)
-	go func() {
+	lifecycle.Go(ctx, "vertex.stream", func() {
 		defer close(out)
 		defer func() {
 			if errClose := httpResp.Body.Close(); errClose != nil {
@@ -774,6 +902,7 @@ func (e *GeminiVertexExecutor) executeStreamWithAPIKey(ctx context.Context, auth
 		scanner := bufio.NewScanner(httpResp.Body)
 		scanner.Buffer(nil, streamScannerBuffer)
 		var param any
+		usageEmulator := helps.NewStreamUsageEmulator(from, baseModel, req.Payload)
 		for scanner.Scan() {
 			line := scanner.Bytes()
 			helps.AppendAPIResponseChunk(ctx, e.cfg, line)
@@ -782,19 +911,23 @@ func (e *GeminiVertexExecutor) executeStreamWithAPIKey(ctx context.Context, auth
 			}
 			lines := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, body, bytes.Clone(line), &param)
 			for i := range lines {
+				usageEmulator.Observe(lines[i])
 				out <- cliproxyexecutor.StreamChunk{Payload: lines[i]}
 			}
 		}
 		lines := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, body, []byte("[DONE]"), &param)
 		for i := range lines {
+			usageEmulator.Observe(lines[i])
 			out <- cliproxyexecutor.StreamChunk{Payload: lines[i]}
 		}
 		if errScan := scanner.Err(); errScan != nil {
 			helps.RecordAPIResponseError(ctx, e.cfg, errScan)
-			reporter.PublishFailure(ctx)
+			reporter.PublishFailure(ctx, errScan)
 			out <- cliproxyexecutor.StreamChunk{Err: errScan}
+		} else if usageChunk := usageEmulator.Finish(); usageChunk != nil {
+			out <- cliproxyexecutor.StreamChunk{Payload: usageChunk}
 		}
-	}()
+	})
 	return &cliproxyexecutor.StreamResult{Headers: httpResp.Header.Clone(), Chunks: out}, nil
 }
 
@@ -859,7 +992,7 @@ func (e *GeminiVertexExecutor) countTokensWithServiceAccount(ctx context.Context
 	})
 
 	httpClient := helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
-	httpResp, errDo := httpClient.Do(httpReq)
+	httpResp, errDo := helps.DoWithRetry(ctx, e.cfg, httpClient, httpReq)
 	if errDo != nil {
 		helps.RecordAPIResponseError(ctx, e.cfg, errDo)
 		return cliproxyexecutor.Response{}, errDo
@@ -948,7 +1081,7 @@ func (e *GeminiVertexExecutor) countTokensWithAPIKey(ctx context.Context, auth *
 	})
 
 	httpClient := helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
-	httpResp, errDo := httpClient.Do(httpReq)
+	httpResp, errDo := helps.DoWithRetry(ctx, e.cfg, httpClient, httpReq)
 	if errDo != nil {
 		helps.RecordAPIResponseError(ctx, e.cfg, errDo)
 		return cliproxyexecutor.Response{}, errDo
@@ -976,11 +1109,15 @@ func (e *GeminiVertexExecutor) countTokensWithAPIKey(ctx context.Context, auth *
 	return cliproxyexecutor.Response{Payload: out, Headers: httpResp.Header.Clone()}, nil
 }
 
-// vertexCreds extracts project, location and raw service account JSON from auth metadata.
+// vertexCreds extracts project, location and raw service account JSON from auth metadata, or
+// from the attributes of a configured vertex credential.
 func vertexCreds(a *cliproxyauth.Auth) (projectID, location string, serviceAccountJSON []byte, err error) {
-	if a == nil || a.Metadata == nil {
+	if a == nil {
 		return "", "", nil, fmt.Errorf("vertex executor: missing auth metadata")
 	}
+	if a.Metadata == nil {
+		return vertexConfigCreds(a)
+	}
 	if v, ok := a.Metadata["project_id"].(string); ok {
 		projectID = strings.TrimSpace(v)
 	}
@@ -1016,6 +1153,48 @@ func vertexCreds(a *cliproxyauth.Auth) (projectID, location string, serviceAccou
 	return projectID, location, saJSON, nil
 }
 
+// vertexConfigCreds extracts project, location and credentials of a vertex entry of the config.
+// The credentials file holds a service account key or an external_account (workload identity
+// federation) configuration; without one, a nil serviceAccountJSON selects Application Default
+// Credentials.
+func vertexConfigCreds(a *cliproxyauth.Auth) (projectID, location string, serviceAccountJSON []byte, err error) {
+	if a.Attributes == nil {
+		return "", "", nil, fmt.Errorf("vertex executor: missing auth metadata")
+	}
+	projectID = strings.TrimSpace(a.Attributes["project_id"])
+	if projectID == "" {
+		return "", "", nil, fmt.Errorf("vertex executor: missing project_id in credentials")
+	}
+	location = strings.TrimSpace(a.Attributes["location"])
+	if location == "" {
+		location = "us-central1"
+	}
+	path := strings.TrimSpace(a.Attributes["credentials_file"])
+	if path == "" {
+		return projectID, location, nil, nil
+	}
+	raw, errRead := os.ReadFile(path)
+	if errRead != nil {
+		return "", "", nil, fmt.Errorf("vertex executor: read credentials file failed: %w", errRead)
+	}
+	var creds map[string]any
+	if errUnmarshal := json.Unmarshal(raw, &creds); errUnmarshal != nil {
+		return "", "", nil, fmt.Errorf("vertex executor: parse credentials file failed: %w", errUnmarshal)
+	}
+	if kind, _ := creds["type"].(string); kind != "service_account" {
+		return projectID, location, raw, nil
+	}
+	normalized, errNorm := vertexauth.NormalizeServiceAccountMap(creds)
+	if errNorm != nil {
+		return "", "", nil, fmt.Errorf("vertex executor: %w", errNorm)
+	}
+	saJSON, errMarshal := json.Marshal(normalized)
+	if errMarshal != nil {
+		return "", "", nil, fmt.Errorf("vertex executor: marshal service_account failed: %w", errMarshal)
+	}
+	return projectID, location, saJSON, nil
+}
+
 // vertexAPICreds extracts API key and base URL from auth attributes following the claudeCreds pattern.
 func vertexAPICreds(a *cliproxyauth.Auth) (apiKey, baseURL string) {
 	if a == nil {
@@ -1044,21 +1223,68 @@ func vertexBaseURL(location string) string {
 }
 
 func vertexAccessToken(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, saJSON []byte) (string, error) {
-	if httpClient := helps.NewProxyAwareHTTPClient(ctx, cfg, auth, 0); httpClient != nil {
-		ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
-	}
-	// Use cloud-platform scope for Vertex AI.
-	creds, errCreds := google.CredentialsFromJSON(ctx, saJSON, "https://www.googleapis.com/auth/cloud-platform")
-	if errCreds != nil {
-		return "", fmt.Errorf("vertex executor: parse service account json failed: %w", errCreds)
+	ts, errSource := vertexTokenSource(ctx, cfg, auth, saJSON, false)
+	if errSource != nil {
+		return "", errSource
 	}
-	tok, errTok := creds.TokenSource.Token()
+	tok, errTok := ts.Token()
 	if errTok != nil {
 		return "", fmt.Errorf("vertex executor: get access token failed: %w", errTok)
 	}
 	return tok.AccessToken, nil
 }
 
+// vertexTokenSource returns the cached token source of a credential, creating it when missing
+// or when renew is set. A nil saJSON selects Application Default Credentials.
+func vertexTokenSource(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, saJSON []byte, renew bool) (oauth2.TokenSource, error) {
+	key := vertexTokenSourceKey(cfg, auth, saJSON)
+	vertexTokenSourcesMu.Lock()
+	defer vertexTokenSourcesMu.Unlock()
+	if ts, ok := vertexTokenSources[key]; ok && !renew {
+		return ts, nil
+	}
+	ts, err := newVertexTokenSource(ctx, cfg, auth, saJSON)
+	if err != nil {
+		return nil, err
+	}
+	vertexTokenSources[key] = ts
+	return ts, nil
+}
+
+// vertexTokenSourceKey identifies a credential together with the proxy its tokens are
+// fetched through.
+func vertexTokenSourceKey(cfg *config.Config, auth *cliproxyauth.Auth, saJSON []byte) string {
+	hasher := sha256.New()
+	if auth != nil {
+		hasher.Write([]byte(auth.ID + "\x00" + auth.ProxyURL + "\x00"))
+	}
+	if cfg != nil {
+		hasher.Write([]byte(cfg.ProxyURL + "\x00"))
+	}
+	hasher.Write(saJSON)
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+// newVertexTokenSource creates a token source for saJSON. The source outlives the request, so
+// it is bound to a background context carrying the proxy-aware HTTP client.
+func newVertexTokenSource(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, saJSON []byte) (oauth2.TokenSource, error) {
+	tsCtx := context.Background()
+	if httpClient := helps.NewProxyAwareHTTPClient(ctx, cfg, auth, 0); httpClient != nil {
+		tsCtx = context.WithValue(tsCtx, oauth2.HTTPClient, httpClient)
+	}
+	var creds *google.Credentials
+	var errCreds error
+	if saJSON == nil {
+		creds, errCreds = google.FindDefaultCredentials(tsCtx, vertexOAuthScope)
+	} else {
+		creds, errCreds = google.CredentialsFromJSON(tsCtx, saJSON, vertexOAuthScope)
+	}
+	if errCreds != nil {
+		return nil, fmt.Errorf("vertex executor: load credentials failed: %w", errCreds)
+	}
+	return oauth2.ReuseTokenSource(nil, creds.TokenSource), nil
+}
+
 // resolveVertexConfig finds the matching vertex-api-key configuration entry for the given auth.
 func (e *GeminiVertexExecutor) resolveVertexConfig(auth *cliproxyauth.Auth) *config.VertexCompatKey {
 	if auth == nil || e.cfg == nil {
@@ -0,0 +1,12 @@
+package helps
+
+import (
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// CheckAttachmentSupport rejects a request whose document or audio attachments the target
+// format cannot accept, instead of forwarding it with the attachments dropped by translation.
+func CheckAttachmentSupport(from, to sdktranslator.Format, payload []byte) error {
+	return translatorcommon.CheckAttachments(from.String(), to.String(), payload)
+}
@@ -0,0 +1,145 @@
+package helps
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"strconv"
+)
+
+// maxAWSEventStreamMessage bounds the size of one event-stream message.
+const maxAWSEventStreamMessage = 16 << 20
+
+// AWSEventStreamMessage is one message of an AWS event stream
+// (application/vnd.amazon.eventstream).
+type AWSEventStreamMessage struct {
+	Headers map[string]string
+	Payload []byte
+}
+
+// MessageType returns the :message-type header: "event", "exception" or "error".
+func (m AWSEventStreamMessage) MessageType() string { return m.Headers[":message-type"] }
+
+// EventType returns the :event-type header of an event message.
+func (m AWSEventStreamMessage) EventType() string { return m.Headers[":event-type"] }
+
+// ExceptionType returns the :exception-type header of an exception message, or the
+// :error-code header of an error message.
+func (m AWSEventStreamMessage) ExceptionType() string {
+	if t := m.Headers[":exception-type"]; t != "" {
+		return t
+	}
+	return m.Headers[":error-code"]
+}
+
+// AWSEventStreamReader decodes the binary messages of an AWS event stream.
+type AWSEventStreamReader struct {
+	r io.Reader
+}
+
+// NewAWSEventStreamReader returns a reader decoding the event stream of r.
+func NewAWSEventStreamReader(r io.Reader) *AWSEventStreamReader {
+	return &AWSEventStreamReader{r: r}
+}
+
+// Next returns the next message. It returns io.EOF when the stream ends between messages and
+// an error when a message is truncated or fails its checksum.
+func (d *AWSEventStreamReader) Next() (AWSEventStreamMessage, error) {
+	var prelude [12]byte
+	if _, err := io.ReadFull(d.r, prelude[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return AWSEventStreamMessage{}, fmt.Errorf("event stream: truncated prelude")
+		}
+		return AWSEventStreamMessage{}, err
+	}
+	totalLen := binary.BigEndian.Uint32(prelude[0:4])
+	headersLen := binary.BigEndian.Uint32(prelude[4:8])
+	if crc32.ChecksumIEEE(prelude[:8]) != binary.BigEndian.Uint32(prelude[8:12]) {
+		return AWSEventStreamMessage{}, fmt.Errorf("event stream: prelude checksum mismatch")
+	}
+	if totalLen < 16 || totalLen > maxAWSEventStreamMessage || headersLen > totalLen-16 {
+		return AWSEventStreamMessage{}, fmt.Errorf("event stream: invalid message length %d", totalLen)
+	}
+
+	rest := make([]byte, totalLen-12)
+	if _, err := io.ReadFull(d.r, rest); err != nil {
+		return AWSEventStreamMessage{}, fmt.Errorf("event stream: truncated message: %w", err)
+	}
+	body, messageCRC := rest[:len(rest)-4], binary.BigEndian.Uint32(rest[len(rest)-4:])
+	crc := crc32.Update(crc32.ChecksumIEEE(prelude[:]), crc32.IEEETable, body)
+	if crc != messageCRC {
+		return AWSEventStreamMessage{}, fmt.Errorf("event stream: message checksum mismatch")
+	}
+
+	headers, err := parseAWSEventStreamHeaders(body[:headersLen])
+	if err != nil {
+		return AWSEventStreamMessage{}, err
+	}
+	return AWSEventStreamMessage{Headers: headers, Payload: body[headersLen:]}, nil
+}
+
+// parseAWSEventStreamHeaders decodes the header block of a message. Non-string values are
+// rendered as text.
+func parseAWSEventStreamHeaders(b []byte) (map[string]string, error) {
+	headers := make(map[string]string)
+	for len(b) > 0 {
+		nameLen := int(b[0])
+		if len(b) < 1+nameLen+1 {
+			return nil, fmt.Errorf("event stream: truncated header")
+		}
+		name := string(b[1 : 1+nameLen])
+		valueType := b[1+nameLen]
+		b = b[2+nameLen:]
+
+		var size int
+		switch valueType {
+		case 0, 1:
+			size = 0
+		case 2:
+			size = 1
+		case 3:
+			size = 2
+		case 4:
+			size = 4
+		case 5, 8:
+			size = 8
+		case 6, 7:
+			if len(b) < 2 {
+				return nil, fmt.Errorf("event stream: truncated header %s", name)
+			}
+			size = int(binary.BigEndian.Uint16(b[:2]))
+			b = b[2:]
+		case 9:
+			size = 16
+		default:
+			return nil, fmt.Errorf("event stream: header %s has unknown type %d", name, valueType)
+		}
+		if len(b) < size {
+			return nil, fmt.Errorf("event stream: truncated header %s", name)
+		}
+		value := b[:size]
+		b = b[size:]
+
+		switch valueType {
+		case 0:
+			headers[name] = "true"
+		case 1:
+			headers[name] = "false"
+		case 2:
+			headers[name] = strconv.Itoa(int(int8(value[0])))
+		case 3:
+			headers[name] = strconv.Itoa(int(int16(binary.BigEndian.Uint16(value))))
+		case 4:
+			headers[name] = strconv.Itoa(int(int32(binary.BigEndian.Uint32(value))))
+		case 5, 8:
+			headers[name] = strconv.FormatInt(int64(binary.BigEndian.Uint64(value)), 10)
+		case 9:
+			headers[name] = fmt.Sprintf("%x", value)
+		default:
+			headers[name] = string(value)
+		}
+	}
+	return headers, nil
+}
@@ -0,0 +1,65 @@
+package helps
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"testing"
+)
+
+func TestAWSEventStreamReader(t *testing.T) {
+	var stream bytes.Buffer
+	stream.Write(encodeAWSEventStreamMessage(map[string]string{":message-type": "event", ":event-type": "chunk"}, []byte(`{"bytes":"e30="}`)))
+	stream.Write(encodeAWSEventStreamMessage(map[string]string{":message-type": "exception", ":exception-type": "throttlingException"}, []byte(`{"message":"slow down"}`)))
+
+	reader := NewAWSEventStreamReader(&stream)
+	msg, err := reader.Next()
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if msg.MessageType() != "event" || msg.EventType() != "chunk" || string(msg.Payload) != `{"bytes":"e30="}` {
+		t.Fatalf("first message = %+v", msg)
+	}
+	msg, err = reader.Next()
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if msg.MessageType() != "exception" || msg.ExceptionType() != "throttlingException" {
+		t.Fatalf("second message = %+v", msg)
+	}
+	if _, err = reader.Next(); err != io.EOF {
+		t.Fatalf("Next at end = %v, want io.EOF", err)
+	}
+}
+
+func TestAWSEventStreamReaderRejectsCorruptMessage(t *testing.T) {
+	frame := encodeAWSEventStreamMessage(map[string]string{":message-type": "event"}, []byte(`{}`))
+	frame[len(frame)-6] ^= 0xff
+	if _, err := NewAWSEventStreamReader(bytes.NewReader(frame)).Next(); err == nil {
+		t.Fatal("expected checksum error")
+	}
+	if _, err := NewAWSEventStreamReader(bytes.NewReader(frame[:10])).Next(); err == nil || err == io.EOF {
+		t.Fatalf("expected truncation error, got %v", err)
+	}
+}
+
+// encodeAWSEventStreamMessage encodes one event-stream message with string headers.
+func encodeAWSEventStreamMessage(headers map[string]string, payload []byte) []byte {
+	var h bytes.Buffer
+	for name, value := range headers {
+		h.WriteByte(byte(len(name)))
+		h.WriteString(name)
+		h.WriteByte(7)
+		_ = binary.Write(&h, binary.BigEndian, uint16(len(value)))
+		h.WriteString(value)
+	}
+	total := 12 + h.Len() + len(payload) + 4
+	out := make([]byte, 12, total)
+	binary.BigEndian.PutUint32(out[0:4], uint32(total))
+	binary.BigEndian.PutUint32(out[4:8], uint32(h.Len()))
+	binary.BigEndian.PutUint32(out[8:12], crc32.ChecksumIEEE(out[:8]))
+	out = append(out, h.Bytes()...)
+	out = append(out, payload...)
+	return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(out))
+}
@@ -0,0 +1,36 @@
+package helps
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestSignAWSRequestVanilla checks the get-vanilla case of the AWS SigV4 test suite.
+func TestSignAWSRequestVanilla(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds := AWSCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	SignAWSRequest(req, nil, creds, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Fatalf("Authorization =\n%s\nwant\n%s", got, want)
+	}
+	if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+		t.Fatalf("X-Amz-Date = %q", got)
+	}
+}
+
+func TestAWSURIEncode(t *testing.T) {
+	if got := AWSURIEncode("us.anthropic.claude-v1:0/x y~"); got != "us.anthropic.claude-v1%3A0%2Fx%20y~" {
+		t.Fatalf("AWSURIEncode = %q", got)
+	}
+	req, _ := http.NewRequest(http.MethodPost, "https://bedrock-runtime.us-east-1.amazonaws.com/model/"+AWSURIEncode("m:0")+"/invoke", nil)
+	if got := awsCanonicalURI(req.URL); got != "/model/m%253A0/invoke" {
+		t.Fatalf("canonical URI = %q", got)
+	}
+}
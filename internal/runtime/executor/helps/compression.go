@@ -0,0 +1,104 @@
+package helps
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// upstreamAcceptEncoding lists the encodings decompressingRoundTripper can decode.
+const upstreamAcceptEncoding = "gzip, br"
+
+// decompressingRoundTripper negotiates compressed responses for non-streaming requests and
+// hands executors the decompressed body, so response parsing and request logging never see
+// compressed bytes. Requests that already set Accept-Encoding are left to the caller.
+type decompressingRoundTripper struct {
+	base http.RoundTripper
+}
+
+// applyUpstreamCompression wraps the client transport with decompressingRoundTripper when
+// cfg.UpstreamCompression is set.
+func applyUpstreamCompression(client *http.Client, cfg *config.Config) {
+	if cfg == nil || !cfg.UpstreamCompression {
+		return
+	}
+	client.Transport = withResponseDecompression(client.Transport)
+}
+
+func withResponseDecompression(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &decompressingRoundTripper{base: rt}
+}
+
+func (t *decompressingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Accept-Encoding") != "" || isStreamingRequest(req) {
+		return t.base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Accept-Encoding", upstreamAcceptEncoding)
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp == nil || resp.Body == nil {
+		return resp, err
+	}
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	var decoded io.ReadCloser
+	switch encoding {
+	case "", "identity":
+		return resp, nil
+	case "gzip", "x-gzip":
+		gzipReader, errGzip := gzip.NewReader(resp.Body)
+		if errGzip != nil {
+			_ = resp.Body.Close()
+			return nil, fmt.Errorf("failed to create gzip reader: %w", errGzip)
+		}
+		decoded = &decodedBody{Reader: gzipReader, body: resp.Body, closeReader: gzipReader.Close}
+	case "br":
+		decoded = &decodedBody{Reader: brotli.NewReader(resp.Body), body: resp.Body}
+	default:
+		// An encoding we did not ask for; leave it to the executor.
+		return resp, nil
+	}
+	resp.Body = decoded
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return resp, nil
+}
+
+// CloseIdleConnections forwards to the wrapped transport.
+func (t *decompressingRoundTripper) CloseIdleConnections() {
+	if closer, ok := t.base.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// isStreamingRequest reports whether req asks for an event stream. Streams are not compressed
+// so that each event reaches the client as soon as the upstream sends it.
+func isStreamingRequest(req *http.Request) bool {
+	accept := strings.ToLower(req.Header.Get("Accept"))
+	if strings.Contains(accept, "event-stream") || strings.Contains(accept, "eventstream") {
+		return true
+	}
+	return req.URL != nil && strings.EqualFold(req.URL.Query().Get("alt"), "sse")
+}
+
+type decodedBody struct {
+	io.Reader
+	body        io.ReadCloser
+	closeReader func() error
+}
+
+func (b *decodedBody) Close() error {
+	if b.closeReader != nil {
+		_ = b.closeReader()
+	}
+	return b.body.Close()
+}
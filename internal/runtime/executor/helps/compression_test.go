@@ -0,0 +1,69 @@
+package helps
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestUpstreamCompressionDecodesNonStreamResponses(t *testing.T) {
+	t.Parallel()
+
+	const payload = `{"choices":[{"message":{"content":"compressed"}}]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept := r.Header.Get("Accept-Encoding")
+		var buf bytes.Buffer
+		switch {
+		case strings.Contains(accept, "br") && r.URL.Query().Get("encoding") == "br":
+			bw := brotli.NewWriter(&buf)
+			_, _ = bw.Write([]byte(payload))
+			_ = bw.Close()
+			w.Header().Set("Content-Encoding", "br")
+		case strings.Contains(accept, "gzip") && r.URL.Query().Get("encoding") == "gzip":
+			gw := gzip.NewWriter(&buf)
+			_, _ = gw.Write([]byte(payload))
+			_ = gw.Close()
+			w.Header().Set("Content-Encoding", "gzip")
+		default:
+			w.Header().Set("X-Accept-Encoding", accept)
+			buf.WriteString(payload)
+		}
+		_, _ = w.Write(buf.Bytes())
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{UpstreamCompression: true}
+	client := NewProxyAwareHTTPClient(context.Background(), cfg, &cliproxyauth.Auth{ID: "compression-test", Provider: "codex"}, 0)
+
+	for _, encoding := range []string{"br", "gzip"} {
+		resp, err := client.Get(server.URL + "?encoding=" + encoding)
+		if err != nil {
+			t.Fatalf("%s: %v", encoding, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if string(body) != payload || resp.Header.Get("Content-Encoding") != "" {
+			t.Fatalf("%s: body = %q, content-encoding = %q", encoding, body, resp.Header.Get("Content-Encoding"))
+		}
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"?encoding=br", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("stream request: %v", err)
+	}
+	_ = resp.Body.Close()
+	if strings.Contains(resp.Header.Get("X-Accept-Encoding"), "br") {
+		t.Fatalf("stream request advertised %q", resp.Header.Get("X-Accept-Encoding"))
+	}
+}
@@ -0,0 +1,72 @@
+package helps
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+const (
+	// CountTokensUpstream answers count_tokens requests with the provider's count endpoint.
+	CountTokensUpstream = "upstream"
+	// CountTokensLocal answers count_tokens requests with the local tokenizer estimate.
+	CountTokensLocal = "local"
+)
+
+// CountTokens answers a count_tokens request for a provider that has a count endpoint. The
+// endpoint is called through upstream unless the provider is configured to count locally;
+// with fallback-to-local enabled, a failed upstream count is answered with the local estimate.
+func CountTokens(ctx context.Context, cfg *config.Config, provider string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, upstream func() (cliproxyexecutor.Response, error)) (cliproxyexecutor.Response, error) {
+	var countCfg config.CountTokensConfig
+	if cfg != nil {
+		countCfg = cfg.CountTokens
+	}
+	if strings.EqualFold(strings.TrimSpace(countCfg.Providers[provider]), CountTokensLocal) {
+		return EstimateTokenCount(ctx, req, opts)
+	}
+
+	resp, err := upstream()
+	if err == nil || !countCfg.FallbackToLocal || ctx.Err() != nil {
+		return resp, err
+	}
+	estimate, errEstimate := EstimateTokenCount(ctx, req, opts)
+	if errEstimate != nil {
+		LogWithRequestID(ctx).Warnf("%s count_tokens failed and the local estimate is unavailable: %v", provider, errEstimate)
+		return resp, err
+	}
+	LogWithRequestID(ctx).Warnf("%s count_tokens failed, answering with the local estimate: %v", provider, err)
+	return estimate, nil
+}
+
+// EstimateTokenCount counts the tokens of a request locally and answers in the format of the
+// count_tokens request.
+func EstimateTokenCount(ctx context.Context, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	count, err := EstimateInputTokens(req.Model, opts.SourceFormat, req.Payload)
+	if err != nil {
+		return cliproxyexecutor.Response{}, err
+	}
+	usageJSON := BuildOpenAIUsageJSON(count)
+	return cliproxyexecutor.Response{Payload: sdktranslator.TranslateTokenCount(ctx, sdktranslator.FormatOpenAI, opts.SourceFormat, count, usageJSON)}, nil
+}
+
+// EstimateInputTokens counts the input tokens of a payload in format locally: the payload is
+// translated to the OpenAI chat format and counted with the tokenizer closest to model.
+func EstimateInputTokens(model string, format sdktranslator.Format, payload []byte) (int64, error) {
+	baseModel := thinking.ParseSuffix(model).ModelName
+	translated := sdktranslator.TranslateRequest(format, sdktranslator.FormatOpenAI, baseModel, payload, false)
+
+	enc, err := TokenizerForModel(baseModel)
+	if err != nil {
+		return 0, fmt.Errorf("token estimate: tokenizer init failed: %w", err)
+	}
+	count, err := CountOpenAIChatTokens(enc, translated)
+	if err != nil {
+		return 0, fmt.Errorf("token estimate: token counting failed: %w", err)
+	}
+	return count, nil
+}
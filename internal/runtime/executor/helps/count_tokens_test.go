@@ -0,0 +1,41 @@
+package helps
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestCountTokensModes(t *testing.T) {
+	req := cliproxyexecutor.Request{Model: "gpt-4o", Payload: []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"hello there"}]}`)}
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FormatOpenAI}
+	errUpstream := errors.New("upstream down")
+	calls := 0
+	failing := func() (cliproxyexecutor.Response, error) {
+		calls++
+		return cliproxyexecutor.Response{}, errUpstream
+	}
+
+	if _, err := CountTokens(context.Background(), &config.Config{}, "claude", req, opts, failing); !errors.Is(err, errUpstream) || calls != 1 {
+		t.Fatalf("default mode must call upstream and return its error, got %v after %d calls", err, calls)
+	}
+
+	cfg := &config.Config{CountTokens: config.CountTokensConfig{FallbackToLocal: true}}
+	resp, err := CountTokens(context.Background(), cfg, "claude", req, opts, failing)
+	if err != nil || calls != 2 {
+		t.Fatalf("fallback must answer after one upstream call, got %v after %d calls", err, calls)
+	}
+	if gjson.GetBytes(resp.Payload, "usage.prompt_tokens").Int() <= 0 {
+		t.Fatalf("expected a local estimate, got %s", resp.Payload)
+	}
+
+	cfg = &config.Config{CountTokens: config.CountTokensConfig{Providers: map[string]string{"claude": "local"}}}
+	if _, err = CountTokens(context.Background(), cfg, "claude", req, opts, failing); err != nil || calls != 2 {
+		t.Fatalf("local mode must not call upstream, got %v after %d calls", err, calls)
+	}
+}
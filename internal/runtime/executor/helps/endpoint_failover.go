@@ -0,0 +1,216 @@
+package helps
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// FallbackBaseURLsAttribute holds the comma-separated endpoints an auth fails over to when its
+// base_url fails with a connect error or a 5xx response.
+const FallbackBaseURLsAttribute = "fallback_base_urls"
+
+const (
+	endpointInitialBackoff = 10 * time.Second
+	endpointMaxBackoff     = 5 * time.Minute
+)
+
+// endpointState tracks the health of one upstream endpoint.
+type endpointState struct {
+	failures  int
+	downUntil time.Time
+}
+
+// endpointHealth records failures per endpoint, shared by all credentials that use it, so an
+// endpoint that keeps failing is tried last until its backoff expires.
+type endpointHealth struct {
+	mu        sync.Mutex
+	endpoints map[string]*endpointState
+}
+
+var upstreamEndpoints = &endpointHealth{endpoints: make(map[string]*endpointState)}
+
+func (h *endpointHealth) markFailure(endpoint string, now time.Time) time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	state := h.endpoints[endpoint]
+	if state == nil {
+		state = &endpointState{}
+		h.endpoints[endpoint] = state
+	}
+	state.failures++
+	backoff := endpointInitialBackoff << min(state.failures-1, 8)
+	backoff = min(backoff, endpointMaxBackoff)
+	state.downUntil = now.Add(backoff)
+	return backoff
+}
+
+func (h *endpointHealth) markSuccess(endpoint string) {
+	h.mu.Lock()
+	delete(h.endpoints, endpoint)
+	h.mu.Unlock()
+}
+
+// order returns endpoints with the healthy ones first, keeping the configured order, followed
+// by the ones still backing off, soonest recovery first.
+func (h *endpointHealth) order(endpoints []string, now time.Time) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	healthy := make([]string, 0, len(endpoints))
+	var down []string
+	for _, endpoint := range endpoints {
+		if state := h.endpoints[endpoint]; state != nil && now.Before(state.downUntil) {
+			down = append(down, endpoint)
+			continue
+		}
+		healthy = append(healthy, endpoint)
+	}
+	sort.SliceStable(down, func(i, j int) bool {
+		return h.endpoints[down[i]].downUntil.Before(h.endpoints[down[j]].downUntil)
+	})
+	return append(healthy, down...)
+}
+
+// failoverRoundTripper sends each request to the auth's endpoints in health order, moving on
+// after a connect error or a 5xx response. Requests to URLs outside the primary endpoint, such
+// as token refreshes, are sent unchanged.
+type failoverRoundTripper struct {
+	base      http.RoundTripper
+	primary   string
+	endpoints []string
+	health    *endpointHealth
+}
+
+// applyEndpointFailover wraps the client transport with failoverRoundTripper when auth lists
+// fallback endpoints in its FallbackBaseURLsAttribute.
+func applyEndpointFailover(client *http.Client, auth *cliproxyauth.Auth) {
+	if auth == nil || auth.Attributes == nil {
+		return
+	}
+	raw := strings.TrimSpace(auth.Attributes[FallbackBaseURLsAttribute])
+	if raw == "" {
+		return
+	}
+	primary := normalizeEndpoint(auth.Attributes["base_url"])
+	endpoints := []string{primary}
+	for _, fallback := range strings.Split(raw, ",") {
+		if fallback = normalizeEndpoint(fallback); fallback != "" && fallback != primary {
+			endpoints = append(endpoints, fallback)
+		}
+	}
+	if len(endpoints) < 2 {
+		return
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	client.Transport = &failoverRoundTripper{base: base, primary: primary, endpoints: endpoints, health: upstreamEndpoints}
+}
+
+func normalizeEndpoint(raw string) string {
+	return strings.TrimRight(strings.TrimSpace(raw), "/")
+}
+
+func (t *failoverRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	target := req.URL.String()
+	primary := t.primary
+	if primary == "" {
+		// The executor uses its built-in endpoint; treat the request origin as the primary.
+		primary = req.URL.Scheme + "://" + req.URL.Host
+	}
+	if !strings.HasPrefix(target, primary) || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+		return t.base.RoundTrip(req)
+	}
+	suffix := strings.TrimPrefix(target, primary)
+
+	endpoints := append([]string{primary}, t.endpoints[1:]...)
+	endpoints = t.health.order(endpoints, time.Now())
+	for idx, endpoint := range endpoints {
+		last := idx == len(endpoints)-1
+		attempt, errAttempt := retargetRequest(req, endpoint+suffix, idx > 0)
+		if errAttempt != nil {
+			return nil, errAttempt
+		}
+		resp, err := t.base.RoundTrip(attempt)
+		failed := err != nil && isConnectError(err) || err == nil && resp.StatusCode >= http.StatusInternalServerError
+		if !failed {
+			if err == nil {
+				t.health.markSuccess(endpoint)
+			}
+			return resp, err
+		}
+		backoff := t.health.markFailure(endpoint, time.Now())
+		if last || req.Context().Err() != nil {
+			return resp, err
+		}
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+			_ = resp.Body.Close()
+		}
+		entry := log.WithFields(log.Fields{
+			"endpoint": endpoint,
+			"next":     endpoints[idx+1],
+			"status":   status,
+			"backoff":  backoff.String(),
+		})
+		if err != nil {
+			entry = entry.WithError(err)
+		}
+		entry.Warn("upstream endpoint failed, failing over")
+	}
+	return nil, errors.New("endpoint failover: no endpoints")
+}
+
+// retargetRequest returns req pointed at rawURL. Retries get a fresh body from GetBody.
+func retargetRequest(req *http.Request, rawURL string, retry bool) (*http.Request, error) {
+	if !retry && rawURL == req.URL.String() {
+		return req, nil
+	}
+	target, errParse := url.Parse(rawURL)
+	if errParse != nil {
+		return nil, errParse
+	}
+	out := req.Clone(req.Context())
+	out.URL = target
+	out.Host = ""
+	if retry && req.GetBody != nil {
+		body, errBody := req.GetBody()
+		if errBody != nil {
+			return nil, errBody
+		}
+		out.Body = body
+	}
+	return out, nil
+}
+
+// isConnectError reports whether err happened before the request reached the upstream, so it
+// is safe to send it to another endpoint.
+func isConnectError(err error) bool {
+	if timeoutErr, ok := errors.AsType[*UpstreamTimeoutError](err); ok {
+		return timeoutErr.Phase == TimeoutPhaseConnect
+	}
+	if _, ok := errors.AsType[*net.DNSError](err); ok {
+		return true
+	}
+	if opErr, ok := errors.AsType[*net.OpError](err); ok {
+		return opErr.Op == "dial"
+	}
+	return false
+}
+
+// CloseIdleConnections forwards to the wrapped transport.
+func (t *failoverRoundTripper) CloseIdleConnections() {
+	if closer, ok := t.base.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
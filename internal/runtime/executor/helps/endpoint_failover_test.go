@@ -0,0 +1,106 @@
+package helps
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestEndpointFailoverOn5xxAndHealthOrder(t *testing.T) {
+	t.Parallel()
+
+	var primaryHits atomic.Int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryHits.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer primary.Close()
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(r.URL.Path + " " + string(body)))
+	}))
+	defer fallback.Close()
+
+	auth := &cliproxyauth.Auth{ID: "failover-5xx", Provider: "codex", Attributes: map[string]string{
+		"base_url":                primary.URL + "/v1",
+		FallbackBaseURLsAttribute: " " + fallback.URL + "/v1/ ",
+	}}
+	client := NewProxyAwareHTTPClient(context.Background(), nil, auth, 0)
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Post(primary.URL+"/v1/responses", "application/json", strings.NewReader(`{"n":1}`))
+		if err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || string(body) != `/v1/responses {"n":1}` {
+			t.Fatalf("request %d: status %d body %q", i, resp.StatusCode, body)
+		}
+	}
+	// The failing primary backs off, so the second request goes straight to the fallback.
+	if got := primaryHits.Load(); got != 1 {
+		t.Fatalf("primary hits = %d, want 1", got)
+	}
+}
+
+func TestEndpointFailoverOnConnectError(t *testing.T) {
+	t.Parallel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadURL := "http://" + listener.Addr().String()
+	_ = listener.Close()
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer fallback.Close()
+
+	auth := &cliproxyauth.Auth{ID: "failover-connect", Provider: "claude", Attributes: map[string]string{
+		"base_url":                deadURL,
+		FallbackBaseURLsAttribute: fallback.URL,
+	}}
+	resp, err := NewProxyAwareHTTPClient(context.Background(), nil, auth, 0).Get(deadURL + "/v1/messages")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200 from fallback", resp.StatusCode)
+	}
+}
+
+func TestEndpointFailoverReturnsLastFailure(t *testing.T) {
+	t.Parallel()
+
+	failing := func() *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+	}
+	primary, fallback := failing(), failing()
+	defer primary.Close()
+	defer fallback.Close()
+
+	auth := &cliproxyauth.Auth{ID: "failover-exhausted", Provider: "gemini", Attributes: map[string]string{
+		"base_url":                primary.URL,
+		FallbackBaseURLsAttribute: fallback.URL,
+	}}
+	resp, err := NewProxyAwareHTTPClient(context.Background(), nil, auth, 0).Get(primary.URL + "/models")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want the last endpoint's 503", resp.StatusCode)
+	}
+}
@@ -0,0 +1,54 @@
+package helps
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// ClassifyFailure returns the usage failure class of an executor error. The request context
+// distinguishes client cancellations from upstream failures that surface as I/O errors; a
+// context cancelled with a deadline cause, such as a stream aborted for idleness, counts as a
+// timeout.
+func ClassifyFailure(ctx context.Context, err error) string {
+	if ctx != nil && ctx.Err() != nil && errors.Is(context.Cause(ctx), context.DeadlineExceeded) {
+		return usage.FailureClassTimeout
+	}
+	if errors.Is(err, context.Canceled) || (ctx != nil && errors.Is(ctx.Err(), context.Canceled)) {
+		return usage.FailureClassClientCancel
+	}
+	if errors.Is(err, context.DeadlineExceeded) || (ctx != nil && errors.Is(ctx.Err(), context.DeadlineExceeded)) {
+		return usage.FailureClassTimeout
+	}
+	if netErr, ok := errors.AsType[net.Error](err); ok && netErr.Timeout() {
+		return usage.FailureClassTimeout
+	}
+	// Checked before status codes because thinking, attachment and parameter errors carry a
+	// 400 status.
+	if _, ok := errors.AsType[*thinking.ThinkingError](err); ok {
+		return usage.FailureClassTranslation
+	}
+	if _, ok := errors.AsType[*json.SyntaxError](err); ok {
+		return usage.FailureClassTranslation
+	}
+	if _, ok := errors.AsType[*translatorcommon.UnsupportedContentError](err); ok {
+		return usage.FailureClassTranslation
+	}
+	if _, ok := errors.AsType[*translatorcommon.UnsupportedParameterError](err); ok {
+		return usage.FailureClassTranslation
+	}
+	if _, ok := errors.AsType[*sdktranslator.MissingTranslatorError](err); ok {
+		return usage.FailureClassTranslation
+	}
+	if statusErr, ok := errors.AsType[cliproxyexecutor.StatusError](err); ok && statusErr.StatusCode() > 0 {
+		return usage.FailureClassForStatus(statusErr.StatusCode())
+	}
+	return usage.FailureClassOther
+}
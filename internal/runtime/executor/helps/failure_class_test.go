@@ -0,0 +1,68 @@
+package helps
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+type testStatusErr struct{ code int }
+
+func (e testStatusErr) Error() string   { return fmt.Sprintf("status %d", e.code) }
+func (e testStatusErr) StatusCode() int { return e.code }
+
+func TestClassifyFailure(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	idle, cancelIdle := context.WithCancelCause(context.Background())
+	cancelIdle(fmt.Errorf("upstream stream idle: %w", context.DeadlineExceeded))
+
+	var syntaxErr *json.SyntaxError
+	errJSON := json.Unmarshal([]byte("{"), &struct{}{})
+	if !errors.As(errJSON, &syntaxErr) {
+		t.Fatalf("expected json syntax error, got %T", errJSON)
+	}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want string
+	}{
+		{"unauthorized", context.Background(), testStatusErr{401}, usage.FailureClassAuth},
+		{"forbidden", context.Background(), testStatusErr{403}, usage.FailureClassAuth},
+		{"rate limited", context.Background(), fmt.Errorf("wrapped: %w", testStatusErr{429}), usage.FailureClassRateLimit},
+		{"server error", context.Background(), testStatusErr{503}, usage.FailureClassUpstream5xx},
+		{"bad request", context.Background(), testStatusErr{400}, usage.FailureClassUpstream4xx},
+		{"gateway timeout", context.Background(), testStatusErr{504}, usage.FailureClassTimeout},
+		{"deadline", context.Background(), fmt.Errorf("read: %w", context.DeadlineExceeded), usage.FailureClassTimeout},
+		{"net timeout", context.Background(), &net.OpError{Op: "read", Err: timeoutErr{}}, usage.FailureClassTimeout},
+		{"client cancel", cancelled, errors.New("unexpected EOF"), usage.FailureClassClientCancel},
+		{"idle abort", idle, context.Canceled, usage.FailureClassTimeout},
+		{"thinking", context.Background(), thinking.NewThinkingError(thinking.ErrUnknownLevel, "unknown level"), usage.FailureClassTranslation},
+		{"json", context.Background(), errJSON, usage.FailureClassTranslation},
+		{"document", context.Background(), &translatorcommon.UnsupportedContentError{Kind: "document", Target: "claude", Reason: "no docx"}, usage.FailureClassTranslation},
+		{"logprobs", context.Background(), &translatorcommon.UnsupportedParameterError{Param: "logprobs", Target: "claude"}, usage.FailureClassTranslation},
+		{"missing translator", context.Background(), &sdktranslator.MissingTranslatorError{From: "claude", To: "acme"}, usage.FailureClassTranslation},
+		{"unknown", context.Background(), errors.New("boom"), usage.FailureClassOther},
+	}
+	for _, tt := range tests {
+		if got := ClassifyFailure(tt.ctx, tt.err); got != tt.want {
+			t.Errorf("%s: ClassifyFailure() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+type timeoutErr struct{}
+
+func (timeoutErr) Error() string   { return "i/o timeout" }
+func (timeoutErr) Timeout() bool   { return true }
+func (timeoutErr) Temporary() bool { return true }
@@ -0,0 +1,328 @@
+package helps
+
+import (
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// historyRules is the resolved set of repairs applied to one payload.
+type historyRules struct {
+	dropOrphanToolResults bool
+	mergeConsecutiveRoles bool
+	dropEmptyContent      bool
+}
+
+// builtinHistoryRules mirrors what each upstream protocol rejects.
+// Claude and Gemini require strictly alternating roles; OpenAI and Codex accept
+// repeated roles but reject tool outputs without a matching call.
+var builtinHistoryRules = map[string]historyRules{
+	"claude":      {dropOrphanToolResults: true, mergeConsecutiveRoles: true, dropEmptyContent: true},
+	"gemini":      {dropOrphanToolResults: true, mergeConsecutiveRoles: true, dropEmptyContent: true},
+	"gemini-cli":  {dropOrphanToolResults: true, mergeConsecutiveRoles: true, dropEmptyContent: true},
+	"antigravity": {dropOrphanToolResults: true, mergeConsecutiveRoles: true, dropEmptyContent: true},
+	"openai":      {dropOrphanToolResults: true, dropEmptyContent: true},
+	"codex":       {dropOrphanToolResults: true, dropEmptyContent: true},
+}
+
+// NormalizeHistoryWithRoot repairs common client mistakes in the conversation history of a
+// translated payload according to the strictness rules of the target protocol. Paths are
+// resolved relative to root (for example, "request" for Gemini CLI).
+func NormalizeHistoryWithRoot(cfg *config.Config, protocol, root string, payload []byte) []byte {
+	if cfg == nil || !cfg.HistoryNormalization.Enabled || len(payload) == 0 {
+		return payload
+	}
+	protocol = strings.ToLower(strings.TrimSpace(protocol))
+	base := sdktranslator.BaseFormat(sdktranslator.FromString(protocol)).String()
+	rules, ok := resolveHistoryRules(cfg.HistoryNormalization, protocol, base)
+	if !ok {
+		return payload
+	}
+
+	switch base {
+	case "claude":
+		return normalizeClaudeHistory(payload, buildPayloadPath(root, "messages"), rules)
+	case "openai":
+		return normalizeOpenAIHistory(payload, buildPayloadPath(root, "messages"), rules)
+	case "gemini", "gemini-cli", "antigravity":
+		return normalizeGeminiHistory(payload, buildPayloadPath(root, "contents"), rules)
+	case "codex":
+		return normalizeCodexHistory(payload, buildPayloadPath(root, "input"), rules)
+	}
+	return payload
+}
+
+func resolveHistoryRules(cfg config.HistoryNormalizationConfig, protocol, base string) (historyRules, bool) {
+	rules, ok := builtinHistoryRules[base]
+	override, hasOverride := cfg.Protocols[protocol]
+	if !hasOverride && protocol != base {
+		override, hasOverride = cfg.Protocols[base]
+	}
+	if !hasOverride {
+		return rules, ok
+	}
+	if override.DropOrphanToolResults != nil {
+		rules.dropOrphanToolResults = *override.DropOrphanToolResults
+	}
+	if override.MergeConsecutiveRoles != nil {
+		rules.mergeConsecutiveRoles = *override.MergeConsecutiveRoles
+	}
+	if override.DropEmptyContent != nil {
+		rules.dropEmptyContent = *override.DropEmptyContent
+	}
+	return rules, true
+}
+
+// normalizeClaudeHistory repairs Anthropic Messages API history.
+func normalizeClaudeHistory(payload []byte, path string, rules historyRules) []byte {
+	messages := gjson.GetBytes(payload, path)
+	if !messages.IsArray() {
+		return payload
+	}
+	toolUseIDs := make(map[string]struct{})
+	var kept []string
+	var keptRoles []string
+	changed := false
+	for _, msg := range messages.Array() {
+		raw := msg.Raw
+		role := msg.Get("role").String()
+		content := msg.Get("content")
+		if content.IsArray() {
+			var blocks []string
+			for _, block := range content.Array() {
+				switch block.Get("type").String() {
+				case "tool_use":
+					toolUseIDs[block.Get("id").String()] = struct{}{}
+				case "tool_result":
+					if rules.dropOrphanToolResults {
+						if _, found := toolUseIDs[block.Get("tool_use_id").String()]; !found {
+							changed = true
+							continue
+						}
+					}
+				}
+				blocks = append(blocks, block.Raw)
+			}
+			if len(blocks) != len(content.Array()) {
+				raw, _ = sjson.SetRaw(raw, "content", "["+strings.Join(blocks, ",")+"]")
+				content = gjson.Get(raw, "content")
+			}
+		}
+		if rules.dropEmptyContent && isEmptyHistoryContent(content) {
+			changed = true
+			continue
+		}
+		if rules.mergeConsecutiveRoles && len(kept) > 0 && keptRoles[len(kept)-1] == role {
+			kept[len(kept)-1] = toolResultsFirst(mergeHistoryContent(kept[len(kept)-1], raw, "content", "text"))
+			changed = true
+			continue
+		}
+		kept = append(kept, raw)
+		keptRoles = append(keptRoles, role)
+	}
+	return setHistoryArray(payload, path, kept, changed)
+}
+
+// normalizeOpenAIHistory repairs Chat Completions history.
+func normalizeOpenAIHistory(payload []byte, path string, rules historyRules) []byte {
+	messages := gjson.GetBytes(payload, path)
+	if !messages.IsArray() {
+		return payload
+	}
+	toolCallIDs := make(map[string]struct{})
+	var kept []string
+	var keptMergeable []bool
+	var keptRoles []string
+	changed := false
+	for _, msg := range messages.Array() {
+		role := msg.Get("role").String()
+		toolCalls := msg.Get("tool_calls")
+		for _, call := range toolCalls.Array() {
+			toolCallIDs[call.Get("id").String()] = struct{}{}
+		}
+		if role == "tool" && rules.dropOrphanToolResults {
+			if _, found := toolCallIDs[msg.Get("tool_call_id").String()]; !found {
+				changed = true
+				continue
+			}
+		}
+		hasToolCalls := len(toolCalls.Array()) > 0 || msg.Get("function_call").Exists()
+		if rules.dropEmptyContent && role != "tool" && !hasToolCalls && isEmptyHistoryContent(msg.Get("content")) {
+			changed = true
+			continue
+		}
+		mergeable := (role == "user" || role == "assistant" || role == "system") && !hasToolCalls
+		if rules.mergeConsecutiveRoles && mergeable && len(kept) > 0 && keptMergeable[len(kept)-1] && keptRoles[len(kept)-1] == role {
+			kept[len(kept)-1] = mergeHistoryContent(kept[len(kept)-1], msg.Raw, "content", "text")
+			changed = true
+			continue
+		}
+		kept = append(kept, msg.Raw)
+		keptMergeable = append(keptMergeable, mergeable)
+		keptRoles = append(keptRoles, role)
+	}
+	return setHistoryArray(payload, path, kept, changed)
+}
+
+// normalizeGeminiHistory repairs generateContent history.
+func normalizeGeminiHistory(payload []byte, path string, rules historyRules) []byte {
+	contents := gjson.GetBytes(payload, path)
+	if !contents.IsArray() {
+		return payload
+	}
+	callIDs := make(map[string]struct{})
+	callNames := make(map[string]struct{})
+	var kept []string
+	var keptRoles []string
+	changed := false
+	for _, item := range contents.Array() {
+		raw := item.Raw
+		role := item.Get("role").String()
+		parts := item.Get("parts")
+		if parts.IsArray() {
+			var keptParts []string
+			for _, part := range parts.Array() {
+				if call := part.Get("functionCall"); call.Exists() {
+					callNames[call.Get("name").String()] = struct{}{}
+					if id := call.Get("id").String(); id != "" {
+						callIDs[id] = struct{}{}
+					}
+				}
+				if response := part.Get("functionResponse"); response.Exists() && rules.dropOrphanToolResults {
+					_, idFound := callIDs[response.Get("id").String()]
+					_, nameFound := callNames[response.Get("name").String()]
+					if !idFound && !nameFound {
+						changed = true
+						continue
+					}
+				}
+				keptParts = append(keptParts, part.Raw)
+			}
+			if len(keptParts) != len(parts.Array()) {
+				raw, _ = sjson.SetRaw(raw, "parts", "["+strings.Join(keptParts, ",")+"]")
+				parts = gjson.Get(raw, "parts")
+			}
+		}
+		if rules.dropEmptyContent && len(parts.Array()) == 0 {
+			changed = true
+			continue
+		}
+		if rules.mergeConsecutiveRoles && len(kept) > 0 && keptRoles[len(kept)-1] == role {
+			kept[len(kept)-1] = mergeHistoryContent(kept[len(kept)-1], raw, "parts", "")
+			changed = true
+			continue
+		}
+		kept = append(kept, raw)
+		keptRoles = append(keptRoles, role)
+	}
+	return setHistoryArray(payload, path, kept, changed)
+}
+
+// normalizeCodexHistory repairs Responses API input items. Role merging is not applied
+// because the Responses API accepts repeated roles.
+func normalizeCodexHistory(payload []byte, path string, rules historyRules) []byte {
+	input := gjson.GetBytes(payload, path)
+	if !input.IsArray() {
+		return payload
+	}
+	callIDs := make(map[string]struct{})
+	var kept []string
+	changed := false
+	for _, item := range input.Array() {
+		itemType := item.Get("type").String()
+		switch itemType {
+		case "function_call", "custom_tool_call", "local_shell_call":
+			callIDs[item.Get("call_id").String()] = struct{}{}
+		case "function_call_output", "custom_tool_call_output", "local_shell_call_output":
+			if rules.dropOrphanToolResults {
+				if _, found := callIDs[item.Get("call_id").String()]; !found {
+					changed = true
+					continue
+				}
+			}
+		case "message", "":
+			if rules.dropEmptyContent && item.Get("role").Exists() && isEmptyHistoryContent(item.Get("content")) {
+				changed = true
+				continue
+			}
+		}
+		kept = append(kept, item.Raw)
+	}
+	return setHistoryArray(payload, path, kept, changed)
+}
+
+// isEmptyHistoryContent reports whether message content is missing, blank or an empty array.
+func isEmptyHistoryContent(content gjson.Result) bool {
+	switch {
+	case !content.Exists() || content.Type == gjson.Null:
+		return true
+	case content.Type == gjson.String:
+		return strings.TrimSpace(content.String()) == ""
+	case content.IsArray():
+		return len(content.Array()) == 0
+	}
+	return false
+}
+
+// mergeHistoryContent appends the content at field of next to prev. String content is
+// joined with a blank line; otherwise both sides are converted to arrays of blocks using
+// textType for string content.
+func mergeHistoryContent(prev, next, field, textType string) string {
+	a := gjson.Get(prev, field)
+	b := gjson.Get(next, field)
+	if a.Type == gjson.String && b.Type == gjson.String {
+		out, _ := sjson.Set(prev, field, a.String()+"\n\n"+b.String())
+		return out
+	}
+	blocks := append(historyContentBlocks(a, textType), historyContentBlocks(b, textType)...)
+	out, _ := sjson.SetRaw(prev, field, "["+strings.Join(blocks, ",")+"]")
+	return out
+}
+
+// toolResultsFirst moves tool_result blocks ahead of the other blocks of a merged Claude
+// message, since Claude rejects user turns whose tool results follow other content.
+func toolResultsFirst(raw string) string {
+	var results, rest []string
+	for _, block := range gjson.Get(raw, "content").Array() {
+		if block.Get("type").String() == "tool_result" {
+			results = append(results, block.Raw)
+		} else {
+			rest = append(rest, block.Raw)
+		}
+	}
+	if len(results) == 0 || len(rest) == 0 {
+		return raw
+	}
+	out, _ := sjson.SetRaw(raw, "content", "["+strings.Join(append(results, rest...), ",")+"]")
+	return out
+}
+
+func historyContentBlocks(content gjson.Result, textType string) []string {
+	if content.IsArray() {
+		var blocks []string
+		for _, block := range content.Array() {
+			blocks = append(blocks, block.Raw)
+		}
+		return blocks
+	}
+	if content.Type == gjson.String && textType != "" && content.String() != "" {
+		block, _ := sjson.Set(`{"type":""}`, "type", textType)
+		block, _ = sjson.Set(block, "text", content.String())
+		return []string{block}
+	}
+	return nil
+}
+
+func setHistoryArray(payload []byte, path string, items []string, changed bool) []byte {
+	if !changed {
+		return payload
+	}
+	out, errSet := sjson.SetRawBytes(payload, path, []byte("["+strings.Join(items, ",")+"]"))
+	if errSet != nil {
+		return payload
+	}
+	return out
+}
@@ -0,0 +1,95 @@
+package helps
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestNormalizeHistoryClaude(t *testing.T) {
+	cfg := &config.Config{HistoryNormalization: config.HistoryNormalizationConfig{Enabled: true}}
+	payload := []byte(`{"messages":[
+		{"role":"user","content":"hi"},
+		{"role":"user","content":[{"type":"tool_result","tool_use_id":"missing","content":"x"}]},
+		{"role":"user","content":[{"type":"text","text":"again"}]},
+		{"role":"assistant","content":[]},
+		{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"f","input":{}}]},
+		{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"ok"}]}
+	]}`)
+
+	out := NormalizeHistoryWithRoot(cfg, "claude", "", payload)
+	messages := gjson.GetBytes(out, "messages").Array()
+	if len(messages) != 3 {
+		t.Fatalf("messages = %d, want 3: %s", len(messages), out)
+	}
+	if got := messages[0].Get("content.#").Int(); got != 2 {
+		t.Fatalf("merged user blocks = %d, want 2: %s", got, messages[0].Raw)
+	}
+	if messages[0].Get("content.0.text").String() != "hi" || messages[0].Get("content.1.text").String() != "again" {
+		t.Fatalf("unexpected merged content: %s", messages[0].Raw)
+	}
+	if messages[2].Get("content.0.tool_use_id").String() != "t1" {
+		t.Fatalf("expected matched tool_result to be kept: %s", messages[2].Raw)
+	}
+}
+
+func TestNormalizeHistoryOpenAIProtocolOverride(t *testing.T) {
+	enabled := true
+	cfg := &config.Config{HistoryNormalization: config.HistoryNormalizationConfig{
+		Enabled:   true,
+		Protocols: map[string]config.HistoryNormalizationRule{"openai": {MergeConsecutiveRoles: &enabled}},
+	}}
+	payload := []byte(`{"messages":[
+		{"role":"user","content":"a"},
+		{"role":"user","content":"b"},
+		{"role":"tool","tool_call_id":"nope","content":"x"},
+		{"role":"assistant","content":null,"tool_calls":[{"id":"c1","type":"function","function":{"name":"f","arguments":"{}"}}]},
+		{"role":"tool","tool_call_id":"c1","content":"y"}
+	]}`)
+
+	out := NormalizeHistoryWithRoot(cfg, "openai", "", payload)
+	messages := gjson.GetBytes(out, "messages").Array()
+	if len(messages) != 3 {
+		t.Fatalf("messages = %d, want 3: %s", len(messages), out)
+	}
+	if messages[0].Get("content").String() != "a\n\nb" {
+		t.Fatalf("unexpected merged content: %s", messages[0].Raw)
+	}
+	if messages[2].Get("tool_call_id").String() != "c1" {
+		t.Fatalf("expected matched tool message to be kept: %s", messages[2].Raw)
+	}
+}
+
+func TestNormalizeHistoryDisabledLeavesPayload(t *testing.T) {
+	payload := []byte(`{"messages":[{"role":"user","content":""}]}`)
+	out := NormalizeHistoryWithRoot(&config.Config{}, "claude", "", payload)
+	if string(out) != string(payload) {
+		t.Fatalf("payload changed while disabled: %s", out)
+	}
+}
+
+func TestNormalizeHistoryClaudeMergeKeepsToolResultsFirst(t *testing.T) {
+	cfg := &config.Config{HistoryNormalization: config.HistoryNormalizationConfig{Enabled: true}}
+	payload := []byte(`{"messages":[
+		{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"f","input":{}},{"type":"tool_use","id":"t2","name":"f","input":{}}]},
+		{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"one"}]},
+		{"role":"user","content":"now continue"},
+		{"role":"user","content":[{"type":"tool_result","tool_use_id":"t2","content":"two"},{"type":"text","text":"thanks"}]}
+	]}`)
+
+	out := NormalizeHistoryWithRoot(cfg, "claude", "", payload)
+	messages := gjson.GetBytes(out, "messages").Array()
+	if len(messages) != 2 {
+		t.Fatalf("messages = %d, want 2: %s", len(messages), out)
+	}
+	var types []string
+	for _, block := range messages[1].Get("content").Array() {
+		types = append(types, block.Get("type").String()+":"+block.Get("tool_use_id").String()+block.Get("text").String())
+	}
+	want := []string{"tool_result:t1", "tool_result:t2", "text:now continue", "text:thanks"}
+	if strings.Join(types, ",") != strings.Join(want, ",") {
+		t.Fatalf("merged blocks = %v, want %v", types, want)
+	}
+}
@@ -0,0 +1,172 @@
+package helps
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultImageInliningMaxBytes = 5 << 20
+	defaultImageInliningTimeout  = 10 * time.Second
+	maxImageInliningRedirects    = 5
+)
+
+var (
+	defaultImageInliningProviders = []string{"claude"}
+	defaultImageInliningTypes     = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+)
+
+// InlineImageURLs downloads the remote images of a request in client format from and replaces
+// them with base64 images when image inlining is enabled for provider. A download that fails or
+// breaks the configured limits rejects the request with an *UnsupportedContentError.
+func InlineImageURLs(ctx context.Context, cfg *config.Config, provider string, from sdktranslator.Format, payload []byte) ([]byte, error) {
+	if cfg == nil || !cfg.ImageInlining.Enabled || len(payload) == 0 {
+		return payload, nil
+	}
+	settings := cfg.ImageInlining
+	providers := settings.Providers
+	if len(providers) == 0 {
+		providers = defaultImageInliningProviders
+	}
+	if !slices.ContainsFunc(providers, func(p string) bool { return strings.EqualFold(strings.TrimSpace(p), provider) }) {
+		return payload, nil
+	}
+
+	fetcher := newImageFetcher(settings)
+	defer fetcher.close()
+	out, err := translatorcommon.InlineRemoteImages(from.String(), payload, func(imageURL string) (translatorcommon.Image, error) {
+		return fetcher.fetch(ctx, imageURL)
+	})
+	if err != nil {
+		return payload, &translatorcommon.UnsupportedContentError{Kind: "image", Target: provider, Reason: err.Error()}
+	}
+	return out, nil
+}
+
+// imageFetcher downloads images within the limits of one image inlining configuration.
+type imageFetcher struct {
+	client       *http.Client
+	transport    *http.Transport
+	maxBytes     int64
+	allowedTypes []string
+}
+
+func newImageFetcher(settings config.ImageInliningConfig) *imageFetcher {
+	timeout := defaultImageInliningTimeout
+	if settings.TimeoutSeconds > 0 {
+		timeout = time.Duration(settings.TimeoutSeconds) * time.Second
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+	if !settings.AllowPrivateNetworks {
+		// Checking the address being dialed, rather than the resolved host name, also covers
+		// redirects and DNS answers that change between lookup and connect.
+		dialer.Control = refusePrivateAddress
+	}
+	transport := &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: timeout,
+	}
+	f := &imageFetcher{
+		transport:    transport,
+		maxBytes:     settings.MaxBytes,
+		allowedTypes: settings.AllowedTypes,
+	}
+	if f.maxBytes <= 0 {
+		f.maxBytes = defaultImageInliningMaxBytes
+	}
+	if len(f.allowedTypes) == 0 {
+		f.allowedTypes = defaultImageInliningTypes
+	}
+	f.client = &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxImageInliningRedirects {
+				return errors.New("too many redirects")
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("redirect to unsupported scheme %q", req.URL.Scheme)
+			}
+			return nil
+		},
+	}
+	return f
+}
+
+func (f *imageFetcher) close() {
+	f.transport.CloseIdleConnections()
+}
+
+// fetch downloads one image and returns it base64 encoded.
+func (f *imageFetcher) fetch(ctx context.Context, imageURL string) (translatorcommon.Image, error) {
+	req, errReq := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if errReq != nil {
+		return translatorcommon.Image{}, fmt.Errorf("fetch %s: %w", imageURL, errReq)
+	}
+	resp, errDo := f.client.Do(req)
+	if errDo != nil {
+		return translatorcommon.Image{}, fmt.Errorf("fetch %s: %w", imageURL, errDo)
+	}
+	defer func() {
+		if errClose := resp.Body.Close(); errClose != nil {
+			log.Errorf("image inlining: close response body error: %v", errClose)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return translatorcommon.Image{}, fmt.Errorf("fetch %s: status %d", imageURL, resp.StatusCode)
+	}
+	if resp.ContentLength > f.maxBytes {
+		return translatorcommon.Image{}, fmt.Errorf("fetch %s: image exceeds %d bytes", imageURL, f.maxBytes)
+	}
+	data, errRead := io.ReadAll(io.LimitReader(resp.Body, f.maxBytes+1))
+	if errRead != nil {
+		return translatorcommon.Image{}, fmt.Errorf("fetch %s: %w", imageURL, errRead)
+	}
+	if int64(len(data)) > f.maxBytes {
+		return translatorcommon.Image{}, fmt.Errorf("fetch %s: image exceeds %d bytes", imageURL, f.maxBytes)
+	}
+
+	mimeType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !slices.Contains(f.allowedTypes, mimeType) {
+		// Servers often label images as application/octet-stream; trust the content instead.
+		mimeType, _, _ = mime.ParseMediaType(http.DetectContentType(data))
+	}
+	if !slices.Contains(f.allowedTypes, mimeType) {
+		return translatorcommon.Image{}, fmt.Errorf("fetch %s: media type %q is not allowed", imageURL, mimeType)
+	}
+	log.Debugf("image inlining: fetched %s (%s, %d bytes)", imageURL, mimeType, len(data))
+	return translatorcommon.Image{MimeType: mimeType, Data: base64.StdEncoding.EncodeToString(data)}, nil
+}
+
+// refusePrivateAddress rejects connections to loopback, private, link-local, multicast and
+// unspecified addresses.
+func refusePrivateAddress(_, address string, _ syscall.RawConn) error {
+	host, _, errSplit := net.SplitHostPort(address)
+	if errSplit != nil {
+		return errSplit
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("refusing to connect to %s: not an IP address", host)
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("refusing to connect to non-public address %s", ip)
+	}
+	return nil
+}
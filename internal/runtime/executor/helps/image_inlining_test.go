@@ -0,0 +1,100 @@
+package helps
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+// pngHeader is enough of a PNG file for content sniffing.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func newImageServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cat.png":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			_, _ = w.Write(pngHeader)
+		case "/page.html":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte("<html></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestInlineImageURLs(t *testing.T) {
+	server := newImageServer(t)
+	cfg := &config.Config{ImageInlining: config.ImageInliningConfig{Enabled: true, AllowPrivateNetworks: true}}
+	want := base64.StdEncoding.EncodeToString(pngHeader)
+
+	openaiPayload := []byte(`{"messages":[{"role":"user","content":[{"type":"text","text":"hi"},{"type":"image_url","image_url":{"url":"` + server.URL + `/cat.png"}}]}]}`)
+	out, err := InlineImageURLs(context.Background(), cfg, "claude", sdktranslator.FormatOpenAI, openaiPayload)
+	if err != nil {
+		t.Fatalf("InlineImageURLs(openai) error: %v", err)
+	}
+	if got := gjson.GetBytes(out, "messages.0.content.1.image_url.url").String(); got != "data:image/png;base64,"+want {
+		t.Fatalf("image_url.url = %q", got)
+	}
+
+	claudePayload := []byte(`{"messages":[{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":[{"type":"image","source":{"type":"url","url":"` + server.URL + `/cat.png"}}]}]}]}`)
+	out, err = InlineImageURLs(context.Background(), cfg, "claude", sdktranslator.FormatClaude, claudePayload)
+	if err != nil {
+		t.Fatalf("InlineImageURLs(claude) error: %v", err)
+	}
+	source := gjson.GetBytes(out, "messages.0.content.0.content.0.source")
+	if source.Get("type").String() != "base64" || source.Get("media_type").String() != "image/png" || source.Get("data").String() != want {
+		t.Fatalf("source = %s", source.Raw)
+	}
+
+	geminiPayload := []byte(`{"request":{"contents":[{"role":"user","parts":[{"fileData":{"mimeType":"image/png","fileUri":"` + server.URL + `/cat.png"}}]}]}}`)
+	out, err = InlineImageURLs(context.Background(), cfg, "claude", sdktranslator.FormatGeminiCLI, geminiPayload)
+	if err != nil {
+		t.Fatalf("InlineImageURLs(gemini-cli) error: %v", err)
+	}
+	part := gjson.GetBytes(out, "request.contents.0.parts.0")
+	if part.Get("fileData").Exists() || part.Get("inlineData.data").String() != want {
+		t.Fatalf("part = %s", part.Raw)
+	}
+
+	if out, err = InlineImageURLs(context.Background(), cfg, "gemini", sdktranslator.FormatOpenAI, openaiPayload); err != nil || string(out) != string(openaiPayload) {
+		t.Fatalf("providers not listed must be left alone, got %s, %v", out, err)
+	}
+}
+
+func TestInlineImageURLsRejects(t *testing.T) {
+	server := newImageServer(t)
+	payload := func(path string) []byte {
+		return []byte(`{"input":[{"role":"user","content":[{"type":"input_image","image_url":"` + server.URL + path + `"}]}]}`)
+	}
+	tests := []struct {
+		name     string
+		settings config.ImageInliningConfig
+		path     string
+	}{
+		{"private address", config.ImageInliningConfig{Enabled: true}, "/cat.png"},
+		{"media type", config.ImageInliningConfig{Enabled: true, AllowPrivateNetworks: true}, "/page.html"},
+		{"size", config.ImageInliningConfig{Enabled: true, AllowPrivateNetworks: true, MaxBytes: 4}, "/cat.png"},
+		{"status", config.ImageInliningConfig{Enabled: true, AllowPrivateNetworks: true}, "/missing.png"},
+	}
+	for _, tt := range tests {
+		cfg := &config.Config{ImageInlining: tt.settings}
+		_, err := InlineImageURLs(context.Background(), cfg, "claude", sdktranslator.FormatOpenAIResponse, payload(tt.path))
+		unsupported, ok := errors.AsType[*translatorcommon.UnsupportedContentError](err)
+		if !ok || unsupported.StatusCode() != http.StatusBadRequest {
+			t.Fatalf("%s: error = %v, want an unsupported image error", tt.name, err)
+		}
+	}
+}
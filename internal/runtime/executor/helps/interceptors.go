@@ -0,0 +1,123 @@
+package helps
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+)
+
+// interceptingRoundTripper runs the configured interceptors around each upstream call: request
+// interceptors after the executor built the translated request, response interceptors before
+// the executor reads and translates the response.
+type interceptingRoundTripper struct {
+	base     http.RoundTripper
+	target   cliproxyexecutor.InterceptTarget
+	requests []cliproxyexecutor.RequestInterceptor
+	// responses run in reverse list order, so the first interceptor wraps the others.
+	responses []cliproxyexecutor.ResponseInterceptor
+}
+
+// applyInterceptors wraps the client transport with the cfg.Interceptors entries that match
+// auth. Entries naming an unknown interceptor or with invalid options are logged and skipped.
+func applyInterceptors(client *http.Client, cfg *config.Config, auth *cliproxyauth.Auth) {
+	if cfg == nil || len(cfg.Interceptors) == 0 || auth == nil {
+		return
+	}
+	rt := &interceptingRoundTripper{
+		target: cliproxyexecutor.InterceptTarget{Provider: auth.Provider, AuthID: auth.ID, Attributes: auth.Attributes},
+	}
+	for _, entry := range cfg.Interceptors {
+		if !interceptorMatches(entry.Provider, auth) {
+			continue
+		}
+		interceptor, errBuild := cliproxyexecutor.NewInterceptor(entry.Name, entry.Options)
+		if errBuild != nil {
+			log.Warnf("interceptors: skipping entry for provider %s: %v", entry.Provider, errBuild)
+			continue
+		}
+		if requestInterceptor, ok := interceptor.(cliproxyexecutor.RequestInterceptor); ok {
+			rt.requests = append(rt.requests, requestInterceptor)
+		}
+		if responseInterceptor, ok := interceptor.(cliproxyexecutor.ResponseInterceptor); ok {
+			rt.responses = append([]cliproxyexecutor.ResponseInterceptor{responseInterceptor}, rt.responses...)
+		}
+	}
+	if len(rt.requests) == 0 && len(rt.responses) == 0 {
+		return
+	}
+	rt.base = client.Transport
+	if rt.base == nil {
+		rt.base = http.DefaultTransport
+	}
+	client.Transport = rt
+}
+
+// interceptorMatches reports whether provider names auth's provider, provider key or
+// openai-compatibility name, or is "*".
+func interceptorMatches(provider string, auth *cliproxyauth.Auth) bool {
+	provider = strings.TrimSpace(provider)
+	if provider == "*" {
+		return true
+	}
+	if provider == "" {
+		return false
+	}
+	for _, name := range []string{auth.Provider, auth.Attributes["provider_key"], auth.Attributes["compat_name"]} {
+		if strings.EqualFold(provider, strings.TrimSpace(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *interceptingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if len(t.requests) > 0 {
+		// A RoundTripper must not modify the caller's request.
+		req = req.Clone(ctx)
+		for _, interceptor := range t.requests {
+			if errIntercept := interceptor.InterceptRequest(ctx, t.target, req); errIntercept != nil {
+				closeRequestBody(req)
+				return nil, errIntercept
+			}
+		}
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp == nil {
+		return resp, err
+	}
+	if errIntercept := t.interceptResponse(ctx, resp); errIntercept != nil {
+		if resp.Body != nil {
+			_ = resp.Body.Close()
+		}
+		return nil, errIntercept
+	}
+	return resp, nil
+}
+
+func (t *interceptingRoundTripper) interceptResponse(ctx context.Context, resp *http.Response) error {
+	for _, interceptor := range t.responses {
+		if errIntercept := interceptor.InterceptResponse(ctx, t.target, resp); errIntercept != nil {
+			return errIntercept
+		}
+	}
+	return nil
+}
+
+// CloseIdleConnections forwards to the wrapped transport.
+func (t *interceptingRoundTripper) CloseIdleConnections() {
+	if closer, ok := t.base.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+func closeRequestBody(req *http.Request) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+}
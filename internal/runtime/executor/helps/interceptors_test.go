@@ -0,0 +1,86 @@
+package helps
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+)
+
+// signingInterceptor signs requests with the credential key and tags responses.
+type signingInterceptor struct{}
+
+func (signingInterceptor) InterceptRequest(_ context.Context, target cliproxyexecutor.InterceptTarget, req *http.Request) error {
+	if target.Attributes["api_key"] == "" {
+		return errors.New("missing key")
+	}
+	req.Header.Set("X-Signature", "signed:"+target.Attributes["api_key"])
+	return nil
+}
+
+func (signingInterceptor) InterceptResponse(_ context.Context, target cliproxyexecutor.InterceptTarget, resp *http.Response) error {
+	resp.Header.Set("X-Intercepted-Auth", target.AuthID)
+	return nil
+}
+
+func TestInterceptorsWrapUpstreamCalls(t *testing.T) {
+	t.Parallel()
+
+	cliproxyexecutor.RegisterInterceptor("test-signing", func(map[string]string) (any, error) {
+		return signingInterceptor{}, nil
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Seen-Signature", r.Header.Get("X-Signature"))
+		w.Header().Set("X-Seen-Tenant", r.Header.Get("X-Tenant"))
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{Interceptors: []config.InterceptorConfig{
+		{Provider: "Acme", Name: "set-headers", Options: map[string]string{"X-Tenant": "team-a"}},
+		{Provider: "acme", Name: "patch-json", Options: map[string]string{"metadata.tag": `"proxy"`, "max_tokens": "64"}},
+		{Provider: "*", Name: "test-signing"},
+		{Provider: "codex", Name: "set-headers", Options: map[string]string{"X-Tenant": "other"}},
+		{Provider: "acme", Name: "does-not-exist"},
+	}}
+	auth := &cliproxyauth.Auth{
+		ID:         "interceptor-test",
+		Provider:   "openai-compatibility",
+		Attributes: map[string]string{"compat_name": "acme", "api_key": "k1"},
+	}
+	client := NewProxyAwareHTTPClient(context.Background(), cfg, auth, 0)
+
+	resp, err := client.Post(server.URL, "application/json", strings.NewReader(`{"model":"m","max_tokens":8}`))
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	if got := resp.Header.Get("X-Seen-Tenant"); got != "team-a" {
+		t.Fatalf("tenant header = %q, want team-a", got)
+	}
+	if got := resp.Header.Get("X-Seen-Signature"); got != "signed:k1" {
+		t.Fatalf("signature = %q", got)
+	}
+	if got := resp.Header.Get("X-Intercepted-Auth"); got != auth.ID {
+		t.Fatalf("response interceptor tag = %q", got)
+	}
+	if gjson.GetBytes(body, "metadata.tag").String() != "proxy" || gjson.GetBytes(body, "max_tokens").Int() != 64 {
+		t.Fatalf("patched body = %s", body)
+	}
+
+	unsigned := &cliproxyauth.Auth{ID: "interceptor-test-unsigned", Provider: "codex"}
+	if _, err = NewProxyAwareHTTPClient(context.Background(), cfg, unsigned, 0).Get(server.URL); err == nil || !strings.Contains(err.Error(), "missing key") {
+		t.Fatalf("error = %v, want request interceptor failure", err)
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/streamwatch"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	log "github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
@@ -153,6 +154,7 @@ func RecordAPIResponseError(ctx context.Context, cfg *config.Config, err error)
 
 // AppendAPIResponseChunk appends an upstream response chunk to Gin context for request logging.
 func AppendAPIResponseChunk(ctx context.Context, cfg *config.Config, chunk []byte) {
+	streamwatch.Publish(logging.GetRequestID(ctx), streamwatch.Upstream, chunk)
 	if cfg == nil || !cfg.RequestLog {
 		return
 	}
@@ -284,6 +286,7 @@ func WebsocketUpgradeRequestURL(rawURL string) string {
 
 // AppendAPIWebsocketResponse stores an upstream websocket response frame in Gin context.
 func AppendAPIWebsocketResponse(ctx context.Context, cfg *config.Config, payload []byte) {
+	streamwatch.Publish(logging.GetRequestID(ctx), streamwatch.Upstream, payload)
 	if cfg == nil || !cfg.RequestLog {
 		return
 	}
@@ -0,0 +1,12 @@
+package helps
+
+import (
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// CheckLogprobsSupport rejects a request that asks for token log probabilities the target
+// format cannot return, instead of answering it without them.
+func CheckLogprobsSupport(from, to sdktranslator.Format, payload []byte) error {
+	return translatorcommon.CheckLogprobs(from.String(), to.String(), payload)
+}
@@ -0,0 +1,48 @@
+package helps
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// AuthStyleBearer sends the API key as "Authorization: Bearer <key>".
+	AuthStyleBearer = "bearer"
+	// AuthStyleNone sends no API key, for providers authenticated by custom headers or a
+	// network boundary.
+	AuthStyleNone = "none"
+)
+
+// OpenAICompatURL joins an OpenAI-compatible provider's base URL with a path template,
+// falling back to defaultPath when the template is empty. "{model}" in the template is
+// replaced with the escaped upstream model name, for deployment-style APIs such as
+// "/openai/deployments/{model}/chat/completions?api-version=2024-10-21".
+func OpenAICompatURL(baseURL, pathTemplate, defaultPath, model string) string {
+	path := strings.TrimSpace(pathTemplate)
+	if path == "" {
+		path = defaultPath
+	}
+	path = strings.ReplaceAll(path, "{model}", url.PathEscape(model))
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return strings.TrimSuffix(baseURL, "/") + path
+}
+
+// ApplyOpenAICompatAuth sets the API key on header according to an OpenAI-compatible
+// provider's auth style: "bearer" (default), "none", or the name of a header that carries the
+// raw key, such as "api-key" or "x-api-key".
+func ApplyOpenAICompatAuth(header http.Header, style, apiKey string) {
+	if apiKey == "" {
+		return
+	}
+	style = strings.TrimSpace(style)
+	switch strings.ToLower(style) {
+	case "", AuthStyleBearer:
+		header.Set("Authorization", "Bearer "+apiKey)
+	case AuthStyleNone:
+	default:
+		header.Set(style, apiKey)
+	}
+}
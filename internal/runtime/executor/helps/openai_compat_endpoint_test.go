@@ -0,0 +1,44 @@
+package helps
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestOpenAICompatURL(t *testing.T) {
+	tests := []struct {
+		base, template, model, want string
+	}{
+		{"https://api.example.com/v1/", "", "m", "https://api.example.com/v1/chat/completions"},
+		{"https://api.example.com", "v1/chat/completions", "m", "https://api.example.com/v1/chat/completions"},
+		{"https://res.openai.azure.com", "/openai/deployments/{model}/chat/completions?api-version=2024-10-21", "gpt 4o", "https://res.openai.azure.com/openai/deployments/gpt%204o/chat/completions?api-version=2024-10-21"},
+	}
+	for _, tt := range tests {
+		if got := OpenAICompatURL(tt.base, tt.template, "/chat/completions", tt.model); got != tt.want {
+			t.Errorf("OpenAICompatURL(%q, %q) = %q, want %q", tt.base, tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestApplyOpenAICompatAuth(t *testing.T) {
+	tests := []struct {
+		style, header, want string
+	}{
+		{"", "Authorization", "Bearer k"},
+		{"Bearer", "Authorization", "Bearer k"},
+		{"api-key", "Api-Key", "k"},
+		{"x-api-key", "X-Api-Key", "k"},
+	}
+	for _, tt := range tests {
+		header := http.Header{}
+		ApplyOpenAICompatAuth(header, tt.style, "k")
+		if got := header.Get(tt.header); got != tt.want {
+			t.Errorf("style %q: %s = %q, want %q", tt.style, tt.header, got, tt.want)
+		}
+	}
+	header := http.Header{}
+	ApplyOpenAICompatAuth(header, "none", "k")
+	if len(header) != 0 {
+		t.Errorf("style none set headers: %v", header)
+	}
+}
@@ -16,6 +16,7 @@ import (
 // and restricts matches to the given protocol when supplied. Defaults are checked
 // against the original payload when provided. requestedModel carries the client-visible
 // model name before alias resolution so payload rules can target aliases precisely.
+// When history normalization is enabled, the conversation history is repaired after the rules run.
 func ApplyPayloadConfigWithRoot(cfg *config.Config, model, protocol, root string, payload, original []byte, requestedModel string) []byte {
 	if cfg == nil || len(payload) == 0 {
 		return payload
@@ -149,6 +150,8 @@ func ApplyPayloadConfigWithRoot(cfg *config.Config, model, protocol, root string
 		}
 	}
 
+	out = NormalizeHistoryWithRoot(cfg, protocol, root, out)
+
 	if cfg.DisableImageGeneration {
 		out = removeToolTypeFromPayloadWithRoot(out, root, "image_generation")
 	}
@@ -0,0 +1,99 @@
+package helps
+
+import (
+	"fmt"
+	"strings"
+
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// PromptCachePassthrough forwards the cache_control markers translated from Claude requests.
+	PromptCachePassthrough = "passthrough"
+	// PromptCacheAuto forwards translated markers and, when a request carries none, marks the
+	// end of a large system prompt so the tool definitions and system prompt are cached.
+	PromptCacheAuto = "auto"
+
+	// promptCacheMinPrefixBytes approximates the 1024 token minimum below which providers do
+	// not cache a prefix.
+	promptCacheMinPrefixBytes = 4096
+)
+
+// ApplyPromptCache applies an OpenAI-compatible provider's prompt-cache mode to a translated
+// Chat Completions payload. Markers sent by Chat Completions clients are always kept. Without a
+// mode, markers that only exist because a request of another format was translated are removed,
+// since most providers do not accept them.
+func ApplyPromptCache(from sdktranslator.Format, payload []byte, mode string) []byte {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	switch mode {
+	case PromptCachePassthrough:
+		return payload
+	case PromptCacheAuto:
+		if countOpenAICacheControls(payload) > 0 {
+			return payload
+		}
+		return injectOpenAISystemCacheControl(payload)
+	}
+	if from == sdktranslator.FormatOpenAI {
+		return payload
+	}
+	return stripOpenAICacheControl(payload)
+}
+
+func countOpenAICacheControls(payload []byte) int {
+	count := 0
+	for _, message := range gjson.GetBytes(payload, "messages").Array() {
+		for _, part := range message.Get("content").Array() {
+			if part.Get("cache_control").Exists() {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func stripOpenAICacheControl(payload []byte) []byte {
+	for i, message := range gjson.GetBytes(payload, "messages").Array() {
+		for j, part := range message.Get("content").Array() {
+			if part.Get("cache_control").Exists() {
+				payload, _ = sjson.DeleteBytes(payload, fmt.Sprintf("messages.%d.content.%d.cache_control", i, j))
+			}
+		}
+	}
+	return payload
+}
+
+// injectOpenAISystemCacheControl marks the last part of the leading system messages when the
+// tool definitions and system prompt together are long enough to be cached. Providers place
+// tool definitions before the system prompt, so one marker covers both.
+func injectOpenAISystemCacheControl(payload []byte) []byte {
+	last := -1
+	prefixBytes := len(gjson.GetBytes(payload, "tools").Raw)
+	for i, message := range gjson.GetBytes(payload, "messages").Array() {
+		role := message.Get("role").String()
+		if role != "system" && role != "developer" {
+			break
+		}
+		last = i
+		prefixBytes += len(message.Get("content").Raw)
+	}
+	if last < 0 || prefixBytes < promptCacheMinPrefixBytes {
+		return payload
+	}
+
+	contentPath := fmt.Sprintf("messages.%d.content", last)
+	content := gjson.GetBytes(payload, contentPath)
+	if content.Type == gjson.String {
+		part := []byte(`{"type":"text","text":""}`)
+		part, _ = sjson.SetBytes(part, "text", content.String())
+		payload, _ = sjson.SetRawBytes(payload, contentPath, []byte(`[`+string(part)+`]`))
+	}
+	parts := len(gjson.GetBytes(payload, contentPath).Array())
+	if parts == 0 {
+		return payload
+	}
+	payload, _ = sjson.SetRawBytes(payload, fmt.Sprintf("%s.%d.cache_control", contentPath, parts-1), []byte(`{"type":"ephemeral"}`))
+	return payload
+}
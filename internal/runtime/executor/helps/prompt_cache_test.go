@@ -0,0 +1,47 @@
+package helps
+
+import (
+	"strings"
+	"testing"
+
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestApplyPromptCacheModes(t *testing.T) {
+	payload := []byte(`{"messages":[{"role":"system","content":[{"type":"text","text":"rules","cache_control":{"type":"ephemeral"}}]},{"role":"user","content":"hi"}]}`)
+
+	stripped := ApplyPromptCache(sdktranslator.FormatClaude, payload, "")
+	if gjson.GetBytes(stripped, "messages.0.content.0.cache_control").Exists() {
+		t.Fatalf("translated markers must be dropped by default: %s", stripped)
+	}
+	if kept := ApplyPromptCache(sdktranslator.FormatOpenAI, payload, ""); !gjson.GetBytes(kept, "messages.0.content.0.cache_control").Exists() {
+		t.Fatalf("markers sent by Chat Completions clients must be kept: %s", kept)
+	}
+	if kept := ApplyPromptCache(sdktranslator.FormatClaude, payload, "passthrough"); !gjson.GetBytes(kept, "messages.0.content.0.cache_control").Exists() {
+		t.Fatalf("passthrough must keep translated markers: %s", kept)
+	}
+}
+
+func TestApplyPromptCacheAutoInjectsOnLargeSystemPrompt(t *testing.T) {
+	large := strings.Repeat("stable instructions ", 300)
+	payload := []byte(`{"messages":[{"role":"system","content":"` + large + `"},{"role":"user","content":"hi"}]}`)
+
+	out := ApplyPromptCache(sdktranslator.FormatOpenAI, payload, "auto")
+	if got := gjson.GetBytes(out, "messages.0.content.0.cache_control.type").String(); got != "ephemeral" {
+		t.Fatalf("expected a marker on the system prompt, got %s", out)
+	}
+	if gjson.GetBytes(out, "messages.0.content.0.text").String() != large {
+		t.Fatal("system prompt text changed")
+	}
+
+	small := []byte(`{"messages":[{"role":"system","content":"short"},{"role":"user","content":"hi"}]}`)
+	if out := ApplyPromptCache(sdktranslator.FormatOpenAI, small, "auto"); string(out) != string(small) {
+		t.Fatalf("short prompts must not be marked: %s", out)
+	}
+
+	marked := []byte(`{"messages":[{"role":"system","content":"` + large + `"},{"role":"user","content":[{"type":"text","text":"hi","cache_control":{"type":"ephemeral"}}]}]}`)
+	if out := ApplyPromptCache(sdktranslator.FormatOpenAI, marked, "auto"); gjson.GetBytes(out, "messages.0.content").Type != gjson.String {
+		t.Fatalf("requests with their own markers must not be changed: %s", out)
+	}
+}
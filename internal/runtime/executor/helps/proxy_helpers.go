@@ -17,6 +17,14 @@ import (
 // 2. Use cfg.ProxyURL if auth proxy is not configured
 // 3. Use RoundTripper from context if neither are configured
 //
+// Proxied and direct clients share a pooled transport per provider, proxy and auth, so
+// connections are reused across requests. The configured upstream timeouts of the auth's
+// provider, and the client's TimeoutHeader override, are applied on top of the chosen transport.
+// With cfg.UpstreamCompression set, non-streaming responses are negotiated compressed and
+// decompressed before the caller reads them. An auth with fallback endpoints fails over to
+// them on connect errors and 5xx responses. The cfg.Interceptors entries matching the auth
+// wrap the client last, so response interceptors see decompressed bodies.
+//
 // Parameters:
 //   - ctx: The context containing optional RoundTripper
 //   - cfg: The application configuration
@@ -26,10 +34,19 @@ import (
 // Returns:
 //   - *http.Client: An HTTP client with configured proxy or transport
 func NewProxyAwareHTTPClient(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, timeout time.Duration) *http.Client {
+	return NewPooledHTTPClient(ctx, cfg, auth, timeout, "", nil)
+}
+
+// NewPooledHTTPClient is NewProxyAwareHTTPClient for executors that need a customized
+// transport. customize adjusts each pooled transport once, before it is shared, and also a
+// copy of a context RoundTripper that is an *http.Transport. variant names the customization
+// so that transports customized differently never share a pool entry.
+func NewPooledHTTPClient(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, timeout time.Duration, variant string, customize func(*http.Transport)) *http.Client {
 	httpClient := &http.Client{}
 	if timeout > 0 {
 		httpClient.Timeout = timeout
 	}
+	timeouts := resolveUpstreamTimeouts(ctx, cfg, auth)
 
 	// Priority 1: Use auth.ProxyURL if configured
 	var proxyURL string
@@ -44,9 +61,13 @@ func NewProxyAwareHTTPClient(ctx context.Context, cfg *config.Config, auth *clip
 
 	// If we have a proxy URL configured, set up the transport
 	if proxyURL != "" {
-		transport := buildProxyTransport(proxyURL)
+		transport := pooledHTTPTransport(cfg, auth, proxyURL, variant, timeouts.connect, customize)
 		if transport != nil {
 			httpClient.Transport = transport
+			applyUpstreamTimeouts(httpClient, timeouts.withoutConnect())
+			applyEndpointFailover(httpClient, auth)
+			applyUpstreamCompression(httpClient, cfg)
+			applyInterceptors(httpClient, cfg, auth)
 			return httpClient
 		}
 		// If proxy setup failed, log and fall through to context RoundTripper
@@ -55,9 +76,24 @@ func NewProxyAwareHTTPClient(ctx context.Context, cfg *config.Config, auth *clip
 
 	// Priority 3: Use RoundTripper from context (typically from RoundTripperFor)
 	if rt, ok := ctx.Value("cliproxy.roundtripper").(http.RoundTripper); ok && rt != nil {
+		if transport, isTransport := rt.(*http.Transport); isTransport && customize != nil {
+			transport = transport.Clone()
+			customize(transport)
+			rt = transport
+		}
 		httpClient.Transport = rt
+		applyUpstreamTimeouts(httpClient, timeouts)
+		applyEndpointFailover(httpClient, auth)
+		applyUpstreamCompression(httpClient, cfg)
+		applyInterceptors(httpClient, cfg, auth)
+		return httpClient
 	}
 
+	httpClient.Transport = pooledHTTPTransport(cfg, auth, "", variant, timeouts.connect, customize)
+	applyUpstreamTimeouts(httpClient, timeouts.withoutConnect())
+	applyEndpointFailover(httpClient, auth)
+	applyUpstreamCompression(httpClient, cfg)
+	applyInterceptors(httpClient, cfg, auth)
 	return httpClient
 }
 
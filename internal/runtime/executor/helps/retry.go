@@ -0,0 +1,155 @@
+package helps
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+const (
+	defaultRetryInitialBackoff = 500 * time.Millisecond
+	defaultRetryMaxBackoff     = 10 * time.Second
+	defaultRetryJitter         = 0.2
+)
+
+var defaultRetryableStatusCodes = []int{
+	http.StatusRequestTimeout,
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// retryPolicy is the resolved form of config.UpstreamRetryConfig.
+type retryPolicy struct {
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	jitter         float64
+	statusCodes    []int
+}
+
+func newRetryPolicy(cfg *config.Config) retryPolicy {
+	var rc config.UpstreamRetryConfig
+	if cfg != nil {
+		rc = cfg.UpstreamRetry
+	}
+	p := retryPolicy{
+		maxAttempts:    max(rc.MaxAttempts, 1),
+		initialBackoff: time.Duration(rc.InitialBackoffMS) * time.Millisecond,
+		maxBackoff:     time.Duration(rc.MaxBackoffMS) * time.Millisecond,
+		jitter:         min(rc.Jitter, 1),
+		statusCodes:    rc.RetryableStatusCodes,
+	}
+	if p.initialBackoff <= 0 {
+		p.initialBackoff = defaultRetryInitialBackoff
+	}
+	if p.maxBackoff <= 0 {
+		p.maxBackoff = defaultRetryMaxBackoff
+	}
+	if p.jitter <= 0 {
+		p.jitter = defaultRetryJitter
+	}
+	if len(p.statusCodes) == 0 {
+		p.statusCodes = defaultRetryableStatusCodes
+	}
+	return p
+}
+
+// backoff returns the jittered delay before retry number retry (starting at 1).
+func (p retryPolicy) backoff(retry int) time.Duration {
+	delay := p.initialBackoff
+	for i := 1; i < retry && delay < p.maxBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, p.maxBackoff)
+	spread := float64(delay) * p.jitter
+	delay += time.Duration(spread * (2*rand.Float64() - 1))
+	return min(max(delay, 0), p.maxBackoff)
+}
+
+// retryDelay reports whether a response with the given status should be retried and how long
+// to wait first. A Retry-After longer than the maximum backoff is not waited for.
+func (p retryPolicy) retryDelay(retry int, resp *http.Response, now time.Time) (time.Duration, bool) {
+	if !slices.Contains(p.statusCodes, resp.StatusCode) {
+		return 0, false
+	}
+	if wait, ok := parseRetryAfterHeader(resp.Header.Get("Retry-After"), now); ok {
+		if wait > p.maxBackoff {
+			return 0, false
+		}
+		return wait, true
+	}
+	return p.backoff(retry), true
+}
+
+// parseRetryAfterHeader parses a Retry-After value given in seconds or as an HTTP date.
+func parseRetryAfterHeader(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// DoWithRetry sends req with client, retrying network errors and retryable statuses according to
+// cfg.UpstreamRetry. Retries happen before the response is returned, so a streaming client never
+// sees a failed attempt. Requests whose body cannot be replayed are sent once.
+func DoWithRetry(ctx context.Context, cfg *config.Config, client *http.Client, req *http.Request) (*http.Response, error) {
+	policy := newRetryPolicy(cfg)
+	if policy.maxAttempts <= 1 || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+		return client.Do(req)
+	}
+	for attempt := 1; ; attempt++ {
+		resp, errDo := client.Do(req)
+		if attempt >= policy.maxAttempts {
+			return resp, errDo
+		}
+		var wait time.Duration
+		if errDo != nil {
+			if ctx.Err() != nil || errors.Is(errDo, context.Canceled) {
+				return nil, errDo
+			}
+			wait = policy.backoff(attempt)
+			LogWithRequestID(ctx).Debugf("upstream request failed, retrying in %s (attempt %d/%d): %v", wait, attempt, policy.maxAttempts, errDo)
+		} else {
+			var retry bool
+			if wait, retry = policy.retryDelay(attempt, resp, time.Now()); !retry {
+				return resp, nil
+			}
+			LogWithRequestID(ctx).Debugf("upstream returned status %d, retrying in %s (attempt %d/%d)", resp.StatusCode, wait, attempt, policy.maxAttempts)
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			_ = resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		if req.GetBody != nil {
+			body, errBody := req.GetBody()
+			if errBody != nil {
+				return nil, errBody
+			}
+			req.Body = body
+		}
+	}
+}
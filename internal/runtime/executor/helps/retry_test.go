@@ -0,0 +1,150 @@
+package helps
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func retryTestConfig(attempts int) *config.Config {
+	return &config.Config{UpstreamRetry: config.UpstreamRetryConfig{MaxAttempts: attempts, InitialBackoffMS: 1, MaxBackoffMS: 1000}}
+}
+
+func TestDoWithRetryRetriesTransientStatus(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"q":1}` {
+			t.Errorf("attempt %d body = %q", calls.Load()+1, body)
+		}
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, server.URL, bytes.NewReader([]byte(`{"q":1}`)))
+	resp, err := DoWithRetry(context.Background(), retryTestConfig(3), server.Client(), req)
+	if err != nil {
+		t.Fatalf("DoWithRetry error: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK || calls.Load() != 3 {
+		t.Fatalf("status = %d after %d calls, want 200 after 3", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestDoWithRetryReturnsLastFailure(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte("bad gateway"))
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, server.URL, bytes.NewReader([]byte("{}")))
+	resp, err := DoWithRetry(context.Background(), retryTestConfig(2), server.Client(), req)
+	if err != nil {
+		t.Fatalf("DoWithRetry error: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusBadGateway || string(body) != "bad gateway" || calls.Load() != 2 {
+		t.Fatalf("got %d %q after %d calls", resp.StatusCode, body, calls.Load())
+	}
+}
+
+func TestDoWithRetrySkipsLongRetryAfterAndClientErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name       string
+		status     int
+		retryAfter string
+	}{
+		{name: "long retry-after", status: http.StatusTooManyRequests, retryAfter: "120"},
+		{name: "client error", status: http.StatusBadRequest},
+	} {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			if tc.retryAfter != "" {
+				w.Header().Set("Retry-After", tc.retryAfter)
+			}
+			w.WriteHeader(tc.status)
+		}))
+
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+		resp, err := DoWithRetry(context.Background(), retryTestConfig(3), server.Client(), req)
+		if err != nil {
+			t.Fatalf("%s: DoWithRetry error: %v", tc.name, err)
+		}
+		_ = resp.Body.Close()
+		server.Close()
+		if resp.StatusCode != tc.status || calls.Load() != 1 {
+			t.Fatalf("%s: status = %d after %d calls, want %d after 1", tc.name, resp.StatusCode, calls.Load(), tc.status)
+		}
+	}
+}
+
+func TestDoWithRetryDisabledByDefault(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+	resp, err := DoWithRetry(context.Background(), &config.Config{}, server.Client(), req)
+	if err != nil {
+		t.Fatalf("DoWithRetry error: %v", err)
+	}
+	_ = resp.Body.Close()
+	if calls.Load() != 1 {
+		t.Fatalf("calls = %d, want 1", calls.Load())
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	t.Parallel()
+
+	policy := newRetryPolicy(&config.Config{UpstreamRetry: config.UpstreamRetryConfig{MaxAttempts: 5, InitialBackoffMS: 100, MaxBackoffMS: 1000, Jitter: 0.5}})
+	for retry, base := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 400 * time.Millisecond} {
+		for range 20 {
+			if got := policy.backoff(retry); got < base/2 || got > base*3/2 {
+				t.Fatalf("backoff(%d) = %s, want within 50%% of %s", retry, got, base)
+			}
+		}
+	}
+	if got := policy.backoff(10); got > time.Second {
+		t.Fatalf("backoff(10) = %s, want capped at 1s", got)
+	}
+
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+	resp.Header.Set("Retry-After", now.Add(time.Second).Format(http.TimeFormat))
+	if wait, ok := policy.retryDelay(1, resp, now); !ok || wait != time.Second {
+		t.Fatalf("retryDelay with HTTP date = %s, %v; want 1s, true", wait, ok)
+	}
+	resp.Header.Set("Retry-After", "0")
+	if wait, ok := policy.retryDelay(1, resp, now); !ok || wait != 0 {
+		t.Fatalf("retryDelay with Retry-After 0 = %s, %v; want 0, true", wait, ok)
+	}
+}
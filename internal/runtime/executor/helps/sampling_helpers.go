@@ -0,0 +1,32 @@
+package helps
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+)
+
+// SamplingWarningsHeader reports sampling parameters that were clamped or dropped for the upstream.
+const SamplingWarningsHeader = "X-CPA-Param-Warnings"
+
+// ApplySamplingCapabilities clamps or drops sampling parameters that the target format does
+// not accept, using the translator capability table, and reports every adjustment to the
+// client through the SamplingWarningsHeader response header.
+func ApplySamplingCapabilities(ctx context.Context, from, to sdktranslator.Format, source, translated []byte) []byte {
+	out, warnings := translatorcommon.ApplySamplingCapabilities(from.String(), to.String(), source, translated)
+	if len(warnings) == 0 {
+		return out
+	}
+	joined := strings.Join(warnings, "; ")
+	log.Debugf("sampling parameters adjusted for %s: %s", to, joined)
+	if ctx != nil {
+		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+			ginCtx.Header(SamplingWarningsHeader, joined)
+		}
+	}
+	return out
+}
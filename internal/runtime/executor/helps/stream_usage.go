@@ -0,0 +1,121 @@
+package helps
+
+import (
+	"strings"
+	"time"
+
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// StreamUsageEmulator guarantees the final usage chunk that Chat Completions clients request
+// with stream_options.include_usage. It watches the translated chunks of a stream and, when
+// the upstream never reported usage, synthesizes the chunk from local tokenizer counts of the
+// client request and the streamed completion. A nil emulator is inactive.
+type StreamUsageEmulator struct {
+	model      string
+	request    []byte
+	completion strings.Builder
+	seen       bool
+	id         string
+	created    int64
+	respModel  string
+}
+
+// NewStreamUsageEmulator returns an emulator for a client request in format from, or nil when
+// the client is not a Chat Completions client or did not ask for usage.
+func NewStreamUsageEmulator(from sdktranslator.Format, model string, request []byte) *StreamUsageEmulator {
+	if from != sdktranslator.FormatOpenAI || !gjson.GetBytes(request, "stream_options.include_usage").Bool() {
+		return nil
+	}
+	return &StreamUsageEmulator{model: model, request: request}
+}
+
+// Observe records a translated chunk that is about to be sent to the client.
+func (e *StreamUsageEmulator) Observe(chunk []byte) {
+	if e == nil || e.seen {
+		return
+	}
+	payload := jsonPayload(chunk)
+	if len(payload) == 0 {
+		return
+	}
+	root := gjson.ParseBytes(payload)
+	if usage := root.Get("usage"); usage.IsObject() && (usage.Get("prompt_tokens").Exists() || usage.Get("total_tokens").Exists()) {
+		e.seen = true
+		return
+	}
+	if id := root.Get("id").String(); id != "" {
+		e.id = id
+	}
+	if created := root.Get("created").Int(); created != 0 {
+		e.created = created
+	}
+	if model := root.Get("model").String(); model != "" {
+		e.respModel = model
+	}
+	for _, choice := range root.Get("choices").Array() {
+		delta := choice.Get("delta")
+		e.add(delta.Get("content").String())
+		e.add(delta.Get("reasoning_content").String())
+		for _, call := range delta.Get("tool_calls").Array() {
+			e.add(call.Get("function.name").String())
+			e.add(call.Get("function.arguments").String())
+		}
+	}
+}
+
+func (e *StreamUsageEmulator) add(text string) {
+	if text == "" {
+		return
+	}
+	e.completion.WriteString(text)
+}
+
+// Finish returns the synthesized usage chunk, or nil when the stream already carried usage.
+// Call it once after the last translated chunk of a successful stream.
+func (e *StreamUsageEmulator) Finish() []byte {
+	if e == nil || e.seen {
+		return nil
+	}
+	e.seen = true
+
+	enc, errEnc := TokenizerForModel(e.model)
+	if errEnc != nil {
+		log.Warnf("stream usage emulation: tokenizer init failed: %v", errEnc)
+		return nil
+	}
+	promptTokens, errPrompt := CountOpenAIChatTokens(enc, e.request)
+	if errPrompt != nil {
+		log.Warnf("stream usage emulation: prompt token counting failed: %v", errPrompt)
+		return nil
+	}
+	var completionTokens int64
+	if e.completion.Len() > 0 {
+		count, errCount := enc.Count(e.completion.String())
+		if errCount != nil {
+			log.Warnf("stream usage emulation: completion token counting failed: %v", errCount)
+			return nil
+		}
+		completionTokens = int64(count)
+	}
+
+	model := e.respModel
+	if model == "" {
+		model = e.model
+	}
+	created := e.created
+	if created == 0 {
+		created = time.Now().Unix()
+	}
+	out := []byte(`{"id":"","object":"chat.completion.chunk","created":0,"model":"","choices":[],"usage":{"prompt_tokens":0,"completion_tokens":0,"total_tokens":0}}`)
+	out, _ = sjson.SetBytes(out, "id", e.id)
+	out, _ = sjson.SetBytes(out, "created", created)
+	out, _ = sjson.SetBytes(out, "model", model)
+	out, _ = sjson.SetBytes(out, "usage.prompt_tokens", promptTokens)
+	out, _ = sjson.SetBytes(out, "usage.completion_tokens", completionTokens)
+	out, _ = sjson.SetBytes(out, "usage.total_tokens", promptTokens+completionTokens)
+	return out
+}
@@ -0,0 +1,69 @@
+package helps
+
+import (
+	"testing"
+
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestStreamUsageEmulatorSynthesizesUsage(t *testing.T) {
+	request := []byte(`{"model":"gpt-4o","stream":true,"stream_options":{"include_usage":true},"messages":[{"role":"user","content":"Say hello"}]}`)
+	emulator := NewStreamUsageEmulator(sdktranslator.FormatOpenAI, "gpt-4o", request)
+	if emulator == nil {
+		t.Fatal("expected an emulator when include_usage is requested")
+	}
+	emulator.Observe([]byte(`{"id":"chatcmpl-1","object":"chat.completion.chunk","created":1700000000,"model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","content":"Hello there"}}]}`))
+	emulator.Observe([]byte(`{"id":"chatcmpl-1","object":"chat.completion.chunk","created":1700000000,"model":"gpt-4o","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"name":"lookup","arguments":"{\"q\":\"x\"}"}}]}}]}`))
+	emulator.Observe([]byte(`{"id":"chatcmpl-1","object":"chat.completion.chunk","created":1700000000,"model":"gpt-4o","choices":[{"index":0,"delta":{},"finish_reason":"stop"}],"usage":null}`))
+
+	chunk := emulator.Finish()
+	if chunk == nil {
+		t.Fatal("expected a synthesized usage chunk")
+	}
+	root := gjson.ParseBytes(chunk)
+	if root.Get("id").String() != "chatcmpl-1" || root.Get("created").Int() != 1700000000 || root.Get("model").String() != "gpt-4o" {
+		t.Fatalf("chunk identity not carried over: %s", chunk)
+	}
+	if !root.Get("choices").IsArray() || len(root.Get("choices").Array()) != 0 {
+		t.Fatalf("choices = %s, want []", root.Get("choices").Raw)
+	}
+	prompt := root.Get("usage.prompt_tokens").Int()
+	completion := root.Get("usage.completion_tokens").Int()
+	if prompt <= 0 || completion <= 0 {
+		t.Fatalf("usage = %s, want positive counts", root.Get("usage").Raw)
+	}
+	if root.Get("usage.total_tokens").Int() != prompt+completion {
+		t.Fatalf("total_tokens = %d, want %d", root.Get("usage.total_tokens").Int(), prompt+completion)
+	}
+	if emulator.Finish() != nil {
+		t.Fatal("Finish should emit the usage chunk only once")
+	}
+}
+
+func TestStreamUsageEmulatorSkipsWhenUpstreamReportsUsage(t *testing.T) {
+	request := []byte(`{"stream_options":{"include_usage":true},"messages":[{"role":"user","content":"hi"}]}`)
+	emulator := NewStreamUsageEmulator(sdktranslator.FormatOpenAI, "gpt-4o", request)
+	emulator.Observe([]byte(`{"id":"chatcmpl-1","choices":[{"index":0,"delta":{"content":"hi"}}]}`))
+	emulator.Observe([]byte(`{"id":"chatcmpl-1","choices":[],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}`))
+	if chunk := emulator.Finish(); chunk != nil {
+		t.Fatalf("unexpected synthesized chunk: %s", chunk)
+	}
+}
+
+func TestStreamUsageEmulatorInactive(t *testing.T) {
+	withoutOption := []byte(`{"messages":[{"role":"user","content":"hi"}]}`)
+	if emulator := NewStreamUsageEmulator(sdktranslator.FormatOpenAI, "gpt-4o", withoutOption); emulator != nil {
+		t.Fatal("expected no emulator without include_usage")
+	}
+	withOption := []byte(`{"stream_options":{"include_usage":true}}`)
+	if emulator := NewStreamUsageEmulator(sdktranslator.FormatClaude, "claude", withOption); emulator != nil {
+		t.Fatal("expected no emulator for non Chat Completions clients")
+	}
+
+	var emulator *StreamUsageEmulator
+	emulator.Observe([]byte(`{}`))
+	if emulator.Finish() != nil {
+		t.Fatal("nil emulator should not emit a chunk")
+	}
+}
@@ -0,0 +1,284 @@
+package helps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// TimeoutHeader lets a client override the upstream timeouts of one request. The value is
+// either a total timeout ("120", "2m") or a comma-separated list of phases such as
+// "connect=5s, first-byte=30, total=10m". Numbers without a unit are seconds.
+const TimeoutHeader = "X-CLIProxy-Timeout"
+
+// Timeout phases reported by UpstreamTimeoutError.
+const (
+	TimeoutPhaseConnect   = "connect"
+	TimeoutPhaseFirstByte = "first-byte"
+	TimeoutPhaseTotal     = "total"
+)
+
+// UpstreamTimeoutError reports that the proxy aborted an upstream request because one of its
+// configured timeouts elapsed. It maps to 504 with a proxy_timeout error body, which tells it
+// apart from a 504 returned by the upstream itself.
+type UpstreamTimeoutError struct {
+	Phase string
+	Limit time.Duration
+}
+
+func (e *UpstreamTimeoutError) Error() string {
+	var message string
+	switch e.Phase {
+	case TimeoutPhaseConnect:
+		message = fmt.Sprintf("proxy timeout: could not connect to the upstream within %s", e.Limit)
+	case TimeoutPhaseFirstByte:
+		message = fmt.Sprintf("proxy timeout: upstream sent no response within %s", e.Limit)
+	default:
+		message = fmt.Sprintf("proxy timeout: upstream request did not complete within %s", e.Limit)
+	}
+	return fmt.Sprintf(`{"error":{"message":%q,"type":"proxy_timeout","code":"proxy_%s_timeout"}}`, message, strings.ReplaceAll(e.Phase, "-", "_"))
+}
+
+// StatusCode implements the status interface used by the API handlers.
+func (e *UpstreamTimeoutError) StatusCode() int { return http.StatusGatewayTimeout }
+
+// Timeout and Temporary implement net.Error, so the failure is classified as a timeout.
+func (e *UpstreamTimeoutError) Timeout() bool   { return true }
+func (e *UpstreamTimeoutError) Temporary() bool { return true }
+
+// upstreamTimeouts is the resolved set of timeouts of one upstream request. Zero disables a phase.
+type upstreamTimeouts struct {
+	connect   time.Duration
+	firstByte time.Duration
+	total     time.Duration
+}
+
+func (t upstreamTimeouts) isZero() bool {
+	return t.connect <= 0 && t.firstByte <= 0 && t.total <= 0
+}
+
+// withoutConnect drops the connect timeout, for transports that already enforce it.
+func (t upstreamTimeouts) withoutConnect() upstreamTimeouts {
+	t.connect = 0
+	return t
+}
+
+// resolveUpstreamTimeouts merges the global timeouts, the overrides of the auth's provider and
+// the client's TimeoutHeader, in that order.
+func resolveUpstreamTimeouts(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth) upstreamTimeouts {
+	var t upstreamTimeouts
+	if cfg != nil {
+		t.merge(cfg.Timeouts.TimeoutSettings)
+		if auth != nil && len(cfg.Timeouts.Providers) > 0 {
+			if settings, ok := cfg.Timeouts.Providers[strings.ToLower(strings.TrimSpace(auth.Provider))]; ok {
+				t.merge(settings)
+			}
+		}
+	}
+	if ctx != nil {
+		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
+			if value := ginCtx.Request.Header.Get(TimeoutHeader); value != "" {
+				t.mergeHeader(value)
+			}
+		}
+	}
+	return t
+}
+
+func (t *upstreamTimeouts) merge(settings config.TimeoutSettings) {
+	if settings.ConnectSeconds > 0 {
+		t.connect = time.Duration(settings.ConnectSeconds) * time.Second
+	}
+	if settings.FirstByteSeconds > 0 {
+		t.firstByte = time.Duration(settings.FirstByteSeconds) * time.Second
+	}
+	if settings.TotalSeconds > 0 {
+		t.total = time.Duration(settings.TotalSeconds) * time.Second
+	}
+}
+
+// mergeHeader applies a TimeoutHeader value. Malformed entries are ignored.
+func (t *upstreamTimeouts) mergeHeader(value string) {
+	for part := range strings.SplitSeq(value, ",") {
+		phase, raw, found := strings.Cut(part, "=")
+		if !found {
+			phase, raw = TimeoutPhaseTotal, part
+		}
+		d, ok := parseTimeoutValue(raw)
+		if !ok {
+			log.Debugf("ignoring invalid %s entry %q", TimeoutHeader, part)
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(phase)) {
+		case TimeoutPhaseConnect:
+			t.connect = d
+		case TimeoutPhaseFirstByte, "ttfb":
+			t.firstByte = d
+		case TimeoutPhaseTotal:
+			t.total = d
+		}
+	}
+}
+
+func parseTimeoutValue(raw string) (time.Duration, bool) {
+	raw = strings.TrimSpace(raw)
+	if seconds, err := strconv.ParseFloat(raw, 64); err == nil {
+		d := time.Duration(seconds * float64(time.Second))
+		return d, d > 0
+	}
+	d, err := time.ParseDuration(raw)
+	return d, err == nil && d > 0
+}
+
+// applyUpstreamTimeouts installs the timeouts on client. The connect timeout is applied to the
+// dialer of *http.Transport based clients; first-byte and total timeouts wrap any transport.
+func applyUpstreamTimeouts(client *http.Client, t upstreamTimeouts) {
+	if t.isZero() {
+		return
+	}
+	base := client.Transport
+	if t.connect > 0 {
+		switch transport := base.(type) {
+		case nil:
+			base = withConnectTimeout(cloneDefaultTransport(), t.connect)
+		case *http.Transport:
+			base = withConnectTimeout(transport.Clone(), t.connect)
+		}
+	}
+	if t.firstByte <= 0 && t.total <= 0 {
+		client.Transport = base
+		return
+	}
+	client.Transport = &timeoutRoundTripper{base: base, firstByte: t.firstByte, total: t.total}
+}
+
+func withConnectTimeout(transport *http.Transport, connect time.Duration) *http.Transport {
+	dial := transport.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{KeepAlive: 30 * time.Second}).DialContext
+	}
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return dialWithTimeout(ctx, connect, func(dialCtx context.Context) (net.Conn, error) {
+			return dial(dialCtx, network, addr)
+		})
+	}
+	transport.TLSHandshakeTimeout = connect
+	return transport
+}
+
+// dialWithTimeout runs dial with a connect timeout. Dialers that ignore their context, such as
+// SOCKS5 proxies, are abandoned when the timeout elapses and their late connection is closed.
+func dialWithTimeout(ctx context.Context, connect time.Duration, dial func(context.Context) (net.Conn, error)) (net.Conn, error) {
+	dialCtx, cancel := context.WithTimeout(ctx, connect)
+	defer cancel()
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		conn, err := dial(dialCtx)
+		done <- result{conn: conn, err: err}
+	}()
+	select {
+	case r := <-done:
+		if r.err != nil && ctx.Err() == nil && errors.Is(dialCtx.Err(), context.DeadlineExceeded) {
+			return nil, &UpstreamTimeoutError{Phase: TimeoutPhaseConnect, Limit: connect}
+		}
+		return r.conn, r.err
+	case <-dialCtx.Done():
+		go func() {
+			if r := <-done; r.conn != nil {
+				_ = r.conn.Close()
+			}
+		}()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &UpstreamTimeoutError{Phase: TimeoutPhaseConnect, Limit: connect}
+	}
+}
+
+// timeoutRoundTripper enforces the first-byte timeout until response headers arrive and the
+// total timeout until the response body is closed.
+type timeoutRoundTripper struct {
+	base      http.RoundTripper
+	firstByte time.Duration
+	total     time.Duration
+}
+
+func (t *timeoutRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	ctx, cancel := context.WithCancelCause(req.Context())
+	var totalTimer, firstByteTimer *time.Timer
+	if t.total > 0 {
+		totalTimer = time.AfterFunc(t.total, func() {
+			cancel(&UpstreamTimeoutError{Phase: TimeoutPhaseTotal, Limit: t.total})
+		})
+	}
+	if t.firstByte > 0 {
+		firstByteTimer = time.AfterFunc(t.firstByte, func() {
+			cancel(&UpstreamTimeoutError{Phase: TimeoutPhaseFirstByte, Limit: t.firstByte})
+		})
+	}
+	stop := func() {
+		if totalTimer != nil {
+			totalTimer.Stop()
+		}
+		cancel(nil)
+	}
+
+	resp, err := base.RoundTrip(req.WithContext(ctx))
+	if firstByteTimer != nil && !firstByteTimer.Stop() && err == nil {
+		// The timer fired as the headers arrived; the body is already canceled.
+		_ = resp.Body.Close()
+		resp, err = nil, context.Cause(ctx)
+	}
+	if err != nil {
+		if timeoutErr, ok := errors.AsType[*UpstreamTimeoutError](context.Cause(ctx)); ok {
+			err = timeoutErr
+		}
+		stop()
+		return nil, err
+	}
+	resp.Body = &timeoutBody{ReadCloser: resp.Body, ctx: ctx, stop: stop}
+	return resp, nil
+}
+
+// timeoutBody reports a total timeout that interrupts the body as an UpstreamTimeoutError and
+// releases the request context when closed.
+type timeoutBody struct {
+	io.ReadCloser
+	ctx  context.Context
+	stop func()
+}
+
+func (b *timeoutBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF {
+		if timeoutErr, ok := errors.AsType[*UpstreamTimeoutError](context.Cause(b.ctx)); ok {
+			err = timeoutErr
+		}
+	}
+	return n, err
+}
+
+func (b *timeoutBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.stop()
+	return err
+}
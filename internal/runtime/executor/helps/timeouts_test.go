@@ -0,0 +1,120 @@
+package helps
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestResolveUpstreamTimeoutsMergesProviderAndHeader(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{Timeouts: config.TimeoutConfig{
+		TimeoutSettings: config.TimeoutSettings{ConnectSeconds: 10, FirstByteSeconds: 60, TotalSeconds: 600},
+		Providers: map[string]config.TimeoutSettings{
+			"claude": {FirstByteSeconds: 120},
+		},
+	}}
+	auth := &cliproxyauth.Auth{Provider: "claude"}
+
+	got := resolveUpstreamTimeouts(context.Background(), cfg, auth)
+	want := upstreamTimeouts{connect: 10 * time.Second, firstByte: 120 * time.Second, total: 600 * time.Second}
+	if got != want {
+		t.Fatalf("timeouts = %+v, want %+v", got, want)
+	}
+
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginCtx.Request = httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+	ginCtx.Request.Header.Set(TimeoutHeader, "connect=2.5, ttfb=30s, bogus, total=abc")
+	ctx := context.WithValue(context.Background(), "gin", ginCtx)
+
+	got = resolveUpstreamTimeouts(ctx, cfg, auth)
+	want = upstreamTimeouts{connect: 2500 * time.Millisecond, firstByte: 30 * time.Second, total: 600 * time.Second}
+	if got != want {
+		t.Fatalf("timeouts with header = %+v, want %+v", got, want)
+	}
+}
+
+func TestTimeoutRoundTripperFirstByteTimeout(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: server.Client().Transport}
+	applyUpstreamTimeouts(client, upstreamTimeouts{firstByte: 50 * time.Millisecond})
+
+	_, err := client.Get(server.URL)
+	timeoutErr, ok := errors.AsType[*UpstreamTimeoutError](err)
+	if !ok {
+		t.Fatalf("error = %v, want UpstreamTimeoutError", err)
+	}
+	if timeoutErr.Phase != TimeoutPhaseFirstByte || timeoutErr.StatusCode() != http.StatusGatewayTimeout {
+		t.Fatalf("phase = %q status = %d", timeoutErr.Phase, timeoutErr.StatusCode())
+	}
+	if !strings.Contains(timeoutErr.Error(), `"type":"proxy_timeout"`) {
+		t.Fatalf("error body = %s", timeoutErr.Error())
+	}
+}
+
+func TestTimeoutRoundTripperTotalTimeoutInterruptsBody(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("data: first\n\n"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: server.Client().Transport}
+	applyUpstreamTimeouts(client, upstreamTimeouts{firstByte: time.Second, total: 100 * time.Millisecond})
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, err = io.ReadAll(resp.Body)
+	if timeoutErr, ok := errors.AsType[*UpstreamTimeoutError](err); !ok || timeoutErr.Phase != TimeoutPhaseTotal {
+		t.Fatalf("read error = %v, want total UpstreamTimeoutError", err)
+	}
+}
+
+func TestTimeoutRoundTripperKeepsUpstreamGatewayTimeout(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGatewayTimeout)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: server.Client().Transport}
+	applyUpstreamTimeouts(client, upstreamTimeouts{firstByte: time.Second, total: time.Second})
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusGatewayTimeout {
+		t.Fatalf("status = %d, want 504 from upstream", resp.StatusCode)
+	}
+}
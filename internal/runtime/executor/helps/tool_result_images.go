@@ -0,0 +1,103 @@
+package helps
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// ToolResultImagesInline keeps image parts inside OpenAI tool messages.
+	ToolResultImagesInline = "inline"
+	// ToolResultImagesUserMessage moves tool result images into a user message after the tool results.
+	ToolResultImagesUserMessage = "user-message"
+	// ToolResultImagesPlaceholder replaces tool result images with a text placeholder.
+	ToolResultImagesPlaceholder = "placeholder"
+
+	defaultToolResultImagePlaceholder = "[image omitted]"
+	movedToolResultImagePlaceholder   = "[image attached in the following user message]"
+)
+
+// RewriteToolResultImages adjusts image parts inside Chat Completions tool messages for
+// upstreams that only accept text tool results. Mode "user-message" keeps the visual
+// context by re-attaching the images in a user message right after the tool results;
+// mode "placeholder" swaps each image for placeholder text. Other modes return the
+// payload unchanged.
+func RewriteToolResultImages(payload []byte, mode, placeholder string) []byte {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	if mode != ToolResultImagesUserMessage && mode != ToolResultImagesPlaceholder {
+		return payload
+	}
+	messages := gjson.GetBytes(payload, "messages")
+	if !messages.IsArray() {
+		return payload
+	}
+	if strings.TrimSpace(placeholder) == "" {
+		placeholder = defaultToolResultImagePlaceholder
+		if mode == ToolResultImagesUserMessage {
+			placeholder = movedToolResultImagePlaceholder
+		}
+	}
+
+	var out []string
+	var pending []string
+	changed := false
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		userMessage, _ := sjson.SetRaw(`{"role":"user"}`, "content", "["+strings.Join(pending, ",")+"]")
+		out = append(out, userMessage)
+		pending = nil
+	}
+
+	for _, msg := range messages.Array() {
+		role := msg.Get("role").String()
+		if role != "tool" {
+			flush()
+			out = append(out, msg.Raw)
+			continue
+		}
+		content := msg.Get("content")
+		if !content.IsArray() {
+			out = append(out, msg.Raw)
+			continue
+		}
+
+		var texts []string
+		var images []string
+		for _, part := range content.Array() {
+			switch part.Get("type").String() {
+			case "image_url", "input_image":
+				images = append(images, part.Raw)
+				texts = append(texts, placeholder)
+			case "text":
+				texts = append(texts, part.Get("text").String())
+			}
+		}
+		if len(images) == 0 {
+			out = append(out, msg.Raw)
+			continue
+		}
+
+		changed = true
+		rewritten, _ := sjson.Set(msg.Raw, "content", strings.Join(texts, "\n\n"))
+		out = append(out, rewritten)
+		if mode == ToolResultImagesUserMessage {
+			header, _ := sjson.Set(`{"type":"text","text":""}`, "text", "Images returned by tool call "+msg.Get("tool_call_id").String()+":")
+			pending = append(pending, header)
+			pending = append(pending, images...)
+		}
+	}
+	flush()
+
+	if !changed {
+		return payload
+	}
+	updated, errSet := sjson.SetRawBytes(payload, "messages", []byte("["+strings.Join(out, ",")+"]"))
+	if errSet != nil {
+		return payload
+	}
+	return updated
+}
@@ -0,0 +1,45 @@
+package helps
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+const toolResultImagePayload = `{"messages":[
+	{"role":"assistant","content":"","tool_calls":[{"id":"call_1","type":"function","function":{"name":"screenshot","arguments":"{}"}}]},
+	{"role":"tool","tool_call_id":"call_1","content":[{"type":"text","text":"captured"},{"type":"image_url","image_url":{"url":"data:image/png;base64,AAAA"}}]},
+	{"role":"user","content":"what do you see?"}
+]}`
+
+func TestRewriteToolResultImagesUserMessage(t *testing.T) {
+	out := RewriteToolResultImages([]byte(toolResultImagePayload), ToolResultImagesUserMessage, "")
+	messages := gjson.GetBytes(out, "messages").Array()
+	if len(messages) != 4 {
+		t.Fatalf("messages = %d, want 4: %s", len(messages), out)
+	}
+	if got := messages[1].Get("content").String(); got != "captured\n\n"+movedToolResultImagePlaceholder {
+		t.Fatalf("tool content = %q", got)
+	}
+	if messages[2].Get("role").String() != "user" || messages[2].Get("content.1.image_url.url").String() != "data:image/png;base64,AAAA" {
+		t.Fatalf("expected images in a user message: %s", messages[2].Raw)
+	}
+	if messages[3].Get("content").String() != "what do you see?" {
+		t.Fatalf("unexpected trailing message: %s", messages[3].Raw)
+	}
+}
+
+func TestRewriteToolResultImagesPlaceholder(t *testing.T) {
+	out := RewriteToolResultImages([]byte(toolResultImagePayload), ToolResultImagesPlaceholder, "[screenshot]")
+	messages := gjson.GetBytes(out, "messages").Array()
+	if len(messages) != 3 {
+		t.Fatalf("messages = %d, want 3: %s", len(messages), out)
+	}
+	if got := messages[1].Get("content").String(); got != "captured\n\n[screenshot]" {
+		t.Fatalf("tool content = %q", got)
+	}
+
+	if inline := RewriteToolResultImages([]byte(toolResultImagePayload), ToolResultImagesInline, ""); string(inline) != toolResultImagePayload {
+		t.Fatalf("inline mode changed payload: %s", inline)
+	}
+}
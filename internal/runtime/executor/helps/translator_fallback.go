@@ -0,0 +1,40 @@
+package helps
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+)
+
+// TranslationFallbackHeader reports a request forwarded unchanged because no translator is
+// registered for its source and target formats.
+const TranslationFallbackHeader = "X-CPA-Translation-Fallback"
+
+// TranslationTargetContextKey is the gin context key holding the format the most recent upstream
+// attempt translated the request into.
+const TranslationTargetContextKey = "API_TRANSLATION_TARGET"
+
+// CheckTranslatorPair applies the configured missing translator mode to a request. It rejects
+// pairs that cannot be served, and warns in the log and through the TranslationFallbackHeader
+// response header when the payload is forwarded unchanged. The target format is recorded on the
+// gin context under TranslationTargetContextKey.
+func CheckTranslatorPair(ctx context.Context, from, to sdktranslator.Format) error {
+	if ctx != nil {
+		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+			ginCtx.Set(TranslationTargetContextKey, to.String())
+		}
+	}
+	passthrough, err := sdktranslator.CheckRequestPair(from, to)
+	if err != nil || !passthrough {
+		return err
+	}
+	log.Warnf("no translator registered from %s to %s, forwarding the request unchanged", from, to)
+	if ctx != nil {
+		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+			ginCtx.Header(TranslationFallbackHeader, from.String()+"->"+to.String())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package helps
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+const (
+	defaultPoolMaxIdleConns        = 256
+	defaultPoolMaxIdleConnsPerHost = 32
+	defaultPoolIdleConnTimeout     = 90 * time.Second
+
+	// maxPooledTransports bounds the pool; the least recently used transport is evicted beyond it.
+	maxPooledTransports = 512
+)
+
+// poolSettings is the resolved cfg.HTTPPool. It is part of the pool key, so a config reload
+// that changes it builds fresh transports.
+type poolSettings struct {
+	maxIdleConns        int
+	maxIdleConnsPerHost int
+	idleConnTimeout     time.Duration
+}
+
+func newPoolSettings(cfg *config.Config) poolSettings {
+	s := poolSettings{
+		maxIdleConns:        defaultPoolMaxIdleConns,
+		maxIdleConnsPerHost: defaultPoolMaxIdleConnsPerHost,
+		idleConnTimeout:     defaultPoolIdleConnTimeout,
+	}
+	if cfg == nil {
+		return s
+	}
+	if cfg.HTTPPool.MaxIdleConns > 0 {
+		s.maxIdleConns = cfg.HTTPPool.MaxIdleConns
+	}
+	if cfg.HTTPPool.MaxIdleConnsPerHost > 0 {
+		s.maxIdleConnsPerHost = cfg.HTTPPool.MaxIdleConnsPerHost
+	}
+	if cfg.HTTPPool.IdleConnTimeoutSeconds > 0 {
+		s.idleConnTimeout = time.Duration(cfg.HTTPPool.IdleConnTimeoutSeconds) * time.Second
+	}
+	return s
+}
+
+func (s poolSettings) apply(transport *http.Transport) {
+	transport.MaxIdleConns = s.maxIdleConns
+	transport.MaxIdleConnsPerHost = s.maxIdleConnsPerHost
+	transport.IdleConnTimeout = s.idleConnTimeout
+}
+
+// transportKey identifies a pooled transport. Credentials never share connections, and a
+// different proxy, connect timeout or customization gets its own transport.
+type transportKey struct {
+	variant  string
+	provider string
+	authID   string
+	proxyURL string
+	connect  time.Duration
+	settings poolSettings
+}
+
+func newTransportKey(cfg *config.Config, auth *cliproxyauth.Auth, proxyURL, variant string, connect time.Duration) transportKey {
+	key := transportKey{variant: variant, proxyURL: proxyURL, connect: connect, settings: newPoolSettings(cfg)}
+	if auth != nil {
+		key.provider = strings.ToLower(strings.TrimSpace(auth.Provider))
+		key.authID = auth.ID
+	}
+	return key
+}
+
+type pooledTransport struct {
+	rt       http.RoundTripper
+	lastUsed time.Time
+}
+
+// transportPool caches upstream transports so executors reuse connections across requests
+// instead of paying a TCP and TLS handshake for each one.
+type transportPool struct {
+	mu      sync.Mutex
+	entries map[transportKey]*pooledTransport
+}
+
+var upstreamTransports = &transportPool{entries: make(map[transportKey]*pooledTransport)}
+
+// get returns the transport stored under key, building it with build on first use. A nil
+// transport from build is returned without being cached.
+func (p *transportPool) get(key transportKey, build func() http.RoundTripper) http.RoundTripper {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	if entry, ok := p.entries[key]; ok {
+		entry.lastUsed = now
+		return entry.rt
+	}
+	rt := build()
+	if rt == nil {
+		return nil
+	}
+	if len(p.entries) >= maxPooledTransports {
+		p.evictOldestLocked()
+	}
+	p.entries[key] = &pooledTransport{rt: rt, lastUsed: now}
+	return rt
+}
+
+func (p *transportPool) evictOldestLocked() {
+	var oldestKey transportKey
+	var oldest *pooledTransport
+	for key, entry := range p.entries {
+		if oldest == nil || entry.lastUsed.Before(oldest.lastUsed) {
+			oldestKey, oldest = key, entry
+		}
+	}
+	if oldest == nil {
+		return
+	}
+	delete(p.entries, oldestKey)
+	// In-flight requests keep their connections; only idle ones are released.
+	if closer, ok := oldest.rt.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// pooledHTTPTransport returns the shared transport for proxyURL, which may be empty to inherit
+// the environment proxy. customize, when set, adjusts a new transport once before it is shared.
+func pooledHTTPTransport(cfg *config.Config, auth *cliproxyauth.Auth, proxyURL, variant string, connect time.Duration, customize func(*http.Transport)) *http.Transport {
+	key := newTransportKey(cfg, auth, proxyURL, variant, connect)
+	rt := upstreamTransports.get(key, func() http.RoundTripper {
+		var transport *http.Transport
+		if proxyURL == "" {
+			transport = cloneDefaultTransport()
+		} else if transport = buildProxyTransport(proxyURL); transport == nil {
+			return nil
+		}
+		key.settings.apply(transport)
+		if connect > 0 {
+			transport = withConnectTimeout(transport, connect)
+		}
+		if customize != nil {
+			customize(transport)
+		}
+		return transport
+	})
+	transport, _ := rt.(*http.Transport)
+	return transport
+}
+
+func cloneDefaultTransport() *http.Transport {
+	if transport, ok := http.DefaultTransport.(*http.Transport); ok {
+		return transport.Clone()
+	}
+	return &http.Transport{Proxy: http.ProxyFromEnvironment}
+}
+
+// pooledUtlsTransport returns the shared utls transport of NewUtlsHTTPClient for proxyURL.
+func pooledUtlsTransport(cfg *config.Config, auth *cliproxyauth.Auth, proxyURL string, connect time.Duration) http.RoundTripper {
+	key := newTransportKey(cfg, auth, proxyURL, "utls", connect)
+	return upstreamTransports.get(key, func() http.RoundTripper {
+		utlsRT := newUtlsRoundTripper(proxyURL)
+		utlsRT.connectTimeout = connect
+		utlsRT.idleConnTimeout = key.settings.idleConnTimeout
+
+		var standardTransport *http.Transport
+		if proxyURL != "" {
+			standardTransport = buildProxyTransport(proxyURL)
+		}
+		if standardTransport == nil {
+			standardTransport = cloneDefaultTransport()
+			standardTransport.Proxy = nil
+		}
+		key.settings.apply(standardTransport)
+		if connect > 0 {
+			standardTransport = withConnectTimeout(standardTransport, connect)
+		}
+		return &fallbackRoundTripper{utls: utlsRT, fallback: standardTransport}
+	})
+}
@@ -0,0 +1,139 @@
+package helps
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestNewProxyAwareHTTPClientSharesTransportPerAuth(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{HTTPPool: config.HTTPPoolConfig{MaxIdleConnsPerHost: 7}}
+	authA := &cliproxyauth.Auth{ID: "pool-test-a", Provider: "codex"}
+	authB := &cliproxyauth.Auth{ID: "pool-test-b", Provider: "codex"}
+
+	first := NewProxyAwareHTTPClient(context.Background(), cfg, authA, 0)
+	second := NewProxyAwareHTTPClient(context.Background(), cfg, authA, 0)
+	other := NewProxyAwareHTTPClient(context.Background(), cfg, authB, 0)
+
+	transport, ok := first.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("transport type = %T, want *http.Transport", first.Transport)
+	}
+	if second.Transport != first.Transport {
+		t.Fatal("expected clients of the same auth to share a transport")
+	}
+	if other.Transport == first.Transport {
+		t.Fatal("expected clients of different auths to use separate transports")
+	}
+	if transport.MaxIdleConnsPerHost != 7 || transport.MaxIdleConns != defaultPoolMaxIdleConns {
+		t.Fatalf("pool limits = %d/%d, want 7/%d", transport.MaxIdleConnsPerHost, transport.MaxIdleConns, defaultPoolMaxIdleConns)
+	}
+}
+
+func TestNewProxyAwareHTTPClientReusesConnections(t *testing.T) {
+	t.Parallel()
+
+	var conns atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	auth := &cliproxyauth.Auth{ID: "pool-test-reuse", Provider: "openai-compatibility"}
+	for i := 0; i < 5; i++ {
+		resp, err := NewProxyAwareHTTPClient(context.Background(), nil, auth, 0).Get(server.URL)
+		if err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}
+	if got := conns.Load(); got != 1 {
+		t.Fatalf("connections = %d, want 1", got)
+	}
+}
+
+func TestTransportPoolEvictsLeastRecentlyUsed(t *testing.T) {
+	t.Parallel()
+
+	pool := &transportPool{entries: make(map[transportKey]*pooledTransport)}
+	for i := 0; i < maxPooledTransports; i++ {
+		pool.get(transportKey{authID: fmt.Sprint(i)}, func() http.RoundTripper { return &http.Transport{} })
+	}
+	// Touch the oldest entry so the second one becomes the eviction candidate.
+	pool.get(transportKey{authID: "0"}, nil)
+	pool.get(transportKey{authID: "new"}, func() http.RoundTripper { return &http.Transport{} })
+
+	if len(pool.entries) != maxPooledTransports {
+		t.Fatalf("entries = %d, want %d", len(pool.entries), maxPooledTransports)
+	}
+	if _, ok := pool.entries[transportKey{authID: "0"}]; !ok {
+		t.Fatal("expected recently used entry to survive eviction")
+	}
+}
+
+// BenchmarkUpstreamClient compares a pooled client with building a transport per request, as
+// executors did before pooling, against a TLS upstream. It reports the p99 request latency.
+func BenchmarkUpstreamClient(b *testing.B) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+	serverTLS := server.Client().Transport.(*http.Transport).TLSClientConfig
+	trustServer := func(transport *http.Transport) {
+		transport.TLSClientConfig = serverTLS.Clone()
+	}
+	auth := &cliproxyauth.Auth{ID: "pool-bench", Provider: "codex"}
+
+	run := func(b *testing.B, newClient func() (*http.Client, func())) {
+		latencies := make([]time.Duration, 0, b.N)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			start := time.Now()
+			client, release := newClient()
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				b.Fatal(err)
+			}
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+			latencies = append(latencies, time.Since(start))
+			release()
+		}
+		b.StopTimer()
+		slices.Sort(latencies)
+		p99 := latencies[(len(latencies)-1)*99/100]
+		b.ReportMetric(float64(p99.Microseconds()), "p99-µs")
+	}
+
+	b.Run("pooled", func(b *testing.B) {
+		run(b, func() (*http.Client, func()) {
+			return NewPooledHTTPClient(context.Background(), nil, auth, 0, "bench", trustServer), func() {}
+		})
+	})
+	b.Run("per-request", func(b *testing.B) {
+		run(b, func() (*http.Client, func()) {
+			transport := cloneDefaultTransport()
+			trustServer(transport)
+			return &http.Client{Transport: transport}, transport.CloseIdleConnections
+		})
+	})
+}
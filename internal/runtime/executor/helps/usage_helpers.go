@@ -24,6 +24,7 @@ type UsageReporter struct {
 	apiKey      string
 	source      string
 	requestedAt time.Time
+	streamRate  float64
 	once        sync.Once
 }
 
@@ -36,6 +37,7 @@ func NewUsageReporter(ctx context.Context, provider, model string, auth *cliprox
 		apiKey:      apiKey,
 		source:      resolveUsageSource(auth, apiKey),
 		authType:    resolveUsageAuthType(auth),
+		streamRate:  streamRateLimitFromContext(ctx),
 	}
 	if auth != nil {
 		reporter.authID = auth.ID
@@ -45,7 +47,7 @@ func NewUsageReporter(ctx context.Context, provider, model string, auth *cliprox
 }
 
 func (r *UsageReporter) Publish(ctx context.Context, detail usage.Detail) {
-	r.publishWithOutcome(ctx, detail, false)
+	r.publishWithOutcome(ctx, detail, "")
 }
 
 func (r *UsageReporter) PublishAdditionalModel(ctx context.Context, model string, detail usage.Detail) {
@@ -68,11 +70,12 @@ func (r *UsageReporter) buildAdditionalModelRecord(model string, detail usage.De
 	if !hasNonZeroTokenUsage(detail) {
 		return usage.Record{}, false
 	}
-	return r.buildRecordForModel(model, detail, false), true
+	return r.buildRecordForModel(model, detail, ""), true
 }
 
-func (r *UsageReporter) PublishFailure(ctx context.Context) {
-	r.publishWithOutcome(ctx, usage.Detail{}, true)
+// PublishFailure records the request as failed, classified by err.
+func (r *UsageReporter) PublishFailure(ctx context.Context, err error) {
+	r.publishWithOutcome(ctx, usage.Detail{}, ClassifyFailure(ctx, err))
 }
 
 func (r *UsageReporter) TrackFailure(ctx context.Context, errPtr *error) {
@@ -80,17 +83,18 @@ func (r *UsageReporter) TrackFailure(ctx context.Context, errPtr *error) {
 		return
 	}
 	if *errPtr != nil {
-		r.PublishFailure(ctx)
+		r.PublishFailure(ctx, *errPtr)
 	}
 }
 
-func (r *UsageReporter) publishWithOutcome(ctx context.Context, detail usage.Detail, failed bool) {
+// publishWithOutcome publishes the record once; a non-empty failureClass marks it failed.
+func (r *UsageReporter) publishWithOutcome(ctx context.Context, detail usage.Detail, failureClass string) {
 	if r == nil {
 		return
 	}
 	detail = normalizeUsageDetailTotal(detail)
 	r.once.Do(func() {
-		usage.PublishRecord(ctx, r.buildRecord(detail, failed))
+		usage.PublishRecord(ctx, r.buildRecord(detail, failureClass))
 	})
 }
 
@@ -121,33 +125,35 @@ func (r *UsageReporter) EnsurePublished(ctx context.Context) {
 		return
 	}
 	r.once.Do(func() {
-		usage.PublishRecord(ctx, r.buildRecord(usage.Detail{}, false))
+		usage.PublishRecord(ctx, r.buildRecord(usage.Detail{}, ""))
 	})
 }
 
-func (r *UsageReporter) buildRecord(detail usage.Detail, failed bool) usage.Record {
+func (r *UsageReporter) buildRecord(detail usage.Detail, failureClass string) usage.Record {
 	if r == nil {
-		return usage.Record{Detail: detail, Failed: failed}
+		return usage.Record{Detail: detail, Failed: failureClass != "", FailureClass: failureClass}
 	}
-	return r.buildRecordForModel(r.model, detail, failed)
+	return r.buildRecordForModel(r.model, detail, failureClass)
 }
 
-func (r *UsageReporter) buildRecordForModel(model string, detail usage.Detail, failed bool) usage.Record {
+func (r *UsageReporter) buildRecordForModel(model string, detail usage.Detail, failureClass string) usage.Record {
 	if r == nil {
-		return usage.Record{Model: model, Detail: detail, Failed: failed}
+		return usage.Record{Model: model, Detail: detail, Failed: failureClass != "", FailureClass: failureClass}
 	}
 	return usage.Record{
-		Provider:    r.provider,
-		Model:       model,
-		Source:      r.source,
-		APIKey:      r.apiKey,
-		AuthID:      r.authID,
-		AuthIndex:   r.authIndex,
-		AuthType:    r.authType,
-		RequestedAt: r.requestedAt,
-		Latency:     r.latency(),
-		Failed:      failed,
-		Detail:      detail,
+		Provider:              r.provider,
+		Model:                 model,
+		Source:                r.source,
+		APIKey:                r.apiKey,
+		AuthID:                r.authID,
+		AuthIndex:             r.authIndex,
+		AuthType:              r.authType,
+		RequestedAt:           r.requestedAt,
+		Latency:               r.latency(),
+		Failed:                failureClass != "",
+		FailureClass:          failureClass,
+		Detail:                detail,
+		StreamTokensPerSecond: r.streamRate,
 	}
 }
 
@@ -183,6 +189,24 @@ func APIKeyFromContext(ctx context.Context) string {
 	return ""
 }
 
+// streamRateLimitFromContext returns the downstream stream rate limit (tokens per second)
+// the handler applied to this request, or 0 when the response is not throttled.
+func streamRateLimitFromContext(ctx context.Context) float64 {
+	if ctx == nil {
+		return 0
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return 0
+	}
+	if v, exists := ginCtx.Get("streamTokensPerSecond"); exists {
+		if rate, isFloat := v.(float64); isFloat {
+			return rate
+		}
+	}
+	return 0
+}
+
 func resolveUsageSource(auth *cliproxyauth.Auth, ctxAPIKey string) string {
 	if auth != nil {
 		provider := strings.TrimSpace(auth.Provider)
@@ -1,6 +1,8 @@
 package helps
 
 import (
+	"context"
+	"errors"
 	"net"
 	"net/http"
 	"strings"
@@ -23,6 +25,10 @@ type utlsRoundTripper struct {
 	connections map[string]*http2.ClientConn
 	pending     map[string]*sync.Cond
 	dialer      proxy.Dialer
+	// connectTimeout bounds dialing and the TLS handshake of new connections. Zero disables it.
+	connectTimeout time.Duration
+	// idleConnTimeout closes HTTP/2 connections idle for longer. Zero keeps them open.
+	idleConnTimeout time.Duration
 }
 
 func newUtlsRoundTripper(proxyURL string) *utlsRoundTripper {
@@ -79,7 +85,15 @@ func (t *utlsRoundTripper) getOrCreateConnection(host, addr string) (*http2.Clie
 }
 
 func (t *utlsRoundTripper) createConnection(host, addr string) (*http2.ClientConn, error) {
-	conn, err := t.dialer.Dial("tcp", addr)
+	var conn net.Conn
+	var err error
+	if t.connectTimeout > 0 {
+		conn, err = dialWithTimeout(context.Background(), t.connectTimeout, func(context.Context) (net.Conn, error) {
+			return t.dialer.Dial("tcp", addr)
+		})
+	} else {
+		conn, err = t.dialer.Dial("tcp", addr)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -87,12 +101,21 @@ func (t *utlsRoundTripper) createConnection(host, addr string) (*http2.ClientCon
 	tlsConfig := &tls.Config{ServerName: host}
 	tlsConn := tls.UClient(conn, tlsConfig, tls.HelloChrome_Auto)
 
+	if t.connectTimeout > 0 {
+		_ = conn.SetDeadline(time.Now().Add(t.connectTimeout))
+	}
 	if err := tlsConn.Handshake(); err != nil {
 		conn.Close()
+		if netErr, ok := errors.AsType[net.Error](err); ok && netErr.Timeout() && t.connectTimeout > 0 {
+			return nil, &UpstreamTimeoutError{Phase: TimeoutPhaseConnect, Limit: t.connectTimeout}
+		}
 		return nil, err
 	}
+	if t.connectTimeout > 0 {
+		_ = conn.SetDeadline(time.Time{})
+	}
 
-	tr := &http2.Transport{}
+	tr := &http2.Transport{IdleConnTimeout: t.idleConnTimeout}
 	h2Conn, err := tr.NewClientConn(tlsConn)
 	if err != nil {
 		tlsConn.Close()
@@ -128,6 +151,19 @@ func (t *utlsRoundTripper) RoundTrip(req *http.Request) (*http.Response, error)
 	return resp, nil
 }
 
+// CloseIdleConnections closes the cached connections without active streams and forgets the
+// rest, which finish their requests and then close after the idle timeout.
+func (t *utlsRoundTripper) CloseIdleConnections() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for host, h2Conn := range t.connections {
+		if h2Conn.State().StreamsActive == 0 {
+			_ = h2Conn.Close()
+		}
+		delete(t.connections, host)
+	}
+}
+
 // anthropicHosts contains the hosts that should use utls Chrome TLS fingerprint.
 var anthropicHosts = map[string]struct{}{
 	"api.anthropic.com": {},
@@ -149,10 +185,19 @@ func (f *fallbackRoundTripper) RoundTrip(req *http.Request) (*http.Response, err
 	return f.fallback.RoundTrip(req)
 }
 
+// CloseIdleConnections releases the idle connections of both transports.
+func (f *fallbackRoundTripper) CloseIdleConnections() {
+	f.utls.CloseIdleConnections()
+	if closer, ok := f.fallback.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
 // NewUtlsHTTPClient creates an HTTP client using utls Chrome TLS fingerprint.
 // Use this for Claude API requests to match real Claude Code's TLS behavior.
-// Falls back to standard transport for non-HTTPS requests.
-func NewUtlsHTTPClient(cfg *config.Config, auth *cliproxyauth.Auth, timeout time.Duration) *http.Client {
+// Falls back to standard transport for non-HTTPS requests. The transport is pooled and upstream
+// timeouts, endpoint failover and interceptors are applied as in NewProxyAwareHTTPClient.
+func NewUtlsHTTPClient(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, timeout time.Duration) *http.Client {
 	var proxyURL string
 	if auth != nil {
 		proxyURL = strings.TrimSpace(auth.ProxyURL)
@@ -161,28 +206,13 @@ func NewUtlsHTTPClient(cfg *config.Config, auth *cliproxyauth.Auth, timeout time
 		proxyURL = strings.TrimSpace(cfg.ProxyURL)
 	}
 
-	utlsRT := newUtlsRoundTripper(proxyURL)
-
-	var standardTransport http.RoundTripper = &http.Transport{
-		DialContext: (&net.Dialer{
-			Timeout:   30 * time.Second,
-			KeepAlive: 30 * time.Second,
-		}).DialContext,
-	}
-	if proxyURL != "" {
-		if transport := buildProxyTransport(proxyURL); transport != nil {
-			standardTransport = transport
-		}
-	}
-
-	client := &http.Client{
-		Transport: &fallbackRoundTripper{
-			utls:     utlsRT,
-			fallback: standardTransport,
-		},
-	}
+	timeouts := resolveUpstreamTimeouts(ctx, cfg, auth)
+	client := &http.Client{Transport: pooledUtlsTransport(cfg, auth, proxyURL, timeouts.connect)}
 	if timeout > 0 {
 		client.Timeout = timeout
 	}
+	applyUpstreamTimeouts(client, timeouts.withoutConnect())
+	applyEndpointFailover(client, auth)
+	applyInterceptors(client, cfg, auth)
 	return client
 }
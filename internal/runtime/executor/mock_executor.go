@@ -0,0 +1,350 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/lifecycle"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor/helps"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// MockExecutor implements an offline provider that generates deterministic or scripted
+// OpenAI chat completions. Responses are produced in the OpenAI format and translated
+// back to the caller's schema, so it exercises translators, streaming, and failover
+// exactly like a real upstream without spending tokens.
+type MockExecutor struct {
+	cfg *config.Config
+
+	mu       sync.Mutex
+	sequence map[string]int
+}
+
+// NewMockExecutor creates an executor serving the mock-provider entries of cfg.
+func NewMockExecutor(cfg *config.Config) *MockExecutor {
+	return &MockExecutor{cfg: cfg, sequence: make(map[string]int)}
+}
+
+// Identifier implements cliproxyauth.ProviderExecutor.
+func (e *MockExecutor) Identifier() string { return "mock" }
+
+// HttpRequest is not supported because the mock provider has no upstream.
+func (e *MockExecutor) HttpRequest(ctx context.Context, auth *cliproxyauth.Auth, req *http.Request) (*http.Response, error) {
+	return nil, statusErr{code: http.StatusNotImplemented, msg: "mock executor: raw HTTP requests are not supported"}
+}
+
+// Execute generates a complete mock response.
+func (e *MockExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := helps.NewUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	entry := e.resolveEntry(auth)
+	seq := e.nextSequence(auth)
+	if err = e.injectedError(entry, seq); err != nil {
+		return resp, err
+	}
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	translated := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
+	if err = e.wait(ctx, time.Duration(entry.LatencyMs)*time.Millisecond); err != nil {
+		return resp, err
+	}
+
+	text, toolCalls := e.reply(entry, seq, translated)
+	body := e.buildCompletion(baseModel, text, toolCalls, translated)
+	reporter.Publish(ctx, helps.ParseOpenAIUsage(body))
+
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, opts.OriginalRequest, translated, body, &param)
+	return cliproxyexecutor.Response{Payload: out}, nil
+}
+
+// ExecuteStream generates a mock response as OpenAI SSE chunks, honoring the configured
+// latency, token rate, and malformed-chunk injection.
+func (e *MockExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (_ *cliproxyexecutor.StreamResult, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := helps.NewUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	entry := e.resolveEntry(auth)
+	seq := e.nextSequence(auth)
+	if err = e.injectedError(entry, seq); err != nil {
+		return nil, err
+	}
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	translated := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, true)
+	text, toolCalls := e.reply(entry, seq, translated)
+	lines := e.buildStreamLines(baseModel, text, toolCalls, translated, entry.MalformedEvery > 0 && seq%entry.MalformedEvery == 0)
+
+	var interval time.Duration
+	if entry.TokensPerSecond > 0 {
+		interval = time.Second / time.Duration(entry.TokensPerSecond)
+	}
+
+	out := make(chan cliproxyexecutor.StreamChunk)
+	lifecycle.Go(ctx, "mock.stream", func() {
+		defer close(out)
+		if errWait := e.wait(ctx, time.Duration(entry.LatencyMs)*time.Millisecond); errWait != nil {
+			reporter.PublishFailure(ctx, errWait)
+			out <- cliproxyexecutor.StreamChunk{Err: errWait}
+			return
+		}
+		var param any
+		usageEmulator := helps.NewStreamUsageEmulator(from, baseModel, req.Payload)
+		for i, line := range lines {
+			if i > 0 {
+				if errWait := e.wait(ctx, interval); errWait != nil {
+					reporter.PublishFailure(ctx, errWait)
+					out <- cliproxyexecutor.StreamChunk{Err: errWait}
+					return
+				}
+			}
+			if detail, ok := helps.ParseOpenAIStreamUsage(line); ok {
+				reporter.Publish(ctx, detail)
+			}
+			chunks := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, translated, line, &param)
+			for j := range chunks {
+				usageEmulator.Observe(chunks[j])
+				out <- cliproxyexecutor.StreamChunk{Payload: chunks[j]}
+			}
+		}
+		for _, chunk := range sdktranslator.FinalizeStream(ctx, to, from, req.Model, opts.OriginalRequest, translated, &param) {
+			usageEmulator.Observe(chunk)
+			out <- cliproxyexecutor.StreamChunk{Payload: chunk}
+		}
+		if usageChunk := usageEmulator.Finish(); usageChunk != nil {
+			out <- cliproxyexecutor.StreamChunk{Payload: usageChunk}
+		}
+		reporter.EnsurePublished(ctx)
+	})
+	return &cliproxyexecutor.StreamResult{Chunks: out}, nil
+}
+
+// CountTokens counts prompt tokens locally using the OpenAI tokenizer.
+func (e *MockExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	translated := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
+
+	enc, err := helps.TokenizerForModel(baseModel)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("mock executor: tokenizer init failed: %w", err)
+	}
+	count, err := helps.CountOpenAIChatTokens(enc, translated)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("mock executor: token counting failed: %w", err)
+	}
+	usageJSON := helps.BuildOpenAIUsageJSON(count)
+	return cliproxyexecutor.Response{Payload: sdktranslator.TranslateTokenCount(ctx, to, from, count, usageJSON)}, nil
+}
+
+// Refresh is a no-op for mock credentials.
+func (e *MockExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
+	log.Debugf("mock executor: refresh called")
+	_ = ctx
+	return auth, nil
+}
+
+// resolveEntry returns the mock-provider configuration bound to auth.
+func (e *MockExecutor) resolveEntry(auth *cliproxyauth.Auth) config.MockProvider {
+	if auth == nil || auth.Attributes == nil || e.cfg == nil {
+		return config.MockProvider{}
+	}
+	name := strings.TrimSpace(auth.Attributes["mock_name"])
+	for i := range e.cfg.MockProvider {
+		if strings.TrimSpace(e.cfg.MockProvider[i].Name) == name {
+			return e.cfg.MockProvider[i]
+		}
+	}
+	return config.MockProvider{}
+}
+
+// nextSequence returns the 1-based request number for auth, used to drive scripted behavior.
+func (e *MockExecutor) nextSequence(auth *cliproxyauth.Auth) int {
+	key := ""
+	if auth != nil {
+		key = auth.ID
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.sequence[key]++
+	return e.sequence[key]
+}
+
+// injectedError returns the configured failure for every FailEvery-th request.
+func (e *MockExecutor) injectedError(entry config.MockProvider, seq int) error {
+	if entry.FailEvery <= 0 || seq%entry.FailEvery != 0 {
+		return nil
+	}
+	status := entry.ErrorStatus
+	if status <= 0 {
+		status = http.StatusInternalServerError
+	}
+	msg := fmt.Sprintf(`{"error":{"message":"mock provider injected failure on request %d","type":"mock_error","code":"%d"}}`, seq, status)
+	return statusErr{code: status, msg: msg}
+}
+
+// wait sleeps for d unless ctx is cancelled first.
+func (e *MockExecutor) wait(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// reply selects the response text and tool calls for request number seq.
+func (e *MockExecutor) reply(entry config.MockProvider, seq int, translated []byte) (string, []config.MockToolCall) {
+	if len(entry.ToolCalls) > 0 {
+		return "", entry.ToolCalls
+	}
+	if len(entry.Responses) > 0 {
+		return entry.Responses[(seq-1)%len(entry.Responses)], nil
+	}
+	return mockLastUserText(translated), nil
+}
+
+// buildCompletion renders a non-streaming OpenAI chat completion.
+func (e *MockExecutor) buildCompletion(model, text string, toolCalls []config.MockToolCall, translated []byte) []byte {
+	body := []byte(`{"object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant"},"finish_reason":"stop"}]}`)
+	body, _ = sjson.SetBytes(body, "id", "chatcmpl-mock-"+uuid.NewString())
+	body, _ = sjson.SetBytes(body, "created", time.Now().Unix())
+	body, _ = sjson.SetBytes(body, "model", model)
+	if len(toolCalls) > 0 {
+		for i, call := range toolCalls {
+			body, _ = sjson.SetRawBytes(body, fmt.Sprintf("choices.0.message.tool_calls.%d", i), mockToolCallJSON(i, call, false))
+		}
+		body, _ = sjson.SetBytes(body, "choices.0.finish_reason", "tool_calls")
+	} else {
+		body, _ = sjson.SetBytes(body, "choices.0.message.content", text)
+	}
+	body, _ = sjson.SetRawBytes(body, "usage", mockUsageJSON(translated, text, toolCalls))
+	return body
+}
+
+// buildStreamLines renders an OpenAI SSE stream, one content token per line.
+func (e *MockExecutor) buildStreamLines(model, text string, toolCalls []config.MockToolCall, translated []byte, malformed bool) [][]byte {
+	base := []byte(`{"object":"chat.completion.chunk","choices":[{"index":0,"delta":{}}]}`)
+	base, _ = sjson.SetBytes(base, "id", "chatcmpl-mock-"+uuid.NewString())
+	base, _ = sjson.SetBytes(base, "created", time.Now().Unix())
+	base, _ = sjson.SetBytes(base, "model", model)
+
+	lines := make([][]byte, 0, 8)
+	appendChunk := func(chunk []byte) {
+		lines = append(lines, append([]byte("data: "), chunk...))
+	}
+
+	first, _ := sjson.SetBytes(base, "choices.0.delta.role", "assistant")
+	appendChunk(first)
+
+	finishReason := "stop"
+	if len(toolCalls) > 0 {
+		finishReason = "tool_calls"
+		for i, call := range toolCalls {
+			chunk, _ := sjson.SetRawBytes(base, "choices.0.delta.tool_calls", []byte("["+string(mockToolCallJSON(i, call, true))+"]"))
+			appendChunk(chunk)
+		}
+	} else {
+		tokens := strings.SplitAfter(text, " ")
+		for i, token := range tokens {
+			if token == "" {
+				continue
+			}
+			if malformed && i == len(tokens)/2 {
+				lines = append(lines, []byte(`data: {"choices":[{"index":0,"delta":{"content":`))
+			}
+			chunk, _ := sjson.SetBytes(base, "choices.0.delta.content", token)
+			appendChunk(chunk)
+		}
+	}
+
+	last, _ := sjson.SetBytes(base, "choices.0.finish_reason", finishReason)
+	last, _ = sjson.SetRawBytes(last, "usage", mockUsageJSON(translated, text, toolCalls))
+	appendChunk(last)
+	lines = append(lines, []byte("data: [DONE]"))
+	return lines
+}
+
+// mockToolCallJSON renders a canned tool call in OpenAI format.
+func mockToolCallJSON(index int, call config.MockToolCall, stream bool) []byte {
+	arguments := strings.TrimSpace(call.Arguments)
+	if arguments == "" {
+		arguments = "{}"
+	}
+	out := []byte(`{"type":"function","function":{}}`)
+	if stream {
+		out, _ = sjson.SetBytes(out, "index", index)
+	}
+	out, _ = sjson.SetBytes(out, "id", fmt.Sprintf("call_mock_%d", index))
+	out, _ = sjson.SetBytes(out, "function.name", call.Name)
+	out, _ = sjson.SetBytes(out, "function.arguments", arguments)
+	return out
+}
+
+// mockUsageJSON estimates token usage by counting whitespace-separated words.
+func mockUsageJSON(translated []byte, text string, toolCalls []config.MockToolCall) []byte {
+	prompt := 0
+	gjson.GetBytes(translated, "messages").ForEach(func(_, message gjson.Result) bool {
+		prompt += len(strings.Fields(mockMessageText(message.Get("content"))))
+		return true
+	})
+	completion := len(strings.Fields(text))
+	for _, call := range toolCalls {
+		completion += len(strings.Fields(call.Name + " " + call.Arguments))
+	}
+	return []byte(fmt.Sprintf(`{"prompt_tokens":%d,"completion_tokens":%d,"total_tokens":%d}`, prompt, completion, prompt+completion))
+}
+
+// mockLastUserText returns the text of the last user message for echo responses.
+func mockLastUserText(translated []byte) string {
+	messages := gjson.GetBytes(translated, "messages").Array()
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Get("role").String() == "user" {
+			if text := mockMessageText(messages[i].Get("content")); text != "" {
+				return text
+			}
+		}
+	}
+	return "This is a mock response."
+}
+
+// mockMessageText flattens OpenAI message content into plain text.
+func mockMessageText(content gjson.Result) string {
+	if content.Type == gjson.String {
+		return content.String()
+	}
+	parts := make([]string, 0, 2)
+	content.ForEach(func(_, part gjson.Result) bool {
+		if text := part.Get("text").String(); text != "" {
+			parts = append(parts, text)
+		}
+		return true
+	})
+	return strings.Join(parts, "\n")
+}
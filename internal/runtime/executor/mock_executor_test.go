@@ -0,0 +1,101 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func newMockExecutorForTest(entry config.MockProvider) (*MockExecutor, *cliproxyauth.Auth) {
+	entry.Name = "mock"
+	cfg := &config.Config{MockProvider: []config.MockProvider{entry}}
+	auth := &cliproxyauth.Auth{ID: "mock-1", Provider: "mock", Attributes: map[string]string{"mock_name": "mock"}}
+	return NewMockExecutor(cfg), auth
+}
+
+func mockRequest(payload string) (cliproxyexecutor.Request, cliproxyexecutor.Options) {
+	return cliproxyexecutor.Request{Model: "mock-echo", Payload: []byte(payload)},
+		cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), OriginalRequest: []byte(payload)}
+}
+
+func TestMockExecutorEchoesLastUserMessage(t *testing.T) {
+	exec, auth := newMockExecutorForTest(config.MockProvider{})
+	req, opts := mockRequest(`{"model":"mock-echo","messages":[{"role":"user","content":"hello mock"}]}`)
+
+	resp, err := exec.Execute(context.Background(), auth, req, opts)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got := gjson.GetBytes(resp.Payload, "choices.0.message.content").String(); got != "hello mock" {
+		t.Fatalf("content = %q, want %q; payload=%s", got, "hello mock", resp.Payload)
+	}
+	if got := gjson.GetBytes(resp.Payload, "usage.prompt_tokens").Int(); got != 2 {
+		t.Fatalf("prompt_tokens = %d, want 2", got)
+	}
+}
+
+func TestMockExecutorScriptedToolCallsAndFailures(t *testing.T) {
+	exec, auth := newMockExecutorForTest(config.MockProvider{
+		ToolCalls:   []config.MockToolCall{{Name: "get_weather", Arguments: `{"city":"Paris"}`}},
+		FailEvery:   2,
+		ErrorStatus: http.StatusServiceUnavailable,
+	})
+	req, opts := mockRequest(`{"model":"mock-echo","messages":[{"role":"user","content":"hi"}]}`)
+
+	resp, err := exec.Execute(context.Background(), auth, req, opts)
+	if err != nil {
+		t.Fatalf("first Execute() error = %v", err)
+	}
+	if got := gjson.GetBytes(resp.Payload, "choices.0.message.tool_calls.0.function.name").String(); got != "get_weather" {
+		t.Fatalf("tool call name = %q; payload=%s", got, resp.Payload)
+	}
+
+	_, err = exec.Execute(context.Background(), auth, req, opts)
+	se, ok := err.(statusErr)
+	if !ok || se.StatusCode() != http.StatusServiceUnavailable {
+		t.Fatalf("second Execute() error = %v, want injected 503", err)
+	}
+}
+
+func TestMockExecutorStreamInjectsMalformedChunk(t *testing.T) {
+	exec, auth := newMockExecutorForTest(config.MockProvider{
+		Responses:      []string{"one two three four"},
+		MalformedEvery: 1,
+	})
+	req, opts := mockRequest(`{"model":"mock-echo","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	opts.Stream = true
+
+	result, err := exec.ExecuteStream(context.Background(), auth, req, opts)
+	if err != nil {
+		t.Fatalf("ExecuteStream() error = %v", err)
+	}
+	var content bytes.Buffer
+	malformed := false
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("unexpected stream error: %v", chunk.Err)
+		}
+		payload := bytes.TrimPrefix(chunk.Payload, []byte("data: "))
+		if bytes.Equal(payload, []byte("[DONE]")) {
+			continue
+		}
+		if !gjson.ValidBytes(payload) {
+			malformed = true
+			continue
+		}
+		content.WriteString(gjson.GetBytes(payload, "choices.0.delta.content").String())
+	}
+	if content.String() != "one two three four" {
+		t.Fatalf("streamed content = %q", content.String())
+	}
+	if !malformed {
+		t.Fatal("expected a malformed chunk in the stream")
+	}
+}
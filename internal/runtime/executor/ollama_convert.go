@@ -0,0 +1,352 @@
+package executor
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// openAIToOllamaRequest converts an OpenAI Chat Completions request into an Ollama /api/chat
+// request, or into an /api/generate request whose prompt is the conversation when generate is
+// set.
+func openAIToOllamaRequest(body []byte, model string, generate, stream bool) ([]byte, error) {
+	out := []byte(`{}`)
+	out, _ = sjson.SetBytes(out, "model", model)
+
+	var system []string
+	var turns []string
+	toolNames := make(map[string]string)
+	messages := make([]any, 0)
+	var images []string
+	for _, msg := range gjson.GetBytes(body, "messages").Array() {
+		role := msg.Get("role").String()
+		text, msgImages := ollamaMessageContent(msg.Get("content"))
+		switch role {
+		case "system", "developer":
+			if generate {
+				system = append(system, text)
+				continue
+			}
+			messages = append(messages, map[string]any{"role": "system", "content": text})
+		case "assistant":
+			entry := map[string]any{"role": "assistant", "content": text}
+			if reasoning := msg.Get("reasoning_content").String(); reasoning != "" {
+				entry["thinking"] = reasoning
+			}
+			var calls []any
+			for _, call := range msg.Get("tool_calls").Array() {
+				name := call.Get("function.name").String()
+				toolNames[call.Get("id").String()] = name
+				arguments := map[string]any{}
+				if raw := call.Get("function.arguments").String(); gjson.Valid(raw) {
+					if parsed, ok := gjson.Parse(raw).Value().(map[string]any); ok {
+						arguments = parsed
+					}
+				}
+				calls = append(calls, map[string]any{"function": map[string]any{"name": name, "arguments": arguments}})
+			}
+			if len(calls) > 0 {
+				entry["tool_calls"] = calls
+			}
+			messages = append(messages, entry)
+			turns = append(turns, "Assistant: "+text)
+		case "tool":
+			entry := map[string]any{"role": "tool", "content": text}
+			if name := toolNames[msg.Get("tool_call_id").String()]; name != "" {
+				entry["tool_name"] = name
+			}
+			messages = append(messages, entry)
+			turns = append(turns, "Tool: "+text)
+		default:
+			entry := map[string]any{"role": "user", "content": text}
+			if len(msgImages) > 0 {
+				entry["images"] = msgImages
+			}
+			messages = append(messages, entry)
+			turns = append(turns, "User: "+text)
+			images = append(images, msgImages...)
+		}
+	}
+
+	tools := gjson.GetBytes(body, "tools")
+	if gjson.GetBytes(body, "tool_choice").String() == "none" {
+		tools = gjson.Result{}
+	}
+	if generate {
+		if tools.IsArray() && len(tools.Array()) > 0 {
+			return nil, statusErr{code: http.StatusBadRequest, msg: fmt.Sprintf("ollama model %s uses the generate endpoint, which does not support tools", model)}
+		}
+		prompt := strings.Join(turns, "\n\n") + "\n\nAssistant:"
+		// A single user message is sent as is, so the model template applies to it.
+		if len(turns) == 1 && strings.HasPrefix(turns[0], "User: ") {
+			prompt = strings.TrimPrefix(turns[0], "User: ")
+		}
+		out, _ = sjson.SetBytes(out, "prompt", prompt)
+		if len(system) > 0 {
+			out, _ = sjson.SetBytes(out, "system", strings.Join(system, "\n\n"))
+		}
+		if len(images) > 0 {
+			out, _ = sjson.SetBytes(out, "images", images)
+		}
+	} else {
+		out, _ = sjson.SetBytes(out, "messages", messages)
+		var functions []string
+		for _, tool := range tools.Array() {
+			if tool.Get("type").String() == "function" {
+				functions = append(functions, tool.Raw)
+			}
+		}
+		if len(functions) > 0 {
+			out, _ = sjson.SetRawBytes(out, "tools", []byte("["+strings.Join(functions, ",")+"]"))
+		}
+	}
+	out, _ = sjson.SetBytes(out, "stream", stream)
+
+	switch format := gjson.GetBytes(body, "response_format"); format.Get("type").String() {
+	case "json_object":
+		out, _ = sjson.SetBytes(out, "format", "json")
+	case "json_schema":
+		if schema := format.Get("json_schema.schema"); schema.IsObject() {
+			out, _ = sjson.SetRawBytes(out, "format", []byte(schema.Raw))
+		} else {
+			out, _ = sjson.SetBytes(out, "format", "json")
+		}
+	}
+	if effort := gjson.GetBytes(body, "reasoning_effort"); effort.Exists() {
+		switch level := effort.String(); {
+		case level == "none":
+			out, _ = sjson.SetBytes(out, "think", false)
+		case strings.Contains(strings.ToLower(model), "gpt-oss") && (level == "low" || level == "medium" || level == "high"):
+			// gpt-oss takes a reasoning level; other thinking models only switch thinking on.
+			out, _ = sjson.SetBytes(out, "think", level)
+		default:
+			out, _ = sjson.SetBytes(out, "think", true)
+		}
+	}
+
+	for _, option := range []struct{ from, to string }{
+		{"temperature", "temperature"},
+		{"top_p", "top_p"},
+		{"top_k", "top_k"},
+		{"seed", "seed"},
+		{"presence_penalty", "presence_penalty"},
+		{"frequency_penalty", "frequency_penalty"},
+		{"max_tokens", "num_predict"},
+		{"max_completion_tokens", "num_predict"},
+	} {
+		if value := gjson.GetBytes(body, option.from); value.Exists() && value.Type == gjson.Number {
+			out, _ = sjson.SetRawBytes(out, "options."+option.to, []byte(value.Raw))
+		}
+	}
+	if stop := gjson.GetBytes(body, "stop"); stop.Exists() {
+		var stops []string
+		if stop.IsArray() {
+			for _, s := range stop.Array() {
+				stops = append(stops, s.String())
+			}
+		} else if stop.String() != "" {
+			stops = append(stops, stop.String())
+		}
+		if len(stops) > 0 {
+			out, _ = sjson.SetBytes(out, "options.stop", stops)
+		}
+	}
+	return out, nil
+}
+
+// ollamaMessageContent returns the text and the base64 images of OpenAI message content.
+// Images must be data URLs; remote URLs are skipped unless image inlining is configured.
+func ollamaMessageContent(content gjson.Result) (string, []string) {
+	if !content.IsArray() {
+		return content.String(), nil
+	}
+	var texts []string
+	var images []string
+	for _, part := range content.Array() {
+		switch part.Get("type").String() {
+		case "text":
+			texts = append(texts, part.Get("text").String())
+		case "image_url":
+			url := part.Get("image_url.url").String()
+			if !strings.HasPrefix(url, "data:") {
+				log.Debugf("ollama executor: skipping remote image %s", url)
+				continue
+			}
+			if _, data, ok := strings.Cut(url, ";base64,"); ok {
+				images = append(images, data)
+			}
+		}
+	}
+	return strings.Join(texts, "\n"), images
+}
+
+// ollamaStreamConverter converts the NDJSON lines of an Ollama chat or generate response into
+// OpenAI Chat Completions chunks.
+type ollamaStreamConverter struct {
+	id        string
+	model     string
+	created   int64
+	started   bool
+	toolCalls int
+}
+
+func newOllamaStreamConverter(model string) *ollamaStreamConverter {
+	return &ollamaStreamConverter{
+		id:      "chatcmpl-" + strings.ReplaceAll(uuid.NewString(), "-", ""),
+		model:   model,
+		created: time.Now().Unix(),
+	}
+}
+
+// convert returns the chunks for one NDJSON line as "data: " lines, ending with the usage
+// chunk and "data: [DONE]" on the final line. Error lines are returned as errors.
+func (c *ollamaStreamConverter) convert(line []byte) ([][]byte, error) {
+	if len(strings.TrimSpace(string(line))) == 0 {
+		return nil, nil
+	}
+	event := gjson.ParseBytes(line)
+	if msg := event.Get("error"); msg.Exists() {
+		return nil, ollamaError(http.StatusBadGateway, msg.String())
+	}
+	var out [][]byte
+	delta := []byte(`{}`)
+	if !c.started {
+		c.started = true
+		delta, _ = sjson.SetBytes(delta, "role", "assistant")
+	}
+	if content := ollamaContent(event); content != "" {
+		delta, _ = sjson.SetBytes(delta, "content", content)
+	}
+	if thinking := ollamaThinking(event); thinking != "" {
+		delta, _ = sjson.SetBytes(delta, "reasoning_content", thinking)
+	}
+	for i, call := range event.Get("message.tool_calls").Array() {
+		delta, _ = sjson.SetRawBytes(delta, fmt.Sprintf("tool_calls.%d", i), c.toolCall(call, true))
+	}
+	if string(delta) != "{}" {
+		out = append(out, c.chunk(delta, ""))
+	}
+	if event.Get("done").Bool() {
+		out = append(out, c.chunk([]byte(`{}`), ollamaFinishReason(event, c.toolCalls > 0)))
+		usage := []byte(`{"choices":[]}`)
+		usage, _ = sjson.SetBytes(usage, "id", c.id)
+		usage, _ = sjson.SetBytes(usage, "object", "chat.completion.chunk")
+		usage, _ = sjson.SetBytes(usage, "created", c.created)
+		usage, _ = sjson.SetBytes(usage, "model", c.model)
+		usage, _ = sjson.SetRawBytes(usage, "usage", ollamaUsage(event))
+		out = append(out, append([]byte("data: "), usage...), []byte("data: [DONE]"))
+	}
+	return out, nil
+}
+
+// toolCall converts an Ollama tool call into an OpenAI tool call, with its index when it is a
+// stream delta.
+func (c *ollamaStreamConverter) toolCall(call gjson.Result, withIndex bool) []byte {
+	out := []byte(`{"type":"function"}`)
+	if withIndex {
+		out, _ = sjson.SetBytes(out, "index", c.toolCalls)
+	}
+	id := call.Get("id").String()
+	if id == "" {
+		id = "call_" + strings.ReplaceAll(uuid.NewString(), "-", "")[:24]
+	}
+	out, _ = sjson.SetBytes(out, "id", id)
+	out, _ = sjson.SetBytes(out, "function.name", call.Get("function.name").String())
+	arguments := call.Get("function.arguments")
+	switch {
+	case arguments.IsObject():
+		out, _ = sjson.SetBytes(out, "function.arguments", arguments.Raw)
+	case arguments.Type == gjson.String:
+		out, _ = sjson.SetBytes(out, "function.arguments", arguments.String())
+	default:
+		out, _ = sjson.SetBytes(out, "function.arguments", "{}")
+	}
+	c.toolCalls++
+	return out
+}
+
+func (c *ollamaStreamConverter) chunk(delta []byte, finishReason string) []byte {
+	out := []byte(`{"object":"chat.completion.chunk","choices":[{"index":0}]}`)
+	out, _ = sjson.SetBytes(out, "id", c.id)
+	out, _ = sjson.SetBytes(out, "created", c.created)
+	out, _ = sjson.SetBytes(out, "model", c.model)
+	out, _ = sjson.SetRawBytes(out, "choices.0.delta", delta)
+	if finishReason != "" {
+		out, _ = sjson.SetBytes(out, "choices.0.finish_reason", finishReason)
+	} else {
+		out, _ = sjson.SetRawBytes(out, "choices.0.finish_reason", []byte("null"))
+	}
+	return append([]byte("data: "), out...)
+}
+
+// ollamaToOpenAIResponse converts a non-streaming Ollama chat or generate response into an
+// OpenAI Chat Completions response.
+func ollamaToOpenAIResponse(data []byte, model string) []byte {
+	event := gjson.ParseBytes(data)
+	converter := newOllamaStreamConverter(model)
+	out := []byte(`{"object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant"}}]}`)
+	out, _ = sjson.SetBytes(out, "id", converter.id)
+	out, _ = sjson.SetBytes(out, "created", converter.created)
+	out, _ = sjson.SetBytes(out, "model", model)
+	out, _ = sjson.SetBytes(out, "choices.0.message.content", ollamaContent(event))
+	if thinking := ollamaThinking(event); thinking != "" {
+		out, _ = sjson.SetBytes(out, "choices.0.message.reasoning_content", thinking)
+	}
+	for i, call := range event.Get("message.tool_calls").Array() {
+		out, _ = sjson.SetRawBytes(out, fmt.Sprintf("choices.0.message.tool_calls.%d", i), converter.toolCall(call, false))
+	}
+	out, _ = sjson.SetBytes(out, "choices.0.finish_reason", ollamaFinishReason(event, converter.toolCalls > 0))
+	out, _ = sjson.SetRawBytes(out, "usage", ollamaUsage(event))
+	return out
+}
+
+// ollamaContent returns the text of a chat message or of a generate response.
+func ollamaContent(event gjson.Result) string {
+	if content := event.Get("message.content"); content.Exists() {
+		return content.String()
+	}
+	return event.Get("response").String()
+}
+
+func ollamaThinking(event gjson.Result) string {
+	if thinking := event.Get("message.thinking"); thinking.Exists() {
+		return thinking.String()
+	}
+	return event.Get("thinking").String()
+}
+
+func ollamaFinishReason(event gjson.Result, toolCalls bool) string {
+	if toolCalls {
+		return "tool_calls"
+	}
+	if event.Get("done_reason").String() == "length" {
+		return "length"
+	}
+	return "stop"
+}
+
+func ollamaUsage(event gjson.Result) []byte {
+	prompt := event.Get("prompt_eval_count").Int()
+	completion := event.Get("eval_count").Int()
+	usage := []byte(`{}`)
+	usage, _ = sjson.SetBytes(usage, "prompt_tokens", prompt)
+	usage, _ = sjson.SetBytes(usage, "completion_tokens", completion)
+	usage, _ = sjson.SetBytes(usage, "total_tokens", prompt+completion)
+	return usage
+}
+
+// ollamaError converts an Ollama error message into a status error. Missing models are
+// reported as 404 with a hint to pull them.
+func ollamaError(code int, message string) error {
+	lower := strings.ToLower(message)
+	if strings.Contains(lower, "not found") && (strings.Contains(lower, "model") || strings.Contains(lower, "pull")) {
+		return statusErr{code: http.StatusNotFound, msg: message + ` (pull the model with "ollama pull" or enable auto-pull)`}
+	}
+	return statusErr{code: code, msg: message}
+}
@@ -0,0 +1,394 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/lifecycle"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor/helps"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+// OllamaExecutor executes requests against an Ollama server through its native /api/chat and
+// /api/generate endpoints. Requests are translated to OpenAI Chat Completions first and then
+// converted to Ollama's format; the NDJSON responses are converted back into Chat Completions
+// so every client format reuses the OpenAI response translators.
+type OllamaExecutor struct {
+	cfg *config.Config
+}
+
+// NewOllamaExecutor creates an executor for Ollama servers.
+func NewOllamaExecutor(cfg *config.Config) *OllamaExecutor { return &OllamaExecutor{cfg: cfg} }
+
+func (e *OllamaExecutor) Identifier() string { return "ollama" }
+
+// ollamaCreds returns the base URL and optional bearer token of an Ollama auth.
+func ollamaCreds(auth *cliproxyauth.Auth) (baseURL, apiKey string) {
+	if auth != nil && auth.Attributes != nil {
+		baseURL = strings.TrimSpace(auth.Attributes["base_url"])
+		apiKey = strings.TrimSpace(auth.Attributes["api_key"])
+	}
+	if baseURL == "" {
+		baseURL = config.DefaultOllamaBaseURL
+	}
+	return strings.TrimSuffix(baseURL, "/"), apiKey
+}
+
+// PrepareRequest injects the Ollama credentials into the outgoing HTTP request.
+func (e *OllamaExecutor) PrepareRequest(req *http.Request, auth *cliproxyauth.Auth) error {
+	if req == nil {
+		return nil
+	}
+	if _, apiKey := ollamaCreds(auth); apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	util.ApplyCustomHeadersFromAttrs(req, attrs)
+	return nil
+}
+
+// HttpRequest injects the Ollama credentials into the request and executes it.
+func (e *OllamaExecutor) HttpRequest(ctx context.Context, auth *cliproxyauth.Auth, req *http.Request) (*http.Response, error) {
+	if req == nil {
+		return nil, fmt.Errorf("ollama executor: request is nil")
+	}
+	if ctx == nil {
+		ctx = req.Context()
+	}
+	httpReq := req.WithContext(ctx)
+	if err := e.PrepareRequest(httpReq, auth); err != nil {
+		return nil, err
+	}
+	httpClient := helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	return httpClient.Do(httpReq)
+}
+
+func (e *OllamaExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	if opts.Alt == "responses/compact" {
+		return resp, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
+	}
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := helps.NewUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+	from := opts.SourceFormat
+	if req.Payload, err = helps.InlineImageURLs(ctx, e.cfg, e.Identifier(), from, req.Payload); err != nil {
+		return resp, err
+	}
+	to := sdktranslator.FromString("openai")
+	translated, err := e.translateRequest(ctx, req, opts, baseModel, false)
+	if err != nil {
+		return resp, err
+	}
+
+	httpResp, err := e.send(ctx, auth, baseModel, translated, false)
+	if err != nil {
+		return resp, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("ollama executor: close response body error: %v", errClose)
+		}
+	}()
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		helps.RecordAPIResponseError(ctx, e.cfg, err)
+		return resp, err
+	}
+	helps.AppendAPIResponseChunk(ctx, e.cfg, data)
+	if msg := gjson.GetBytes(data, "error"); msg.Exists() {
+		return resp, ollamaError(http.StatusBadGateway, msg.String())
+	}
+	body := ollamaToOpenAIResponse(data, baseModel)
+	reporter.Publish(ctx, helps.ParseOpenAIUsage(body))
+
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, opts.OriginalRequest, translated, body, &param)
+	resp = cliproxyexecutor.Response{Payload: out, Headers: httpResp.Header.Clone()}
+	return resp, nil
+}
+
+func (e *OllamaExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (_ *cliproxyexecutor.StreamResult, err error) {
+	if opts.Alt == "responses/compact" {
+		return nil, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
+	}
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := helps.NewUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+	from := opts.SourceFormat
+	if req.Payload, err = helps.InlineImageURLs(ctx, e.cfg, e.Identifier(), from, req.Payload); err != nil {
+		return nil, err
+	}
+	to := sdktranslator.FromString("openai")
+	translated, err := e.translateRequest(ctx, req, opts, baseModel, true)
+	if err != nil {
+		return nil, err
+	}
+
+	httpResp, err := e.send(ctx, auth, baseModel, translated, true)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan cliproxyexecutor.StreamChunk)
+	lifecycle.Go(ctx, "ollama.stream", func() {
+		defer close(out)
+		defer func() {
+			if errClose := httpResp.Body.Close(); errClose != nil {
+				log.Errorf("ollama executor: close response body error: %v", errClose)
+			}
+		}()
+
+		scanner := bufio.NewScanner(httpResp.Body)
+		scanner.Buffer(nil, streamScannerBuffer)
+		converter := newOllamaStreamConverter(baseModel)
+		var param any
+		usageEmulator := helps.NewStreamUsageEmulator(from, baseModel, req.Payload)
+		emit := func(lines [][]byte) {
+			for _, line := range lines {
+				if detail, ok := helps.ParseOpenAIStreamUsage(line); ok {
+					reporter.Publish(ctx, detail)
+				}
+				chunks := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, translated, line, &param)
+				for i := range chunks {
+					usageEmulator.Observe(chunks[i])
+					out <- cliproxyexecutor.StreamChunk{Payload: chunks[i]}
+				}
+			}
+		}
+		done := false
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			helps.AppendAPIResponseChunk(ctx, e.cfg, line)
+			lines, errConvert := converter.convert(line)
+			if errConvert != nil {
+				helps.RecordAPIResponseError(ctx, e.cfg, errConvert)
+				reporter.PublishFailure(ctx, errConvert)
+				out <- cliproxyexecutor.StreamChunk{Err: errConvert}
+				return
+			}
+			emit(lines)
+			if gjson.GetBytes(line, "done").Bool() {
+				done = true
+			}
+		}
+		if errScan := scanner.Err(); errScan != nil {
+			helps.RecordAPIResponseError(ctx, e.cfg, errScan)
+			reporter.PublishFailure(ctx, errScan)
+			out <- cliproxyexecutor.StreamChunk{Err: errScan}
+			return
+		}
+		if !done {
+			// The server closed the stream without a final line; finish the response so
+			// clients still receive a terminal event.
+			lines, _ := converter.convert([]byte(`{"done":true}`))
+			emit(lines)
+		}
+		chunks := sdktranslator.FinalizeStream(ctx, to, from, req.Model, opts.OriginalRequest, translated, &param)
+		for i := range chunks {
+			usageEmulator.Observe(chunks[i])
+			out <- cliproxyexecutor.StreamChunk{Payload: chunks[i]}
+		}
+		if usageChunk := usageEmulator.Finish(); usageChunk != nil {
+			out <- cliproxyexecutor.StreamChunk{Payload: usageChunk}
+		}
+	})
+	return &cliproxyexecutor.StreamResult{Headers: httpResp.Header.Clone(), Chunks: out}, nil
+}
+
+// CountTokens counts locally: Ollama has no token counting endpoint.
+func (e *OllamaExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return helps.EstimateTokenCount(ctx, req, opts)
+}
+
+// Refresh is a no-op: Ollama credentials are static.
+func (e *OllamaExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
+	log.Debugf("ollama executor: refresh called")
+	_ = ctx
+	return auth, nil
+}
+
+// translateRequest translates the client request into an OpenAI Chat Completions request.
+func (e *OllamaExecutor) translateRequest(ctx context.Context, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, baseModel string, stream bool) ([]byte, error) {
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	originalPayload := req.Payload
+	if len(opts.OriginalRequest) > 0 {
+		originalPayload = opts.OriginalRequest
+	}
+	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, stream)
+	translated := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, stream)
+	requestedModel := helps.PayloadRequestedModel(opts, req.Model)
+	translated = helps.ApplyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", translated, originalTranslated, requestedModel)
+	if err := helps.CheckAttachmentSupport(from, to, req.Payload); err != nil {
+		return nil, err
+	}
+	if err := helps.CheckLogprobsSupport(from, to, req.Payload); err != nil {
+		return nil, err
+	}
+	if err := helps.CheckTranslatorPair(ctx, from, to); err != nil {
+		return nil, err
+	}
+	translated = helps.ApplySamplingCapabilities(ctx, from, to, req.Payload, translated)
+	return thinking.ApplyThinking(translated, req.Model, from.String(), to.String(), e.Identifier())
+}
+
+// send converts a Chat Completions request for model to the Ollama API the model is configured
+// for and returns the successful response. A model the server does not have is pulled and the
+// request retried when the credential enables auto-pull.
+func (e *OllamaExecutor) send(ctx context.Context, auth *cliproxyauth.Auth, model string, body []byte, stream bool) (*http.Response, error) {
+	path := "/api/chat"
+	generate := e.modelEndpoint(auth, model) == "generate"
+	if generate {
+		path = "/api/generate"
+	}
+	payload, err := openAIToOllamaRequest(body, model, generate, stream)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := e.post(ctx, auth, path, payload)
+	if se, ok := errors.AsType[statusErr](err); !ok || se.code != http.StatusNotFound || !ollamaAutoPull(auth) {
+		return httpResp, err
+	}
+	if errPull := e.pull(ctx, auth, model); errPull != nil {
+		return nil, errPull
+	}
+	return e.post(ctx, auth, path, payload)
+}
+
+// post sends payload to path of the Ollama server and returns the successful response.
+func (e *OllamaExecutor) post(ctx context.Context, auth *cliproxyauth.Auth, path string, payload []byte) (*http.Response, error) {
+	baseURL, apiKey := ollamaCreds(auth)
+	url := baseURL + path
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	util.ApplyCustomHeadersFromAttrs(httpReq, attrs)
+
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	helps.RecordAPIRequest(ctx, e.cfg, helps.UpstreamRequestLog{
+		URL:       url,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      payload,
+		Provider:  e.Identifier(),
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpClient := helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpResp, err := helps.DoWithRetry(ctx, e.cfg, httpClient, httpReq)
+	if err != nil {
+		helps.RecordAPIResponseError(ctx, e.cfg, err)
+		return nil, err
+	}
+	helps.RecordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		b, _ := io.ReadAll(httpResp.Body)
+		helps.AppendAPIResponseChunk(ctx, e.cfg, b)
+		helps.LogWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, helps.SummarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("ollama executor: close response body error: %v", errClose)
+		}
+		message := gjson.GetBytes(b, "error").String()
+		if message == "" {
+			message = string(b)
+		}
+		return nil, ollamaError(httpResp.StatusCode, message)
+	}
+	return httpResp, nil
+}
+
+// pull downloads model to the Ollama server and waits for it to finish. The status lines of
+// the pull are logged; an error line or a pull that ends without success fails the request.
+func (e *OllamaExecutor) pull(ctx context.Context, auth *cliproxyauth.Auth, model string) error {
+	log.Infof("ollama executor: pulling model %s", model)
+	payload := fmt.Appendf(nil, `{"model":%q,"stream":true}`, model)
+	httpResp, err := e.post(ctx, auth, "/api/pull", payload)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("ollama executor: close response body error: %v", errClose)
+		}
+	}()
+	scanner := bufio.NewScanner(httpResp.Body)
+	scanner.Buffer(nil, streamScannerBuffer)
+	lastStatus := ""
+	for scanner.Scan() {
+		line := gjson.ParseBytes(scanner.Bytes())
+		if msg := line.Get("error"); msg.Exists() {
+			return statusErr{code: http.StatusBadGateway, msg: fmt.Sprintf("ollama executor: pull model %s failed: %s", model, msg.String())}
+		}
+		status := line.Get("status").String()
+		if status == "success" {
+			log.Infof("ollama executor: pulled model %s", model)
+			return nil
+		}
+		if status != "" && status != lastStatus {
+			log.Debugf("ollama executor: pull model %s: %s", model, status)
+			lastStatus = status
+		}
+	}
+	if errScan := scanner.Err(); errScan != nil {
+		return fmt.Errorf("ollama executor: pull model %s: %w", model, errScan)
+	}
+	return statusErr{code: http.StatusBadGateway, msg: fmt.Sprintf("ollama executor: pull model %s ended without success (last status %q)", model, lastStatus)}
+}
+
+// modelEndpoint returns the Ollama API configured for model: "chat" or "generate".
+func (e *OllamaExecutor) modelEndpoint(auth *cliproxyauth.Auth, model string) string {
+	if e.cfg == nil {
+		return "chat"
+	}
+	baseURL, apiKey := ollamaCreds(auth)
+	for i := range e.cfg.OllamaKey {
+		entry := &e.cfg.OllamaKey[i]
+		if !strings.EqualFold(strings.TrimSuffix(strings.TrimSpace(entry.BaseURL), "/"), baseURL) || strings.TrimSpace(entry.APIKey) != apiKey {
+			continue
+		}
+		for _, m := range entry.Models {
+			if strings.EqualFold(strings.TrimSpace(m.Name), model) && strings.EqualFold(strings.TrimSpace(m.Endpoint), "generate") {
+				return "generate"
+			}
+		}
+	}
+	return "chat"
+}
+
+func ollamaAutoPull(auth *cliproxyauth.Auth) bool {
+	return auth != nil && auth.Attributes != nil && auth.Attributes["auto_pull"] == "true"
+}
@@ -0,0 +1,217 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func newOllamaTestAuth(baseURL string) *cliproxyauth.Auth {
+	return &cliproxyauth.Auth{Provider: "ollama", Attributes: map[string]string{"base_url": baseURL}}
+}
+
+func TestOllamaExecutorExecuteChat(t *testing.T) {
+	var gotPath string
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotBody, _ = io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(`{"model":"llama3.2","created_at":"2025-01-01T00:00:00Z","message":{"role":"assistant","content":"hi there"},` +
+			`"done":true,"done_reason":"stop","prompt_eval_count":7,"eval_count":3}`))
+	}))
+	defer server.Close()
+
+	executor := NewOllamaExecutor(&config.Config{})
+	payload := []byte(`{"model":"llama3.2","max_tokens":32,"temperature":0.2,"stop":"END","messages":[` +
+		`{"role":"system","content":"be brief"},` +
+		`{"role":"user","content":[{"type":"text","text":"what is this?"},{"type":"image_url","image_url":{"url":"data:image/png;base64,iVBORw0KGgo="}}]}]}`)
+	resp, err := executor.Execute(context.Background(), newOllamaTestAuth(server.URL), cliproxyexecutor.Request{Model: "llama3.2", Payload: payload}, cliproxyexecutor.Options{
+		SourceFormat: sdktranslator.FromString("openai"),
+	})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if gotPath != "/api/chat" {
+		t.Fatalf("path = %s, want /api/chat", gotPath)
+	}
+	if gjson.GetBytes(gotBody, "stream").Bool() || gjson.GetBytes(gotBody, "options.num_predict").Int() != 32 || gjson.GetBytes(gotBody, "options.stop.0").String() != "END" {
+		t.Fatalf("unexpected chat body: %s", gotBody)
+	}
+	if gjson.GetBytes(gotBody, "messages.0.role").String() != "system" || gjson.GetBytes(gotBody, "messages.1.images.0").String() != "iVBORw0KGgo=" {
+		t.Fatalf("unexpected messages: %s", gotBody)
+	}
+	if got := gjson.GetBytes(resp.Payload, "choices.0.message.content").String(); got != "hi there" {
+		t.Fatalf("content = %q: %s", got, resp.Payload)
+	}
+	if gjson.GetBytes(resp.Payload, "usage.prompt_tokens").Int() != 7 || gjson.GetBytes(resp.Payload, "usage.completion_tokens").Int() != 3 {
+		t.Fatalf("unexpected usage: %s", resp.Payload)
+	}
+}
+
+func TestOllamaExecutorStreamToolCallsToClaude(t *testing.T) {
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		for _, line := range []string{
+			`{"model":"qwen3","message":{"role":"assistant","content":"","thinking":"need weather"},"done":false}`,
+			`{"model":"qwen3","message":{"role":"assistant","content":"Checking."},"done":false}`,
+			`{"model":"qwen3","message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"get_weather","arguments":{"city":"Paris"}}}]},"done":false}`,
+			`{"model":"qwen3","message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","prompt_eval_count":12,"eval_count":8}`,
+		} {
+			_, _ = w.Write([]byte(line + "\n"))
+		}
+	}))
+	defer server.Close()
+
+	executor := NewOllamaExecutor(&config.Config{})
+	payload := []byte(`{"model":"qwen3","max_tokens":256,"stream":true,` +
+		`"tools":[{"name":"get_weather","description":"weather","input_schema":{"type":"object","properties":{"city":{"type":"string"}}}}],` +
+		`"messages":[{"role":"user","content":"weather in Paris?"}]}`)
+	result, err := executor.ExecuteStream(context.Background(), newOllamaTestAuth(server.URL), cliproxyexecutor.Request{Model: "qwen3", Payload: payload}, cliproxyexecutor.Options{
+		SourceFormat:    sdktranslator.FromString("claude"),
+		OriginalRequest: payload,
+		Stream:          true,
+	})
+	if err != nil {
+		t.Fatalf("ExecuteStream error: %v", err)
+	}
+	var stream strings.Builder
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("stream error: %v", chunk.Err)
+		}
+		stream.Write(chunk.Payload)
+	}
+	if !gjson.GetBytes(gotBody, "stream").Bool() || gjson.GetBytes(gotBody, "tools.0.function.name").String() != "get_weather" {
+		t.Fatalf("unexpected chat body: %s", gotBody)
+	}
+	out := stream.String()
+	for _, want := range []string{`"thinking":"need weather"`, `"text":"Checking."`, `"type":"tool_use"`, `"name":"get_weather"`, `\"city\":\"Paris\"`, `"stop_reason":"tool_use"`} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("stream missing %s:\n%s", want, out)
+		}
+	}
+}
+
+func TestOllamaExecutorGenerateEndpoint(t *testing.T) {
+	var gotPath string
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotBody, _ = io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(`{"model":"base","response":"func main() {}","done":true,"done_reason":"length","prompt_eval_count":4,"eval_count":16}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{OllamaKey: []config.OllamaKey{{
+		BaseURL: server.URL,
+		Models:  []config.OllamaModel{{Name: "qwen2.5-coder:7b-base", Alias: "coder", Endpoint: "generate"}},
+	}}}
+	executor := NewOllamaExecutor(cfg)
+	payload := []byte(`{"model":"coder","messages":[{"role":"system","content":"complete Go code"},{"role":"user","content":"package main"}]}`)
+	resp, err := executor.Execute(context.Background(), newOllamaTestAuth(server.URL), cliproxyexecutor.Request{Model: "qwen2.5-coder:7b-base", Payload: payload}, cliproxyexecutor.Options{
+		SourceFormat: sdktranslator.FromString("openai"),
+	})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if gotPath != "/api/generate" {
+		t.Fatalf("path = %s, want /api/generate", gotPath)
+	}
+	if gjson.GetBytes(gotBody, "prompt").String() != "package main" || gjson.GetBytes(gotBody, "system").String() != "complete Go code" {
+		t.Fatalf("unexpected generate body: %s", gotBody)
+	}
+	if gjson.GetBytes(resp.Payload, "choices.0.message.content").String() != "func main() {}" || gjson.GetBytes(resp.Payload, "choices.0.finish_reason").String() != "length" {
+		t.Fatalf("unexpected response: %s", resp.Payload)
+	}
+}
+
+func TestOllamaExecutorMissingModel(t *testing.T) {
+	var mu sync.Mutex
+	pulled := false
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/api/pull":
+			if gjson.GetBytes(mustReadAll(r.Body), "model").String() != "llama3.2" {
+				_, _ = w.Write([]byte(`{"error":"wrong model"}` + "\n"))
+				return
+			}
+			pulled = true
+			_, _ = w.Write([]byte(`{"status":"pulling manifest"}` + "\n" + `{"status":"verifying sha256 digest"}` + "\n" + `{"status":"success"}` + "\n"))
+		case "/api/chat":
+			if !pulled {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"error":"model \"llama3.2\" not found, try pulling it first"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"message":{"role":"assistant","content":"ready"},"done":true}`))
+		}
+	}))
+	defer server.Close()
+
+	executor := NewOllamaExecutor(&config.Config{})
+	req := cliproxyexecutor.Request{Model: "llama3.2", Payload: []byte(`{"model":"llama3.2","messages":[{"role":"user","content":"hi"}]}`)}
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")}
+
+	_, err := executor.Execute(context.Background(), newOllamaTestAuth(server.URL), req, opts)
+	se, ok := errors.AsType[statusErr](err)
+	if !ok || se.code != http.StatusNotFound || !strings.Contains(se.msg, "ollama pull") {
+		t.Fatalf("expected a 404 pull hint, got %v", err)
+	}
+
+	auth := newOllamaTestAuth(server.URL)
+	auth.Attributes["auto_pull"] = "true"
+	resp, err := executor.Execute(context.Background(), auth, req, opts)
+	if err != nil {
+		t.Fatalf("Execute with auto-pull error: %v", err)
+	}
+	if gjson.GetBytes(resp.Payload, "choices.0.message.content").String() != "ready" {
+		t.Fatalf("unexpected response: %s", resp.Payload)
+	}
+	if got := strings.Join(paths, ","); got != "/api/chat,/api/chat,/api/pull,/api/chat" {
+		t.Fatalf("paths = %s", got)
+	}
+}
+
+func TestOllamaExecutorPullError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/pull" {
+			_, _ = w.Write([]byte(`{"status":"pulling manifest"}` + "\n" + `{"error":"pull model manifest: file does not exist"}` + "\n"))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"model \"nope\" not found, try pulling it first"}`))
+	}))
+	defer server.Close()
+
+	auth := newOllamaTestAuth(server.URL)
+	auth.Attributes["auto_pull"] = "true"
+	_, err := NewOllamaExecutor(&config.Config{}).Execute(context.Background(), auth,
+		cliproxyexecutor.Request{Model: "nope", Payload: []byte(`{"model":"nope","messages":[{"role":"user","content":"hi"}]}`)},
+		cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")})
+	se, ok := errors.AsType[statusErr](err)
+	if !ok || se.code != http.StatusBadGateway || !strings.Contains(se.msg, "file does not exist") {
+		t.Fatalf("expected the pull error, got %v", err)
+	}
+}
+
+func mustReadAll(r io.Reader) []byte {
+	data, _ := io.ReadAll(r)
+	return data
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/lifecycle"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor/helps"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
@@ -27,6 +28,11 @@ import (
 type OpenAICompatExecutor struct {
 	provider string
 	cfg      *config.Config
+
+	// buildURL and applyAuth, when set, replace the openai-compatibility path templates and
+	// auth style for vendors with their own URL layout and authentication, such as Azure OpenAI.
+	buildURL  func(auth *cliproxyauth.Auth, baseURL, defaultPath, model string) string
+	applyAuth func(header http.Header, auth *cliproxyauth.Auth, apiKey string)
 }
 
 // NewOpenAICompatExecutor creates an executor bound to a provider key (e.g., "openrouter").
@@ -43,9 +49,7 @@ func (e *OpenAICompatExecutor) PrepareRequest(req *http.Request, auth *cliproxya
 		return nil
 	}
 	_, apiKey := e.resolveCredentials(auth)
-	if strings.TrimSpace(apiKey) != "" {
-		req.Header.Set("Authorization", "Bearer "+apiKey)
-	}
+	e.setAuth(req.Header, auth, apiKey)
 	var attrs map[string]string
 	if auth != nil {
 		attrs = auth.Attributes
@@ -83,21 +87,37 @@ func (e *OpenAICompatExecutor) Execute(ctx context.Context, auth *cliproxyauth.A
 	}
 
 	from := opts.SourceFormat
+	if req.Payload, err = helps.InlineImageURLs(ctx, e.cfg, e.Identifier(), from, req.Payload); err != nil {
+		return resp, err
+	}
 	to := sdktranslator.FromString("openai")
-	endpoint := "/chat/completions"
+	url := e.endpointURL(auth, baseURL, "/chat/completions", baseModel)
 	if opts.Alt == "responses/compact" {
 		to = sdktranslator.FromString("openai-response")
-		endpoint = "/responses/compact"
+		url = e.endpointURL(auth, baseURL, "/responses/compact", baseModel)
 	}
 	originalPayloadSource := req.Payload
 	if len(opts.OriginalRequest) > 0 {
 		originalPayloadSource = opts.OriginalRequest
 	}
 	originalPayload := originalPayloadSource
-	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, opts.Stream)
-	translated := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, opts.Stream)
+	dialect := e.dialectFormat(auth, to)
+	originalTranslated := sdktranslator.TranslateRequest(from, dialect, baseModel, originalPayload, opts.Stream)
+	translated := sdktranslator.TranslateRequest(from, dialect, baseModel, req.Payload, opts.Stream)
 	requestedModel := helps.PayloadRequestedModel(opts, req.Model)
 	translated = helps.ApplyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", translated, originalTranslated, requestedModel)
+	if err = helps.CheckAttachmentSupport(from, to, req.Payload); err != nil {
+		return resp, err
+	}
+	if err = helps.CheckLogprobsSupport(from, to, req.Payload); err != nil {
+		return resp, err
+	}
+	if err = helps.CheckTranslatorPair(ctx, from, to); err != nil {
+		return resp, err
+	}
+	translated = helps.ApplySamplingCapabilities(ctx, from, to, req.Payload, translated)
+	translated = e.rewriteToolResultImages(auth, translated)
+	translated = e.applyPromptCache(from, auth, translated)
 	if opts.Alt == "responses/compact" {
 		if updated, errDelete := sjson.DeleteBytes(translated, "stream"); errDelete == nil {
 			translated = updated
@@ -109,15 +129,12 @@ func (e *OpenAICompatExecutor) Execute(ctx context.Context, auth *cliproxyauth.A
 		return resp, err
 	}
 
-	url := strings.TrimSuffix(baseURL, "/") + endpoint
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(translated))
 	if err != nil {
 		return resp, err
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
-	if apiKey != "" {
-		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
-	}
+	e.setAuth(httpReq.Header, auth, apiKey)
 	httpReq.Header.Set("User-Agent", "cli-proxy-openai-compat")
 	var attrs map[string]string
 	if auth != nil {
@@ -143,7 +160,7 @@ func (e *OpenAICompatExecutor) Execute(ctx context.Context, auth *cliproxyauth.A
 	})
 
 	httpClient := helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
-	httpResp, err := httpClient.Do(httpReq)
+	httpResp, err := helps.DoWithRetry(ctx, e.cfg, httpClient, httpReq)
 	if err != nil {
 		helps.RecordAPIResponseError(ctx, e.cfg, err)
 		return resp, err
@@ -172,7 +189,7 @@ func (e *OpenAICompatExecutor) Execute(ctx context.Context, auth *cliproxyauth.A
 	reporter.EnsurePublished(ctx)
 	// Translate response back to source format when needed
 	var param any
-	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, opts.OriginalRequest, translated, body, &param)
+	out := sdktranslator.TranslateNonStream(ctx, dialect, from, req.Model, opts.OriginalRequest, translated, body, &param)
 	resp = cliproxyexecutor.Response{Payload: out, Headers: httpResp.Header.Clone()}
 	return resp, nil
 }
@@ -190,16 +207,32 @@ func (e *OpenAICompatExecutor) ExecuteStream(ctx context.Context, auth *cliproxy
 	}
 
 	from := opts.SourceFormat
+	if req.Payload, err = helps.InlineImageURLs(ctx, e.cfg, e.Identifier(), from, req.Payload); err != nil {
+		return nil, err
+	}
 	to := sdktranslator.FromString("openai")
 	originalPayloadSource := req.Payload
 	if len(opts.OriginalRequest) > 0 {
 		originalPayloadSource = opts.OriginalRequest
 	}
 	originalPayload := originalPayloadSource
-	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, true)
-	translated := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, true)
+	dialect := e.dialectFormat(auth, to)
+	originalTranslated := sdktranslator.TranslateRequest(from, dialect, baseModel, originalPayload, true)
+	translated := sdktranslator.TranslateRequest(from, dialect, baseModel, req.Payload, true)
 	requestedModel := helps.PayloadRequestedModel(opts, req.Model)
 	translated = helps.ApplyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", translated, originalTranslated, requestedModel)
+	if err = helps.CheckAttachmentSupport(from, to, req.Payload); err != nil {
+		return nil, err
+	}
+	if err = helps.CheckLogprobsSupport(from, to, req.Payload); err != nil {
+		return nil, err
+	}
+	if err = helps.CheckTranslatorPair(ctx, from, to); err != nil {
+		return nil, err
+	}
+	translated = helps.ApplySamplingCapabilities(ctx, from, to, req.Payload, translated)
+	translated = e.rewriteToolResultImages(auth, translated)
+	translated = e.applyPromptCache(from, auth, translated)
 
 	translated, err = thinking.ApplyThinking(translated, req.Model, from.String(), to.String(), e.Identifier())
 	if err != nil {
@@ -210,15 +243,13 @@ func (e *OpenAICompatExecutor) ExecuteStream(ctx context.Context, auth *cliproxy
 	// are captured even when the upstream is an OpenAI-compatible provider.
 	translated, _ = sjson.SetBytes(translated, "stream_options.include_usage", true)
 
-	url := strings.TrimSuffix(baseURL, "/") + "/chat/completions"
+	url := e.endpointURL(auth, baseURL, "/chat/completions", baseModel)
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(translated))
 	if err != nil {
 		return nil, err
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
-	if apiKey != "" {
-		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
-	}
+	e.setAuth(httpReq.Header, auth, apiKey)
 	httpReq.Header.Set("User-Agent", "cli-proxy-openai-compat")
 	var attrs map[string]string
 	if auth != nil {
@@ -246,7 +277,7 @@ func (e *OpenAICompatExecutor) ExecuteStream(ctx context.Context, auth *cliproxy
 	})
 
 	httpClient := helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
-	httpResp, err := httpClient.Do(httpReq)
+	httpResp, err := helps.DoWithRetry(ctx, e.cfg, httpClient, httpReq)
 	if err != nil {
 		helps.RecordAPIResponseError(ctx, e.cfg, err)
 		return nil, err
@@ -263,7 +294,7 @@ func (e *OpenAICompatExecutor) ExecuteStream(ctx context.Context, auth *cliproxy
 		return nil, err
 	}
 	out := make(chan cliproxyexecutor.StreamChunk)
-	go func() {
+	lifecycle.Go(ctx, "openai-compat.stream", func() {
 		defer close(out)
 		defer func() {
 			if errClose := httpResp.Body.Close(); errClose != nil {
@@ -273,6 +304,7 @@ func (e *OpenAICompatExecutor) ExecuteStream(ctx context.Context, auth *cliproxy
 		scanner := bufio.NewScanner(httpResp.Body)
 		scanner.Buffer(nil, 52_428_800) // 50MB
 		var param any
+		usageEmulator := helps.NewStreamUsageEmulator(from, baseModel, req.Payload)
 		for scanner.Scan() {
 			line := scanner.Bytes()
 			helps.AppendAPIResponseChunk(ctx, e.cfg, line)
@@ -289,27 +321,33 @@ func (e *OpenAICompatExecutor) ExecuteStream(ctx context.Context, auth *cliproxy
 
 			// OpenAI-compatible streams are SSE: lines typically prefixed with "data: ".
 			// Pass through translator; it yields one or more chunks for the target schema.
-			chunks := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, translated, bytes.Clone(line), &param)
+			chunks := sdktranslator.TranslateStream(ctx, dialect, from, req.Model, opts.OriginalRequest, translated, bytes.Clone(line), &param)
 			for i := range chunks {
+				usageEmulator.Observe(chunks[i])
 				out <- cliproxyexecutor.StreamChunk{Payload: chunks[i]}
 			}
 		}
 		if errScan := scanner.Err(); errScan != nil {
 			helps.RecordAPIResponseError(ctx, e.cfg, errScan)
-			reporter.PublishFailure(ctx)
+			reporter.PublishFailure(ctx, errScan)
 			out <- cliproxyexecutor.StreamChunk{Err: errScan}
 		} else {
 			// In case the upstream close the stream without a terminal [DONE] marker.
 			// Feed a synthetic done marker through the translator so pending
 			// response.completed events are still emitted exactly once.
-			chunks := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, translated, []byte("data: [DONE]"), &param)
+			chunks := sdktranslator.TranslateStream(ctx, dialect, from, req.Model, opts.OriginalRequest, translated, []byte("data: [DONE]"), &param)
+			chunks = append(chunks, sdktranslator.FinalizeStream(ctx, dialect, from, req.Model, opts.OriginalRequest, translated, &param)...)
 			for i := range chunks {
+				usageEmulator.Observe(chunks[i])
 				out <- cliproxyexecutor.StreamChunk{Payload: chunks[i]}
 			}
+			if usageChunk := usageEmulator.Finish(); usageChunk != nil {
+				out <- cliproxyexecutor.StreamChunk{Payload: usageChunk}
+			}
 		}
 		// Ensure we record the request if no usage chunk was ever seen
 		reporter.EnsurePublished(ctx)
-	}()
+	})
 	return &cliproxyexecutor.StreamResult{Headers: httpResp.Header.Clone(), Chunks: out}, nil
 }
 
@@ -318,7 +356,7 @@ func (e *OpenAICompatExecutor) CountTokens(ctx context.Context, auth *cliproxyau
 
 	from := opts.SourceFormat
 	to := sdktranslator.FromString("openai")
-	translated := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
+	translated := sdktranslator.TranslateRequest(from, e.dialectFormat(auth, to), baseModel, req.Payload, false)
 
 	modelForCounting := baseModel
 
@@ -390,6 +428,77 @@ func (e *OpenAICompatExecutor) resolveCompatConfig(auth *cliproxyauth.Auth) *con
 	return nil
 }
 
+// endpointURL returns the URL of a request to the provider, using its configured path template
+// for the endpoint whose default path is defaultPath.
+func (e *OpenAICompatExecutor) endpointURL(auth *cliproxyauth.Auth, baseURL, defaultPath, model string) string {
+	if e.buildURL != nil {
+		return e.buildURL(auth, baseURL, defaultPath, model)
+	}
+	template := ""
+	if compat := e.resolveCompatConfig(auth); compat != nil {
+		switch defaultPath {
+		case "/chat/completions":
+			template = compat.ChatPath
+		case "/responses/compact":
+			template = compat.CompactPath
+		}
+	}
+	return helps.OpenAICompatURL(baseURL, template, defaultPath, model)
+}
+
+// setAuth sets the API key on header the way the provider expects it.
+func (e *OpenAICompatExecutor) setAuth(header http.Header, auth *cliproxyauth.Auth, apiKey string) {
+	if e.applyAuth != nil {
+		e.applyAuth(header, auth, apiKey)
+		return
+	}
+	style := ""
+	if compat := e.resolveCompatConfig(auth); compat != nil {
+		style = compat.AuthStyle
+	}
+	helps.ApplyOpenAICompatAuth(header, style, apiKey)
+}
+
+// dialectFormat returns the translator dialect configured for the provider when it
+// derives from the base target format, so requests and responses use its rewrites.
+// Formats registered by translator plugins are used as configured.
+func (e *OpenAICompatExecutor) dialectFormat(auth *cliproxyauth.Auth, base sdktranslator.Format) sdktranslator.Format {
+	compat := e.resolveCompatConfig(auth)
+	if compat == nil {
+		return base
+	}
+	name := strings.TrimSpace(compat.Translator)
+	if name == "" {
+		return base
+	}
+	dialect := sdktranslator.FromString(name)
+	if sdktranslator.IsPluginFormat(dialect) {
+		return dialect
+	}
+	if sdktranslator.BaseFormat(dialect) != base {
+		return base
+	}
+	return dialect
+}
+
+// rewriteToolResultImages applies the provider's tool-result-images mode to the translated payload.
+func (e *OpenAICompatExecutor) rewriteToolResultImages(auth *cliproxyauth.Auth, payload []byte) []byte {
+	compat := e.resolveCompatConfig(auth)
+	if compat == nil {
+		return payload
+	}
+	return helps.RewriteToolResultImages(payload, compat.ToolResultImages, compat.ToolResultImagePlaceholder)
+}
+
+// applyPromptCache applies the provider's prompt-cache mode to the translated payload.
+func (e *OpenAICompatExecutor) applyPromptCache(from sdktranslator.Format, auth *cliproxyauth.Auth, payload []byte) []byte {
+	mode := ""
+	if compat := e.resolveCompatConfig(auth); compat != nil {
+		mode = compat.PromptCache
+	}
+	return helps.ApplyPromptCache(from, payload, mode)
+}
+
 func (e *OpenAICompatExecutor) overrideModel(payload []byte, model string) []byte {
 	if len(payload) == 0 || model == "" {
 		return payload
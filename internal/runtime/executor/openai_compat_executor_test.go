@@ -0,0 +1,50 @@
+package executor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+func TestOpenAICompatExecutorPathTemplateAndAuthStyle(t *testing.T) {
+	var gotURI, gotKey, gotAuthorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotURI = r.URL.RequestURI()
+		gotKey = r.Header.Get("api-key")
+		gotAuthorization = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"c1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{OpenAICompatibility: []config.OpenAICompatibility{{
+		Name:      "azure",
+		BaseURL:   server.URL,
+		ChatPath:  "/openai/deployments/{model}/chat/completions?api-version=2024-10-21",
+		AuthStyle: "api-key",
+	}}}
+	executor := NewOpenAICompatExecutor("azure", cfg)
+	auth := &cliproxyauth.Auth{Provider: "azure", Attributes: map[string]string{
+		"base_url": server.URL,
+		"api_key":  "secret",
+	}}
+	_, err := executor.Execute(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "gpt-4o",
+		Payload: []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if want := "/openai/deployments/gpt-4o/chat/completions?api-version=2024-10-21"; gotURI != want {
+		t.Fatalf("request URI = %q, want %q", gotURI, want)
+	}
+	if gotKey != "secret" || gotAuthorization != "" {
+		t.Fatalf("api-key = %q, Authorization = %q; want the key in api-key only", gotKey, gotAuthorization)
+	}
+}
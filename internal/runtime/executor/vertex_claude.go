@@ -0,0 +1,231 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/lifecycle"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor/helps"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/sjson"
+)
+
+// vertexAnthropicVersion is the anthropic_version Vertex AI requires in Claude request bodies.
+const vertexAnthropicVersion = "vertex-2023-10-16"
+
+// isVertexClaudeModel reports whether model names a Claude model of the Anthropic publisher on
+// Vertex AI, such as "claude-sonnet-4@20250514".
+func isVertexClaudeModel(model string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(model)), "claude-")
+}
+
+// vertexClaudeURL returns the rawPredict or streamRawPredict endpoint of a Claude model.
+func vertexClaudeURL(projectID, location, model string, stream bool) string {
+	action := "rawPredict"
+	if stream {
+		action = "streamRawPredict"
+	}
+	return fmt.Sprintf("%s/%s/projects/%s/locations/%s/publishers/anthropic/models/%s:%s", vertexBaseURL(location), vertexAPIVersion, projectID, location, model, action)
+}
+
+// vertexClaudeBody adapts a Claude Messages request to Vertex AI: the model goes in the URL,
+// anthropic_version is required and betas move to the anthropic-beta header.
+func vertexClaudeBody(body []byte, stream bool) ([]byte, []string) {
+	betas, body := extractAndRemoveBetas(body)
+	body, _ = sjson.DeleteBytes(body, "model")
+	body, _ = sjson.DeleteBytes(body, "metadata")
+	body, _ = sjson.SetBytes(body, "anthropic_version", vertexAnthropicVersion)
+	body, _ = sjson.SetBytes(body, "stream", stream)
+	return body, betas
+}
+
+// executeClaudeWithServiceAccount sends a non-streaming request to a Claude model on Vertex AI.
+func (e *GeminiVertexExecutor) executeClaudeWithServiceAccount(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, projectID, location string, saJSON []byte) (resp cliproxyexecutor.Response, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := helps.NewUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+	from := opts.SourceFormat
+	if req.Payload, err = helps.InlineImageURLs(ctx, e.cfg, e.Identifier(), from, req.Payload); err != nil {
+		return resp, err
+	}
+	to := sdktranslator.FromString("claude")
+	// The Claude response translators read stream events, so only Claude clients get a
+	// non-streaming upstream request.
+	stream := from != to
+	body, err := translateClaudeRequest(ctx, e.cfg, e.Identifier(), req, opts, baseModel, stream)
+	if err != nil {
+		return resp, err
+	}
+
+	httpResp, err := e.sendClaude(ctx, auth, vertexClaudeURL(projectID, location, baseModel, stream), body, stream, saJSON)
+	if err != nil {
+		return resp, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("vertex executor: close response body error: %v", errClose)
+		}
+	}()
+	data, errRead := io.ReadAll(httpResp.Body)
+	if errRead != nil {
+		helps.RecordAPIResponseError(ctx, e.cfg, errRead)
+		return resp, errRead
+	}
+	helps.AppendAPIResponseChunk(ctx, e.cfg, data)
+	if stream {
+		for _, line := range bytes.Split(data, []byte("\n")) {
+			if detail, ok := helps.ParseClaudeStreamUsage(line); ok {
+				reporter.Publish(ctx, detail)
+			}
+		}
+	} else {
+		reporter.Publish(ctx, helps.ParseClaudeUsage(data))
+	}
+
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, opts.OriginalRequest, body, data, &param)
+	resp = cliproxyexecutor.Response{Payload: out, Headers: httpResp.Header.Clone()}
+	return resp, nil
+}
+
+// executeClaudeStreamWithServiceAccount streams a response from a Claude model on Vertex AI.
+func (e *GeminiVertexExecutor) executeClaudeStreamWithServiceAccount(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, projectID, location string, saJSON []byte) (_ *cliproxyexecutor.StreamResult, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := helps.NewUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+	from := opts.SourceFormat
+	if req.Payload, err = helps.InlineImageURLs(ctx, e.cfg, e.Identifier(), from, req.Payload); err != nil {
+		return nil, err
+	}
+	to := sdktranslator.FromString("claude")
+	body, err := translateClaudeRequest(ctx, e.cfg, e.Identifier(), req, opts, baseModel, true)
+	if err != nil {
+		return nil, err
+	}
+
+	httpResp, err := e.sendClaude(ctx, auth, vertexClaudeURL(projectID, location, baseModel, true), body, true, saJSON)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan cliproxyexecutor.StreamChunk)
+	lifecycle.Go(ctx, "vertex.claude.stream", func() {
+		defer close(out)
+		defer func() {
+			if errClose := httpResp.Body.Close(); errClose != nil {
+				log.Errorf("vertex executor: close response body error: %v", errClose)
+			}
+		}()
+
+		scanner := bufio.NewScanner(httpResp.Body)
+		scanner.Buffer(nil, streamScannerBuffer)
+		var param any
+		usageEmulator := helps.NewStreamUsageEmulator(from, baseModel, req.Payload)
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			helps.AppendAPIResponseChunk(ctx, e.cfg, line)
+			if detail, ok := helps.ParseClaudeStreamUsage(line); ok {
+				reporter.Publish(ctx, detail)
+			}
+			// Claude clients receive the server-sent events as they are.
+			if from == to {
+				cloned := make([]byte, len(line)+1)
+				copy(cloned, line)
+				cloned[len(line)] = '\n'
+				out <- cliproxyexecutor.StreamChunk{Payload: cloned}
+				continue
+			}
+			chunks := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, body, bytes.Clone(line), &param)
+			for i := range chunks {
+				usageEmulator.Observe(chunks[i])
+				out <- cliproxyexecutor.StreamChunk{Payload: chunks[i]}
+			}
+		}
+		if errScan := scanner.Err(); errScan != nil {
+			helps.RecordAPIResponseError(ctx, e.cfg, errScan)
+			reporter.PublishFailure(ctx, errScan)
+			out <- cliproxyexecutor.StreamChunk{Err: errScan}
+		} else if from != to {
+			if usageChunk := usageEmulator.Finish(); usageChunk != nil {
+				out <- cliproxyexecutor.StreamChunk{Payload: usageChunk}
+			}
+		}
+	})
+	return &cliproxyexecutor.StreamResult{Headers: httpResp.Header.Clone(), Chunks: out}, nil
+}
+
+// sendClaude posts a Claude Messages request to url and returns the successful response.
+func (e *GeminiVertexExecutor) sendClaude(ctx context.Context, auth *cliproxyauth.Auth, url string, body []byte, stream bool, saJSON []byte) (*http.Response, error) {
+	payload, betas := vertexClaudeBody(body, stream)
+	httpReq, errNewReq := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if errNewReq != nil {
+		return nil, errNewReq
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	} else {
+		httpReq.Header.Set("Accept", "application/json")
+	}
+	if len(betas) > 0 {
+		httpReq.Header.Set("Anthropic-Beta", strings.Join(betas, ","))
+	}
+	token, errTok := vertexAccessToken(ctx, e.cfg, auth, saJSON)
+	if errTok != nil {
+		log.Errorf("vertex executor: access token error: %v", errTok)
+		return nil, statusErr{code: http.StatusInternalServerError, msg: "internal server error"}
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+token)
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	util.ApplyCustomHeadersFromAttrs(httpReq, attrs)
+
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	helps.RecordAPIRequest(ctx, e.cfg, helps.UpstreamRequestLog{
+		URL:       url,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      payload,
+		Provider:  e.Identifier(),
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpClient := helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpResp, errDo := helps.DoWithRetry(ctx, e.cfg, httpClient, httpReq)
+	if errDo != nil {
+		helps.RecordAPIResponseError(ctx, e.cfg, errDo)
+		return nil, errDo
+	}
+	helps.RecordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		b, _ := io.ReadAll(httpResp.Body)
+		helps.AppendAPIResponseChunk(ctx, e.cfg, b)
+		helps.LogWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, helps.SummarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("vertex executor: close response body error: %v", errClose)
+		}
+		return nil, statusErr{code: httpResp.StatusCode, msg: string(b)}
+	}
+	return httpResp, nil
+}
@@ -0,0 +1,190 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+	"golang.org/x/oauth2"
+)
+
+// seedVertexToken caches a static access token for auth so no Google credentials are needed.
+func seedVertexToken(t *testing.T, cfg *config.Config, auth *cliproxyauth.Auth, token string) {
+	t.Helper()
+	key := vertexTokenSourceKey(cfg, auth, nil)
+	vertexTokenSourcesMu.Lock()
+	vertexTokenSources[key] = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+	vertexTokenSourcesMu.Unlock()
+	t.Cleanup(func() {
+		vertexTokenSourcesMu.Lock()
+		delete(vertexTokenSources, key)
+		vertexTokenSourcesMu.Unlock()
+	})
+}
+
+func newVertexCredentialAuth(id string) *cliproxyauth.Auth {
+	return &cliproxyauth.Auth{
+		ID:         id,
+		Provider:   "vertex",
+		Attributes: map[string]string{"project_id": "my-project", "location": "us-east5"},
+	}
+}
+
+func TestGeminiVertexExecutor_ExecuteClaudeRawPredict(t *testing.T) {
+	cfg := &config.Config{}
+	exec := NewGeminiVertexExecutor(cfg)
+	auth := newVertexCredentialAuth("vertex-claude-raw")
+	seedVertexToken(t, cfg, auth, "ya29.token")
+
+	var gotURL, gotAuth, gotBeta string
+	var gotBody []byte
+	ctx := context.WithValue(context.Background(), "cliproxy.roundtripper", roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		gotURL = req.URL.String()
+		gotAuth = req.Header.Get("Authorization")
+		gotBeta = req.Header.Get("Anthropic-Beta")
+		gotBody, _ = io.ReadAll(req.Body)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body: io.NopCloser(strings.NewReader(`{"id":"msg_vrtx_1","type":"message","role":"assistant","model":"claude-sonnet-4@20250514",` +
+				`"content":[{"type":"text","text":"hi"}],"stop_reason":"end_turn","usage":{"input_tokens":3,"output_tokens":1}}`)),
+		}, nil
+	}))
+
+	payload := []byte(`{"model":"claude-sonnet-4@20250514","max_tokens":64,"betas":["context-1m-2025-08-07"],"messages":[{"role":"user","content":"hello"}]}`)
+	resp, err := exec.Execute(ctx, auth, cliproxyexecutor.Request{Model: "claude-sonnet-4@20250514", Payload: payload}, cliproxyexecutor.Options{
+		SourceFormat: sdktranslator.FromString("claude"),
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	wantURL := "https://us-east5-aiplatform.googleapis.com/v1/projects/my-project/locations/us-east5/publishers/anthropic/models/claude-sonnet-4@20250514:rawPredict"
+	if gotURL != wantURL {
+		t.Errorf("url = %s, want %s", gotURL, wantURL)
+	}
+	if gotAuth != "Bearer ya29.token" {
+		t.Errorf("Authorization = %q", gotAuth)
+	}
+	if gotBeta != "context-1m-2025-08-07" {
+		t.Errorf("Anthropic-Beta = %q", gotBeta)
+	}
+	if gjson.GetBytes(gotBody, "anthropic_version").String() != vertexAnthropicVersion {
+		t.Errorf("anthropic_version missing: %s", gotBody)
+	}
+	if gjson.GetBytes(gotBody, "model").Exists() || gjson.GetBytes(gotBody, "betas").Exists() {
+		t.Errorf("model and betas must not be sent in the body: %s", gotBody)
+	}
+	if !gjson.GetBytes(gotBody, "stream").Exists() || gjson.GetBytes(gotBody, "stream").Bool() {
+		t.Errorf("expected stream=false: %s", gotBody)
+	}
+	if got := gjson.GetBytes(resp.Payload, "content.0.text").String(); got != "hi" {
+		t.Errorf("response text = %q, payload %s", got, resp.Payload)
+	}
+}
+
+func TestGeminiVertexExecutor_ExecuteClaudeForOpenAIClient(t *testing.T) {
+	cfg := &config.Config{}
+	exec := NewGeminiVertexExecutor(cfg)
+	auth := newVertexCredentialAuth("vertex-claude-openai")
+	auth.Attributes["location"] = "global"
+	seedVertexToken(t, cfg, auth, "ya29.token")
+
+	var gotURL string
+	var gotBody []byte
+	sse := strings.Join([]string{
+		`event: message_start`,
+		`data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4@20250514","content":[],"usage":{"input_tokens":3,"output_tokens":0}}}`,
+		``,
+		`event: content_block_start`,
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		``,
+		`event: content_block_delta`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"hello there"}}`,
+		``,
+		`event: content_block_stop`,
+		`data: {"type":"content_block_stop","index":0}`,
+		``,
+		`event: message_delta`,
+		`data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":2}}`,
+		``,
+		`event: message_stop`,
+		`data: {"type":"message_stop"}`,
+		``,
+	}, "\n")
+	ctx := context.WithValue(context.Background(), "cliproxy.roundtripper", roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		gotURL = req.URL.String()
+		gotBody, _ = io.ReadAll(req.Body)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"text/event-stream"}},
+			Body:       io.NopCloser(strings.NewReader(sse)),
+		}, nil
+	}))
+
+	payload := []byte(`{"model":"claude-sonnet-4@20250514","messages":[{"role":"user","content":"hello"}]}`)
+	resp, err := exec.Execute(ctx, auth, cliproxyexecutor.Request{Model: "claude-sonnet-4@20250514", Payload: payload}, cliproxyexecutor.Options{
+		SourceFormat:    sdktranslator.FromString("openai"),
+		OriginalRequest: payload,
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if !strings.HasPrefix(gotURL, "https://aiplatform.googleapis.com/v1/projects/my-project/locations/global/") || !strings.HasSuffix(gotURL, ":streamRawPredict") {
+		t.Errorf("unexpected url %s", gotURL)
+	}
+	if !gjson.GetBytes(gotBody, "stream").Bool() {
+		t.Errorf("expected stream=true: %s", gotBody)
+	}
+	if got := gjson.GetBytes(resp.Payload, "choices.0.message.content").String(); got != "hello there" {
+		t.Errorf("content = %q, payload %s", got, resp.Payload)
+	}
+}
+
+func TestVertexConfigCreds(t *testing.T) {
+	auth := newVertexCredentialAuth("vertex-creds")
+	project, location, saJSON, err := vertexCreds(auth)
+	if err != nil {
+		t.Fatalf("vertexCreds() error = %v", err)
+	}
+	if project != "my-project" || location != "us-east5" || saJSON != nil {
+		t.Errorf("got %s %s %s, want Application Default Credentials for my-project in us-east5", project, location, saJSON)
+	}
+
+	path := filepath.Join(t.TempDir(), "wif.json")
+	external := `{"type":"external_account","audience":"//iam.googleapis.com/projects/1/locations/global/workloadIdentityPools/p/providers/q"}`
+	if errWrite := os.WriteFile(path, []byte(external), 0o600); errWrite != nil {
+		t.Fatal(errWrite)
+	}
+	auth.Attributes["credentials_file"] = path
+	if _, _, saJSON, err = vertexCreds(auth); err != nil || string(saJSON) != external {
+		t.Errorf("vertexCreds() = %s, %v; want the external_account file as is", saJSON, err)
+	}
+
+	auth.Attributes["credentials_file"] = filepath.Join(t.TempDir(), "missing.json")
+	if _, _, _, err = vertexCreds(auth); err == nil {
+		t.Error("expected an error for a missing credentials file")
+	}
+}
+
+func TestVertexTokenStateShouldRefresh(t *testing.T) {
+	now := time.Now()
+	if (vertexTokenState{expiry: now.Add(time.Hour)}).ShouldRefresh(now, nil) {
+		t.Error("fresh token should not be refreshed")
+	}
+	if !(vertexTokenState{expiry: now.Add(time.Minute)}).ShouldRefresh(now, nil) {
+		t.Error("token expiring within the refresh lead should be refreshed")
+	}
+	if (vertexTokenState{}).ShouldRefresh(now, nil) {
+		t.Error("token without expiry should not be refreshed")
+	}
+}
@@ -0,0 +1,538 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// sqliteDriverNames lists the database/sql driver names of SQLite drivers, in order of
+// preference. The pure-Go modernc.org/sqlite driver registers "sqlite" and is linked into builds
+// with the sqlitestore tag; "sqlite3" is registered by github.com/mattn/go-sqlite3 in custom cgo
+// builds.
+var sqliteDriverNames = []string{"sqlite", "sqlite3"}
+
+// SQLiteStoreConfig captures configuration required to initialize a SQLite-backed store.
+type SQLiteStoreConfig struct {
+	// Path is the SQLite database file.
+	Path string
+	// Driver overrides the database/sql driver name; it defaults to the first linked driver
+	// of sqliteDriverNames.
+	Driver      string
+	ConfigTable string
+	AuthTable   string
+	SpoolDir    string
+}
+
+// SQLiteStore persists configuration and authentication metadata in a single SQLite database
+// file while mirroring data to a local workspace so existing file-based workflows continue to
+// operate. It suits deployments that mount one persistent volume but want a single file to
+// back up instead of an auth directory.
+type SQLiteStore struct {
+	db         *sql.DB
+	cfg        SQLiteStoreConfig
+	spoolRoot  string
+	configPath string
+	authDir    string
+	mu         sync.Mutex
+}
+
+// NewSQLiteStore opens the SQLite database and prepares the local workspace.
+func NewSQLiteStore(ctx context.Context, cfg SQLiteStoreConfig) (*SQLiteStore, error) {
+	dbPath := strings.TrimSpace(cfg.Path)
+	if dbPath == "" {
+		return nil, fmt.Errorf("sqlite store: database path is required")
+	}
+	cfg.Path = dbPath
+	driver, err := resolveSQLiteDriver(cfg.Driver)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Driver = driver
+	if cfg.ConfigTable == "" {
+		cfg.ConfigTable = defaultConfigTable
+	}
+	if cfg.AuthTable == "" {
+		cfg.AuthTable = defaultAuthTable
+	}
+
+	spoolRoot := strings.TrimSpace(cfg.SpoolDir)
+	if spoolRoot == "" {
+		spoolRoot = filepath.Join(filepath.Dir(dbPath), "sqlitestore")
+	}
+	absSpool, err := filepath.Abs(spoolRoot)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite store: resolve spool directory: %w", err)
+	}
+	configDir := filepath.Join(absSpool, "config")
+	authDir := filepath.Join(absSpool, "auths")
+	if err = os.MkdirAll(configDir, 0o700); err != nil {
+		return nil, fmt.Errorf("sqlite store: create config directory: %w", err)
+	}
+	if err = os.MkdirAll(authDir, 0o700); err != nil {
+		return nil, fmt.Errorf("sqlite store: create auth directory: %w", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(dbPath), 0o700); err != nil {
+		return nil, fmt.Errorf("sqlite store: create database directory: %w", err)
+	}
+
+	db, err := sql.Open(cfg.Driver, cfg.Path)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite store: open database: %w", err)
+	}
+	// SQLite serializes writers; a single connection avoids "database is locked" errors.
+	db.SetMaxOpenConns(1)
+	if err = db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("sqlite store: ping database: %w", err)
+	}
+
+	return &SQLiteStore{
+		db:         db,
+		cfg:        cfg,
+		spoolRoot:  absSpool,
+		configPath: filepath.Join(configDir, "config.yaml"),
+		authDir:    authDir,
+	}, nil
+}
+
+// resolveSQLiteDriver returns the driver to open the database with.
+func resolveSQLiteDriver(preferred string) (string, error) {
+	registered := sql.Drivers()
+	if preferred = strings.TrimSpace(preferred); preferred != "" {
+		if !slices.Contains(registered, preferred) {
+			return "", fmt.Errorf("sqlite store: database driver %q is not registered", preferred)
+		}
+		return preferred, nil
+	}
+	for _, name := range sqliteDriverNames {
+		if slices.Contains(registered, name) {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("sqlite store: no SQLite driver is linked into this binary (build with -tags sqlitestore, expected one of %s)", strings.Join(sqliteDriverNames, ", "))
+}
+
+// Close releases the underlying database connection.
+func (s *SQLiteStore) Close() error {
+	if s == nil || s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// EnsureSchema creates the required tables.
+func (s *SQLiteStore) EnsureSchema(ctx context.Context) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("sqlite store: not initialized")
+	}
+	for _, table := range []string{s.cfg.ConfigTable, s.cfg.AuthTable} {
+		if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+			CREATE TABLE IF NOT EXISTS %s (
+				id TEXT PRIMARY KEY,
+				content TEXT NOT NULL,
+				created_at TEXT NOT NULL,
+				updated_at TEXT NOT NULL
+			)
+		`, quoteIdentifier(table))); err != nil {
+			return fmt.Errorf("sqlite store: create table %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// Bootstrap synchronizes configuration and auth records between SQLite and the local workspace.
+func (s *SQLiteStore) Bootstrap(ctx context.Context, exampleConfigPath string) error {
+	if err := s.EnsureSchema(ctx); err != nil {
+		return err
+	}
+	if err := s.syncConfigFromDatabase(ctx, exampleConfigPath); err != nil {
+		return err
+	}
+	return s.syncAuthFromDatabase(ctx)
+}
+
+// ConfigPath returns the managed configuration file path inside the spool directory.
+func (s *SQLiteStore) ConfigPath() string {
+	if s == nil {
+		return ""
+	}
+	return s.configPath
+}
+
+// AuthDir returns the local directory containing mirrored auth files.
+func (s *SQLiteStore) AuthDir() string {
+	if s == nil {
+		return ""
+	}
+	return s.authDir
+}
+
+// WorkDir exposes the root spool directory used for mirroring.
+func (s *SQLiteStore) WorkDir() string {
+	if s == nil {
+		return ""
+	}
+	return s.spoolRoot
+}
+
+// SetBaseDir implements the optional interface used by authenticators; it is a no-op because
+// the SQLite-backed store controls its own workspace.
+func (s *SQLiteStore) SetBaseDir(string) {}
+
+// Save persists authentication metadata to disk and SQLite.
+func (s *SQLiteStore) Save(ctx context.Context, auth *cliproxyauth.Auth) (string, error) {
+	if auth == nil {
+		return "", fmt.Errorf("sqlite store: auth is nil")
+	}
+	path, err := s.resolveAuthPath(auth)
+	if err != nil {
+		return "", err
+	}
+	if auth.Disabled {
+		if _, statErr := os.Stat(path); errors.Is(statErr, fs.ErrNotExist) {
+			return "", nil
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", fmt.Errorf("sqlite store: create auth directory: %w", err)
+	}
+	switch {
+	case auth.Storage != nil:
+		if err = auth.Storage.SaveTokenToFile(path); err != nil {
+			return "", err
+		}
+	case auth.Metadata != nil:
+		raw, errMarshal := json.Marshal(auth.Metadata)
+		if errMarshal != nil {
+			return "", fmt.Errorf("sqlite store: marshal metadata: %w", errMarshal)
+		}
+		if existing, errRead := os.ReadFile(path); errRead == nil {
+			if jsonEqual(existing, raw) {
+				return path, nil
+			}
+		} else if !errors.Is(errRead, fs.ErrNotExist) {
+			return "", fmt.Errorf("sqlite store: read existing metadata: %w", errRead)
+		}
+		tmp := path + ".tmp"
+		if errWrite := os.WriteFile(tmp, raw, 0o600); errWrite != nil {
+			return "", fmt.Errorf("sqlite store: write temp auth file: %w", errWrite)
+		}
+		if errRename := os.Rename(tmp, path); errRename != nil {
+			return "", fmt.Errorf("sqlite store: rename auth file: %w", errRename)
+		}
+	default:
+		return "", fmt.Errorf("sqlite store: nothing to persist for %s", auth.ID)
+	}
+
+	if auth.Attributes == nil {
+		auth.Attributes = make(map[string]string)
+	}
+	auth.Attributes["path"] = path
+	if strings.TrimSpace(auth.FileName) == "" {
+		auth.FileName = auth.ID
+	}
+
+	relID, err := s.relativeAuthID(path)
+	if err != nil {
+		return "", err
+	}
+	if err = s.syncAuthFile(ctx, relID, path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// List enumerates all auth records stored in SQLite.
+func (s *SQLiteStore) List(ctx context.Context) ([]*cliproxyauth.Auth, error) {
+	query := fmt.Sprintf("SELECT id, content, created_at, updated_at FROM %s ORDER BY id", quoteIdentifier(s.cfg.AuthTable))
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite store: list auth: %w", err)
+	}
+	defer rows.Close()
+
+	auths := make([]*cliproxyauth.Auth, 0, 32)
+	for rows.Next() {
+		var id, payload, createdAt, updatedAt string
+		if err = rows.Scan(&id, &payload, &createdAt, &updatedAt); err != nil {
+			return nil, fmt.Errorf("sqlite store: scan auth row: %w", err)
+		}
+		path, errPath := s.absoluteAuthPath(id)
+		if errPath != nil {
+			log.WithError(errPath).Warnf("sqlite store: skipping auth %s outside spool", id)
+			continue
+		}
+		metadata := make(map[string]any)
+		if err = json.Unmarshal([]byte(payload), &metadata); err != nil {
+			log.WithError(err).Warnf("sqlite store: skipping auth %s with invalid json", id)
+			continue
+		}
+		provider := strings.TrimSpace(valueAsString(metadata["type"]))
+		if provider == "" {
+			provider = "unknown"
+		}
+		attr := map[string]string{"path": path}
+		if email := strings.TrimSpace(valueAsString(metadata["email"])); email != "" {
+			attr["email"] = email
+		}
+		auth := &cliproxyauth.Auth{
+			ID:         normalizeAuthID(id),
+			Provider:   provider,
+			FileName:   normalizeAuthID(id),
+			Label:      labelFor(metadata),
+			Status:     cliproxyauth.StatusActive,
+			Attributes: attr,
+			Metadata:   metadata,
+			CreatedAt:  parseSQLiteTime(createdAt),
+			UpdatedAt:  parseSQLiteTime(updatedAt),
+		}
+		cliproxyauth.ApplyCustomHeadersFromMetadata(auth)
+		auths = append(auths, auth)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite store: iterate auth rows: %w", err)
+	}
+	return auths, nil
+}
+
+// Delete removes an auth file and the corresponding database record.
+func (s *SQLiteStore) Delete(ctx context.Context, id string) error {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return fmt.Errorf("sqlite store: id is empty")
+	}
+	path := id
+	if !filepath.IsAbs(id) {
+		abs, errPath := s.absoluteAuthPath(id)
+		if errPath != nil {
+			return errPath
+		}
+		path = abs
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("sqlite store: delete auth file: %w", err)
+	}
+	relID, err := s.relativeAuthID(path)
+	if err != nil {
+		return err
+	}
+	return s.deleteRecord(ctx, s.cfg.AuthTable, relID)
+}
+
+// PersistAuthFiles stores the provided auth file changes in SQLite.
+func (s *SQLiteStore) PersistAuthFiles(ctx context.Context, _ string, paths ...string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range paths {
+		trimmed := strings.TrimSpace(p)
+		if trimmed == "" {
+			continue
+		}
+		if !filepath.IsAbs(trimmed) {
+			trimmed = filepath.Join(s.authDir, trimmed)
+		}
+		relID, err := s.relativeAuthID(trimmed)
+		if err != nil {
+			log.WithError(err).Warnf("sqlite store: ignoring auth path %s", p)
+			continue
+		}
+		if err = s.syncAuthFile(ctx, relID, trimmed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PersistConfig mirrors the local configuration file to SQLite.
+func (s *SQLiteStore) PersistConfig(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.configPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return s.deleteRecord(ctx, s.cfg.ConfigTable, defaultConfigKey)
+		}
+		return fmt.Errorf("sqlite store: read config file: %w", err)
+	}
+	return s.upsertRecord(ctx, s.cfg.ConfigTable, defaultConfigKey, normalizeLineEndings(string(data)))
+}
+
+// syncConfigFromDatabase writes the stored config to disk or seeds the database from template.
+func (s *SQLiteStore) syncConfigFromDatabase(ctx context.Context, exampleConfigPath string) error {
+	query := fmt.Sprintf("SELECT content FROM %s WHERE id = ?", quoteIdentifier(s.cfg.ConfigTable))
+	var content string
+	err := s.db.QueryRowContext(ctx, query, defaultConfigKey).Scan(&content)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		if _, errStat := os.Stat(s.configPath); errors.Is(errStat, fs.ErrNotExist) {
+			if exampleConfigPath != "" {
+				if errCopy := misc.CopyConfigTemplate(exampleConfigPath, s.configPath); errCopy != nil {
+					return fmt.Errorf("sqlite store: copy example config: %w", errCopy)
+				}
+			} else if errWrite := os.WriteFile(s.configPath, []byte{}, 0o600); errWrite != nil {
+				return fmt.Errorf("sqlite store: create empty config: %w", errWrite)
+			}
+		}
+		data, errRead := os.ReadFile(s.configPath)
+		if errRead != nil {
+			return fmt.Errorf("sqlite store: read local config: %w", errRead)
+		}
+		return s.upsertRecord(ctx, s.cfg.ConfigTable, defaultConfigKey, normalizeLineEndings(string(data)))
+	case err != nil:
+		return fmt.Errorf("sqlite store: load config from database: %w", err)
+	default:
+		if err = os.WriteFile(s.configPath, []byte(normalizeLineEndings(content)), 0o600); err != nil {
+			return fmt.Errorf("sqlite store: write config to spool: %w", err)
+		}
+		return nil
+	}
+}
+
+// syncAuthFromDatabase populates the local auth directory from SQLite data.
+func (s *SQLiteStore) syncAuthFromDatabase(ctx context.Context) error {
+	query := fmt.Sprintf("SELECT id, content FROM %s", quoteIdentifier(s.cfg.AuthTable))
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("sqlite store: load auth from database: %w", err)
+	}
+	defer rows.Close()
+
+	if err = os.RemoveAll(s.authDir); err != nil {
+		return fmt.Errorf("sqlite store: reset auth directory: %w", err)
+	}
+	if err = os.MkdirAll(s.authDir, 0o700); err != nil {
+		return fmt.Errorf("sqlite store: recreate auth directory: %w", err)
+	}
+	for rows.Next() {
+		var id, payload string
+		if err = rows.Scan(&id, &payload); err != nil {
+			return fmt.Errorf("sqlite store: scan auth row: %w", err)
+		}
+		path, errPath := s.absoluteAuthPath(id)
+		if errPath != nil {
+			log.WithError(errPath).Warnf("sqlite store: skipping auth %s outside spool", id)
+			continue
+		}
+		if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return fmt.Errorf("sqlite store: create auth subdir: %w", err)
+		}
+		if err = os.WriteFile(path, []byte(payload), 0o600); err != nil {
+			return fmt.Errorf("sqlite store: write auth file: %w", err)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("sqlite store: iterate auth rows: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) syncAuthFile(ctx context.Context, relID, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return s.deleteRecord(ctx, s.cfg.AuthTable, relID)
+		}
+		return fmt.Errorf("sqlite store: read auth file: %w", err)
+	}
+	if len(data) == 0 {
+		return s.deleteRecord(ctx, s.cfg.AuthTable, relID)
+	}
+	return s.upsertRecord(ctx, s.cfg.AuthTable, relID, string(data))
+}
+
+func (s *SQLiteStore) upsertRecord(ctx context.Context, table, id, content string) error {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	query := fmt.Sprintf(`
+		INSERT INTO %s (id, content, created_at, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (id)
+		DO UPDATE SET content = excluded.content, updated_at = excluded.updated_at
+	`, quoteIdentifier(table))
+	if _, err := s.db.ExecContext(ctx, query, id, content, now, now); err != nil {
+		return fmt.Errorf("sqlite store: upsert %s record: %w", table, err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) deleteRecord(ctx context.Context, table, id string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = ?", quoteIdentifier(table))
+	if _, err := s.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("sqlite store: delete %s record: %w", table, err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) resolveAuthPath(auth *cliproxyauth.Auth) (string, error) {
+	if auth.Attributes != nil {
+		if p := strings.TrimSpace(auth.Attributes["path"]); p != "" {
+			return p, nil
+		}
+	}
+	if fileName := strings.TrimSpace(auth.FileName); fileName != "" {
+		if filepath.IsAbs(fileName) {
+			return fileName, nil
+		}
+		return filepath.Join(s.authDir, fileName), nil
+	}
+	if auth.ID == "" {
+		return "", fmt.Errorf("sqlite store: missing id")
+	}
+	if filepath.IsAbs(auth.ID) {
+		return auth.ID, nil
+	}
+	return filepath.Join(s.authDir, filepath.FromSlash(auth.ID)), nil
+}
+
+func (s *SQLiteStore) relativeAuthID(path string) (string, error) {
+	rel, err := filepath.Rel(s.authDir, filepath.Clean(path))
+	if err != nil {
+		return "", fmt.Errorf("sqlite store: compute relative path: %w", err)
+	}
+	if strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("sqlite store: path %s outside managed directory", path)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+func (s *SQLiteStore) absoluteAuthPath(id string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(id))
+	if strings.HasPrefix(clean, "..") || filepath.IsAbs(clean) {
+		return "", fmt.Errorf("sqlite store: invalid auth identifier %s", id)
+	}
+	return filepath.Join(s.authDir, clean), nil
+}
+
+func parseSQLiteTime(value string) time.Time {
+	parsed, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(value))
+	if err != nil {
+		return time.Time{}
+	}
+	return parsed
+}
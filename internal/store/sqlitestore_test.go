@@ -0,0 +1,94 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	_ "modernc.org/sqlite"
+)
+
+func TestSQLiteStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "cliproxy.db")
+
+	first, err := NewSQLiteStore(ctx, SQLiteStoreConfig{Path: dbPath, SpoolDir: filepath.Join(dir, "spool-a")})
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	if err = first.Bootstrap(ctx, ""); err != nil {
+		t.Fatalf("Bootstrap: %v", err)
+	}
+	if err = os.WriteFile(first.ConfigPath(), []byte("port: 8317\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err = first.PersistConfig(ctx); err != nil {
+		t.Fatalf("PersistConfig: %v", err)
+	}
+	auth := &cliproxyauth.Auth{ID: "team/a.json", Metadata: map[string]any{"type": "claude", "email": "a@example.com"}}
+	if _, err = first.Save(ctx, auth); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err = first.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// A second instance with a fresh spool restores both records from the database file.
+	second, err := NewSQLiteStore(ctx, SQLiteStoreConfig{Path: dbPath, SpoolDir: filepath.Join(dir, "spool-b")})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer func() { _ = second.Close() }()
+	if err = second.Bootstrap(ctx, ""); err != nil {
+		t.Fatalf("Bootstrap reopened: %v", err)
+	}
+	if data, errRead := os.ReadFile(second.ConfigPath()); errRead != nil || string(data) != "port: 8317\n" {
+		t.Fatalf("restored config = %q, %v", data, errRead)
+	}
+	auths, err := second.List(ctx)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(auths) != 1 || auths[0].ID != "team/a.json" || auths[0].Provider != "claude" || auths[0].Attributes["email"] != "a@example.com" {
+		t.Fatalf("auths = %+v", auths)
+	}
+	if _, errStat := os.Stat(filepath.Join(second.AuthDir(), "team", "a.json")); errStat != nil {
+		t.Fatalf("auth file not mirrored: %v", errStat)
+	}
+
+	if err = second.Delete(ctx, "team/a.json"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if auths, err = second.List(ctx); err != nil || len(auths) != 0 {
+		t.Fatalf("List after delete = %d, %v", len(auths), err)
+	}
+}
+
+func TestNewSQLiteStoreRejectsUnknownDriver(t *testing.T) {
+	_, err := NewSQLiteStore(context.Background(), SQLiteStoreConfig{Path: filepath.Join(t.TempDir(), "x.db"), Driver: "missing"})
+	if err == nil || !strings.Contains(err.Error(), "not registered") {
+		t.Fatalf("expected unregistered driver error, got %v", err)
+	}
+}
+
+func TestSQLiteStoreAuthPathsStayInsideSpool(t *testing.T) {
+	s := &SQLiteStore{authDir: filepath.Join(t.TempDir(), "auths")}
+	rel, err := s.relativeAuthID(filepath.Join(s.authDir, "team", "a.json"))
+	if err != nil || rel != "team/a.json" {
+		t.Fatalf("relativeAuthID = %q, %v", rel, err)
+	}
+	if _, err = s.relativeAuthID(filepath.Join(s.authDir, "..", "b.json")); err == nil {
+		t.Fatal("expected path outside auth dir to be rejected")
+	}
+	if _, err = s.absoluteAuthPath("../escape.json"); err == nil {
+		t.Fatal("expected escaping identifier to be rejected")
+	}
+	path, err := s.absoluteAuthPath("team/a.json")
+	if err != nil || path != filepath.Join(s.authDir, "team", "a.json") {
+		t.Fatalf("absoluteAuthPath = %q, %v", path, err)
+	}
+}
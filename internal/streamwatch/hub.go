@@ -0,0 +1,183 @@
+// Package streamwatch fans out the upstream and downstream chunks of in-flight requests to
+// management clients watching a single request ID, so stuck or misbehaving streams can be
+// debugged live without enabling request logging.
+package streamwatch
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+)
+
+const defaultBufferSize = 256
+
+// Direction tells whether a chunk was received from the provider or sent to the client.
+type Direction string
+
+const (
+	// Upstream chunks are received from the provider before translation.
+	Upstream Direction = "upstream"
+	// Downstream chunks are sent to the client after translation.
+	Downstream Direction = "downstream"
+)
+
+// Event is one message delivered to a watcher.
+type Event struct {
+	Seq       uint64    `json:"seq"`
+	Time      time.Time `json:"time"`
+	Direction Direction `json:"direction,omitempty"`
+	Data      string    `json:"data,omitempty"`
+	// Dropped counts chunks discarded since the previous event because the watcher fell behind.
+	Dropped uint64 `json:"dropped,omitempty"`
+	// Done marks the final event, sent when the request finished.
+	Done bool `json:"done,omitempty"`
+}
+
+// Subscription receives the events of one request.
+type Subscription struct {
+	hub       *Hub
+	requestID string
+	events    chan Event
+	seq       uint64
+	dropped   uint64
+	closed    bool
+}
+
+// Events returns the channel of watched events. It is closed after the final event or when
+// the subscription is closed.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close detaches the subscription from the hub.
+func (s *Subscription) Close() {
+	s.hub.remove(s)
+}
+
+// Hub routes published chunks to the subscriptions of their request.
+type Hub struct {
+	mu         sync.Mutex
+	subs       map[string]map[*Subscription]struct{}
+	watchers   atomic.Int64
+	redact     atomic.Bool
+	bufferSize atomic.Int64
+}
+
+// NewHub returns a hub without watchers.
+func NewHub() *Hub {
+	h := &Hub{subs: make(map[string]map[*Subscription]struct{})}
+	h.bufferSize.Store(defaultBufferSize)
+	return h
+}
+
+var defaultHub = NewHub()
+
+// Default returns the process-wide hub.
+func Default() *Hub {
+	return defaultHub
+}
+
+// Publish forwards chunk to the watchers of requestID on the default hub.
+func Publish(requestID string, direction Direction, chunk []byte) {
+	defaultHub.Publish(requestID, direction, chunk)
+}
+
+// Finish ends the watch of requestID on the default hub.
+func Finish(requestID string) {
+	defaultHub.Finish(requestID)
+}
+
+// Configure applies the stream watch settings.
+func (h *Hub) Configure(cfg config.StreamWatchConfig) {
+	h.redact.Store(cfg.RedactContent)
+	size := cfg.BufferSize
+	if size <= 0 {
+		size = defaultBufferSize
+	}
+	h.bufferSize.Store(int64(size))
+}
+
+// Subscribe starts watching requestID. The request does not need to have started yet.
+func (h *Hub) Subscribe(requestID string) *Subscription {
+	sub := &Subscription{hub: h, requestID: requestID, events: make(chan Event, h.bufferSize.Load())}
+	h.mu.Lock()
+	set := h.subs[requestID]
+	if set == nil {
+		set = make(map[*Subscription]struct{})
+		h.subs[requestID] = set
+	}
+	set[sub] = struct{}{}
+	h.mu.Unlock()
+	h.watchers.Add(1)
+	return sub
+}
+
+// Publish forwards chunk to the watchers of requestID. It is a no-op when nobody watches.
+func (h *Hub) Publish(requestID string, direction Direction, chunk []byte) {
+	if h.watchers.Load() == 0 || requestID == "" || len(chunk) == 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	set := h.subs[requestID]
+	if len(set) == 0 {
+		return
+	}
+	data := string(chunk)
+	if h.redact.Load() || logging.PrivacyModeEnabled() {
+		data = string(Redact(chunk))
+	}
+	now := time.Now()
+	for sub := range set {
+		sub.seq++
+		event := Event{Seq: sub.seq, Time: now, Direction: direction, Data: data, Dropped: sub.dropped}
+		select {
+		case sub.events <- event:
+			sub.dropped = 0
+		default:
+			sub.dropped++
+		}
+	}
+}
+
+// Finish sends the final event to the watchers of requestID and closes their subscriptions.
+// When a watcher's buffer is full the final event is dropped but the channel still closes.
+func (h *Hub) Finish(requestID string) {
+	if h.watchers.Load() == 0 || requestID == "" {
+		return
+	}
+	h.mu.Lock()
+	set := h.subs[requestID]
+	delete(h.subs, requestID)
+	for sub := range set {
+		sub.seq++
+		select {
+		case sub.events <- Event{Seq: sub.seq, Time: time.Now(), Dropped: sub.dropped, Done: true}:
+		default:
+		}
+		sub.closed = true
+		close(sub.events)
+		h.watchers.Add(-1)
+	}
+	h.mu.Unlock()
+}
+
+func (h *Hub) remove(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	if set := h.subs[sub.requestID]; set != nil {
+		delete(set, sub)
+		if len(set) == 0 {
+			delete(h.subs, sub.requestID)
+		}
+	}
+	close(sub.events)
+	h.watchers.Add(-1)
+}
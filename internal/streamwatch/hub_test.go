@@ -0,0 +1,68 @@
+package streamwatch
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestHubDeliversChunksUntilFinish(t *testing.T) {
+	hub := NewHub()
+	hub.Configure(config.StreamWatchConfig{BufferSize: 2})
+	hub.Publish("req-1", Upstream, []byte("ignored without watchers"))
+
+	sub := hub.Subscribe("req-1")
+	other := hub.Subscribe("req-2")
+	defer other.Close()
+	hub.Publish("req-1", Upstream, []byte("a"))
+	hub.Publish("req-1", Downstream, []byte("b"))
+	hub.Publish("req-1", Downstream, []byte("dropped"))
+
+	first := <-sub.Events()
+	if first.Seq != 1 || first.Direction != Upstream || first.Data != "a" {
+		t.Fatalf("first event = %+v", first)
+	}
+	second := <-sub.Events()
+	if second.Direction != Downstream || second.Data != "b" {
+		t.Fatalf("second event = %+v", second)
+	}
+	hub.Publish("req-1", Downstream, []byte("c"))
+	if third := <-sub.Events(); third.Data != "c" || third.Dropped != 1 || third.Seq != 4 {
+		t.Fatalf("third event = %+v, want one dropped chunk reported", third)
+	}
+
+	hub.Finish("req-1")
+	if done := <-sub.Events(); !done.Done {
+		t.Fatalf("final event = %+v", done)
+	}
+	if _, ok := <-sub.Events(); ok {
+		t.Fatal("events channel should close after finish")
+	}
+	sub.Close()
+	if got := hub.watchers.Load(); got != 1 {
+		t.Fatalf("watchers = %d, want 1", got)
+	}
+}
+
+func TestHubRedactsWhenConfigured(t *testing.T) {
+	hub := NewHub()
+	hub.Configure(config.StreamWatchConfig{RedactContent: true})
+	sub := hub.Subscribe("req")
+	defer sub.Close()
+	hub.Publish("req", Upstream, []byte(`data: {"type":"content_block_delta","delta":{"type":"text_delta","text":"secret"}}`))
+	event := <-sub.Events()
+	if strings.Contains(event.Data, "secret") || !strings.Contains(event.Data, "content_block_delta") {
+		t.Fatalf("redacted data = %s", event.Data)
+	}
+}
+
+func TestRedactKeepsStructure(t *testing.T) {
+	chunk := []byte("event: message_delta\ndata: {\"model\":\"m\",\"choices\":[{\"delta\":{\"content\":\"hello\"},\"finish_reason\":\"stop\",\"index\":0}]}\ndata: [DONE]\nplain text")
+	got := string(Redact(chunk))
+	for _, want := range []string{"event: message_delta", `"model":"m"`, `"content":"[redacted 5 bytes]"`, `"finish_reason":"stop"`, `"index":0`, "data: [DONE]", "[redacted 10 bytes]"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("redacted chunk missing %q:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package streamwatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+)
+
+// structuralKeys lists JSON keys whose string values describe the stream structure rather
+// than user content, so they survive redaction.
+var structuralKeys = map[string]bool{
+	"type":          true,
+	"event":         true,
+	"id":            true,
+	"object":        true,
+	"model":         true,
+	"modelVersion":  true,
+	"responseId":    true,
+	"role":          true,
+	"name":          true,
+	"status":        true,
+	"call_id":       true,
+	"item_id":       true,
+	"finish_reason": true,
+	"finishReason":  true,
+	"stop_reason":   true,
+}
+
+// Redact replaces free-form strings in a chunk with their length while keeping its structure.
+// SSE field names, structural JSON keys, numbers and booleans are preserved; lines that are
+// not JSON are replaced entirely.
+func Redact(chunk []byte) []byte {
+	lines := bytes.Split(chunk, []byte("\n"))
+	for i, line := range lines {
+		lines[i] = redactLine(line)
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
+func redactLine(line []byte) []byte {
+	trimmed := bytes.TrimSpace(line)
+	if len(trimmed) == 0 || bytes.HasPrefix(trimmed, []byte("event:")) || bytes.HasPrefix(trimmed, []byte(":")) {
+		return line
+	}
+	prefix := []byte(nil)
+	payload := trimmed
+	if after, ok := bytes.CutPrefix(trimmed, []byte("data:")); ok {
+		prefix = []byte("data: ")
+		payload = bytes.TrimSpace(after)
+		if bytes.Equal(payload, []byte("[DONE]")) {
+			return line
+		}
+	}
+	var value any
+	if errUnmarshal := json.Unmarshal(payload, &value); errUnmarshal != nil {
+		return append(prefix, redactedText(len(payload))...)
+	}
+	out, errMarshal := json.Marshal(redactValue("", value))
+	if errMarshal != nil {
+		return append(prefix, redactedText(len(payload))...)
+	}
+	return append(prefix, out...)
+}
+
+func redactValue(key string, value any) any {
+	switch typed := value.(type) {
+	case map[string]any:
+		for k, v := range typed {
+			typed[k] = redactValue(k, v)
+		}
+		return typed
+	case []any:
+		for i, v := range typed {
+			typed[i] = redactValue(key, v)
+		}
+		return typed
+	case string:
+		if structuralKeys[key] || typed == "" {
+			return typed
+		}
+		return redactedText(len(typed))
+	default:
+		return typed
+	}
+}
+
+func redactedText(n int) string {
+	return "[redacted " + strconv.Itoa(n) + " bytes]"
+}
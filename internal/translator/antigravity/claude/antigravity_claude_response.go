@@ -12,8 +12,6 @@ import (
 	"encoding/base64"
 	"fmt"
 	"strings"
-	"sync/atomic"
-	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
 	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
@@ -78,9 +76,6 @@ type Params struct {
 	ToolNameMap map[string]string
 }
 
-// toolUseIDCounter provides a process-wide unique counter for tool use identifiers.
-var toolUseIDCounter uint64
-
 // ConvertAntigravityResponseToClaude performs sophisticated streaming response format conversion.
 // This function implements a complex state machine that translates backend client responses
 // into Claude Code-compatible Server-Sent Events (SSE) format. It manages different response types
@@ -289,7 +284,7 @@ func ConvertAntigravityResponseToClaude(_ context.Context, _ string, originalReq
 				// This creates the structure for a function call in Claude Code format
 				// Create the tool use block with unique ID and function details
 				data := []byte(fmt.Sprintf(`{"type":"content_block_start","index":%d,"content_block":{"type":"tool_use","id":"","name":"","input":{}}}`, params.ResponseIndex))
-				data, _ = sjson.SetBytes(data, "content_block.id", util.SanitizeClaudeToolID(fmt.Sprintf("%s-%d-%d", fcName, time.Now().UnixNano(), atomic.AddUint64(&toolUseIDCounter, 1))))
+				data, _ = sjson.SetBytes(data, "content_block.id", util.SanitizeClaudeToolID(translatorcommon.GeminiToolCallID(functionCallResult, fcName)))
 				data, _ = sjson.SetBytes(data, "content_block.name", fcName)
 				appendEvent("content_block_start", string(data))
 
@@ -377,14 +372,7 @@ func resolveStopReason(params *Params) string {
 		return "tool_use"
 	}
 
-	switch params.FinishReason {
-	case "MAX_TOKENS":
-		return "max_tokens"
-	case "STOP", "FINISH_REASON_UNSPECIFIED", "UNKNOWN":
-		return "end_turn"
-	}
-
-	return "end_turn"
+	return translatorcommon.ClaudeStopReasonFromGemini(params.FinishReason)
 }
 
 // ConvertAntigravityResponseToClaudeNonStream converts a non-streaming Gemini CLI response to a non-streaming Claude response.
@@ -442,7 +430,6 @@ func ConvertAntigravityResponseToClaudeNonStream(_ context.Context, _ string, or
 	textBuilder := strings.Builder{}
 	thinkingBuilder := strings.Builder{}
 	thinkingSignature := ""
-	toolIDCounter := 0
 	hasToolCall := false
 
 	flushText := func() {
@@ -502,9 +489,8 @@ func ConvertAntigravityResponseToClaudeNonStream(_ context.Context, _ string, or
 				hasToolCall = true
 
 				name := util.RestoreSanitizedToolName(toolNameMap, functionCall.Get("name").String())
-				toolIDCounter++
 				toolBlock := []byte(`{"type":"tool_use","id":"","name":"","input":{}}`)
-				toolBlock, _ = sjson.SetBytes(toolBlock, "id", fmt.Sprintf("tool_%d", toolIDCounter))
+				toolBlock, _ = sjson.SetBytes(toolBlock, "id", util.SanitizeClaudeToolID(translatorcommon.GeminiToolCallID(functionCall, name)))
 				toolBlock, _ = sjson.SetBytes(toolBlock, "name", name)
 
 				if args := functionCall.Get("args"); args.Exists() && args.Raw != "" && gjson.Valid(args.Raw) && args.IsObject() {
@@ -526,14 +512,7 @@ func ConvertAntigravityResponseToClaudeNonStream(_ context.Context, _ string, or
 		stopReason = "tool_use"
 	} else {
 		if finish := root.Get("response.candidates.0.finishReason"); finish.Exists() {
-			switch finish.String() {
-			case "MAX_TOKENS":
-				stopReason = "max_tokens"
-			case "STOP", "FINISH_REASON_UNSPECIFIED", "UNKNOWN":
-				stopReason = "end_turn"
-			default:
-				stopReason = "end_turn"
-			}
+			stopReason = translatorcommon.ClaudeStopReasonFromGemini(finish.String())
 		}
 	}
 	responseJSON, _ = sjson.SetBytes(responseJSON, "stop_reason", stopReason)
@@ -6,7 +6,7 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini/common"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	log "github.com/sirupsen/logrus"
@@ -105,6 +105,11 @@ func ConvertOpenAIRequestToAntigravity(modelName string, inputRawJSON []byte, _
 		}
 	}
 
+	// Structured output: response_format -> request.generationConfig.responseMimeType/responseJsonSchema
+	if responseFormat, ok := translatorcommon.ResponseFormatFromOpenAI(gjson.ParseBytes(rawJSON)); ok {
+		out = responseFormat.ApplyToGemini(out, "request.generationConfig")
+	}
+
 	// messages -> systemInstruction + contents
 	messages := gjson.GetBytes(rawJSON, "messages")
 	if messages.IsArray() {
@@ -151,23 +156,10 @@ func ConvertOpenAIRequestToAntigravity(modelName string, inputRawJSON []byte, _
 
 			if (role == "system" || role == "developer") && len(arr) > 1 {
 				// system -> request.systemInstruction as a user message style
-				if content.Type == gjson.String {
+				for _, block := range translatorcommon.SystemPromptFromOpenAIMessage(content) {
 					out, _ = sjson.SetBytes(out, "request.systemInstruction.role", "user")
-					out, _ = sjson.SetBytes(out, fmt.Sprintf("request.systemInstruction.parts.%d.text", systemPartIndex), content.String())
+					out, _ = sjson.SetBytes(out, fmt.Sprintf("request.systemInstruction.parts.%d.text", systemPartIndex), block.Text)
 					systemPartIndex++
-				} else if content.IsObject() && content.Get("type").String() == "text" {
-					out, _ = sjson.SetBytes(out, "request.systemInstruction.role", "user")
-					out, _ = sjson.SetBytes(out, fmt.Sprintf("request.systemInstruction.parts.%d.text", systemPartIndex), content.Get("text").String())
-					systemPartIndex++
-				} else if content.IsArray() {
-					contents := content.Array()
-					if len(contents) > 0 {
-						out, _ = sjson.SetBytes(out, "request.systemInstruction.role", "user")
-						for j := 0; j < len(contents); j++ {
-							out, _ = sjson.SetBytes(out, fmt.Sprintf("request.systemInstruction.parts.%d.text", systemPartIndex), contents[j].Get("text").String())
-							systemPartIndex++
-						}
-					}
 				}
 			} else if role == "user" || ((role == "system" || role == "developer") && len(arr) == 1) {
 				// Build single user content node to avoid splitting into multiple contents
@@ -186,59 +178,35 @@ func ConvertOpenAIRequestToAntigravity(modelName string, inputRawJSON []byte, _
 							}
 							p++
 						case "image_url":
-							imageURL := item.Get("image_url.url").String()
-							if len(imageURL) > 5 {
-								pieces := strings.SplitN(imageURL[5:], ";", 2)
-								if len(pieces) == 2 && len(pieces[1]) > 7 {
-									mime := pieces[0]
-									data := pieces[1][7:]
-									node, _ = sjson.SetBytes(node, "parts."+itoa(p)+".inlineData.mimeType", mime)
-									node, _ = sjson.SetBytes(node, "parts."+itoa(p)+".inlineData.data", data)
-									node, _ = sjson.SetBytes(node, "parts."+itoa(p)+".thoughtSignature", geminiCLIFunctionThoughtSignature)
-									p++
-								}
+							if img, ok := translatorcommon.ImageFromURL(item.Get("image_url.url").String()); ok {
+								node, _ = sjson.SetRawBytes(node, "parts."+itoa(p), img.GeminiPart())
+								node, _ = sjson.SetBytes(node, "parts."+itoa(p)+".thoughtSignature", geminiCLIFunctionThoughtSignature)
+								p++
 							}
 						case "file":
-							filename := item.Get("file.filename").String()
-							fileData := item.Get("file.file_data").String()
-							ext := ""
-							if sp := strings.Split(filename, "."); len(sp) > 1 {
-								ext = sp[len(sp)-1]
+							doc, ok := translatorcommon.DocumentFromOpenAIFile(item.Get("file"))
+							if !ok {
+								break
 							}
-							if mimeType, ok := misc.MimeTypes[ext]; ok {
-								node, _ = sjson.SetBytes(node, "parts."+itoa(p)+".inlineData.mimeType", mimeType)
-								node, _ = sjson.SetBytes(node, "parts."+itoa(p)+".inlineData.data", fileData)
-								p++
-							} else {
-								log.Warnf("Unknown file name extension '%s' in user message, skip", ext)
+							part, errRender := doc.GeminiPart()
+							if errRender != nil {
+								log.Warnf("Unsupported file in user message, skip: %v", errRender)
+								break
 							}
+							node, _ = sjson.SetRawBytes(node, "parts."+itoa(p), part)
+							p++
 						case "input_audio":
-							audioData := item.Get("input_audio.data").String()
-							audioFormat := item.Get("input_audio.format").String()
-							if audioData != "" {
-								audioMimeMap := map[string]string{
-									"mp3":       "audio/mpeg",
-									"wav":       "audio/wav",
-									"ogg":       "audio/ogg",
-									"flac":      "audio/flac",
-									"aac":       "audio/aac",
-									"webm":      "audio/webm",
-									"pcm16":     "audio/pcm",
-									"g711_ulaw": "audio/basic",
-									"g711_alaw": "audio/basic",
-								}
-								mimeType := "audio/wav"
-								if audioFormat != "" {
-									if mapped, ok := audioMimeMap[audioFormat]; ok {
-										mimeType = mapped
-									} else {
-										mimeType = "audio/" + audioFormat
-									}
-								}
-								node, _ = sjson.SetBytes(node, "parts."+itoa(p)+".inlineData.mime_type", mimeType)
-								node, _ = sjson.SetBytes(node, "parts."+itoa(p)+".inlineData.data", audioData)
-								p++
+							audio, ok := translatorcommon.AudioFromOpenAIInput(item.Get("input_audio"))
+							if !ok {
+								break
+							}
+							part, errRender := audio.GeminiPart()
+							if errRender != nil {
+								log.Warnf("Unsupported audio in user message, skip: %v", errRender)
+								break
 							}
+							node, _ = sjson.SetRawBytes(node, "parts."+itoa(p), part)
+							p++
 						}
 					}
 				}
@@ -260,18 +228,11 @@ func ConvertOpenAIRequestToAntigravity(modelName string, inputRawJSON []byte, _
 							}
 							p++
 						case "image_url":
-							// If the assistant returned an inline data URL, preserve it for history fidelity.
-							imageURL := item.Get("image_url.url").String()
-							if len(imageURL) > 5 { // expect data:...
-								pieces := strings.SplitN(imageURL[5:], ";", 2)
-								if len(pieces) == 2 && len(pieces[1]) > 7 {
-									mime := pieces[0]
-									data := pieces[1][7:]
-									node, _ = sjson.SetBytes(node, "parts."+itoa(p)+".inlineData.mimeType", mime)
-									node, _ = sjson.SetBytes(node, "parts."+itoa(p)+".inlineData.data", data)
-									node, _ = sjson.SetBytes(node, "parts."+itoa(p)+".thoughtSignature", geminiCLIFunctionThoughtSignature)
-									p++
-								}
+							// If the assistant returned an image, preserve it for history fidelity.
+							if img, ok := translatorcommon.ImageFromURL(item.Get("image_url.url").String()); ok {
+								node, _ = sjson.SetRawBytes(node, "parts."+itoa(p), img.GeminiPart())
+								node, _ = sjson.SetBytes(node, "parts."+itoa(p)+".thoughtSignature", geminiCLIFunctionThoughtSignature)
+								p++
 							}
 						}
 					}
@@ -448,6 +409,11 @@ func ConvertOpenAIRequestToAntigravity(modelName string, inputRawJSON []byte, _
 		}
 	}
 
+	// tool_choice -> request.toolConfig.functionCallingConfig
+	if toolChoice, ok := translatorcommon.ToolChoiceFromOpenAI(gjson.GetBytes(rawJSON, "tool_choice")); ok {
+		out = toolChoice.ApplyToGemini(out, "request.")
+	}
+
 	return common.AttachDefaultSafetySettings(out, "request.safetySettings")
 }
 
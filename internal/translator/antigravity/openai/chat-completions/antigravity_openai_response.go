@@ -11,9 +11,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
-	"sync/atomic"
 	"time"
 
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	log "github.com/sirupsen/logrus"
 
@@ -30,11 +30,10 @@ type convertCliResponseToOpenAIChatParams struct {
 	UpstreamFinishReason string // Caches the upstream finish reason for final chunk
 	ToolIntentBuffer     *util.ToolIntentBuffer
 	SanitizedNameMap     map[string]string
+	// Candidates holds the Gemini translator state when several candidates were requested.
+	Candidates any
 }
 
-// functionCallIDCounter provides a process-wide unique counter for function call identifiers.
-var functionCallIDCounter uint64
-
 // ConvertAntigravityResponseToOpenAI translates a single chunk of a streaming response from the
 // Gemini CLI API format to the OpenAI Chat Completions streaming format.
 // It processes various Gemini CLI event types and transforms them into OpenAI-compatible JSON responses.
@@ -49,7 +48,7 @@ var functionCallIDCounter uint64
 //
 // Returns:
 //   - [][]byte: A slice of OpenAI-compatible JSON responses
-func ConvertAntigravityResponseToOpenAI(_ context.Context, _ string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) [][]byte {
+func ConvertAntigravityResponseToOpenAI(ctx context.Context, modelName string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) [][]byte {
 	if *param == nil {
 		*param = &convertCliResponseToOpenAIChatParams{
 			UnixTimestamp:    0,
@@ -69,6 +68,11 @@ func ConvertAntigravityResponseToOpenAI(_ context.Context, _ string, originalReq
 		return [][]byte{}
 	}
 
+	// Several candidates are streamed as separate choices by the Gemini translator.
+	if gjson.GetBytes(requestRawJSON, "request.generationConfig.candidateCount").Int() > 1 {
+		return ConvertGeminiResponseToOpenAI(ctx, modelName, originalRequestRawJSON, requestRawJSON, []byte(gjson.GetBytes(rawJSON, "response").Raw), &(*param).(*convertCliResponseToOpenAIChatParams).Candidates)
+	}
+
 	// Initialize the OpenAI SSE template.
 	template := []byte(`{"id":"","object":"chat.completion.chunk","created":12345,"model":"model","choices":[{"index":0,"delta":{"role":null,"content":null,"reasoning_content":null,"tool_calls":null},"finish_reason":null,"native_finish_reason":null}]}`)
 
@@ -174,7 +178,7 @@ func ConvertAntigravityResponseToOpenAI(_ context.Context, _ string, originalReq
 
 						for _, intent := range tagIntents {
 							functionCallTemplate := []byte(`{"id":"","index":0,"type":"function","function":{"name":"","arguments":""}}`)
-							functionCallTemplate, _ = sjson.SetBytes(functionCallTemplate, "id", fmt.Sprintf("%s-%d-%d", intent.Name, time.Now().UnixNano(), atomic.AddUint64(&functionCallIDCounter, 1)))
+							functionCallTemplate, _ = sjson.SetBytes(functionCallTemplate, "id", translatorcommon.NewToolCallID(intent.Name))
 							functionCallTemplate, _ = sjson.SetBytes(functionCallTemplate, "index", functionCallIndex)
 							functionCallTemplate, _ = sjson.SetBytes(functionCallTemplate, "function.name", intent.Name)
 							if len(intent.Arguments) > 0 {
@@ -204,7 +208,7 @@ func ConvertAntigravityResponseToOpenAI(_ context.Context, _ string, originalReq
 
 				functionCallTemplate := []byte(`{"id": "","index": 0,"type": "function","function": {"name": "","arguments": ""}}`)
 				fcName := util.RestoreSanitizedToolName((*param).(*convertCliResponseToOpenAIChatParams).SanitizedNameMap, functionCallResult.Get("name").String())
-				functionCallTemplate, _ = sjson.SetBytes(functionCallTemplate, "id", fmt.Sprintf("%s-%d-%d", fcName, time.Now().UnixNano(), atomic.AddUint64(&functionCallIDCounter, 1)))
+				functionCallTemplate, _ = sjson.SetBytes(functionCallTemplate, "id", translatorcommon.GeminiToolCallID(functionCallResult, fcName))
 				functionCallTemplate, _ = sjson.SetBytes(functionCallTemplate, "index", functionCallIndex)
 				functionCallTemplate, _ = sjson.SetBytes(functionCallTemplate, "function.name", fcName)
 				if fcArgsResult := functionCallResult.Get("args"); fcArgsResult.Exists() {
@@ -251,10 +255,8 @@ func ConvertAntigravityResponseToOpenAI(_ context.Context, _ string, originalReq
 		var finishReason string
 		if sawToolCall {
 			finishReason = "tool_calls"
-		} else if upstreamFinishReason == "MAX_TOKENS" {
-			finishReason = "max_tokens"
 		} else {
-			finishReason = "stop"
+			finishReason = translatorcommon.OpenAIFinishReasonFromGemini(upstreamFinishReason)
 		}
 		template, _ = sjson.SetBytes(template, "choices.0.finish_reason", finishReason)
 		template, _ = sjson.SetBytes(template, "choices.0.native_finish_reason", strings.ToLower(upstreamFinishReason))
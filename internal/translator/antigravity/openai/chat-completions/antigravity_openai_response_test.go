@@ -76,10 +76,10 @@ func TestFinishReasonMaxTokens(t *testing.T) {
 	chunk2 := []byte(`{"response":{"candidates":[{"finishReason":"MAX_TOKENS"}],"usageMetadata":{"promptTokenCount":10,"candidatesTokenCount":100,"totalTokenCount":110}}}`)
 	result2 := ConvertAntigravityResponseToOpenAI(ctx, "model", nil, nil, chunk2, &param)
 
-	// Verify finish_reason is "max_tokens"
+	// Verify finish_reason is "length"
 	fr := gjson.GetBytes(result2[0], "choices.0.finish_reason").String()
-	if fr != "max_tokens" {
-		t.Errorf("Expected finish_reason 'max_tokens', got: %s", fr)
+	if fr != "length" {
+		t.Errorf("Expected finish_reason 'length', got: %s", fr)
 	}
 }
 
@@ -126,3 +126,25 @@ func TestNoFinishReasonOnIntermediateChunks(t *testing.T) {
 		t.Errorf("Expected no finish_reason on intermediate chunk, got: %v", fr2)
 	}
 }
+
+func TestMultipleCandidatesStreamAsSeparateChoices(t *testing.T) {
+	var param any
+	request := []byte(`{"request":{"generationConfig":{"candidateCount":2}}}`)
+	chunk := []byte(`{"response":{"candidates":[{"index":0,"content":{"parts":[{"text":"a"}]}},{"index":1,"content":{"parts":[{"text":"b"}]},"finishReason":"STOP"}]}}`)
+
+	result := ConvertAntigravityResponseToOpenAI(context.Background(), "model", nil, request, chunk, &param)
+	if len(result) != 2 {
+		t.Fatalf("Expected 2 chunks, got %d", len(result))
+	}
+	for i, want := range []string{"a", "b"} {
+		if got := gjson.GetBytes(result[i], "choices.0.index").Int(); got != int64(i) {
+			t.Errorf("chunk %d: index = %d", i, got)
+		}
+		if got := gjson.GetBytes(result[i], "choices.0.delta.content").String(); got != want {
+			t.Errorf("chunk %d: content = %q, want %q", i, got, want)
+		}
+	}
+	if got := gjson.GetBytes(result[0], "choices.0.finish_reason").String(); got != "" {
+		t.Errorf("candidate 0 must not inherit the finish reason of candidate 1, got %q", got)
+	}
+}
@@ -6,16 +6,15 @@
 package gemini
 
 import (
-	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
-	"math/big"
 	"strings"
 
 	"github.com/google/uuid"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
@@ -34,7 +33,7 @@ var (
 // 1. Model name mapping and generation configuration extraction
 // 2. System instruction conversion to Claude Code format
 // 3. Message content conversion with proper role mapping
-// 4. Tool call and tool result handling with FIFO queue for ID matching
+// 4. Tool call and tool result handling paired by ID or name
 // 5. Image and file data conversion to Claude Code base64 format
 // 6. Tool declaration and tool choice configuration mapping
 //
@@ -67,24 +66,10 @@ func ConvertGeminiRequestToClaude(modelName string, inputRawJSON []byte, stream
 
 	root := gjson.ParseBytes(rawJSON)
 
-	// Helper for generating tool call IDs in the form: toolu_<alphanum>
-	// This ensures unique identifiers for tool calls in the Claude Code format
-	genToolCallID := func() string {
-		const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-		var b strings.Builder
-		// 24 chars random suffix for uniqueness
-		for i := 0; i < 24; i++ {
-			n, _ := rand.Int(rand.Reader, big.NewInt(int64(len(letters))))
-			b.WriteByte(letters[n.Int64()])
-		}
-		return "toolu_" + b.String()
-	}
-
-	// FIFO queue to store tool call IDs for matching with tool results
-	// Gemini uses sequential pairing across possibly multiple in-flight
-	// functionCalls, so we keep a FIFO queue of generated tool IDs and
-	// consume them in order when functionResponses arrive.
-	var pendingToolIDs []string
+	// Pairs functionResponses with the functionCalls they answer: by id when the client sent
+	// one, otherwise with the oldest unanswered call of the same name. Derived IDs are stable
+	// across turns, so the same history always yields the same tool_use IDs.
+	toolIDs := translatorcommon.NewToolCallCorrelator("toolu_")
 
 	// Model mapping to specify which Claude Code model to use
 	out, _ = sjson.SetBytes(out, "model", modelName)
@@ -205,26 +190,9 @@ func ConvertGeminiRequestToClaude(modelName string, inputRawJSON []byte, stream
 		}
 	}
 
-	// System instruction conversion to Claude Code format
-	if sysInstr := root.Get("system_instruction"); sysInstr.Exists() {
-		if parts := sysInstr.Get("parts"); parts.Exists() && parts.IsArray() {
-			var systemText strings.Builder
-			parts.ForEach(func(_, part gjson.Result) bool {
-				if text := part.Get("text"); text.Exists() {
-					if systemText.Len() > 0 {
-						systemText.WriteString("\n")
-					}
-					systemText.WriteString(text.String())
-				}
-				return true
-			})
-			if systemText.Len() > 0 {
-				// Create system message in Claude Code format
-				systemMessage := []byte(`{"role":"user","content":[{"type":"text","text":""}]}`)
-				systemMessage, _ = sjson.SetBytes(systemMessage, "content.0.text", systemText.String())
-				out, _ = sjson.SetRawBytes(out, "messages.-1", systemMessage)
-			}
-		}
+	// System instruction conversion to Claude system blocks
+	if system := translatorcommon.SystemPromptFromGemini(root); len(system) > 0 {
+		out, _ = sjson.SetRawBytes(out, "system", system.ClaudeBlocks())
 	}
 
 	// Contents conversion to messages with proper role mapping
@@ -262,11 +230,8 @@ func ConvertGeminiRequestToClaude(modelName string, inputRawJSON []byte, stream
 					if fc := part.Get("functionCall"); fc.Exists() && role == "assistant" {
 						toolUse := []byte(`{"type":"tool_use","id":"","name":"","input":{}}`)
 
-						// Generate a unique tool ID and enqueue it for later matching
-						// with the corresponding functionResponse
-						toolID := genToolCallID()
-						pendingToolIDs = append(pendingToolIDs, toolID)
-						toolUse, _ = sjson.SetBytes(toolUse, "id", toolID)
+						toolID := toolIDs.Call(fc.Get("id").String(), fc.Get("name").String())
+						toolUse, _ = sjson.SetBytes(toolUse, "id", util.SanitizeClaudeToolID(toolID))
 
 						if name := fc.Get("name"); name.Exists() {
 							toolUse, _ = sjson.SetBytes(toolUse, "name", name.String())
@@ -282,18 +247,8 @@ func ConvertGeminiRequestToClaude(modelName string, inputRawJSON []byte, stream
 					if fr := part.Get("functionResponse"); fr.Exists() {
 						toolResult := []byte(`{"type":"tool_result","tool_use_id":"","content":""}`)
 
-						// Attach the oldest queued tool_id to pair the response
-						// with its call. If the queue is empty, generate a new id.
-						var toolID string
-						if len(pendingToolIDs) > 0 {
-							toolID = pendingToolIDs[0]
-							// Pop the first element from the queue
-							pendingToolIDs = pendingToolIDs[1:]
-						} else {
-							// Fallback: generate new ID if no pending tool_use found
-							toolID = genToolCallID()
-						}
-						toolResult, _ = sjson.SetBytes(toolResult, "tool_use_id", toolID)
+						toolID := toolIDs.Result(fr.Get("id").String(), fr.Get("name").String())
+						toolResult, _ = sjson.SetBytes(toolResult, "tool_use_id", util.SanitizeClaudeToolID(toolID))
 
 						// Extract result content from the function response
 						if result := fr.Get("response.result"); result.Exists() {
@@ -305,25 +260,38 @@ func ConvertGeminiRequestToClaude(modelName string, inputRawJSON []byte, stream
 						return true
 					}
 
-					// Image content (inline_data) conversion to Claude Code format
-					if inlineData := part.Get("inline_data"); inlineData.Exists() {
-						imageContent := []byte(`{"type":"image","source":{"type":"base64","media_type":"","data":""}}`)
-						if mimeType := inlineData.Get("mime_type"); mimeType.Exists() {
-							imageContent, _ = sjson.SetBytes(imageContent, "source.media_type", mimeType.String())
-						}
-						if data := inlineData.Get("data"); data.Exists() {
-							imageContent, _ = sjson.SetBytes(imageContent, "source.data", data.String())
-						}
-						msg, _ = sjson.SetRawBytes(msg, "content.-1", imageContent)
+					// Image content (inline data or remote image files) conversion to Claude Code format
+					if img, ok := translatorcommon.ImageFromGeminiPart(part); ok {
+						msg, _ = sjson.SetRawBytes(msg, "content.-1", img.ClaudeBlock())
 						return true
 					}
 
-					// File data conversion to text content with file info
-					if fileData := part.Get("file_data"); fileData.Exists() {
+					// Document content (PDF or plain-text inline data and file references) conversion
+					if doc, ok := translatorcommon.DocumentFromGeminiPart(part); ok {
+						if docContent, errRender := doc.ClaudeBlock(); errRender == nil {
+							msg, _ = sjson.SetRawBytes(msg, "content.-1", docContent)
+							return true
+						}
+					}
+
+					// Other file data conversion to text content with file info
+					fileData := part.Get("file_data")
+					if !fileData.Exists() {
+						fileData = part.Get("fileData")
+					}
+					if fileData.Exists() {
 						// For file data, we'll convert to text content with file info
 						textContent := []byte(`{"type":"text","text":""}`)
-						fileInfo := "File: " + fileData.Get("file_uri").String()
-						if mimeType := fileData.Get("mime_type"); mimeType.Exists() {
+						fileURI := fileData.Get("file_uri")
+						if !fileURI.Exists() {
+							fileURI = fileData.Get("fileUri")
+						}
+						fileInfo := "File: " + fileURI.String()
+						mimeType := fileData.Get("mime_type")
+						if !mimeType.Exists() {
+							mimeType = fileData.Get("mimeType")
+						}
+						if mimeType.Exists() {
 							fileInfo += " (Type: " + mimeType.String() + ")"
 						}
 						textContent, _ = sjson.SetBytes(textContent, "text", fileInfo)
@@ -386,19 +354,8 @@ func ConvertGeminiRequestToClaude(modelName string, inputRawJSON []byte, stream
 	}
 
 	// Tool config mapping from Gemini format to Claude Code format
-	if toolConfig := root.Get("tool_config"); toolConfig.Exists() {
-		if funcCalling := toolConfig.Get("function_calling_config"); funcCalling.Exists() {
-			if mode := funcCalling.Get("mode"); mode.Exists() {
-				switch mode.String() {
-				case "AUTO":
-					out, _ = sjson.SetRawBytes(out, "tool_choice", []byte(`{"type":"auto"}`))
-				case "NONE":
-					out, _ = sjson.SetRawBytes(out, "tool_choice", []byte(`{"type":"none"}`))
-				case "ANY":
-					out, _ = sjson.SetRawBytes(out, "tool_choice", []byte(`{"type":"any"}`))
-				}
-			}
-		}
+	if toolChoice, ok := translatorcommon.ToolChoiceFromGemini(root); ok {
+		out = toolChoice.ApplyToClaude(out)
 	}
 
 	// Stream setting configuration
@@ -36,6 +36,7 @@ type ConvertAnthropicResponseToGeminiParams struct {
 	// Streaming state for tool_use assembly
 	// Keyed by content_block index from Claude SSE events
 	ToolUseNames map[int]string           // function/tool name per block index
+	ToolUseIDs   map[int]string           // tool_use id per block index
 	ToolUseArgs  map[int]*strings.Builder // accumulates partial_json across deltas
 }
 
@@ -110,6 +111,12 @@ func ConvertClaudeResponseToGemini(_ context.Context, modelName string, original
 				if name := cb.Get("name"); name.Exists() {
 					(*param).(*ConvertAnthropicResponseToGeminiParams).ToolUseNames[idx] = name.String()
 				}
+				if id := cb.Get("id").String(); id != "" {
+					if (*param).(*ConvertAnthropicResponseToGeminiParams).ToolUseIDs == nil {
+						(*param).(*ConvertAnthropicResponseToGeminiParams).ToolUseIDs = map[int]string{}
+					}
+					(*param).(*ConvertAnthropicResponseToGeminiParams).ToolUseIDs[idx] = id
+				}
 			}
 		}
 		return [][]byte{}
@@ -177,6 +184,9 @@ func ConvertClaudeResponseToGemini(_ context.Context, modelName string, original
 			if argsTrim != "" {
 				functionCall, _ = sjson.SetRawBytes(functionCall, "functionCall.args", []byte(argsTrim))
 			}
+			if id := (*param).(*ConvertAnthropicResponseToGeminiParams).ToolUseIDs[idx]; id != "" {
+				functionCall, _ = sjson.SetBytes(functionCall, "functionCall.id", id)
+			}
 			template, _ = sjson.SetRawBytes(template, "candidates.0.content.parts.-1", functionCall)
 			template, _ = sjson.SetBytes(template, "candidates.0.finishReason", "STOP")
 			(*param).(*ConvertAnthropicResponseToGeminiParams).LastStorageOutput = append([]byte(nil), template...)
@@ -187,6 +197,7 @@ func ConvertClaudeResponseToGemini(_ context.Context, modelName string, original
 			if (*param).(*ConvertAnthropicResponseToGeminiParams).ToolUseNames != nil {
 				delete((*param).(*ConvertAnthropicResponseToGeminiParams).ToolUseNames, idx)
 			}
+			delete((*param).(*ConvertAnthropicResponseToGeminiParams).ToolUseIDs, idx)
 			return [][]byte{template}
 		}
 		return [][]byte{}
@@ -195,18 +206,7 @@ func ConvertClaudeResponseToGemini(_ context.Context, modelName string, original
 		// Handle message-level changes (like stop reason and usage information)
 		if delta := root.Get("delta"); delta.Exists() {
 			if stopReason := delta.Get("stop_reason"); stopReason.Exists() {
-				switch stopReason.String() {
-				case "end_turn":
-					template, _ = sjson.SetBytes(template, "candidates.0.finishReason", "STOP")
-				case "tool_use":
-					template, _ = sjson.SetBytes(template, "candidates.0.finishReason", "STOP")
-				case "max_tokens":
-					template, _ = sjson.SetBytes(template, "candidates.0.finishReason", "MAX_TOKENS")
-				case "stop_sequence":
-					template, _ = sjson.SetBytes(template, "candidates.0.finishReason", "STOP")
-				default:
-					template, _ = sjson.SetBytes(template, "candidates.0.finishReason", "STOP")
-				}
+				template, _ = sjson.SetBytes(template, "candidates.0.finishReason", translatorcommon.GeminiFinishReasonFromClaude(stopReason.String()))
 			}
 		}
 
@@ -348,6 +348,12 @@ func ConvertClaudeResponseToGeminiNonStream(_ context.Context, modelName string,
 					if name := cb.Get("name"); name.Exists() {
 						newParam.ToolUseNames[idx] = name.String()
 					}
+					if id := cb.Get("id").String(); id != "" {
+						if newParam.ToolUseIDs == nil {
+							newParam.ToolUseIDs = map[int]string{}
+						}
+						newParam.ToolUseIDs[idx] = id
+					}
 				}
 			}
 			continue
@@ -409,6 +415,9 @@ func ConvertClaudeResponseToGeminiNonStream(_ context.Context, modelName string,
 				if argsTrim != "" {
 					functionCallJSON, _ = sjson.SetRawBytes(functionCallJSON, "functionCall.args", []byte(argsTrim))
 				}
+				if id := newParam.ToolUseIDs[idx]; id != "" {
+					functionCallJSON, _ = sjson.SetBytes(functionCallJSON, "functionCall.id", id)
+				}
 				allParts = append(allParts, functionCallJSON)
 				// cleanup used state for this index
 				if newParam.ToolUseArgs != nil {
@@ -417,6 +426,7 @@ func ConvertClaudeResponseToGeminiNonStream(_ context.Context, modelName string,
 				if newParam.ToolUseNames != nil {
 					delete(newParam.ToolUseNames, idx)
 				}
+				delete(newParam.ToolUseIDs, idx)
 			}
 
 		case "message_delta":
@@ -16,6 +16,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -164,27 +165,16 @@ func ConvertOpenAIRequestToClaude(modelName string, inputRawJSON []byte, stream
 
 	// Process messages and transform them to Claude Code format
 	if messages := root.Get("messages"); messages.Exists() && messages.IsArray() {
+		// System and developer messages become top-level system blocks, keeping their cache markers
+		var system translatorcommon.SystemPrompt
 		messageIndex := 0
 		messages.ForEach(func(_, message gjson.Result) bool {
 			role := message.Get("role").String()
 			contentResult := message.Get("content")
 
 			switch role {
-			case "system":
-				if contentResult.Exists() && contentResult.Type == gjson.String && contentResult.String() != "" {
-					textPart := []byte(`{"type":"text","text":""}`)
-					textPart, _ = sjson.SetBytes(textPart, "text", contentResult.String())
-					out, _ = sjson.SetRawBytes(out, "system.-1", textPart)
-				} else if contentResult.Exists() && contentResult.IsArray() {
-					contentResult.ForEach(func(_, part gjson.Result) bool {
-						if part.Get("type").String() == "text" {
-							textPart := []byte(`{"type":"text","text":""}`)
-							textPart, _ = sjson.SetBytes(textPart, "text", part.Get("text").String())
-							out, _ = sjson.SetRawBytes(out, "system.-1", textPart)
-						}
-						return true
-					})
-				}
+			case "system", "developer":
+				system = append(system, translatorcommon.SystemPromptFromOpenAIMessage(contentResult)...)
 			case "user", "assistant":
 				msg := []byte(`{"role":"","content":[]}`)
 				msg, _ = sjson.SetBytes(msg, "role", role)
@@ -263,11 +253,14 @@ func ConvertOpenAIRequestToClaude(modelName string, inputRawJSON []byte, stream
 			return true
 		})
 
+		if len(system) > 0 {
+			out, _ = sjson.SetRawBytes(out, "system", system.ClaudeBlocks())
+		}
+
 		// Preserve a minimal conversational turn for system-only inputs.
 		// Claude payloads with top-level system instructions but no messages are risky for downstream validation.
 		if messageIndex == 0 {
-			system := gjson.GetBytes(out, "system")
-			if system.Exists() && system.IsArray() && len(system.Array()) > 0 {
+			if len(system) > 0 {
 				fallbackMsg := []byte(`{"role":"user","content":[{"type":"text","text":""}]}`)
 				out, _ = sjson.SetRawBytes(out, "messages.-1", fallbackMsg)
 			}
@@ -290,6 +283,7 @@ func ConvertOpenAIRequestToClaude(modelName string, inputRawJSON []byte, stream
 				} else if parameters := function.Get("parametersJsonSchema"); parameters.Exists() {
 					anthropicTool, _ = sjson.SetRawBytes(anthropicTool, "input_schema", []byte(parameters.Raw))
 				}
+				anthropicTool = translatorcommon.WithCacheControl(anthropicTool, tool)
 
 				out, _ = sjson.SetRawBytes(out, "tools.-1", anthropicTool)
 				hasAnthropicTools = true
@@ -303,55 +297,42 @@ func ConvertOpenAIRequestToClaude(modelName string, inputRawJSON []byte, stream
 	}
 
 	// Tool choice mapping from OpenAI format to Claude Code format
-	if toolChoice := root.Get("tool_choice"); toolChoice.Exists() {
-		switch toolChoice.Type {
-		case gjson.String:
-			choice := toolChoice.String()
-			switch choice {
-			case "none":
-				// Don't set tool_choice, Claude Code will not use tools
-			case "auto":
-				out, _ = sjson.SetRawBytes(out, "tool_choice", []byte(`{"type":"auto"}`))
-			case "required":
-				out, _ = sjson.SetRawBytes(out, "tool_choice", []byte(`{"type":"any"}`))
-			}
-		case gjson.JSON:
-			// Specific tool choice mapping
-			if toolChoice.Get("type").String() == "function" {
-				functionName := toolChoice.Get("function.name").String()
-				toolChoiceJSON := []byte(`{"type":"tool","name":""}`)
-				toolChoiceJSON, _ = sjson.SetBytes(toolChoiceJSON, "name", functionName)
-				out, _ = sjson.SetRawBytes(out, "tool_choice", toolChoiceJSON)
-			}
-		default:
-		}
+	if toolChoice, ok := translatorcommon.ToolChoiceFromOpenAI(root.Get("tool_choice")); ok {
+		out = toolChoice.ApplyToClaude(out)
+	}
+
+	// Structured output: force a tool call whose input follows the requested schema
+	if responseFormat, ok := translatorcommon.ResponseFormatFromOpenAI(root); ok {
+		out = responseFormat.ApplyToClaude(out, root.Get("tool_choice").String() != "none")
+	}
+
+	if allowed, ok := translatorcommon.ParallelToolCallsFromOpenAI(root); ok && !allowed {
+		out = translatorcommon.DisableParallelToolUse(out)
 	}
 
 	return out
 }
 
+// convertOpenAIContentPartToClaudePart converts one Chat Completions content part, keeping the
+// cache_control marker that prompt-caching clients attach to it.
 func convertOpenAIContentPartToClaudePart(part gjson.Result) string {
 	switch part.Get("type").String() {
 	case "text":
 		textPart := []byte(`{"type":"text","text":""}`)
 		textPart, _ = sjson.SetBytes(textPart, "text", part.Get("text").String())
-		return string(textPart)
+		return string(translatorcommon.WithCacheControl(textPart, part))
 
 	case "image_url":
-		return convertOpenAIImageURLToClaudePart(part.Get("image_url.url").String())
+		imagePart := convertOpenAIImageURLToClaudePart(part.Get("image_url.url").String())
+		if imagePart == "" {
+			return ""
+		}
+		return string(translatorcommon.WithCacheControl([]byte(imagePart), part))
 
 	case "file":
-		fileData := part.Get("file.file_data").String()
-		if strings.HasPrefix(fileData, "data:") {
-			semicolonIdx := strings.Index(fileData, ";")
-			commaIdx := strings.Index(fileData, ",")
-			if semicolonIdx != -1 && commaIdx != -1 && commaIdx > semicolonIdx {
-				mediaType := strings.TrimPrefix(fileData[:semicolonIdx], "data:")
-				data := fileData[commaIdx+1:]
-				docPart := []byte(`{"type":"document","source":{"type":"base64","media_type":"","data":""}}`)
-				docPart, _ = sjson.SetBytes(docPart, "source.media_type", mediaType)
-				docPart, _ = sjson.SetBytes(docPart, "source.data", data)
-				return string(docPart)
+		if doc, ok := translatorcommon.DocumentFromOpenAIFile(part.Get("file")); ok {
+			if docPart, errRender := doc.ClaudeBlock(); errRender == nil {
+				return string(translatorcommon.WithCacheControl(docPart, part))
 			}
 		}
 	}
@@ -243,3 +243,97 @@ func TestConvertOpenAIRequestToClaude_SystemOnlyInputKeepsFallbackUserMessage(t
 		t.Fatalf("Expected fallback text %q, got %q", "", got)
 	}
 }
+
+func TestConvertOpenAIRequestToClaude_ResponseFormatForcesStructuredOutputTool(t *testing.T) {
+	inputJSON := `{
+		"model": "gpt-4.1",
+		"reasoning_effort": "high",
+		"messages": [{"role": "user", "content": "Name a colour"}],
+		"response_format": {
+			"type": "json_schema",
+			"json_schema": {
+				"name": "colour",
+				"schema": {"type": "object", "properties": {"name": {"type": "string"}}, "required": ["name"]}
+			}
+		}
+	}`
+
+	result := ConvertOpenAIRequestToClaude("claude-sonnet-4-5", []byte(inputJSON), false)
+	resultJSON := gjson.ParseBytes(result)
+
+	if got := resultJSON.Get("tools.0.name").String(); got != "structured_output" {
+		t.Fatalf("Expected structured output tool, got %q. Output: %s", got, string(result))
+	}
+	if got := resultJSON.Get("tools.0.input_schema.required.0").String(); got != "name" {
+		t.Fatalf("Expected schema to be the tool input schema, got %q. Output: %s", got, string(result))
+	}
+	if got := resultJSON.Get("tool_choice.name").String(); got != "structured_output" {
+		t.Fatalf("Expected tool_choice to force structured output, got %q. Output: %s", got, string(result))
+	}
+	if resultJSON.Get("thinking").Exists() {
+		t.Fatalf("Expected thinking to be removed with a forced tool. Output: %s", string(result))
+	}
+}
+
+func TestConvertOpenAIRequestToClaude_ToolChoiceNone(t *testing.T) {
+	inputJSON := `{
+		"model": "gpt-4.1",
+		"messages": [{"role": "user", "content": "hi"}],
+		"tools": [{"type": "function", "function": {"name": "lookup", "parameters": {"type": "object"}}}],
+		"tool_choice": "none"
+	}`
+
+	result := ConvertOpenAIRequestToClaude("claude-sonnet-4-5", []byte(inputJSON), false)
+
+	if got := gjson.GetBytes(result, "tool_choice.type").String(); got != "none" {
+		t.Fatalf("Expected tool_choice none, got %q. Output: %s", got, string(result))
+	}
+	if got := gjson.GetBytes(result, "tools.0.name").String(); got != "lookup" {
+		t.Fatalf("Expected tools to be kept, got %q. Output: %s", got, string(result))
+	}
+}
+
+func TestConvertOpenAIRequestToClaude_ParallelToolCallsDisabled(t *testing.T) {
+	inputJSON := `{
+		"model": "gpt-4.1",
+		"messages": [{"role": "user", "content": "hi"}],
+		"tools": [{"type": "function", "function": {"name": "lookup", "parameters": {"type": "object"}}}],
+		"parallel_tool_calls": false
+	}`
+
+	result := ConvertOpenAIRequestToClaude("claude-sonnet-4-5", []byte(inputJSON), false)
+
+	if got := gjson.GetBytes(result, "tool_choice.type").String(); got != "auto" {
+		t.Fatalf("Expected tool_choice auto, got %q. Output: %s", got, string(result))
+	}
+	if !gjson.GetBytes(result, "tool_choice.disable_parallel_tool_use").Bool() {
+		t.Fatalf("Expected disable_parallel_tool_use to be set. Output: %s", string(result))
+	}
+}
+
+func TestConvertOpenAIRequestToClaude_SystemAndDeveloperMessages(t *testing.T) {
+	inputJSON := `{
+		"model": "gpt-4.1",
+		"messages": [
+			{"role": "system", "content": [{"type": "text", "text": "You are helpful.", "cache_control": {"type": "ephemeral"}}]},
+			{"role": "user", "content": "hi"},
+			{"role": "developer", "content": "Answer in French."}
+		]
+	}`
+
+	result := ConvertOpenAIRequestToClaude("claude-sonnet-4-5", []byte(inputJSON), false)
+
+	system := gjson.GetBytes(result, "system")
+	if len(system.Array()) != 2 {
+		t.Fatalf("Expected 2 system blocks, got %s", system.Raw)
+	}
+	if got := system.Get("0.cache_control.type").String(); got != "ephemeral" {
+		t.Fatalf("Expected cache_control to be kept, got %q. Output: %s", got, string(result))
+	}
+	if got := system.Get("1.text").String(); got != "Answer in French." {
+		t.Fatalf("Expected developer message in system, got %q. Output: %s", got, string(result))
+	}
+	if got := gjson.GetBytes(result, "messages.#").Int(); got != 1 {
+		t.Fatalf("Expected 1 message, got %d. Output: %s", got, string(result))
+	}
+}
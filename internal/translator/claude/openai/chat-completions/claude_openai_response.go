@@ -12,6 +12,8 @@ import (
 	"strings"
 	"time"
 
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
+	log "github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -27,6 +29,10 @@ type ConvertAnthropicResponseToOpenAIParams struct {
 	FinishReason string
 	// Tool calls accumulator for streaming
 	ToolCallsAccumulator map[int]*ToolCallAccumulator
+	// StructuredOutput is set when the client requested a JSON response_format
+	StructuredOutput bool
+	// ToolCallsSent is set once a client tool call has been streamed
+	ToolCallsSent bool
 }
 
 // ToolCallAccumulator holds the state for accumulating tool call data
@@ -64,9 +70,10 @@ func calculateClaudeUsageTokens(usage gjson.Result) (promptTokens, completionTok
 func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) [][]byte {
 	if *param == nil {
 		*param = &ConvertAnthropicResponseToOpenAIParams{
-			CreatedAt:    0,
-			ResponseID:   "",
-			FinishReason: "",
+			CreatedAt:        0,
+			ResponseID:       "",
+			FinishReason:     "",
+			StructuredOutput: structuredOutputRequested(originalRequestRawJSON),
 		}
 	}
 
@@ -190,15 +197,21 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 				if arguments == "" {
 					arguments = "{}"
 				}
+				delete((*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator, index)
+
+				// The forced structured output call is the message content requested by the client
+				if (*param).(*ConvertAnthropicResponseToOpenAIParams).StructuredOutput && accumulator.Name == translatorcommon.StructuredOutputToolName {
+					template, _ = sjson.SetBytes(template, "choices.0.delta.content", structuredOutputContent(arguments))
+					return [][]byte{template}
+				}
+
+				(*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsSent = true
 				template, _ = sjson.SetBytes(template, "choices.0.delta.tool_calls.0.index", index)
 				template, _ = sjson.SetBytes(template, "choices.0.delta.tool_calls.0.id", accumulator.ID)
 				template, _ = sjson.SetBytes(template, "choices.0.delta.tool_calls.0.type", "function")
 				template, _ = sjson.SetBytes(template, "choices.0.delta.tool_calls.0.function.name", accumulator.Name)
 				template, _ = sjson.SetBytes(template, "choices.0.delta.tool_calls.0.function.arguments", arguments)
 
-				// Clean up the accumulator for this index
-				delete((*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator, index)
-
 				return [][]byte{template}
 			}
 		}
@@ -208,7 +221,10 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 		// Handle message-level changes including stop reason and usage
 		if delta := root.Get("delta"); delta.Exists() {
 			if stopReason := delta.Get("stop_reason"); stopReason.Exists() {
-				(*param).(*ConvertAnthropicResponseToOpenAIParams).FinishReason = mapAnthropicStopReasonToOpenAI(stopReason.String())
+				(*param).(*ConvertAnthropicResponseToOpenAIParams).FinishReason = translatorcommon.OpenAIFinishReasonFromClaude(stopReason.String())
+				if (*param).(*ConvertAnthropicResponseToOpenAIParams).StructuredOutput && !(*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsSent && stopReason.String() == "tool_use" {
+					(*param).(*ConvertAnthropicResponseToOpenAIParams).FinishReason = "stop"
+				}
 				template, _ = sjson.SetBytes(template, "choices.0.finish_reason", (*param).(*ConvertAnthropicResponseToOpenAIParams).FinishReason)
 			}
 		}
@@ -247,20 +263,21 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 	}
 }
 
-// mapAnthropicStopReasonToOpenAI maps Anthropic stop reasons to OpenAI stop reasons
-func mapAnthropicStopReasonToOpenAI(anthropicReason string) string {
-	switch anthropicReason {
-	case "end_turn":
-		return "stop"
-	case "tool_use":
-		return "tool_calls"
-	case "max_tokens":
-		return "length"
-	case "stop_sequence":
-		return "stop"
-	default:
-		return "stop"
+// structuredOutputRequested reports whether the client asked for a JSON response_format, which
+// the request translator turns into a forced structured output tool call.
+func structuredOutputRequested(originalRequestRawJSON []byte) bool {
+	_, ok := translatorcommon.ResponseFormatFromOpenAI(gjson.ParseBytes(originalRequestRawJSON))
+	return ok
+}
+
+// structuredOutputContent returns the input of the structured output tool call as the JSON
+// message content, repairing it when the model was cut off.
+func structuredOutputContent(arguments string) string {
+	content, ok := translatorcommon.RepairJSON(arguments)
+	if !ok {
+		log.Warnf("claude openai response: structured output is not valid JSON")
 	}
+	return content
 }
 
 // ConvertClaudeResponseToOpenAINonStream converts a non-streaming Claude Code response to a non-streaming OpenAI response.
@@ -385,8 +402,19 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 	out, _ = sjson.SetBytes(out, "created", createdAt)
 	out, _ = sjson.SetBytes(out, "model", model)
 
-	// Set message content by combining all text parts
+	// Set message content by combining all text parts, or from the structured output tool call
 	messageContent := strings.Join(contentParts, "")
+	if structuredOutputRequested(originalRequestRawJSON) {
+		for index, accumulator := range toolCallsAccumulator {
+			if accumulator.Name == translatorcommon.StructuredOutputToolName {
+				messageContent = structuredOutputContent(accumulator.Arguments.String())
+				delete(toolCallsAccumulator, index)
+			}
+		}
+		if len(toolCallsAccumulator) == 0 && stopReason == "tool_use" {
+			stopReason = "end_turn"
+		}
+	}
 	out, _ = sjson.SetBytes(out, "choices.0.message.content", messageContent)
 
 	// Add reasoning content if available (following OpenAI reasoning format)
@@ -428,10 +456,10 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 		if toolCallsCount > 0 {
 			out, _ = sjson.SetBytes(out, "choices.0.finish_reason", "tool_calls")
 		} else {
-			out, _ = sjson.SetBytes(out, "choices.0.finish_reason", mapAnthropicStopReasonToOpenAI(stopReason))
+			out, _ = sjson.SetBytes(out, "choices.0.finish_reason", translatorcommon.OpenAIFinishReasonFromClaude(stopReason))
 		}
 	} else {
-		out, _ = sjson.SetBytes(out, "choices.0.finish_reason", mapAnthropicStopReasonToOpenAI(stopReason))
+		out, _ = sjson.SetBytes(out, "choices.0.finish_reason", translatorcommon.OpenAIFinishReasonFromClaude(stopReason))
 	}
 
 	return out
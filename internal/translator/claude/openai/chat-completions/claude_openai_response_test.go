@@ -2,6 +2,7 @@ package chat_completions
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/tidwall/gjson"
@@ -56,3 +57,40 @@ func TestConvertClaudeResponseToOpenAINonStream_UsageIncludesCachedTokens(t *tes
 		t.Fatalf("expected cached_tokens %d, got %d", 22000, gotCachedTokens)
 	}
 }
+
+func TestConvertClaudeResponseToOpenAI_StructuredOutputBecomesContent(t *testing.T) {
+	originalRequest := []byte(`{"response_format":{"type":"json_object"}}`)
+	events := []string{
+		`data: {"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4-5"}}`,
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_1","name":"structured_output"}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"name\":"}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"\"red\"}"}}`,
+		`data: {"type":"content_block_stop","index":0}`,
+		`data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":4}}`,
+	}
+
+	var param any
+	var content, finishReason string
+	for _, event := range events {
+		for _, chunk := range ConvertClaudeResponseToOpenAI(context.Background(), "claude-sonnet-4-5", originalRequest, nil, []byte(event), &param) {
+			if gjson.GetBytes(chunk, "choices.0.delta.tool_calls").Exists() {
+				t.Fatalf("structured output must not be streamed as a tool call: %s", chunk)
+			}
+			content += gjson.GetBytes(chunk, "choices.0.delta.content").String()
+			if reason := gjson.GetBytes(chunk, "choices.0.finish_reason"); reason.Type == gjson.String {
+				finishReason = reason.String()
+			}
+		}
+	}
+	if content != `{"name":"red"}` || finishReason != "stop" {
+		t.Fatalf("stream content = %q, finish_reason = %q", content, finishReason)
+	}
+
+	out := ConvertClaudeResponseToOpenAINonStream(context.Background(), "", originalRequest, nil, []byte(strings.Join(events, "\n")), nil)
+	if got := gjson.GetBytes(out, "choices.0.message.content").String(); got != `{"name":"red"}` {
+		t.Fatalf("non-stream content = %q. Output: %s", got, out)
+	}
+	if gjson.GetBytes(out, "choices.0.message.tool_calls").Exists() || gjson.GetBytes(out, "choices.0.finish_reason").String() != "stop" {
+		t.Fatalf("non-stream structured output kept as tool call. Output: %s", out)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -124,59 +125,21 @@ func ConvertOpenAIResponsesRequestToClaude(modelName string, inputRawJSON []byte
 	// Stream
 	out, _ = sjson.SetBytes(out, "stream", stream)
 
-	// instructions -> as a leading message (use role user for Claude API compatibility)
-	instructionsText := ""
-	extractedFromSystem := false
-	if instr := root.Get("instructions"); instr.Exists() && instr.Type == gjson.String {
-		instructionsText = instr.String()
-		if instructionsText != "" {
-			sysMsg := []byte(`{"role":"user","content":""}`)
-			sysMsg, _ = sjson.SetBytes(sysMsg, "content", instructionsText)
-			out, _ = sjson.SetRawBytes(out, "messages.-1", sysMsg)
-		}
-	}
-
-	if instructionsText == "" {
-		if input := root.Get("input"); input.Exists() && input.IsArray() {
-			input.ForEach(func(_, item gjson.Result) bool {
-				if strings.EqualFold(item.Get("role").String(), "system") {
-					var builder strings.Builder
-					if parts := item.Get("content"); parts.Exists() && parts.IsArray() {
-						parts.ForEach(func(_, part gjson.Result) bool {
-							textResult := part.Get("text")
-							text := textResult.String()
-							if builder.Len() > 0 && text != "" {
-								builder.WriteByte('\n')
-							}
-							builder.WriteString(text)
-							return true
-						})
-					} else if parts.Type == gjson.String {
-						builder.WriteString(parts.String())
-					}
-					instructionsText = builder.String()
-					if instructionsText != "" {
-						sysMsg := []byte(`{"role":"user","content":""}`)
-						sysMsg, _ = sjson.SetBytes(sysMsg, "content", instructionsText)
-						out, _ = sjson.SetRawBytes(out, "messages.-1", sysMsg)
-						extractedFromSystem = true
-					}
-				}
-				return instructionsText == ""
-			})
-		}
+	// instructions and system/developer input messages -> top-level system blocks
+	if system := translatorcommon.SystemPromptFromResponses(root); len(system) > 0 {
+		out, _ = sjson.SetRawBytes(out, "system", system.ClaudeBlocks())
 	}
 
 	// input array processing
 	if input := root.Get("input"); input.Exists() && input.IsArray() {
 		input.ForEach(func(_, item gjson.Result) bool {
-			if extractedFromSystem && strings.EqualFold(item.Get("role").String(), "system") {
-				return true
-			}
 			typ := item.Get("type").String()
 			if typ == "" && item.Get("role").String() != "" {
 				typ = "message"
 			}
+			if typ == "message" && translatorcommon.IsSystemRole(item.Get("role").String()) {
+				return true
+			}
 			switch typ {
 			case "message":
 				// Determine role and construct Claude-compatible content parts.
@@ -238,23 +201,11 @@ func ConvertOpenAIResponsesRequestToClaude(modelName string, inputRawJSON []byte
 								}
 							}
 						case "input_file":
-							fileData := part.Get("file_data").String()
-							if fileData != "" {
-								mediaType := "application/octet-stream"
-								data := fileData
-								if strings.HasPrefix(fileData, "data:") {
-									trimmed := strings.TrimPrefix(fileData, "data:")
-									mediaAndData := strings.SplitN(trimmed, ";base64,", 2)
-									if len(mediaAndData) == 2 {
-										if mediaAndData[0] != "" {
-											mediaType = mediaAndData[0]
-										}
-										data = mediaAndData[1]
-									}
-								}
-								contentPart := []byte(`{"type":"document","source":{"type":"base64","media_type":"","data":""}}`)
-								contentPart, _ = sjson.SetBytes(contentPart, "source.media_type", mediaType)
-								contentPart, _ = sjson.SetBytes(contentPart, "source.data", data)
+							doc, ok := translatorcommon.DocumentFromResponsesFile(part)
+							if !ok {
+								break
+							}
+							if contentPart, errRender := doc.ClaudeBlock(); errRender == nil {
 								partsJSON = append(partsJSON, string(contentPart))
 								if role == "" {
 									role = "user"
@@ -272,7 +223,7 @@ func ConvertOpenAIResponsesRequestToClaude(modelName string, inputRawJSON []byte
 				if role == "" {
 					r := item.Get("role").String()
 					switch r {
-					case "user", "assistant", "system":
+					case "user", "assistant":
 						role = r
 					default:
 						role = "user"
@@ -293,7 +244,7 @@ func ConvertOpenAIResponsesRequestToClaude(modelName string, inputRawJSON []byte
 						}
 					}
 					out, _ = sjson.SetRawBytes(out, "messages.-1", msg)
-				} else if textAggregate.Len() > 0 || role == "system" {
+				} else if textAggregate.Len() > 0 {
 					msg := []byte(`{"role":"","content":""}`)
 					msg, _ = sjson.SetBytes(msg, "role", role)
 					msg, _ = sjson.SetBytes(msg, "content", textAggregate.String())
@@ -339,6 +290,11 @@ func ConvertOpenAIResponsesRequestToClaude(modelName string, inputRawJSON []byte
 		})
 	}
 
+	// Claude needs a conversational turn even when the request only carries instructions
+	if !gjson.GetBytes(out, "messages.0").Exists() && gjson.GetBytes(out, "system.0").Exists() {
+		out, _ = sjson.SetRawBytes(out, "messages.-1", []byte(`{"role":"user","content":[{"type":"text","text":""}]}`))
+	}
+
 	// tools mapping: parameters -> input_schema
 	if tools := root.Get("tools"); tools.Exists() && tools.IsArray() {
 		toolsJSON := []byte("[]")
@@ -365,28 +321,13 @@ func ConvertOpenAIResponsesRequestToClaude(modelName string, inputRawJSON []byte
 		}
 	}
 
-	// Map tool_choice similar to Chat Completions translator (optional in docs, safe to handle)
-	if toolChoice := root.Get("tool_choice"); toolChoice.Exists() {
-		switch toolChoice.Type {
-		case gjson.String:
-			switch toolChoice.String() {
-			case "auto":
-				out, _ = sjson.SetRawBytes(out, "tool_choice", []byte(`{"type":"auto"}`))
-			case "none":
-				// Leave unset; implies no tools
-			case "required":
-				out, _ = sjson.SetRawBytes(out, "tool_choice", []byte(`{"type":"any"}`))
-			}
-		case gjson.JSON:
-			if toolChoice.Get("type").String() == "function" {
-				fn := toolChoice.Get("function.name").String()
-				toolChoiceJSON := []byte(`{"name":"","type":"tool"}`)
-				toolChoiceJSON, _ = sjson.SetBytes(toolChoiceJSON, "name", fn)
-				out, _ = sjson.SetRawBytes(out, "tool_choice", toolChoiceJSON)
-			}
-		default:
+	// Map tool_choice; none is sent explicitly since the tools stay declared
+	if toolChoice, ok := translatorcommon.ToolChoiceFromOpenAI(root.Get("tool_choice")); ok {
+		out = toolChoice.ApplyToClaude(out)
+	}
 
-		}
+	if allowed, ok := translatorcommon.ParallelToolCallsFromOpenAI(root); ok && !allowed {
+		out = translatorcommon.DisableParallelToolUse(out)
 	}
 
 	return out
@@ -37,6 +37,8 @@ type claudeToResponsesState struct {
 	InputTokens  int64
 	OutputTokens int64
 	UsageSeen    bool
+	// StopReason is the stop_reason reported by message_delta.
+	StopReason string
 }
 
 var dataTag = []byte("data:")
@@ -273,6 +275,9 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 			st.ReasoningPartAdded = false
 		}
 	case "message_delta":
+		if v := root.Get("delta.stop_reason"); v.Exists() {
+			st.StopReason = v.String()
+		}
 		if usage := root.Get("usage"); usage.Exists() {
 			if v := usage.Get("output_tokens"); v.Exists() {
 				st.OutputTokens = v.Int()
@@ -426,7 +431,8 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 				completed, _ = sjson.SetBytes(completed, "response.usage.total_tokens", total)
 			}
 		}
-		out = append(out, emitEvent("response.completed", completed))
+		completed, event := translatorcommon.ResponsesTerminalEvent(completed, translatorcommon.OpenAIFinishReasonFromClaude(st.StopReason))
+		out = append(out, emitEvent(event, completed))
 	}
 
 	return out
@@ -470,6 +476,7 @@ func ConvertClaudeResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 		reasoningItemID string
 		inputTokens     int64
 		outputTokens    int64
+		stopReason      string
 	)
 
 	// Per-index tool call aggregation
@@ -551,6 +558,9 @@ func ConvertClaudeResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 			_ = root
 
 		case "message_delta":
+			if v := root.Get("delta.stop_reason"); v.Exists() {
+				stopReason = v.String()
+			}
 			if usage := root.Get("usage"); usage.Exists() {
 				outputTokens = usage.Get("output_tokens").Int()
 			}
@@ -559,6 +569,7 @@ func ConvertClaudeResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 
 	// Populate base fields
 	out, _ = sjson.SetBytes(out, "id", responseID)
+	out = translatorcommon.MarkResponsesIncomplete(out, translatorcommon.OpenAIFinishReasonFromClaude(stopReason))
 	out, _ = sjson.SetBytes(out, "created_at", createdAt)
 
 	// Inject request echo fields as top-level (similar to streaming variant)
@@ -12,6 +12,7 @@ import (
 	"strings"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -44,36 +45,8 @@ func ConvertClaudeRequestToCodex(modelName string, inputRawJSON []byte, _ bool)
 	template, _ = sjson.SetBytes(template, "model", modelName)
 
 	// Process system messages and convert them to input content format.
-	systemsResult := rootResult.Get("system")
-	if systemsResult.Exists() {
-		message := []byte(`{"type":"message","role":"developer","content":[]}`)
-		contentIndex := 0
-
-		appendSystemText := func(text string) {
-			if text == "" || strings.HasPrefix(text, "x-anthropic-billing-header: ") {
-				return
-			}
-
-			message, _ = sjson.SetBytes(message, fmt.Sprintf("content.%d.type", contentIndex), "input_text")
-			message, _ = sjson.SetBytes(message, fmt.Sprintf("content.%d.text", contentIndex), text)
-			contentIndex++
-		}
-
-		if systemsResult.Type == gjson.String {
-			appendSystemText(systemsResult.String())
-		} else if systemsResult.IsArray() {
-			systemResults := systemsResult.Array()
-			for i := 0; i < len(systemResults); i++ {
-				systemResult := systemResults[i]
-				if systemResult.Get("type").String() == "text" {
-					appendSystemText(systemResult.Get("text").String())
-				}
-			}
-		}
-
-		if contentIndex > 0 {
-			template, _ = sjson.SetRawBytes(template, "input.-1", message)
-		}
+	if system := translatorcommon.SystemPromptFromClaude(rootResult.Get("system")); len(system) > 0 {
+		template, _ = sjson.SetRawBytes(template, "input.-1", system.ResponsesMessage())
 	}
 
 	// Process messages and transform their contents to appropriate formats.
@@ -122,6 +95,12 @@ func ConvertClaudeRequestToCodex(modelName string, inputRawJSON []byte, _ bool)
 				hasContent = true
 			}
 
+			appendFileContent := func(part []byte) {
+				message, _ = sjson.SetRawBytes(message, fmt.Sprintf("content.%d", contentIndex), part)
+				contentIndex++
+				hasContent = true
+			}
+
 			appendReasoningContent := func(part gjson.Result) {
 				if messageRole != "assistant" {
 					return
@@ -151,22 +130,13 @@ func ConvertClaudeRequestToCodex(modelName string, inputRawJSON []byte, _ bool)
 					case "thinking":
 						appendReasoningContent(messageContentResult)
 					case "image":
-						sourceResult := messageContentResult.Get("source")
-						if sourceResult.Exists() {
-							data := sourceResult.Get("data").String()
-							if data == "" {
-								data = sourceResult.Get("base64").String()
-							}
-							if data != "" {
-								mediaType := sourceResult.Get("media_type").String()
-								if mediaType == "" {
-									mediaType = sourceResult.Get("mime_type").String()
-								}
-								if mediaType == "" {
-									mediaType = "application/octet-stream"
-								}
-								dataURL := fmt.Sprintf("data:%s;base64,%s", mediaType, data)
-								appendImageContent(dataURL)
+						if img, ok := translatorcommon.ImageFromClaudeSource(messageContentResult.Get("source")); ok {
+							appendImageContent(img.URLString())
+						}
+					case "document":
+						if doc, ok := translatorcommon.DocumentFromClaudeBlock(messageContentResult); ok {
+							if part, errRender := doc.ResponsesPart(); errRender == nil {
+								appendFileContent(part)
 							}
 						}
 					case "tool_use":
@@ -197,26 +167,10 @@ func ConvertClaudeRequestToCodex(modelName string, inputRawJSON []byte, _ bool)
 							for k := 0; k < len(contentResults); k++ {
 								toolResultContentType := contentResults[k].Get("type").String()
 								if toolResultContentType == "image" {
-									sourceResult := contentResults[k].Get("source")
-									if sourceResult.Exists() {
-										data := sourceResult.Get("data").String()
-										if data == "" {
-											data = sourceResult.Get("base64").String()
-										}
-										if data != "" {
-											mediaType := sourceResult.Get("media_type").String()
-											if mediaType == "" {
-												mediaType = sourceResult.Get("mime_type").String()
-											}
-											if mediaType == "" {
-												mediaType = "application/octet-stream"
-											}
-											dataURL := fmt.Sprintf("data:%s;base64,%s", mediaType, data)
-
-											toolResultContent, _ = sjson.SetBytes(toolResultContent, fmt.Sprintf("%d.type", toolResultContentIndex), "input_image")
-											toolResultContent, _ = sjson.SetBytes(toolResultContent, fmt.Sprintf("%d.image_url", toolResultContentIndex), dataURL)
-											toolResultContentIndex++
-										}
+									if img, ok := translatorcommon.ImageFromClaudeSource(contentResults[k].Get("source")); ok {
+										toolResultContent, _ = sjson.SetBytes(toolResultContent, fmt.Sprintf("%d.type", toolResultContentIndex), "input_image")
+										toolResultContent, _ = sjson.SetBytes(toolResultContent, fmt.Sprintf("%d.image_url", toolResultContentIndex), img.URLString())
+										toolResultContentIndex++
 									}
 								} else if toolResultContentType == "text" {
 									toolResultContent, _ = sjson.SetBytes(toolResultContent, fmt.Sprintf("%d.type", toolResultContentIndex), "input_text")
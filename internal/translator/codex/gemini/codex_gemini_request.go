@@ -6,13 +6,12 @@
 package gemini
 
 import (
-	"crypto/rand"
 	"fmt"
-	"math/big"
 	"strconv"
 	"strings"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
@@ -25,7 +24,7 @@ import (
 // 1. Model name mapping and generation configuration extraction
 // 2. System instruction conversion to Codex format
 // 3. Message content conversion with proper role mapping
-// 4. Tool call and tool result handling with FIFO queue for ID matching
+// 4. Tool call and tool result handling paired by ID or name
 // 5. Tool declaration and tool choice configuration mapping
 //
 // Parameters:
@@ -63,44 +62,17 @@ func ConvertGeminiRequestToCodex(modelName string, inputRawJSON []byte, _ bool)
 		}
 	}
 
-	// helper for generating paired call IDs in the form: call_<alphanum>
-	// Gemini uses sequential pairing across possibly multiple in-flight
-	// functionCalls, so we keep a FIFO queue of generated call IDs and
-	// consume them in order when functionResponses arrive.
-	var pendingCallIDs []string
-
-	// genCallID creates a random call id like: call_<8chars>
-	genCallID := func() string {
-		const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-		var b strings.Builder
-		// 8 chars random suffix
-		for i := 0; i < 24; i++ {
-			n, _ := rand.Int(rand.Reader, big.NewInt(int64(len(letters))))
-			b.WriteByte(letters[n.Int64()])
-		}
-		return "call_" + b.String()
-	}
+	// Pairs functionResponses with the functionCalls they answer: by id when the client sent
+	// one, otherwise with the oldest unanswered call of the same name. Derived IDs are stable
+	// across turns, so the same history always yields the same call_ids.
+	callIDs := translatorcommon.NewToolCallCorrelator("call_")
 
 	// Model
 	out, _ = sjson.SetBytes(out, "model", modelName)
 
-	// System instruction -> as a user message with input_text parts
-	sysParts := root.Get("system_instruction.parts")
-	if sysParts.IsArray() {
-		msg := []byte(`{"type":"message","role":"developer","content":[]}`)
-		arr := sysParts.Array()
-		for i := 0; i < len(arr); i++ {
-			p := arr[i]
-			if t := p.Get("text"); t.Exists() {
-				part := []byte(`{}`)
-				part, _ = sjson.SetBytes(part, "type", "input_text")
-				part, _ = sjson.SetBytes(part, "text", t.String())
-				msg, _ = sjson.SetRawBytes(msg, "content.-1", part)
-			}
-		}
-		if len(gjson.GetBytes(msg, "content").Array()) > 0 {
-			out, _ = sjson.SetRawBytes(out, "input.-1", msg)
-		}
+	// System instruction -> as a developer message with input_text parts
+	if system := translatorcommon.SystemPromptFromGemini(root); len(system) > 0 {
+		out, _ = sjson.SetRawBytes(out, "input.-1", system.ResponsesMessage())
 	}
 
 	// Contents -> messages and function calls/results
@@ -137,6 +109,24 @@ func ConvertGeminiRequestToCodex(modelName string, inputRawJSON []byte, _ bool)
 					continue
 				}
 
+				// inline or file image; Codex only accepts input_image from the user side
+				if img, ok := translatorcommon.ImageFromGeminiPart(p); ok {
+					msg := []byte(`{"type":"message","role":"user","content":[]}`)
+					msg, _ = sjson.SetRawBytes(msg, "content.-1", img.ResponsesPart())
+					out, _ = sjson.SetRawBytes(out, "input.-1", msg)
+					continue
+				}
+
+				// document attachment, likewise only accepted from the user side
+				if doc, ok := translatorcommon.DocumentFromGeminiPart(p); ok {
+					if part, errRender := doc.ResponsesPart(); errRender == nil {
+						msg := []byte(`{"type":"message","role":"user","content":[]}`)
+						msg, _ = sjson.SetRawBytes(msg, "content.-1", part)
+						out, _ = sjson.SetRawBytes(out, "input.-1", msg)
+					}
+					continue
+				}
+
 				// function call from model
 				if fc := p.Get("functionCall"); fc.Exists() {
 					fn := []byte(`{"type":"function_call"}`)
@@ -152,12 +142,7 @@ func ConvertGeminiRequestToCodex(modelName string, inputRawJSON []byte, _ bool)
 					if args := fc.Get("args"); args.Exists() {
 						fn, _ = sjson.SetBytes(fn, "arguments", args.Raw)
 					}
-					// generate a paired random call_id and enqueue it so the
-					// corresponding functionResponse can pop the earliest id
-					// to preserve ordering when multiple calls are present.
-					id := genCallID()
-					fn, _ = sjson.SetBytes(fn, "call_id", id)
-					pendingCallIDs = append(pendingCallIDs, id)
+					fn, _ = sjson.SetBytes(fn, "call_id", callIDs.Call(fc.Get("id").String(), fc.Get("name").String()))
 					out, _ = sjson.SetRawBytes(out, "input.-1", fn)
 					continue
 				}
@@ -171,18 +156,7 @@ func ConvertGeminiRequestToCodex(modelName string, inputRawJSON []byte, _ bool)
 					} else if resp := fr.Get("response"); resp.Exists() {
 						fno, _ = sjson.SetBytes(fno, "output", resp.Raw)
 					}
-					// fno, _ = sjson.SetBytes(fno, "call_id", "call_W6nRJzFXyPM2LFBbfo98qAbq")
-					// attach the oldest queued call_id to pair the response
-					// with its call. If the queue is empty, generate a new id.
-					var id string
-					if len(pendingCallIDs) > 0 {
-						id = pendingCallIDs[0]
-						// pop the first element
-						pendingCallIDs = pendingCallIDs[1:]
-					} else {
-						id = genCallID()
-					}
-					fno, _ = sjson.SetBytes(fno, "call_id", id)
+					fno, _ = sjson.SetBytes(fno, "call_id", callIDs.Result(fr.Get("id").String(), fr.Get("name").String()))
 					out, _ = sjson.SetRawBytes(out, "input.-1", fno)
 					continue
 				}
@@ -236,6 +210,18 @@ func ConvertGeminiRequestToCodex(modelName string, inputRawJSON []byte, _ bool)
 				out, _ = sjson.SetRawBytes(out, "tools.-1", tool)
 			}
 		}
+
+		// Gemini toolConfig -> tool_choice, using the shortened tool names
+		if toolChoice, ok := translatorcommon.ToolChoiceFromGemini(root); ok {
+			for i, name := range toolChoice.Names {
+				if short, okShort := shortMap[name]; okShort {
+					toolChoice.Names[i] = short
+				} else {
+					toolChoice.Names[i] = shortenNameIfNeeded(name)
+				}
+			}
+			out = toolChoice.ApplyToResponses(out)
+		}
 	}
 
 	// Fixed flags aligning with Codex expectations
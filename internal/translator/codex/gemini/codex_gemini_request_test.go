@@ -0,0 +1,36 @@
+package gemini
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertGeminiRequestToCodex_ImageParts(t *testing.T) {
+	input := []byte(`{
+		"contents": [{
+			"role": "user",
+			"parts": [
+				{"text": "compare these"},
+				{"inlineData": {"mimeType": "image/png", "data": "aGVsbG8="}},
+				{"fileData": {"mimeType": "image/jpeg", "fileUri": "https://example.com/cat.jpg"}}
+			]
+		}]
+	}`)
+
+	out := ConvertGeminiRequestToCodex("gpt-5", input, false)
+
+	items := gjson.GetBytes(out, "input").Array()
+	if len(items) != 3 {
+		t.Fatalf("expected 3 input items, got %d: %s", len(items), out)
+	}
+	if got := items[1].Get("content.0.type").String(); got != "input_image" {
+		t.Fatalf("inline image type = %q, want input_image", got)
+	}
+	if got := items[1].Get("content.0.image_url").String(); got != "data:image/png;base64,aGVsbG8=" {
+		t.Fatalf("inline image url = %q", got)
+	}
+	if got := items[2].Get("content.0.image_url").String(); got != "https://example.com/cat.jpg" {
+		t.Fatalf("file image url = %q", got)
+	}
+}
@@ -156,6 +156,9 @@ func ConvertCodexResponseToGemini(_ context.Context, modelName string, originalR
 					functionCall, _ = sjson.SetRawBytes(functionCall, "functionCall.args", []byte(argsStr))
 				}
 			}
+			if callID := itemResult.Get("call_id").String(); callID != "" {
+				functionCall, _ = sjson.SetBytes(functionCall, "functionCall.id", callID)
+			}
 
 			template, _ = sjson.SetRawBytes(template, "candidates.0.content.parts.-1", functionCall)
 			template, _ = sjson.SetBytes(template, "candidates.0.finishReason", "STOP")
@@ -209,7 +212,8 @@ func ConvertCodexResponseToGemini(_ context.Context, modelName string, originalR
 			return [][]byte{template}
 		}
 		return [][]byte{}
-	} else if typeStr == "response.completed" { // Handle response completion with usage metadata
+	} else if typeStr == "response.completed" || typeStr == "response.incomplete" { // Handle response completion with usage metadata
+		template, _ = sjson.SetBytes(template, "candidates.0.finishReason", translatorcommon.GeminiFinishReasonFromOpenAI(translatorcommon.OpenAIFinishReasonFromResponses(rootResult.Get("response"))))
 		template, _ = sjson.SetBytes(template, "usageMetadata.promptTokenCount", rootResult.Get("response.usage.input_tokens").Int())
 		template, _ = sjson.SetBytes(template, "usageMetadata.candidatesTokenCount", rootResult.Get("response.usage.output_tokens").Int())
 		totalTokens := rootResult.Get("response.usage.input_tokens").Int() + rootResult.Get("response.usage.output_tokens").Int()
@@ -242,8 +246,8 @@ func ConvertCodexResponseToGemini(_ context.Context, modelName string, originalR
 func ConvertCodexResponseToGeminiNonStream(_ context.Context, modelName string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, _ *any) []byte {
 	rootResult := gjson.ParseBytes(rawJSON)
 
-	// Verify this is a response.completed event
-	if rootResult.Get("type").String() != "response.completed" {
+	// Verify this is a response.completed or response.incomplete event
+	if typeStr := rootResult.Get("type").String(); typeStr != "response.completed" && typeStr != "response.incomplete" {
 		return []byte{}
 	}
 
@@ -278,7 +282,6 @@ func ConvertCodexResponseToGeminiNonStream(_ context.Context, modelName string,
 		}
 
 		// Process output content to build parts array
-		hasToolCall := false
 		var pendingFunctionCalls [][]byte
 
 		flushPendingFunctionCalls := func() {
@@ -343,7 +346,6 @@ func ConvertCodexResponseToGeminiNonStream(_ context.Context, modelName string,
 
 				case "function_call":
 					// Collect function call for potential merging with consecutive ones
-					hasToolCall = true
 					functionCall := []byte(`{"functionCall":{"args":{},"name":""}}`)
 					{
 						n := value.Get("name").String()
@@ -361,6 +363,9 @@ func ConvertCodexResponseToGeminiNonStream(_ context.Context, modelName string,
 							functionCall, _ = sjson.SetRawBytes(functionCall, "functionCall.args", []byte(argsStr))
 						}
 					}
+					if callID := value.Get("call_id").String(); callID != "" {
+						functionCall, _ = sjson.SetBytes(functionCall, "functionCall.id", callID)
+					}
 
 					pendingFunctionCalls = append(pendingFunctionCalls, functionCall)
 				}
@@ -371,12 +376,8 @@ func ConvertCodexResponseToGeminiNonStream(_ context.Context, modelName string,
 			flushPendingFunctionCalls()
 		}
 
-		// Set finish reason based on whether there were tool calls
-		if hasToolCall {
-			template, _ = sjson.SetBytes(template, "candidates.0.finishReason", "STOP")
-		} else {
-			template, _ = sjson.SetBytes(template, "candidates.0.finishReason", "STOP")
-		}
+		// Gemini has no tool call finish reason, so only truncation and filtering are reported
+		template, _ = sjson.SetBytes(template, "candidates.0.finishReason", translatorcommon.GeminiFinishReasonFromOpenAI(translatorcommon.OpenAIFinishReasonFromResponses(responseData)))
 	}
 	return template
 }
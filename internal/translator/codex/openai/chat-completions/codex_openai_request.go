@@ -10,6 +10,7 @@ import (
 	"strconv"
 	"strings"
 
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -59,7 +60,9 @@ func ConvertOpenAIRequestToCodex(modelName string, inputRawJSON []byte, stream b
 	} else {
 		out, _ = sjson.SetBytes(out, "reasoning.effort", "medium")
 	}
-	out, _ = sjson.SetBytes(out, "parallel_tool_calls", true)
+	// Default to parallel tool calls unless the client explicitly disables them.
+	parallelToolCalls, ok := translatorcommon.ParallelToolCallsFromOpenAI(gjson.ParseBytes(rawJSON))
+	out, _ = sjson.SetBytes(out, "parallel_tool_calls", parallelToolCalls || !ok)
 	out, _ = sjson.SetBytes(out, "reasoning.summary", "auto")
 	out, _ = sjson.SetBytes(out, "include", []string{"reasoning.encrypted_content"})
 
@@ -181,16 +184,10 @@ func ConvertOpenAIRequestToCodex(modelName string, inputRawJSON []byte, stream b
 							}
 						case "file":
 							if role == "user" {
-								fileData := it.Get("file.file_data").String()
-								filename := it.Get("file.filename").String()
-								if fileData != "" {
-									part := []byte(`{}`)
-									part, _ = sjson.SetBytes(part, "type", "input_file")
-									part, _ = sjson.SetBytes(part, "file_data", fileData)
-									if filename != "" {
-										part, _ = sjson.SetBytes(part, "filename", filename)
+								if doc, ok := translatorcommon.DocumentFromOpenAIFile(it.Get("file")); ok {
+									if part, errRender := doc.ResponsesPart(); errRender == nil {
+										msg, _ = sjson.SetRawBytes(msg, "content.-1", part)
 									}
-									msg, _ = sjson.SetRawBytes(msg, "content.-1", part)
 								}
 							}
 						}
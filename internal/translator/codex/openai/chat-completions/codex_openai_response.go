@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -160,8 +161,8 @@ func ConvertCodexResponseToOpenAI(_ context.Context, modelName string, originalR
 
 		template, _ = sjson.SetBytes(template, "choices.0.delta.role", "assistant")
 		template, _ = sjson.SetRawBytes(template, "choices.0.delta.images.-1", imagePayload)
-	} else if dataType == "response.completed" {
-		finishReason := "stop"
+	} else if dataType == "response.completed" || dataType == "response.incomplete" {
+		finishReason := translatorcommon.OpenAIFinishReasonFromResponses(rootResult.Get("response"))
 		if (*param).(*ConvertCliToOpenAIParams).FunctionCallIndex != -1 {
 			finishReason = "tool_calls"
 		}
@@ -316,7 +317,7 @@ func ConvertCodexResponseToOpenAI(_ context.Context, modelName string, originalR
 func ConvertCodexResponseToOpenAINonStream(_ context.Context, _ string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, _ *any) []byte {
 	rootResult := gjson.ParseBytes(rawJSON)
 	// Verify this is a response.completed event
-	if rootResult.Get("type").String() != "response.completed" {
+	if typeStr := rootResult.Get("type").String(); typeStr != "response.completed" && typeStr != "response.incomplete" {
 		return []byte{}
 	}
 
@@ -468,8 +469,8 @@ func ConvertCodexResponseToOpenAINonStream(_ context.Context, _ string, original
 	// Extract and set the finish reason based on status
 	if statusResult := responseResult.Get("status"); statusResult.Exists() {
 		status := statusResult.String()
-		if status == "completed" {
-			finishReason := "stop"
+		if status == "completed" || status == "incomplete" {
+			finishReason := translatorcommon.OpenAIFinishReasonFromResponses(responseResult)
 			if len(toolCalls) > 0 {
 				finishReason = "tool_calls"
 			}
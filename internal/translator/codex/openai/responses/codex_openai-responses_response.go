@@ -25,8 +25,8 @@ func ConvertCodexResponseToOpenAIResponses(_ context.Context, _ string, _, _, ra
 // from a non-streaming OpenAI Chat Completions response.
 func ConvertCodexResponseToOpenAIResponsesNonStream(_ context.Context, _ string, _, _, rawJSON []byte, _ *any) []byte {
 	rootResult := gjson.ParseBytes(rawJSON)
-	// Verify this is a response.completed event
-	if rootResult.Get("type").String() != "response.completed" {
+	// Verify this is a response.completed or response.incomplete event
+	if typeStr := rootResult.Get("type").String(); typeStr != "response.completed" && typeStr != "response.incomplete" {
 		return []byte{}
 	}
 	responseResult := rootResult.Get("response")
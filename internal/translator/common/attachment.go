@@ -0,0 +1,67 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// UnsupportedContentError reports a document or audio attachment that the target format
+// cannot accept.
+type UnsupportedContentError struct {
+	// Kind is the attachment kind, such as "document" or "audio".
+	Kind string
+	// Target is the format the request was translated to.
+	Target string
+	// Reason describes why the attachment cannot be sent.
+	Reason string
+}
+
+// Error implements the error interface.
+func (e *UnsupportedContentError) Error() string {
+	if e.Target == "" {
+		return fmt.Sprintf("%s attachment not supported: %s", e.Kind, e.Reason)
+	}
+	return fmt.Sprintf("%s attachment not supported by %s: %s", e.Kind, e.Target, e.Reason)
+}
+
+// StatusCode implements a portable status code interface for HTTP handlers.
+func (e *UnsupportedContentError) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+func unsupportedContent(kind, format string, args ...any) error {
+	return &UnsupportedContentError{Kind: kind, Reason: fmt.Sprintf(format, args...)}
+}
+
+// CheckAttachments returns an *UnsupportedContentError for the first document or audio
+// attachment in a request of format from that format to cannot accept, so the request can be
+// rejected instead of silently losing the attachment. Requests passed through untranslated are
+// not checked.
+func CheckAttachments(from, to string, payload []byte) error {
+	if from == to {
+		return nil
+	}
+	if render, ok := documentRenderers[to]; ok {
+		for _, doc := range documentsIn(from, payload) {
+			if _, err := render(doc); err != nil {
+				return withTarget(err, to)
+			}
+		}
+	}
+	if render, ok := audioRenderers[to]; ok {
+		for _, audio := range audioIn(from, payload) {
+			if _, err := render(audio); err != nil {
+				return withTarget(err, to)
+			}
+		}
+	}
+	return nil
+}
+
+func withTarget(err error, target string) error {
+	if unsupported, ok := errors.AsType[*UnsupportedContentError](err); ok {
+		unsupported.Target = target
+	}
+	return err
+}
@@ -0,0 +1,44 @@
+package common
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestCheckAttachments(t *testing.T) {
+	docx := []byte(`{"messages":[{"role":"user","content":[{"type":"file","file":{"filename":"a.docx","file_data":"UEsDBA=="}}]}]}`)
+	err := CheckAttachments("openai", "claude", docx)
+	unsupported, ok := errors.AsType[*UnsupportedContentError](err)
+	if !ok || unsupported.Target != "claude" || unsupported.StatusCode() != http.StatusBadRequest {
+		t.Fatalf("CheckAttachments(openai -> claude, docx) = %v", err)
+	}
+	if err = CheckAttachments("openai", "gemini", docx); err != nil {
+		t.Fatalf("CheckAttachments(openai -> gemini, docx) = %v", err)
+	}
+
+	claudeURL := []byte(`{"messages":[{"role":"user","content":[{"type":"document","source":{"type":"url","url":"https://example.com/a.pdf"}}]}]}`)
+	if err = CheckAttachments("claude", "openai", claudeURL); err == nil {
+		t.Fatal("expected URL document to be rejected for chat completions")
+	}
+	if err = CheckAttachments("claude", "codex", claudeURL); err != nil {
+		t.Fatalf("CheckAttachments(claude -> codex, url) = %v", err)
+	}
+
+	geminiFile := []byte(`{"contents":[{"role":"user","parts":[{"fileData":{"mimeType":"application/pdf","fileUri":"https://generativelanguage.googleapis.com/v1beta/files/abc"}}]}]}`)
+	if err = CheckAttachments("gemini", "claude", geminiFile); err == nil {
+		t.Fatal("expected Gemini file reference to be rejected for Claude")
+	}
+	wav := []byte(`{"messages":[{"role":"user","content":[{"type":"input_audio","input_audio":{"data":"` + wavBase64(t, 16000, 16) + `","format":"wav"}}]}]}`)
+	if err = CheckAttachments("openai", "gemini", wav); err != nil {
+		t.Fatalf("CheckAttachments(openai -> gemini, wav) = %v", err)
+	}
+	err = CheckAttachments("openai", "claude", wav)
+	if unsupported, ok = errors.AsType[*UnsupportedContentError](err); !ok || unsupported.Kind != "audio" {
+		t.Fatalf("CheckAttachments(openai -> claude, wav) = %v", err)
+	}
+
+	if err = CheckAttachments("claude", "claude", claudeURL); err != nil {
+		t.Fatalf("passthrough requests must not be checked, got %v", err)
+	}
+}
@@ -0,0 +1,246 @@
+package common
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// audioMimeTypes maps OpenAI input_audio format names to media types.
+var audioMimeTypes = map[string]string{
+	"mp3":       "audio/mpeg",
+	"wav":       "audio/wav",
+	"aiff":      "audio/aiff",
+	"ogg":       "audio/ogg",
+	"flac":      "audio/flac",
+	"aac":       "audio/aac",
+	"webm":      "audio/webm",
+	"pcm16":     "audio/pcm",
+	"g711_ulaw": "audio/basic",
+	"g711_alaw": "audio/basic",
+}
+
+// audioFormatAliases maps media types that have no entry in audioMimeTypes to a format name.
+var audioFormatAliases = map[string]string{
+	"audio/mp3":    "mp3",
+	"audio/x-wav":  "wav",
+	"audio/wave":   "wav",
+	"audio/x-aiff": "aiff",
+	"audio/x-flac": "flac",
+}
+
+// audioHeaderBase64Len bounds how much of an audio payload is decoded to validate its header.
+const audioHeaderBase64Len = 4096
+
+// Audio is a provider-neutral audio prompt shared by the request translators. Format is the
+// OpenAI input_audio format name, such as "wav" or "mp3", and Data the base64 payload.
+type Audio struct {
+	Format   string
+	MimeType string
+	Data     string
+}
+
+func unsupportedAudio(format string, args ...any) error {
+	return unsupportedContent("audio", format, args...)
+}
+
+// AudioFromOpenAIInput reads an OpenAI input_audio object. The format defaults to wav.
+func AudioFromOpenAIInput(input gjson.Result) (Audio, bool) {
+	audio := Audio{
+		Format: strings.ToLower(strings.TrimSpace(input.Get("format").String())),
+		Data:   input.Get("data").String(),
+	}
+	if audio.Format == "" {
+		audio.Format = "wav"
+	}
+	audio.MimeType = audioMimeTypes[audio.Format]
+	return audio, audio.Data != ""
+}
+
+// AudioFromResponsesInput reads an OpenAI Responses input_audio content part, which carries the
+// data and format either inline or in a nested input_audio object.
+func AudioFromResponsesInput(part gjson.Result) (Audio, bool) {
+	if nested := part.Get("input_audio"); nested.IsObject() {
+		return AudioFromOpenAIInput(nested)
+	}
+	return AudioFromOpenAIInput(part)
+}
+
+// AudioFromGeminiPart reads inline audio from a Gemini content part.
+func AudioFromGeminiPart(part gjson.Result) (Audio, bool) {
+	inline := firstExisting(part, "inlineData", "inline_data")
+	mimeType := firstExisting(inline, "mimeType", "mime_type").String()
+	if !strings.HasPrefix(mimeType, "audio/") {
+		return Audio{}, false
+	}
+	audio := Audio{MimeType: mimeType, Data: inline.Get("data").String()}
+	baseType, _, _ := strings.Cut(mimeType, ";")
+	for format, candidate := range audioMimeTypes {
+		if candidate == baseType && format != "g711_alaw" {
+			audio.Format = format
+		}
+	}
+	if audio.Format == "" {
+		audio.Format = audioFormatAliases[baseType]
+	}
+	return audio, audio.Data != ""
+}
+
+// validate checks that the format is known and that WAV and MP3 payloads start with a
+// well-formed header at a supported sample rate, sample size and bitrate.
+func (a Audio) validate() error {
+	if a.MimeType == "" {
+		return unsupportedAudio("unknown audio format %q", a.Format)
+	}
+	if a.Format != "wav" && a.Format != "mp3" {
+		return nil
+	}
+	encoded := a.Data
+	if len(encoded) > audioHeaderBase64Len {
+		encoded = encoded[:audioHeaderBase64Len]
+	}
+	header, errDecode := base64.StdEncoding.DecodeString(encoded)
+	if errDecode != nil {
+		return unsupportedAudio("%s audio data is not valid base64", a.Format)
+	}
+	if a.Format == "wav" {
+		return validateWAVHeader(header)
+	}
+	return validateMP3Header(header)
+}
+
+// validateWAVHeader checks the RIFF container and the fmt chunk of a WAV file.
+func validateWAVHeader(header []byte) error {
+	if len(header) < 12 || string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return unsupportedAudio("wav audio does not start with a RIFF/WAVE header")
+	}
+	for offset := 12; offset+8 <= len(header); {
+		chunkID := string(header[offset : offset+4])
+		chunkSize := int(binary.LittleEndian.Uint32(header[offset+4 : offset+8]))
+		body := offset + 8
+		if chunkID != "fmt " {
+			offset = body + chunkSize + chunkSize%2
+			continue
+		}
+		if chunkSize < 16 || body+16 > len(header) {
+			break
+		}
+		channels := binary.LittleEndian.Uint16(header[body+2 : body+4])
+		sampleRate := binary.LittleEndian.Uint32(header[body+4 : body+8])
+		bitsPerSample := binary.LittleEndian.Uint16(header[body+14 : body+16])
+		if channels == 0 || channels > 8 {
+			return unsupportedAudio("wav audio has %d channels", channels)
+		}
+		if sampleRate < 8000 || sampleRate > 192000 {
+			return unsupportedAudio("wav sample rate %d Hz is outside 8000-192000 Hz", sampleRate)
+		}
+		switch bitsPerSample {
+		case 8, 16, 24, 32:
+		default:
+			return unsupportedAudio("wav sample size of %d bits is not supported", bitsPerSample)
+		}
+		return nil
+	}
+	return unsupportedAudio("wav audio has no fmt chunk")
+}
+
+// validateMP3Header checks for an ID3 tag or an MPEG audio frame with a valid bitrate.
+func validateMP3Header(header []byte) error {
+	if len(header) >= 3 && string(header[0:3]) == "ID3" {
+		return nil
+	}
+	if len(header) < 4 || header[0] != 0xFF || header[1]&0xE0 != 0xE0 {
+		return unsupportedAudio("mp3 audio does not start with an ID3 tag or MPEG frame")
+	}
+	if bitrateIndex := header[2] >> 4; bitrateIndex == 0 || bitrateIndex == 0x0F {
+		return unsupportedAudio("mp3 frame has an invalid or free-format bitrate")
+	}
+	if header[2]>>2&0x03 == 0x03 {
+		return unsupportedAudio("mp3 frame has an invalid sample rate")
+	}
+	return nil
+}
+
+// GeminiPart renders the audio as a Gemini inlineData part.
+func (a Audio) GeminiPart() ([]byte, error) {
+	if errValidate := a.validate(); errValidate != nil {
+		return nil, errValidate
+	}
+	part := []byte(`{"inlineData":{"mimeType":"","data":""}}`)
+	part, _ = sjson.SetBytes(part, "inlineData.mimeType", a.MimeType)
+	part, _ = sjson.SetBytes(part, "inlineData.data", a.Data)
+	return part, nil
+}
+
+// OpenAIPart renders the audio as an OpenAI Chat Completions input_audio content part, which
+// only accepts wav and mp3.
+func (a Audio) OpenAIPart() ([]byte, error) {
+	if a.Format != "wav" && a.Format != "mp3" {
+		return nil, unsupportedAudio("only wav and mp3 audio are accepted, got %s", a.MimeType)
+	}
+	if errValidate := a.validate(); errValidate != nil {
+		return nil, errValidate
+	}
+	part := []byte(`{"type":"input_audio","input_audio":{"data":"","format":""}}`)
+	part, _ = sjson.SetBytes(part, "input_audio.data", a.Data)
+	part, _ = sjson.SetBytes(part, "input_audio.format", a.Format)
+	return part, nil
+}
+
+func rejectAudio(Audio) ([]byte, error) {
+	return nil, unsupportedAudio("the target does not accept audio input")
+}
+
+// audioRenderers maps target formats to the renderer their request translators use.
+var audioRenderers = map[string]func(Audio) ([]byte, error){
+	"claude":          rejectAudio,
+	"gemini":          Audio.GeminiPart,
+	"gemini-cli":      Audio.GeminiPart,
+	"antigravity":     Audio.GeminiPart,
+	"openai":          Audio.OpenAIPart,
+	"openai-response": rejectAudio,
+	"codex":           rejectAudio,
+}
+
+// audioIn collects the audio prompts of a request in the given client format.
+func audioIn(format string, payload []byte) []Audio {
+	var prompts []Audio
+	add := func(audio Audio, ok bool) {
+		if ok {
+			prompts = append(prompts, audio)
+		}
+	}
+	root := gjson.ParseBytes(payload)
+	switch format {
+	case "openai":
+		for _, message := range root.Get("messages").Array() {
+			for _, item := range message.Get("content").Array() {
+				if item.Get("type").String() == "input_audio" {
+					add(AudioFromOpenAIInput(item.Get("input_audio")))
+				}
+			}
+		}
+	case "openai-response":
+		for _, item := range root.Get("input").Array() {
+			for _, part := range item.Get("content").Array() {
+				if part.Get("type").String() == "input_audio" {
+					add(AudioFromResponsesInput(part))
+				}
+			}
+		}
+	case "gemini", "gemini-cli", "antigravity":
+		contents := root.Get("contents")
+		if !contents.Exists() {
+			contents = root.Get("request.contents")
+		}
+		for _, content := range contents.Array() {
+			for _, part := range content.Get("parts").Array() {
+				add(AudioFromGeminiPart(part))
+			}
+		}
+	}
+	return prompts
+}
@@ -0,0 +1,99 @@
+package common
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+// wavBase64 returns a base64 mono PCM WAV header with the given sample rate and sample size.
+func wavBase64(t *testing.T, sampleRate uint32, bitsPerSample uint16) string {
+	t.Helper()
+	header := make([]byte, 44)
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], 36)
+	copy(header[8:12], "WAVE")
+	copy(header[12:16], "fmt ")
+	binary.LittleEndian.PutUint32(header[16:20], 16)
+	binary.LittleEndian.PutUint16(header[20:22], 1)
+	binary.LittleEndian.PutUint16(header[22:24], 1)
+	binary.LittleEndian.PutUint32(header[24:28], sampleRate)
+	binary.LittleEndian.PutUint32(header[28:32], sampleRate*uint32(bitsPerSample)/8)
+	binary.LittleEndian.PutUint16(header[32:34], bitsPerSample/8)
+	binary.LittleEndian.PutUint16(header[34:36], bitsPerSample)
+	copy(header[36:40], "data")
+	return base64.StdEncoding.EncodeToString(header)
+}
+
+func TestAudioFromOpenAIInput(t *testing.T) {
+	audio, ok := AudioFromOpenAIInput(gjson.Parse(`{"data":"AAA=","format":"MP3"}`))
+	if !ok || audio.Format != "mp3" || audio.MimeType != "audio/mpeg" {
+		t.Fatalf("input_audio parsed as %+v, %v", audio, ok)
+	}
+
+	// Responses parts may carry the audio inline instead of in a nested input_audio object.
+	audio, ok = AudioFromResponsesInput(gjson.Parse(`{"type":"input_audio","data":"AAA="}`))
+	if !ok || audio.Format != "wav" || audio.MimeType != "audio/wav" {
+		t.Fatalf("inline Responses audio parsed as %+v, %v", audio, ok)
+	}
+}
+
+func TestAudioFromGeminiPart(t *testing.T) {
+	audio, ok := AudioFromGeminiPart(gjson.Parse(`{"inline_data":{"mime_type":"audio/x-wav","data":"AAA="}}`))
+	if !ok || audio.Format != "wav" {
+		t.Fatalf("wav part parsed as %+v, %v", audio, ok)
+	}
+	audio, ok = AudioFromGeminiPart(gjson.Parse(`{"inlineData":{"mimeType":"audio/mpeg","data":"AAA="}}`))
+	if !ok || audio.Format != "mp3" {
+		t.Fatalf("mp3 part parsed as %+v, %v", audio, ok)
+	}
+	if audio, ok = AudioFromGeminiPart(gjson.Parse(`{"inlineData":{"mimeType":"image/png","data":"AAA="}}`)); ok {
+		t.Fatalf("image part unexpectedly parsed as audio %+v", audio)
+	}
+}
+
+func TestAudioValidation(t *testing.T) {
+	mp3 := base64.StdEncoding.EncodeToString([]byte{0xFF, 0xFB, 0x90, 0x64})
+	tests := []struct {
+		name  string
+		audio Audio
+		ok    bool
+	}{
+		{"wav 16kHz", Audio{Format: "wav", MimeType: "audio/wav", Data: wavBase64(t, 16000, 16)}, true},
+		{"wav low sample rate", Audio{Format: "wav", MimeType: "audio/wav", Data: wavBase64(t, 4000, 16)}, false},
+		{"wav 12-bit", Audio{Format: "wav", MimeType: "audio/wav", Data: wavBase64(t, 16000, 12)}, false},
+		{"wav without header", Audio{Format: "wav", MimeType: "audio/wav", Data: mp3}, false},
+		{"mp3 frame", Audio{Format: "mp3", MimeType: "audio/mpeg", Data: mp3}, true},
+		{"mp3 free bitrate", Audio{Format: "mp3", MimeType: "audio/mpeg", Data: base64.StdEncoding.EncodeToString([]byte{0xFF, 0xFB, 0x00, 0x64})}, false},
+		{"mp3 ID3 tag", Audio{Format: "mp3", MimeType: "audio/mpeg", Data: base64.StdEncoding.EncodeToString([]byte("ID3\x04"))}, true},
+		{"invalid base64", Audio{Format: "mp3", MimeType: "audio/mpeg", Data: "not base64!"}, false},
+		{"unknown format", Audio{Format: "opus", Data: "AAA="}, false},
+		{"unchecked format", Audio{Format: "flac", MimeType: "audio/flac", Data: "AAA="}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.audio.validate(); (err == nil) != tt.ok {
+				t.Fatalf("validate() = %v, want ok=%v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestAudioRendering(t *testing.T) {
+	wav := Audio{Format: "wav", MimeType: "audio/wav", Data: wavBase64(t, 24000, 16)}
+	part, err := wav.GeminiPart()
+	if err != nil || gjson.GetBytes(part, "inlineData.mimeType").String() != "audio/wav" {
+		t.Fatalf("GeminiPart() = %s, %v", part, err)
+	}
+	part, err = wav.OpenAIPart()
+	if err != nil || gjson.GetBytes(part, "input_audio.format").String() != "wav" {
+		t.Fatalf("OpenAIPart() = %s, %v", part, err)
+	}
+
+	flac := Audio{Format: "flac", MimeType: "audio/flac", Data: "AAA="}
+	if part, err = flac.OpenAIPart(); err == nil {
+		t.Fatalf("expected flac to be rejected for chat completions, got %s", part)
+	}
+}
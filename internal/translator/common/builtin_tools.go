@@ -0,0 +1,229 @@
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// BuiltinTool is a provider-hosted tool that Gemini offers natively. Its value is the key of
+// the Gemini tool declaration.
+type BuiltinTool string
+
+const (
+	// BuiltinToolWebSearch grounds the answer with Google Search.
+	BuiltinToolWebSearch BuiltinTool = "googleSearch"
+	// BuiltinToolCodeExecution lets the model write and run Python code.
+	BuiltinToolCodeExecution BuiltinTool = "codeExecution"
+)
+
+// builtinToolFromOpenAIType maps the hosted tool types of Chat Completions and Responses
+// requests.
+func builtinToolFromOpenAIType(toolType string) (BuiltinTool, bool) {
+	switch {
+	case toolType == "web_search" || strings.HasPrefix(toolType, "web_search_"):
+		return BuiltinToolWebSearch, true
+	case toolType == "code_interpreter":
+		return BuiltinToolCodeExecution, true
+	}
+	return "", false
+}
+
+// BuiltinToolsFromOpenAI reads the hosted tools of a Chat Completions request: web_search and
+// code_interpreter tool entries and the web_search_options parameter.
+func BuiltinToolsFromOpenAI(root gjson.Result) []BuiltinTool {
+	tools := BuiltinToolsFromResponses(root)
+	if root.Get("web_search_options").Exists() {
+		tools = append(tools, BuiltinToolWebSearch)
+	}
+	return tools
+}
+
+// BuiltinToolsFromResponses reads the web_search and code_interpreter tools of a Responses
+// request.
+func BuiltinToolsFromResponses(root gjson.Result) []BuiltinTool {
+	var tools []BuiltinTool
+	for _, tool := range root.Get("tools").Array() {
+		if builtin, ok := builtinToolFromOpenAIType(tool.Get("type").String()); ok {
+			tools = append(tools, builtin)
+		}
+	}
+	return tools
+}
+
+// BuiltinToolsFromClaude reads the versioned web_search and code_execution server tools of a
+// Claude request.
+func BuiltinToolsFromClaude(root gjson.Result) []BuiltinTool {
+	var tools []BuiltinTool
+	for _, tool := range root.Get("tools").Array() {
+		toolType := tool.Get("type").String()
+		switch {
+		case strings.HasPrefix(toolType, "web_search_"):
+			tools = append(tools, BuiltinToolWebSearch)
+		case strings.HasPrefix(toolType, "code_execution_"):
+			tools = append(tools, BuiltinToolCodeExecution)
+		}
+	}
+	return tools
+}
+
+// ApplyBuiltinToolsToGemini appends a declaration for each built-in tool to the Gemini tools
+// array at toolsPath, skipping tools that are already declared.
+func ApplyBuiltinToolsToGemini(out []byte, toolsPath string, tools []BuiltinTool) []byte {
+	for _, tool := range tools {
+		declared := false
+		for _, existing := range gjson.GetBytes(out, toolsPath).Array() {
+			if existing.Get(string(tool)).Exists() {
+				declared = true
+				break
+			}
+		}
+		if declared {
+			continue
+		}
+		declaration, _ := sjson.SetRawBytes([]byte(`{}`), string(tool), []byte(`{}`))
+		out, _ = sjson.SetRawBytes(out, toolsPath+".-1", declaration)
+	}
+	return out
+}
+
+// GeminiBuiltinToolPartsAsText rewrites the built-in tool output of every candidate of a Gemini
+// response, plain or wrapped under "response", into text parts that translators forward like
+// any other text: executableCode and codeExecutionResult parts become fenced code blocks, and
+// the web sources of googleSearch grounding are appended as a numbered list.
+func GeminiBuiltinToolPartsAsText(rawJSON []byte) []byte {
+	if !bytes.Contains(rawJSON, []byte("executableCode")) && !bytes.Contains(rawJSON, []byte("codeExecutionResult")) && !bytes.Contains(rawJSON, []byte("groundingChunks")) {
+		return rawJSON
+	}
+	candidatesPath := "candidates"
+	if gjson.GetBytes(rawJSON, "response.candidates").Exists() {
+		candidatesPath = "response.candidates"
+	}
+
+	out := rawJSON
+	for i, candidate := range gjson.GetBytes(rawJSON, candidatesPath).Array() {
+		partsPath := fmt.Sprintf("%s.%d.content.parts", candidatesPath, i)
+		for j, part := range candidate.Get("content.parts").Array() {
+			text, ok := builtinToolPartText(part)
+			if !ok {
+				continue
+			}
+			textPart, _ := sjson.SetBytes([]byte(`{}`), "text", text)
+			out, _ = sjson.SetRawBytes(out, fmt.Sprintf("%s.%d", partsPath, j), textPart)
+		}
+		if sources := groundingSourcesText(candidate); sources != "" {
+			out, _ = sjson.SetBytes(out, partsPath+".-1.text", sources)
+		}
+	}
+	return out
+}
+
+func builtinToolPartText(part gjson.Result) (string, bool) {
+	if code := part.Get("executableCode"); code.Exists() {
+		language := strings.ToLower(code.Get("language").String())
+		if language == "" || language == "language_unspecified" {
+			language = "python"
+		}
+		return "\n```" + language + "\n" + strings.TrimRight(code.Get("code").String(), "\n") + "\n```\n", true
+	}
+	if result := part.Get("codeExecutionResult"); result.Exists() {
+		header := ""
+		if outcome := result.Get("outcome").String(); outcome != "" && outcome != "OUTCOME_OK" {
+			header = "Execution failed (" + outcome + "):\n"
+		}
+		return "\n" + header + "```\n" + strings.TrimRight(result.Get("output").String(), "\n") + "\n```\n", true
+	}
+	return "", false
+}
+
+func groundingSourcesText(candidate gjson.Result) string {
+	var sources strings.Builder
+	n := 0
+	for _, chunk := range candidate.Get("groundingMetadata.groundingChunks").Array() {
+		uri := chunk.Get("web.uri").String()
+		if uri == "" {
+			continue
+		}
+		title := chunk.Get("web.title").String()
+		if title == "" {
+			title = uri
+		}
+		n++
+		fmt.Fprintf(&sources, "\n%d. [%s](%s)", n, title, uri)
+	}
+	if n == 0 {
+		return ""
+	}
+	return "\n\nSources:" + sources.String()
+}
+
+// URLCitation is a web source cited by a span of the answer text. StartIndex and EndIndex
+// count characters, as OpenAI annotations do.
+type URLCitation struct {
+	URL        string
+	Title      string
+	StartIndex int
+	EndIndex   int
+}
+
+// URLCitationsFromGemini reads the grounding supports of a Gemini candidate and locates each
+// supported segment in text, the answer text the citations refer to. Segments that cannot be
+// found in text are skipped.
+func URLCitationsFromGemini(candidate gjson.Result, text string) []URLCitation {
+	chunks := candidate.Get("groundingMetadata.groundingChunks").Array()
+	var citations []URLCitation
+	for _, support := range candidate.Get("groundingMetadata.groundingSupports").Array() {
+		segment := support.Get("segment.text").String()
+		offset := strings.Index(text, segment)
+		if segment == "" || offset < 0 {
+			continue
+		}
+		start := utf8.RuneCountInString(text[:offset])
+		end := start + utf8.RuneCountInString(segment)
+		for _, index := range support.Get("groundingChunkIndices").Array() {
+			i := int(index.Int())
+			if i < 0 || i >= len(chunks) || chunks[i].Get("web.uri").String() == "" {
+				continue
+			}
+			citations = append(citations, URLCitation{
+				URL:        chunks[i].Get("web.uri").String(),
+				Title:      chunks[i].Get("web.title").String(),
+				StartIndex: start,
+				EndIndex:   end,
+			})
+		}
+	}
+	return citations
+}
+
+// OpenAIAnnotations renders citations as the annotations of a Chat Completions message.
+func OpenAIAnnotations(citations []URLCitation) []byte {
+	out := []byte(`[]`)
+	for _, citation := range citations {
+		annotation := []byte(`{"type":"url_citation","url_citation":{"url":"","title":"","start_index":0,"end_index":0}}`)
+		annotation, _ = sjson.SetBytes(annotation, "url_citation.url", citation.URL)
+		annotation, _ = sjson.SetBytes(annotation, "url_citation.title", citation.Title)
+		annotation, _ = sjson.SetBytes(annotation, "url_citation.start_index", citation.StartIndex)
+		annotation, _ = sjson.SetBytes(annotation, "url_citation.end_index", citation.EndIndex)
+		out, _ = sjson.SetRawBytes(out, "-1", annotation)
+	}
+	return out
+}
+
+// ResponsesAnnotations renders citations as the annotations of a Responses output_text part.
+func ResponsesAnnotations(citations []URLCitation) []byte {
+	out := []byte(`[]`)
+	for _, citation := range citations {
+		annotation := []byte(`{"type":"url_citation","url":"","title":"","start_index":0,"end_index":0}`)
+		annotation, _ = sjson.SetBytes(annotation, "url", citation.URL)
+		annotation, _ = sjson.SetBytes(annotation, "title", citation.Title)
+		annotation, _ = sjson.SetBytes(annotation, "start_index", citation.StartIndex)
+		annotation, _ = sjson.SetBytes(annotation, "end_index", citation.EndIndex)
+		out, _ = sjson.SetRawBytes(out, "-1", annotation)
+	}
+	return out
+}
@@ -0,0 +1,92 @@
+package common
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestBuiltinToolsReaders(t *testing.T) {
+	openai := gjson.Parse(`{"tools":[{"type":"function","function":{"name":"f"}},{"type":"code_interpreter"}],"web_search_options":{}}`)
+	if got := BuiltinToolsFromOpenAI(openai); !slices.Equal(got, []BuiltinTool{BuiltinToolCodeExecution, BuiltinToolWebSearch}) {
+		t.Fatalf("BuiltinToolsFromOpenAI() = %v", got)
+	}
+	responses := gjson.Parse(`{"tools":[{"type":"web_search_preview"},{"type":"function","name":"f"}]}`)
+	if got := BuiltinToolsFromResponses(responses); !slices.Equal(got, []BuiltinTool{BuiltinToolWebSearch}) {
+		t.Fatalf("BuiltinToolsFromResponses() = %v", got)
+	}
+	claude := gjson.Parse(`{"tools":[{"type":"web_search_20250305","name":"web_search"},{"type":"code_execution_20250522","name":"code_execution"},{"name":"f","input_schema":{}}]}`)
+	if got := BuiltinToolsFromClaude(claude); !slices.Equal(got, []BuiltinTool{BuiltinToolWebSearch, BuiltinToolCodeExecution}) {
+		t.Fatalf("BuiltinToolsFromClaude() = %v", got)
+	}
+}
+
+func TestApplyBuiltinToolsToGemini(t *testing.T) {
+	out := []byte(`{"request":{"tools":[{"functionDeclarations":[]},{"googleSearch":{}}]}}`)
+	out = ApplyBuiltinToolsToGemini(out, "request.tools", []BuiltinTool{BuiltinToolWebSearch, BuiltinToolCodeExecution, BuiltinToolCodeExecution})
+	if got := gjson.GetBytes(out, "request.tools").Raw; got != `[{"functionDeclarations":[]},{"googleSearch":{}},{"codeExecution":{}}]` {
+		t.Fatalf("tools = %s", got)
+	}
+	if out := ApplyBuiltinToolsToGemini([]byte(`{}`), "tools", nil); string(out) != `{}` {
+		t.Fatalf("no tools must leave the request unchanged: %s", out)
+	}
+}
+
+func TestGeminiBuiltinToolPartsAsText(t *testing.T) {
+	raw := []byte(`{"response":{"candidates":[{"content":{"parts":[
+		{"executableCode":{"language":"PYTHON","code":"print(1+1)\n"}},
+		{"codeExecutionResult":{"outcome":"OUTCOME_OK","output":"2\n"}},
+		{"text":"The answer is 2."}
+	]},"groundingMetadata":{"groundingChunks":[{"web":{"uri":"https://example.com/a","title":"Example"}}]}}]}}`)
+
+	parts := gjson.GetBytes(GeminiBuiltinToolPartsAsText(raw), "response.candidates.0.content.parts").Array()
+	if len(parts) != 4 {
+		t.Fatalf("parts = %v", parts)
+	}
+	if got := parts[0].Get("text").String(); got != "\n```python\nprint(1+1)\n```\n" {
+		t.Fatalf("code part = %q", got)
+	}
+	if got := parts[1].Get("text").String(); got != "\n```\n2\n```\n" {
+		t.Fatalf("result part = %q", got)
+	}
+	if got := parts[3].Get("text").String(); got != "\n\nSources:\n1. [Example](https://example.com/a)" {
+		t.Fatalf("sources part = %q", got)
+	}
+
+	plain := []byte(`{"candidates":[{"content":{"parts":[{"text":"hi"}]}}]}`)
+	if out := GeminiBuiltinToolPartsAsText(plain); string(out) != string(plain) {
+		t.Fatalf("responses without built-in tool output must be unchanged: %s", out)
+	}
+}
+
+func TestURLCitationsFromGemini(t *testing.T) {
+	text := "Café prices rose. Rents fell."
+	candidate := gjson.Parse(`{"groundingMetadata":{
+		"groundingChunks":[{"web":{"uri":"https://example.com/a","title":"A"}},{"web":{"uri":"https://example.com/b","title":"B"}}],
+		"groundingSupports":[
+			{"segment":{"text":"Rents fell."},"groundingChunkIndices":[1]},
+			{"segment":{"text":"not in the answer"},"groundingChunkIndices":[0]}
+		]}}`)
+
+	citations := URLCitationsFromGemini(candidate, text)
+	if len(citations) != 1 {
+		t.Fatalf("citations = %+v", citations)
+	}
+	if c := citations[0]; c.URL != "https://example.com/b" || c.StartIndex != 18 || c.EndIndex != 29 {
+		t.Fatalf("citation = %+v", c)
+	}
+	if got := []rune(text)[citations[0].StartIndex:citations[0].EndIndex]; string(got) != "Rents fell." {
+		t.Fatalf("citation span = %q", string(got))
+	}
+
+	openai := gjson.ParseBytes(OpenAIAnnotations(citations))
+	if openai.Get("0.type").String() != "url_citation" || openai.Get("0.url_citation.title").String() != "B" {
+		t.Fatalf("OpenAIAnnotations() = %s", openai.Raw)
+	}
+	responses := gjson.ParseBytes(ResponsesAnnotations(citations))
+	if !strings.Contains(responses.Raw, `"url":"https://example.com/b"`) || responses.Get("0.end_index").Int() != 29 {
+		t.Fatalf("ResponsesAnnotations() = %s", responses.Raw)
+	}
+}
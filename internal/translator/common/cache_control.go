@@ -0,0 +1,16 @@
+package common
+
+import (
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// WithCacheControl copies the cache_control marker of source onto block. Claude blocks and the
+// content parts of OpenAI-compatible providers that support prompt caching share the
+// {"type":"ephemeral"} marker layout, so the marker is copied verbatim.
+func WithCacheControl(block []byte, source gjson.Result) []byte {
+	if cacheControl := source.Get("cache_control"); cacheControl.IsObject() {
+		block, _ = sjson.SetRawBytes(block, "cache_control", []byte(cacheControl.Raw))
+	}
+	return block
+}
@@ -0,0 +1,343 @@
+package common
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const pdfMimeType = "application/pdf"
+
+// Document is a provider-neutral document attachment, such as a PDF, shared by the request
+// translators. Data holds a base64 payload, URL a remote location, or FileID a file uploaded to
+// the provider of FileFormat; such references only resolve against that provider.
+type Document struct {
+	MimeType   string
+	Data       string
+	URL        string
+	FileID     string
+	FileFormat string
+	Filename   string
+}
+
+func unsupportedDocument(format string, args ...any) error {
+	return unsupportedContent("document", format, args...)
+}
+
+// DocumentFromOpenAIFile reads the file object of an OpenAI Chat Completions file content part.
+func DocumentFromOpenAIFile(file gjson.Result) (Document, bool) {
+	doc := Document{Filename: file.Get("filename").String()}
+	if fileID := file.Get("file_id").String(); fileID != "" {
+		doc.FileID, doc.FileFormat = fileID, "openai"
+		doc.MimeType = mimeTypeFromName(doc.Filename)
+		return doc, true
+	}
+	return doc.withFileData(file.Get("file_data").String())
+}
+
+// DocumentFromResponsesFile reads an OpenAI Responses input_file content part.
+func DocumentFromResponsesFile(part gjson.Result) (Document, bool) {
+	doc := Document{Filename: part.Get("filename").String()}
+	if fileID := part.Get("file_id").String(); fileID != "" {
+		doc.FileID, doc.FileFormat = fileID, "openai"
+		doc.MimeType = mimeTypeFromName(doc.Filename)
+		return doc, true
+	}
+	if fileURL := part.Get("file_url").String(); fileURL != "" {
+		doc.URL = fileURL
+		if doc.MimeType = mimeTypeFromName(doc.Filename); doc.MimeType == "" {
+			doc.MimeType = mimeTypeFromURL(fileURL)
+		}
+		return doc, true
+	}
+	return doc.withFileData(part.Get("file_data").String())
+}
+
+// withFileData fills the payload from an OpenAI file_data value, which is a base64 data URL or
+// bare base64 data.
+func (doc Document) withFileData(fileData string) (Document, bool) {
+	if fileData == "" {
+		return doc, false
+	}
+	if mimeType, data, ok := ParseDataURL(fileData); ok {
+		doc.MimeType, doc.Data = mimeType, data
+	} else if strings.HasPrefix(fileData, "data:") {
+		return doc, false
+	} else {
+		doc.Data = fileData
+	}
+	if doc.MimeType == "" || doc.MimeType == defaultMimeType {
+		if mimeType := mimeTypeFromName(doc.Filename); mimeType != "" {
+			doc.MimeType = mimeType
+		}
+	}
+	if doc.MimeType == "" {
+		doc.MimeType = defaultMimeType
+	}
+	return doc, true
+}
+
+// DocumentFromClaudeBlock reads a Claude document content block. Plain-text and content
+// sources are converted to base64 text/plain payloads.
+func DocumentFromClaudeBlock(block gjson.Result) (Document, bool) {
+	source := block.Get("source")
+	doc := Document{Filename: block.Get("title").String()}
+	switch source.Get("type").String() {
+	case "base64":
+		doc.Data = source.Get("data").String()
+		if doc.MimeType = source.Get("media_type").String(); doc.MimeType == "" {
+			doc.MimeType = pdfMimeType
+		}
+	case "text":
+		if text := source.Get("data").String(); text != "" {
+			doc.Data = base64.StdEncoding.EncodeToString([]byte(text))
+		}
+		if doc.MimeType = source.Get("media_type").String(); doc.MimeType == "" {
+			doc.MimeType = "text/plain"
+		}
+	case "content":
+		var text strings.Builder
+		for _, item := range source.Get("content").Array() {
+			if item.Get("type").String() == "text" {
+				text.WriteString(item.Get("text").String())
+			}
+		}
+		if text.Len() > 0 {
+			doc.Data = base64.StdEncoding.EncodeToString([]byte(text.String()))
+		}
+		doc.MimeType = "text/plain"
+	case "url":
+		// Claude only accepts PDFs by URL.
+		doc.URL, doc.MimeType = source.Get("url").String(), pdfMimeType
+		return doc, doc.URL != ""
+	case "file":
+		doc.FileID, doc.FileFormat = source.Get("file_id").String(), "claude"
+		return doc, doc.FileID != ""
+	default:
+		return doc, false
+	}
+	return doc, doc.Data != ""
+}
+
+// DocumentFromGeminiPart reads a document from a Gemini inline or file part. Parts carrying
+// images, audio or video are not documents. File URIs outside HTTP(S), such as Cloud Storage
+// and Gemini Files API locations, only resolve for Gemini.
+func DocumentFromGeminiPart(part gjson.Result) (Document, bool) {
+	if inline := firstExisting(part, "inlineData", "inline_data"); inline.Exists() {
+		doc := Document{
+			MimeType: firstExisting(inline, "mimeType", "mime_type").String(),
+			Data:     inline.Get("data").String(),
+		}
+		return doc, doc.Data != "" && isDocumentMimeType(doc.MimeType)
+	}
+	if file := firstExisting(part, "fileData", "file_data"); file.Exists() {
+		fileURI := firstExisting(file, "fileUri", "file_uri").String()
+		doc := Document{MimeType: firstExisting(file, "mimeType", "mime_type").String()}
+		if doc.MimeType == "" {
+			doc.MimeType = mimeTypeFromURL(fileURI)
+		}
+		if isGeminiFileURI(fileURI) {
+			doc.FileID, doc.FileFormat = fileURI, "gemini"
+		} else {
+			doc.URL = fileURI
+		}
+		return doc, fileURI != "" && isDocumentMimeType(doc.MimeType)
+	}
+	return Document{}, false
+}
+
+func isDocumentMimeType(mimeType string) bool {
+	return mimeType != defaultMimeType && (strings.HasPrefix(mimeType, "application/") || strings.HasPrefix(mimeType, "text/"))
+}
+
+func isGeminiFileURI(fileURI string) bool {
+	if strings.HasPrefix(fileURI, "https://generativelanguage.googleapis.com/") {
+		return true
+	}
+	return !strings.HasPrefix(fileURI, "https://") && !strings.HasPrefix(fileURI, "http://")
+}
+
+// dataURL returns the base64 payload as a data URL.
+func (doc Document) dataURL() string {
+	return "data:" + doc.MimeType + ";base64," + doc.Data
+}
+
+// filename returns the document file name, deriving one from the media type when missing.
+func (doc Document) filename() string {
+	if doc.Filename != "" {
+		return doc.Filename
+	}
+	if doc.MimeType == pdfMimeType {
+		return "document.pdf"
+	}
+	if strings.HasPrefix(doc.MimeType, "text/") {
+		return "document.txt"
+	}
+	return "document"
+}
+
+// ClaudeBlock renders the document as a Claude document block. Claude accepts PDFs, by value or
+// URL, and plain text.
+func (doc Document) ClaudeBlock() ([]byte, error) {
+	var block []byte
+	switch {
+	case doc.FileID != "":
+		if doc.FileFormat != "claude" {
+			return nil, unsupportedDocument("%s file %q cannot be resolved; send the file content instead", doc.FileFormat, doc.FileID)
+		}
+		block = []byte(`{"type":"document","source":{"type":"file","file_id":""}}`)
+		block, _ = sjson.SetBytes(block, "source.file_id", doc.FileID)
+	case doc.URL != "":
+		if doc.MimeType != "" && doc.MimeType != pdfMimeType {
+			return nil, unsupportedDocument("only PDF documents can be referenced by URL, got %s", doc.MimeType)
+		}
+		block = []byte(`{"type":"document","source":{"type":"url","url":""}}`)
+		block, _ = sjson.SetBytes(block, "source.url", doc.URL)
+	case doc.MimeType == pdfMimeType:
+		block = []byte(`{"type":"document","source":{"type":"base64","media_type":"application/pdf","data":""}}`)
+		block, _ = sjson.SetBytes(block, "source.data", doc.Data)
+	case strings.HasPrefix(doc.MimeType, "text/"):
+		text, errDecode := base64.StdEncoding.DecodeString(doc.Data)
+		if errDecode != nil {
+			return nil, unsupportedDocument("%s document is not valid base64", doc.MimeType)
+		}
+		block = []byte(`{"type":"document","source":{"type":"text","media_type":"text/plain","data":""}}`)
+		block, _ = sjson.SetBytes(block, "source.data", string(text))
+	default:
+		return nil, unsupportedDocument("media type %s is not supported, only PDF and plain text", doc.MimeType)
+	}
+	if doc.Filename != "" {
+		block, _ = sjson.SetBytes(block, "title", doc.Filename)
+	}
+	return block, nil
+}
+
+// GeminiPart renders the document as a Gemini content part: inlineData for base64 payloads and
+// fileData for URLs and Gemini file references. Gemini needs the media type of every part.
+func (doc Document) GeminiPart() ([]byte, error) {
+	fileURI := doc.URL
+	if doc.FileID != "" {
+		if doc.FileFormat != "gemini" {
+			return nil, unsupportedDocument("%s file %q cannot be resolved; send the file content instead", doc.FileFormat, doc.FileID)
+		}
+		fileURI = doc.FileID
+	}
+	if doc.MimeType == "" || doc.MimeType == defaultMimeType {
+		return nil, unsupportedDocument("the media type of %s is unknown", doc.filename())
+	}
+	if fileURI != "" {
+		part := []byte(`{"fileData":{"mimeType":"","fileUri":""}}`)
+		part, _ = sjson.SetBytes(part, "fileData.mimeType", doc.MimeType)
+		part, _ = sjson.SetBytes(part, "fileData.fileUri", fileURI)
+		return part, nil
+	}
+	part := []byte(`{"inlineData":{"mimeType":"","data":""}}`)
+	part, _ = sjson.SetBytes(part, "inlineData.mimeType", doc.MimeType)
+	part, _ = sjson.SetBytes(part, "inlineData.data", doc.Data)
+	return part, nil
+}
+
+// OpenAIPart renders the document as an OpenAI Chat Completions file content part, which takes
+// inline data or an OpenAI file ID but no URL.
+func (doc Document) OpenAIPart() ([]byte, error) {
+	part := []byte(`{"type":"file","file":{}}`)
+	switch {
+	case doc.FileID != "":
+		if doc.FileFormat != "openai" {
+			return nil, unsupportedDocument("%s file %q cannot be resolved; send the file content instead", doc.FileFormat, doc.FileID)
+		}
+		part, _ = sjson.SetBytes(part, "file.file_id", doc.FileID)
+	case doc.URL != "":
+		return nil, unsupportedDocument("chat completions file parts cannot reference URL %s", doc.URL)
+	default:
+		part, _ = sjson.SetBytes(part, "file.filename", doc.filename())
+		part, _ = sjson.SetBytes(part, "file.file_data", doc.dataURL())
+	}
+	return part, nil
+}
+
+// ResponsesPart renders the document as an OpenAI Responses input_file content part.
+func (doc Document) ResponsesPart() ([]byte, error) {
+	part := []byte(`{"type":"input_file"}`)
+	switch {
+	case doc.FileID != "":
+		if doc.FileFormat != "openai" {
+			return nil, unsupportedDocument("%s file %q cannot be resolved; send the file content instead", doc.FileFormat, doc.FileID)
+		}
+		part, _ = sjson.SetBytes(part, "file_id", doc.FileID)
+	case doc.URL != "":
+		part, _ = sjson.SetBytes(part, "file_url", doc.URL)
+	default:
+		part, _ = sjson.SetBytes(part, "filename", doc.filename())
+		part, _ = sjson.SetBytes(part, "file_data", doc.dataURL())
+	}
+	return part, nil
+}
+
+// documentRenderers maps target formats to the renderer their request translators use.
+var documentRenderers = map[string]func(Document) ([]byte, error){
+	"claude":          Document.ClaudeBlock,
+	"gemini":          Document.GeminiPart,
+	"gemini-cli":      Document.GeminiPart,
+	"antigravity":     Document.GeminiPart,
+	"openai":          Document.OpenAIPart,
+	"openai-response": Document.ResponsesPart,
+	"codex":           Document.ResponsesPart,
+}
+
+// documentsIn collects the document attachments of a request in the given client format.
+func documentsIn(format string, payload []byte) []Document {
+	var docs []Document
+	add := func(doc Document, ok bool) {
+		if ok {
+			docs = append(docs, doc)
+		}
+	}
+	root := gjson.ParseBytes(payload)
+	switch format {
+	case "openai":
+		for _, message := range root.Get("messages").Array() {
+			for _, item := range message.Get("content").Array() {
+				if item.Get("type").String() == "file" {
+					add(DocumentFromOpenAIFile(item.Get("file")))
+				}
+			}
+		}
+	case "openai-response":
+		for _, item := range root.Get("input").Array() {
+			for _, part := range item.Get("content").Array() {
+				if part.Get("type").String() == "input_file" {
+					add(DocumentFromResponsesFile(part))
+				}
+			}
+		}
+	case "claude":
+		var walk func(content gjson.Result)
+		walk = func(content gjson.Result) {
+			for _, block := range content.Array() {
+				switch block.Get("type").String() {
+				case "document":
+					add(DocumentFromClaudeBlock(block))
+				case "tool_result":
+					walk(block.Get("content"))
+				}
+			}
+		}
+		for _, message := range root.Get("messages").Array() {
+			walk(message.Get("content"))
+		}
+	case "gemini", "gemini-cli", "antigravity":
+		contents := root.Get("contents")
+		if !contents.Exists() {
+			contents = root.Get("request.contents")
+		}
+		for _, content := range contents.Array() {
+			for _, part := range content.Get("parts").Array() {
+				add(DocumentFromGeminiPart(part))
+			}
+		}
+	}
+	return docs
+}
@@ -0,0 +1,86 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestDocumentFromOpenAIFile(t *testing.T) {
+	doc, ok := DocumentFromOpenAIFile(gjson.Parse(`{"filename":"report.pdf","file_data":"data:application/pdf;base64,JVBERi0="}`))
+	if !ok || doc.MimeType != "application/pdf" || doc.Data != "JVBERi0=" || doc.Filename != "report.pdf" {
+		t.Fatalf("data URL file parsed as %+v, %v", doc, ok)
+	}
+
+	// Bare base64 data takes its media type from the file name.
+	doc, ok = DocumentFromOpenAIFile(gjson.Parse(`{"filename":"notes.txt","file_data":"aGk="}`))
+	if !ok || doc.MimeType != "text/plain" || doc.Data != "aGk=" {
+		t.Fatalf("bare base64 file parsed as %+v, %v", doc, ok)
+	}
+
+	doc, ok = DocumentFromOpenAIFile(gjson.Parse(`{"file_id":"file-abc"}`))
+	if !ok || doc.FileID != "file-abc" || doc.FileFormat != "openai" {
+		t.Fatalf("file reference parsed as %+v, %v", doc, ok)
+	}
+}
+
+func TestDocumentFromClaudeBlock(t *testing.T) {
+	doc, ok := DocumentFromClaudeBlock(gjson.Parse(`{"type":"document","title":"spec","source":{"type":"text","media_type":"text/plain","data":"hi"}}`))
+	if !ok || doc.MimeType != "text/plain" || doc.Data != "aGk=" || doc.Filename != "spec" {
+		t.Fatalf("text source parsed as %+v, %v", doc, ok)
+	}
+
+	doc, ok = DocumentFromClaudeBlock(gjson.Parse(`{"type":"document","source":{"type":"url","url":"https://example.com/a.pdf"}}`))
+	if !ok || doc.URL != "https://example.com/a.pdf" || doc.MimeType != "application/pdf" {
+		t.Fatalf("url source parsed as %+v, %v", doc, ok)
+	}
+}
+
+func TestDocumentFromGeminiPart(t *testing.T) {
+	doc, ok := DocumentFromGeminiPart(gjson.Parse(`{"inline_data":{"mime_type":"application/pdf","data":"JVBERi0="}}`))
+	if !ok || doc.MimeType != "application/pdf" || doc.Data != "JVBERi0=" {
+		t.Fatalf("inline part parsed as %+v, %v", doc, ok)
+	}
+
+	doc, ok = DocumentFromGeminiPart(gjson.Parse(`{"fileData":{"mimeType":"application/pdf","fileUri":"gs://bucket/a.pdf"}}`))
+	if !ok || doc.FileID != "gs://bucket/a.pdf" || doc.FileFormat != "gemini" {
+		t.Fatalf("storage file part parsed as %+v, %v", doc, ok)
+	}
+
+	for _, raw := range []string{
+		`{"inlineData":{"mimeType":"image/png","data":"AAA"}}`,
+		`{"inlineData":{"mimeType":"audio/wav","data":"AAA"}}`,
+		`{"inlineData":{"data":"AAA"}}`,
+	} {
+		if doc, ok = DocumentFromGeminiPart(gjson.Parse(raw)); ok {
+			t.Fatalf("%s unexpectedly parsed as document %+v", raw, doc)
+		}
+	}
+}
+
+func TestDocumentRendering(t *testing.T) {
+	pdf := Document{MimeType: "application/pdf", Data: "JVBERi0="}
+
+	block, err := pdf.ClaudeBlock()
+	if err != nil || gjson.GetBytes(block, "source.type").String() != "base64" || gjson.GetBytes(block, "source.data").String() != "JVBERi0=" {
+		t.Fatalf("ClaudeBlock() = %s, %v", block, err)
+	}
+	part, err := pdf.GeminiPart()
+	if err != nil || gjson.GetBytes(part, "inlineData.mimeType").String() != "application/pdf" {
+		t.Fatalf("GeminiPart() = %s, %v", part, err)
+	}
+	part, err = pdf.OpenAIPart()
+	if err != nil || gjson.GetBytes(part, "file.file_data").String() != "data:application/pdf;base64,JVBERi0=" || gjson.GetBytes(part, "file.filename").String() != "document.pdf" {
+		t.Fatalf("OpenAIPart() = %s, %v", part, err)
+	}
+	part, err = pdf.ResponsesPart()
+	if err != nil || gjson.GetBytes(part, "type").String() != "input_file" || gjson.GetBytes(part, "file_data").String() != "data:application/pdf;base64,JVBERi0=" {
+		t.Fatalf("ResponsesPart() = %s, %v", part, err)
+	}
+
+	text := Document{MimeType: "text/plain", Data: "aGk="}
+	block, err = text.ClaudeBlock()
+	if err != nil || gjson.GetBytes(block, "source.type").String() != "text" || gjson.GetBytes(block, "source.data").String() != "hi" {
+		t.Fatalf("text ClaudeBlock() = %s, %v", block, err)
+	}
+}
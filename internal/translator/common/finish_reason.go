@@ -0,0 +1,130 @@
+package common
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Termination reasons of the Chat Completions format, which the mappings below use as the
+// common vocabulary between providers.
+const (
+	FinishReasonStop          = "stop"
+	FinishReasonLength        = "length"
+	FinishReasonToolCalls     = "tool_calls"
+	FinishReasonContentFilter = "content_filter"
+)
+
+// OpenAIFinishReasonFromGemini maps a Gemini finishReason to a Chat Completions finish_reason.
+// Safety, recitation and other policy blocks become content_filter; reasons without an
+// equivalent, such as MALFORMED_FUNCTION_CALL or OTHER, become stop.
+func OpenAIFinishReasonFromGemini(reason string) string {
+	switch strings.ToUpper(strings.TrimSpace(reason)) {
+	case "MAX_TOKENS":
+		return FinishReasonLength
+	case "SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII",
+		"IMAGE_SAFETY", "IMAGE_PROHIBITED_CONTENT", "IMAGE_RECITATION":
+		return FinishReasonContentFilter
+	}
+	return FinishReasonStop
+}
+
+// OpenAIFinishReasonFromClaude maps a Claude stop_reason to a Chat Completions finish_reason.
+func OpenAIFinishReasonFromClaude(reason string) string {
+	switch reason {
+	case "max_tokens", "model_context_window_exceeded":
+		return FinishReasonLength
+	case "tool_use":
+		return FinishReasonToolCalls
+	case "refusal":
+		return FinishReasonContentFilter
+	}
+	return FinishReasonStop
+}
+
+// ClaudeStopReasonFromOpenAI maps a Chat Completions finish_reason to a Claude stop_reason.
+func ClaudeStopReasonFromOpenAI(reason string) string {
+	switch reason {
+	case FinishReasonLength, "max_tokens":
+		return "max_tokens"
+	case FinishReasonToolCalls, "function_call":
+		return "tool_use"
+	case FinishReasonContentFilter:
+		return "refusal"
+	}
+	return "end_turn"
+}
+
+// ClaudeStopReasonFromGemini maps a Gemini finishReason to a Claude stop_reason.
+func ClaudeStopReasonFromGemini(reason string) string {
+	return ClaudeStopReasonFromOpenAI(OpenAIFinishReasonFromGemini(reason))
+}
+
+// GeminiFinishReasonFromOpenAI maps a Chat Completions finish_reason to a Gemini finishReason.
+// Gemini has no tool call reason, so tool calls finish with STOP.
+func GeminiFinishReasonFromOpenAI(reason string) string {
+	switch reason {
+	case FinishReasonLength, "max_tokens":
+		return "MAX_TOKENS"
+	case FinishReasonContentFilter:
+		return "SAFETY"
+	}
+	return "STOP"
+}
+
+// GeminiFinishReasonFromClaude maps a Claude stop_reason to a Gemini finishReason.
+func GeminiFinishReasonFromClaude(reason string) string {
+	return GeminiFinishReasonFromOpenAI(OpenAIFinishReasonFromClaude(reason))
+}
+
+// OpenAIFinishReasonFromResponses maps the status of a Responses API response, as carried by
+// response.completed and response.incomplete events, to a Chat Completions finish_reason.
+func OpenAIFinishReasonFromResponses(response gjson.Result) string {
+	switch response.Get("incomplete_details.reason").String() {
+	case "max_output_tokens":
+		return FinishReasonLength
+	case "content_filter":
+		return FinishReasonContentFilter
+	}
+	return FinishReasonStop
+}
+
+// ResponsesIncompleteReason returns the incomplete_details.reason of a Responses API response
+// that ended with the given Chat Completions finish_reason, or "" when the response completed.
+func ResponsesIncompleteReason(reason string) string {
+	switch reason {
+	case FinishReasonLength:
+		return "max_output_tokens"
+	case FinishReasonContentFilter:
+		return "content_filter"
+	}
+	return ""
+}
+
+// MarkResponsesIncomplete marks a Responses API response object as incomplete when finishReason,
+// a Chat Completions finish_reason, reports truncation or filtering.
+func MarkResponsesIncomplete(resp []byte, finishReason string) []byte {
+	reason := ResponsesIncompleteReason(finishReason)
+	if reason == "" {
+		return resp
+	}
+	resp, _ = sjson.SetBytes(resp, "status", "incomplete")
+	resp, _ = sjson.SetRawBytes(resp, "incomplete_details", []byte(`{"reason":""}`))
+	resp, _ = sjson.SetBytes(resp, "incomplete_details.reason", reason)
+	return resp
+}
+
+// ResponsesTerminalEvent turns a response.completed event into a response.incomplete event when
+// finishReason reports truncation or filtering, and returns the event with its type.
+func ResponsesTerminalEvent(completed []byte, finishReason string) ([]byte, string) {
+	reason := ResponsesIncompleteReason(finishReason)
+	if reason == "" {
+		return completed, "response.completed"
+	}
+	completed, _ = sjson.SetBytes(completed, "type", "response.incomplete")
+	completed, _ = sjson.SetBytes(completed, "response.status", "incomplete")
+	completed, _ = sjson.SetRawBytes(completed, "response.incomplete_details", []byte(`{"reason":""}`))
+	completed, _ = sjson.SetBytes(completed, "response.incomplete_details.reason", reason)
+	return completed, "response.incomplete"
+}
@@ -0,0 +1,53 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestFinishReasonMappings(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"gemini max tokens", OpenAIFinishReasonFromGemini("MAX_TOKENS"), FinishReasonLength},
+		{"gemini safety", OpenAIFinishReasonFromGemini("SAFETY"), FinishReasonContentFilter},
+		{"gemini prohibited content", OpenAIFinishReasonFromGemini("PROHIBITED_CONTENT"), FinishReasonContentFilter},
+		{"gemini malformed call", OpenAIFinishReasonFromGemini("MALFORMED_FUNCTION_CALL"), FinishReasonStop},
+		{"claude refusal", OpenAIFinishReasonFromClaude("refusal"), FinishReasonContentFilter},
+		{"claude tool use", OpenAIFinishReasonFromClaude("tool_use"), FinishReasonToolCalls},
+		{"claude context window", OpenAIFinishReasonFromClaude("model_context_window_exceeded"), FinishReasonLength},
+		{"openai content filter to claude", ClaudeStopReasonFromOpenAI(FinishReasonContentFilter), "refusal"},
+		{"gemini recitation to claude", ClaudeStopReasonFromGemini("RECITATION"), "refusal"},
+		{"claude refusal to gemini", GeminiFinishReasonFromClaude("refusal"), "SAFETY"},
+		{"openai length to gemini", GeminiFinishReasonFromOpenAI(FinishReasonLength), "MAX_TOKENS"},
+		{"responses max output tokens", OpenAIFinishReasonFromResponses(gjson.Parse(`{"status":"incomplete","incomplete_details":{"reason":"max_output_tokens"}}`)), FinishReasonLength},
+		{"responses content filter", OpenAIFinishReasonFromResponses(gjson.Parse(`{"status":"incomplete","incomplete_details":{"reason":"content_filter"}}`)), FinishReasonContentFilter},
+		{"responses completed", OpenAIFinishReasonFromResponses(gjson.Parse(`{"status":"completed"}`)), FinishReasonStop},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestResponsesTerminalEvent(t *testing.T) {
+	completed := []byte(`{"type":"response.completed","response":{"status":"completed","incomplete_details":null}}`)
+	if _, event := ResponsesTerminalEvent(completed, FinishReasonToolCalls); event != "response.completed" {
+		t.Fatalf("event = %q, want response.completed", event)
+	}
+	out, event := ResponsesTerminalEvent(completed, FinishReasonContentFilter)
+	if event != "response.incomplete" || gjson.GetBytes(out, "type").String() != event {
+		t.Fatalf("event = %q, payload = %s", event, out)
+	}
+	if gjson.GetBytes(out, "response.status").String() != "incomplete" || gjson.GetBytes(out, "response.incomplete_details.reason").String() != "content_filter" {
+		t.Fatalf("payload = %s", out)
+	}
+	resp := MarkResponsesIncomplete([]byte(`{"status":"completed","incomplete_details":null}`), FinishReasonLength)
+	if gjson.GetBytes(resp, "incomplete_details.reason").String() != "max_output_tokens" {
+		t.Fatalf("MarkResponsesIncomplete() = %s", resp)
+	}
+}
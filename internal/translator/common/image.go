@@ -0,0 +1,190 @@
+package common
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// defaultMimeType is used when a base64 attachment carries no media type.
+const defaultMimeType = "application/octet-stream"
+
+// Image is a provider-neutral image reference shared by the request translators, so an image
+// read from any client format can be written in any target format. Either Data holds the
+// base64 payload (without a data URL prefix) or URL holds a remote location.
+type Image struct {
+	MimeType string
+	Data     string
+	URL      string
+}
+
+// ParseDataURL splits a base64 data URL into its media type and payload.
+func ParseDataURL(dataURL string) (mimeType, data string, ok bool) {
+	rest, found := strings.CutPrefix(dataURL, "data:")
+	if !found {
+		return "", "", false
+	}
+	meta, payload, found := strings.Cut(rest, ",")
+	if !found || payload == "" || !strings.HasSuffix(meta, ";base64") {
+		return "", "", false
+	}
+	mimeType, _, _ = strings.Cut(meta, ";")
+	if mimeType == "" {
+		mimeType = defaultMimeType
+	}
+	return mimeType, payload, true
+}
+
+// ImageFromURL reads an OpenAI image_url or Responses input_image value, which is either a
+// base64 data URL or a remote URL.
+func ImageFromURL(imageURL string) (Image, bool) {
+	imageURL = strings.TrimSpace(imageURL)
+	if imageURL == "" {
+		return Image{}, false
+	}
+	if mimeType, data, ok := ParseDataURL(imageURL); ok {
+		return Image{MimeType: mimeType, Data: data}, true
+	}
+	if strings.HasPrefix(imageURL, "data:") {
+		return Image{}, false
+	}
+	return Image{MimeType: mimeTypeFromURL(imageURL), URL: imageURL}, true
+}
+
+// ImageFromClaudeSource reads the source of a Claude image block, accepting base64 and url
+// sources.
+func ImageFromClaudeSource(source gjson.Result) (Image, bool) {
+	if source.Get("type").String() == "url" {
+		return ImageFromURL(source.Get("url").String())
+	}
+	data := source.Get("data").String()
+	if data == "" {
+		data = source.Get("base64").String()
+	}
+	if data == "" {
+		return Image{}, false
+	}
+	mimeType := source.Get("media_type").String()
+	if mimeType == "" {
+		mimeType = source.Get("mime_type").String()
+	}
+	if mimeType == "" {
+		mimeType = defaultMimeType
+	}
+	return Image{MimeType: mimeType, Data: data}, true
+}
+
+// ImageFromGeminiPart reads an inline or file-backed image from a Gemini content part. Both the
+// camelCase and snake_case field spellings accepted by the Gemini API are recognised. Inline
+// parts without a media type count as images; file parts only when they point at an image over
+// HTTP(S).
+func ImageFromGeminiPart(part gjson.Result) (Image, bool) {
+	if inline := firstExisting(part, "inlineData", "inline_data"); inline.Exists() {
+		data := inline.Get("data").String()
+		if data == "" {
+			return Image{}, false
+		}
+		mimeType := firstExisting(inline, "mimeType", "mime_type").String()
+		if mimeType == "" {
+			mimeType = defaultMimeType
+		}
+		if mimeType != defaultMimeType && !strings.HasPrefix(mimeType, "image/") {
+			return Image{}, false
+		}
+		return Image{MimeType: mimeType, Data: data}, true
+	}
+	if file := firstExisting(part, "fileData", "file_data"); file.Exists() {
+		fileURI := firstExisting(file, "fileUri", "file_uri").String()
+		if !strings.HasPrefix(fileURI, "https://") && !strings.HasPrefix(fileURI, "http://") {
+			return Image{}, false
+		}
+		mimeType := firstExisting(file, "mimeType", "mime_type").String()
+		if mimeType == "" {
+			mimeType = mimeTypeFromURL(fileURI)
+		}
+		if !strings.HasPrefix(mimeType, "image/") {
+			return Image{}, false
+		}
+		return Image{MimeType: mimeType, URL: fileURI}, true
+	}
+	return Image{}, false
+}
+
+// URLString returns the image as a data URL or its remote URL, the form OpenAI-style APIs take.
+func (img Image) URLString() string {
+	if img.URL != "" {
+		return img.URL
+	}
+	return "data:" + img.MimeType + ";base64," + img.Data
+}
+
+// OpenAIPart renders the image as an OpenAI Chat Completions image_url content part.
+func (img Image) OpenAIPart() []byte {
+	part := []byte(`{"type":"image_url","image_url":{"url":""}}`)
+	part, _ = sjson.SetBytes(part, "image_url.url", img.URLString())
+	return part
+}
+
+// ResponsesPart renders the image as an OpenAI Responses input_image content part.
+func (img Image) ResponsesPart() []byte {
+	part := []byte(`{"type":"input_image","image_url":""}`)
+	part, _ = sjson.SetBytes(part, "image_url", img.URLString())
+	return part
+}
+
+// ClaudeBlock renders the image as a Claude image content block.
+func (img Image) ClaudeBlock() []byte {
+	if img.URL != "" {
+		block := []byte(`{"type":"image","source":{"type":"url","url":""}}`)
+		block, _ = sjson.SetBytes(block, "source.url", img.URL)
+		return block
+	}
+	block := []byte(`{"type":"image","source":{"type":"base64","media_type":"","data":""}}`)
+	block, _ = sjson.SetBytes(block, "source.media_type", img.MimeType)
+	block, _ = sjson.SetBytes(block, "source.data", img.Data)
+	return block
+}
+
+// GeminiPart renders the image as a Gemini content part: inlineData for base64 payloads and
+// fileData for remote URLs.
+func (img Image) GeminiPart() []byte {
+	if img.URL != "" {
+		part := []byte(`{"fileData":{"fileUri":""}}`)
+		if img.MimeType != "" {
+			part, _ = sjson.SetBytes(part, "fileData.mimeType", img.MimeType)
+		}
+		part, _ = sjson.SetBytes(part, "fileData.fileUri", img.URL)
+		return part
+	}
+	part := []byte(`{"inlineData":{"mimeType":"","data":""}}`)
+	part, _ = sjson.SetBytes(part, "inlineData.mimeType", img.MimeType)
+	part, _ = sjson.SetBytes(part, "inlineData.data", img.Data)
+	return part
+}
+
+// mimeTypeFromURL guesses the media type of a remote attachment from its path extension.
+func mimeTypeFromURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return mimeTypeFromName(parsed.Path)
+}
+
+// mimeTypeFromName guesses a media type from a file name extension.
+func mimeTypeFromName(name string) string {
+	return misc.MimeTypes[strings.ToLower(strings.TrimPrefix(path.Ext(name), "."))]
+}
+
+func firstExisting(result gjson.Result, paths ...string) gjson.Result {
+	for _, p := range paths {
+		if value := result.Get(p); value.Exists() {
+			return value
+		}
+	}
+	return gjson.Result{}
+}
@@ -0,0 +1,134 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// remoteImage is a remote image found in a request, with the path of the content part or block
+// that holds it.
+type remoteImage struct {
+	path string
+	url  string
+}
+
+// InlineRemoteImages replaces the remote image URLs of a request in the given client format with
+// base64 images returned by fetch, so translation emits inline images only. Each distinct URL is
+// fetched once; the first fetch error is returned with the payload unchanged.
+func InlineRemoteImages(format string, payload []byte, fetch func(imageURL string) (Image, error)) ([]byte, error) {
+	images := remoteImagesIn(format, payload)
+	if len(images) == 0 {
+		return payload, nil
+	}
+	fetched := make(map[string]Image, len(images))
+	for _, remote := range images {
+		if _, ok := fetched[remote.url]; ok {
+			continue
+		}
+		img, err := fetch(remote.url)
+		if err != nil {
+			return payload, err
+		}
+		fetched[remote.url] = img
+	}
+	out := payload
+	for _, remote := range images {
+		img := fetched[remote.url]
+		var errSet error
+		switch format {
+		case "openai":
+			if gjson.GetBytes(out, remote.path+".image_url").IsObject() {
+				out, errSet = sjson.SetBytes(out, remote.path+".image_url.url", img.URLString())
+			} else {
+				out, errSet = sjson.SetBytes(out, remote.path+".image_url", img.URLString())
+			}
+		case "openai-response":
+			out, errSet = sjson.SetBytes(out, remote.path+".image_url", img.URLString())
+		case "claude":
+			out, errSet = sjson.SetRawBytes(out, remote.path+".source", []byte(gjson.GetBytes(img.ClaudeBlock(), "source").Raw))
+		case "gemini", "gemini-cli", "antigravity":
+			out, _ = sjson.DeleteBytes(out, remote.path+".fileData")
+			out, _ = sjson.DeleteBytes(out, remote.path+".file_data")
+			out, errSet = sjson.SetRawBytes(out, remote.path+".inlineData", []byte(gjson.GetBytes(img.GeminiPart(), "inlineData").Raw))
+		}
+		if errSet != nil {
+			return payload, fmt.Errorf("inline image %s: %w", remote.url, errSet)
+		}
+	}
+	return out, nil
+}
+
+// remoteImagesIn collects the HTTP(S) image URLs of a request in the given client format.
+func remoteImagesIn(format string, payload []byte) []remoteImage {
+	var images []remoteImage
+	add := func(path string, img Image, ok bool) {
+		if ok && isRemoteURL(img.URL) {
+			images = append(images, remoteImage{path: path, url: img.URL})
+		}
+	}
+	root := gjson.ParseBytes(payload)
+	switch format {
+	case "openai":
+		for i, message := range root.Get("messages").Array() {
+			for j, item := range message.Get("content").Array() {
+				if item.Get("type").String() != "image_url" {
+					continue
+				}
+				imageURL := item.Get("image_url.url")
+				if !imageURL.Exists() {
+					imageURL = item.Get("image_url")
+				}
+				img, ok := ImageFromURL(imageURL.String())
+				add(fmt.Sprintf("messages.%d.content.%d", i, j), img, ok)
+			}
+		}
+	case "openai-response":
+		for i, item := range root.Get("input").Array() {
+			for j, part := range item.Get("content").Array() {
+				if part.Get("type").String() == "input_image" {
+					img, ok := ImageFromURL(part.Get("image_url").String())
+					add(fmt.Sprintf("input.%d.content.%d", i, j), img, ok)
+				}
+			}
+		}
+	case "claude":
+		var walk func(path string, content gjson.Result)
+		walk = func(path string, content gjson.Result) {
+			for j, block := range content.Array() {
+				blockPath := fmt.Sprintf("%s.%d", path, j)
+				switch block.Get("type").String() {
+				case "image":
+					img, ok := ImageFromClaudeSource(block.Get("source"))
+					add(blockPath, img, ok)
+				case "tool_result":
+					walk(blockPath+".content", block.Get("content"))
+				}
+			}
+		}
+		for i, message := range root.Get("messages").Array() {
+			walk(fmt.Sprintf("messages.%d.content", i), message.Get("content"))
+		}
+	case "gemini", "gemini-cli", "antigravity":
+		contentsPath := "contents"
+		contents := root.Get(contentsPath)
+		if !contents.Exists() {
+			contentsPath = "request.contents"
+			contents = root.Get(contentsPath)
+		}
+		for i, content := range contents.Array() {
+			for j, part := range content.Get("parts").Array() {
+				img, ok := ImageFromGeminiPart(part)
+				add(fmt.Sprintf("%s.%d.parts.%d", contentsPath, i, j), img, ok)
+			}
+		}
+	}
+	return images
+}
+
+func isRemoteURL(rawURL string) bool {
+	lower := strings.ToLower(rawURL)
+	return strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "http://")
+}
@@ -0,0 +1,92 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestImageFromURL(t *testing.T) {
+	img, ok := ImageFromURL("data:image/png;base64,aGVsbG8=")
+	if !ok || img.MimeType != "image/png" || img.Data != "aGVsbG8=" || img.URL != "" {
+		t.Fatalf("data URL parsed as %+v, %v", img, ok)
+	}
+	if got := img.URLString(); got != "data:image/png;base64,aGVsbG8=" {
+		t.Fatalf("URLString() = %q", got)
+	}
+
+	img, ok = ImageFromURL("https://example.com/cat.JPG?size=large")
+	if !ok || img.URL != "https://example.com/cat.JPG?size=large" || img.MimeType != "image/jpeg" {
+		t.Fatalf("remote URL parsed as %+v, %v", img, ok)
+	}
+
+	if _, ok = ImageFromURL("data:image/png,not-base64"); ok {
+		t.Fatal("expected non-base64 data URL to be rejected")
+	}
+	if _, ok = ImageFromURL(""); ok {
+		t.Fatal("expected empty URL to be rejected")
+	}
+}
+
+func TestImageFromClaudeSource(t *testing.T) {
+	img, ok := ImageFromClaudeSource(gjson.Parse(`{"type":"base64","media_type":"image/webp","data":"AAA"}`))
+	if !ok || img.MimeType != "image/webp" || img.Data != "AAA" {
+		t.Fatalf("base64 source parsed as %+v, %v", img, ok)
+	}
+
+	img, ok = ImageFromClaudeSource(gjson.Parse(`{"type":"url","url":"https://example.com/a.png"}`))
+	if !ok || img.URL != "https://example.com/a.png" || img.MimeType != "image/png" {
+		t.Fatalf("url source parsed as %+v, %v", img, ok)
+	}
+
+	if _, ok = ImageFromClaudeSource(gjson.Parse(`{"type":"base64"}`)); ok {
+		t.Fatal("expected source without data to be rejected")
+	}
+}
+
+func TestImageFromGeminiPart(t *testing.T) {
+	for _, raw := range []string{
+		`{"inlineData":{"mimeType":"image/png","data":"AAA"}}`,
+		`{"inline_data":{"mime_type":"image/png","data":"AAA"}}`,
+	} {
+		img, ok := ImageFromGeminiPart(gjson.Parse(raw))
+		if !ok || img.MimeType != "image/png" || img.Data != "AAA" {
+			t.Fatalf("%s parsed as %+v, %v", raw, img, ok)
+		}
+	}
+
+	img, ok := ImageFromGeminiPart(gjson.Parse(`{"file_data":{"file_uri":"https://example.com/a.gif"}}`))
+	if !ok || img.URL != "https://example.com/a.gif" || img.MimeType != "image/gif" {
+		t.Fatalf("file part parsed as %+v, %v", img, ok)
+	}
+
+	for _, raw := range []string{
+		`{"fileData":{"fileUri":"https://example.com/doc.pdf","mimeType":"application/pdf"}}`,
+		`{"fileData":{"fileUri":"gs://bucket/a.png","mimeType":"image/png"}}`,
+		`{"text":"hello"}`,
+	} {
+		if img, ok = ImageFromGeminiPart(gjson.Parse(raw)); ok {
+			t.Fatalf("%s unexpectedly parsed as image %+v", raw, img)
+		}
+	}
+}
+
+func TestImageRoundTripsBetweenFormats(t *testing.T) {
+	inline := Image{MimeType: "image/png", Data: "AAA"}
+	remote := Image{MimeType: "image/png", URL: "https://example.com/a.png"}
+
+	for _, img := range []Image{inline, remote} {
+		if got, ok := ImageFromURL(gjson.GetBytes(img.OpenAIPart(), "image_url.url").String()); !ok || got != img {
+			t.Fatalf("OpenAI round trip of %+v gave %+v", img, got)
+		}
+		if got, ok := ImageFromURL(gjson.GetBytes(img.ResponsesPart(), "image_url").String()); !ok || got != img {
+			t.Fatalf("Responses round trip of %+v gave %+v", img, got)
+		}
+		if got, ok := ImageFromClaudeSource(gjson.GetBytes(img.ClaudeBlock(), "source")); !ok || got != img {
+			t.Fatalf("Claude round trip of %+v gave %+v", img, got)
+		}
+		if got, ok := ImageFromGeminiPart(gjson.ParseBytes(img.GeminiPart())); !ok || got != img {
+			t.Fatalf("Gemini round trip of %+v gave %+v", img, got)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package common
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// responsesLogprobsInclude is the include entry that asks the Responses API for output text
+// logprobs.
+const responsesLogprobsInclude = "message.output_text.logprobs"
+
+// maxTopLogprobs is the largest number of alternatives per token that OpenAI and Gemini accept.
+const maxTopLogprobs = 20
+
+// logprobsTargets lists the target formats that can return token log probabilities.
+var logprobsTargets = map[string]struct{}{
+	"openai":     {},
+	"gemini":     {},
+	"gemini-cli": {},
+}
+
+// UnsupportedParameterError reports a request parameter that the target format cannot honour.
+type UnsupportedParameterError struct {
+	// Param is the client-facing name of the parameter.
+	Param string
+	// Target is the format the request was translated to.
+	Target string
+}
+
+// Error implements the error interface.
+func (e *UnsupportedParameterError) Error() string {
+	return fmt.Sprintf("%s is not supported by %s", e.Param, e.Target)
+}
+
+// StatusCode implements a portable status code interface for HTTP handlers.
+func (e *UnsupportedParameterError) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+// Logprobs is a provider-neutral request for token log probabilities. Top is the number of
+// most likely alternatives to return for each token; zero returns the chosen tokens only.
+type Logprobs struct {
+	Top int64
+}
+
+// LogprobsFromOpenAI reads logprobs and top_logprobs from a Chat Completions request.
+func LogprobsFromOpenAI(root gjson.Result) (Logprobs, bool) {
+	if !root.Get("logprobs").Bool() {
+		return Logprobs{}, false
+	}
+	return Logprobs{Top: clampTopLogprobs(root.Get("top_logprobs").Int())}, true
+}
+
+// LogprobsFromResponses reads a Responses request, which asks for logprobs through the
+// message.output_text.logprobs include or a positive top_logprobs.
+func LogprobsFromResponses(root gjson.Result) (Logprobs, bool) {
+	top := clampTopLogprobs(root.Get("top_logprobs").Int())
+	requested := top > 0
+	for _, include := range root.Get("include").Array() {
+		if include.String() == responsesLogprobsInclude {
+			requested = true
+		}
+	}
+	return Logprobs{Top: top}, requested
+}
+
+// LogprobsFromGemini reads responseLogprobs and logprobs from the generationConfig of a Gemini
+// request.
+func LogprobsFromGemini(request gjson.Result) (Logprobs, bool) {
+	config := request.Get("generationConfig")
+	if !config.Get("responseLogprobs").Bool() {
+		return Logprobs{}, false
+	}
+	return Logprobs{Top: clampTopLogprobs(config.Get("logprobs").Int())}, true
+}
+
+// LogprobsFromRequest reads the logprobs request of a payload in the given client format.
+func LogprobsFromRequest(format string, payload []byte) (Logprobs, bool) {
+	root := gjson.ParseBytes(payload)
+	switch format {
+	case "openai":
+		return LogprobsFromOpenAI(root)
+	case "openai-response":
+		return LogprobsFromResponses(root)
+	case "gemini":
+		return LogprobsFromGemini(root)
+	case "gemini-cli":
+		return LogprobsFromGemini(root.Get("request"))
+	}
+	return Logprobs{}, false
+}
+
+// CheckLogprobs returns an *UnsupportedParameterError when a request of format from asks for
+// logprobs that format to cannot return. Requests passed through untranslated are not checked.
+func CheckLogprobs(from, to string, payload []byte) error {
+	if from == to {
+		return nil
+	}
+	if _, ok := LogprobsFromRequest(from, payload); !ok {
+		return nil
+	}
+	if _, ok := logprobsTargets[to]; ok {
+		return nil
+	}
+	return &UnsupportedParameterError{Param: "logprobs", Target: to}
+}
+
+// ApplyToOpenAI sets logprobs and top_logprobs on a Chat Completions request.
+func (l Logprobs) ApplyToOpenAI(out []byte) []byte {
+	out, _ = sjson.SetBytes(out, "logprobs", true)
+	if l.Top > 0 {
+		out, _ = sjson.SetBytes(out, "top_logprobs", l.Top)
+	}
+	return out
+}
+
+// ApplyToGemini sets responseLogprobs and logprobs on the generationConfig found at configPath
+// of a Gemini request.
+func (l Logprobs) ApplyToGemini(out []byte, configPath string) []byte {
+	out, _ = sjson.SetBytes(out, configPath+".responseLogprobs", true)
+	if l.Top > 0 {
+		out, _ = sjson.SetBytes(out, configPath+".logprobs", l.Top)
+	}
+	return out
+}
+
+func clampTopLogprobs(top int64) int64 {
+	return min(max(top, 0), maxTopLogprobs)
+}
+
+// TokenLogprob is the log probability of one generated token together with the most likely
+// alternatives at its position.
+type TokenLogprob struct {
+	Token   string
+	Logprob float64
+	Top     []TokenLogprob
+}
+
+// TokenLogprobsFromGemini reads the logprobsResult of a Gemini candidate. The alternatives of
+// chosenCandidates[i] are topCandidates[i].candidates.
+func TokenLogprobsFromGemini(candidate gjson.Result) []TokenLogprob {
+	result := candidate.Get("logprobsResult")
+	topCandidates := result.Get("topCandidates").Array()
+	var tokens []TokenLogprob
+	for i, chosen := range result.Get("chosenCandidates").Array() {
+		token := TokenLogprob{Token: chosen.Get("token").String(), Logprob: chosen.Get("logProbability").Float()}
+		if i < len(topCandidates) {
+			for _, alternative := range topCandidates[i].Get("candidates").Array() {
+				token.Top = append(token.Top, TokenLogprob{Token: alternative.Get("token").String(), Logprob: alternative.Get("logProbability").Float()})
+			}
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+// TokenLogprobsFromOpenAI reads a Chat Completions logprobs.content array or a Responses
+// output_text logprobs array, which share the same entry layout.
+func TokenLogprobsFromOpenAI(content gjson.Result) []TokenLogprob {
+	var tokens []TokenLogprob
+	for _, entry := range content.Array() {
+		token := TokenLogprob{Token: entry.Get("token").String(), Logprob: entry.Get("logprob").Float()}
+		for _, alternative := range entry.Get("top_logprobs").Array() {
+			token.Top = append(token.Top, TokenLogprob{Token: alternative.Get("token").String(), Logprob: alternative.Get("logprob").Float()})
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+// OpenAILogprobs renders tokens as the logprobs object of a Chat Completions choice.
+func OpenAILogprobs(tokens []TokenLogprob) []byte {
+	out := []byte(`{"content":[],"refusal":null}`)
+	out, _ = sjson.SetRawBytes(out, "content", ResponsesLogprobs(tokens))
+	return out
+}
+
+// ResponsesLogprobs renders tokens as the logprobs array of a Responses output_text part.
+func ResponsesLogprobs(tokens []TokenLogprob) []byte {
+	out := []byte(`[]`)
+	for _, token := range tokens {
+		entry := openAITokenLogprob(token)
+		entry, _ = sjson.SetRawBytes(entry, "top_logprobs", []byte(`[]`))
+		for _, alternative := range token.Top {
+			entry, _ = sjson.SetRawBytes(entry, "top_logprobs.-1", openAITokenLogprob(alternative))
+		}
+		out, _ = sjson.SetRawBytes(out, "-1", entry)
+	}
+	return out
+}
+
+func openAITokenLogprob(token TokenLogprob) []byte {
+	entry := []byte(`{"token":"","logprob":0,"bytes":[]}`)
+	entry, _ = sjson.SetBytes(entry, "token", token.Token)
+	entry, _ = sjson.SetBytes(entry, "logprob", token.Logprob)
+	for _, b := range []byte(token.Token) {
+		entry, _ = sjson.SetBytes(entry, "bytes.-1", int(b))
+	}
+	return entry
+}
+
+// GeminiLogprobsResult renders tokens as the logprobsResult of a Gemini candidate.
+func GeminiLogprobsResult(tokens []TokenLogprob) []byte {
+	out := []byte(`{"topCandidates":[],"chosenCandidates":[]}`)
+	for _, token := range tokens {
+		out, _ = sjson.SetRawBytes(out, "chosenCandidates.-1", geminiTokenLogprob(token))
+		top := []byte(`{"candidates":[]}`)
+		for _, alternative := range token.Top {
+			top, _ = sjson.SetRawBytes(top, "candidates.-1", geminiTokenLogprob(alternative))
+		}
+		out, _ = sjson.SetRawBytes(out, "topCandidates.-1", top)
+	}
+	return out
+}
+
+func geminiTokenLogprob(token TokenLogprob) []byte {
+	entry := []byte(`{"token":"","logProbability":0}`)
+	entry, _ = sjson.SetBytes(entry, "token", token.Token)
+	entry, _ = sjson.SetBytes(entry, "logProbability", token.Logprob)
+	return entry
+}
@@ -0,0 +1,80 @@
+package common
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestLogprobsReaders(t *testing.T) {
+	if l, ok := LogprobsFromOpenAI(gjson.Parse(`{"logprobs":true,"top_logprobs":50}`)); !ok || l.Top != maxTopLogprobs {
+		t.Fatalf("LogprobsFromOpenAI() = %+v, %v", l, ok)
+	}
+	if _, ok := LogprobsFromOpenAI(gjson.Parse(`{"top_logprobs":3}`)); ok {
+		t.Fatal("top_logprobs without logprobs must not request logprobs for chat completions")
+	}
+	if l, ok := LogprobsFromResponses(gjson.Parse(`{"include":["message.output_text.logprobs"]}`)); !ok || l.Top != 0 {
+		t.Fatalf("LogprobsFromResponses(include) = %+v, %v", l, ok)
+	}
+	if l, ok := LogprobsFromResponses(gjson.Parse(`{"top_logprobs":2}`)); !ok || l.Top != 2 {
+		t.Fatalf("LogprobsFromResponses(top_logprobs) = %+v, %v", l, ok)
+	}
+	if l, ok := LogprobsFromRequest("gemini-cli", []byte(`{"request":{"generationConfig":{"responseLogprobs":true,"logprobs":4}}}`)); !ok || l.Top != 4 {
+		t.Fatalf("LogprobsFromRequest(gemini-cli) = %+v, %v", l, ok)
+	}
+}
+
+func TestLogprobsApply(t *testing.T) {
+	l := Logprobs{Top: 3}
+	out := l.ApplyToGemini([]byte(`{}`), "request.generationConfig")
+	if !gjson.GetBytes(out, "request.generationConfig.responseLogprobs").Bool() || gjson.GetBytes(out, "request.generationConfig.logprobs").Int() != 3 {
+		t.Fatalf("ApplyToGemini() = %s", out)
+	}
+	out = Logprobs{}.ApplyToOpenAI([]byte(`{}`))
+	if !gjson.GetBytes(out, "logprobs").Bool() || gjson.GetBytes(out, "top_logprobs").Exists() {
+		t.Fatalf("ApplyToOpenAI() = %s", out)
+	}
+}
+
+func TestCheckLogprobs(t *testing.T) {
+	chat := []byte(`{"logprobs":true,"messages":[{"role":"user","content":"hi"}]}`)
+	err := CheckLogprobs("openai", "claude", chat)
+	unsupported, ok := errors.AsType[*UnsupportedParameterError](err)
+	if !ok || unsupported.Target != "claude" || unsupported.StatusCode() != http.StatusBadRequest {
+		t.Fatalf("CheckLogprobs(openai -> claude) = %v", err)
+	}
+	if err = CheckLogprobs("openai", "gemini", chat); err != nil {
+		t.Fatalf("CheckLogprobs(openai -> gemini) = %v", err)
+	}
+	if err = CheckLogprobs("openai", "claude", []byte(`{"messages":[]}`)); err != nil {
+		t.Fatalf("requests without logprobs must not be checked, got %v", err)
+	}
+	responses := []byte(`{"include":["message.output_text.logprobs"]}`)
+	if err = CheckLogprobs("openai-response", "codex", responses); err == nil {
+		t.Fatal("expected logprobs to be rejected for codex")
+	}
+}
+
+func TestTokenLogprobsRoundTrip(t *testing.T) {
+	candidate := gjson.Parse(`{"logprobsResult":{"topCandidates":[{"candidates":[{"token":"Hi","logProbability":-0.1},{"token":"Hey","logProbability":-2.5}]}],"chosenCandidates":[{"token":"Hi","logProbability":-0.1}]}}`)
+	tokens := TokenLogprobsFromGemini(candidate)
+	if len(tokens) != 1 || tokens[0].Token != "Hi" || len(tokens[0].Top) != 2 || tokens[0].Top[1].Token != "Hey" {
+		t.Fatalf("TokenLogprobsFromGemini() = %+v", tokens)
+	}
+
+	chat := OpenAILogprobs(tokens)
+	if got := gjson.GetBytes(chat, "content.0.bytes").Raw; got != "[72,105]" {
+		t.Fatalf("bytes = %s", got)
+	}
+	if got := gjson.GetBytes(chat, "content.0.top_logprobs.1.logprob").Float(); got != -2.5 {
+		t.Fatalf("top logprob = %v", got)
+	}
+
+	back := TokenLogprobsFromOpenAI(gjson.GetBytes(chat, "content"))
+	result := GeminiLogprobsResult(back)
+	if got := TokenLogprobsFromGemini(gjson.Parse(`{"logprobsResult":` + string(result) + `}`)); len(got) != 1 || got[0].Logprob != -0.1 || len(got[0].Top) != 2 {
+		t.Fatalf("round trip = %+v", got)
+	}
+}
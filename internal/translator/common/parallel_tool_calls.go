@@ -0,0 +1,43 @@
+package common
+
+import (
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ParallelToolCallsFromOpenAI reads parallel_tool_calls from a Chat Completions or Responses
+// request. ok is false when the client left the choice to the provider.
+func ParallelToolCallsFromOpenAI(root gjson.Result) (allowed, ok bool) {
+	v := root.Get("parallel_tool_calls")
+	if v.Type != gjson.True && v.Type != gjson.False {
+		return false, false
+	}
+	return v.Bool(), true
+}
+
+// ParallelToolCallsFromClaude reads tool_choice.disable_parallel_tool_use from a Claude
+// request. ok is false when the client left the choice to the provider.
+func ParallelToolCallsFromClaude(root gjson.Result) (allowed, ok bool) {
+	v := root.Get("tool_choice.disable_parallel_tool_use")
+	if v.Type != gjson.True && v.Type != gjson.False {
+		return false, false
+	}
+	return !v.Bool(), true
+}
+
+// DisableParallelToolUse limits a Claude request to one tool call per turn. Claude carries the
+// flag on tool_choice, so an auto choice is added when the request has none; requests without
+// tools or with tool use turned off are left unchanged.
+func DisableParallelToolUse(out []byte) []byte {
+	if !gjson.GetBytes(out, "tools.0").Exists() {
+		return out
+	}
+	switch gjson.GetBytes(out, "tool_choice.type").String() {
+	case "none":
+		return out
+	case "":
+		out, _ = sjson.SetRawBytes(out, "tool_choice", []byte(`{"type":"auto"}`))
+	}
+	out, _ = sjson.SetBytes(out, "tool_choice.disable_parallel_tool_use", true)
+	return out
+}
@@ -0,0 +1,31 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestParallelToolCallsParsing(t *testing.T) {
+	if allowed, ok := ParallelToolCallsFromOpenAI(gjson.Parse(`{"parallel_tool_calls":false}`)); !ok || allowed {
+		t.Fatalf("ParallelToolCallsFromOpenAI(false) = %v, %v", allowed, ok)
+	}
+	if _, ok := ParallelToolCallsFromOpenAI(gjson.Parse(`{}`)); ok {
+		t.Fatal("ParallelToolCallsFromOpenAI() reported a choice for a request without one")
+	}
+	if allowed, ok := ParallelToolCallsFromClaude(gjson.Parse(`{"tool_choice":{"type":"auto","disable_parallel_tool_use":true}}`)); !ok || allowed {
+		t.Fatalf("ParallelToolCallsFromClaude(disabled) = %v, %v", allowed, ok)
+	}
+}
+
+func TestDisableParallelToolUse(t *testing.T) {
+	out := DisableParallelToolUse([]byte(`{"tools":[{"name":"a"}],"tool_choice":{"type":"tool","name":"a"}}`))
+	if gjson.GetBytes(out, "tool_choice.name").String() != "a" || !gjson.GetBytes(out, "tool_choice.disable_parallel_tool_use").Bool() {
+		t.Fatalf("DisableParallelToolUse() = %s", out)
+	}
+	for _, in := range []string{`{}`, `{"tools":[{"name":"a"}],"tool_choice":{"type":"none"}}`} {
+		if out := DisableParallelToolUse([]byte(in)); string(out) != in {
+			t.Fatalf("DisableParallelToolUse(%s) = %s", in, out)
+		}
+	}
+}
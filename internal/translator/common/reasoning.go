@@ -0,0 +1,75 @@
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// openAIReasoningFields lists the fields OpenAI-compatible providers use for reasoning text in a
+// Chat Completions message or delta: reasoning_content (DeepSeek and most compatible servers),
+// reasoning (vLLM, OpenRouter) and reasoning_details (OpenRouter), in order of preference.
+var openAIReasoningFields = []string{"reasoning_content", "reasoning", "reasoning_details"}
+
+// OpenAIReasoningTexts returns the reasoning text of a Chat Completions message or stream delta.
+// Providers that fill several reasoning fields repeat the same text in each, so only the first
+// field carrying text is read.
+func OpenAIReasoningTexts(message gjson.Result) []string {
+	for _, field := range openAIReasoningFields {
+		if texts := reasoningTexts(message.Get(field)); len(texts) > 0 {
+			return texts
+		}
+	}
+	return nil
+}
+
+// NormalizeOpenAIReasoning sets reasoning_content on the messages or deltas of a Chat Completions
+// response whose provider reported reasoning under another field, so OpenAI-format clients find
+// it where they expect it.
+func NormalizeOpenAIReasoning(payload []byte) []byte {
+	if !bytes.Contains(payload, []byte(`"reasoning`)) {
+		return payload
+	}
+	for i, choice := range gjson.GetBytes(payload, "choices").Array() {
+		for _, key := range []string{"delta", "message"} {
+			message := choice.Get(key)
+			if !message.IsObject() || message.Get("reasoning_content").Exists() {
+				continue
+			}
+			if texts := OpenAIReasoningTexts(message); len(texts) > 0 {
+				payload, _ = sjson.SetBytes(payload, fmt.Sprintf("choices.%d.%s.reasoning_content", i, key), strings.Join(texts, ""))
+			}
+		}
+	}
+	return payload
+}
+
+// reasoningTexts collects the non-empty texts of a reasoning field, which is a string, a text
+// object or an array of either. OpenRouter reasoning_details entries carry their text in text or,
+// for summaries, summary; encrypted entries have none.
+func reasoningTexts(node gjson.Result) []string {
+	var texts []string
+	if node.IsArray() {
+		for _, value := range node.Array() {
+			texts = append(texts, reasoningTexts(value)...)
+		}
+		return texts
+	}
+	switch {
+	case node.Type == gjson.String:
+		if text := node.String(); text != "" {
+			texts = append(texts, text)
+		}
+	case node.IsObject():
+		for _, field := range []string{"text", "summary"} {
+			if text := node.Get(field); text.Type == gjson.String && text.String() != "" {
+				texts = append(texts, text.String())
+				break
+			}
+		}
+	}
+	return texts
+}
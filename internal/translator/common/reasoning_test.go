@@ -0,0 +1,39 @@
+package common
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestOpenAIReasoningTexts(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    []string
+	}{
+		{"reasoning_content", `{"reasoning_content":"a"}`, []string{"a"}},
+		{"reasoning", `{"reasoning":"b"}`, []string{"b"}},
+		{"reasoning_details", `{"reasoning_details":[{"type":"reasoning.text","text":"c"},{"type":"reasoning.encrypted","data":"x"},{"type":"reasoning.summary","summary":"d"}]}`, []string{"c", "d"}},
+		{"first field wins", `{"reasoning_content":"a","reasoning":"a","reasoning_details":[{"text":"a"}]}`, []string{"a"}},
+		{"empty falls through", `{"reasoning_content":"","reasoning":"b"}`, []string{"b"}},
+		{"none", `{"content":"hi"}`, nil},
+	}
+	for _, tt := range tests {
+		if got := OpenAIReasoningTexts(gjson.Parse(tt.message)); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeOpenAIReasoning(t *testing.T) {
+	out := NormalizeOpenAIReasoning([]byte(`{"choices":[{"index":0,"delta":{"reasoning":"step"}}]}`))
+	if got := gjson.GetBytes(out, "choices.0.delta.reasoning_content").String(); got != "step" {
+		t.Fatalf("delta.reasoning_content = %q: %s", got, out)
+	}
+	in := []byte(`{"choices":[{"index":0,"message":{"reasoning_content":"kept","reasoning":"other"}}]}`)
+	if out = NormalizeOpenAIReasoning(in); string(out) != string(in) {
+		t.Fatalf("existing reasoning_content must be left alone: %s", out)
+	}
+}
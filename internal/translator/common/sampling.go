@@ -0,0 +1,186 @@
+package common
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// SamplingParam describes how a target format accepts one sampling parameter.
+// An empty Path marks the parameter as unsupported by the target. Map forwards the
+// client's value to Path when the request translator did not carry the parameter over.
+type SamplingParam struct {
+	Path    string
+	Min     float64
+	Max     float64
+	Integer bool
+	Map     bool
+}
+
+// samplingParamNames lists the canonical sampling parameters in a stable order.
+var samplingParamNames = []string{"temperature", "top_p", "top_k", "frequency_penalty", "presence_penalty", "seed", "n"}
+
+// samplingDefaults holds the values of parameters that need no warning when a target drops them.
+var samplingDefaults = map[string]float64{"n": 1}
+
+// samplingSourcePaths maps canonical parameter names to their JSON paths in each client format.
+var samplingSourcePaths = map[string]map[string]string{
+	"openai": {
+		"temperature":       "temperature",
+		"top_p":             "top_p",
+		"top_k":             "top_k",
+		"frequency_penalty": "frequency_penalty",
+		"presence_penalty":  "presence_penalty",
+		"seed":              "seed",
+		"n":                 "n",
+	},
+	"openai-response": {
+		"temperature": "temperature",
+		"top_p":       "top_p",
+	},
+	"claude": {
+		"temperature": "temperature",
+		"top_p":       "top_p",
+		"top_k":       "top_k",
+	},
+	"gemini":     geminiSamplingPaths(""),
+	"gemini-cli": geminiSamplingPaths("request."),
+}
+
+// samplingCapabilities is the per-target parameter capability table. Parameters missing
+// from a target's map are passed through untouched.
+var samplingCapabilities = map[string]map[string]SamplingParam{
+	"claude": {
+		"temperature":       {Path: "temperature", Min: 0, Max: 1},
+		"top_p":             {Path: "top_p", Min: 0, Max: 1},
+		"top_k":             {Path: "top_k", Min: 0, Max: math.MaxInt32, Integer: true, Map: true},
+		"frequency_penalty": {},
+		"presence_penalty":  {},
+		"seed":              {},
+		"n":                 {},
+	},
+	"openai": {
+		"temperature":       {Path: "temperature", Min: 0, Max: 2},
+		"top_p":             {Path: "top_p", Min: 0, Max: 1},
+		"frequency_penalty": {Path: "frequency_penalty", Min: -2, Max: 2},
+		"presence_penalty":  {Path: "presence_penalty", Min: -2, Max: 2},
+		"n":                 {Path: "n", Min: 1, Max: 128, Integer: true},
+	},
+	"codex": {
+		"temperature": {},
+		"top_p":       {},
+		"top_k":       {},
+		"n":           {},
+	},
+	"gemini":      geminiSamplingCapabilities(""),
+	"gemini-cli":  geminiSamplingCapabilities("request."),
+	"antigravity": geminiSamplingCapabilities("request."),
+}
+
+func geminiSamplingPaths(prefix string) map[string]string {
+	return map[string]string{
+		"temperature":       prefix + "generationConfig.temperature",
+		"top_p":             prefix + "generationConfig.topP",
+		"top_k":             prefix + "generationConfig.topK",
+		"frequency_penalty": prefix + "generationConfig.frequencyPenalty",
+		"presence_penalty":  prefix + "generationConfig.presencePenalty",
+		"seed":              prefix + "generationConfig.seed",
+		"n":                 prefix + "generationConfig.candidateCount",
+	}
+}
+
+// geminiSamplingCapabilities leaves penalties unmapped: not every Gemini model accepts them,
+// so they are only forwarded when a request translator carries them over.
+func geminiSamplingCapabilities(prefix string) map[string]SamplingParam {
+	paths := geminiSamplingPaths(prefix)
+	return map[string]SamplingParam{
+		"temperature":       {Path: paths["temperature"], Min: 0, Max: 2, Map: true},
+		"top_p":             {Path: paths["top_p"], Min: 0, Max: 1, Map: true},
+		"top_k":             {Path: paths["top_k"], Min: 1, Max: math.MaxInt32, Integer: true, Map: true},
+		"frequency_penalty": {Path: paths["frequency_penalty"], Min: -2, Max: 2},
+		"presence_penalty":  {Path: paths["presence_penalty"], Min: -2, Max: 2},
+		"seed":              {Path: paths["seed"], Min: math.MinInt32, Max: math.MaxInt32, Integer: true, Map: true},
+		"n":                 {Path: paths["n"], Min: 1, Max: 8, Integer: true},
+	}
+}
+
+// ApplySamplingCapabilities maps, clamps or drops sampling parameters in a translated request
+// according to the target format's capability table. Parameters the request translators did
+// not carry over are mapped to the target's name when the table allows it and reported
+// otherwise. It returns the updated payload and human-readable warnings for every change to
+// what the client asked for.
+func ApplySamplingCapabilities(from, to string, source, translated []byte) ([]byte, []string) {
+	caps, ok := samplingCapabilities[to]
+	if !ok || len(translated) == 0 {
+		return translated, nil
+	}
+	sourcePaths := samplingSourcePaths[from]
+
+	out := translated
+	var warnings []string
+	for _, name := range samplingParamNames {
+		spec, known := caps[name]
+		if !known {
+			continue
+		}
+		var src gjson.Result
+		if path, hasPath := sourcePaths[name]; hasPath {
+			src = gjson.GetBytes(source, path)
+		}
+
+		if spec.Path == "" {
+			dropped := src.Exists() && !isSamplingDefault(name, src)
+			if gjson.GetBytes(out, name).Exists() {
+				out, _ = sjson.DeleteBytes(out, name)
+				dropped = true
+			}
+			if dropped {
+				warnings = append(warnings, fmt.Sprintf("%s is not supported by %s and was dropped", name, to))
+			}
+			continue
+		}
+
+		current := gjson.GetBytes(out, spec.Path)
+		if !current.Exists() {
+			if !src.Exists() {
+				continue
+			}
+			if !spec.Map || src.Type != gjson.Number {
+				warnings = append(warnings, fmt.Sprintf("%s was not forwarded to %s", name, to))
+				continue
+			}
+			out, _ = sjson.SetRawBytes(out, spec.Path, []byte(src.Raw))
+			current = src
+		}
+		if current.Type != gjson.Number {
+			continue
+		}
+		value := current.Float()
+		clamped := math.Min(math.Max(value, spec.Min), spec.Max)
+		if spec.Integer {
+			clamped = math.Trunc(clamped)
+		}
+		if clamped == value {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("%s %s clamped to %s for %s", name, formatSamplingValue(value), formatSamplingValue(clamped), to))
+		if spec.Integer {
+			out, _ = sjson.SetBytes(out, spec.Path, int64(clamped))
+		} else {
+			out, _ = sjson.SetBytes(out, spec.Path, clamped)
+		}
+	}
+	return out, warnings
+}
+
+func isSamplingDefault(name string, value gjson.Result) bool {
+	def, ok := samplingDefaults[name]
+	return ok && value.Type == gjson.Number && value.Float() == def
+}
+
+func formatSamplingValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,97 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestApplySamplingCapabilitiesClampsAndDrops(t *testing.T) {
+	source := []byte(`{"temperature":1.5,"top_p":0.9,"frequency_penalty":0.5}`)
+	translated := []byte(`{"temperature":1.5,"frequency_penalty":0.5}`)
+
+	out, warnings := ApplySamplingCapabilities("openai", "claude", source, translated)
+	if got := gjson.GetBytes(out, "temperature").Float(); got != 1 {
+		t.Fatalf("temperature = %v, want 1: %s", got, out)
+	}
+	if gjson.GetBytes(out, "frequency_penalty").Exists() {
+		t.Fatalf("expected frequency_penalty to be dropped: %s", out)
+	}
+	want := []string{
+		"temperature 1.5 clamped to 1 for claude",
+		"top_p was not forwarded to claude",
+		"frequency_penalty is not supported by claude and was dropped",
+	}
+	if len(warnings) != len(want) {
+		t.Fatalf("warnings = %q, want %q", warnings, want)
+	}
+	for i := range want {
+		if warnings[i] != want[i] {
+			t.Fatalf("warnings[%d] = %q, want %q", i, warnings[i], want[i])
+		}
+	}
+}
+
+func TestApplySamplingCapabilitiesGeminiRoot(t *testing.T) {
+	translated := []byte(`{"request":{"generationConfig":{"topK":0,"temperature":0.7}}}`)
+	out, warnings := ApplySamplingCapabilities("gemini-cli", "gemini-cli", translated, translated)
+	if got := gjson.GetBytes(out, "request.generationConfig.topK").Int(); got != 1 {
+		t.Fatalf("topK = %d, want 1: %s", got, out)
+	}
+	if len(warnings) != 1 {
+		t.Fatalf("warnings = %q, want one clamp warning", warnings)
+	}
+
+	untouched, warnings := ApplySamplingCapabilities("openai", "unknown", translated, translated)
+	if string(untouched) != string(translated) || len(warnings) != 0 {
+		t.Fatalf("unknown target should pass through, got %s %q", untouched, warnings)
+	}
+}
+
+func TestApplySamplingCapabilitiesChoices(t *testing.T) {
+	source := []byte(`{"n":3}`)
+	_, warnings := ApplySamplingCapabilities("openai", "claude", source, []byte(`{"model":"m"}`))
+	if len(warnings) != 1 || warnings[0] != "n is not supported by claude and was dropped" {
+		t.Fatalf("warnings = %q, want a dropped n warning", warnings)
+	}
+	if _, warnings = ApplySamplingCapabilities("openai", "claude", []byte(`{"n":1}`), []byte(`{"model":"m"}`)); len(warnings) != 0 {
+		t.Fatalf("n=1 needs no warning, got %q", warnings)
+	}
+
+	out, warnings := ApplySamplingCapabilities("openai", "gemini", []byte(`{"n":12}`), []byte(`{"generationConfig":{"candidateCount":12}}`))
+	if got := gjson.GetBytes(out, "generationConfig.candidateCount").Int(); got != 8 {
+		t.Fatalf("candidateCount = %d, want 8: %s", got, out)
+	}
+	if len(warnings) != 1 {
+		t.Fatalf("warnings = %q, want one clamp warning", warnings)
+	}
+}
+
+func TestApplySamplingCapabilitiesMapsMissingParams(t *testing.T) {
+	out, warnings := ApplySamplingCapabilities("openai", "claude", []byte(`{"top_k":40,"presence_penalty":1}`), []byte(`{"model":"m"}`))
+	if got := gjson.GetBytes(out, "top_k").Int(); got != 40 {
+		t.Fatalf("top_k = %d, want 40: %s", got, out)
+	}
+	if len(warnings) != 1 || warnings[0] != "presence_penalty is not supported by claude and was dropped" {
+		t.Fatalf("warnings = %q, want only the dropped penalty", warnings)
+	}
+
+	out, warnings = ApplySamplingCapabilities("openai", "gemini-cli", []byte(`{"seed":7,"frequency_penalty":0.5}`), []byte(`{"request":{}}`))
+	if got := gjson.GetBytes(out, "request.generationConfig.seed").Int(); got != 7 {
+		t.Fatalf("seed = %d, want 7: %s", got, out)
+	}
+	if gjson.GetBytes(out, "request.generationConfig.frequencyPenalty").Exists() {
+		t.Fatalf("penalties must not be mapped to Gemini: %s", out)
+	}
+	if len(warnings) != 1 || warnings[0] != "frequency_penalty was not forwarded to gemini-cli" {
+		t.Fatalf("warnings = %q", warnings)
+	}
+
+	out, warnings = ApplySamplingCapabilities("gemini", "claude", []byte(`{"generationConfig":{"topK":-3}}`), []byte(`{"model":"m"}`))
+	if got := gjson.GetBytes(out, "top_k").Int(); got != 0 {
+		t.Fatalf("top_k = %d, want 0: %s", got, out)
+	}
+	if len(warnings) != 1 || warnings[0] != "top_k -3 clamped to 0 for claude" {
+		t.Fatalf("warnings = %q", warnings)
+	}
+}
@@ -0,0 +1,166 @@
+package common
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// StructuredOutputToolName is the tool a Claude request is forced to call when the client asks
+// for a JSON response; its input is returned to the client as the message content.
+const StructuredOutputToolName = "structured_output"
+
+// ResponseFormat is a JSON response_format requested by an OpenAI Chat Completions client.
+// Schema is empty for json_object requests, which ask for any JSON object.
+type ResponseFormat struct {
+	Name        string
+	Description string
+	Schema      string
+}
+
+// ResponseFormatFromOpenAI reads the json_schema or json_object response_format of a Chat
+// Completions request.
+func ResponseFormatFromOpenAI(root gjson.Result) (ResponseFormat, bool) {
+	responseFormat := root.Get("response_format")
+	switch responseFormat.Get("type").String() {
+	case "json_schema":
+		jsonSchema := responseFormat.Get("json_schema")
+		format := ResponseFormat{
+			Name:        jsonSchema.Get("name").String(),
+			Description: jsonSchema.Get("description").String(),
+		}
+		if schema := jsonSchema.Get("schema"); schema.IsObject() {
+			format.Schema = schema.Raw
+		}
+		return format, true
+	case "json_object":
+		return ResponseFormat{}, true
+	}
+	return ResponseFormat{}, false
+}
+
+// ClaudeTool renders the format as the Claude tool the request is forced to call.
+func (f ResponseFormat) ClaudeTool() []byte {
+	description := "Respond with the final answer as the input of this tool."
+	if f.Description != "" {
+		description += " " + f.Description
+	}
+	schema := f.Schema
+	if schema == "" {
+		schema = `{"type":"object"}`
+	}
+	tool := []byte(`{"name":"","description":"","input_schema":{}}`)
+	tool, _ = sjson.SetBytes(tool, "name", StructuredOutputToolName)
+	tool, _ = sjson.SetBytes(tool, "description", description)
+	tool, _ = sjson.SetRawBytes(tool, "input_schema", []byte(schema))
+	return tool
+}
+
+// ApplyToClaude adds the structured output tool to a Claude request and forces the model to
+// call it. When the client declared tools it allows, the model may call those first, so any
+// tool is required instead. Claude rejects extended thinking together with a forced tool, so
+// thinking is turned off.
+func (f ResponseFormat) ApplyToClaude(out []byte, allowClientTools bool) []byte {
+	hasClientTools := len(gjson.GetBytes(out, "tools").Array()) > 0
+	out, _ = sjson.SetRawBytes(out, "tools.-1", f.ClaudeTool())
+	if allowClientTools && hasClientTools {
+		out, _ = sjson.SetRawBytes(out, "tool_choice", []byte(`{"type":"any"}`))
+	} else {
+		toolChoice := []byte(`{"type":"tool","name":""}`)
+		toolChoice, _ = sjson.SetBytes(toolChoice, "name", StructuredOutputToolName)
+		out, _ = sjson.SetRawBytes(out, "tool_choice", toolChoice)
+	}
+	out, _ = sjson.DeleteBytes(out, "thinking")
+	return out
+}
+
+// ApplyToGemini sets the JSON response media type and schema on the generationConfig found at
+// configPath of a Gemini request.
+func (f ResponseFormat) ApplyToGemini(out []byte, configPath string) []byte {
+	out, _ = sjson.SetBytes(out, configPath+".responseMimeType", "application/json")
+	if f.Schema != "" {
+		out, _ = sjson.SetRawBytes(out, configPath+".responseJsonSchema", []byte(f.Schema))
+	}
+	return out
+}
+
+// RepairJSON returns text as valid JSON, stripping Markdown code fences and surrounding prose
+// and closing a payload cut off by the token limit. ok is false when no valid JSON could be
+// recovered, in which case text is returned unchanged.
+func RepairJSON(text string) (string, bool) {
+	candidate := strings.TrimSpace(text)
+	if json.Valid([]byte(candidate)) {
+		return candidate, true
+	}
+	if fenced, found := strings.CutPrefix(candidate, "```"); found {
+		if newline := strings.IndexByte(fenced, '\n'); newline >= 0 {
+			fenced = fenced[newline+1:]
+		}
+		candidate = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(fenced), "```"))
+	}
+	if start := strings.IndexAny(candidate, "{["); start > 0 {
+		candidate = candidate[start:]
+	}
+	if json.Valid([]byte(candidate)) {
+		return candidate, true
+	}
+	if end := strings.LastIndexAny(candidate, "}]"); end >= 0 && json.Valid([]byte(candidate[:end+1])) {
+		return candidate[:end+1], true
+	}
+	if closed := closeJSON(candidate); json.Valid([]byte(closed)) {
+		return closed, true
+	}
+	return text, false
+}
+
+// closeJSON terminates an unfinished string and closes the objects and arrays left open in a
+// truncated JSON document, dropping a trailing comma or dangling key separator.
+func closeJSON(text string) string {
+	var closers []byte
+	inString, escaped := false, false
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			closers = append(closers, '}')
+		case '[':
+			closers = append(closers, ']')
+		case '}', ']':
+			if len(closers) > 0 {
+				closers = closers[:len(closers)-1]
+			}
+		}
+	}
+	var b strings.Builder
+	b.WriteString(text)
+	if inString {
+		if escaped {
+			b.WriteByte('\\')
+		}
+		b.WriteByte('"')
+	}
+	closed := strings.TrimRight(b.String(), " \t\r\n")
+	closed = strings.TrimSuffix(closed, ",")
+	if strings.HasSuffix(closed, ":") {
+		closed += "null"
+	}
+	for i := len(closers) - 1; i >= 0; i-- {
+		closed += string(closers[i])
+	}
+	return closed
+}
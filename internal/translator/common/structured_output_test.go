@@ -0,0 +1,65 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestResponseFormatFromOpenAI(t *testing.T) {
+	format, ok := ResponseFormatFromOpenAI(gjson.Parse(`{"response_format":{"type":"json_schema","json_schema":{"name":"answer","schema":{"type":"object"}}}}`))
+	if !ok || format.Name != "answer" || format.Schema != `{"type":"object"}` {
+		t.Fatalf("json_schema parsed as %+v, %v", format, ok)
+	}
+	if format, ok = ResponseFormatFromOpenAI(gjson.Parse(`{"response_format":{"type":"json_object"}}`)); !ok || format.Schema != "" {
+		t.Fatalf("json_object parsed as %+v, %v", format, ok)
+	}
+	if _, ok = ResponseFormatFromOpenAI(gjson.Parse(`{"response_format":{"type":"text"}}`)); ok {
+		t.Fatal("text response_format must not request structured output")
+	}
+}
+
+func TestResponseFormatApply(t *testing.T) {
+	format := ResponseFormat{Schema: `{"type":"object","required":["a"]}`}
+
+	out := format.ApplyToGemini([]byte(`{"request":{}}`), "request.generationConfig")
+	if gjson.GetBytes(out, "request.generationConfig.responseMimeType").String() != "application/json" ||
+		gjson.GetBytes(out, "request.generationConfig.responseJsonSchema.required.0").String() != "a" {
+		t.Fatalf("ApplyToGemini() = %s", out)
+	}
+
+	// Client tools stay callable, so any tool is required rather than the structured output one.
+	out = format.ApplyToClaude([]byte(`{"tools":[{"name":"lookup"}],"tool_choice":{"type":"auto"}}`), true)
+	if gjson.GetBytes(out, "tools.1.name").String() != StructuredOutputToolName || gjson.GetBytes(out, "tool_choice.type").String() != "any" {
+		t.Fatalf("ApplyToClaude(client tools) = %s", out)
+	}
+	out = format.ApplyToClaude([]byte(`{"tools":[{"name":"lookup"}]}`), false)
+	if gjson.GetBytes(out, "tool_choice.name").String() != StructuredOutputToolName {
+		t.Fatalf("ApplyToClaude(tool_choice none) = %s", out)
+	}
+}
+
+func TestRepairJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+		ok   bool
+	}{
+		{"valid", ` {"a":1} `, `{"a":1}`, true},
+		{"code fence", "```json\n{\"a\":1}\n```", `{"a":1}`, true},
+		{"surrounding prose", `Here you go: {"a":[1,2]} Hope it helps.`, `{"a":[1,2]}`, true},
+		{"truncated string", `{"a":"hel`, `{"a":"hel"}`, true},
+		{"truncated array", `{"a":[1,2,`, `{"a":[1,2]}`, true},
+		{"dangling key", `{"a":`, `{"a":null}`, true},
+		{"not json", `no json here`, `no json here`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := RepairJSON(tt.in)
+			if got != tt.want || ok != tt.ok {
+				t.Fatalf("RepairJSON(%q) = %q, %v; want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package common
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// claudeBillingHeaderPrefix marks the billing block Claude Code adds to its system prompt. It
+// only means something to Anthropic, so it is not forwarded to other providers.
+const claudeBillingHeaderPrefix = "x-anthropic-billing-header:"
+
+// SystemBlock is one text block of a system prompt. CacheControl keeps the raw cache marker
+// of the block so Claude and Chat Completions targets can honour it; other targets drop it.
+type SystemBlock struct {
+	Text         string
+	CacheControl string
+}
+
+// SystemPrompt is a provider-neutral system prompt shared by the request translators. Claude
+// system arrays, OpenAI system and developer messages and Responses instructions all map onto
+// an ordered list of text blocks, so several system messages are kept as separate blocks
+// instead of being merged or dropped. Blank blocks are skipped.
+type SystemPrompt []SystemBlock
+
+// IsSystemRole reports whether an OpenAI message role carries system instructions.
+func IsSystemRole(role string) bool {
+	return strings.EqualFold(role, "system") || strings.EqualFold(role, "developer")
+}
+
+// SystemPromptFromClaude reads a Claude system field, either a string or an array of text
+// blocks.
+func SystemPromptFromClaude(system gjson.Result) SystemPrompt {
+	var prompt SystemPrompt
+	if system.Type == gjson.String {
+		return prompt.add(system.String(), "")
+	}
+	for _, block := range system.Array() {
+		if block.Get("type").String() != "text" || strings.HasPrefix(block.Get("text").String(), claudeBillingHeaderPrefix) {
+			continue
+		}
+		prompt = prompt.add(block.Get("text").String(), block.Get("cache_control").Raw)
+	}
+	return prompt
+}
+
+// SystemPromptFromOpenAIMessage reads the content of a Chat Completions or Responses system
+// or developer message: a string, a single text part or an array of text parts.
+func SystemPromptFromOpenAIMessage(content gjson.Result) SystemPrompt {
+	var prompt SystemPrompt
+	if content.Type == gjson.String {
+		return prompt.add(content.String(), "")
+	}
+	parts := content.Array()
+	if content.IsObject() {
+		parts = []gjson.Result{content}
+	}
+	for _, part := range parts {
+		switch part.Get("type").String() {
+		case "text", "input_text", "output_text", "":
+			prompt = prompt.add(part.Get("text").String(), part.Get("cache_control").Raw)
+		}
+	}
+	return prompt
+}
+
+// SystemPromptFromResponses reads the instructions of a Responses request followed by its
+// system and developer input messages.
+func SystemPromptFromResponses(root gjson.Result) SystemPrompt {
+	var prompt SystemPrompt
+	if instructions := root.Get("instructions"); instructions.Type == gjson.String {
+		prompt = prompt.add(instructions.String(), "")
+	}
+	for _, item := range root.Get("input").Array() {
+		if t := item.Get("type").String(); (t == "" || t == "message") && IsSystemRole(item.Get("role").String()) {
+			prompt = append(prompt, SystemPromptFromOpenAIMessage(item.Get("content"))...)
+		}
+	}
+	return prompt
+}
+
+// SystemPromptFromGemini reads the text parts of the systemInstruction of a Gemini request,
+// accepting the snake_case spelling as well.
+func SystemPromptFromGemini(request gjson.Result) SystemPrompt {
+	instruction := request.Get("systemInstruction")
+	if !instruction.Exists() {
+		instruction = request.Get("system_instruction")
+	}
+	var prompt SystemPrompt
+	for _, part := range instruction.Get("parts").Array() {
+		prompt = prompt.add(part.Get("text").String(), "")
+	}
+	return prompt
+}
+
+func (p SystemPrompt) add(text, cacheControl string) SystemPrompt {
+	if strings.TrimSpace(text) == "" {
+		return p
+	}
+	return append(p, SystemBlock{Text: text, CacheControl: cacheControl})
+}
+
+// ClaudeBlocks renders the prompt as a Claude system array, keeping cache markers.
+func (p SystemPrompt) ClaudeBlocks() []byte {
+	out := []byte(`[]`)
+	for _, block := range p {
+		part := []byte(`{"type":"text","text":""}`)
+		part, _ = sjson.SetBytes(part, "text", block.Text)
+		if block.CacheControl != "" {
+			part, _ = sjson.SetRawBytes(part, "cache_control", []byte(block.CacheControl))
+		}
+		out, _ = sjson.SetRawBytes(out, "-1", part)
+	}
+	return out
+}
+
+// GeminiInstruction renders the prompt as a Gemini systemInstruction with one part per block.
+func (p SystemPrompt) GeminiInstruction() []byte {
+	out := []byte(`{"role":"user","parts":[]}`)
+	for _, block := range p {
+		out, _ = sjson.SetBytes(out, "parts.-1.text", block.Text)
+	}
+	return out
+}
+
+// OpenAIMessage renders the prompt as a Chat Completions system message with one text part
+// per block, keeping cache markers.
+func (p SystemPrompt) OpenAIMessage() []byte {
+	out := []byte(`{"role":"system","content":[]}`)
+	for _, block := range p {
+		part := []byte(`{"type":"text","text":""}`)
+		part, _ = sjson.SetBytes(part, "text", block.Text)
+		if block.CacheControl != "" {
+			part, _ = sjson.SetRawBytes(part, "cache_control", []byte(block.CacheControl))
+		}
+		out, _ = sjson.SetRawBytes(out, "content.-1", part)
+	}
+	return out
+}
+
+// ResponsesMessage renders the prompt as a Responses developer message with one input_text
+// part per block.
+func (p SystemPrompt) ResponsesMessage() []byte {
+	out := []byte(`{"type":"message","role":"developer","content":[]}`)
+	for _, block := range p {
+		part := []byte(`{"type":"input_text","text":""}`)
+		part, _ = sjson.SetBytes(part, "text", block.Text)
+		out, _ = sjson.SetRawBytes(out, "content.-1", part)
+	}
+	return out
+}
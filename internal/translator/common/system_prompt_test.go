@@ -0,0 +1,62 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestSystemPromptFromClaude(t *testing.T) {
+	system := SystemPromptFromClaude(gjson.Parse(`[
+		{"type":"text","text":"x-anthropic-billing-header: cc_version=1"},
+		{"type":"text","text":"You are helpful.","cache_control":{"type":"ephemeral"}},
+		{"type":"text","text":"  "},
+		{"type":"text","text":"Be brief."}
+	]`))
+	if len(system) != 2 || system[0].Text != "You are helpful." || system[1].Text != "Be brief." {
+		t.Fatalf("SystemPromptFromClaude() = %+v", system)
+	}
+
+	blocks := system.ClaudeBlocks()
+	if gjson.GetBytes(blocks, "0.cache_control.type").String() != "ephemeral" || gjson.GetBytes(blocks, "1.cache_control").Exists() {
+		t.Fatalf("ClaudeBlocks() = %s", blocks)
+	}
+	if out := system.GeminiInstruction(); gjson.GetBytes(out, "parts.#").Int() != 2 || gjson.GetBytes(out, "parts.1.text").String() != "Be brief." {
+		t.Fatalf("GeminiInstruction() = %s", out)
+	}
+	if out := system.OpenAIMessage(); gjson.GetBytes(out, "role").String() != "system" || gjson.GetBytes(out, "content.#").Int() != 2 {
+		t.Fatalf("OpenAIMessage() = %s", out)
+	}
+	if out := system.ResponsesMessage(); gjson.GetBytes(out, "role").String() != "developer" || gjson.GetBytes(out, "content.1.type").String() != "input_text" {
+		t.Fatalf("ResponsesMessage() = %s", out)
+	}
+
+	if system := SystemPromptFromClaude(gjson.Parse(`"Be brief."`)); len(system) != 1 {
+		t.Fatalf("string system = %+v", system)
+	}
+}
+
+func TestSystemPromptFromResponses(t *testing.T) {
+	system := SystemPromptFromResponses(gjson.Parse(`{
+		"instructions": "Follow the rules.",
+		"input": [
+			{"role": "system", "content": "First."},
+			{"role": "user", "content": "hi"},
+			{"type": "message", "role": "developer", "content": [{"type": "input_text", "text": "Second."}]}
+		]
+	}`))
+	if len(system) != 3 || system[0].Text != "Follow the rules." || system[1].Text != "First." || system[2].Text != "Second." {
+		t.Fatalf("SystemPromptFromResponses() = %+v", system)
+	}
+}
+
+func TestSystemPromptFromGemini(t *testing.T) {
+	for _, raw := range []string{
+		`{"systemInstruction":{"parts":[{"text":"First."},{"text":"Second."}]}}`,
+		`{"system_instruction":{"parts":[{"text":"First."},{"text":"Second."}]}}`,
+	} {
+		if system := SystemPromptFromGemini(gjson.Parse(raw)); len(system) != 2 || system[1].Text != "Second." {
+			t.Fatalf("SystemPromptFromGemini(%s) = %+v", raw, system)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// toolCallIDCounter keeps generated tool call IDs unique within the process.
+var toolCallIDCounter uint64
+
+// NewToolCallID returns a unique ID for a tool call the upstream did not identify. The tool name
+// is kept as a prefix to ease debugging.
+func NewToolCallID(name string) string {
+	return fmt.Sprintf("%s-%d-%d", name, time.Now().UnixNano(), atomic.AddUint64(&toolCallIDCounter, 1))
+}
+
+// GeminiToolCallID returns the ID clients should see for a Gemini functionCall: the id the
+// upstream assigned, which must come back unchanged for the upstream to match the result, or a
+// new ID when it assigned none.
+func GeminiToolCallID(functionCall gjson.Result, name string) string {
+	if id := strings.TrimSpace(functionCall.Get("id").String()); id != "" {
+		return id
+	}
+	return NewToolCallID(name)
+}
+
+// ToolCallCorrelator assigns IDs to the tool calls and results of a request whose format does
+// not require them, such as Gemini, so they can be written in a format that does. IDs the client
+// sent are kept; missing ones are derived from the call's position in the conversation, so the
+// same history is given the same IDs on every turn. A result without an ID answers the oldest
+// unanswered call of the same name.
+type ToolCallCorrelator struct {
+	prefix  string
+	calls   int
+	pending map[string][]string
+}
+
+// NewToolCallCorrelator returns a correlator whose derived IDs start with prefix, such as "call_"
+// or "toolu_".
+func NewToolCallCorrelator(prefix string) *ToolCallCorrelator {
+	return &ToolCallCorrelator{prefix: prefix, pending: make(map[string][]string)}
+}
+
+// Call returns the ID of the next tool call in the conversation.
+func (c *ToolCallCorrelator) Call(id, name string) string {
+	if id = strings.TrimSpace(id); id == "" {
+		id = c.derive(name)
+	}
+	c.pending[name] = append(c.pending[name], id)
+	return id
+}
+
+// Result returns the ID of the call a tool result answers.
+func (c *ToolCallCorrelator) Result(id, name string) string {
+	id = strings.TrimSpace(id)
+	queue := c.pending[name]
+	if id != "" {
+		for i, pending := range queue {
+			if pending == id {
+				c.pending[name] = append(queue[:i:i], queue[i+1:]...)
+				break
+			}
+		}
+		return id
+	}
+	if len(queue) > 0 {
+		c.pending[name] = queue[1:]
+		return queue[0]
+	}
+	// A result without a matching call still needs a stable ID.
+	return c.derive(name)
+}
+
+// derive returns the ID of the next position in the conversation.
+func (c *ToolCallCorrelator) derive(name string) string {
+	c.calls++
+	sum := sha256.Sum256(fmt.Appendf(nil, "%d:%s", c.calls, name))
+	return c.prefix + hex.EncodeToString(sum[:12])
+}
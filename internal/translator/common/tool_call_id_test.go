@@ -0,0 +1,63 @@
+package common
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestToolCallCorrelatorPairsByName(t *testing.T) {
+	ids := func() []string {
+		c := NewToolCallCorrelator("call_")
+		a := c.Call("", "search")
+		b := c.Call("", "fetch")
+		a2 := c.Call("", "search")
+		return []string{a, b, a2, c.Result("", "fetch"), c.Result("", "search"), c.Result("", "search")}
+	}
+	got := ids()
+	if got[3] != got[1] {
+		t.Fatalf("fetch result paired with %q, want %q", got[3], got[1])
+	}
+	if got[4] != got[0] || got[5] != got[2] {
+		t.Fatalf("search results paired with %q, %q, want %q, %q", got[4], got[5], got[0], got[2])
+	}
+	if got[0] == got[2] || !strings.HasPrefix(got[0], "call_") {
+		t.Fatalf("derived IDs must be distinct and prefixed: %q", got)
+	}
+	again := ids()
+	for i := range got {
+		if got[i] != again[i] {
+			t.Fatalf("derived IDs differ between runs: %q vs %q", got, again)
+		}
+	}
+}
+
+func TestToolCallCorrelatorKeepsClientIDs(t *testing.T) {
+	c := NewToolCallCorrelator("toolu_")
+	if id := c.Call("fc_1", "search"); id != "fc_1" {
+		t.Fatalf("Call = %q, want fc_1", id)
+	}
+	c.Call("", "search")
+	if id := c.Result("fc_1", "search"); id != "fc_1" {
+		t.Fatalf("Result = %q, want fc_1", id)
+	}
+	// fc_1 was answered by ID, so a result without one answers the derived call.
+	if id := c.Result("", "search"); id == "fc_1" || !strings.HasPrefix(id, "toolu_") {
+		t.Fatalf("Result without ID = %q, want the derived call", id)
+	}
+	if id := c.Result("", "unknown"); !strings.HasPrefix(id, "toolu_") {
+		t.Fatalf("unmatched Result = %q, want a derived ID", id)
+	}
+}
+
+func TestGeminiToolCallID(t *testing.T) {
+	if id := GeminiToolCallID(gjson.Parse(`{"id":"abc","name":"f"}`), "f"); id != "abc" {
+		t.Fatalf("upstream id not kept: %q", id)
+	}
+	first := GeminiToolCallID(gjson.Parse(`{"name":"f"}`), "f")
+	second := GeminiToolCallID(gjson.Parse(`{"name":"f"}`), "f")
+	if first == second || !strings.HasPrefix(first, "f-") {
+		t.Fatalf("generated IDs must be unique and keep the name: %q, %q", first, second)
+	}
+}
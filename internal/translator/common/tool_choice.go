@@ -0,0 +1,222 @@
+package common
+
+import (
+	"slices"
+	"strconv"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Tool choice modes shared by all formats.
+const (
+	ToolChoiceAuto     = "auto"
+	ToolChoiceNone     = "none"
+	ToolChoiceRequired = "required"
+)
+
+// ToolChoice is a provider-neutral tool choice shared by the request translators. Names
+// restricts the functions the model may call; a required choice with a single name forces that
+// function. Targets that cannot express a restriction natively get their tool list filtered to
+// the allowed functions instead.
+type ToolChoice struct {
+	Mode  string
+	Names []string
+}
+
+// ToolChoiceFromOpenAI reads a Chat Completions or Responses tool_choice: a mode string, a
+// function choice or an allowed_tools choice.
+func ToolChoiceFromOpenAI(toolChoice gjson.Result) (ToolChoice, bool) {
+	if toolChoice.Type == gjson.String {
+		switch mode := toolChoice.String(); mode {
+		case ToolChoiceAuto, ToolChoiceNone, ToolChoiceRequired:
+			return ToolChoice{Mode: mode}, true
+		}
+		return ToolChoice{}, false
+	}
+	switch toolChoice.Get("type").String() {
+	case "function":
+		name := firstExisting(toolChoice, "function.name", "name").String()
+		if name == "" {
+			return ToolChoice{}, false
+		}
+		return ToolChoice{Mode: ToolChoiceRequired, Names: []string{name}}, true
+	case "allowed_tools":
+		allowed := toolChoice
+		if nested := toolChoice.Get("allowed_tools"); nested.IsObject() {
+			allowed = nested
+		}
+		choice := ToolChoice{Mode: allowed.Get("mode").String()}
+		if choice.Mode != ToolChoiceRequired {
+			choice.Mode = ToolChoiceAuto
+		}
+		for _, tool := range allowed.Get("tools").Array() {
+			if name := firstExisting(tool, "function.name", "name").String(); name != "" {
+				choice.Names = append(choice.Names, name)
+			}
+		}
+		return choice, true
+	}
+	return ToolChoice{}, false
+}
+
+// ToolChoiceFromClaude reads a Claude tool_choice.
+func ToolChoiceFromClaude(toolChoice gjson.Result) (ToolChoice, bool) {
+	choiceType := toolChoice.Get("type").String()
+	if toolChoice.Type == gjson.String {
+		choiceType = toolChoice.String()
+	}
+	switch choiceType {
+	case "auto":
+		return ToolChoice{Mode: ToolChoiceAuto}, true
+	case "none":
+		return ToolChoice{Mode: ToolChoiceNone}, true
+	case "any":
+		return ToolChoice{Mode: ToolChoiceRequired}, true
+	case "tool":
+		if name := toolChoice.Get("name").String(); name != "" {
+			return ToolChoice{Mode: ToolChoiceRequired, Names: []string{name}}, true
+		}
+	}
+	return ToolChoice{}, false
+}
+
+// ToolChoiceFromGemini reads the function calling config of a Gemini request, accepting both
+// the camelCase and snake_case spellings. VALIDATED mode is treated as AUTO.
+func ToolChoiceFromGemini(request gjson.Result) (ToolChoice, bool) {
+	toolConfig := firstExisting(request, "toolConfig", "tool_config")
+	config := firstExisting(toolConfig, "functionCallingConfig", "function_calling_config")
+	var choice ToolChoice
+	switch config.Get("mode").String() {
+	case "AUTO", "VALIDATED":
+		choice.Mode = ToolChoiceAuto
+	case "NONE":
+		return ToolChoice{Mode: ToolChoiceNone}, true
+	case "ANY":
+		choice.Mode = ToolChoiceRequired
+	default:
+		return ToolChoice{}, false
+	}
+	for _, name := range firstExisting(config, "allowedFunctionNames", "allowed_function_names").Array() {
+		choice.Names = append(choice.Names, name.String())
+	}
+	return choice, true
+}
+
+// forced returns the single function a required choice forces, if any.
+func (c ToolChoice) forced() (string, bool) {
+	if c.Mode == ToolChoiceRequired && len(c.Names) == 1 {
+		return c.Names[0], true
+	}
+	return "", false
+}
+
+// restricted reports whether the tool list must be filtered to emulate Names on a target that
+// can only force a single function.
+func (c ToolChoice) restricted() bool {
+	if c.Mode == ToolChoiceNone || len(c.Names) == 0 {
+		return false
+	}
+	_, forced := c.forced()
+	return !forced
+}
+
+// ApplyToOpenAI sets the tool_choice of a Chat Completions request.
+func (c ToolChoice) ApplyToOpenAI(out []byte) []byte {
+	if c.restricted() {
+		out = filterTools(out, "tools", "function.name", c.Names)
+	}
+	if name, ok := c.forced(); ok {
+		choice := []byte(`{"type":"function","function":{"name":""}}`)
+		choice, _ = sjson.SetBytes(choice, "function.name", name)
+		out, _ = sjson.SetRawBytes(out, "tool_choice", choice)
+		return out
+	}
+	out, _ = sjson.SetBytes(out, "tool_choice", c.Mode)
+	return out
+}
+
+// ApplyToResponses sets the tool_choice of a Responses request.
+func (c ToolChoice) ApplyToResponses(out []byte) []byte {
+	if c.restricted() {
+		out = filterTools(out, "tools", "name", c.Names)
+	}
+	if name, ok := c.forced(); ok {
+		choice := []byte(`{"type":"function","name":""}`)
+		choice, _ = sjson.SetBytes(choice, "name", name)
+		out, _ = sjson.SetRawBytes(out, "tool_choice", choice)
+		return out
+	}
+	out, _ = sjson.SetBytes(out, "tool_choice", c.Mode)
+	return out
+}
+
+// ApplyToClaude sets the tool_choice of a Claude request.
+func (c ToolChoice) ApplyToClaude(out []byte) []byte {
+	if c.restricted() {
+		out = filterTools(out, "tools", "name", c.Names)
+	}
+	choice := []byte(`{"type":"auto"}`)
+	switch name, forced := c.forced(); {
+	case forced:
+		choice = []byte(`{"type":"tool","name":""}`)
+		choice, _ = sjson.SetBytes(choice, "name", name)
+	case c.Mode == ToolChoiceNone:
+		choice = []byte(`{"type":"none"}`)
+	case c.Mode == ToolChoiceRequired:
+		choice = []byte(`{"type":"any"}`)
+	}
+	out, _ = sjson.SetRawBytes(out, "tool_choice", choice)
+	return out
+}
+
+// ApplyToGemini sets the function calling config of a Gemini request whose fields live under
+// prefix, such as "" or "request.". Gemini only honours allowed function names in ANY mode, so
+// an AUTO choice restricted to some functions filters the declarations instead.
+func (c ToolChoice) ApplyToGemini(out []byte, prefix string) []byte {
+	names := make([]string, 0, len(c.Names))
+	for _, name := range c.Names {
+		names = append(names, util.SanitizeFunctionName(name))
+	}
+	config := []byte(`{"mode":""}`)
+	switch c.Mode {
+	case ToolChoiceNone:
+		config, _ = sjson.SetBytes(config, "mode", "NONE")
+	case ToolChoiceRequired:
+		config, _ = sjson.SetBytes(config, "mode", "ANY")
+		if len(names) > 0 {
+			config, _ = sjson.SetBytes(config, "allowedFunctionNames", names)
+		}
+	default:
+		config, _ = sjson.SetBytes(config, "mode", "AUTO")
+		if len(names) > 0 {
+			for i, tool := range gjson.GetBytes(out, prefix+"tools").Array() {
+				if tool.Get("functionDeclarations").Exists() {
+					out = filterTools(out, prefix+"tools."+strconv.Itoa(i)+".functionDeclarations", "name", names)
+				}
+			}
+		}
+	}
+	out, _ = sjson.SetRawBytes(out, prefix+"toolConfig.functionCallingConfig", config)
+	return out
+}
+
+// filterTools keeps the tools at path whose name, read at namePath, is one of names. Tools
+// without a name, such as built-in tools, are kept.
+func filterTools(out []byte, path, namePath string, names []string) []byte {
+	tools := gjson.GetBytes(out, path)
+	if !tools.IsArray() {
+		return out
+	}
+	kept := []byte(`[]`)
+	for _, tool := range tools.Array() {
+		name := tool.Get(namePath)
+		if name.Exists() && !slices.Contains(names, name.String()) {
+			continue
+		}
+		kept, _ = sjson.SetRawBytes(kept, "-1", []byte(tool.Raw))
+	}
+	out, _ = sjson.SetRawBytes(out, path, kept)
+	return out
+}
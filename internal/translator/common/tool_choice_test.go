@@ -0,0 +1,81 @@
+package common
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestToolChoiceParsing(t *testing.T) {
+	tests := []struct {
+		name  string
+		parse func(gjson.Result) (ToolChoice, bool)
+		raw   string
+		mode  string
+		names []string
+	}{
+		{"openai required", ToolChoiceFromOpenAI, `"required"`, ToolChoiceRequired, nil},
+		{"openai chat function", ToolChoiceFromOpenAI, `{"type":"function","function":{"name":"lookup"}}`, ToolChoiceRequired, []string{"lookup"}},
+		{"responses function", ToolChoiceFromOpenAI, `{"type":"function","name":"lookup"}`, ToolChoiceRequired, []string{"lookup"}},
+		{"openai allowed tools", ToolChoiceFromOpenAI, `{"type":"allowed_tools","allowed_tools":{"mode":"auto","tools":[{"type":"function","function":{"name":"a"}},{"type":"function","function":{"name":"b"}}]}}`, ToolChoiceAuto, []string{"a", "b"}},
+		{"claude any", ToolChoiceFromClaude, `{"type":"any"}`, ToolChoiceRequired, nil},
+		{"claude none", ToolChoiceFromClaude, `{"type":"none"}`, ToolChoiceNone, nil},
+		{"claude tool", ToolChoiceFromClaude, `{"type":"tool","name":"lookup"}`, ToolChoiceRequired, []string{"lookup"}},
+		{"gemini any", ToolChoiceFromGemini, `{"toolConfig":{"functionCallingConfig":{"mode":"ANY","allowedFunctionNames":["a","b"]}}}`, ToolChoiceRequired, []string{"a", "b"}},
+		{"gemini snake case", ToolChoiceFromGemini, `{"tool_config":{"function_calling_config":{"mode":"NONE"}}}`, ToolChoiceNone, nil},
+		{"gemini validated", ToolChoiceFromGemini, `{"toolConfig":{"functionCallingConfig":{"mode":"VALIDATED"}}}`, ToolChoiceAuto, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			choice, ok := tt.parse(gjson.Parse(tt.raw))
+			if !ok || choice.Mode != tt.mode || !slices.Equal(choice.Names, tt.names) {
+				t.Fatalf("parsed %s as %+v, %v", tt.raw, choice, ok)
+			}
+		})
+	}
+}
+
+func TestToolChoiceApply(t *testing.T) {
+	forced := ToolChoice{Mode: ToolChoiceRequired, Names: []string{"lookup"}}
+	if out := forced.ApplyToClaude([]byte(`{}`)); gjson.GetBytes(out, "tool_choice.type").String() != "tool" || gjson.GetBytes(out, "tool_choice.name").String() != "lookup" {
+		t.Fatalf("forced ApplyToClaude() = %s", out)
+	}
+	if out := forced.ApplyToOpenAI([]byte(`{}`)); gjson.GetBytes(out, "tool_choice.function.name").String() != "lookup" {
+		t.Fatalf("forced ApplyToOpenAI() = %s", out)
+	}
+	if out := forced.ApplyToResponses([]byte(`{}`)); gjson.GetBytes(out, "tool_choice.name").String() != "lookup" {
+		t.Fatalf("forced ApplyToResponses() = %s", out)
+	}
+	if out := forced.ApplyToGemini([]byte(`{}`), "request."); gjson.GetBytes(out, "request.toolConfig.functionCallingConfig.mode").String() != "ANY" ||
+		gjson.GetBytes(out, "request.toolConfig.functionCallingConfig.allowedFunctionNames.0").String() != "lookup" {
+		t.Fatalf("forced ApplyToGemini() = %s", out)
+	}
+
+	none := ToolChoice{Mode: ToolChoiceNone}
+	if out := none.ApplyToClaude([]byte(`{}`)); gjson.GetBytes(out, "tool_choice.type").String() != "none" {
+		t.Fatalf("none ApplyToClaude() = %s", out)
+	}
+	if out := none.ApplyToGemini([]byte(`{}`), ""); gjson.GetBytes(out, "toolConfig.functionCallingConfig.mode").String() != "NONE" {
+		t.Fatalf("none ApplyToGemini() = %s", out)
+	}
+
+	// A choice between several functions is emulated by dropping the other tools.
+	subset := ToolChoice{Mode: ToolChoiceRequired, Names: []string{"a", "b"}}
+	out := subset.ApplyToOpenAI([]byte(`{"tools":[{"type":"function","function":{"name":"a"}},{"type":"function","function":{"name":"b"}},{"type":"function","function":{"name":"c"}}]}`))
+	if gjson.GetBytes(out, "tools.#").Int() != 2 || gjson.GetBytes(out, "tool_choice").String() != "required" {
+		t.Fatalf("subset ApplyToOpenAI() = %s", out)
+	}
+	out = subset.ApplyToClaude([]byte(`{"tools":[{"name":"a"},{"name":"c"}]}`))
+	if gjson.GetBytes(out, "tools.#").Int() != 1 || gjson.GetBytes(out, "tool_choice.type").String() != "any" {
+		t.Fatalf("subset ApplyToClaude() = %s", out)
+	}
+
+	// Gemini ignores allowed names in AUTO mode, so the declarations are filtered.
+	auto := ToolChoice{Mode: ToolChoiceAuto, Names: []string{"a"}}
+	out = auto.ApplyToGemini([]byte(`{"tools":[{"functionDeclarations":[{"name":"a"},{"name":"c"}]},{"googleSearch":{}}]}`), "")
+	if gjson.GetBytes(out, "tools.0.functionDeclarations.#").Int() != 1 || !gjson.GetBytes(out, "tools.1.googleSearch").Exists() ||
+		gjson.GetBytes(out, "toolConfig.functionCallingConfig.allowedFunctionNames").Exists() {
+		t.Fatalf("restricted auto ApplyToGemini() = %s", out)
+	}
+}
@@ -0,0 +1,253 @@
+// Package conformance runs a shared corpus of requests and responses through every registered
+// translator pair. Each pair produces a report that is compared against a golden file, so any
+// change in a conversion shows up as a diff and lossy conversions are listed explicitly.
+//
+// Corpus payloads tag the content that should survive translation with marker tokens of the
+// form mk_<name> (text, tool names, arguments, identifiers). A marker present in the input but
+// missing from the output is reported as lost.
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// Model is the model name passed to every translator.
+const Model = "conformance-model"
+
+// OriginalRequestCase names the request case used as the client request when translating
+// responses, so response translators can resolve tool names and request options.
+const OriginalRequestCase = "tools"
+
+// volatileKeys hold values that change between processes without changing within one, such
+// as timestamps and per-process identifiers.
+var volatileKeys = map[string]struct{}{
+	"created":    {},
+	"created_at": {},
+	"createTime": {},
+	"user_id":    {},
+}
+
+var markerPattern = regexp.MustCompile(`mk_[a-z0-9_]+`)
+
+// Case is one corpus payload in a given format.
+type Case struct {
+	Name    string
+	Format  sdktranslator.Format
+	Payload []byte
+}
+
+// Corpus holds client requests keyed by their source format and upstream responses keyed by
+// their upstream format.
+type Corpus struct {
+	Requests  map[sdktranslator.Format][]Case
+	Responses map[sdktranslator.Format][]Case
+}
+
+// Result is the outcome of translating one case.
+type Result struct {
+	// Lost lists the markers of the input that are missing from the output.
+	Lost []string `json:"lost,omitempty"`
+	// Error records a translator panic or an output that is not JSON.
+	Error string `json:"error,omitempty"`
+	// Output is the normalized translation with non-deterministic values masked.
+	Output any `json:"output,omitempty"`
+}
+
+// Report collects the results of one translator pair, keyed by case name.
+type Report struct {
+	Requests  map[string]Result `json:"requests,omitempty"`
+	Responses map[string]Result `json:"responses,omitempty"`
+}
+
+// LoadCorpus reads dir/requests/<format>/<case>.json and dir/responses/<format>/<case>.json.
+// Responses may also be .sse transcripts for upstreams whose non-stream translators consume
+// the event stream.
+func LoadCorpus(dir string) (*Corpus, error) {
+	requests, errLoad := loadCases(filepath.Join(dir, "requests"))
+	if errLoad != nil {
+		return nil, errLoad
+	}
+	responses, errLoad := loadCases(filepath.Join(dir, "responses"))
+	if errLoad != nil {
+		return nil, errLoad
+	}
+	return &Corpus{Requests: requests, Responses: responses}, nil
+}
+
+func loadCases(dir string) (map[sdktranslator.Format][]Case, error) {
+	files, errGlob := filepath.Glob(filepath.Join(dir, "*", "*.*"))
+	if errGlob != nil {
+		return nil, errGlob
+	}
+	cases := make(map[sdktranslator.Format][]Case)
+	for _, file := range files {
+		payload, errRead := os.ReadFile(file)
+		if errRead != nil {
+			return nil, errRead
+		}
+		ext := filepath.Ext(file)
+		switch ext {
+		case ".json":
+			if !json.Valid(payload) {
+				return nil, fmt.Errorf("conformance: %s is not valid JSON", file)
+			}
+		case ".sse":
+		default:
+			return nil, fmt.Errorf("conformance: %s has an unsupported extension", file)
+		}
+		format := sdktranslator.Format(filepath.Base(filepath.Dir(file)))
+		name := strings.TrimSuffix(filepath.Base(file), ext)
+		cases[format] = append(cases[format], Case{Name: name, Format: format, Payload: payload})
+	}
+	return cases, nil
+}
+
+// Run translates the corpus through one pair of the registry. Requests are translated from
+// pair.From to pair.To and upstream responses in pair.To back to pair.From.
+func Run(registry *sdktranslator.Registry, pair sdktranslator.Pair, corpus *Corpus) Report {
+	report := Report{Requests: make(map[string]Result), Responses: make(map[string]Result)}
+	for _, c := range corpus.Requests[pair.From] {
+		report.Requests[c.Name] = evaluate(c.Payload, func() []byte {
+			return registry.TranslateRequest(pair.From, pair.To, Model, c.Payload, false)
+		})
+	}
+
+	original := originalRequest(corpus.Requests[pair.From])
+	translated := registry.TranslateRequest(pair.From, pair.To, Model, original, false)
+	if registry.HasResponseTransformer(pair.From, pair.To) {
+		for _, c := range corpus.Responses[pair.To] {
+			report.Responses[c.Name] = evaluate(c.Payload, func() []byte {
+				var param any
+				return registry.TranslateNonStream(context.Background(), pair.To, pair.From, Model, original, translated, c.Payload, &param)
+			})
+		}
+	}
+	return report
+}
+
+// Marshal renders a report as indented JSON with sorted keys, the format of the golden files.
+func (r Report) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if errEncode := encoder.Encode(r); errEncode != nil {
+		return nil, errEncode
+	}
+	return buf.Bytes(), nil
+}
+
+// Lossy lists "<kind>/<case>: markers" for every case that lost content.
+func (r Report) Lossy() []string {
+	var lossy []string
+	for kind, results := range map[string]map[string]Result{"requests": r.Requests, "responses": r.Responses} {
+		for name, result := range results {
+			if len(result.Lost) > 0 {
+				lossy = append(lossy, kind+"/"+name+": "+strings.Join(result.Lost, ", "))
+			}
+		}
+	}
+	slices.Sort(lossy)
+	return lossy
+}
+
+// Markers returns the sorted, distinct marker tokens found in payload.
+func Markers(payload []byte) []string {
+	markers := markerPattern.FindAllString(string(payload), -1)
+	slices.Sort(markers)
+	return slices.Compact(markers)
+}
+
+func originalRequest(cases []Case) []byte {
+	for _, c := range cases {
+		if c.Name == OriginalRequestCase {
+			return c.Payload
+		}
+	}
+	if len(cases) > 0 {
+		return cases[0].Payload
+	}
+	return []byte(`{}`)
+}
+
+// evaluate runs translate twice so values that differ between runs can be masked, then
+// checks which markers of input survived.
+func evaluate(input []byte, translate func() []byte) (result Result) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			result = Result{Error: fmt.Sprintf("panic: %v", recovered)}
+		}
+	}()
+
+	first, errFirst := decode(translate())
+	if errFirst != nil {
+		return Result{Error: errFirst.Error()}
+	}
+	second, errSecond := decode(translate())
+	if errSecond != nil {
+		return Result{Error: errSecond.Error()}
+	}
+	result.Output = mask(first, second, "")
+
+	encoded, _ := json.Marshal(first)
+	for _, marker := range Markers(input) {
+		if !bytes.Contains(encoded, []byte(marker)) {
+			result.Lost = append(result.Lost, marker)
+		}
+	}
+	return result
+}
+
+func decode(payload []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	var value any
+	if errDecode := decoder.Decode(&value); errDecode != nil {
+		return nil, fmt.Errorf("output is not JSON: %w", errDecode)
+	}
+	return value, nil
+}
+
+// mask replaces values that differ between a and b, and known timestamps, with a placeholder.
+func mask(a, b any, key string) any {
+	if _, ok := volatileKeys[key]; ok {
+		return "<volatile>"
+	}
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok {
+			return "<volatile>"
+		}
+		out := make(map[string]any, len(av))
+		for k, v := range av {
+			out[k] = mask(v, bv[k], k)
+		}
+		return out
+	case []any:
+		bv, ok := b.([]any)
+		if !ok || len(bv) != len(av) {
+			return "<volatile>"
+		}
+		out := make([]any, len(av))
+		for i := range av {
+			out[i] = mask(av[i], bv[i], "")
+		}
+		return out
+	default:
+		if a != b {
+			return "<volatile>"
+		}
+		return a
+	}
+}
@@ -0,0 +1,117 @@
+package conformance
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files from the current translators")
+
+// TestTranslatorConformance compares every registered pair against its golden report. Run
+// with -update after an intended change and review the golden diff, in particular the lost
+// markers.
+func TestTranslatorConformance(t *testing.T) {
+	log.SetLevel(log.PanicLevel)
+	t.Cleanup(func() { log.SetLevel(log.InfoLevel) })
+
+	corpus, errLoad := LoadCorpus(filepath.Join("testdata", "corpus"))
+	if errLoad != nil {
+		t.Fatalf("load corpus: %v", errLoad)
+	}
+
+	pairs := sdktranslator.Default().Pairs()
+	if len(pairs) == 0 {
+		t.Fatal("no translator pairs registered")
+	}
+	seen := make(map[string]struct{}, len(pairs))
+	for _, pair := range pairs {
+		name := string(pair.From) + "_to_" + string(pair.To)
+		seen[name+".json"] = struct{}{}
+		t.Run(name, func(t *testing.T) {
+			report := Run(sdktranslator.Default(), pair, corpus)
+			got, errMarshal := report.Marshal()
+			if errMarshal != nil {
+				t.Fatalf("marshal report: %v", errMarshal)
+			}
+			for _, lossy := range report.Lossy() {
+				t.Logf("lossy: %s", lossy)
+			}
+
+			path := filepath.Join("testdata", "golden", name+".json")
+			if *update {
+				if errWrite := os.WriteFile(path, got, 0o644); errWrite != nil {
+					t.Fatalf("write golden: %v", errWrite)
+				}
+				return
+			}
+			want, errRead := os.ReadFile(path)
+			if errRead != nil {
+				t.Fatalf("read golden (run with -update to create it): %v", errRead)
+			}
+			if !bytes.Equal(got, want) {
+				line, gotLine, wantLine := firstDifference(got, want)
+				t.Errorf("%s differs from the current translation at line %d:\n got: %s\nwant: %s\nrun with -update and review the diff", path, line, gotLine, wantLine)
+			}
+		})
+	}
+
+	goldens, _ := filepath.Glob(filepath.Join("testdata", "golden", "*.json"))
+	for _, golden := range goldens {
+		if _, ok := seen[filepath.Base(golden)]; !ok {
+			t.Errorf("stale golden file %s has no registered pair", golden)
+		}
+	}
+}
+
+// firstDifference returns the first line, counted from 1, where got and want differ.
+func firstDifference(got, want []byte) (int, string, string) {
+	gotLines := strings.Split(string(got), "\n")
+	wantLines := strings.Split(string(want), "\n")
+	for i := 0; i < max(len(gotLines), len(wantLines)); i++ {
+		var g, w string
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if g != w {
+			return i + 1, strings.TrimSpace(g), strings.TrimSpace(w)
+		}
+	}
+	return 0, "", ""
+}
+
+func TestMarkersAndMasking(t *testing.T) {
+	if got := Markers([]byte(`{"a":"mk_b mk_a","c":"\"mk_b\""}`)); strings.Join(got, ",") != "mk_a,mk_b" {
+		t.Fatalf("Markers() = %v", got)
+	}
+
+	calls := 0
+	result := evaluate([]byte(`{"text":"mk_kept","id":"mk_dropped"}`), func() []byte {
+		calls++
+		if calls == 1 {
+			return []byte(`{"text":"mk_kept","id":"a","created":1}`)
+		}
+		return []byte(`{"text":"mk_kept","id":"b","created":1}`)
+	})
+	output := result.Output.(map[string]any)
+	if output["id"] != "<volatile>" || output["created"] != "<volatile>" || output["text"] != "mk_kept" {
+		t.Fatalf("masked output = %v", output)
+	}
+	if len(result.Lost) != 1 || result.Lost[0] != "mk_dropped" {
+		t.Fatalf("lost = %v", result.Lost)
+	}
+
+	if result := evaluate(nil, func() []byte { panic("boom") }); result.Error != "panic: boom" {
+		t.Fatalf("panic result = %+v", result)
+	}
+}
@@ -8,6 +8,7 @@ package claude
 import (
 	"strings"
 
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini/common"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/tidwall/gjson"
@@ -42,29 +43,15 @@ func ConvertClaudeRequestToCLI(modelName string, inputRawJSON []byte, _ bool) []
 	out, _ = sjson.SetBytes(out, "model", modelName)
 
 	// system instruction
-	if systemResult := gjson.GetBytes(rawJSON, "system"); systemResult.IsArray() {
-		systemInstruction := []byte(`{"role":"user","parts":[]}`)
-		hasSystemParts := false
-		systemResult.ForEach(func(_, systemPromptResult gjson.Result) bool {
-			if systemPromptResult.Get("type").String() == "text" {
-				textResult := systemPromptResult.Get("text")
-				if textResult.Type == gjson.String {
-					part := []byte(`{"text":""}`)
-					part, _ = sjson.SetBytes(part, "text", textResult.String())
-					systemInstruction, _ = sjson.SetRawBytes(systemInstruction, "parts.-1", part)
-					hasSystemParts = true
-				}
-			}
-			return true
-		})
-		if hasSystemParts {
-			out, _ = sjson.SetRawBytes(out, "request.systemInstruction", systemInstruction)
-		}
-	} else if systemResult.Type == gjson.String {
-		out, _ = sjson.SetBytes(out, "request.systemInstruction.parts.-1.text", systemResult.String())
+	if system := translatorcommon.SystemPromptFromClaude(gjson.GetBytes(rawJSON, "system")); len(system) > 0 {
+		out, _ = sjson.SetRawBytes(out, "request.systemInstruction", system.GeminiInstruction())
 	}
 
 	// contents
+	// tool_use_id → tool name, recorded as tool_use blocks are converted so the tool_result
+	// answering one can name the function Gemini expects.
+	toolNameByID := make(map[string]string)
+
 	if messagesResult := gjson.GetBytes(rawJSON, "messages"); messagesResult.IsArray() {
 		messagesResult.ForEach(func(_, messageResult gjson.Result) bool {
 			roleResult := messageResult.Get("role")
@@ -90,6 +77,10 @@ func ConvertClaudeRequestToCLI(modelName string, inputRawJSON []byte, _ bool) []
 
 					case "tool_use":
 						functionName := util.SanitizeFunctionName(contentResult.Get("name").String())
+						toolUseID := contentResult.Get("id").String()
+						if toolUseID != "" {
+							toolNameByID[toolUseID] = functionName
+						}
 						functionArgs := contentResult.Get("input").String()
 						argsResult := gjson.Parse(functionArgs)
 						if argsResult.IsObject() && gjson.Valid(functionArgs) {
@@ -97,6 +88,9 @@ func ConvertClaudeRequestToCLI(modelName string, inputRawJSON []byte, _ bool) []
 							part, _ = sjson.SetBytes(part, "thoughtSignature", geminiCLIClaudeThoughtSignature)
 							part, _ = sjson.SetBytes(part, "functionCall.name", functionName)
 							part, _ = sjson.SetRawBytes(part, "functionCall.args", []byte(functionArgs))
+							if toolUseID != "" {
+								part, _ = sjson.SetBytes(part, "functionCall.id", toolUseID)
+							}
 							contentJSON, _ = sjson.SetRawBytes(contentJSON, "parts.-1", part)
 						}
 
@@ -105,26 +99,29 @@ func ConvertClaudeRequestToCLI(modelName string, inputRawJSON []byte, _ bool) []
 						if toolCallID == "" {
 							return true
 						}
-						funcName := toolCallID
-						toolCallIDs := strings.Split(toolCallID, "-")
-						if len(toolCallIDs) > 1 {
-							funcName = strings.Join(toolCallIDs[0:len(toolCallIDs)-1], "-")
+						funcName, ok := toolNameByID[toolCallID]
+						if !ok {
+							funcName = toolCallID
+							toolCallIDs := strings.Split(toolCallID, "-")
+							if len(toolCallIDs) > 1 {
+								funcName = strings.Join(toolCallIDs[0:len(toolCallIDs)-1], "-")
+							}
 						}
 						responseData := contentResult.Get("content").Raw
-						part := []byte(`{"functionResponse":{"name":"","response":{"result":""}}}`)
+						part := []byte(`{"functionResponse":{"id":"","name":"","response":{"result":""}}}`)
+						part, _ = sjson.SetBytes(part, "functionResponse.id", toolCallID)
 						part, _ = sjson.SetBytes(part, "functionResponse.name", util.SanitizeFunctionName(funcName))
 						part, _ = sjson.SetBytes(part, "functionResponse.response.result", responseData)
 						contentJSON, _ = sjson.SetRawBytes(contentJSON, "parts.-1", part)
 
 					case "image":
-						source := contentResult.Get("source")
-						if source.Get("type").String() == "base64" {
-							mimeType := source.Get("media_type").String()
-							data := source.Get("data").String()
-							if mimeType != "" && data != "" {
-								part := []byte(`{"inlineData":{"mime_type":"","data":""}}`)
-								part, _ = sjson.SetBytes(part, "inlineData.mime_type", mimeType)
-								part, _ = sjson.SetBytes(part, "inlineData.data", data)
+						if img, ok := translatorcommon.ImageFromClaudeSource(contentResult.Get("source")); ok {
+							contentJSON, _ = sjson.SetRawBytes(contentJSON, "parts.-1", img.GeminiPart())
+						}
+
+					case "document":
+						if doc, ok := translatorcommon.DocumentFromClaudeBlock(contentResult); ok {
+							if part, errRender := doc.GeminiPart(); errRender == nil {
 								contentJSON, _ = sjson.SetRawBytes(contentJSON, "parts.-1", part)
 							}
 						}
@@ -173,31 +170,12 @@ func ConvertClaudeRequestToCLI(modelName string, inputRawJSON []byte, _ bool) []
 		}
 	}
 
-	// tool_choice
-	toolChoiceResult := gjson.GetBytes(rawJSON, "tool_choice")
-	if toolChoiceResult.Exists() {
-		toolChoiceType := ""
-		toolChoiceName := ""
-		if toolChoiceResult.IsObject() {
-			toolChoiceType = toolChoiceResult.Get("type").String()
-			toolChoiceName = toolChoiceResult.Get("name").String()
-		} else if toolChoiceResult.Type == gjson.String {
-			toolChoiceType = toolChoiceResult.String()
-		}
+	// web_search and code_execution server tools -> Gemini built-in tools
+	out = translatorcommon.ApplyBuiltinToolsToGemini(out, "request.tools", translatorcommon.BuiltinToolsFromClaude(gjson.ParseBytes(rawJSON)))
 
-		switch toolChoiceType {
-		case "auto":
-			out, _ = sjson.SetBytes(out, "request.toolConfig.functionCallingConfig.mode", "AUTO")
-		case "none":
-			out, _ = sjson.SetBytes(out, "request.toolConfig.functionCallingConfig.mode", "NONE")
-		case "any":
-			out, _ = sjson.SetBytes(out, "request.toolConfig.functionCallingConfig.mode", "ANY")
-		case "tool":
-			out, _ = sjson.SetBytes(out, "request.toolConfig.functionCallingConfig.mode", "ANY")
-			if toolChoiceName != "" {
-				out, _ = sjson.SetBytes(out, "request.toolConfig.functionCallingConfig.allowedFunctionNames", []string{util.SanitizeFunctionName(toolChoiceName)})
-			}
-		}
+	// tool_choice
+	if toolChoice, ok := translatorcommon.ToolChoiceFromClaude(gjson.GetBytes(rawJSON, "tool_choice")); ok {
+		out = toolChoice.ApplyToGemini(out, "request.")
 	}
 
 	// Map Anthropic thinking -> Gemini CLI thinkingConfig when enabled
@@ -12,8 +12,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
-	"sync/atomic"
-	"time"
 
 	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
@@ -35,9 +33,6 @@ type Params struct {
 	ToolIntentBuffer *util.ToolIntentBuffer
 }
 
-// toolUseIDCounter provides a process-wide unique counter for tool use identifiers.
-var toolUseIDCounter uint64
-
 // ConvertGeminiCLIResponseToClaude performs sophisticated streaming response format conversion.
 // This function implements a complex state machine that translates backend client responses
 // into Claude Code-compatible Server-Sent Events (SSE) format. It manages different response types
@@ -78,6 +73,8 @@ func ConvertGeminiCLIResponseToClaude(_ context.Context, _ string, originalReque
 		return [][]byte{}
 	}
 
+	rawJSON = translatorcommon.GeminiBuiltinToolPartsAsText(rawJSON)
+
 	// Track whether tools are being used in this response chunk
 	usedTool := false
 	output := make([]byte, 0, 1024)
@@ -179,7 +176,7 @@ func ConvertGeminiCLIResponseToClaude(_ context.Context, _ string, originalReque
 						for _, intent := range tagIntents {
 							clientToolName := util.RestoreSanitizedToolName(p.ToolNameMap, intent.Name)
 							data := []byte(fmt.Sprintf(`{"type":"content_block_start","index":%d,"content_block":{"type":"tool_use","id":"","name":"","input":{}}}`, p.ResponseIndex))
-							data, _ = sjson.SetBytes(data, "content_block.id", util.SanitizeClaudeToolID(fmt.Sprintf("%s-%d-%d", clientToolName, time.Now().UnixNano(), atomic.AddUint64(&toolUseIDCounter, 1))))
+							data, _ = sjson.SetBytes(data, "content_block.id", util.SanitizeClaudeToolID(translatorcommon.NewToolCallID(clientToolName)))
 							data, _ = sjson.SetBytes(data, "content_block.name", clientToolName)
 							appendEvent("content_block_start", string(data))
 
@@ -228,7 +225,7 @@ func ConvertGeminiCLIResponseToClaude(_ context.Context, _ string, originalReque
 				// This creates the structure for a function call in Claude Code format
 				// Create the tool use block with unique ID and function details
 				data := []byte(fmt.Sprintf(`{"type":"content_block_start","index":%d,"content_block":{"type":"tool_use","id":"","name":"","input":{}}}`, (*param).(*Params).ResponseIndex))
-				data, _ = sjson.SetBytes(data, "content_block.id", util.SanitizeClaudeToolID(fmt.Sprintf("%s-%d-%d", fcName, time.Now().UnixNano(), atomic.AddUint64(&toolUseIDCounter, 1))))
+				data, _ = sjson.SetBytes(data, "content_block.id", util.SanitizeClaudeToolID(translatorcommon.GeminiToolCallID(functionCallResult, fcName)))
 				data, _ = sjson.SetBytes(data, "content_block.name", fcName)
 				appendEvent("content_block_start", string(data))
 
@@ -256,8 +253,8 @@ func ConvertGeminiCLIResponseToClaude(_ context.Context, _ string, originalReque
 				// Set tool_use stop reason if tools were used in this response
 				if usedTool {
 					template = []byte(`{"type":"message_delta","delta":{"stop_reason":"tool_use","stop_sequence":null},"usage":{"input_tokens":0,"output_tokens":0}}`)
-				} else if finish := gjson.GetBytes(rawJSON, "response.candidates.0.finishReason"); finish.Exists() && finish.String() == "MAX_TOKENS" {
-					template = []byte(`{"type":"message_delta","delta":{"stop_reason":"max_tokens","stop_sequence":null},"usage":{"input_tokens":0,"output_tokens":0}}`)
+				} else if finish := gjson.GetBytes(rawJSON, "response.candidates.0.finishReason"); finish.Exists() {
+					template, _ = sjson.SetBytes(template, "delta.stop_reason", translatorcommon.ClaudeStopReasonFromGemini(finish.String()))
 				}
 
 				// Include thinking tokens in output token count if present
@@ -287,6 +284,7 @@ func ConvertGeminiCLIResponseToClaudeNonStream(_ context.Context, _ string, orig
 	toolNameMap := util.SanitizedToolNameMap(originalRequestRawJSON)
 	_ = requestRawJSON
 
+	rawJSON = translatorcommon.GeminiBuiltinToolPartsAsText(rawJSON)
 	root := gjson.ParseBytes(rawJSON)
 
 	out := []byte(`{"id":"","type":"message","role":"assistant","model":"","content":[],"stop_reason":null,"stop_sequence":null,"usage":{"input_tokens":0,"output_tokens":0}}`)
@@ -301,7 +299,6 @@ func ConvertGeminiCLIResponseToClaudeNonStream(_ context.Context, _ string, orig
 	parts := root.Get("response.candidates.0.content.parts")
 	textBuilder := strings.Builder{}
 	thinkingBuilder := strings.Builder{}
-	toolIDCounter := 0
 	hasToolCall := false
 
 	flushText := func() {
@@ -318,10 +315,9 @@ func ConvertGeminiCLIResponseToClaudeNonStream(_ context.Context, _ string, orig
 
 		for _, intent := range tagIntents {
 			hasToolCall = true
-			toolIDCounter++
 			clientToolName := util.RestoreSanitizedToolName(toolNameMap, intent.Name)
 			toolBlock := []byte(`{"type":"tool_use","id":"","name":"","input":{}}`)
-			toolBlock, _ = sjson.SetBytes(toolBlock, "id", util.SanitizeClaudeToolID(fmt.Sprintf("%s-%d", clientToolName, toolIDCounter)))
+			toolBlock, _ = sjson.SetBytes(toolBlock, "id", util.SanitizeClaudeToolID(translatorcommon.NewToolCallID(clientToolName)))
 			toolBlock, _ = sjson.SetBytes(toolBlock, "name", clientToolName)
 			if len(intent.Arguments) > 0 {
 				if argsJSON, err := json.Marshal(intent.Arguments); err == nil {
@@ -363,9 +359,8 @@ func ConvertGeminiCLIResponseToClaudeNonStream(_ context.Context, _ string, orig
 				hasToolCall = true
 
 				name := util.RestoreSanitizedToolName(toolNameMap, functionCall.Get("name").String())
-				toolIDCounter++
 				toolBlock := []byte(`{"type":"tool_use","id":"","name":"","input":{}}`)
-				toolBlock, _ = sjson.SetBytes(toolBlock, "id", fmt.Sprintf("tool_%d", toolIDCounter))
+				toolBlock, _ = sjson.SetBytes(toolBlock, "id", util.SanitizeClaudeToolID(translatorcommon.GeminiToolCallID(functionCall, name)))
 				toolBlock, _ = sjson.SetBytes(toolBlock, "name", name)
 				inputRaw := "{}"
 				if args := functionCall.Get("args"); args.Exists() && gjson.Valid(args.Raw) && args.IsObject() {
@@ -386,14 +381,7 @@ func ConvertGeminiCLIResponseToClaudeNonStream(_ context.Context, _ string, orig
 		stopReason = "tool_use"
 	} else {
 		if finish := root.Get("response.candidates.0.finishReason"); finish.Exists() {
-			switch finish.String() {
-			case "MAX_TOKENS":
-				stopReason = "max_tokens"
-			case "STOP", "FINISH_REASON_UNSPECIFIED", "UNKNOWN":
-				stopReason = "end_turn"
-			default:
-				stopReason = "end_turn"
-			}
+			stopReason = translatorcommon.ClaudeStopReasonFromGemini(finish.String())
 		}
 	}
 	out, _ = sjson.SetBytes(out, "stop_reason", stopReason)
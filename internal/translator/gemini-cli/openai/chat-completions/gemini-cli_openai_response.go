@@ -11,9 +11,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
-	"sync/atomic"
 	"time"
 
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
 	. "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini/openai/chat-completions"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	log "github.com/sirupsen/logrus"
@@ -27,11 +27,10 @@ type convertCliResponseToOpenAIChatParams struct {
 	FunctionIndex    int
 	ToolIntentBuffer *util.ToolIntentBuffer
 	SanitizedNameMap map[string]string
+	// Candidates holds the Gemini translator state when several candidates were requested.
+	Candidates any
 }
 
-// functionCallIDCounter provides a process-wide unique counter for function call identifiers.
-var functionCallIDCounter uint64
-
 // ConvertCliResponseToOpenAI translates a single chunk of a streaming response from the
 // Gemini CLI API format to the OpenAI Chat Completions streaming format.
 // It processes various Gemini CLI event types and transforms them into OpenAI-compatible JSON responses.
@@ -46,7 +45,7 @@ var functionCallIDCounter uint64
 //
 // Returns:
 //   - [][]byte: A slice of OpenAI-compatible JSON responses
-func ConvertCliResponseToOpenAI(_ context.Context, _ string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) [][]byte {
+func ConvertCliResponseToOpenAI(ctx context.Context, modelName string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) [][]byte {
 	if *param == nil {
 		*param = &convertCliResponseToOpenAIChatParams{
 			UnixTimestamp:    0,
@@ -66,6 +65,13 @@ func ConvertCliResponseToOpenAI(_ context.Context, _ string, originalRequestRawJ
 		return [][]byte{}
 	}
 
+	// Several candidates are streamed as separate choices by the Gemini translator.
+	if gjson.GetBytes(requestRawJSON, "request.generationConfig.candidateCount").Int() > 1 {
+		return ConvertGeminiResponseToOpenAI(ctx, modelName, originalRequestRawJSON, requestRawJSON, []byte(gjson.GetBytes(rawJSON, "response").Raw), &(*param).(*convertCliResponseToOpenAIChatParams).Candidates)
+	}
+
+	rawJSON = translatorcommon.GeminiBuiltinToolPartsAsText(rawJSON)
+
 	// Initialize the OpenAI SSE template.
 	template := []byte(`{"id":"","object":"chat.completion.chunk","created":12345,"model":"model","choices":[{"index":0,"delta":{"role":null,"content":null,"reasoning_content":null,"tool_calls":null},"finish_reason":null,"native_finish_reason":null}]}`)
 
@@ -178,7 +184,7 @@ func ConvertCliResponseToOpenAI(_ context.Context, _ string, originalRequestRawJ
 
 						for _, intent := range tagIntents {
 							functionCallTemplate := []byte(`{"id":"","index":0,"type":"function","function":{"name":"","arguments":""}}`)
-							functionCallTemplate, _ = sjson.SetBytes(functionCallTemplate, "id", fmt.Sprintf("%s-%d-%d", intent.Name, time.Now().UnixNano(), atomic.AddUint64(&functionCallIDCounter, 1)))
+							functionCallTemplate, _ = sjson.SetBytes(functionCallTemplate, "id", translatorcommon.NewToolCallID(intent.Name))
 							functionCallTemplate, _ = sjson.SetBytes(functionCallTemplate, "index", functionCallIndex)
 							functionCallTemplate, _ = sjson.SetBytes(functionCallTemplate, "function.name", intent.Name)
 							if len(intent.Arguments) > 0 {
@@ -208,7 +214,7 @@ func ConvertCliResponseToOpenAI(_ context.Context, _ string, originalRequestRawJ
 
 				functionCallTemplate := []byte(`{"id":"","index":0,"type":"function","function":{"name":"","arguments":""}}`)
 				fcName := util.RestoreSanitizedToolName((*param).(*convertCliResponseToOpenAIChatParams).SanitizedNameMap, functionCallResult.Get("name").String())
-				functionCallTemplate, _ = sjson.SetBytes(functionCallTemplate, "id", fmt.Sprintf("%s-%d-%d", fcName, time.Now().UnixNano(), atomic.AddUint64(&functionCallIDCounter, 1)))
+				functionCallTemplate, _ = sjson.SetBytes(functionCallTemplate, "id", translatorcommon.GeminiToolCallID(functionCallResult, fcName))
 				functionCallTemplate, _ = sjson.SetBytes(functionCallTemplate, "index", functionCallIndex)
 				functionCallTemplate, _ = sjson.SetBytes(functionCallTemplate, "function.name", fcName)
 				if fcArgsResult := functionCallResult.Get("args"); fcArgsResult.Exists() {
@@ -246,12 +252,14 @@ func ConvertCliResponseToOpenAI(_ context.Context, _ string, originalRequestRawJ
 	if hasFunctionCall {
 		template, _ = sjson.SetBytes(template, "choices.0.finish_reason", "tool_calls")
 		template, _ = sjson.SetBytes(template, "choices.0.native_finish_reason", "tool_calls")
-	} else if finishReason != "" && (*param).(*convertCliResponseToOpenAIChatParams).FunctionIndex == 0 {
-		// Only pass through specific finish reasons
-		if finishReason == "max_tokens" || finishReason == "stop" {
-			template, _ = sjson.SetBytes(template, "choices.0.finish_reason", finishReason)
-			template, _ = sjson.SetBytes(template, "choices.0.native_finish_reason", finishReason)
-		}
+	} else if finishReason != "" && finishReason != "finish_reason_unspecified" && (*param).(*convertCliResponseToOpenAIChatParams).FunctionIndex == 0 {
+		template, _ = sjson.SetBytes(template, "choices.0.finish_reason", translatorcommon.OpenAIFinishReasonFromGemini(finishReason))
+		template, _ = sjson.SetBytes(template, "choices.0.native_finish_reason", finishReason)
+	}
+
+	// Token log probabilities: logprobsResult -> logprobs.content
+	if tokens := translatorcommon.TokenLogprobsFromGemini(gjson.GetBytes(rawJSON, "response.candidates.0")); len(tokens) > 0 {
+		template, _ = sjson.SetRawBytes(template, "choices.0.logprobs", translatorcommon.OpenAILogprobs(tokens))
 	}
 
 	return [][]byte{template}
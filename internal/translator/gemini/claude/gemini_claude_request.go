@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini/common"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/tidwall/gjson"
@@ -36,29 +37,15 @@ func ConvertClaudeRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 	out, _ = sjson.SetBytes(out, "model", modelName)
 
 	// system instruction
-	if systemResult := gjson.GetBytes(rawJSON, "system"); systemResult.IsArray() {
-		systemInstruction := []byte(`{"role":"user","parts":[]}`)
-		hasSystemParts := false
-		systemResult.ForEach(func(_, systemPromptResult gjson.Result) bool {
-			if systemPromptResult.Get("type").String() == "text" {
-				textResult := systemPromptResult.Get("text")
-				if textResult.Type == gjson.String {
-					part := []byte(`{"text":""}`)
-					part, _ = sjson.SetBytes(part, "text", textResult.String())
-					systemInstruction, _ = sjson.SetRawBytes(systemInstruction, "parts.-1", part)
-					hasSystemParts = true
-				}
-			}
-			return true
-		})
-		if hasSystemParts {
-			out, _ = sjson.SetRawBytes(out, "system_instruction", systemInstruction)
-		}
-	} else if systemResult.Type == gjson.String {
-		out, _ = sjson.SetBytes(out, "system_instruction.parts.-1.text", systemResult.String())
+	if system := translatorcommon.SystemPromptFromClaude(gjson.GetBytes(rawJSON, "system")); len(system) > 0 {
+		out, _ = sjson.SetRawBytes(out, "system_instruction", system.GeminiInstruction())
 	}
 
 	// contents
+	// tool_use_id → tool name, recorded as tool_use blocks are converted so the tool_result
+	// answering one can name the function Gemini expects.
+	toolNameByID := make(map[string]string)
+
 	if messagesResult := gjson.GetBytes(rawJSON, "messages"); messagesResult.IsArray() {
 		messagesResult.ForEach(func(_, messageResult gjson.Result) bool {
 			roleResult := messageResult.Get("role")
@@ -83,13 +70,11 @@ func ConvertClaudeRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 						contentJSON, _ = sjson.SetRawBytes(contentJSON, "parts.-1", part)
 
 					case "tool_use":
-						functionName := contentResult.Get("name").String()
-						if toolUseID := contentResult.Get("id").String(); toolUseID != "" {
-							if derived := toolNameFromClaudeToolUseID(toolUseID); derived != "" {
-								functionName = derived
-							}
+						functionName := util.SanitizeFunctionName(contentResult.Get("name").String())
+						toolUseID := contentResult.Get("id").String()
+						if toolUseID != "" {
+							toolNameByID[toolUseID] = functionName
 						}
-						functionName = util.SanitizeFunctionName(functionName)
 						functionArgs := contentResult.Get("input").String()
 						argsResult := gjson.Parse(functionArgs)
 						if argsResult.IsObject() && gjson.Valid(functionArgs) {
@@ -97,6 +82,9 @@ func ConvertClaudeRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 							part, _ = sjson.SetBytes(part, "thoughtSignature", geminiClaudeThoughtSignature)
 							part, _ = sjson.SetBytes(part, "functionCall.name", functionName)
 							part, _ = sjson.SetRawBytes(part, "functionCall.args", []byte(functionArgs))
+							if toolUseID != "" {
+								part, _ = sjson.SetBytes(part, "functionCall.id", toolUseID)
+							}
 							contentJSON, _ = sjson.SetRawBytes(contentJSON, "parts.-1", part)
 						}
 
@@ -105,31 +93,41 @@ func ConvertClaudeRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 						if toolCallID == "" {
 							return true
 						}
-						funcName := toolNameFromClaudeToolUseID(toolCallID)
+						funcName, ok := toolNameByID[toolCallID]
+						if !ok {
+							funcName = toolNameFromClaudeToolUseID(toolCallID)
+						}
 						if funcName == "" {
 							funcName = toolCallID
 						}
 						funcName = util.SanitizeFunctionName(funcName)
 						responseData := contentResult.Get("content").Raw
-						part := []byte(`{"functionResponse":{"name":"","response":{"result":""}}}`)
+						part := []byte(`{"functionResponse":{"id":"","name":"","response":{"result":""}}}`)
+						part, _ = sjson.SetBytes(part, "functionResponse.id", toolCallID)
 						part, _ = sjson.SetBytes(part, "functionResponse.name", funcName)
 						part, _ = sjson.SetBytes(part, "functionResponse.response.result", responseData)
 						contentJSON, _ = sjson.SetRawBytes(contentJSON, "parts.-1", part)
 
 					case "image":
-						source := contentResult.Get("source")
-						if source.Get("type").String() != "base64" {
+						img, ok := translatorcommon.ImageFromClaudeSource(contentResult.Get("source"))
+						if !ok {
 							return true
 						}
-						mimeType := source.Get("media_type").String()
-						data := source.Get("data").String()
-						if mimeType == "" || data == "" {
+						if img.URL != "" {
+							contentJSON, _ = sjson.SetRawBytes(contentJSON, "parts.-1", img.GeminiPart())
 							return true
 						}
 						part := []byte(`{"inline_data":{"mime_type":"","data":""}}`)
-						part, _ = sjson.SetBytes(part, "inline_data.mime_type", mimeType)
-						part, _ = sjson.SetBytes(part, "inline_data.data", data)
+						part, _ = sjson.SetBytes(part, "inline_data.mime_type", img.MimeType)
+						part, _ = sjson.SetBytes(part, "inline_data.data", img.Data)
 						contentJSON, _ = sjson.SetRawBytes(contentJSON, "parts.-1", part)
+
+					case "document":
+						if doc, ok := translatorcommon.DocumentFromClaudeBlock(contentResult); ok {
+							if part, errRender := doc.GeminiPart(); errRender == nil {
+								contentJSON, _ = sjson.SetRawBytes(contentJSON, "parts.-1", part)
+							}
+						}
 					}
 					return true
 				})
@@ -207,31 +205,12 @@ func ConvertClaudeRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 		}
 	}
 
-	// tool_choice
-	toolChoiceResult := gjson.GetBytes(rawJSON, "tool_choice")
-	if toolChoiceResult.Exists() {
-		toolChoiceType := ""
-		toolChoiceName := ""
-		if toolChoiceResult.IsObject() {
-			toolChoiceType = toolChoiceResult.Get("type").String()
-			toolChoiceName = toolChoiceResult.Get("name").String()
-		} else if toolChoiceResult.Type == gjson.String {
-			toolChoiceType = toolChoiceResult.String()
-		}
+	// web_search and code_execution server tools -> Gemini built-in tools
+	out = translatorcommon.ApplyBuiltinToolsToGemini(out, "tools", translatorcommon.BuiltinToolsFromClaude(gjson.ParseBytes(rawJSON)))
 
-		switch toolChoiceType {
-		case "auto":
-			out, _ = sjson.SetBytes(out, "toolConfig.functionCallingConfig.mode", "AUTO")
-		case "none":
-			out, _ = sjson.SetBytes(out, "toolConfig.functionCallingConfig.mode", "NONE")
-		case "any":
-			out, _ = sjson.SetBytes(out, "toolConfig.functionCallingConfig.mode", "ANY")
-		case "tool":
-			out, _ = sjson.SetBytes(out, "toolConfig.functionCallingConfig.mode", "ANY")
-			if toolChoiceName != "" {
-				out, _ = sjson.SetBytes(out, "toolConfig.functionCallingConfig.allowedFunctionNames", []string{util.SanitizeFunctionName(toolChoiceName)})
-			}
-		}
+	// tool_choice
+	if toolChoice, ok := translatorcommon.ToolChoiceFromClaude(gjson.GetBytes(rawJSON, "tool_choice")); ok {
+		out = toolChoice.ApplyToGemini(out, "")
 	}
 
 	// Map Anthropic thinking -> Gemini thinking config when enabled
@@ -285,6 +264,8 @@ func ConvertClaudeRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 	return result
 }
 
+// toolNameFromClaudeToolUseID recovers the tool name embedded in an ID generated for a Gemini
+// function call, for tool results whose tool_use is no longer in the conversation.
 func toolNameFromClaudeToolUseID(toolUseID string) string {
 	parts := strings.Split(toolUseID, "-")
 	if len(parts) <= 1 {
@@ -78,3 +78,71 @@ func TestConvertClaudeRequestToGemini_ImageContent(t *testing.T) {
 		t.Fatalf("Expected image data 'aGVsbG8=', got '%s'", got)
 	}
 }
+
+func TestConvertClaudeRequestToGemini_URLImageContent(t *testing.T) {
+	inputJSON := []byte(`{
+		"model": "gemini-3-flash-preview",
+		"messages": [
+			{
+				"role": "user",
+				"content": [
+					{"type": "image", "source": {"type": "url", "url": "https://example.com/cat.png"}}
+				]
+			}
+		]
+	}`)
+
+	output := ConvertClaudeRequestToGemini("gemini-3-flash-preview", inputJSON, false)
+
+	part := gjson.GetBytes(output, "contents.0.parts.0")
+	if got := part.Get("fileData.fileUri").String(); got != "https://example.com/cat.png" {
+		t.Fatalf("Expected file URI 'https://example.com/cat.png', got '%s'", got)
+	}
+	if got := part.Get("fileData.mimeType").String(); got != "image/png" {
+		t.Fatalf("Expected file mime type 'image/png', got '%s'", got)
+	}
+}
+
+func TestConvertClaudeRequestToGemini_DocumentContent(t *testing.T) {
+	inputJSON := []byte(`{
+		"model": "gemini-3-flash-preview",
+		"messages": [
+			{
+				"role": "user",
+				"content": [
+					{"type": "document", "source": {"type": "base64", "media_type": "application/pdf", "data": "JVBERi0="}}
+				]
+			}
+		]
+	}`)
+
+	output := ConvertClaudeRequestToGemini("gemini-3-flash-preview", inputJSON, false)
+
+	part := gjson.GetBytes(output, "contents.0.parts.0")
+	if got := part.Get("inlineData.mimeType").String(); got != "application/pdf" {
+		t.Fatalf("Expected document mime type 'application/pdf', got '%s'", got)
+	}
+	if got := part.Get("inlineData.data").String(); got != "JVBERi0=" {
+		t.Fatalf("Expected document data 'JVBERi0=', got '%s'", got)
+	}
+}
+
+func TestConvertClaudeRequestToGemini_BuiltinTools(t *testing.T) {
+	inputJSON := []byte(`{
+		"model": "gemini-3-flash-preview",
+		"messages": [{"role": "user", "content": "What changed in Go 1.26?"}],
+		"tools": [
+			{"type": "web_search_20250305", "name": "web_search", "max_uses": 3},
+			{"name": "lookup", "input_schema": {"type": "object", "properties": {}}}
+		]
+	}`)
+
+	output := ConvertClaudeRequestToGemini("gemini-3-flash-preview", inputJSON, false)
+
+	if got := gjson.GetBytes(output, "tools.0.functionDeclarations.0.name").String(); got != "lookup" {
+		t.Fatalf("Expected the function tool to be kept, got %s", gjson.GetBytes(output, "tools").Raw)
+	}
+	if !gjson.GetBytes(output, "tools.1.googleSearch").Exists() {
+		t.Fatalf("Expected web_search to map to googleSearch, got %s", gjson.GetBytes(output, "tools").Raw)
+	}
+}
@@ -12,8 +12,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
-	"sync/atomic"
-	"time"
 
 	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
@@ -34,9 +32,6 @@ type Params struct {
 	ToolIntentBuffer *util.ToolIntentBuffer
 }
 
-// toolUseIDCounter provides a process-wide unique counter for tool use identifiers.
-var toolUseIDCounter uint64
-
 // ConvertGeminiResponseToClaude performs sophisticated streaming response format conversion.
 // This function implements a complex state machine that translates backend client responses
 // into Claude-compatible Server-Sent Events (SSE) format. It manages different response types
@@ -80,6 +75,8 @@ func ConvertGeminiResponseToClaude(_ context.Context, _ string, originalRequestR
 		return [][]byte{}
 	}
 
+	rawJSON = translatorcommon.GeminiBuiltinToolPartsAsText(rawJSON)
+
 	output := make([]byte, 0, 1024)
 	appendEvent := func(event, payload string) {
 		output = translatorcommon.AppendSSEEventString(output, event, payload, 3)
@@ -179,7 +176,7 @@ func ConvertGeminiResponseToClaude(_ context.Context, _ string, originalRequestR
 						for _, intent := range tagIntents {
 							clientToolName := util.MapToolName(p.ToolNameMap, intent.Name)
 							data := []byte(fmt.Sprintf(`{"type":"content_block_start","index":%d,"content_block":{"type":"tool_use","id":"","name":"","input":{}}}`, p.ResponseIndex))
-							data, _ = sjson.SetBytes(data, "content_block.id", util.SanitizeClaudeToolID(fmt.Sprintf("%s-%d-%d", intent.Name, time.Now().UnixNano(), atomic.AddUint64(&toolUseIDCounter, 1))))
+							data, _ = sjson.SetBytes(data, "content_block.id", util.SanitizeClaudeToolID(translatorcommon.NewToolCallID(intent.Name)))
 							data, _ = sjson.SetBytes(data, "content_block.name", clientToolName)
 							appendEvent("content_block_start", string(data))
 
@@ -241,7 +238,7 @@ func ConvertGeminiResponseToClaude(_ context.Context, _ string, originalRequestR
 				// This creates the structure for a function call in Claude format
 				// Create the tool use block with unique ID and function details
 				data := []byte(fmt.Sprintf(`{"type":"content_block_start","index":%d,"content_block":{"type":"tool_use","id":"","name":"","input":{}}}`, (*param).(*Params).ResponseIndex))
-				data, _ = sjson.SetBytes(data, "content_block.id", util.SanitizeClaudeToolID(fmt.Sprintf("%s-%d", upstreamToolName, atomic.AddUint64(&toolUseIDCounter, 1))))
+				data, _ = sjson.SetBytes(data, "content_block.id", util.SanitizeClaudeToolID(translatorcommon.GeminiToolCallID(functionCallResult, upstreamToolName)))
 				data, _ = sjson.SetBytes(data, "content_block.name", clientToolName)
 				appendEvent("content_block_start", string(data))
 
@@ -265,8 +262,8 @@ func ConvertGeminiResponseToClaude(_ context.Context, _ string, originalRequestR
 				template := []byte(`{"type":"message_delta","delta":{"stop_reason":"end_turn","stop_sequence":null},"usage":{"input_tokens":0,"output_tokens":0}}`)
 				if (*param).(*Params).SawToolCall {
 					template = []byte(`{"type":"message_delta","delta":{"stop_reason":"tool_use","stop_sequence":null},"usage":{"input_tokens":0,"output_tokens":0}}`)
-				} else if finish := gjson.GetBytes(rawJSON, "candidates.0.finishReason"); finish.Exists() && finish.String() == "MAX_TOKENS" {
-					template = []byte(`{"type":"message_delta","delta":{"stop_reason":"max_tokens","stop_sequence":null},"usage":{"input_tokens":0,"output_tokens":0}}`)
+				} else if finish := gjson.GetBytes(rawJSON, "candidates.0.finishReason"); finish.Exists() {
+					template, _ = sjson.SetBytes(template, "delta.stop_reason", translatorcommon.ClaudeStopReasonFromGemini(finish.String()))
 				}
 
 				thoughtsTokenCount := usageResult.Get("thoughtsTokenCount").Int()
@@ -294,6 +291,7 @@ func ConvertGeminiResponseToClaude(_ context.Context, _ string, originalRequestR
 func ConvertGeminiResponseToClaudeNonStream(_ context.Context, _ string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, _ *any) []byte {
 	_ = requestRawJSON
 
+	rawJSON = translatorcommon.GeminiBuiltinToolPartsAsText(rawJSON)
 	root := gjson.ParseBytes(rawJSON)
 	toolNameMap := util.ToolNameMapFromClaudeRequest(originalRequestRawJSON)
 	sanitizedNameMap := util.SanitizedToolNameMap(originalRequestRawJSON)
@@ -310,7 +308,6 @@ func ConvertGeminiResponseToClaudeNonStream(_ context.Context, _ string, origina
 	parts := root.Get("candidates.0.content.parts")
 	textBuilder := strings.Builder{}
 	thinkingBuilder := strings.Builder{}
-	toolIDCounter := 0
 	hasToolCall := false
 
 	flushText := func() {
@@ -327,10 +324,9 @@ func ConvertGeminiResponseToClaudeNonStream(_ context.Context, _ string, origina
 
 		for _, intent := range tagIntents {
 			hasToolCall = true
-			toolIDCounter++
 			clientToolName := util.MapToolName(toolNameMap, intent.Name)
 			toolBlock := []byte(`{"type":"tool_use","id":"","name":"","input":{}}`)
-			toolBlock, _ = sjson.SetBytes(toolBlock, "id", util.SanitizeClaudeToolID(fmt.Sprintf("%s-%d", intent.Name, toolIDCounter)))
+			toolBlock, _ = sjson.SetBytes(toolBlock, "id", util.SanitizeClaudeToolID(translatorcommon.NewToolCallID(intent.Name)))
 			toolBlock, _ = sjson.SetBytes(toolBlock, "name", clientToolName)
 			if len(intent.Arguments) > 0 {
 				if argsJSON, err := json.Marshal(intent.Arguments); err == nil {
@@ -374,9 +370,8 @@ func ConvertGeminiResponseToClaudeNonStream(_ context.Context, _ string, origina
 				upstreamToolName := functionCall.Get("name").String()
 				upstreamToolName = util.RestoreSanitizedToolName(sanitizedNameMap, upstreamToolName)
 				clientToolName := util.MapToolName(toolNameMap, upstreamToolName)
-				toolIDCounter++
 				toolBlock := []byte(`{"type":"tool_use","id":"","name":"","input":{}}`)
-				toolBlock, _ = sjson.SetBytes(toolBlock, "id", util.SanitizeClaudeToolID(fmt.Sprintf("%s-%d", upstreamToolName, toolIDCounter)))
+				toolBlock, _ = sjson.SetBytes(toolBlock, "id", util.SanitizeClaudeToolID(translatorcommon.GeminiToolCallID(functionCall, upstreamToolName)))
 				toolBlock, _ = sjson.SetBytes(toolBlock, "name", clientToolName)
 				inputRaw := "{}"
 				if args := functionCall.Get("args"); args.Exists() && gjson.Valid(args.Raw) && args.IsObject() {
@@ -397,14 +392,7 @@ func ConvertGeminiResponseToClaudeNonStream(_ context.Context, _ string, origina
 		stopReason = "tool_use"
 	} else {
 		if finish := root.Get("candidates.0.finishReason"); finish.Exists() {
-			switch finish.String() {
-			case "MAX_TOKENS":
-				stopReason = "max_tokens"
-			case "STOP", "FINISH_REASON_UNSPECIFIED", "UNKNOWN":
-				stopReason = "end_turn"
-			default:
-				stopReason = "end_turn"
-			}
+			stopReason = translatorcommon.ClaudeStopReasonFromGemini(finish.String())
 		}
 	}
 	out, _ = sjson.SetBytes(out, "stop_reason", stopReason)
@@ -11,9 +11,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
-	"sync/atomic"
 	"time"
 
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	log "github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
@@ -29,9 +29,6 @@ type convertGeminiResponseToOpenAIChatParams struct {
 	SanitizedNameMap  map[string]string
 }
 
-// functionCallIDCounter provides a process-wide unique counter for function call identifiers.
-var functionCallIDCounter uint64
-
 // ConvertGeminiResponseToOpenAI translates a single chunk of a streaming response from the
 // Gemini API format to the OpenAI Chat Completions streaming format.
 // It processes various Gemini event types and transforms them into OpenAI-compatible JSON responses.
@@ -77,6 +74,8 @@ func ConvertGeminiResponseToOpenAI(_ context.Context, _ string, originalRequestR
 		return [][]byte{}
 	}
 
+	rawJSON = translatorcommon.GeminiBuiltinToolPartsAsText(rawJSON)
+
 	// Initialize the OpenAI SSE base template.
 	// We use a base template and clone it for each candidate to support multiple candidates.
 	baseTemplate := []byte(`{"id":"","object":"chat.completion.chunk","created":12345,"model":"model","choices":[{"index":0,"delta":{"role":null,"content":null,"reasoning_content":null,"tool_calls":null},"finish_reason":null,"native_finish_reason":null}]}`)
@@ -146,7 +145,7 @@ func ConvertGeminiResponseToOpenAI(_ context.Context, _ string, originalRequestR
 				finishReason = stopReasonResult.String()
 			}
 			if finishReason == "" {
-				if finishReasonResult := gjson.GetBytes(rawJSON, "candidates.0.finishReason"); finishReasonResult.Exists() {
+				if finishReasonResult := candidate.Get("finishReason"); finishReasonResult.Exists() {
 					finishReason = finishReasonResult.String()
 				}
 			}
@@ -209,7 +208,7 @@ func ConvertGeminiResponseToOpenAI(_ context.Context, _ string, originalRequestR
 
 								for _, intent := range tagIntents {
 									functionCallTemplate := []byte(`{"id":"","index":0,"type":"function","function":{"name":"","arguments":""}}`)
-									functionCallTemplate, _ = sjson.SetBytes(functionCallTemplate, "id", fmt.Sprintf("%s-%d-%d", intent.Name, time.Now().UnixNano(), atomic.AddUint64(&functionCallIDCounter, 1)))
+									functionCallTemplate, _ = sjson.SetBytes(functionCallTemplate, "id", translatorcommon.NewToolCallID(intent.Name))
 									functionCallTemplate, _ = sjson.SetBytes(functionCallTemplate, "index", functionCallIndex)
 									functionCallTemplate, _ = sjson.SetBytes(functionCallTemplate, "function.name", intent.Name)
 									if len(intent.Arguments) > 0 {
@@ -242,7 +241,7 @@ func ConvertGeminiResponseToOpenAI(_ context.Context, _ string, originalRequestR
 
 						functionCallTemplate := []byte(`{"id":"","index":0,"type":"function","function":{"name":"","arguments":""}}`)
 						fcName := util.RestoreSanitizedToolName(p.SanitizedNameMap, functionCallResult.Get("name").String())
-						functionCallTemplate, _ = sjson.SetBytes(functionCallTemplate, "id", fmt.Sprintf("%s-%d-%d", fcName, time.Now().UnixNano(), atomic.AddUint64(&functionCallIDCounter, 1)))
+						functionCallTemplate, _ = sjson.SetBytes(functionCallTemplate, "id", translatorcommon.GeminiToolCallID(functionCallResult, fcName))
 						functionCallTemplate, _ = sjson.SetBytes(functionCallTemplate, "index", functionCallIndex)
 						functionCallTemplate, _ = sjson.SetBytes(functionCallTemplate, "function.name", fcName)
 						if fcArgsResult := functionCallResult.Get("args"); fcArgsResult.Exists() {
@@ -280,12 +279,14 @@ func ConvertGeminiResponseToOpenAI(_ context.Context, _ string, originalRequestR
 			if hasFunctionCall {
 				template, _ = sjson.SetBytes(template, "choices.0.finish_reason", "tool_calls")
 				template, _ = sjson.SetBytes(template, "choices.0.native_finish_reason", "tool_calls")
-			} else if finishReason != "" {
-				// Only pass through specific finish reasons
-				if finishReason == "max_tokens" || finishReason == "stop" {
-					template, _ = sjson.SetBytes(template, "choices.0.finish_reason", finishReason)
-					template, _ = sjson.SetBytes(template, "choices.0.native_finish_reason", finishReason)
-				}
+			} else if finishReason != "" && finishReason != "finish_reason_unspecified" {
+				template, _ = sjson.SetBytes(template, "choices.0.finish_reason", translatorcommon.OpenAIFinishReasonFromGemini(finishReason))
+				template, _ = sjson.SetBytes(template, "choices.0.native_finish_reason", finishReason)
+			}
+
+			// Token log probabilities: logprobsResult -> logprobs.content
+			if tokens := translatorcommon.TokenLogprobsFromGemini(candidate); len(tokens) > 0 {
+				template, _ = sjson.SetRawBytes(template, "choices.0.logprobs", translatorcommon.OpenAILogprobs(tokens))
 			}
 
 			responseStrings = append(responseStrings, template)
@@ -323,7 +324,9 @@ func ConvertGeminiResponseToOpenAI(_ context.Context, _ string, originalRequestR
 // Returns:
 //   - []byte: An OpenAI-compatible JSON response containing all message content and metadata
 func ConvertGeminiResponseToOpenAINonStream(_ context.Context, _ string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, _ *any) []byte {
+	rawJSON = translatorcommon.GeminiBuiltinToolPartsAsText(rawJSON)
 	sanitizedNameMap := util.SanitizedToolNameMap(originalRequestRawJSON)
+	_, structuredOutput := translatorcommon.ResponseFormatFromOpenAI(gjson.ParseBytes(originalRequestRawJSON))
 	var unixTimestamp int64
 	// Initialize template with an empty choices array to support multiple candidates.
 	template := []byte(`{"id":"","object":"chat.completion","created":123456,"model":"model","choices":[]}`)
@@ -382,7 +385,7 @@ func ConvertGeminiResponseToOpenAINonStream(_ context.Context, _ string, origina
 
 			// Set finish reason.
 			if finishReasonResult := candidate.Get("finishReason"); finishReasonResult.Exists() {
-				choiceTemplate, _ = sjson.SetBytes(choiceTemplate, "finish_reason", strings.ToLower(finishReasonResult.String()))
+				choiceTemplate, _ = sjson.SetBytes(choiceTemplate, "finish_reason", translatorcommon.OpenAIFinishReasonFromGemini(finishReasonResult.String()))
 				choiceTemplate, _ = sjson.SetBytes(choiceTemplate, "native_finish_reason", strings.ToLower(finishReasonResult.String()))
 			}
 
@@ -418,7 +421,7 @@ func ConvertGeminiResponseToOpenAINonStream(_ context.Context, _ string, origina
 						}
 						functionCallItemTemplate := []byte(`{"id":"","type":"function","function":{"name":"","arguments":""}}`)
 						fcName := util.RestoreSanitizedToolName(sanitizedNameMap, functionCallResult.Get("name").String())
-						functionCallItemTemplate, _ = sjson.SetBytes(functionCallItemTemplate, "id", fmt.Sprintf("%s-%d-%d", fcName, time.Now().UnixNano(), atomic.AddUint64(&functionCallIDCounter, 1)))
+						functionCallItemTemplate, _ = sjson.SetBytes(functionCallItemTemplate, "id", translatorcommon.GeminiToolCallID(functionCallResult, fcName))
 						functionCallItemTemplate, _ = sjson.SetBytes(functionCallItemTemplate, "function.name", fcName)
 						if fcArgsResult := functionCallResult.Get("args"); fcArgsResult.Exists() {
 							functionCallItemTemplate, _ = sjson.SetBytes(functionCallItemTemplate, "function.arguments", fcArgsResult.Raw)
@@ -469,7 +472,7 @@ func ConvertGeminiResponseToOpenAINonStream(_ context.Context, _ string, origina
 					for _, intent := range tagIntents {
 						hasFunctionCall = true
 						functionCallItemTemplate := []byte(`{"id":"","type":"function","function":{"name":"","arguments":""}}`)
-						functionCallItemTemplate, _ = sjson.SetBytes(functionCallItemTemplate, "id", fmt.Sprintf("%s-%d-%d", intent.Name, time.Now().UnixNano(), atomic.AddUint64(&functionCallIDCounter, 1)))
+						functionCallItemTemplate, _ = sjson.SetBytes(functionCallItemTemplate, "id", translatorcommon.NewToolCallID(intent.Name))
 						functionCallItemTemplate, _ = sjson.SetBytes(functionCallItemTemplate, "function.name", intent.Name)
 						if len(intent.Arguments) > 0 {
 							if argsJSON, err := json.Marshal(intent.Arguments); err == nil {
@@ -485,6 +488,27 @@ func ConvertGeminiResponseToOpenAINonStream(_ context.Context, _ string, origina
 				}
 			}
 
+			// Structured output: hand back valid JSON content as requested by response_format
+			if structuredOutput && !hasFunctionCall {
+				if content := gjson.GetBytes(choiceTemplate, "message.content"); content.Type == gjson.String {
+					repaired, okRepair := translatorcommon.RepairJSON(content.String())
+					if !okRepair {
+						log.Warnf("gemini openai response: structured output is not valid JSON")
+					}
+					choiceTemplate, _ = sjson.SetBytes(choiceTemplate, "message.content", repaired)
+				}
+			}
+
+			// Token log probabilities: logprobsResult -> logprobs.content
+			if tokens := translatorcommon.TokenLogprobsFromGemini(candidate); len(tokens) > 0 {
+				choiceTemplate, _ = sjson.SetRawBytes(choiceTemplate, "logprobs", translatorcommon.OpenAILogprobs(tokens))
+			}
+
+			// Google Search grounding: groundingSupports -> message.annotations
+			if citations := translatorcommon.URLCitationsFromGemini(candidate, gjson.GetBytes(choiceTemplate, "message.content").String()); len(citations) > 0 {
+				choiceTemplate, _ = sjson.SetRawBytes(choiceTemplate, "message.annotations", translatorcommon.OpenAIAnnotations(citations))
+			}
+
 			// Append the constructed choice to the main choices array.
 			template, _ = sjson.SetRawBytes(template, "choices.-1", choiceTemplate)
 			return true
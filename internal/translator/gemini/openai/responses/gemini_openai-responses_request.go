@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"strings"
 
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini/common"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/tidwall/gjson"
@@ -24,11 +25,9 @@ func ConvertOpenAIResponsesRequestToGemini(modelName string, inputRawJSON []byte
 
 	root := gjson.ParseBytes(rawJSON)
 
-	// Extract system instruction from OpenAI "instructions" field
-	if instructions := root.Get("instructions"); instructions.Exists() {
-		systemInstr := []byte(`{"parts":[{"text":""}]}`)
-		systemInstr, _ = sjson.SetBytes(systemInstr, "parts.0.text", instructions.String())
-		out, _ = sjson.SetRawBytes(out, "systemInstruction", systemInstr)
+	// Extract system instruction from the "instructions" field and system/developer input messages
+	if system := translatorcommon.SystemPromptFromResponses(root); len(system) > 0 {
+		out, _ = sjson.SetRawBytes(out, "systemInstruction", system.GeminiInstruction())
 	}
 
 	// Convert input messages to Gemini contents format
@@ -118,31 +117,7 @@ func ConvertOpenAIResponsesRequestToGemini(modelName string, inputRawJSON []byte
 
 			switch itemType {
 			case "message":
-				if strings.EqualFold(itemRole, "system") {
-					if contentArray := item.Get("content"); contentArray.Exists() {
-						systemInstr := []byte(`{"parts":[]}`)
-						if systemInstructionResult := gjson.GetBytes(out, "systemInstruction"); systemInstructionResult.Exists() {
-							systemInstr = []byte(systemInstructionResult.Raw)
-						}
-
-						if contentArray.IsArray() {
-							contentArray.ForEach(func(_, contentItem gjson.Result) bool {
-								part := []byte(`{"text":""}`)
-								text := contentItem.Get("text").String()
-								part, _ = sjson.SetBytes(part, "text", text)
-								systemInstr, _ = sjson.SetRawBytes(systemInstr, "parts.-1", part)
-								return true
-							})
-						} else if contentArray.Type == gjson.String {
-							part := []byte(`{"text":""}`)
-							part, _ = sjson.SetBytes(part, "text", contentArray.String())
-							systemInstr, _ = sjson.SetRawBytes(systemInstr, "parts.-1", part)
-						}
-
-						if gjson.GetBytes(systemInstr, "parts.#").Int() > 0 {
-							out, _ = sjson.SetRawBytes(out, "systemInstruction", systemInstr)
-						}
-					}
+				if translatorcommon.IsSystemRole(itemRole) {
 					continue
 				}
 
@@ -210,59 +185,22 @@ func ConvertOpenAIResponsesRequestToGemini(modelName string, inputRawJSON []byte
 							if imageURL == "" {
 								imageURL = contentItem.Get("url").String()
 							}
-							if imageURL != "" {
-								mimeType := "application/octet-stream"
-								data := ""
-								if strings.HasPrefix(imageURL, "data:") {
-									trimmed := strings.TrimPrefix(imageURL, "data:")
-									mediaAndData := strings.SplitN(trimmed, ";base64,", 2)
-									if len(mediaAndData) == 2 {
-										if mediaAndData[0] != "" {
-											mimeType = mediaAndData[0]
-										}
-										data = mediaAndData[1]
-									} else {
-										mediaAndData = strings.SplitN(trimmed, ",", 2)
-										if len(mediaAndData) == 2 {
-											if mediaAndData[0] != "" {
-												mimeType = mediaAndData[0]
-											}
-											data = mediaAndData[1]
-										}
-									}
-								}
-								if data != "" {
+							if img, ok := translatorcommon.ImageFromURL(imageURL); ok {
+								if img.URL != "" {
+									partJSON = img.GeminiPart()
+								} else {
 									partJSON = []byte(`{"inline_data":{"mime_type":"","data":""}}`)
-									partJSON, _ = sjson.SetBytes(partJSON, "inline_data.mime_type", mimeType)
-									partJSON, _ = sjson.SetBytes(partJSON, "inline_data.data", data)
+									partJSON, _ = sjson.SetBytes(partJSON, "inline_data.mime_type", img.MimeType)
+									partJSON, _ = sjson.SetBytes(partJSON, "inline_data.data", img.Data)
 								}
 							}
+						case "input_file":
+							if doc, ok := translatorcommon.DocumentFromResponsesFile(contentItem); ok {
+								partJSON, _ = doc.GeminiPart()
+							}
 						case "input_audio":
-							audioData := contentItem.Get("data").String()
-							audioFormat := contentItem.Get("format").String()
-							if audioData != "" {
-								audioMimeMap := map[string]string{
-									"mp3":       "audio/mpeg",
-									"wav":       "audio/wav",
-									"ogg":       "audio/ogg",
-									"flac":      "audio/flac",
-									"aac":       "audio/aac",
-									"webm":      "audio/webm",
-									"pcm16":     "audio/pcm",
-									"g711_ulaw": "audio/basic",
-									"g711_alaw": "audio/basic",
-								}
-								mimeType := "audio/wav"
-								if audioFormat != "" {
-									if mapped, ok := audioMimeMap[audioFormat]; ok {
-										mimeType = mapped
-									} else {
-										mimeType = "audio/" + audioFormat
-									}
-								}
-								partJSON = []byte(`{"inline_data":{"mime_type":"","data":""}}`)
-								partJSON, _ = sjson.SetBytes(partJSON, "inline_data.mime_type", mimeType)
-								partJSON, _ = sjson.SetBytes(partJSON, "inline_data.data", audioData)
+							if audio, ok := translatorcommon.AudioFromResponsesInput(contentItem); ok {
+								partJSON, _ = audio.GeminiPart()
 							}
 						}
 
@@ -397,6 +335,14 @@ func ConvertOpenAIResponsesRequestToGemini(modelName string, inputRawJSON []byte
 		}
 	}
 
+	// Map hosted web_search and code_interpreter tools to Gemini built-in tools
+	out = translatorcommon.ApplyBuiltinToolsToGemini(out, "tools", translatorcommon.BuiltinToolsFromResponses(root))
+
+	// Convert tool_choice to Gemini toolConfig.functionCallingConfig
+	if toolChoice, ok := translatorcommon.ToolChoiceFromOpenAI(root.Get("tool_choice")); ok {
+		out = toolChoice.ApplyToGemini(out, "")
+	}
+
 	// Handle generation config from OpenAI format
 	if maxOutputTokens := root.Get("max_output_tokens"); maxOutputTokens.Exists() {
 		genConfig := []byte(`{"maxOutputTokens":0}`)
@@ -433,6 +379,11 @@ func ConvertOpenAIResponsesRequestToGemini(modelName string, inputRawJSON []byte
 		out, _ = sjson.SetBytes(out, "generationConfig.stopSequences", sequences)
 	}
 
+	// Handle top_logprobs and the output text logprobs include
+	if logprobs, ok := translatorcommon.LogprobsFromResponses(root); ok {
+		out = logprobs.ApplyToGemini(out, "generationConfig")
+	}
+
 	// Apply thinking configuration: convert OpenAI Responses API reasoning.effort to Gemini thinkingConfig.
 	// Inline translation-only mapping; capability checks happen later in ApplyThinking.
 	re := root.Get("reasoning.effort")
@@ -27,6 +27,8 @@ type geminiToResponsesState struct {
 	CurrentMsgID string
 	TextBuf      strings.Builder
 	ItemTextBuf  strings.Builder
+	Logprobs     []translatorcommon.TokenLogprob
+	Grounding    string
 
 	// reasoning aggregation
 	ReasoningOpened bool
@@ -48,9 +50,6 @@ type geminiToResponsesState struct {
 // responseIDCounter provides a process-wide unique counter for synthesized response identifiers.
 var responseIDCounter uint64
 
-// funcCallIDCounter provides a process-wide unique counter for function call identifiers.
-var funcCallIDCounter uint64
-
 func pickRequestJSON(originalRequestRawJSON, requestRawJSON []byte) []byte {
 	if len(originalRequestRawJSON) > 0 && gjson.ValidBytes(originalRequestRawJSON) {
 		return originalRequestRawJSON
@@ -124,6 +123,7 @@ func ConvertGeminiResponseToOpenAIResponses(_ context.Context, modelName string,
 	if len(rawJSON) == 0 || bytes.Equal(rawJSON, []byte("[DONE]")) {
 		return [][]byte{}
 	}
+	rawJSON = translatorcommon.GeminiBuiltinToolPartsAsText(rawJSON)
 
 	root := gjson.ParseBytes(rawJSON)
 	if !root.Exists() {
@@ -180,18 +180,33 @@ func ConvertGeminiResponseToOpenAIResponses(_ context.Context, modelName string,
 		done, _ = sjson.SetBytes(done, "item_id", st.CurrentMsgID)
 		done, _ = sjson.SetBytes(done, "output_index", st.MsgIndex)
 		done, _ = sjson.SetBytes(done, "text", fullText)
+		if len(st.Logprobs) > 0 {
+			done, _ = sjson.SetRawBytes(done, "logprobs", translatorcommon.ResponsesLogprobs(st.Logprobs))
+		}
 		out = append(out, emitEvent("response.output_text.done", done))
 		partDone := []byte(`{"type":"response.content_part.done","sequence_number":0,"item_id":"","output_index":0,"content_index":0,"part":{"type":"output_text","annotations":[],"logprobs":[],"text":""}}`)
 		partDone, _ = sjson.SetBytes(partDone, "sequence_number", nextSeq())
 		partDone, _ = sjson.SetBytes(partDone, "item_id", st.CurrentMsgID)
 		partDone, _ = sjson.SetBytes(partDone, "output_index", st.MsgIndex)
 		partDone, _ = sjson.SetBytes(partDone, "part.text", fullText)
+		if len(st.Logprobs) > 0 {
+			partDone, _ = sjson.SetRawBytes(partDone, "part.logprobs", translatorcommon.ResponsesLogprobs(st.Logprobs))
+		}
+		if citations := translatorcommon.URLCitationsFromGemini(gjson.Parse(st.Grounding), fullText); len(citations) > 0 {
+			partDone, _ = sjson.SetRawBytes(partDone, "part.annotations", translatorcommon.ResponsesAnnotations(citations))
+		}
 		out = append(out, emitEvent("response.content_part.done", partDone))
 		final := []byte(`{"type":"response.output_item.done","sequence_number":0,"output_index":0,"item":{"id":"","type":"message","status":"completed","content":[{"type":"output_text","text":""}],"role":"assistant"}}`)
 		final, _ = sjson.SetBytes(final, "sequence_number", nextSeq())
 		final, _ = sjson.SetBytes(final, "output_index", st.MsgIndex)
 		final, _ = sjson.SetBytes(final, "item.id", st.CurrentMsgID)
 		final, _ = sjson.SetBytes(final, "item.content.0.text", fullText)
+		if len(st.Logprobs) > 0 {
+			final, _ = sjson.SetRawBytes(final, "item.content.0.logprobs", translatorcommon.ResponsesLogprobs(st.Logprobs))
+		}
+		if citations := translatorcommon.URLCitationsFromGemini(gjson.Parse(st.Grounding), fullText); len(citations) > 0 {
+			final, _ = sjson.SetRawBytes(final, "item.content.0.annotations", translatorcommon.ResponsesAnnotations(citations))
+		}
 		out = append(out, emitEvent("response.output_item.done", final))
 
 		st.MsgClosed = true
@@ -231,6 +246,14 @@ func ConvertGeminiResponseToOpenAIResponses(_ context.Context, modelName string,
 		st.NextIndex = 0
 	}
 
+	// Token log probabilities of this chunk travel with its first text delta.
+	chunkLogprobs := translatorcommon.TokenLogprobsFromGemini(root.Get("candidates.0"))
+
+	// Grounding supports arrive with the last chunk and are resolved against the full text.
+	if root.Get("candidates.0.groundingMetadata").Exists() {
+		st.Grounding = root.Get("candidates.0").Raw
+	}
+
 	// Handle parts (text/thought/functionCall)
 	if parts := root.Get("candidates.0.content.parts"); parts.Exists() && parts.IsArray() {
 		parts.ForEach(func(_, part gjson.Result) bool {
@@ -302,6 +325,11 @@ func ConvertGeminiResponseToOpenAIResponses(_ context.Context, modelName string,
 				msg, _ = sjson.SetBytes(msg, "item_id", st.CurrentMsgID)
 				msg, _ = sjson.SetBytes(msg, "output_index", st.MsgIndex)
 				msg, _ = sjson.SetBytes(msg, "delta", t.String())
+				if len(chunkLogprobs) > 0 {
+					msg, _ = sjson.SetRawBytes(msg, "logprobs", translatorcommon.ResponsesLogprobs(chunkLogprobs))
+					st.Logprobs = append(st.Logprobs, chunkLogprobs...)
+					chunkLogprobs = nil
+				}
 				out = append(out, emitEvent("response.output_text.delta", msg))
 				return true
 			}
@@ -320,7 +348,7 @@ func ConvertGeminiResponseToOpenAIResponses(_ context.Context, modelName string,
 					st.FuncArgsBuf[idx] = &strings.Builder{}
 				}
 				if st.FuncCallIDs[idx] == "" {
-					st.FuncCallIDs[idx] = fmt.Sprintf("call_%d_%d", time.Now().UnixNano(), atomic.AddUint64(&funcCallIDCounter, 1))
+					st.FuncCallIDs[idx] = translatorcommon.GeminiToolCallID(fc, name)
 				}
 				st.FuncNames[idx] = name
 
@@ -515,6 +543,12 @@ func ConvertGeminiResponseToOpenAIResponses(_ context.Context, modelName string,
 				item := []byte(`{"id":"","type":"message","status":"completed","content":[{"type":"output_text","annotations":[],"logprobs":[],"text":""}],"role":"assistant"}`)
 				item, _ = sjson.SetBytes(item, "id", st.CurrentMsgID)
 				item, _ = sjson.SetBytes(item, "content.0.text", st.TextBuf.String())
+				if len(st.Logprobs) > 0 {
+					item, _ = sjson.SetRawBytes(item, "content.0.logprobs", translatorcommon.ResponsesLogprobs(st.Logprobs))
+				}
+				if citations := translatorcommon.URLCitationsFromGemini(gjson.Parse(st.Grounding), st.TextBuf.String()); len(citations) > 0 {
+					item, _ = sjson.SetRawBytes(item, "content.0.annotations", translatorcommon.ResponsesAnnotations(citations))
+				}
 				outputsWrapper, _ = sjson.SetRawBytes(outputsWrapper, "arr.-1", item)
 				continue
 			}
@@ -561,7 +595,8 @@ func ConvertGeminiResponseToOpenAIResponses(_ context.Context, modelName string,
 			}
 		}
 
-		out = append(out, emitEvent("response.completed", completed))
+		completed, event := translatorcommon.ResponsesTerminalEvent(completed, translatorcommon.OpenAIFinishReasonFromGemini(fr.String()))
+		out = append(out, emitEvent(event, completed))
 	}
 
 	return out
@@ -569,12 +604,16 @@ func ConvertGeminiResponseToOpenAIResponses(_ context.Context, modelName string,
 
 // ConvertGeminiResponseToOpenAIResponsesNonStream aggregates Gemini response JSON into a single OpenAI Responses JSON object.
 func ConvertGeminiResponseToOpenAIResponsesNonStream(_ context.Context, _ string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, _ *any) []byte {
+	rawJSON = translatorcommon.GeminiBuiltinToolPartsAsText(rawJSON)
 	root := gjson.ParseBytes(rawJSON)
 	root = unwrapGeminiResponseRoot(root)
 	sanitizedNameMap := util.SanitizedToolNameMap(originalRequestRawJSON)
 
 	// Base response scaffold
 	resp := []byte(`{"id":"","object":"response","created_at":0,"status":"completed","background":false,"error":null,"incomplete_details":null}`)
+	if fr := root.Get("candidates.0.finishReason"); fr.Exists() {
+		resp = translatorcommon.MarkResponsesIncomplete(resp, translatorcommon.OpenAIFinishReasonFromGemini(fr.String()))
+	}
 
 	// id: prefer provider responseId, otherwise synthesize
 	id := root.Get("responseId").String()
@@ -703,7 +742,7 @@ func ConvertGeminiResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 			if fc := p.Get("functionCall"); fc.Exists() {
 				name := util.RestoreSanitizedToolName(sanitizedNameMap, fc.Get("name").String())
 				args := fc.Get("args")
-				callID := fmt.Sprintf("call_%x_%d", time.Now().UnixNano(), atomic.AddUint64(&funcCallIDCounter, 1))
+				callID := translatorcommon.GeminiToolCallID(fc, name)
 				itemJSON := []byte(`{"id":"","type":"function_call","status":"completed","arguments":"","call_id":"","name":""}`)
 				itemJSON, _ = sjson.SetBytes(itemJSON, "id", fmt.Sprintf("fc_%s", callID))
 				itemJSON, _ = sjson.SetBytes(itemJSON, "call_id", callID)
@@ -740,6 +779,12 @@ func ConvertGeminiResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 		itemJSON := []byte(`{"id":"","type":"message","status":"completed","content":[{"type":"output_text","annotations":[],"logprobs":[],"text":""}],"role":"assistant"}`)
 		itemJSON, _ = sjson.SetBytes(itemJSON, "id", fmt.Sprintf("msg_%s_0", strings.TrimPrefix(id, "resp_")))
 		itemJSON, _ = sjson.SetBytes(itemJSON, "content.0.text", messageText.String())
+		if tokens := translatorcommon.TokenLogprobsFromGemini(root.Get("candidates.0")); len(tokens) > 0 {
+			itemJSON, _ = sjson.SetRawBytes(itemJSON, "content.0.logprobs", translatorcommon.ResponsesLogprobs(tokens))
+		}
+		if citations := translatorcommon.URLCitationsFromGemini(root.Get("candidates.0"), messageText.String()); len(citations) > 0 {
+			itemJSON, _ = sjson.SetRawBytes(itemJSON, "content.0.annotations", translatorcommon.ResponsesAnnotations(citations))
+		}
 		appendOutput(itemJSON)
 	}
 
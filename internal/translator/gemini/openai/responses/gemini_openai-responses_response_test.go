@@ -351,3 +351,64 @@ func TestConvertGeminiResponseToOpenAIResponses_ResponseOutputOrdering(t *testin
 		t.Fatalf("expected response.completed after message added: msgAdded=%d completed=%d", posMsgAdded, posCompleted)
 	}
 }
+
+func TestConvertGeminiResponseToOpenAIResponses_Logprobs(t *testing.T) {
+	in := []string{
+		`data: {"candidates":[{"content":{"role":"model","parts":[{"text":"Hi"}]},"logprobsResult":{"topCandidates":[{"candidates":[{"token":"Hi","logProbability":-0.1},{"token":"Hey","logProbability":-2.3}]}],"chosenCandidates":[{"token":"Hi","logProbability":-0.1}]}}],"modelVersion":"test-model","responseId":"req_lp"}`,
+		`data: {"candidates":[{"content":{"role":"model","parts":[{"text":"!"}]},"finishReason":"STOP","logprobsResult":{"topCandidates":[{"candidates":[{"token":"!","logProbability":-0.4}]}],"chosenCandidates":[{"token":"!","logProbability":-0.4}]}}],"usageMetadata":{"promptTokenCount":2,"candidatesTokenCount":2,"totalTokenCount":4},"modelVersion":"test-model","responseId":"req_lp"}`,
+	}
+
+	var param any
+	var deltas, done, completed gjson.Result
+	for _, line := range in {
+		for _, chunk := range ConvertGeminiResponseToOpenAIResponses(context.Background(), "test-model", nil, nil, []byte(line), &param) {
+			ev, data := parseSSEEvent(t, chunk)
+			switch ev {
+			case "response.output_text.delta":
+				if !deltas.Exists() {
+					deltas = data
+				}
+			case "response.output_text.done":
+				done = data
+			case "response.completed":
+				completed = data
+			}
+		}
+	}
+
+	if got := deltas.Get("logprobs.0.top_logprobs.1.token").String(); got != "Hey" {
+		t.Fatalf("first delta logprobs = %s", deltas.Get("logprobs").Raw)
+	}
+	if got := done.Get("logprobs.#").Int(); got != 2 {
+		t.Fatalf("output_text.done logprobs = %s", done.Get("logprobs").Raw)
+	}
+	if got := completed.Get("response.output.0.content.0.logprobs.1.logprob").Float(); got != -0.4 {
+		t.Fatalf("completed logprobs = %s", completed.Get("response.output.0.content.0.logprobs").Raw)
+	}
+}
+
+func TestConvertGeminiResponseToOpenAIResponses_GroundingAnnotations(t *testing.T) {
+	in := []string{
+		`data: {"candidates":[{"content":{"role":"model","parts":[{"text":"Go 1.26 "}]}}],"modelVersion":"test-model","responseId":"req_gs"}`,
+		`data: {"candidates":[{"content":{"role":"model","parts":[{"text":"added errors.AsType."}]},"finishReason":"STOP","groundingMetadata":{"groundingChunks":[{"web":{"uri":"https://go.dev/doc/go1.26","title":"go.dev"}}],"groundingSupports":[{"segment":{"text":"added errors.AsType."},"groundingChunkIndices":[0]}]}}],"usageMetadata":{"promptTokenCount":2,"candidatesTokenCount":4,"totalTokenCount":6},"modelVersion":"test-model","responseId":"req_gs"}`,
+	}
+
+	var param any
+	var completed gjson.Result
+	for _, line := range in {
+		for _, chunk := range ConvertGeminiResponseToOpenAIResponses(context.Background(), "test-model", nil, nil, []byte(line), &param) {
+			if ev, data := parseSSEEvent(t, chunk); ev == "response.completed" {
+				completed = data
+			}
+		}
+	}
+
+	content := completed.Get("response.output.0.content.0")
+	if !strings.Contains(content.Get("text").String(), "Sources:\n1. [go.dev](https://go.dev/doc/go1.26)") {
+		t.Fatalf("expected the grounding sources in the text, got %q", content.Get("text").String())
+	}
+	annotation := content.Get("annotations.0")
+	if annotation.Get("url").String() != "https://go.dev/doc/go1.26" || annotation.Get("start_index").Int() != 8 || annotation.Get("end_index").Int() != 28 {
+		t.Fatalf("annotations = %s", content.Get("annotations").Raw)
+	}
+}
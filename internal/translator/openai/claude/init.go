@@ -15,6 +15,7 @@ func init() {
 			Stream:     ConvertOpenAIResponseToClaude,
 			NonStream:  ConvertOpenAIResponseToClaudeNonStream,
 			TokenCount: ClaudeTokenCount,
+			Finalize:   FinalizeOpenAIResponseToClaude,
 		},
 	)
 }
@@ -6,9 +6,11 @@
 package claude
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -99,31 +101,8 @@ func ConvertClaudeRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 	messagesJSON := []byte(`[]`)
 
 	// Handle system message first
-	systemMsgJSON := []byte(`{"role":"system","content":[]}`)
-	hasSystemContent := false
-	if system := root.Get("system"); system.Exists() {
-		if system.Type == gjson.String {
-			if system.String() != "" {
-				oldSystem := []byte(`{"type":"text","text":""}`)
-				oldSystem, _ = sjson.SetBytes(oldSystem, "text", system.String())
-				systemMsgJSON, _ = sjson.SetRawBytes(systemMsgJSON, "content.-1", oldSystem)
-				hasSystemContent = true
-			}
-		} else if system.Type == gjson.JSON {
-			if system.IsArray() {
-				systemResults := system.Array()
-				for i := 0; i < len(systemResults); i++ {
-					if contentItem, ok := convertClaudeContentPart(systemResults[i]); ok {
-						systemMsgJSON, _ = sjson.SetRawBytes(systemMsgJSON, "content.-1", []byte(contentItem))
-						hasSystemContent = true
-					}
-				}
-			}
-		}
-	}
-	// Only add system message if it has content
-	if hasSystemContent {
-		messagesJSON, _ = sjson.SetRawBytes(messagesJSON, "-1", systemMsgJSON)
+	if system := translatorcommon.SystemPromptFromClaude(root.Get("system")); len(system) > 0 {
+		messagesJSON, _ = sjson.SetRawBytes(messagesJSON, "-1", system.OpenAIMessage())
 	}
 
 	// Process Anthropic messages
@@ -157,7 +136,7 @@ func ConvertClaudeRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 					case "redacted_thinking":
 						// Explicitly ignore redacted_thinking - never map to reasoning_content (AC2)
 
-					case "text", "image":
+					case "text", "image", "document":
 						if contentItem, ok := convertClaudeContentPart(part); ok {
 							contentItems = append(contentItems, []byte(contentItem))
 						}
@@ -189,6 +168,7 @@ func ConvertClaudeRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 						} else {
 							toolResultJSON, _ = sjson.SetBytes(toolResultJSON, "content", toolResultContent)
 						}
+						toolResultJSON = withToolResultCacheControl(toolResultJSON, part)
 						toolResults = append(toolResults, toolResultJSON)
 					}
 					return true
@@ -302,22 +282,15 @@ func ConvertClaudeRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 	}
 
 	// Tool choice mapping - convert Anthropic tool_choice to OpenAI format
-	if toolChoice := root.Get("tool_choice"); toolChoice.Exists() {
-		switch toolChoice.Get("type").String() {
-		case "auto":
-			out, _ = sjson.SetBytes(out, "tool_choice", "auto")
-		case "any":
-			out, _ = sjson.SetBytes(out, "tool_choice", "required")
-		case "tool":
-			// Specific tool choice
-			toolName := toolChoice.Get("name").String()
-			toolChoiceJSON := []byte(`{"type":"function","function":{"name":""}}`)
-			toolChoiceJSON, _ = sjson.SetBytes(toolChoiceJSON, "function.name", toolName)
-			out, _ = sjson.SetRawBytes(out, "tool_choice", toolChoiceJSON)
-		default:
-			// Default to auto if not specified
-			out, _ = sjson.SetBytes(out, "tool_choice", "auto")
-		}
+	if toolChoice, ok := translatorcommon.ToolChoiceFromClaude(root.Get("tool_choice")); ok {
+		out = toolChoice.ApplyToOpenAI(out)
+	} else if root.Get("tool_choice").Exists() {
+		out, _ = sjson.SetBytes(out, "tool_choice", "auto")
+	}
+
+	// Claude carries the parallel tool call switch on tool_choice
+	if allowed, ok := translatorcommon.ParallelToolCallsFromClaude(root); ok && gjson.GetBytes(out, "tools.0").Exists() {
+		out, _ = sjson.SetBytes(out, "parallel_tool_calls", allowed)
 	}
 
 	// Handle user parameter (for tracking)
@@ -339,7 +312,7 @@ func convertClaudeContentPart(part gjson.Result) (string, bool) {
 		}
 		textContent := []byte(`{"type":"text","text":""}`)
 		textContent, _ = sjson.SetBytes(textContent, "text", text)
-		return string(textContent), true
+		return string(translatorcommon.WithCacheControl(textContent, part)), true
 
 	case "image":
 		var imageURL string
@@ -372,13 +345,44 @@ func convertClaudeContentPart(part gjson.Result) (string, bool) {
 		imageContent := []byte(`{"type":"image_url","image_url":{"url":""}}`)
 		imageContent, _ = sjson.SetBytes(imageContent, "image_url.url", imageURL)
 
-		return string(imageContent), true
+		return string(translatorcommon.WithCacheControl(imageContent, part)), true
+
+	case "document":
+		doc, ok := translatorcommon.DocumentFromClaudeBlock(part)
+		if !ok {
+			return "", false
+		}
+		fileContent, errRender := doc.OpenAIPart()
+		if errRender != nil {
+			return "", false
+		}
+		return string(translatorcommon.WithCacheControl(fileContent, part)), true
 
 	default:
 		return "", false
 	}
 }
 
+// withToolResultCacheControl moves the cache marker of a Claude tool_result onto the last
+// content part of the tool message, turning plain text content into a text part first.
+func withToolResultCacheControl(toolResultJSON []byte, part gjson.Result) []byte {
+	if !part.Get("cache_control").IsObject() {
+		return toolResultJSON
+	}
+	content := gjson.GetBytes(toolResultJSON, "content")
+	if content.Type == gjson.String {
+		textContent := []byte(`{"type":"text","text":""}`)
+		textContent, _ = sjson.SetBytes(textContent, "text", content.String())
+		toolResultJSON, _ = sjson.SetRawBytes(toolResultJSON, "content", []byte(`[`+string(textContent)+`]`))
+	}
+	last := len(gjson.GetBytes(toolResultJSON, "content").Array()) - 1
+	if last < 0 {
+		return toolResultJSON
+	}
+	toolResultJSON, _ = sjson.SetRawBytes(toolResultJSON, fmt.Sprintf("content.%d.cache_control", last), []byte(part.Get("cache_control").Raw))
+	return toolResultJSON
+}
+
 func convertClaudeToolResultContent(content gjson.Result) (string, bool) {
 	if !content.Exists() {
 		return "", false
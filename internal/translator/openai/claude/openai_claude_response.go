@@ -45,6 +45,10 @@ type ConvertOpenAIResponseToAnthropicParams struct {
 	MessageStarted bool
 	// Track if message_stop has been sent
 	MessageStopSent bool
+	// Track if tool call arguments had to be repaired because the stream was cut off
+	TruncatedToolCall bool
+	// Track if the upstream reported an error mid-stream; later chunks are dropped
+	Errored bool
 	// Tool call content block index mapping
 	ToolCallBlockIndexes map[int]int
 	// Index assigned to text content block
@@ -101,6 +105,10 @@ func ConvertOpenAIResponseToClaude(_ context.Context, _ string, originalRequestR
 	}
 	rawJSON = bytes.TrimSpace(rawJSON[5:])
 
+	if (*param).(*ConvertOpenAIResponseToAnthropicParams).Errored {
+		return [][]byte{}
+	}
+
 	if (*param).(*ConvertOpenAIResponseToAnthropicParams).ToolNameMap == nil {
 		(*param).(*ConvertOpenAIResponseToAnthropicParams).ToolNameMap = util.ToolNameMapFromClaudeRequest(originalRequestRawJSON)
 	}
@@ -118,10 +126,27 @@ func ConvertOpenAIResponseToClaude(_ context.Context, _ string, originalRequestR
 	}
 }
 
+// FinalizeOpenAIResponseToClaude closes a Claude stream whose OpenAI upstream ended without
+// a [DONE] marker or finish_reason, emitting any pending content_block_stop, message_delta
+// and message_stop events. It returns nothing when the stream was already terminated.
+func FinalizeOpenAIResponseToClaude(_ context.Context, _ string, _, _ []byte, param *any) [][]byte {
+	if param == nil || *param == nil {
+		return nil
+	}
+	p, ok := (*param).(*ConvertOpenAIResponseToAnthropicParams)
+	if !ok || !p.MessageStarted || p.MessageStopSent {
+		return nil
+	}
+	return convertOpenAIDoneToAnthropic(p)
+}
+
 func effectiveOpenAIFinishReason(param *ConvertOpenAIResponseToAnthropicParams) string {
 	if param == nil {
 		return ""
 	}
+	if param.TruncatedToolCall || param.FinishReason == "length" {
+		return "length"
+	}
 	if param.SawToolCall {
 		return "tool_calls"
 	}
@@ -133,6 +158,11 @@ func convertOpenAIStreamingChunkToAnthropic(rawJSON []byte, param *ConvertOpenAI
 	root := gjson.ParseBytes(rawJSON)
 	var results [][]byte
 
+	// Some OpenAI-compatible upstreams report failures mid-stream as a data frame with an error object.
+	if errNode := root.Get("error"); errNode.Exists() && errNode.Type != gjson.Null {
+		return convertOpenAIStreamErrorToAnthropic(errNode, param)
+	}
+
 	// Initialize parameters if needed
 	if param.MessageID == "" {
 		param.MessageID = root.Get("id").String()
@@ -153,14 +183,15 @@ func convertOpenAIStreamingChunkToAnthropic(rawJSON []byte, param *ConvertOpenAI
 			messageStartJSON, _ = sjson.SetBytes(messageStartJSON, "message.id", param.MessageID)
 			messageStartJSON, _ = sjson.SetBytes(messageStartJSON, "message.model", param.Model)
 			results = append(results, translatorcommon.AppendSSEEventBytes(nil, "message_start", messageStartJSON, 2))
+			results = append(results, translatorcommon.AppendSSEEventBytes(nil, "ping", []byte(`{"type":"ping"}`), 2))
 			param.MessageStarted = true
 
 			// Don't send content_block_start for text here - wait for actual content
 		}
 
 		// Handle reasoning content delta
-		if reasoning := delta.Get("reasoning_content"); reasoning.Exists() {
-			for _, reasoningText := range collectOpenAIReasoningTexts(reasoning) {
+		if reasoningTexts := translatorcommon.OpenAIReasoningTexts(delta); len(reasoningTexts) > 0 {
+			for _, reasoningText := range reasoningTexts {
 				if reasoningText == "" {
 					continue
 				}
@@ -269,7 +300,7 @@ func convertOpenAIStreamingChunkToAnthropic(rawJSON []byte, param *ConvertOpenAI
 	// Handle finish_reason (but don't send message_delta/message_stop yet)
 	if finishReason := root.Get("choices.0.finish_reason"); finishReason.Exists() && finishReason.String() != "" {
 		reason := finishReason.String()
-		if param.SawToolCall {
+		if param.SawToolCall && reason != "length" {
 			param.FinishReason = "tool_calls"
 		} else {
 			param.FinishReason = reason
@@ -297,7 +328,7 @@ func convertOpenAIStreamingChunkToAnthropic(rawJSON []byte, param *ConvertOpenAI
 				if accumulator.Arguments.Len() > 0 {
 					inputDeltaJSON := []byte(`{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":""}}`)
 					inputDeltaJSON, _ = sjson.SetBytes(inputDeltaJSON, "index", blockIndex)
-					inputDeltaJSON, _ = sjson.SetBytes(inputDeltaJSON, "delta.partial_json", util.FixJSON(accumulator.Arguments.String()))
+					inputDeltaJSON, _ = sjson.SetBytes(inputDeltaJSON, "delta.partial_json", param.closeToolArguments(accumulator))
 					results = append(results, translatorcommon.AppendSSEEventBytes(nil, "content_block_delta", inputDeltaJSON, 2))
 				}
 
@@ -321,7 +352,7 @@ func convertOpenAIStreamingChunkToAnthropic(rawJSON []byte, param *ConvertOpenAI
 			inputTokens, outputTokens, cachedTokens = extractOpenAIUsage(usage)
 			// Send message_delta with usage
 			messageDeltaJSON := []byte(`{"type":"message_delta","delta":{"stop_reason":"","stop_sequence":null},"usage":{"input_tokens":0,"output_tokens":0}}`)
-			messageDeltaJSON, _ = sjson.SetBytes(messageDeltaJSON, "delta.stop_reason", mapOpenAIFinishReasonToAnthropic(effectiveOpenAIFinishReason(param)))
+			messageDeltaJSON, _ = sjson.SetBytes(messageDeltaJSON, "delta.stop_reason", translatorcommon.ClaudeStopReasonFromOpenAI(effectiveOpenAIFinishReason(param)))
 			messageDeltaJSON, _ = sjson.SetBytes(messageDeltaJSON, "usage.input_tokens", inputTokens)
 			messageDeltaJSON, _ = sjson.SetBytes(messageDeltaJSON, "usage.output_tokens", outputTokens)
 			if cachedTokens > 0 {
@@ -337,6 +368,56 @@ func convertOpenAIStreamingChunkToAnthropic(rawJSON []byte, param *ConvertOpenAI
 	return results
 }
 
+// convertOpenAIStreamErrorToAnthropic turns a mid-stream upstream error into a Claude error
+// event and ends the message so clients fail fast instead of waiting for more content.
+func convertOpenAIStreamErrorToAnthropic(errNode gjson.Result, param *ConvertOpenAIResponseToAnthropicParams) [][]byte {
+	message := errNode.Get("message").String()
+	if errNode.Type == gjson.String {
+		message = errNode.String()
+	}
+	if message == "" {
+		message = errNode.Raw
+	}
+	errorJSON := []byte(`{"type":"error","error":{"type":"","message":""}}`)
+	errorJSON, _ = sjson.SetBytes(errorJSON, "error.type", mapOpenAIErrorTypeToAnthropic(errNode))
+	errorJSON, _ = sjson.SetBytes(errorJSON, "error.message", message)
+	results := [][]byte{translatorcommon.AppendSSEEventBytes(nil, "error", errorJSON, 2)}
+
+	if param.MessageStarted {
+		emitMessageStopIfNeeded(param, &results)
+	}
+	param.Errored = true
+	param.MessageStopSent = true
+	return results
+}
+
+// mapOpenAIErrorTypeToAnthropic picks the Claude error type for an OpenAI-style error object,
+// keeping types Claude already understands and otherwise deriving one from the error code.
+func mapOpenAIErrorTypeToAnthropic(errNode gjson.Result) string {
+	switch errorType := errNode.Get("type").String(); errorType {
+	case "invalid_request_error", "authentication_error", "permission_error", "not_found_error",
+		"request_too_large", "rate_limit_error", "api_error", "overloaded_error":
+		return errorType
+	}
+	switch code := errNode.Get("code").String(); code {
+	case "400":
+		return "invalid_request_error"
+	case "401":
+		return "authentication_error"
+	case "403":
+		return "permission_error"
+	case "404":
+		return "not_found_error"
+	case "413":
+		return "request_too_large"
+	case "429", "rate_limit_exceeded", "insufficient_quota":
+		return "rate_limit_error"
+	case "503", "529":
+		return "overloaded_error"
+	}
+	return "api_error"
+}
+
 // convertOpenAIDoneToAnthropic handles the [DONE] marker and sends final events
 func convertOpenAIDoneToAnthropic(param *ConvertOpenAIResponseToAnthropicParams) [][]byte {
 	var results [][]byte
@@ -360,7 +441,7 @@ func convertOpenAIDoneToAnthropic(param *ConvertOpenAIResponseToAnthropicParams)
 			if accumulator.Arguments.Len() > 0 {
 				inputDeltaJSON := []byte(`{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":""}}`)
 				inputDeltaJSON, _ = sjson.SetBytes(inputDeltaJSON, "index", blockIndex)
-				inputDeltaJSON, _ = sjson.SetBytes(inputDeltaJSON, "delta.partial_json", util.FixJSON(accumulator.Arguments.String()))
+				inputDeltaJSON, _ = sjson.SetBytes(inputDeltaJSON, "delta.partial_json", param.closeToolArguments(accumulator))
 				results = append(results, translatorcommon.AppendSSEEventBytes(nil, "content_block_delta", inputDeltaJSON, 2))
 			}
 
@@ -372,10 +453,11 @@ func convertOpenAIDoneToAnthropic(param *ConvertOpenAIResponseToAnthropicParams)
 		param.ContentBlocksStopped = true
 	}
 
-	// If we haven't sent message_delta yet (no usage info was received), send it now
-	if param.FinishReason != "" && !param.MessageDeltaSent {
+	// If we haven't sent message_delta yet (no usage info was received, or the upstream
+	// closed without a finish_reason), send it now so the message is always closed properly.
+	if (param.FinishReason != "" || param.MessageStarted) && !param.MessageDeltaSent {
 		messageDeltaJSON := []byte(`{"type":"message_delta","delta":{"stop_reason":"","stop_sequence":null},"usage":{"input_tokens":0,"output_tokens":0}}`)
-		messageDeltaJSON, _ = sjson.SetBytes(messageDeltaJSON, "delta.stop_reason", mapOpenAIFinishReasonToAnthropic(effectiveOpenAIFinishReason(param)))
+		messageDeltaJSON, _ = sjson.SetBytes(messageDeltaJSON, "delta.stop_reason", translatorcommon.ClaudeStopReasonFromOpenAI(effectiveOpenAIFinishReason(param)))
 		results = append(results, translatorcommon.AppendSSEEventBytes(nil, "message_delta", messageDeltaJSON, 2))
 		param.MessageDeltaSent = true
 	}
@@ -397,8 +479,7 @@ func convertOpenAINonStreamingToAnthropic(rawJSON []byte) [][]byte {
 	if choices := root.Get("choices"); choices.Exists() && choices.IsArray() && len(choices.Array()) > 0 {
 		choice := choices.Array()[0] // Take first choice
 
-		reasoningNode := choice.Get("message.reasoning_content")
-		for _, reasoningText := range collectOpenAIReasoningTexts(reasoningNode) {
+		for _, reasoningText := range translatorcommon.OpenAIReasoningTexts(choice.Get("message")) {
 			if reasoningText == "" {
 				continue
 			}
@@ -440,7 +521,7 @@ func convertOpenAINonStreamingToAnthropic(rawJSON []byte) [][]byte {
 
 		// Set stop reason
 		if finishReason := choice.Get("finish_reason"); finishReason.Exists() {
-			out, _ = sjson.SetBytes(out, "stop_reason", mapOpenAIFinishReasonToAnthropic(finishReason.String()))
+			out, _ = sjson.SetBytes(out, "stop_reason", translatorcommon.ClaudeStopReasonFromOpenAI(finishReason.String()))
 		}
 	}
 
@@ -457,22 +538,16 @@ func convertOpenAINonStreamingToAnthropic(rawJSON []byte) [][]byte {
 	return [][]byte{out}
 }
 
-// mapOpenAIFinishReasonToAnthropic maps OpenAI finish reasons to Anthropic equivalents
-func mapOpenAIFinishReasonToAnthropic(openAIReason string) string {
-	switch openAIReason {
-	case "stop":
-		return "end_turn"
-	case "length":
-		return "max_tokens"
-	case "tool_calls":
-		return "tool_use"
-	case "content_filter":
-		return "end_turn" // Anthropic doesn't have direct equivalent
-	case "function_call": // Legacy OpenAI
-		return "tool_use"
-	default:
-		return "end_turn"
+// closeToolArguments returns the accumulated tool arguments as valid JSON. Arguments cut off
+// mid-stream are closed on a best-effort basis and the message is marked as truncated so
+// the stop reason reports max_tokens.
+func (p *ConvertOpenAIResponseToAnthropicParams) closeToolArguments(accumulator *ToolCallAccumulator) string {
+	args := util.FixJSON(accumulator.Arguments.String())
+	repaired, truncated := util.CloseTruncatedJSON(args)
+	if truncated {
+		p.TruncatedToolCall = true
 	}
+	return repaired
 }
 
 func (p *ConvertOpenAIResponseToAnthropicParams) toolContentBlockIndex(openAIToolIndex int) int {
@@ -485,38 +560,6 @@ func (p *ConvertOpenAIResponseToAnthropicParams) toolContentBlockIndex(openAIToo
 	return idx
 }
 
-func collectOpenAIReasoningTexts(node gjson.Result) []string {
-	var texts []string
-	if !node.Exists() {
-		return texts
-	}
-
-	if node.IsArray() {
-		node.ForEach(func(_, value gjson.Result) bool {
-			texts = append(texts, collectOpenAIReasoningTexts(value)...)
-			return true
-		})
-		return texts
-	}
-
-	switch node.Type {
-	case gjson.String:
-		if text := node.String(); text != "" {
-			texts = append(texts, text)
-		}
-	case gjson.JSON:
-		if text := node.Get("text"); text.Exists() {
-			if textStr := text.String(); textStr != "" {
-				texts = append(texts, textStr)
-			}
-		} else if raw := node.Raw; raw != "" && !strings.HasPrefix(raw, "{") && !strings.HasPrefix(raw, "[") {
-			texts = append(texts, raw)
-		}
-	}
-
-	return texts
-}
-
 func stopThinkingContentBlock(param *ConvertOpenAIResponseToAnthropicParams, results *[][]byte) {
 	if !param.ThinkingContentBlockStarted {
 		return
@@ -573,11 +616,23 @@ func ConvertOpenAIResponseToClaudeNonStream(_ context.Context, _ string, origina
 		choice := choices.Array()[0]
 
 		if finishReason := choice.Get("finish_reason"); finishReason.Exists() {
-			out, _ = sjson.SetBytes(out, "stop_reason", mapOpenAIFinishReasonToAnthropic(finishReason.String()))
+			out, _ = sjson.SetBytes(out, "stop_reason", translatorcommon.ClaudeStopReasonFromOpenAI(finishReason.String()))
 			stopReasonSet = true
 		}
 
 		if message := choice.Get("message"); message.Exists() {
+			// Claude expects thinking to precede the answer it led to.
+			if reasoningTexts := translatorcommon.OpenAIReasoningTexts(message); len(reasoningTexts) > 0 {
+				for _, reasoningText := range reasoningTexts {
+					if reasoningText == "" {
+						continue
+					}
+					block := []byte(`{"type":"thinking","thinking":""}`)
+					block, _ = sjson.SetBytes(block, "thinking", reasoningText)
+					out, _ = sjson.SetRawBytes(out, "content.-1", block)
+				}
+			}
+
 			if contentResult := message.Get("content"); contentResult.Exists() {
 				if contentResult.IsArray() {
 					var textBuilder strings.Builder
@@ -658,17 +713,6 @@ func ConvertOpenAIResponseToClaudeNonStream(_ context.Context, _ string, origina
 				}
 			}
 
-			if reasoning := message.Get("reasoning_content"); reasoning.Exists() {
-				for _, reasoningText := range collectOpenAIReasoningTexts(reasoning) {
-					if reasoningText == "" {
-						continue
-					}
-					block := []byte(`{"type":"thinking","thinking":""}`)
-					block, _ = sjson.SetBytes(block, "thinking", reasoningText)
-					out, _ = sjson.SetRawBytes(out, "content.-1", block)
-				}
-			}
-
 			if toolCalls := message.Get("tool_calls"); toolCalls.Exists() && toolCalls.IsArray() {
 				toolCalls.ForEach(func(_, toolCall gjson.Result) bool {
 					hasToolCall = true
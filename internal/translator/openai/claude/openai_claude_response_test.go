@@ -0,0 +1,153 @@
+package claude
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAIResponseToClaudeFinalizeWithoutFinishReason(t *testing.T) {
+	original := []byte(`{"stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	var param any
+
+	var out [][]byte
+	out = append(out, ConvertOpenAIResponseToClaude(context.Background(), "m", original, nil, []byte(`data: {"id":"c1","model":"m","choices":[{"index":0,"delta":{"role":"assistant","content":"hello"}}]}`), &param)...)
+	out = append(out, FinalizeOpenAIResponseToClaude(context.Background(), "m", original, nil, &param)...)
+	joined := bytes.Join(out, nil)
+
+	for _, event := range []string{"event: message_start", "event: ping", "event: content_block_stop", "event: message_delta", "event: message_stop"} {
+		if !bytes.Contains(joined, []byte(event)) {
+			t.Fatalf("missing %q in stream:\n%s", event, joined)
+		}
+	}
+	if !bytes.Contains(joined, []byte(`"stop_reason":"end_turn"`)) {
+		t.Fatalf("expected end_turn stop reason:\n%s", joined)
+	}
+
+	if extra := FinalizeOpenAIResponseToClaude(context.Background(), "m", original, nil, &param); len(extra) != 0 {
+		t.Fatalf("expected finalizer to be idempotent, got %q", extra)
+	}
+}
+
+func TestConvertOpenAIResponseToClaudeRepairsTruncatedToolArguments(t *testing.T) {
+	original := []byte(`{"stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	var param any
+
+	var out [][]byte
+	out = append(out, ConvertOpenAIResponseToClaude(context.Background(), "m", original, nil, []byte(`data: {"id":"c1","model":"m","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"write","arguments":"{\"path\":\"/tmp/a\",\"body\":\"hel"}}]}}]}`), &param)...)
+	out = append(out, FinalizeOpenAIResponseToClaude(context.Background(), "m", original, nil, &param)...)
+	joined := bytes.Join(out, nil)
+
+	if !bytes.Contains(joined, []byte(`"partial_json":"{\"path\":\"/tmp/a\",\"body\":\"hel\"}"`)) {
+		t.Fatalf("expected repaired tool arguments:\n%s", joined)
+	}
+	if !bytes.Contains(joined, []byte(`"stop_reason":"max_tokens"`)) {
+		t.Fatalf("expected max_tokens stop reason:\n%s", joined)
+	}
+}
+
+func TestConvertOpenAIResponseToClaudeSequentialThinkingBlocksGetNewIndexes(t *testing.T) {
+	original := []byte(`{"stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	var param any
+
+	var out [][]byte
+	for _, chunk := range []string{
+		`data: {"id":"c1","model":"m","choices":[{"index":0,"delta":{"role":"assistant","reasoning_content":"plan"}}]}`,
+		`data: {"id":"c1","model":"m","choices":[{"index":0,"delta":{"content":"step one"}}]}`,
+		`data: {"id":"c1","model":"m","choices":[{"index":0,"delta":{"reasoning_content":"check"}}]}`,
+		`data: {"id":"c1","model":"m","choices":[{"index":0,"delta":{"content":"step two"}}]}`,
+		`data: {"id":"c1","model":"m","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`,
+	} {
+		out = append(out, ConvertOpenAIResponseToClaude(context.Background(), "m", original, nil, []byte(chunk), &param)...)
+	}
+
+	var starts []string
+	open := make(map[int64]bool)
+	lastIndex := int64(-1)
+	for _, event := range out {
+		for _, line := range bytes.Split(event, []byte("\n")) {
+			payload, ok := bytes.CutPrefix(line, []byte("data: "))
+			if !ok {
+				continue
+			}
+			root := gjson.ParseBytes(payload)
+			index := root.Get("index").Int()
+			switch root.Get("type").String() {
+			case "content_block_start":
+				if index <= lastIndex {
+					t.Fatalf("block index %d reused after %d:\n%s", index, lastIndex, bytes.Join(out, nil))
+				}
+				lastIndex = index
+				open[index] = true
+				starts = append(starts, root.Get("content_block.type").String())
+			case "content_block_delta":
+				if !open[index] {
+					t.Fatalf("delta for block %d that is not open:\n%s", index, bytes.Join(out, nil))
+				}
+			case "content_block_stop":
+				if !open[index] {
+					t.Fatalf("stop for block %d that is not open:\n%s", index, bytes.Join(out, nil))
+				}
+				delete(open, index)
+			}
+		}
+	}
+	if want := []string{"thinking", "text", "thinking", "text"}; !slices.Equal(starts, want) {
+		t.Fatalf("block types = %v, want %v", starts, want)
+	}
+	if len(open) != 0 {
+		t.Fatalf("blocks left open: %v", open)
+	}
+}
+
+func TestConvertOpenAIResponseToClaudeTranslatesMidStreamError(t *testing.T) {
+	original := []byte(`{"stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	var param any
+
+	var out [][]byte
+	for _, chunk := range []string{
+		`data: {"id":"c1","model":"m","choices":[{"index":0,"delta":{"role":"assistant","content":"partial"}}]}`,
+		`data: {"error":{"message":"quota exceeded","type":"requests","code":429}}`,
+		`data: {"id":"c1","model":"m","choices":[{"index":0,"delta":{"content":"ignored"}}]}`,
+		`data: [DONE]`,
+	} {
+		out = append(out, ConvertOpenAIResponseToClaude(context.Background(), "m", original, nil, []byte(chunk), &param)...)
+	}
+	out = append(out, FinalizeOpenAIResponseToClaude(context.Background(), "m", original, nil, &param)...)
+	joined := bytes.Join(out, nil)
+
+	if !bytes.Contains(joined, []byte(`event: error`)) || !bytes.Contains(joined, []byte(`{"type":"error","error":{"type":"rate_limit_error","message":"quota exceeded"}}`)) {
+		t.Fatalf("expected Claude error event:\n%s", joined)
+	}
+	if got := bytes.Count(joined, []byte("event: message_stop")); got != 1 {
+		t.Fatalf("message_stop count = %d, want 1:\n%s", got, joined)
+	}
+	if bytes.Contains(joined, []byte("ignored")) || bytes.Contains(joined, []byte("event: message_delta")) {
+		t.Fatalf("expected no events after the error:\n%s", joined)
+	}
+}
+
+func TestConvertOpenAIResponseToClaudeReadsReasoningField(t *testing.T) {
+	original := []byte(`{"stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	var param any
+
+	var out [][]byte
+	for _, line := range []string{
+		`data: {"id":"c1","model":"m","choices":[{"index":0,"delta":{"role":"assistant","reasoning":"think"}}]}`,
+		`data: {"id":"c1","model":"m","choices":[{"index":0,"delta":{"content":"answer"},"finish_reason":"stop"}]}`,
+	} {
+		out = append(out, ConvertOpenAIResponseToClaude(context.Background(), "m", original, nil, []byte(line), &param)...)
+	}
+	joined := bytes.Join(out, nil)
+	if !bytes.Contains(joined, []byte(`"type":"thinking_delta","thinking":"think"`)) {
+		t.Fatalf("expected reasoning as a thinking delta:\n%s", joined)
+	}
+
+	nonStream := ConvertOpenAIResponseToClaudeNonStream(context.Background(), "m", original, nil, []byte(`{"id":"c1","model":"m","choices":[{"index":0,"message":{"role":"assistant","content":"answer","reasoning_details":[{"type":"reasoning.text","text":"think"}]},"finish_reason":"stop"}]}`), nil)
+	if got := gjson.GetBytes(nonStream, "content.0.thinking").String(); got != "think" {
+		t.Fatalf("content.0.thinking = %q: %s", got, nonStream)
+	}
+}
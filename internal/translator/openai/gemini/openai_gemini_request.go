@@ -6,12 +6,10 @@
 package gemini
 
 import (
-	"crypto/rand"
-	"fmt"
-	"math/big"
 	"strings"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -26,18 +24,6 @@ func ConvertGeminiRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 
 	root := gjson.ParseBytes(rawJSON)
 
-	// Helper for generating tool call IDs in the form: call_<alphanum>
-	genToolCallID := func() string {
-		const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-		var b strings.Builder
-		// 24 chars random suffix
-		for i := 0; i < 24; i++ {
-			n, _ := rand.Int(rand.Reader, big.NewInt(int64(len(letters))))
-			b.WriteByte(letters[n.Int64()])
-		}
-		return "call_" + b.String()
-	}
-
 	// Model mapping
 	out, _ = sjson.SetBytes(out, "model", modelName)
 
@@ -81,6 +67,11 @@ func ConvertGeminiRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 			out, _ = sjson.SetBytes(out, "n", candidateCount.Int())
 		}
 
+		// responseLogprobs/logprobs -> logprobs/top_logprobs
+		if logprobs, ok := translatorcommon.LogprobsFromGemini(root); ok {
+			out = logprobs.ApplyToOpenAI(out)
+		}
+
 		// Map Gemini thinkingConfig to OpenAI reasoning_effort.
 		// Always perform conversion to support allowCompat models that may not be in registry.
 		// Note: Google official Python SDK sends snake_case fields (thinking_level/thinking_budget).
@@ -112,7 +103,8 @@ func ConvertGeminiRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 	out, _ = sjson.SetBytes(out, "stream", stream)
 
 	// Process contents (Gemini messages) -> OpenAI messages
-	var toolCallIDs []string // Track tool call IDs for matching with tool results
+	// Pairs function responses with the function calls they answer.
+	toolCallIDs := translatorcommon.NewToolCallCorrelator("call_")
 
 	// System instruction -> OpenAI system message
 	// Gemini may provide `systemInstruction` or `system_instruction`; support both keys.
@@ -135,19 +127,15 @@ func ConvertGeminiRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 					hasContent = true
 				}
 
-				// Handle inline data (e.g., images)
-				if inlineData := part.Get("inlineData"); inlineData.Exists() {
-					mimeType := inlineData.Get("mimeType").String()
-					if mimeType == "" {
-						mimeType = "application/octet-stream"
-					}
-					data := inlineData.Get("data").String()
-					imageURL := fmt.Sprintf("data:%s;base64,%s", mimeType, data)
-
-					contentPart := []byte(`{"type":"image_url","image_url":{"url":""}}`)
-					contentPart, _ = sjson.SetBytes(contentPart, "image_url.url", imageURL)
-					msg, _ = sjson.SetRawBytes(msg, "content.-1", contentPart)
+				// Handle inline or file data (e.g., images)
+				if img, ok := translatorcommon.ImageFromGeminiPart(part); ok {
+					msg, _ = sjson.SetRawBytes(msg, "content.-1", img.OpenAIPart())
 					hasContent = true
+				} else if doc, okDoc := translatorcommon.DocumentFromGeminiPart(part); okDoc {
+					if filePart, errRender := doc.OpenAIPart(); errRender == nil {
+						msg, _ = sjson.SetRawBytes(msg, "content.-1", filePart)
+						hasContent = true
+					}
 				}
 				return true
 			})
@@ -190,27 +178,28 @@ func ConvertGeminiRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 						contentPartsCount++
 					}
 
-					// Handle inline data (e.g., images)
-					if inlineData := part.Get("inlineData"); inlineData.Exists() {
+					// Handle inline or file data (e.g., images)
+					if img, ok := translatorcommon.ImageFromGeminiPart(part); ok {
 						onlyTextContent = false
-
-						mimeType := inlineData.Get("mimeType").String()
-						if mimeType == "" {
-							mimeType = "application/octet-stream"
-						}
-						data := inlineData.Get("data").String()
-						imageURL := fmt.Sprintf("data:%s;base64,%s", mimeType, data)
-
-						contentPart := []byte(`{"type":"image_url","image_url":{"url":""}}`)
-						contentPart, _ = sjson.SetBytes(contentPart, "image_url.url", imageURL)
-						contentWrapper, _ = sjson.SetRawBytes(contentWrapper, "arr.-1", contentPart)
+						contentWrapper, _ = sjson.SetRawBytes(contentWrapper, "arr.-1", img.OpenAIPart())
 						contentPartsCount++
+					} else if doc, okDoc := translatorcommon.DocumentFromGeminiPart(part); okDoc {
+						if filePart, errRender := doc.OpenAIPart(); errRender == nil {
+							onlyTextContent = false
+							contentWrapper, _ = sjson.SetRawBytes(contentWrapper, "arr.-1", filePart)
+							contentPartsCount++
+						}
+					} else if audio, okAudio := translatorcommon.AudioFromGeminiPart(part); okAudio {
+						if audioPart, errRender := audio.OpenAIPart(); errRender == nil {
+							onlyTextContent = false
+							contentWrapper, _ = sjson.SetRawBytes(contentWrapper, "arr.-1", audioPart)
+							contentPartsCount++
+						}
 					}
 
 					// Handle function calls (Gemini) -> tool calls (OpenAI)
 					if functionCall := part.Get("functionCall"); functionCall.Exists() {
-						toolCallID := genToolCallID()
-						toolCallIDs = append(toolCallIDs, toolCallID)
+						toolCallID := toolCallIDs.Call(functionCall.Get("id").String(), functionCall.Get("name").String())
 
 						toolCall := []byte(`{"id":"","type":"function","function":{"name":"","arguments":""}}`)
 						toolCall, _ = sjson.SetBytes(toolCall, "id", toolCallID)
@@ -241,16 +230,8 @@ func ConvertGeminiRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 							}
 						}
 
-						// Try to match with previous tool call ID
-						_ = functionResponse.Get("name").String() // functionName not used for now
-						if len(toolCallIDs) > 0 {
-							// Use the last tool call ID (simple matching by function name)
-							// In a real implementation, you might want more sophisticated matching
-							toolMsg, _ = sjson.SetBytes(toolMsg, "tool_call_id", toolCallIDs[len(toolCallIDs)-1])
-						} else {
-							// Generate a tool call ID if none available
-							toolMsg, _ = sjson.SetBytes(toolMsg, "tool_call_id", genToolCallID())
-						}
+						toolCallID := toolCallIDs.Result(functionResponse.Get("id").String(), functionResponse.Get("name").String())
+						toolMsg, _ = sjson.SetBytes(toolMsg, "tool_call_id", toolCallID)
 
 						out, _ = sjson.SetRawBytes(out, "messages.-1", toolMsg)
 					}
@@ -302,19 +283,9 @@ func ConvertGeminiRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 		})
 	}
 
-	// Tool choice mapping (Gemini doesn't have direct equivalent, but we can handle it)
-	if toolConfig := root.Get("toolConfig"); toolConfig.Exists() {
-		if functionCallingConfig := toolConfig.Get("functionCallingConfig"); functionCallingConfig.Exists() {
-			mode := functionCallingConfig.Get("mode").String()
-			switch mode {
-			case "NONE":
-				out, _ = sjson.SetBytes(out, "tool_choice", "none")
-			case "AUTO":
-				out, _ = sjson.SetBytes(out, "tool_choice", "auto")
-			case "ANY":
-				out, _ = sjson.SetBytes(out, "tool_choice", "required")
-			}
-		}
+	// Tool choice mapping; allowed function names are kept by filtering the tools
+	if toolChoice, ok := translatorcommon.ToolChoiceFromGemini(root); ok {
+		out = toolChoice.ApplyToOpenAI(out)
 	}
 
 	return out
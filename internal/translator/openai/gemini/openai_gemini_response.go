@@ -124,8 +124,8 @@ func ConvertOpenAIResponseToGemini(_ context.Context, _ string, originalRequestR
 			var chunkOutputs [][]byte
 
 			// Handle reasoning/thinking delta
-			if reasoning := delta.Get("reasoning_content"); reasoning.Exists() {
-				for _, reasoningText := range extractReasoningTexts(reasoning) {
+			if reasoningTexts := translatorcommon.OpenAIReasoningTexts(delta); len(reasoningTexts) > 0 {
+				for _, reasoningText := range reasoningTexts {
 					if reasoningText == "" {
 						continue
 					}
@@ -144,6 +144,9 @@ func ConvertOpenAIResponseToGemini(_ context.Context, _ string, originalRequestR
 				// Create text part for this delta
 				contentTemplate := append([]byte(nil), baseTemplate...)
 				contentTemplate, _ = sjson.SetBytes(contentTemplate, "candidates.0.content.parts.0.text", contentText)
+				if tokens := translatorcommon.TokenLogprobsFromOpenAI(choice.Get("logprobs.content")); len(tokens) > 0 {
+					contentTemplate, _ = sjson.SetRawBytes(contentTemplate, "candidates.0.logprobsResult", translatorcommon.GeminiLogprobsResult(tokens))
+				}
 				chunkOutputs = append(chunkOutputs, contentTemplate)
 			}
 
@@ -207,7 +210,7 @@ func ConvertOpenAIResponseToGemini(_ context.Context, _ string, originalRequestR
 
 			// Handle finish reason
 			if finishReason := choice.Get("finish_reason"); finishReason.Exists() {
-				geminiFinishReason := mapOpenAIFinishReasonToGemini(finishReason.String())
+				geminiFinishReason := translatorcommon.GeminiFinishReasonFromOpenAI(finishReason.String())
 				template, _ = sjson.SetBytes(template, "candidates.0.finishReason", geminiFinishReason)
 
 				// If we have accumulated tool calls, output them now
@@ -218,6 +221,9 @@ func ConvertOpenAIResponseToGemini(_ context.Context, _ string, originalRequestR
 						argsPath := fmt.Sprintf("candidates.0.content.parts.%d.functionCall.args", partIndex)
 						template, _ = sjson.SetBytes(template, namePath, accumulator.Name)
 						template, _ = sjson.SetRawBytes(template, argsPath, []byte(parseArgsToObjectRaw(accumulator.Arguments.String())))
+						if accumulator.ID != "" {
+							template, _ = sjson.SetBytes(template, fmt.Sprintf("candidates.0.content.parts.%d.functionCall.id", partIndex), accumulator.ID)
+						}
 						partIndex++
 					}
 
@@ -248,22 +254,6 @@ func ConvertOpenAIResponseToGemini(_ context.Context, _ string, originalRequestR
 	return [][]byte{}
 }
 
-// mapOpenAIFinishReasonToGemini maps OpenAI finish reasons to Gemini finish reasons
-func mapOpenAIFinishReasonToGemini(openAIReason string) string {
-	switch openAIReason {
-	case "stop":
-		return "STOP"
-	case "length":
-		return "MAX_TOKENS"
-	case "tool_calls":
-		return "STOP" // Gemini doesn't have a specific tool_calls finish reason
-	case "content_filter":
-		return "SAFETY"
-	default:
-		return "STOP"
-	}
-}
-
 // parseArgsToObjectRaw safely parses a JSON string of function arguments into an object JSON string.
 // It returns "{}" if the input is empty or cannot be parsed as a JSON object.
 func parseArgsToObjectRaw(argsStr string) string {
@@ -560,8 +550,8 @@ func ConvertOpenAIResponseToGeminiNonStream(_ context.Context, _ string, origina
 			partIndex := 0
 
 			// Handle reasoning content before visible text
-			if reasoning := message.Get("reasoning_content"); reasoning.Exists() {
-				for _, reasoningText := range extractReasoningTexts(reasoning) {
+			if reasoningTexts := translatorcommon.OpenAIReasoningTexts(message); len(reasoningTexts) > 0 {
+				for _, reasoningText := range reasoningTexts {
 					if reasoningText == "" {
 						continue
 					}
@@ -575,6 +565,9 @@ func ConvertOpenAIResponseToGeminiNonStream(_ context.Context, _ string, origina
 			if content := message.Get("content"); content.Exists() && content.String() != "" {
 				out, _ = sjson.SetBytes(out, fmt.Sprintf("candidates.0.content.parts.%d.text", partIndex), content.String())
 				partIndex++
+				if tokens := translatorcommon.TokenLogprobsFromOpenAI(choice.Get("logprobs.content")); len(tokens) > 0 {
+					out, _ = sjson.SetRawBytes(out, "candidates.0.logprobsResult", translatorcommon.GeminiLogprobsResult(tokens))
+				}
 			}
 
 			// Handle tool calls
@@ -589,6 +582,9 @@ func ConvertOpenAIResponseToGeminiNonStream(_ context.Context, _ string, origina
 						argsPath := fmt.Sprintf("candidates.0.content.parts.%d.functionCall.args", partIndex)
 						out, _ = sjson.SetBytes(out, namePath, functionName)
 						out, _ = sjson.SetRawBytes(out, argsPath, []byte(parseArgsToObjectRaw(functionArgs)))
+						if toolCallID := toolCall.Get("id").String(); toolCallID != "" {
+							out, _ = sjson.SetBytes(out, fmt.Sprintf("candidates.0.content.parts.%d.functionCall.id", partIndex), toolCallID)
+						}
 						partIndex++
 					}
 					return true
@@ -597,7 +593,7 @@ func ConvertOpenAIResponseToGeminiNonStream(_ context.Context, _ string, origina
 
 			// Handle finish reason
 			if finishReason := choice.Get("finish_reason"); finishReason.Exists() {
-				geminiFinishReason := mapOpenAIFinishReasonToGemini(finishReason.String())
+				geminiFinishReason := translatorcommon.GeminiFinishReasonFromOpenAI(finishReason.String())
 				out, _ = sjson.SetBytes(out, "candidates.0.finishReason", geminiFinishReason)
 			}
 
@@ -636,31 +632,3 @@ func reasoningTokensFromUsage(usage gjson.Result) int64 {
 	}
 	return 0
 }
-
-func extractReasoningTexts(node gjson.Result) []string {
-	var texts []string
-	if !node.Exists() {
-		return texts
-	}
-
-	if node.IsArray() {
-		node.ForEach(func(_, value gjson.Result) bool {
-			texts = append(texts, extractReasoningTexts(value)...)
-			return true
-		})
-		return texts
-	}
-
-	switch node.Type {
-	case gjson.String:
-		texts = append(texts, node.String())
-	case gjson.JSON:
-		if text := node.Get("text"); text.Exists() {
-			texts = append(texts, text.String())
-		} else if raw := strings.TrimSpace(node.Raw); raw != "" && !strings.HasPrefix(raw, "{") && !strings.HasPrefix(raw, "[") {
-			texts = append(texts, raw)
-		}
-	}
-
-	return texts
-}
@@ -1,15 +1,19 @@
 // Package chat_completions provides passthrough response translation for OpenAI Chat Completions.
-// It normalizes OpenAI-compatible SSE lines by stripping the "data:" prefix and dropping "[DONE]".
+// It normalizes OpenAI-compatible SSE lines by stripping the "data:" prefix and dropping "[DONE]",
+// and copies reasoning reported under provider-specific fields into reasoning_content.
 package chat_completions
 
 import (
 	"bytes"
 	"context"
+
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
 )
 
 // ConvertOpenAIResponseToOpenAI normalizes a single chunk of an OpenAI-compatible streaming response.
 // If the chunk is an SSE "data:" line, the prefix is stripped and the remaining JSON payload is returned.
-// The "[DONE]" marker yields no output.
+// The "[DONE]" marker yields no output. Reasoning reported under another field is copied into
+// reasoning_content.
 //
 // Parameters:
 //   - ctx: The context for the request, used for cancellation and timeout handling
@@ -26,10 +30,11 @@ func ConvertOpenAIResponseToOpenAI(_ context.Context, _ string, originalRequestR
 	if bytes.Equal(rawJSON, []byte("[DONE]")) {
 		return [][]byte{}
 	}
-	return [][]byte{rawJSON}
+	return [][]byte{translatorcommon.NormalizeOpenAIReasoning(rawJSON)}
 }
 
-// ConvertOpenAIResponseToOpenAINonStream passes through a non-streaming OpenAI response.
+// ConvertOpenAIResponseToOpenAINonStream passes through a non-streaming OpenAI response, copying
+// reasoning reported under another field into reasoning_content.
 //
 // Parameters:
 //   - ctx: The context for the request, used for cancellation and timeout handling
@@ -40,5 +45,5 @@ func ConvertOpenAIResponseToOpenAI(_ context.Context, _ string, originalRequestR
 // Returns:
 //   - []byte: The OpenAI-compatible JSON response.
 func ConvertOpenAIResponseToOpenAINonStream(ctx context.Context, modelName string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) []byte {
-	return rawJSON
+	return translatorcommon.NormalizeOpenAIReasoning(rawJSON)
 }
@@ -3,6 +3,7 @@ package responses
 import (
 	"strings"
 
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -48,6 +49,10 @@ func ConvertOpenAIResponsesRequestToOpenAIChatCompletions(modelName string, inpu
 		out, _ = sjson.SetBytes(out, "parallel_tool_calls", parallelToolCalls.Bool())
 	}
 
+	if logprobs, ok := translatorcommon.LogprobsFromResponses(root); ok {
+		out = logprobs.ApplyToOpenAI(out)
+	}
+
 	// Convert instructions to system message
 	if instructions := root.Get("instructions"); instructions.Exists() {
 		systemMessage := []byte(`{"role":"system","content":""}`)
@@ -94,6 +99,18 @@ func ConvertOpenAIResponsesRequestToOpenAIChatCompletions(modelName string, inpu
 							contentPart := []byte(`{"type":"image_url","image_url":{"url":""}}`)
 							contentPart, _ = sjson.SetBytes(contentPart, "image_url.url", imageURL)
 							message, _ = sjson.SetRawBytes(message, "content.-1", contentPart)
+						case "input_file":
+							if doc, ok := translatorcommon.DocumentFromResponsesFile(contentItem); ok {
+								if contentPart, errRender := doc.OpenAIPart(); errRender == nil {
+									message, _ = sjson.SetRawBytes(message, "content.-1", contentPart)
+								}
+							}
+						case "input_audio":
+							if audio, ok := translatorcommon.AudioFromResponsesInput(contentItem); ok {
+								if contentPart, errRender := audio.OpenAIPart(); errRender == nil {
+									message, _ = sjson.SetRawBytes(message, "content.-1", contentPart)
+								}
+							}
 						}
 						return true
 					})
@@ -205,9 +222,9 @@ func ConvertOpenAIResponsesRequestToOpenAIChatCompletions(modelName string, inpu
 		}
 	}
 
-	// Convert tool_choice if present
-	if toolChoice := root.Get("tool_choice"); toolChoice.Exists() {
-		out, _ = sjson.SetBytes(out, "tool_choice", toolChoice.String())
+	// Convert tool_choice if present; Responses function choices carry the name at the top level
+	if toolChoice, ok := translatorcommon.ToolChoiceFromOpenAI(root.Get("tool_choice")); ok {
+		out = toolChoice.ApplyToOpenAI(out)
 	}
 
 	return out
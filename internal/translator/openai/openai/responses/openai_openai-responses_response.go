@@ -31,6 +31,7 @@ type oaiToResponsesState struct {
 	// aggregation buffers for response.output
 	// Per-output message text buffers by index
 	MsgTextBuf   map[int]*strings.Builder
+	MsgLogprobs  map[int][]translatorcommon.TokenLogprob
 	ReasoningBuf strings.Builder
 	Reasonings   []oaiToResponsesStateReasoning
 	FuncArgsBuf  map[string]*strings.Builder
@@ -53,6 +54,8 @@ type oaiToResponsesState struct {
 	TotalTokens      int64
 	ReasoningTokens  int64
 	UsageSeen        bool
+	// FinishReason is the last finish_reason reported by a choice.
+	FinishReason string
 }
 
 // responseIDCounter provides a process-wide unique counter for synthesized response identifiers.
@@ -155,6 +158,9 @@ func buildResponsesCompletedEvent(st *oaiToResponsesState, requestRawJSON []byte
 			item := []byte(`{"id":"","type":"message","status":"completed","content":[{"type":"output_text","annotations":[],"logprobs":[],"text":""}],"role":"assistant"}`)
 			item, _ = sjson.SetBytes(item, "id", fmt.Sprintf("msg_%s_%d", st.ResponseID, i))
 			item, _ = sjson.SetBytes(item, "content.0.text", txt)
+			if tokens := st.MsgLogprobs[i]; len(tokens) > 0 {
+				item, _ = sjson.SetRawBytes(item, "content.0.logprobs", translatorcommon.ResponsesLogprobs(tokens))
+			}
 			outputItems = append(outputItems, completedOutputItem{index: st.MsgOutputIx[i], raw: item})
 		}
 	}
@@ -194,7 +200,8 @@ func buildResponsesCompletedEvent(st *oaiToResponsesState, requestRawJSON []byte
 		}
 		completed, _ = sjson.SetBytes(completed, "response.usage.total_tokens", total)
 	}
-	return emitRespEvent("response.completed", completed)
+	completed, event := translatorcommon.ResponsesTerminalEvent(completed, st.FinishReason)
+	return emitRespEvent(event, completed)
 }
 
 // ConvertOpenAIChatCompletionsResponseToOpenAIResponses converts OpenAI Chat Completions streaming chunks
@@ -208,6 +215,7 @@ func ConvertOpenAIChatCompletionsResponseToOpenAIResponses(ctx context.Context,
 			FuncOutputIx:    make(map[string]int),
 			MsgOutputIx:     make(map[int]int),
 			MsgTextBuf:      make(map[int]*strings.Builder),
+			MsgLogprobs:     make(map[int][]translatorcommon.TokenLogprob),
 			MsgItemAdded:    make(map[int]bool),
 			MsgContentAdded: make(map[int]bool),
 			MsgItemDone:     make(map[int]bool),
@@ -286,6 +294,7 @@ func ConvertOpenAIChatCompletionsResponseToOpenAIResponses(ctx context.Context,
 		st.Created = root.Get("created").Int()
 		// reset aggregation state for a new streaming response
 		st.MsgTextBuf = make(map[int]*strings.Builder)
+		st.MsgLogprobs = make(map[int][]translatorcommon.TokenLogprob)
 		st.ReasoningBuf.Reset()
 		st.ReasoningID = ""
 		st.ReasoningIndex = 0
@@ -388,6 +397,10 @@ func ConvertOpenAIChatCompletionsResponseToOpenAIResponses(ctx context.Context,
 					msg, _ = sjson.SetBytes(msg, "output_index", msgOutputIndex)
 					msg, _ = sjson.SetBytes(msg, "content_index", 0)
 					msg, _ = sjson.SetBytes(msg, "delta", c.String())
+					if tokens := translatorcommon.TokenLogprobsFromOpenAI(choice.Get("logprobs.content")); len(tokens) > 0 {
+						msg, _ = sjson.SetRawBytes(msg, "logprobs", translatorcommon.ResponsesLogprobs(tokens))
+						st.MsgLogprobs[idx] = append(st.MsgLogprobs[idx], tokens...)
+					}
 					out = append(out, emitRespEvent("response.output_text.delta", msg))
 					// aggregate for response.output
 					if st.MsgTextBuf[idx] == nil {
@@ -396,8 +409,8 @@ func ConvertOpenAIChatCompletionsResponseToOpenAIResponses(ctx context.Context,
 					st.MsgTextBuf[idx].WriteString(c.String())
 				}
 
-				// reasoning_content (OpenAI reasoning incremental text)
-				if rc := delta.Get("reasoning_content"); rc.Exists() && rc.String() != "" {
+				// reasoning_content or an equivalent field (OpenAI reasoning incremental text)
+				if rcText := strings.Join(translatorcommon.OpenAIReasoningTexts(delta), ""); rcText != "" {
 					// On first appearance, add reasoning item and part
 					if st.ReasoningID == "" {
 						st.ReasoningID = fmt.Sprintf("rs_%s_%d", st.ResponseID, idx)
@@ -414,12 +427,12 @@ func ConvertOpenAIChatCompletionsResponseToOpenAIResponses(ctx context.Context,
 						out = append(out, emitRespEvent("response.reasoning_summary_part.added", part))
 					}
 					// Append incremental text to reasoning buffer
-					st.ReasoningBuf.WriteString(rc.String())
+					st.ReasoningBuf.WriteString(rcText)
 					msg := []byte(`{"type":"response.reasoning_summary_text.delta","sequence_number":0,"item_id":"","output_index":0,"summary_index":0,"delta":""}`)
 					msg, _ = sjson.SetBytes(msg, "sequence_number", nextSeq())
 					msg, _ = sjson.SetBytes(msg, "item_id", st.ReasoningID)
 					msg, _ = sjson.SetBytes(msg, "output_index", st.ReasoningIndex)
-					msg, _ = sjson.SetBytes(msg, "delta", rc.String())
+					msg, _ = sjson.SetBytes(msg, "delta", rcText)
 					out = append(out, emitRespEvent("response.reasoning_summary_text.delta", msg))
 				}
 
@@ -443,6 +456,9 @@ func ConvertOpenAIChatCompletionsResponseToOpenAIResponses(ctx context.Context,
 						done, _ = sjson.SetBytes(done, "output_index", msgOutputIndex)
 						done, _ = sjson.SetBytes(done, "content_index", 0)
 						done, _ = sjson.SetBytes(done, "text", fullText)
+						if tokens := st.MsgLogprobs[idx]; len(tokens) > 0 {
+							done, _ = sjson.SetRawBytes(done, "logprobs", translatorcommon.ResponsesLogprobs(tokens))
+						}
 						out = append(out, emitRespEvent("response.output_text.done", done))
 
 						partDone := []byte(`{"type":"response.content_part.done","sequence_number":0,"item_id":"","output_index":0,"content_index":0,"part":{"type":"output_text","annotations":[],"logprobs":[],"text":""}}`)
@@ -451,6 +467,9 @@ func ConvertOpenAIChatCompletionsResponseToOpenAIResponses(ctx context.Context,
 						partDone, _ = sjson.SetBytes(partDone, "output_index", msgOutputIndex)
 						partDone, _ = sjson.SetBytes(partDone, "content_index", 0)
 						partDone, _ = sjson.SetBytes(partDone, "part.text", fullText)
+						if tokens := st.MsgLogprobs[idx]; len(tokens) > 0 {
+							partDone, _ = sjson.SetRawBytes(partDone, "part.logprobs", translatorcommon.ResponsesLogprobs(tokens))
+						}
 						out = append(out, emitRespEvent("response.content_part.done", partDone))
 
 						itemDone := []byte(`{"type":"response.output_item.done","sequence_number":0,"output_index":0,"item":{"id":"","type":"message","status":"completed","content":[{"type":"output_text","annotations":[],"logprobs":[],"text":""}],"role":"assistant"}}`)
@@ -458,6 +477,9 @@ func ConvertOpenAIChatCompletionsResponseToOpenAIResponses(ctx context.Context,
 						itemDone, _ = sjson.SetBytes(itemDone, "output_index", msgOutputIndex)
 						itemDone, _ = sjson.SetBytes(itemDone, "item.id", fmt.Sprintf("msg_%s_%d", st.ResponseID, idx))
 						itemDone, _ = sjson.SetBytes(itemDone, "item.content.0.text", fullText)
+						if tokens := st.MsgLogprobs[idx]; len(tokens) > 0 {
+							itemDone, _ = sjson.SetRawBytes(itemDone, "item.content.0.logprobs", translatorcommon.ResponsesLogprobs(tokens))
+						}
 						out = append(out, emitRespEvent("response.output_item.done", itemDone))
 						st.MsgItemDone[idx] = true
 					}
@@ -521,6 +543,7 @@ func ConvertOpenAIChatCompletionsResponseToOpenAIResponses(ctx context.Context,
 			// deferred until the terminal [DONE] marker so late usage-only chunks can
 			// still populate response.usage.
 			if fr := choice.Get("finish_reason"); fr.Exists() && fr.String() != "" {
+				st.FinishReason = fr.String()
 				// Emit message done events for all indices that started a message
 				if len(st.MsgItemAdded) > 0 {
 					// sort indices for deterministic order
@@ -542,6 +565,9 @@ func ConvertOpenAIChatCompletionsResponseToOpenAIResponses(ctx context.Context,
 							done, _ = sjson.SetBytes(done, "output_index", msgOutputIndex)
 							done, _ = sjson.SetBytes(done, "content_index", 0)
 							done, _ = sjson.SetBytes(done, "text", fullText)
+							if tokens := st.MsgLogprobs[i]; len(tokens) > 0 {
+								done, _ = sjson.SetRawBytes(done, "logprobs", translatorcommon.ResponsesLogprobs(tokens))
+							}
 							out = append(out, emitRespEvent("response.output_text.done", done))
 
 							partDone := []byte(`{"type":"response.content_part.done","sequence_number":0,"item_id":"","output_index":0,"content_index":0,"part":{"type":"output_text","annotations":[],"logprobs":[],"text":""}}`)
@@ -550,6 +576,9 @@ func ConvertOpenAIChatCompletionsResponseToOpenAIResponses(ctx context.Context,
 							partDone, _ = sjson.SetBytes(partDone, "output_index", msgOutputIndex)
 							partDone, _ = sjson.SetBytes(partDone, "content_index", 0)
 							partDone, _ = sjson.SetBytes(partDone, "part.text", fullText)
+							if tokens := st.MsgLogprobs[i]; len(tokens) > 0 {
+								partDone, _ = sjson.SetRawBytes(partDone, "part.logprobs", translatorcommon.ResponsesLogprobs(tokens))
+							}
 							out = append(out, emitRespEvent("response.content_part.done", partDone))
 
 							itemDone := []byte(`{"type":"response.output_item.done","sequence_number":0,"output_index":0,"item":{"id":"","type":"message","status":"completed","content":[{"type":"output_text","annotations":[],"logprobs":[],"text":""}],"role":"assistant"}}`)
@@ -557,6 +586,9 @@ func ConvertOpenAIChatCompletionsResponseToOpenAIResponses(ctx context.Context,
 							itemDone, _ = sjson.SetBytes(itemDone, "output_index", msgOutputIndex)
 							itemDone, _ = sjson.SetBytes(itemDone, "item.id", fmt.Sprintf("msg_%s_%d", st.ResponseID, i))
 							itemDone, _ = sjson.SetBytes(itemDone, "item.content.0.text", fullText)
+							if tokens := st.MsgLogprobs[i]; len(tokens) > 0 {
+								itemDone, _ = sjson.SetRawBytes(itemDone, "item.content.0.logprobs", translatorcommon.ResponsesLogprobs(tokens))
+							}
 							out = append(out, emitRespEvent("response.output_item.done", itemDone))
 							st.MsgItemDone[i] = true
 						}
@@ -625,6 +657,7 @@ func ConvertOpenAIChatCompletionsResponseToOpenAIResponsesNonStream(_ context.Co
 
 	// Basic response scaffold
 	resp := []byte(`{"id":"","object":"response","created_at":0,"status":"completed","background":false,"error":null,"incomplete_details":null}`)
+	resp = translatorcommon.MarkResponsesIncomplete(resp, root.Get("choices.0.finish_reason").String())
 
 	// id: use provider id if present, otherwise synthesize
 	id := root.Get("id").String()
@@ -718,7 +751,7 @@ func ConvertOpenAIChatCompletionsResponseToOpenAIResponsesNonStream(_ context.Co
 	// Build output list from choices[...]
 	outputsWrapper := []byte(`{"arr":[]}`)
 	// Detect and capture reasoning content if present
-	rcText := gjson.GetBytes(rawJSON, "choices.0.message.reasoning_content").String()
+	rcText := strings.Join(translatorcommon.OpenAIReasoningTexts(gjson.GetBytes(rawJSON, "choices.0.message")), "")
 	includeReasoning := rcText != ""
 	if !includeReasoning && len(requestRawJSON) > 0 {
 		includeReasoning = gjson.GetBytes(requestRawJSON, "reasoning").Exists()
@@ -747,6 +780,9 @@ func ConvertOpenAIChatCompletionsResponseToOpenAIResponsesNonStream(_ context.Co
 					item := []byte(`{"id":"","type":"message","status":"completed","content":[{"type":"output_text","annotations":[],"logprobs":[],"text":""}],"role":"assistant"}`)
 					item, _ = sjson.SetBytes(item, "id", fmt.Sprintf("msg_%s_%d", id, int(choice.Get("index").Int())))
 					item, _ = sjson.SetBytes(item, "content.0.text", c.String())
+					if tokens := translatorcommon.TokenLogprobsFromOpenAI(choice.Get("logprobs.content")); len(tokens) > 0 {
+						item, _ = sjson.SetRawBytes(item, "content.0.logprobs", translatorcommon.ResponsesLogprobs(tokens))
+					}
 					outputsWrapper, _ = sjson.SetRawBytes(outputsWrapper, "arr.-1", item)
 				}
 
@@ -421,3 +421,74 @@ func TestConvertOpenAIChatCompletionsResponseToOpenAIResponses_FunctionCallDoneA
 		t.Fatalf("unexpected completed function_call order: %v", completedOrder)
 	}
 }
+
+func TestConvertOpenAIChatCompletionsResponseToOpenAIResponses_Logprobs(t *testing.T) {
+	t.Parallel()
+
+	request := []byte(`{"model":"gpt-4o","include":["message.output_text.logprobs"]}`)
+	in := []string{
+		`data: {"id":"resp_lp","object":"chat.completion.chunk","created":1773896263,"model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","content":"Hi"},"logprobs":{"content":[{"token":"Hi","logprob":-0.1,"bytes":[72,105],"top_logprobs":[{"token":"Hi","logprob":-0.1,"bytes":[72,105]}]}]},"finish_reason":null}]}`,
+		`data: {"id":"resp_lp","object":"chat.completion.chunk","created":1773896263,"model":"gpt-4o","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`,
+		`data: [DONE]`,
+	}
+
+	var param any
+	var delta, itemDone, completed gjson.Result
+	for _, line := range in {
+		for _, chunk := range ConvertOpenAIChatCompletionsResponseToOpenAIResponses(context.Background(), "model", request, request, []byte(line), &param) {
+			event, data := parseOpenAIResponsesSSEEvent(t, chunk)
+			switch event {
+			case "response.output_text.delta":
+				delta = data
+			case "response.output_item.done":
+				itemDone = data
+			case "response.completed":
+				completed = data
+			}
+		}
+	}
+
+	if got := delta.Get("logprobs.0.token").String(); got != "Hi" {
+		t.Fatalf("delta logprobs = %s", delta.Get("logprobs").Raw)
+	}
+	if got := itemDone.Get("item.content.0.logprobs.0.logprob").Float(); got != -0.1 {
+		t.Fatalf("output_item.done logprobs = %s", itemDone.Get("item.content.0.logprobs").Raw)
+	}
+	if got := completed.Get("response.output.0.content.0.logprobs.0.top_logprobs.#").Int(); got != 1 {
+		t.Fatalf("completed logprobs = %s", completed.Get("response.output.0.content.0.logprobs").Raw)
+	}
+
+	nonStream := []byte(`{"id":"chatcmpl_lp","object":"chat.completion","created":1773896263,"model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"},"logprobs":{"content":[{"token":"Hi","logprob":-0.2,"bytes":[72,105],"top_logprobs":[]}]},"finish_reason":"stop"}]}`)
+	out := ConvertOpenAIChatCompletionsResponseToOpenAIResponsesNonStream(context.Background(), "model", request, request, nonStream, nil)
+	if got := gjson.GetBytes(out, "output.0.content.0.logprobs.0.logprob").Float(); got != -0.2 {
+		t.Fatalf("non-stream logprobs = %s", gjson.GetBytes(out, "output.0.content.0.logprobs").Raw)
+	}
+}
+
+func TestConvertOpenAIChatCompletionsResponseToOpenAIResponses_ContentFilterEndsIncomplete(t *testing.T) {
+	request := []byte(`{"model":"gpt-5.4"}`)
+	in := []string{
+		`data: {"id":"resp_filtered","object":"chat.completion.chunk","created":1773896263,"model":"model","choices":[{"index":0,"delta":{"role":"assistant","content":"partial"},"finish_reason":null}]}`,
+		`data: {"id":"resp_filtered","object":"chat.completion.chunk","created":1773896263,"model":"model","choices":[{"index":0,"delta":{},"finish_reason":"content_filter"}]}`,
+		`data: [DONE]`,
+	}
+
+	var param any
+	var out [][]byte
+	for _, line := range in {
+		out = append(out, ConvertOpenAIChatCompletionsResponseToOpenAIResponses(context.Background(), "model", request, request, []byte(line), &param)...)
+	}
+
+	event, data := parseOpenAIResponsesSSEEvent(t, out[len(out)-1])
+	if event != "response.incomplete" || data.Get("type").String() != event {
+		t.Fatalf("terminal event = %q, data = %s", event, data.Raw)
+	}
+	if data.Get("response.status").String() != "incomplete" || data.Get("response.incomplete_details.reason").String() != "content_filter" {
+		t.Fatalf("terminal response = %s", data.Get("response").Raw)
+	}
+
+	nonStream := ConvertOpenAIChatCompletionsResponseToOpenAIResponsesNonStream(context.Background(), "model", request, request, []byte(`{"id":"chatcmpl-1","object":"chat.completion","created":1773896263,"model":"model","choices":[{"index":0,"message":{"role":"assistant","content":"partial"},"finish_reason":"length"}]}`), nil)
+	if gjson.GetBytes(nonStream, "status").String() != "incomplete" || gjson.GetBytes(nonStream, "incomplete_details.reason").String() != "max_output_tokens" {
+		t.Fatalf("non-stream response = %s", nonStream)
+	}
+}
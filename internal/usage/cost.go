@@ -0,0 +1,223 @@
+package usage
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+)
+
+// PricingFunc returns the price of a model in USD per million tokens, or nil when unknown.
+type PricingFunc func(model string) *registry.ModelPricing
+
+// CostFilter selects the request details included in a cost report. Empty string fields and
+// zero times match every value.
+type CostFilter struct {
+	Start  time.Time
+	End    time.Time
+	APIKey string
+	Model  string
+}
+
+// CostBreakdown aggregates the token usage and cost of a group of requests. Costs are in USD.
+// UncachedCost is what the same requests would have cost if cached input had been billed at
+// the regular input price, and Savings is the difference to Cost.
+type CostBreakdown struct {
+	Requests        int64   `json:"requests"`
+	InputTokens     int64   `json:"input_tokens"`
+	CachedTokens    int64   `json:"cached_tokens"`
+	OutputTokens    int64   `json:"output_tokens"`
+	ReasoningTokens int64   `json:"reasoning_tokens"`
+	CacheHitRate    float64 `json:"cache_hit_rate"`
+	Cost            float64 `json:"cost"`
+	UncachedCost    float64 `json:"uncached_cost"`
+	Savings         float64 `json:"savings"`
+	SavingsPercent  float64 `json:"savings_percent"`
+	// UnpricedRequests counts requests whose model has no configured pricing; they are
+	// included in the token counts but not in the costs.
+	UnpricedRequests int64 `json:"unpriced_requests,omitempty"`
+
+	// promptTokens counts uncached plus cached input, whichever way the provider reports it.
+	promptTokens int64
+}
+
+// APICostReport is the cost of the requests made with one API key.
+type APICostReport struct {
+	CostBreakdown
+	Models map[string]CostBreakdown `json:"models"`
+}
+
+// CostReport summarises cost and cache savings per API key and model.
+type CostReport struct {
+	Currency string                   `json:"currency"`
+	Start    *time.Time               `json:"start,omitempty"`
+	End      *time.Time               `json:"end,omitempty"`
+	Total    CostBreakdown            `json:"total"`
+	APIs     map[string]APICostReport `json:"apis"`
+	// UnpricedModels lists models without pricing; add model-metadata pricing to cost them.
+	UnpricedModels []string `json:"unpriced_models"`
+}
+
+// gemini-family providers report reasoning tokens separately from output tokens; the others
+// include them in the output count.
+var separateReasoningProviders = map[string]bool{
+	"gemini":      true,
+	"gemini-cli":  true,
+	"vertex":      true,
+	"aistudio":    true,
+	"antigravity": true,
+}
+
+// cachedSeparateProviders report cached input next to, not inside, the input token count.
+var cachedSeparateProviders = map[string]bool{
+	"claude": true,
+}
+
+// billableTokens splits a request's usage into uncached input, cached input and output tokens.
+func billableTokens(detail RequestDetail) (uncachedInput, cached, output int64) {
+	tokens := detail.Tokens
+	cached = max(tokens.CachedTokens, 0)
+	input := max(tokens.InputTokens, 0)
+	separate := cachedSeparateProviders[detail.Provider]
+	if detail.Provider == "" {
+		// Details recorded before the provider was tracked: a cached count above the input
+		// count can only be reported separately.
+		separate = cached > input
+	}
+	if separate {
+		uncachedInput = input
+	} else {
+		uncachedInput = max(input-cached, 0)
+	}
+	output = max(tokens.OutputTokens, 0)
+	if separateReasoningProviders[detail.Provider] {
+		output += max(tokens.ReasoningTokens, 0)
+	}
+	return uncachedInput, cached, output
+}
+
+// add accumulates one request into the breakdown. pricing may be nil.
+func (b *CostBreakdown) add(detail RequestDetail, pricing *registry.ModelPricing) {
+	b.Requests++
+	b.InputTokens += detail.Tokens.InputTokens
+	b.CachedTokens += detail.Tokens.CachedTokens
+	b.OutputTokens += detail.Tokens.OutputTokens
+	b.ReasoningTokens += detail.Tokens.ReasoningTokens
+	uncachedInput, cached, output := billableTokens(detail)
+	b.promptTokens += uncachedInput + cached
+	if pricing == nil {
+		b.UnpricedRequests++
+		return
+	}
+	cacheRead := pricing.CacheRead
+	if cacheRead <= 0 {
+		// Without a cache price cached input is billed like regular input.
+		cacheRead = pricing.Input
+	}
+	outputCost := float64(output) * pricing.Output
+	b.Cost += (float64(uncachedInput)*pricing.Input + float64(cached)*cacheRead + outputCost) / 1e6
+	b.UncachedCost += (float64(uncachedInput+cached)*pricing.Input + outputCost) / 1e6
+}
+
+// finish derives the ratio fields and rounds the costs to a millionth of a dollar.
+func (b *CostBreakdown) finish() {
+	b.Cost = roundCost(b.Cost)
+	b.UncachedCost = roundCost(b.UncachedCost)
+	b.Savings = roundCost(b.UncachedCost - b.Cost)
+	if b.UncachedCost > 0 {
+		b.SavingsPercent = math.Round(b.Savings/b.UncachedCost*10000) / 100
+	}
+	if b.promptTokens > 0 {
+		b.CacheHitRate = math.Round(float64(b.CachedTokens)/float64(b.promptTokens)*10000) / 10000
+	}
+}
+
+func roundCost(value float64) float64 {
+	return math.Round(value*1e6) / 1e6
+}
+
+// CostReport prices the retained request details matching filter. Failed requests are skipped
+// since providers do not bill them. A nil pricing function leaves every request unpriced.
+func (s *RequestStatistics) CostReport(filter CostFilter, pricing PricingFunc) CostReport {
+	report := CostReport{
+		Currency:       "USD",
+		APIs:           make(map[string]APICostReport),
+		UnpricedModels: []string{},
+	}
+	if !filter.Start.IsZero() {
+		start := filter.Start.UTC()
+		report.Start = &start
+	}
+	if !filter.End.IsZero() {
+		end := filter.End.UTC()
+		report.End = &end
+	}
+	if s == nil {
+		return report
+	}
+
+	prices := make(map[string]*registry.ModelPricing)
+	unpriced := make(map[string]bool)
+	priceOf := func(model string) *registry.ModelPricing {
+		price, ok := prices[model]
+		if !ok {
+			if pricing != nil {
+				price = pricing(model)
+			}
+			prices[model] = price
+		}
+		if price == nil {
+			unpriced[model] = true
+		}
+		return price
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for apiName, stats := range s.apis {
+		if filter.APIKey != "" && apiName != filter.APIKey {
+			continue
+		}
+		apiReport := APICostReport{Models: make(map[string]CostBreakdown)}
+		for modelName, modelStatsValue := range stats.Models {
+			if filter.Model != "" && modelName != filter.Model {
+				continue
+			}
+			var modelReport CostBreakdown
+			for _, detail := range modelStatsValue.Details {
+				if detail.Failed {
+					continue
+				}
+				if !filter.Start.IsZero() && detail.Timestamp.Before(filter.Start) {
+					continue
+				}
+				if !filter.End.IsZero() && !detail.Timestamp.Before(filter.End) {
+					continue
+				}
+				price := priceOf(modelName)
+				modelReport.add(detail, price)
+				apiReport.add(detail, price)
+				report.Total.add(detail, price)
+			}
+			if modelReport.Requests == 0 {
+				continue
+			}
+			modelReport.finish()
+			apiReport.Models[modelName] = modelReport
+		}
+		if apiReport.Requests == 0 {
+			continue
+		}
+		apiReport.finish()
+		report.APIs[apiName] = apiReport
+	}
+	report.Total.finish()
+
+	for model := range unpriced {
+		report.UnpricedModels = append(report.UnpricedModels, model)
+	}
+	sort.Strings(report.UnpricedModels)
+	return report
+}
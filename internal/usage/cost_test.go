@@ -0,0 +1,81 @@
+package usage
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestRequestStatisticsCostReport(t *testing.T) {
+	base := time.Date(2026, 3, 20, 12, 0, 0, 0, time.UTC)
+	stats := NewRequestStatistics()
+	for _, rec := range []coreusage.Record{
+		// OpenAI-style usage counts cached tokens inside the input tokens.
+		{APIKey: "a", Model: "m1", Provider: "codex", RequestedAt: base, Detail: coreusage.Detail{InputTokens: 1000, CachedTokens: 400, OutputTokens: 100}},
+		// Claude reports cached tokens next to the input tokens.
+		{APIKey: "a", Model: "m1", Provider: "claude", RequestedAt: base, Detail: coreusage.Detail{InputTokens: 600, CachedTokens: 400, OutputTokens: 100}},
+		// Gemini reports reasoning tokens next to the output tokens.
+		{APIKey: "b", Model: "m1", Provider: "gemini-cli", RequestedAt: base, Detail: coreusage.Detail{InputTokens: 1000, OutputTokens: 50, ReasoningTokens: 50}},
+		{APIKey: "b", Model: "m1", Provider: "gemini-cli", RequestedAt: base, Failed: true, Detail: coreusage.Detail{InputTokens: 1000}},
+		{APIKey: "a", Model: "m2", Provider: "codex", RequestedAt: base, Detail: coreusage.Detail{InputTokens: 10}},
+	} {
+		stats.Record(context.Background(), rec)
+	}
+
+	pricing := func(model string) *registry.ModelPricing {
+		if model == "m1" {
+			return &registry.ModelPricing{Input: 2, Output: 10, CacheRead: 0.5}
+		}
+		return nil
+	}
+	report := stats.CostReport(CostFilter{}, pricing)
+
+	total := report.Total
+	if total.Requests != 4 || total.UnpricedRequests != 1 {
+		t.Fatalf("requests = %d, unpriced = %d; want 4 and 1", total.Requests, total.UnpricedRequests)
+	}
+	assertCost(t, "total cost", total.Cost, 0.0078)
+	assertCost(t, "total uncached cost", total.UncachedCost, 0.009)
+	assertCost(t, "total savings", total.Savings, 0.0012)
+	assertCost(t, "total savings percent", total.SavingsPercent, 13.33)
+	assertCost(t, "total cache hit rate", total.CacheHitRate, 0.2658)
+
+	modelA := report.APIs["a"].Models["m1"]
+	assertCost(t, "a/m1 cost", modelA.Cost, 0.0048)
+	assertCost(t, "a/m1 savings", modelA.Savings, 0.0012)
+	assertCost(t, "a/m1 savings percent", modelA.SavingsPercent, 20)
+	assertCost(t, "a/m1 cache hit rate", modelA.CacheHitRate, 0.4)
+	if modelB := report.APIs["b"].Models["m1"]; modelB.Requests != 1 || modelB.Savings != 0 {
+		t.Fatalf("unexpected b/m1 breakdown: %+v", modelB)
+	}
+	if len(report.UnpricedModels) != 1 || report.UnpricedModels[0] != "m2" {
+		t.Fatalf("unpriced models = %v, want [m2]", report.UnpricedModels)
+	}
+
+	filtered := stats.CostReport(CostFilter{APIKey: "b", Start: base.Add(time.Minute)}, pricing)
+	if filtered.Total.Requests != 0 || len(filtered.APIs) != 0 {
+		t.Fatalf("expected empty filtered report, got %+v", filtered)
+	}
+}
+
+func TestCostReportWithoutCachePriceHasNoSavings(t *testing.T) {
+	stats := NewRequestStatistics()
+	stats.Record(context.Background(), coreusage.Record{APIKey: "a", Model: "m", Provider: "codex", Detail: coreusage.Detail{InputTokens: 1000, CachedTokens: 500}})
+
+	report := stats.CostReport(CostFilter{}, func(string) *registry.ModelPricing {
+		return &registry.ModelPricing{Input: 1}
+	})
+	assertCost(t, "cost", report.Total.Cost, 0.001)
+	assertCost(t, "savings", report.Total.Savings, 0)
+}
+
+func assertCost(t *testing.T, name string, got, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > 1e-9 {
+		t.Fatalf("%s = %v, want %v", name, got, want)
+	}
+}
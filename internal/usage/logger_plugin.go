@@ -5,6 +5,10 @@ package usage
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"strconv"
 	"strings"
@@ -18,8 +22,18 @@ import (
 
 var statisticsEnabled atomic.Bool
 
+// anonymizationEnabled hashes API keys and credential sources before they are stored.
+var anonymizationEnabled atomic.Bool
+
+// anonymizationKey is the HMAC key of AnonymizeIdentifier. It starts as a random key for the
+// process and is replaced by the per-install key once LoadAnonymizationKey succeeds.
+var anonymizationKey atomic.Pointer[[]byte]
+
 func init() {
 	statisticsEnabled.Store(true)
+	key := make([]byte, anonymizationKeySize)
+	_, _ = rand.Read(key)
+	anonymizationKey.Store(&key)
 	coreusage.RegisterPlugin(NewLoggerPlugin())
 }
 
@@ -57,6 +71,22 @@ func SetStatisticsEnabled(enabled bool) { statisticsEnabled.Store(enabled) }
 // StatisticsEnabled reports the current recording state.
 func StatisticsEnabled() bool { return statisticsEnabled.Load() }
 
+// SetAnonymizationEnabled toggles hashing of API keys and credential sources in recorded statistics.
+func SetAnonymizationEnabled(enabled bool) { anonymizationEnabled.Store(enabled) }
+
+// AnonymizeIdentifier returns a stable, non-reversible identifier for value so that
+// aggregates can still be grouped by it. The identifier is an HMAC keyed with the per-install
+// secret, so low-entropy values such as e-mail addresses cannot be recovered by hashing
+// candidates. Empty values are returned unchanged.
+func AnonymizeIdentifier(value string) string {
+	if value == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, *anonymizationKey.Load())
+	mac.Write([]byte(value))
+	return "anon-" + hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
 // RequestStatistics maintains aggregated request metrics in memory.
 type RequestStatistics struct {
 	mu sync.RWMutex
@@ -72,13 +102,16 @@ type RequestStatistics struct {
 	requestsByHour map[int]int64
 	tokensByDay    map[string]int64
 	tokensByHour   map[int]int64
+
+	failuresByClass map[string]int64
 }
 
 // apiStats holds aggregated metrics for a single API key.
 type apiStats struct {
-	TotalRequests int64
-	TotalTokens   int64
-	Models        map[string]*modelStats
+	TotalRequests   int64
+	TotalTokens     int64
+	FailuresByClass map[string]int64
+	Models          map[string]*modelStats
 }
 
 // modelStats holds aggregated metrics for a specific model within an API.
@@ -90,12 +123,21 @@ type modelStats struct {
 
 // RequestDetail stores the timestamp, latency, and token usage for a single request.
 type RequestDetail struct {
-	Timestamp time.Time  `json:"timestamp"`
-	LatencyMs int64      `json:"latency_ms"`
-	Source    string     `json:"source"`
-	AuthIndex string     `json:"auth_index"`
-	Tokens    TokenStats `json:"tokens"`
-	Failed    bool       `json:"failed"`
+	Timestamp time.Time `json:"timestamp"`
+	LatencyMs int64     `json:"latency_ms"`
+	Source    string    `json:"source"`
+	AuthIndex string    `json:"auth_index"`
+	// Provider is the upstream provider that served the request; it tells how the provider
+	// reports cached and reasoning tokens when costs are computed.
+	Provider string     `json:"provider,omitempty"`
+	Tokens   TokenStats `json:"tokens"`
+	Failed   bool       `json:"failed"`
+	// FailureClass tells why a failed request failed (auth, rate_limit, timeout, ...).
+	FailureClass string `json:"failure_class,omitempty"`
+	// PolicyDenied marks requests rejected by a client API key policy.
+	PolicyDenied bool `json:"policy_denied,omitempty"`
+	// StreamTokensPerSecond is the output rate limit applied to the streamed response.
+	StreamTokensPerSecond float64 `json:"stream_tokens_per_second,omitempty"`
 }
 
 // TokenStats captures the token usage breakdown for a request.
@@ -120,6 +162,9 @@ type StatisticsSnapshot struct {
 	RequestsByHour map[string]int64 `json:"requests_by_hour"`
 	TokensByDay    map[string]int64 `json:"tokens_by_day"`
 	TokensByHour   map[string]int64 `json:"tokens_by_hour"`
+
+	// FailuresByClass counts failed requests per failure class.
+	FailuresByClass map[string]int64 `json:"failures_by_class,omitempty"`
 }
 
 type ExportPayload struct {
@@ -135,9 +180,10 @@ type ImportPayload struct {
 
 // APISnapshot summarises metrics for a single API key.
 type APISnapshot struct {
-	TotalRequests int64                    `json:"total_requests"`
-	TotalTokens   int64                    `json:"total_tokens"`
-	Models        map[string]ModelSnapshot `json:"models"`
+	TotalRequests   int64                    `json:"total_requests"`
+	TotalTokens     int64                    `json:"total_tokens"`
+	FailuresByClass map[string]int64         `json:"failures_by_class,omitempty"`
+	Models          map[string]ModelSnapshot `json:"models"`
 }
 
 // ModelSnapshot summarises metrics for a specific model.
@@ -155,11 +201,12 @@ func GetRequestStatistics() *RequestStatistics { return defaultRequestStatistics
 // NewRequestStatistics constructs an empty statistics store.
 func NewRequestStatistics() *RequestStatistics {
 	return &RequestStatistics{
-		apis:           make(map[string]*apiStats),
-		requestsByDay:  make(map[string]int64),
-		requestsByHour: make(map[int]int64),
-		tokensByDay:    make(map[string]int64),
-		tokensByHour:   make(map[int]int64),
+		apis:            make(map[string]*apiStats),
+		requestsByDay:   make(map[string]int64),
+		requestsByHour:  make(map[int]int64),
+		tokensByDay:     make(map[string]int64),
+		tokensByHour:    make(map[int]int64),
+		failuresByClass: make(map[string]int64),
 	}
 }
 
@@ -178,13 +225,24 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 	detail := normaliseDetail(record.Detail)
 	totalTokens := detail.TotalTokens
 	statsKey := record.APIKey
+	source := record.Source
+	if anonymizationEnabled.Load() {
+		statsKey = AnonymizeIdentifier(statsKey)
+		source = AnonymizeIdentifier(source)
+	}
 	if statsKey == "" {
 		statsKey = resolveAPIIdentifier(ctx, record)
 	}
 	failed := record.Failed
+	failureClass := record.FailureClass
 	if !failed {
 		failed = !resolveSuccess(ctx)
 	}
+	if !failed {
+		failureClass = ""
+	} else if failureClass == "" {
+		failureClass = resolveFailureClass(ctx)
+	}
 	success := !failed
 	modelName := record.Model
 	if modelName == "" {
@@ -210,12 +268,16 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 		s.apis[statsKey] = stats
 	}
 	s.updateAPIStats(stats, modelName, RequestDetail{
-		Timestamp: timestamp,
-		LatencyMs: normaliseLatency(record.Latency),
-		Source:    record.Source,
-		AuthIndex: record.AuthIndex,
-		Tokens:    detail,
-		Failed:    failed,
+		Timestamp:             timestamp,
+		LatencyMs:             normaliseLatency(record.Latency),
+		Source:                source,
+		AuthIndex:             record.AuthIndex,
+		Provider:              record.Provider,
+		Tokens:                detail,
+		Failed:                failed,
+		FailureClass:          failureClass,
+		PolicyDenied:          record.PolicyDenied,
+		StreamTokensPerSecond: record.StreamTokensPerSecond,
 	})
 
 	s.requestsByDay[dayKey]++
@@ -227,6 +289,17 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 func (s *RequestStatistics) updateAPIStats(stats *apiStats, model string, detail RequestDetail) {
 	stats.TotalRequests++
 	stats.TotalTokens += detail.Tokens.TotalTokens
+	if detail.Failed {
+		class := detail.FailureClass
+		if class == "" {
+			class = coreusage.FailureClassOther
+		}
+		if stats.FailuresByClass == nil {
+			stats.FailuresByClass = make(map[string]int64)
+		}
+		stats.FailuresByClass[class]++
+		s.failuresByClass[class]++
+	}
 	modelStatsValue, ok := stats.Models[model]
 	if !ok {
 		modelStatsValue = &modelStats{}
@@ -255,9 +328,10 @@ func (s *RequestStatistics) Snapshot() StatisticsSnapshot {
 	result.APIs = make(map[string]APISnapshot, len(s.apis))
 	for apiName, stats := range s.apis {
 		apiSnapshot := APISnapshot{
-			TotalRequests: stats.TotalRequests,
-			TotalTokens:   stats.TotalTokens,
-			Models:        make(map[string]ModelSnapshot, len(stats.Models)),
+			TotalRequests:   stats.TotalRequests,
+			TotalTokens:     stats.TotalTokens,
+			FailuresByClass: copyStringInt64Map(stats.FailuresByClass),
+			Models:          make(map[string]ModelSnapshot, len(stats.Models)),
 		}
 		for modelName, modelStatsValue := range stats.Models {
 			requestDetails := make([]RequestDetail, len(modelStatsValue.Details))
@@ -293,6 +367,8 @@ func (s *RequestStatistics) Snapshot() StatisticsSnapshot {
 		result.TokensByHour[key] = v
 	}
 
+	result.FailuresByClass = copyStringInt64Map(s.failuresByClass)
+
 	return result
 }
 
@@ -383,7 +459,11 @@ func (s *RequestStatistics) Replace(snapshot StatisticsSnapshot) {
 
 	s.apis = make(map[string]*apiStats, len(snapshot.APIs))
 	for apiName, apiSnapshot := range snapshot.APIs {
-		stats := &apiStats{TotalRequests: apiSnapshot.TotalRequests, TotalTokens: apiSnapshot.TotalTokens}
+		stats := &apiStats{
+			TotalRequests:   apiSnapshot.TotalRequests,
+			TotalTokens:     apiSnapshot.TotalTokens,
+			FailuresByClass: copyStringInt64Map(apiSnapshot.FailuresByClass),
+		}
 		if len(apiSnapshot.Models) > 0 {
 			stats.Models = make(map[string]*modelStats, len(apiSnapshot.Models))
 			for modelName, modelSnapshot := range apiSnapshot.Models {
@@ -406,6 +486,7 @@ func (s *RequestStatistics) Replace(snapshot StatisticsSnapshot) {
 	s.requestsByHour = copyHourSnapshot(snapshot.RequestsByHour)
 	s.tokensByDay = copyStringInt64Map(snapshot.TokensByDay)
 	s.tokensByHour = copyHourSnapshot(snapshot.TokensByHour)
+	s.failuresByClass = copyStringInt64Map(snapshot.FailuresByClass)
 }
 
 func copyStringInt64Map(src map[string]int64) map[string]int64 {
@@ -520,6 +601,19 @@ func resolveSuccess(ctx context.Context) bool {
 
 const httpStatusBadRequest = 400
 
+// resolveFailureClass classifies a failed request without an explicit class from the
+// response status.
+func resolveFailureClass(ctx context.Context) string {
+	if ctx != nil {
+		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+			if status := ginCtx.Writer.Status(); status >= httpStatusBadRequest {
+				return coreusage.FailureClassForStatus(status)
+			}
+		}
+	}
+	return coreusage.FailureClassOther
+}
+
 func normaliseDetail(detail coreusage.Detail) TokenStats {
 	tokens := TokenStats{
 		InputTokens:     detail.InputTokens,
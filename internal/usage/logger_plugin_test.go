@@ -2,6 +2,10 @@ package usage
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -94,3 +98,96 @@ func TestRequestStatisticsMergeSnapshotDedupIgnoresLatency(t *testing.T) {
 		t.Fatalf("details len = %d, want 1", len(details))
 	}
 }
+
+func TestRequestStatisticsRecordAnonymizesIdentifiers(t *testing.T) {
+	SetAnonymizationEnabled(true)
+	t.Cleanup(func() { SetAnonymizationEnabled(false) })
+
+	stats := NewRequestStatistics()
+	for i := 0; i < 2; i++ {
+		stats.Record(context.Background(), coreusage.Record{
+			APIKey:      "sk-secret",
+			Source:      "user@example.com",
+			Model:       "gpt-5.4",
+			RequestedAt: time.Date(2026, 3, 20, 12, 0, i, 0, time.UTC),
+			Detail:      coreusage.Detail{TotalTokens: 10},
+		})
+	}
+
+	snapshot := stats.Snapshot()
+	if _, ok := snapshot.APIs["sk-secret"]; ok {
+		t.Fatal("raw API key must not be used as a statistics key")
+	}
+	api, ok := snapshot.APIs[AnonymizeIdentifier("sk-secret")]
+	if !ok {
+		t.Fatalf("anonymized API key missing: %+v", snapshot.APIs)
+	}
+	if api.TotalRequests != 2 || api.TotalTokens != 20 {
+		t.Fatalf("aggregates = %d requests / %d tokens, want 2 / 20", api.TotalRequests, api.TotalTokens)
+	}
+	for _, detail := range api.Models["gpt-5.4"].Details {
+		if detail.Source != AnonymizeIdentifier("user@example.com") {
+			t.Fatalf("source = %q, want anonymized value", detail.Source)
+		}
+	}
+}
+
+func TestLoadAnonymizationKeyPersistsSecret(t *testing.T) {
+	previous := anonymizationKey.Load()
+	t.Cleanup(func() { anonymizationKey.Store(previous) })
+
+	unkeyed := AnonymizeIdentifier("user@example.com")
+	dir := t.TempDir()
+	if err := LoadAnonymizationKey(dir); err != nil {
+		t.Fatalf("LoadAnonymizationKey: %v", err)
+	}
+	first := AnonymizeIdentifier("user@example.com")
+	if first == unkeyed {
+		t.Fatal("identifier should depend on the install key")
+	}
+	sum := sha256.Sum256([]byte("user@example.com"))
+	if first == "anon-"+hex.EncodeToString(sum[:])[:16] {
+		t.Fatal("identifier must not be a plain SHA-256 of the value")
+	}
+
+	anonymizationKey.Store(previous)
+	if err := LoadAnonymizationKey(dir); err != nil {
+		t.Fatalf("reload LoadAnonymizationKey: %v", err)
+	}
+	if got := AnonymizeIdentifier("user@example.com"); got != first {
+		t.Fatalf("identifier after reload = %q, want %q", got, first)
+	}
+	if info, err := os.Stat(filepath.Join(dir, anonymizationKeyFileName)); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("key file = %v, %v; want mode 0600", info, err)
+	}
+}
+
+func TestRequestStatisticsCountsFailuresByClass(t *testing.T) {
+	stats := NewRequestStatistics()
+	for _, rec := range []coreusage.Record{
+		{APIKey: "a", Model: "m", Failed: true, FailureClass: coreusage.FailureClassRateLimit},
+		{APIKey: "a", Model: "m", Failed: true, FailureClass: coreusage.FailureClassRateLimit},
+		{APIKey: "b", Model: "m", Failed: true},
+		{APIKey: "b", Model: "m", FailureClass: coreusage.FailureClassAuth},
+	} {
+		stats.Record(context.Background(), rec)
+	}
+
+	snapshot := stats.Snapshot()
+	if got := snapshot.FailuresByClass; got[coreusage.FailureClassRateLimit] != 2 || got[coreusage.FailureClassOther] != 1 || len(got) != 2 {
+		t.Fatalf("failures by class = %v", got)
+	}
+	if got := snapshot.APIs["a"].FailuresByClass[coreusage.FailureClassRateLimit]; got != 2 {
+		t.Fatalf("api a rate_limit failures = %d, want 2", got)
+	}
+	details := snapshot.APIs["b"].Models["m"].Details
+	if details[0].FailureClass != coreusage.FailureClassOther || details[1].FailureClass != "" {
+		t.Fatalf("detail classes = %q, %q; want other and empty for the success", details[0].FailureClass, details[1].FailureClass)
+	}
+
+	restored := NewRequestStatistics()
+	restored.Replace(snapshot)
+	if got := restored.Snapshot().FailuresByClass[coreusage.FailureClassRateLimit]; got != 2 {
+		t.Fatalf("restored rate_limit failures = %d, want 2", got)
+	}
+}
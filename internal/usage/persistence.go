@@ -2,17 +2,25 @@ package usage
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 )
 
-const usageStatsFileName = "usage_stats.json"
+const (
+	usageStatsFileName       = "usage_stats.json"
+	anonymizationKeyFileName = "anonymization.key"
+	anonymizationKeySize     = 32
+)
 
 var persistenceMu sync.Mutex
 
@@ -24,6 +32,52 @@ func StatsFilePath(authDir string) string {
 	return filepath.Join(authDir, usageStatsFileName)
 }
 
+// LoadAnonymizationKey loads the per-install secret used by AnonymizeIdentifier from auth dir,
+// creating it on first use so anonymized identifiers stay stable across restarts.
+func LoadAnonymizationKey(authDir string) error {
+	if authDir == "" {
+		return fmt.Errorf("auth-dir is empty")
+	}
+	path := filepath.Join(authDir, anonymizationKeyFileName)
+	data, err := os.ReadFile(path)
+	if err == nil {
+		key, errDecode := hex.DecodeString(strings.TrimSpace(string(data)))
+		if errDecode != nil || len(key) < anonymizationKeySize {
+			return fmt.Errorf("invalid anonymization key in %s", path)
+		}
+		anonymizationKey.Store(&key)
+		return nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read anonymization key: %w", err)
+	}
+
+	key := make([]byte, anonymizationKeySize)
+	if _, err = rand.Read(key); err != nil {
+		return fmt.Errorf("failed to generate anonymization key: %w", err)
+	}
+	if err = os.MkdirAll(authDir, 0o700); err != nil {
+		return fmt.Errorf("failed to create auth dir: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		// Another process created the key first; use theirs.
+		return LoadAnonymizationKey(authDir)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create anonymization key: %w", err)
+	}
+	_, errWrite := file.WriteString(hex.EncodeToString(key) + "\n")
+	if errClose := file.Close(); errWrite == nil {
+		errWrite = errClose
+	}
+	if errWrite != nil {
+		return fmt.Errorf("failed to write anonymization key: %w", errWrite)
+	}
+	anonymizationKey.Store(&key)
+	return nil
+}
+
 // LoadFromFile replaces the in-memory statistics with snapshot loaded from disk.
 func (s *RequestStatistics) LoadFromFile(path string) error {
 	if s == nil || path == "" {
@@ -0,0 +1,130 @@
+package usage
+
+import (
+	"fmt"
+	"time"
+)
+
+// MaxTimeSeriesBuckets bounds the number of buckets a single time-series query may return.
+const MaxTimeSeriesBuckets = 2000
+
+// timeSeriesIntervals maps supported interval names to their bucket width.
+var timeSeriesIntervals = map[string]time.Duration{
+	"5m": 5 * time.Minute,
+	"1h": time.Hour,
+	"1d": 24 * time.Hour,
+}
+
+// timeSeriesDefaultWindows defines the lookback applied when no start time is given.
+var timeSeriesDefaultWindows = map[string]time.Duration{
+	"5m": 24 * time.Hour,
+	"1h": 7 * 24 * time.Hour,
+	"1d": 30 * 24 * time.Hour,
+}
+
+// TimeSeriesFilter selects the request details aggregated into a time series.
+// Empty string fields match every value.
+type TimeSeriesFilter struct {
+	Interval  string
+	Start     time.Time
+	End       time.Time
+	APIKey    string
+	Model     string
+	Source    string
+	AuthIndex string
+}
+
+// TimeSeriesBucket aggregates the requests that started within one interval.
+type TimeSeriesBucket struct {
+	Start        time.Time  `json:"start"`
+	Requests     int64      `json:"requests"`
+	SuccessCount int64      `json:"success_count"`
+	FailureCount int64      `json:"failure_count"`
+	Tokens       TokenStats `json:"tokens"`
+}
+
+// TimeSeries is a contiguous, zero-filled series of buckets in UTC.
+type TimeSeries struct {
+	Interval string             `json:"interval"`
+	Start    time.Time          `json:"start"`
+	End      time.Time          `json:"end"`
+	Buckets  []TimeSeriesBucket `json:"buckets"`
+}
+
+// TimeSeries buckets the retained request details matching filter. Start is aligned down to
+// the interval boundary and End is exclusive. When Start is zero a default lookback for the
+// interval is used; when End is zero the current time is used.
+func (s *RequestStatistics) TimeSeries(filter TimeSeriesFilter) (TimeSeries, error) {
+	width, ok := timeSeriesIntervals[filter.Interval]
+	if !ok {
+		return TimeSeries{}, fmt.Errorf("unsupported interval %q (expected 5m, 1h or 1d)", filter.Interval)
+	}
+	end := filter.End.UTC()
+	if filter.End.IsZero() {
+		end = time.Now().UTC()
+	}
+	start := filter.Start.UTC()
+	if filter.Start.IsZero() {
+		start = end.Add(-timeSeriesDefaultWindows[filter.Interval])
+	}
+	start = start.Truncate(width)
+	if !end.After(start) {
+		return TimeSeries{}, fmt.Errorf("end must be after start")
+	}
+	count := int((end.Sub(start) + width - 1) / width)
+	if count > MaxTimeSeriesBuckets {
+		return TimeSeries{}, fmt.Errorf("range spans %d buckets, maximum is %d", count, MaxTimeSeriesBuckets)
+	}
+
+	series := TimeSeries{
+		Interval: filter.Interval,
+		Start:    start,
+		End:      end,
+		Buckets:  make([]TimeSeriesBucket, count),
+	}
+	for i := range series.Buckets {
+		series.Buckets[i].Start = start.Add(time.Duration(i) * width)
+	}
+	if s == nil {
+		return series, nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for apiName, stats := range s.apis {
+		if filter.APIKey != "" && apiName != filter.APIKey {
+			continue
+		}
+		for modelName, modelStatsValue := range stats.Models {
+			if filter.Model != "" && modelName != filter.Model {
+				continue
+			}
+			for _, detail := range modelStatsValue.Details {
+				if filter.Source != "" && detail.Source != filter.Source {
+					continue
+				}
+				if filter.AuthIndex != "" && detail.AuthIndex != filter.AuthIndex {
+					continue
+				}
+				ts := detail.Timestamp.UTC()
+				if ts.Before(start) || !ts.Before(end) {
+					continue
+				}
+				bucket := &series.Buckets[int(ts.Sub(start)/width)]
+				bucket.Requests++
+				if detail.Failed {
+					bucket.FailureCount++
+				} else {
+					bucket.SuccessCount++
+				}
+				bucket.Tokens.InputTokens += detail.Tokens.InputTokens
+				bucket.Tokens.OutputTokens += detail.Tokens.OutputTokens
+				bucket.Tokens.ReasoningTokens += detail.Tokens.ReasoningTokens
+				bucket.Tokens.CachedTokens += detail.Tokens.CachedTokens
+				bucket.Tokens.TotalTokens += detail.Tokens.TotalTokens
+			}
+		}
+	}
+	return series, nil
+}
@@ -0,0 +1,67 @@
+package usage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestRequestStatisticsTimeSeriesBuckets(t *testing.T) {
+	base := time.Date(2026, 3, 20, 12, 0, 0, 0, time.UTC)
+	stats := NewRequestStatistics()
+	for _, rec := range []coreusage.Record{
+		{APIKey: "a", Model: "m1", RequestedAt: base.Add(time.Minute), Detail: coreusage.Detail{InputTokens: 1, TotalTokens: 3}},
+		{APIKey: "a", Model: "m1", RequestedAt: base.Add(4 * time.Minute), Failed: true, Detail: coreusage.Detail{TotalTokens: 2}},
+		{APIKey: "a", Model: "m2", RequestedAt: base.Add(11 * time.Minute), Detail: coreusage.Detail{TotalTokens: 5}},
+		{APIKey: "b", Model: "m1", RequestedAt: base.Add(2 * time.Minute), Detail: coreusage.Detail{TotalTokens: 7}},
+	} {
+		stats.Record(context.Background(), rec)
+	}
+
+	series, err := stats.TimeSeries(TimeSeriesFilter{
+		Interval: "5m",
+		Start:    base.Add(2 * time.Second),
+		End:      base.Add(15 * time.Minute),
+		APIKey:   "a",
+	})
+	if err != nil {
+		t.Fatalf("TimeSeries: %v", err)
+	}
+	if !series.Start.Equal(base) || len(series.Buckets) != 3 {
+		t.Fatalf("start = %v, buckets = %d; want %v and 3", series.Start, len(series.Buckets), base)
+	}
+	first, second, third := series.Buckets[0], series.Buckets[1], series.Buckets[2]
+	if first.Requests != 2 || first.SuccessCount != 1 || first.FailureCount != 1 || first.Tokens.TotalTokens != 5 || first.Tokens.InputTokens != 1 {
+		t.Fatalf("unexpected first bucket: %+v", first)
+	}
+	if second.Requests != 0 || !second.Start.Equal(base.Add(5*time.Minute)) {
+		t.Fatalf("empty bucket not zero-filled: %+v", second)
+	}
+	if third.Requests != 1 || third.Tokens.TotalTokens != 5 {
+		t.Fatalf("unexpected third bucket: %+v", third)
+	}
+
+	series, err = stats.TimeSeries(TimeSeriesFilter{Interval: "1h", Start: base, End: base.Add(time.Hour), Model: "m1"})
+	if err != nil {
+		t.Fatalf("TimeSeries: %v", err)
+	}
+	if len(series.Buckets) != 1 || series.Buckets[0].Requests != 3 || series.Buckets[0].Tokens.TotalTokens != 12 {
+		t.Fatalf("unexpected model-filtered series: %+v", series.Buckets)
+	}
+}
+
+func TestRequestStatisticsTimeSeriesRejectsInvalidRanges(t *testing.T) {
+	stats := NewRequestStatistics()
+	now := time.Now()
+	for _, filter := range []TimeSeriesFilter{
+		{Interval: "1w"},
+		{Interval: "5m", Start: now, End: now.Add(-time.Hour)},
+		{Interval: "5m", Start: now.Add(-365 * 24 * time.Hour), End: now},
+	} {
+		if _, err := stats.TimeSeries(filter); err == nil {
+			t.Fatalf("expected error for %+v", filter)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package util
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// CloseTruncatedJSON performs a best-effort repair of a JSON document that was cut off
+// mid-stream, such as tool call arguments from an interrupted upstream response.
+// Open strings are terminated, dangling keys, separators and partial literals are
+// dropped, and unclosed objects and arrays are closed. It returns the input unchanged
+// and false when it is already valid JSON; otherwise it returns a syntactically valid
+// document (falling back to "{}") and true.
+func CloseTruncatedJSON(input string) (string, bool) {
+	trimmed := strings.TrimSpace(input)
+	if trimmed == "" {
+		return "{}", true
+	}
+	if json.Valid([]byte(trimmed)) {
+		return input, false
+	}
+
+	type frame struct {
+		closer    byte
+		expectKey bool
+	}
+	var stack []frame
+	inString := false
+	stringIsKey := false
+	escaped := false
+	// safeEnd marks a prefix that forms valid JSON once safeClosers are appended.
+	safeEnd := 0
+	safeClosers := ""
+
+	closers := func() string {
+		var sb strings.Builder
+		for i := len(stack) - 1; i >= 0; i-- {
+			sb.WriteByte(stack[i].closer)
+		}
+		return sb.String()
+	}
+	markSafe := func(end int) {
+		safeEnd = end
+		safeClosers = closers()
+	}
+
+	for i := 0; i < len(trimmed); i++ {
+		c := trimmed[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+				if !stringIsKey {
+					markSafe(i + 1)
+				}
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+			stringIsKey = len(stack) > 0 && stack[len(stack)-1].closer == '}' && stack[len(stack)-1].expectKey
+		case '{':
+			stack = append(stack, frame{closer: '}', expectKey: true})
+			markSafe(i + 1)
+		case '[':
+			stack = append(stack, frame{closer: ']'})
+			markSafe(i + 1)
+		case '}', ']':
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			markSafe(i + 1)
+		case ':':
+			if len(stack) > 0 {
+				stack[len(stack)-1].expectKey = false
+			}
+		case ',':
+			// The value before a separator is complete.
+			markSafe(i)
+			if len(stack) > 0 && stack[len(stack)-1].closer == '}' {
+				stack[len(stack)-1].expectKey = true
+			}
+		}
+	}
+
+	var candidates []string
+	if inString && !stringIsKey {
+		body := trimmed
+		if escaped {
+			body = body[:len(body)-1]
+		}
+		// Drop an incomplete \uXXXX escape.
+		if idx := strings.LastIndex(body, `\u`); idx >= 0 && len(body)-idx < 6 && (idx == 0 || body[idx-1] != '\\') {
+			body = body[:idx]
+		}
+		candidates = append(candidates, body+`"`+closers())
+	} else if !inString {
+		candidates = append(candidates, trimmed+closers())
+	}
+	candidates = append(candidates, trimmed[:safeEnd]+safeClosers)
+
+	for _, candidate := range candidates {
+		if json.Valid([]byte(candidate)) {
+			return candidate, true
+		}
+	}
+	return "{}", true
+}
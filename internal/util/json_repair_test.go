@@ -0,0 +1,30 @@
+package util
+
+import "testing"
+
+func TestCloseTruncatedJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		want      string
+		truncated bool
+	}{
+		{name: "valid", input: `{"a":1}`, want: `{"a":1}`},
+		{name: "open string value", input: `{"path":"/tmp/fi`, want: `{"path":"/tmp/fi"}`, truncated: true},
+		{name: "nested", input: `{"a":[1,2,{"b":"c"`, want: `{"a":[1,2,{"b":"c"}]}`, truncated: true},
+		{name: "dangling key", input: `{"a":1,"b`, want: `{"a":1}`, truncated: true},
+		{name: "dangling colon", input: `{"a":1,"b":`, want: `{"a":1}`, truncated: true},
+		{name: "partial literal", input: `{"a":"x","b":tr`, want: `{"a":"x"}`, truncated: true},
+		{name: "trailing escape", input: `{"a":"line\`, want: `{"a":"line"}`, truncated: true},
+		{name: "partial unicode escape", input: `{"a":"x\u00`, want: `{"a":"x"}`, truncated: true},
+		{name: "empty", input: ``, want: `{}`, truncated: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, truncated := CloseTruncatedJSON(tt.input)
+			if got != tt.want || truncated != tt.truncated {
+				t.Fatalf("CloseTruncatedJSON(%q) = %q, %v; want %q, %v", tt.input, got, truncated, tt.want, tt.truncated)
+			}
+		})
+	}
+}
@@ -63,6 +63,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.MaxRetryInterval != newCfg.MaxRetryInterval {
 		changes = append(changes, fmt.Sprintf("max-retry-interval: %d -> %d", oldCfg.MaxRetryInterval, newCfg.MaxRetryInterval))
 	}
+	if !reflect.DeepEqual(oldCfg.UpstreamRetry, newCfg.UpstreamRetry) {
+		changes = append(changes, fmt.Sprintf("upstream-retry: max-attempts %d -> %d", oldCfg.UpstreamRetry.MaxAttempts, newCfg.UpstreamRetry.MaxAttempts))
+	}
 	if oldCfg.ProxyURL != newCfg.ProxyURL {
 		changes = append(changes, fmt.Sprintf("proxy-url: %s -> %s", formatProxyURL(oldCfg.ProxyURL), formatProxyURL(newCfg.ProxyURL)))
 	}
@@ -106,6 +109,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if strings.TrimSpace(o.BaseURL) != strings.TrimSpace(n.BaseURL) {
 				changes = append(changes, fmt.Sprintf("gemini[%d].base-url: %s -> %s", i, strings.TrimSpace(o.BaseURL), strings.TrimSpace(n.BaseURL)))
 			}
+			if !reflect.DeepEqual(trimStrings(o.FallbackBaseURLs), trimStrings(n.FallbackBaseURLs)) {
+				changes = append(changes, fmt.Sprintf("gemini[%d].fallback-base-urls: %v -> %v", i, trimStrings(o.FallbackBaseURLs), trimStrings(n.FallbackBaseURLs)))
+			}
 			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
 				changes = append(changes, fmt.Sprintf("gemini[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
 			}
@@ -141,6 +147,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if strings.TrimSpace(o.BaseURL) != strings.TrimSpace(n.BaseURL) {
 				changes = append(changes, fmt.Sprintf("claude[%d].base-url: %s -> %s", i, strings.TrimSpace(o.BaseURL), strings.TrimSpace(n.BaseURL)))
 			}
+			if !reflect.DeepEqual(trimStrings(o.FallbackBaseURLs), trimStrings(n.FallbackBaseURLs)) {
+				changes = append(changes, fmt.Sprintf("claude[%d].fallback-base-urls: %v -> %v", i, trimStrings(o.FallbackBaseURLs), trimStrings(n.FallbackBaseURLs)))
+			}
 			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
 				changes = append(changes, fmt.Sprintf("claude[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
 			}
@@ -187,6 +196,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if strings.TrimSpace(o.BaseURL) != strings.TrimSpace(n.BaseURL) {
 				changes = append(changes, fmt.Sprintf("codex[%d].base-url: %s -> %s", i, strings.TrimSpace(o.BaseURL), strings.TrimSpace(n.BaseURL)))
 			}
+			if !reflect.DeepEqual(trimStrings(o.FallbackBaseURLs), trimStrings(n.FallbackBaseURLs)) {
+				changes = append(changes, fmt.Sprintf("codex[%d].fallback-base-urls: %v -> %v", i, trimStrings(o.FallbackBaseURLs), trimStrings(n.FallbackBaseURLs)))
+			}
 			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
 				changes = append(changes, fmt.Sprintf("codex[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
 			}
@@ -324,6 +336,137 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 		}
 	}
 
+	// Vertex credentials (service account key files or Application Default Credentials)
+	if len(oldCfg.VertexCredentials) != len(newCfg.VertexCredentials) {
+		changes = append(changes, fmt.Sprintf("vertex credentials count: %d -> %d", len(oldCfg.VertexCredentials), len(newCfg.VertexCredentials)))
+	} else {
+		for i := range oldCfg.VertexCredentials {
+			o := oldCfg.VertexCredentials[i]
+			n := newCfg.VertexCredentials[i]
+			if strings.TrimSpace(o.ProjectID) != strings.TrimSpace(n.ProjectID) {
+				changes = append(changes, fmt.Sprintf("vertex-credential[%d].project-id: %s -> %s", i, strings.TrimSpace(o.ProjectID), strings.TrimSpace(n.ProjectID)))
+			}
+			if strings.TrimSpace(o.Location) != strings.TrimSpace(n.Location) {
+				changes = append(changes, fmt.Sprintf("vertex-credential[%d].location: %s -> %s", i, strings.TrimSpace(o.Location), strings.TrimSpace(n.Location)))
+			}
+			if strings.TrimSpace(o.CredentialsFile) != strings.TrimSpace(n.CredentialsFile) {
+				changes = append(changes, fmt.Sprintf("vertex-credential[%d].credentials-file: %s -> %s", i, strings.TrimSpace(o.CredentialsFile), strings.TrimSpace(n.CredentialsFile)))
+			}
+			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
+				changes = append(changes, fmt.Sprintf("vertex-credential[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
+			}
+			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
+				changes = append(changes, fmt.Sprintf("vertex-credential[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
+			}
+			oldModels := SummarizeVertexModels(o.Models)
+			newModels := SummarizeVertexModels(n.Models)
+			if oldModels.hash != newModels.hash {
+				changes = append(changes, fmt.Sprintf("vertex-credential[%d].models: updated (%d -> %d entries)", i, oldModels.count, newModels.count))
+			}
+			oldExcluded := SummarizeExcludedModels(o.ExcludedModels)
+			newExcluded := SummarizeExcludedModels(n.ExcludedModels)
+			if oldExcluded.hash != newExcluded.hash {
+				changes = append(changes, fmt.Sprintf("vertex-credential[%d].excluded-models: updated (%d -> %d entries)", i, oldExcluded.count, newExcluded.count))
+			}
+			if !equalStringMap(o.Headers, n.Headers) {
+				changes = append(changes, fmt.Sprintf("vertex-credential[%d].headers: updated", i))
+			}
+		}
+	}
+
+	// Azure OpenAI keys
+	if len(oldCfg.AzureOpenAIKey) != len(newCfg.AzureOpenAIKey) {
+		changes = append(changes, fmt.Sprintf("azure-openai count: %d -> %d", len(oldCfg.AzureOpenAIKey), len(newCfg.AzureOpenAIKey)))
+	} else {
+		for i := range oldCfg.AzureOpenAIKey {
+			o := oldCfg.AzureOpenAIKey[i]
+			n := newCfg.AzureOpenAIKey[i]
+			if strings.TrimSpace(o.BaseURL) != strings.TrimSpace(n.BaseURL) {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].base-url: %s -> %s", i, strings.TrimSpace(o.BaseURL), strings.TrimSpace(n.BaseURL)))
+			}
+			if strings.TrimSpace(o.APIVersion) != strings.TrimSpace(n.APIVersion) {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].api-version: %s -> %s", i, strings.TrimSpace(o.APIVersion), strings.TrimSpace(n.APIVersion)))
+			}
+			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
+			}
+			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
+			}
+			if strings.TrimSpace(o.APIKey) != strings.TrimSpace(n.APIKey) {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].api-key: updated", i))
+			}
+			if ComputeAzureOpenAIModelsHash(o.Models) != ComputeAzureOpenAIModelsHash(n.Models) {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].models: updated (%d -> %d entries)", i, len(o.Models), len(n.Models)))
+			}
+			if !equalStringMap(o.Headers, n.Headers) {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].headers: updated", i))
+			}
+		}
+	}
+
+	// Bedrock credentials
+	if len(oldCfg.BedrockKey) != len(newCfg.BedrockKey) {
+		changes = append(changes, fmt.Sprintf("bedrock count: %d -> %d", len(oldCfg.BedrockKey), len(newCfg.BedrockKey)))
+	} else {
+		for i := range oldCfg.BedrockKey {
+			o := oldCfg.BedrockKey[i]
+			n := newCfg.BedrockKey[i]
+			if strings.TrimSpace(o.Region) != strings.TrimSpace(n.Region) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].region: %s -> %s", i, strings.TrimSpace(o.Region), strings.TrimSpace(n.Region)))
+			}
+			if strings.TrimSpace(o.BaseURL) != strings.TrimSpace(n.BaseURL) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].base-url: %s -> %s", i, strings.TrimSpace(o.BaseURL), strings.TrimSpace(n.BaseURL)))
+			}
+			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
+			}
+			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
+			}
+			if o.AccessKeyID != n.AccessKeyID || o.SecretAccessKey != n.SecretAccessKey || o.SessionToken != n.SessionToken || o.APIKey != n.APIKey {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].credentials: updated", i))
+			}
+			if ComputeBedrockModelsHash(o.Models) != ComputeBedrockModelsHash(n.Models) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].models: updated (%d -> %d entries)", i, len(o.Models), len(n.Models)))
+			}
+			if !equalStringMap(o.Headers, n.Headers) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].headers: updated", i))
+			}
+		}
+	}
+
+	// Ollama servers
+	if len(oldCfg.OllamaKey) != len(newCfg.OllamaKey) {
+		changes = append(changes, fmt.Sprintf("ollama count: %d -> %d", len(oldCfg.OllamaKey), len(newCfg.OllamaKey)))
+	} else {
+		for i := range oldCfg.OllamaKey {
+			o := oldCfg.OllamaKey[i]
+			n := newCfg.OllamaKey[i]
+			if strings.TrimSpace(o.BaseURL) != strings.TrimSpace(n.BaseURL) {
+				changes = append(changes, fmt.Sprintf("ollama[%d].base-url: %s -> %s", i, strings.TrimSpace(o.BaseURL), strings.TrimSpace(n.BaseURL)))
+			}
+			if o.AutoPull != n.AutoPull {
+				changes = append(changes, fmt.Sprintf("ollama[%d].auto-pull: %t -> %t", i, o.AutoPull, n.AutoPull))
+			}
+			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
+				changes = append(changes, fmt.Sprintf("ollama[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
+			}
+			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
+				changes = append(changes, fmt.Sprintf("ollama[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
+			}
+			if strings.TrimSpace(o.APIKey) != strings.TrimSpace(n.APIKey) {
+				changes = append(changes, fmt.Sprintf("ollama[%d].api-key: updated", i))
+			}
+			if ComputeOllamaModelsHash(o.Models) != ComputeOllamaModelsHash(n.Models) {
+				changes = append(changes, fmt.Sprintf("ollama[%d].models: updated (%d -> %d entries)", i, len(o.Models), len(n.Models)))
+			}
+			if !equalStringMap(o.Headers, n.Headers) {
+				changes = append(changes, fmt.Sprintf("ollama[%d].headers: updated", i))
+			}
+		}
+	}
+
 	return changes
 }
 
@@ -71,6 +71,51 @@ func ComputeCodexModelsHash(models []config.CodexModel) string {
 	return hashJoined(keys)
 }
 
+// ComputeAzureOpenAIModelsHash returns a stable hash for Azure OpenAI deployment aliases.
+func ComputeAzureOpenAIModelsHash(models []config.AzureOpenAIModel) string {
+	keys := normalizeModelPairs(func(out func(key string)) {
+		for _, model := range models {
+			deployment := strings.TrimSpace(model.Deployment)
+			alias := strings.TrimSpace(model.Alias)
+			if deployment == "" && alias == "" {
+				continue
+			}
+			out(strings.ToLower(deployment) + "|" + strings.ToLower(alias))
+		}
+	})
+	return hashJoined(keys)
+}
+
+// ComputeBedrockModelsHash returns a stable hash for Bedrock model aliases.
+func ComputeBedrockModelsHash(models []config.BedrockModel) string {
+	keys := normalizeModelPairs(func(out func(key string)) {
+		for _, model := range models {
+			name := strings.TrimSpace(model.Name)
+			alias := strings.TrimSpace(model.Alias)
+			if name == "" && alias == "" {
+				continue
+			}
+			out(strings.ToLower(name) + "|" + strings.ToLower(alias))
+		}
+	})
+	return hashJoined(keys)
+}
+
+// ComputeOllamaModelsHash returns a stable hash for Ollama model aliases and endpoints.
+func ComputeOllamaModelsHash(models []config.OllamaModel) string {
+	keys := normalizeModelPairs(func(out func(key string)) {
+		for _, model := range models {
+			name := strings.TrimSpace(model.Name)
+			alias := strings.TrimSpace(model.Alias)
+			if name == "" && alias == "" {
+				continue
+			}
+			out(strings.ToLower(name) + "|" + strings.ToLower(alias) + "|" + strings.ToLower(strings.TrimSpace(model.Endpoint)))
+		}
+	})
+	return hashJoined(keys)
+}
+
 // ComputeGeminiModelsHash returns a stable hash for Gemini model aliases.
 func ComputeGeminiModelsHash(models []config.GeminiModel) string {
 	keys := normalizeModelPairs(func(out func(key string)) {
@@ -145,6 +145,9 @@ func openAICompatSignature(entry config.OpenAICompatibility) string {
 	if v := strings.TrimSpace(entry.BaseURL); v != "" {
 		parts = append(parts, "base="+v)
 	}
+	if fallbacks := trimStrings(entry.FallbackBaseURLs); len(fallbacks) > 0 {
+		parts = append(parts, "fallbacks="+strings.Join(fallbacks, ","))
+	}
 
 	models := make([]string, 0, len(entry.Models))
 	for _, model := range entry.Models {
@@ -10,7 +10,7 @@ import (
 )
 
 // ConfigSynthesizer generates Auth entries from configuration API keys.
-// It handles Gemini, Claude, Codex, OpenAI-compat, and Vertex-compat providers.
+// It handles Gemini, Claude, Codex, OpenAI-compat, Vertex-compat, and mock providers.
 type ConfigSynthesizer struct{}
 
 // NewConfigSynthesizer creates a new ConfigSynthesizer instance.
@@ -35,6 +35,16 @@ func (s *ConfigSynthesizer) Synthesize(ctx *SynthesisContext) ([]*coreauth.Auth,
 	out = append(out, s.synthesizeOpenAICompat(ctx)...)
 	// Vertex-compat
 	out = append(out, s.synthesizeVertexCompat(ctx)...)
+	// Vertex AI projects with OAuth credentials
+	out = append(out, s.synthesizeVertexCredentials(ctx)...)
+	// Azure OpenAI
+	out = append(out, s.synthesizeAzureOpenAIKeys(ctx)...)
+	// AWS Bedrock
+	out = append(out, s.synthesizeBedrockKeys(ctx)...)
+	// Ollama
+	out = append(out, s.synthesizeOllamaKeys(ctx)...)
+	// Mock providers
+	out = append(out, s.synthesizeMockProviders(ctx)...)
 
 	return out, nil
 }
@@ -69,6 +79,7 @@ func (s *ConfigSynthesizer) synthesizeGeminiKeys(ctx *SynthesisContext) []*corea
 		if hash := diff.ComputeGeminiModelsHash(entry.Models); hash != "" {
 			attrs["models_hash"] = hash
 		}
+		addFallbackBaseURLsToAttrs(entry.FallbackBaseURLs, attrs)
 		addConfigHeadersToAttrs(entry.Headers, attrs)
 		a := &coreauth.Auth{
 			ID:         id,
@@ -116,6 +127,7 @@ func (s *ConfigSynthesizer) synthesizeClaudeKeys(ctx *SynthesisContext) []*corea
 		if hash := diff.ComputeClaudeModelsHash(ck.Models); hash != "" {
 			attrs["models_hash"] = hash
 		}
+		addFallbackBaseURLsToAttrs(ck.FallbackBaseURLs, attrs)
 		addConfigHeadersToAttrs(ck.Headers, attrs)
 		proxyURL := strings.TrimSpace(ck.ProxyURL)
 		a := &coreauth.Auth{
@@ -166,6 +178,7 @@ func (s *ConfigSynthesizer) synthesizeCodexKeys(ctx *SynthesisContext) []*coreau
 		if hash := diff.ComputeCodexModelsHash(ck.Models); hash != "" {
 			attrs["models_hash"] = hash
 		}
+		addFallbackBaseURLsToAttrs(ck.FallbackBaseURLs, attrs)
 		addConfigHeadersToAttrs(ck.Headers, attrs)
 		proxyURL := strings.TrimSpace(ck.ProxyURL)
 		a := &coreauth.Auth{
@@ -227,6 +240,7 @@ func (s *ConfigSynthesizer) synthesizeOpenAICompat(ctx *SynthesisContext) []*cor
 			if hash := diff.ComputeOpenAICompatModelsHash(compat.Models); hash != "" {
 				attrs["models_hash"] = hash
 			}
+			addFallbackBaseURLsToAttrs(compat.FallbackBaseURLs, attrs)
 			addConfigHeadersToAttrs(compat.Headers, attrs)
 			a := &coreauth.Auth{
 				ID:         id,
@@ -258,6 +272,7 @@ func (s *ConfigSynthesizer) synthesizeOpenAICompat(ctx *SynthesisContext) []*cor
 			if hash := diff.ComputeOpenAICompatModelsHash(compat.Models); hash != "" {
 				attrs["models_hash"] = hash
 			}
+			addFallbackBaseURLsToAttrs(compat.FallbackBaseURLs, attrs)
 			addConfigHeadersToAttrs(compat.Headers, attrs)
 			a := &coreauth.Auth{
 				ID:         id,
@@ -323,3 +338,242 @@ func (s *ConfigSynthesizer) synthesizeVertexCompat(ctx *SynthesisContext) []*cor
 	}
 	return out
 }
+
+// vertexTokenRefreshIntervalSeconds bounds the age of Vertex OAuth tokens when their expiry
+// is unknown; Google access tokens last one hour.
+const vertexTokenRefreshIntervalSeconds = 45 * 60
+
+// synthesizeVertexCredentials creates Auth entries for Vertex AI projects reached with a service
+// account key file or Application Default Credentials.
+func (s *ConfigSynthesizer) synthesizeVertexCredentials(ctx *SynthesisContext) []*coreauth.Auth {
+	cfg := ctx.Config
+	now := ctx.Now
+	idGen := ctx.IDGenerator
+
+	out := make([]*coreauth.Auth, 0, len(cfg.VertexCredentials))
+	for i := range cfg.VertexCredentials {
+		entry := &cfg.VertexCredentials[i]
+		projectID := strings.TrimSpace(entry.ProjectID)
+		if projectID == "" {
+			continue
+		}
+		location := strings.TrimSpace(entry.Location)
+		credentialsFile := strings.TrimSpace(entry.CredentialsFile)
+		proxyURL := strings.TrimSpace(entry.ProxyURL)
+		id, token := idGen.Next("vertex:credential", projectID, location, credentialsFile, proxyURL)
+		attrs := map[string]string{
+			"source":     fmt.Sprintf("config:vertex[%s]", token),
+			"project_id": projectID,
+			"location":   location,
+		}
+		if credentialsFile != "" {
+			attrs["credentials_file"] = credentialsFile
+		}
+		// Mint the first access token at startup; the executor schedules later renewals
+		// from the token expiry.
+		attrs["refresh_interval_seconds"] = strconv.Itoa(vertexTokenRefreshIntervalSeconds)
+		if entry.Priority != 0 {
+			attrs["priority"] = strconv.Itoa(entry.Priority)
+		}
+		if hash := diff.ComputeVertexCompatModelsHash(entry.Models); hash != "" {
+			attrs["models_hash"] = hash
+		}
+		addConfigHeadersToAttrs(entry.Headers, attrs)
+		a := &coreauth.Auth{
+			ID:         id,
+			Provider:   "vertex",
+			Label:      "vertex-" + projectID,
+			Prefix:     strings.TrimSpace(entry.Prefix),
+			Status:     coreauth.StatusActive,
+			ProxyURL:   proxyURL,
+			Attributes: attrs,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		ApplyAuthExcludedModelsMeta(a, cfg, entry.ExcludedModels, "oauth")
+		out = append(out, a)
+	}
+	return out
+}
+
+// synthesizeAzureOpenAIKeys creates Auth entries for Azure OpenAI resources.
+func (s *ConfigSynthesizer) synthesizeAzureOpenAIKeys(ctx *SynthesisContext) []*coreauth.Auth {
+	cfg := ctx.Config
+	now := ctx.Now
+	idGen := ctx.IDGenerator
+
+	out := make([]*coreauth.Auth, 0, len(cfg.AzureOpenAIKey))
+	for i := range cfg.AzureOpenAIKey {
+		entry := &cfg.AzureOpenAIKey[i]
+		key := strings.TrimSpace(entry.APIKey)
+		base := strings.TrimSpace(entry.BaseURL)
+		if key == "" || base == "" {
+			continue
+		}
+		proxyURL := strings.TrimSpace(entry.ProxyURL)
+		id, token := idGen.Next("azure-openai:apikey", key, base, proxyURL)
+		attrs := map[string]string{
+			"source":   fmt.Sprintf("config:azure-openai[%s]", token),
+			"base_url": base,
+			"api_key":  key,
+		}
+		if version := strings.TrimSpace(entry.APIVersion); version != "" {
+			attrs["api_version"] = version
+		}
+		if entry.Priority != 0 {
+			attrs["priority"] = strconv.Itoa(entry.Priority)
+		}
+		if hash := diff.ComputeAzureOpenAIModelsHash(entry.Models); hash != "" {
+			attrs["models_hash"] = hash
+		}
+		addConfigHeadersToAttrs(entry.Headers, attrs)
+		a := &coreauth.Auth{
+			ID:         id,
+			Provider:   "azure-openai",
+			Label:      "azure-openai-apikey",
+			Prefix:     strings.TrimSpace(entry.Prefix),
+			Status:     coreauth.StatusActive,
+			ProxyURL:   proxyURL,
+			Attributes: attrs,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// synthesizeBedrockKeys creates Auth entries for AWS Bedrock credentials.
+func (s *ConfigSynthesizer) synthesizeBedrockKeys(ctx *SynthesisContext) []*coreauth.Auth {
+	cfg := ctx.Config
+	now := ctx.Now
+	idGen := ctx.IDGenerator
+
+	out := make([]*coreauth.Auth, 0, len(cfg.BedrockKey))
+	for i := range cfg.BedrockKey {
+		entry := &cfg.BedrockKey[i]
+		identity := strings.TrimSpace(entry.GetAPIKey())
+		region := strings.TrimSpace(entry.Region)
+		if identity == "" || region == "" {
+			continue
+		}
+		base := strings.TrimSpace(entry.BaseURL)
+		proxyURL := strings.TrimSpace(entry.ProxyURL)
+		id, token := idGen.Next("bedrock:config", identity, region, base, proxyURL)
+		attrs := map[string]string{
+			"source":   fmt.Sprintf("config:bedrock[%s]", token),
+			"api_key":  identity,
+			"base_url": base,
+			"region":   region,
+		}
+		if secret := strings.TrimSpace(entry.SecretAccessKey); secret != "" && strings.TrimSpace(entry.APIKey) == "" {
+			attrs["secret_access_key"] = secret
+			if sessionToken := strings.TrimSpace(entry.SessionToken); sessionToken != "" {
+				attrs["session_token"] = sessionToken
+			}
+		}
+		if entry.Priority != 0 {
+			attrs["priority"] = strconv.Itoa(entry.Priority)
+		}
+		if hash := diff.ComputeBedrockModelsHash(entry.Models); hash != "" {
+			attrs["models_hash"] = hash
+		}
+		addConfigHeadersToAttrs(entry.Headers, attrs)
+		a := &coreauth.Auth{
+			ID:         id,
+			Provider:   "bedrock",
+			Label:      "bedrock-" + region,
+			Prefix:     strings.TrimSpace(entry.Prefix),
+			Status:     coreauth.StatusActive,
+			ProxyURL:   proxyURL,
+			Attributes: attrs,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// synthesizeOllamaKeys creates Auth entries for Ollama servers.
+func (s *ConfigSynthesizer) synthesizeOllamaKeys(ctx *SynthesisContext) []*coreauth.Auth {
+	cfg := ctx.Config
+	now := ctx.Now
+	idGen := ctx.IDGenerator
+
+	out := make([]*coreauth.Auth, 0, len(cfg.OllamaKey))
+	for i := range cfg.OllamaKey {
+		entry := &cfg.OllamaKey[i]
+		base := strings.TrimSpace(entry.BaseURL)
+		if base == "" {
+			continue
+		}
+		key := strings.TrimSpace(entry.APIKey)
+		proxyURL := strings.TrimSpace(entry.ProxyURL)
+		id, token := idGen.Next("ollama:server", key, base, proxyURL)
+		attrs := map[string]string{
+			"source":   fmt.Sprintf("config:ollama[%s]", token),
+			"base_url": base,
+		}
+		if key != "" {
+			attrs["api_key"] = key
+		}
+		if entry.AutoPull {
+			attrs["auto_pull"] = "true"
+		}
+		if entry.Priority != 0 {
+			attrs["priority"] = strconv.Itoa(entry.Priority)
+		}
+		if hash := diff.ComputeOllamaModelsHash(entry.Models); hash != "" {
+			attrs["models_hash"] = hash
+		}
+		addConfigHeadersToAttrs(entry.Headers, attrs)
+		a := &coreauth.Auth{
+			ID:         id,
+			Provider:   "ollama",
+			Label:      "ollama",
+			Prefix:     strings.TrimSpace(entry.Prefix),
+			Status:     coreauth.StatusActive,
+			ProxyURL:   proxyURL,
+			Attributes: attrs,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// synthesizeMockProviders creates Auth entries for offline mock providers.
+func (s *ConfigSynthesizer) synthesizeMockProviders(ctx *SynthesisContext) []*coreauth.Auth {
+	cfg := ctx.Config
+	now := ctx.Now
+	idGen := ctx.IDGenerator
+
+	out := make([]*coreauth.Auth, 0, len(cfg.MockProvider))
+	for i := range cfg.MockProvider {
+		entry := &cfg.MockProvider[i]
+		name := strings.TrimSpace(entry.Name)
+		if name == "" {
+			continue
+		}
+		id, token := idGen.Next("mock:config", name)
+		attrs := map[string]string{
+			"source":      fmt.Sprintf("config:mock[%s]", token),
+			"mock_name":   name,
+			"mock_models": strings.Join(entry.Models, ","),
+		}
+		a := &coreauth.Auth{
+			ID:         id,
+			Provider:   "mock",
+			Label:      name,
+			Prefix:     strings.TrimSpace(entry.Prefix),
+			Status:     coreauth.StatusActive,
+			Attributes: attrs,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		out = append(out, a)
+	}
+	return out
+}
@@ -160,9 +160,10 @@ func TestConfigSynthesizer_ClaudeKeys(t *testing.T) {
 		Config: &config.Config{
 			ClaudeKey: []config.ClaudeKey{
 				{
-					APIKey:  "sk-ant-api-xxx",
-					Prefix:  "main",
-					BaseURL: "https://api.anthropic.com",
+					APIKey:           "sk-ant-api-xxx",
+					Prefix:           "main",
+					BaseURL:          "https://api.anthropic.com",
+					FallbackBaseURLs: []string{" https://backup-a.example.com ", "", "https://backup-b.example.com"},
 					Models: []config.ClaudeModel{
 						{Name: "claude-3-opus"},
 						{Name: "claude-3-sonnet"},
@@ -197,6 +198,9 @@ func TestConfigSynthesizer_ClaudeKeys(t *testing.T) {
 	if _, ok := auths[0].Attributes["models_hash"]; !ok {
 		t.Error("expected models_hash in attributes")
 	}
+	if got := auths[0].Attributes["fallback_base_urls"]; got != "https://backup-a.example.com,https://backup-b.example.com" {
+		t.Errorf("expected trimmed fallback_base_urls, got %s", got)
+	}
 }
 
 func TestConfigSynthesizer_ClaudeKeys_SkipsEmptyAndHeaders(t *testing.T) {
@@ -404,6 +408,79 @@ func TestConfigSynthesizer_VertexCompat(t *testing.T) {
 	}
 }
 
+func TestConfigSynthesizer_AzureOpenAIKeys(t *testing.T) {
+	synth := NewConfigSynthesizer()
+	ctx := &SynthesisContext{
+		Config: &config.Config{
+			AzureOpenAIKey: []config.AzureOpenAIKey{
+				{APIKey: "", BaseURL: "https://res.openai.azure.com"},
+				{
+					APIKey:     "azure-key",
+					BaseURL:    "https://res.openai.azure.com",
+					APIVersion: "2025-01-01-preview",
+					Models:     []config.AzureOpenAIModel{{Deployment: "prod-gpt-4o", Alias: "gpt-4o"}},
+				},
+			},
+		},
+		Now:         time.Now(),
+		IDGenerator: NewStableIDGenerator(),
+	}
+
+	auths, err := synth.Synthesize(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(auths) != 1 {
+		t.Fatalf("expected 1 auth, got %d", len(auths))
+	}
+	if auths[0].Provider != "azure-openai" {
+		t.Errorf("expected provider azure-openai, got %s", auths[0].Provider)
+	}
+	if auths[0].Attributes["api_version"] != "2025-01-01-preview" {
+		t.Errorf("expected api_version attribute, got %q", auths[0].Attributes["api_version"])
+	}
+	if auths[0].Attributes["models_hash"] == "" {
+		t.Error("expected models_hash attribute")
+	}
+}
+
+func TestConfigSynthesizer_BedrockKeys(t *testing.T) {
+	synth := NewConfigSynthesizer()
+	ctx := &SynthesisContext{
+		Config: &config.Config{
+			BedrockKey: []config.BedrockKey{
+				{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "session", Region: "us-west-2",
+					Models: []config.BedrockModel{{Name: "us.anthropic.claude-sonnet-4-20250514-v1:0", Alias: "claude-sonnet-4"}}},
+				{APIKey: "bedrock-key", AccessKeyID: "AKID2", SecretAccessKey: "ignored", Region: "eu-west-1"},
+			},
+		},
+		Now:         time.Now(),
+		IDGenerator: NewStableIDGenerator(),
+	}
+
+	auths, err := synth.Synthesize(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(auths) != 2 {
+		t.Fatalf("expected 2 auths, got %d", len(auths))
+	}
+	signed := auths[0]
+	if signed.Provider != "bedrock" || signed.Attributes["api_key"] != "AKID" || signed.Attributes["region"] != "us-west-2" {
+		t.Errorf("unexpected SigV4 auth: provider %s, attrs %v", signed.Provider, signed.Attributes)
+	}
+	if signed.Attributes["secret_access_key"] != "secret" || signed.Attributes["session_token"] != "session" {
+		t.Errorf("expected SigV4 secrets in attributes, got %v", signed.Attributes)
+	}
+	if signed.Attributes["models_hash"] == "" {
+		t.Error("expected models_hash attribute")
+	}
+	// A Bedrock API key takes precedence over the access key pair.
+	if bearer := auths[1]; bearer.Attributes["api_key"] != "bedrock-key" || bearer.Attributes["secret_access_key"] != "" {
+		t.Errorf("unexpected API key auth attrs: %v", bearer.Attributes)
+	}
+}
+
 func TestConfigSynthesizer_VertexCompat_SkipsEmptyAndHeaders(t *testing.T) {
 	synth := NewConfigSynthesizer()
 	ctx := &SynthesisContext{
@@ -542,6 +619,75 @@ func TestConfigSynthesizer_VertexCompat_WithModels(t *testing.T) {
 	}
 }
 
+func TestConfigSynthesizer_VertexCredentials(t *testing.T) {
+	synth := NewConfigSynthesizer()
+	ctx := &SynthesisContext{
+		Config: &config.Config{
+			VertexCredentials: []config.VertexCredential{
+				{ProjectID: "my-project", Location: "us-east5", CredentialsFile: "/secrets/sa.json",
+					Models: []config.VertexCompatModel{{Name: "claude-sonnet-4@20250514", Alias: "claude-sonnet-4"}}},
+				{ProjectID: "", Location: "us-central1"},
+			},
+		},
+		Now:         time.Now(),
+		IDGenerator: NewStableIDGenerator(),
+	}
+
+	auths, err := synth.Synthesize(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(auths) != 1 {
+		t.Fatalf("expected 1 auth, got %d", len(auths))
+	}
+	a := auths[0]
+	if a.Provider != "vertex" || a.Label != "vertex-my-project" {
+		t.Errorf("unexpected auth: provider %s, label %s", a.Provider, a.Label)
+	}
+	if a.Attributes["project_id"] != "my-project" || a.Attributes["location"] != "us-east5" || a.Attributes["credentials_file"] != "/secrets/sa.json" {
+		t.Errorf("unexpected attributes: %v", a.Attributes)
+	}
+	if a.Attributes["api_key"] != "" || a.Attributes["auth_kind"] != "oauth" {
+		t.Errorf("expected an OAuth auth without api_key, got %v", a.Attributes)
+	}
+	if a.Attributes["models_hash"] == "" || a.Attributes["refresh_interval_seconds"] == "" {
+		t.Errorf("expected models_hash and refresh_interval_seconds, got %v", a.Attributes)
+	}
+}
+
+func TestConfigSynthesizer_OllamaKeys(t *testing.T) {
+	synth := NewConfigSynthesizer()
+	ctx := &SynthesisContext{
+		Config: &config.Config{
+			OllamaKey: []config.OllamaKey{
+				{BaseURL: "http://gpu-box:11434", AutoPull: true, Priority: 2,
+					Models: []config.OllamaModel{{Name: "llama3.2", Alias: "local"}}},
+				{BaseURL: ""},
+			},
+		},
+		Now:         time.Now(),
+		IDGenerator: NewStableIDGenerator(),
+	}
+
+	auths, err := synth.Synthesize(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(auths) != 1 {
+		t.Fatalf("expected 1 auth, got %d", len(auths))
+	}
+	a := auths[0]
+	if a.Provider != "ollama" || a.Attributes["base_url"] != "http://gpu-box:11434" {
+		t.Errorf("unexpected auth: provider %s, attributes %v", a.Provider, a.Attributes)
+	}
+	if a.Attributes["auto_pull"] != "true" || a.Attributes["priority"] != "2" || a.Attributes["models_hash"] == "" {
+		t.Errorf("unexpected attributes: %v", a.Attributes)
+	}
+	if _, ok := a.Attributes["api_key"]; ok {
+		t.Errorf("expected no api_key attribute, got %v", a.Attributes)
+	}
+}
+
 func TestConfigSynthesizer_IDStability(t *testing.T) {
 	cfg := &config.Config{
 		GeminiKey: []config.GeminiKey{
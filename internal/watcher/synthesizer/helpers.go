@@ -118,3 +118,20 @@ func addConfigHeadersToAttrs(headers map[string]string, attrs map[string]string)
 		attrs["header:"+key] = val
 	}
 }
+
+// addFallbackBaseURLsToAttrs stores the non-empty fallback endpoints as a comma-separated
+// fallback_base_urls attribute, in order.
+func addFallbackBaseURLsToAttrs(urls []string, attrs map[string]string) {
+	if len(urls) == 0 || attrs == nil {
+		return
+	}
+	cleaned := make([]string, 0, len(urls))
+	for _, raw := range urls {
+		if trimmed := strings.TrimSpace(raw); trimmed != "" {
+			cleaned = append(cleaned, trimmed)
+		}
+	}
+	if len(cleaned) > 0 {
+		attrs["fallback_base_urls"] = strings.Join(cleaned, ",")
+	}
+}
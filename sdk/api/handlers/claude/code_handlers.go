@@ -76,6 +76,11 @@ func (h *ClaudeCodeAPIHandler) ClaudeMessages(c *gin.Context) {
 		return
 	}
 
+	if errMsg := h.ValidateRequest(h.HandlerType(), rawJSON); errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		return
+	}
+
 	// Check if the client requested a streaming response.
 	streamResult := gjson.GetBytes(rawJSON, "stream")
 	if !streamResult.Exists() || streamResult.Type == gjson.False {
@@ -105,6 +110,11 @@ func (h *ClaudeCodeAPIHandler) ClaudeCountTokens(c *gin.Context) {
 		return
 	}
 
+	if errMsg := h.ValidateRequest(h.HandlerType(), rawJSON); errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		return
+	}
+
 	c.Header("Content-Type", "application/json")
 
 	alt := h.GetAlt(c)
@@ -303,6 +313,10 @@ func (h *ClaudeCodeAPIHandler) forwardClaudeStream(c *gin.Context, flusher http.
 			errorBytes, _ := json.Marshal(h.toClaudeError(errMsg))
 			_, _ = fmt.Fprintf(c.Writer, "event: error\ndata: %s\n\n", errorBytes)
 		},
+		WriteKeepAlive: func() {
+			// Claude clients expect periodic ping events rather than bare SSE comments.
+			_, _ = c.Writer.Write([]byte("event: ping\ndata: {\"type\":\"ping\"}\n\n"))
+		},
 	})
 }
 
@@ -317,6 +331,11 @@ type claudeErrorResponse struct {
 }
 
 func (h *ClaudeCodeAPIHandler) toClaudeError(msg *interfaces.ErrorMessage) claudeErrorResponse {
+	// Errors the proxy already rendered in the Claude format are passed through unchanged.
+	var rendered claudeErrorResponse
+	if errUnmarshal := json.Unmarshal([]byte(msg.Error.Error()), &rendered); errUnmarshal == nil && rendered.Type == "error" && rendered.Error.Type != "" {
+		return rendered
+	}
 	return claudeErrorResponse{
 		Type: "error",
 		Error: claudeErrorDetail{
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/diagnostics"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+)
+
+// recordFailure stores an execution failure in the failure diagnostics store together with
+// the credential and translation path of the last attempt.
+func (h *BaseAPIHandler) recordFailure(ctx context.Context, tracker *responseMetadata, handlerType, modelName string, stream bool, msg *interfaces.ErrorMessage) {
+	if msg == nil || !diagnostics.Enabled() {
+		return
+	}
+	failure := diagnostics.Failure{
+		HandlerType: handlerType,
+		Model:       modelName,
+		Stream:      stream,
+		StatusCode:  msg.StatusCode,
+	}
+	if msg.Error != nil {
+		failure.Error = msg.Error.Error()
+	}
+	if ctx != nil {
+		failure.RequestID = logging.GetRequestID(ctx)
+		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
+			failure.Method = ginCtx.Request.Method
+			failure.Path = ginCtx.Request.URL.Path
+			failure.Translation = translationPath(ginCtx, handlerType)
+			if failure.RequestID == "" {
+				failure.RequestID = logging.GetGinRequestID(ginCtx)
+			}
+		}
+	}
+
+	authID, attempts := tracker.lastAuthID()
+	failure.AuthID = authID
+	failure.Attempts = attempts
+	if authID != "" && h.AuthManager != nil {
+		if auth, found := h.AuthManager.GetByID(authID); found && auth != nil {
+			failure.Provider = strings.ToLower(strings.TrimSpace(auth.Provider))
+			failure.AuthIndex = auth.EnsureIndex()
+			failure.AuthLabel = auth.Label
+		}
+	}
+	diagnostics.RecordFailure(failure)
+}
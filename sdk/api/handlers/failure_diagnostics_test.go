@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/diagnostics"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor/helps"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+func TestRecordFailureCapturesAttemptContext(t *testing.T) {
+	store := diagnostics.Default()
+	store.Configure(config.FailureDiagnosticsConfig{Enable: true}, "")
+	t.Cleanup(func() { store.Configure(config.FailureDiagnosticsConfig{}, "") })
+
+	manager := coreauth.NewManager(nil, nil, nil)
+	if _, err := manager.Register(context.Background(), &coreauth.Auth{ID: "claude-1", Provider: "claude", Label: "team-a"}); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager)
+
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	ctx := context.WithValue(context.Background(), "gin", c)
+	if errPair := helps.CheckTranslatorPair(ctx, sdktranslator.FormatOpenAI, sdktranslator.FormatClaude); errPair != nil {
+		t.Fatalf("CheckTranslatorPair: %v", errPair)
+	}
+
+	meta := map[string]any{}
+	tracker := handler.trackResponseMetadata(meta)
+	if tracker == nil {
+		t.Fatal("tracker should be installed while failure diagnostics are enabled")
+	}
+	callback := meta[coreexecutor.SelectedAuthCallbackMetadataKey].(func(string))
+	callback("gemini-1")
+	callback("claude-1")
+	handler.writeResponseMetadata(ctx, tracker, "openai")
+	if c.Writer.Header().Get(ResponseProviderHeader) != "" {
+		t.Fatal("metadata headers should stay disabled")
+	}
+
+	handler.recordFailure(ctx, tracker, "openai", "claude-sonnet", true, &interfaces.ErrorMessage{
+		StatusCode: http.StatusBadGateway,
+		Error:      errors.New(`{"error":{"message":"overloaded"}}`),
+	})
+
+	failures := store.List(0)
+	if len(failures) != 1 {
+		t.Fatalf("failures = %d, want 1", len(failures))
+	}
+	got := failures[0]
+	if got.Provider != "claude" || got.AuthID != "claude-1" || got.AuthLabel != "team-a" || got.Attempts != 2 {
+		t.Fatalf("unexpected credential context: %+v", got)
+	}
+	if got.Translation != "openai->claude" || got.StatusCode != http.StatusBadGateway || !got.Stream || got.Path != "/v1/chat/completions" {
+		t.Fatalf("unexpected failure record: %+v", got)
+	}
+}
@@ -153,6 +153,11 @@ func (h *GeminiAPIHandler) GeminiHandler(c *gin.Context) {
 	method := action[1]
 	rawJSON, _ := c.GetRawData()
 
+	if errMsg := h.ValidateRequest(h.HandlerType(), rawJSON); errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		return
+	}
+
 	switch method {
 	case "generateContent":
 		h.handleGenerateContent(c, action[0], rawJSON)
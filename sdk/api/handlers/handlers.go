@@ -9,13 +9,16 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/lifecycle"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/streamwatch"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
@@ -47,6 +50,10 @@ type ErrorDetail struct {
 
 const idempotencyKeyMetadataKey = "idempotency_key"
 
+// StreamErrorContextKey is the gin context key set to true when a streamed response ends with an
+// error after its 200 status was sent.
+const StreamErrorContextKey = "API_STREAM_ERROR"
+
 const (
 	defaultStreamingKeepAliveSeconds = 0
 	defaultStreamingBootstrapRetries = 0
@@ -188,6 +195,45 @@ func StreamingBootstrapRetries(cfg *config.SDKConfig) int {
 	return retries
 }
 
+// StreamingIdleTimeout returns how long an upstream stream may stay silent after its first chunk
+// before it is aborted. Returning 0 disables idle detection (default when unset).
+func StreamingIdleTimeout(cfg *config.SDKConfig) time.Duration {
+	seconds := 0
+	if cfg != nil {
+		seconds = cfg.Streaming.IdleTimeoutSeconds
+	}
+	if seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// StreamingFirstChunkTimeout returns how long a stream may take to produce its first chunk.
+// A zero duration disables the limit.
+func StreamingFirstChunkTimeout(cfg *config.SDKConfig) time.Duration {
+	seconds := 0
+	if cfg != nil {
+		seconds = cfg.Streaming.FirstChunkTimeoutSeconds
+	}
+	if seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// StreamingIdleRetries returns how many times an idle stream may be retried before any
+// payload reaches the client.
+func StreamingIdleRetries(cfg *config.SDKConfig) int {
+	retries := 0
+	if cfg != nil {
+		retries = cfg.Streaming.IdleRetries
+	}
+	if retries < 0 {
+		retries = 0
+	}
+	return retries
+}
+
 // PassthroughHeadersEnabled returns whether upstream response headers should be forwarded to clients.
 // Default is false.
 func PassthroughHeadersEnabled(cfg *config.SDKConfig) bool {
@@ -502,12 +548,28 @@ func appendAPIResponse(c *gin.Context, data []byte) {
 // ExecuteWithAuthManager executes a non-streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	ctx = h.withReasoningFormat(ctx, handlerType)
+	if errMsg := h.enforceAPIKeyPolicy(ctx, handlerType, modelName, rawJSON); errMsg != nil {
+		return nil, nil, errMsg
+	}
+	modelName, script, errMsg := h.matchRoutingScript(ctx, handlerType, modelName, rawJSON, false)
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
 	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
 	if errMsg != nil {
 		return nil, nil, errMsg
 	}
 	reqMeta := requestExecutionMetadata(ctx)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = normalizedModel
+	providers, errMsg = h.applyRoutingScript(script, providers, reqMeta)
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
+	providers, errMsg = h.applyRoutingPinHeaders(ctx, providers, reqMeta)
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
 	payload := rawJSON
 	if len(payload) == 0 {
 		payload = nil
@@ -524,9 +586,13 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 		Headers:         headersFromContext(ctx),
 	}
 	opts.Metadata = reqMeta
+	tracker := h.trackResponseMetadata(reqMeta)
+	requestID := logging.GetRequestID(ctx)
+	defer streamwatch.Finish(requestID)
 	resp, err := h.AuthManager.Execute(ctx, providers, req, opts)
+	h.writeResponseMetadata(ctx, tracker, handlerType)
 	if err != nil {
-		err = enrichAuthSelectionError(err, providers, normalizedModel)
+		err = enrichAuthSelectionError(unwrapTransportStatusError(err), providers, normalizedModel)
 		status := http.StatusInternalServerError
 		if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
 			if code := se.StatusCode(); code > 0 {
@@ -539,8 +605,11 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 				addon = hdr.Clone()
 			}
 		}
-		return nil, nil, &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
+		errMsg = &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
+		h.recordFailure(ctx, tracker, handlerType, normalizedModel, false, errMsg)
+		return nil, nil, errMsg
 	}
+	streamwatch.Publish(requestID, streamwatch.Downstream, resp.Payload)
 	if !PassthroughHeadersEnabled(h.Cfg) {
 		return resp.Payload, nil, nil
 	}
@@ -550,12 +619,27 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 // ExecuteCountWithAuthManager executes a non-streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	if errMsg := h.enforceAPIKeyPolicy(ctx, handlerType, modelName, rawJSON); errMsg != nil {
+		return nil, nil, errMsg
+	}
+	modelName, script, errMsg := h.matchRoutingScript(ctx, handlerType, modelName, rawJSON, false)
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
 	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
 	if errMsg != nil {
 		return nil, nil, errMsg
 	}
 	reqMeta := requestExecutionMetadata(ctx)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = normalizedModel
+	providers, errMsg = h.applyRoutingScript(script, providers, reqMeta)
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
+	providers, errMsg = h.applyRoutingPinHeaders(ctx, providers, reqMeta)
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
 	payload := rawJSON
 	if len(payload) == 0 {
 		payload = nil
@@ -572,9 +656,11 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 		Headers:         headersFromContext(ctx),
 	}
 	opts.Metadata = reqMeta
+	tracker := h.trackResponseMetadata(reqMeta)
 	resp, err := h.AuthManager.ExecuteCount(ctx, providers, req, opts)
+	h.writeResponseMetadata(ctx, tracker, handlerType)
 	if err != nil {
-		err = enrichAuthSelectionError(err, providers, normalizedModel)
+		err = enrichAuthSelectionError(unwrapTransportStatusError(err), providers, normalizedModel)
 		status := http.StatusInternalServerError
 		if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
 			if code := se.StatusCode(); code > 0 {
@@ -587,7 +673,9 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 				addon = hdr.Clone()
 			}
 		}
-		return nil, nil, &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
+		errMsg = &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
+		h.recordFailure(ctx, tracker, handlerType, normalizedModel, false, errMsg)
+		return nil, nil, errMsg
 	}
 	if !PassthroughHeadersEnabled(h.Cfg) {
 		return resp.Payload, nil, nil
@@ -599,6 +687,21 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 // This path is the only supported execution route.
 // The returned http.Header carries upstream response headers captured before streaming begins.
 func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage) {
+	ctx = h.withReasoningFormat(ctx, handlerType)
+	ctx = sdktranslator.WithToolCallTracking(ctx)
+	if errMsg := h.enforceAPIKeyPolicy(ctx, handlerType, modelName, rawJSON); errMsg != nil {
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errMsg
+		close(errChan)
+		return nil, nil, errChan
+	}
+	modelName, script, errMsg := h.matchRoutingScript(ctx, handlerType, modelName, rawJSON, true)
+	if errMsg != nil {
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errMsg
+		close(errChan)
+		return nil, nil, errChan
+	}
 	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
 	if errMsg != nil {
 		errChan := make(chan *interfaces.ErrorMessage, 1)
@@ -608,6 +711,20 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 	}
 	reqMeta := requestExecutionMetadata(ctx)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = normalizedModel
+	providers, errMsg = h.applyRoutingScript(script, providers, reqMeta)
+	if errMsg != nil {
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errMsg
+		close(errChan)
+		return nil, nil, errChan
+	}
+	providers, errMsg = h.applyRoutingPinHeaders(ctx, providers, reqMeta)
+	if errMsg != nil {
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errMsg
+		close(errChan)
+		return nil, nil, errChan
+	}
 	payload := rawJSON
 	if len(payload) == 0 {
 		payload = nil
@@ -624,23 +741,66 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		Headers:         headersFromContext(ctx),
 	}
 	opts.Metadata = reqMeta
-	streamResult, err := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
+	idleTimeout := StreamingIdleTimeout(h.Cfg)
+	idleWatchdog := newStreamIdleWatchdog(idleTimeout)
+	firstChunkTimeout := StreamingFirstChunkTimeout(h.Cfg)
+	idleRetries := 0
+	maxIdleRetries := StreamingIdleRetries(h.Cfg)
+	watched := idleWatchdog != nil || firstChunkTimeout > 0
+	var stalled *stalledAuths
+	if watched && maxIdleRetries > 0 {
+		stalled = trackStalledAuths(reqMeta)
+	}
+	tracker := h.trackResponseMetadata(reqMeta)
+	cancelAttempt := func(error) {}
+	// dispatch starts a new upstream attempt, bounded by the first-chunk timeout when one is set,
+	// and arms the idle watchdog once the first chunk arrived. An attempt that misses the
+	// first-chunk timeout is retried on another credential while idle retries remain.
+	dispatch := func() (*coreexecutor.StreamResult, error) {
+		for {
+			cancelAttempt(nil)
+			var attemptCtx context.Context
+			attemptCtx, cancelAttempt = streamAttemptContext(ctx, watched)
+			result, errDispatch := h.executeStreamWatched(attemptCtx, cancelAttempt, firstChunkTimeout, providers, req, opts)
+			if errDispatch == nil {
+				idleWatchdog.Touch()
+				return result, nil
+			}
+			if !errors.Is(errDispatch, errStreamIdle) || idleRetries >= maxIdleRetries {
+				return nil, errDispatch
+			}
+			idleRetries++
+			stalled.exclude(reqMeta)
+		}
+	}
+	// Resolve the throttle before dispatch: executors record the applied rate in usage when
+	// they start.
+	throttle := newStreamThrottle(ctx, h.Cfg)
+	streamResult, err := dispatch()
+	h.writeResponseMetadata(ctx, tracker, handlerType)
 	if err != nil {
-		err = enrichAuthSelectionError(err, providers, normalizedModel)
+		cancelAttempt(nil)
 		errChan := make(chan *interfaces.ErrorMessage, 1)
-		status := http.StatusInternalServerError
-		if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
-			if code := se.StatusCode(); code > 0 {
-				status = code
+		if errors.Is(err, errStreamIdle) {
+			errMsg = streamIdleTimeoutError(handlerType, firstChunkTimeout)
+		} else {
+			err = enrichAuthSelectionError(unwrapTransportStatusError(err), providers, normalizedModel)
+			status := http.StatusInternalServerError
+			if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
+				if code := se.StatusCode(); code > 0 {
+					status = code
+				}
 			}
-		}
-		var addon http.Header
-		if he, ok := err.(interface{ Headers() http.Header }); ok && he != nil {
-			if hdr := he.Headers(); hdr != nil {
-				addon = hdr.Clone()
+			var addon http.Header
+			if he, ok := err.(interface{ Headers() http.Header }); ok && he != nil {
+				if hdr := he.Headers(); hdr != nil {
+					addon = hdr.Clone()
+				}
 			}
+			errMsg = &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
 		}
-		errChan <- &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
+		h.recordFailure(ctx, tracker, handlerType, normalizedModel, true, errMsg)
+		errChan <- errMsg
 		close(errChan)
 		return nil, nil, errChan
 	}
@@ -657,14 +817,20 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 	chunks := streamResult.Chunks
 	dataChan := make(chan []byte)
 	errChan := make(chan *interfaces.ErrorMessage, 1)
-	go func() {
+	requestID := logging.GetRequestID(ctx)
+	lifecycle.Go(ctx, "handlers.stream", func() {
+		defer streamwatch.Finish(requestID)
 		defer close(dataChan)
 		defer close(errChan)
+		defer func() { cancelAttempt(nil) }()
+		defer idleWatchdog.Stop()
 		sentPayload := false
 		bootstrapRetries := 0
 		maxBootstrapRetries := StreamingBootstrapRetries(h.Cfg)
 
 		sendErr := func(msg *interfaces.ErrorMessage) bool {
+			h.recordFailure(ctx, tracker, handlerType, normalizedModel, true, msg)
+			markStreamFailed(ctx)
 			if ctx == nil {
 				errChan <- msg
 				return true
@@ -678,6 +844,10 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		}
 
 		sendData := func(chunk []byte) bool {
+			if !throttle.wait(ctx, chunk) {
+				return false
+			}
+			streamwatch.Publish(requestID, streamwatch.Downstream, chunk)
 			if ctx == nil {
 				dataChan <- chunk
 				return true
@@ -714,6 +884,29 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 					case <-ctx.Done():
 						return
 					case chunk, ok = <-chunks:
+					case <-idleWatchdog.C():
+						// The upstream went silent: abort it and, if nothing reached the client yet,
+						// try again on another credential.
+						cancelAttempt(errStreamIdle)
+						idleWatchdog.Stop()
+						if !sentPayload && idleRetries < maxIdleRetries {
+							idleRetries++
+							stalled.exclude(reqMeta)
+							retryResult, retryErr := dispatch()
+							if retryErr == nil {
+								if passthroughHeadersEnabled {
+									replaceHeader(upstreamHeaders, FilterUpstreamHeaders(retryResult.Headers))
+								}
+								chunks = retryResult.Chunks
+								continue outer
+							}
+							if errors.Is(retryErr, errStreamIdle) {
+								_ = sendErr(streamIdleTimeoutError(handlerType, firstChunkTimeout))
+								return
+							}
+						}
+						_ = sendErr(streamIdleTimeoutError(handlerType, idleTimeout))
+						return
 					}
 				} else {
 					chunk, ok = <-chunks
@@ -721,6 +914,7 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 				if !ok {
 					return
 				}
+				idleWatchdog.Touch()
 				if chunk.Err != nil {
 					streamErr := chunk.Err
 					// Safe bootstrap recovery: if the upstream fails before any payload bytes are sent,
@@ -728,7 +922,8 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 					if !sentPayload {
 						if bootstrapRetries < maxBootstrapRetries && bootstrapEligible(streamErr) {
 							bootstrapRetries++
-							retryResult, retryErr := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
+							idleWatchdog.Stop()
+							retryResult, retryErr := dispatch()
 							if retryErr == nil {
 								if passthroughHeadersEnabled {
 									replaceHeader(upstreamHeaders, FilterUpstreamHeaders(retryResult.Headers))
@@ -736,7 +931,11 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 								chunks = retryResult.Chunks
 								continue outer
 							}
-							streamErr = enrichAuthSelectionError(retryErr, providers, normalizedModel)
+							if errors.Is(retryErr, errStreamIdle) {
+								_ = sendErr(streamIdleTimeoutError(handlerType, firstChunkTimeout))
+								return
+							}
+							streamErr = enrichAuthSelectionError(unwrapTransportStatusError(retryErr), providers, normalizedModel)
 						}
 					}
 
@@ -769,7 +968,7 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 				}
 			}
 		}
-	}()
+	})
 	return dataChan, upstreamHeaders, errChan
 }
 
@@ -857,6 +1056,16 @@ func (h *BaseAPIHandler) getRequestDetails(modelName string) (providers []string
 	return providers, resolvedModelName, nil
 }
 
+// ModelProviders returns the providers that can serve modelName, or nil when none is known.
+// Routing scripts and pin headers may narrow the list further when the request executes.
+func (h *BaseAPIHandler) ModelProviders(modelName string) []string {
+	providers, _, errMsg := h.getRequestDetails(modelName)
+	if errMsg != nil {
+		return nil
+	}
+	return providers
+}
+
 func cloneBytes(src []byte) []byte {
 	if len(src) == 0 {
 		return nil
@@ -886,6 +1095,20 @@ func replaceHeader(dst http.Header, src http.Header) {
 	}
 }
 
+// unwrapTransportStatusError returns the cause of a *url.Error when the cause carries its own
+// status code, such as a proxy timeout raised by the upstream transport, so its status and
+// error body reach the client instead of the generic transport failure.
+func unwrapTransportStatusError(err error) error {
+	urlErr, ok := errors.AsType[*url.Error](err)
+	if !ok || urlErr == nil {
+		return err
+	}
+	if _, ok := urlErr.Err.(interface{ StatusCode() int }); ok {
+		return urlErr.Err
+	}
+	return err
+}
+
 func enrichAuthSelectionError(err error, providers []string, model string) error {
 	if err == nil {
 		return nil
@@ -1000,6 +1223,17 @@ func (h *BaseAPIHandler) LoggingAPIResponseError(ctx context.Context, err *inter
 	}
 }
 
+// markStreamFailed flags the gin context of a streamed request that ended with an error, so
+// middleware caching responses can tell a failed stream from a completed one.
+func markStreamFailed(ctx context.Context) {
+	if ctx == nil {
+		return
+	}
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+		ginCtx.Set(StreamErrorContextKey, true)
+	}
+}
+
 // APIHandlerCancelFunc is a function type for canceling an API handler's context.
 // It can optionally accept parameters, which are used for logging the response.
 type APIHandlerCancelFunc func(params ...interface{})
@@ -0,0 +1,202 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/agentloop"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// AgentChatCompletions handles the /v1/agent/chat/completions endpoint.
+// It behaves like /v1/chat/completions, except that tool calls for tools configured under
+// agent-loop.tools are executed by the proxy and fed back to the model until it produces a
+// final answer, calls a client tool, or the iteration limit is reached. The messages the
+// loop appended are returned in the "agent_loop" field so clients can continue the
+// conversation. When the model calls client and proxy tools in the same turn, the proxy
+// tools run first and their results are returned in "agent_loop.tool_results", to be sent
+// after the assistant message together with the client's own results. Only non-streaming
+// requests are supported.
+func (h *OpenAIAPIHandler) AgentChatCompletions(c *gin.Context) {
+	if h.Cfg == nil || !h.Cfg.AgentLoop.Enabled {
+		c.JSON(http.StatusNotFound, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: "agent loop is disabled",
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: fmt.Sprintf("Invalid request: %v", err),
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+	if gjson.GetBytes(rawJSON, "stream").Bool() {
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: "streaming is not supported by the agent loop endpoint",
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+	if errMsg := h.ValidateRequest(h.HandlerType(), rawJSON); errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		return
+	}
+
+	c.Header("Content-Type", "application/json")
+	toolbox := agentloop.NewToolbox(h.Cfg.AgentLoop)
+	payload, serverTools := injectAgentTools(rawJSON, toolbox)
+	maxIterations := agentloop.MaxIterations(h.Cfg.AgentLoop)
+	modelName := gjson.GetBytes(rawJSON, "model").String()
+
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	var appended []string
+	var usage agentLoopUsage
+	for iteration := 1; ; iteration++ {
+		resp, upstreamHeaders, errMsg := h.ExecuteWithAuthManager(cliCtx, h.HandlerType(), modelName, payload, h.GetAlt(c))
+		if errMsg != nil {
+			h.WriteErrorResponse(c, errMsg)
+			cliCancel(errMsg.Error)
+			return
+		}
+		usage.add(gjson.GetBytes(resp, "usage"))
+
+		message := gjson.GetBytes(resp, "choices.0.message")
+		calls := message.Get("tool_calls").Array()
+		limitReached := iteration >= maxIterations
+		serverCalls, clientCalls := splitToolCalls(calls, serverTools)
+		if len(serverCalls) == 0 || limitReached {
+			if len(serverCalls) > 0 {
+				resp = withClientToolCalls(resp, clientCalls)
+			}
+			resp = finalizeAgentResponse(resp, usage, iteration, len(serverCalls) > 0, appended)
+			handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+			_, _ = c.Writer.Write(resp)
+			cliCancel()
+			return
+		}
+
+		var results []string
+		for _, call := range serverCalls {
+			result, errCall := toolbox.Call(cliCtx, call.Get("function.name").String(), call.Get("function.arguments").String())
+			if errCall != nil {
+				result = "Error: " + errCall.Error()
+			}
+			toolMessage, _ := sjson.Set(`{"role":"tool"}`, "tool_call_id", call.Get("id").String())
+			toolMessage, _ = sjson.Set(toolMessage, "content", result)
+			results = append(results, toolMessage)
+		}
+		if len(clientCalls) > 0 {
+			resp = finalizeAgentResponse(resp, usage, iteration, false, appended)
+			resp, _ = sjson.SetRawBytes(resp, "agent_loop.tool_results", []byte("["+strings.Join(results, ",")+"]"))
+			handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+			_, _ = c.Writer.Write(resp)
+			cliCancel()
+			return
+		}
+
+		turn := append([]string{message.Raw}, results...)
+		appended = append(appended, turn...)
+		for _, item := range turn {
+			payload, _ = sjson.SetRawBytes(payload, "messages.-1", []byte(item))
+		}
+	}
+}
+
+// injectAgentTools adds the proxy-executed tools to the request. Client tools with the same
+// name take precedence and are left to the client. It returns the updated payload and the
+// set of tool names the proxy executes.
+func injectAgentTools(rawJSON []byte, toolbox *agentloop.Toolbox) ([]byte, map[string]struct{}) {
+	clientTools := make(map[string]struct{})
+	for _, tool := range gjson.GetBytes(rawJSON, "tools").Array() {
+		clientTools[tool.Get("function.name").String()] = struct{}{}
+	}
+	serverTools := make(map[string]struct{})
+	payload := rawJSON
+	for _, name := range toolbox.Names() {
+		if _, taken := clientTools[name]; taken {
+			continue
+		}
+		payload, _ = sjson.SetRawBytes(payload, "tools.-1", []byte(toolbox.Definition(name)))
+		serverTools[name] = struct{}{}
+	}
+	return payload, serverTools
+}
+
+// splitToolCalls separates the calls the proxy executes from those left to the client.
+func splitToolCalls(calls []gjson.Result, serverTools map[string]struct{}) (server, client []gjson.Result) {
+	for _, call := range calls {
+		if _, ok := serverTools[call.Get("function.name").String()]; ok {
+			server = append(server, call)
+		} else {
+			client = append(client, call)
+		}
+	}
+	return server, client
+}
+
+// withClientToolCalls drops the calls of proxy tools left unexecuted when the loop limit is
+// reached, since the client cannot run tools it never declared, and sets finish_reason to match
+// the calls that remain.
+func withClientToolCalls(resp []byte, clientCalls []gjson.Result) []byte {
+	if len(clientCalls) == 0 {
+		resp, _ = sjson.DeleteBytes(resp, "choices.0.message.tool_calls")
+		if content := gjson.GetBytes(resp, "choices.0.message.content"); content.Type == gjson.Null {
+			resp, _ = sjson.SetBytes(resp, "choices.0.message.content", "")
+		}
+		resp, _ = sjson.SetBytes(resp, "choices.0.finish_reason", "stop")
+		return resp
+	}
+	raw := make([]string, 0, len(clientCalls))
+	for _, call := range clientCalls {
+		raw = append(raw, call.Raw)
+	}
+	resp, _ = sjson.SetRawBytes(resp, "choices.0.message.tool_calls", []byte("["+strings.Join(raw, ",")+"]"))
+	resp, _ = sjson.SetBytes(resp, "choices.0.finish_reason", "tool_calls")
+	return resp
+}
+
+// agentLoopUsage sums token usage across loop iterations.
+type agentLoopUsage struct {
+	prompt     int64
+	completion int64
+	total      int64
+	seen       bool
+}
+
+func (u *agentLoopUsage) add(usage gjson.Result) {
+	if !usage.Exists() {
+		return
+	}
+	u.seen = true
+	u.prompt += usage.Get("prompt_tokens").Int()
+	u.completion += usage.Get("completion_tokens").Int()
+	u.total += usage.Get("total_tokens").Int()
+}
+
+// finalizeAgentResponse reports the summed usage and the loop trace on the final response.
+func finalizeAgentResponse(resp []byte, usage agentLoopUsage, iterations int, limitReached bool, appended []string) []byte {
+	if usage.seen && iterations > 1 {
+		resp, _ = sjson.SetBytes(resp, "usage.prompt_tokens", usage.prompt)
+		resp, _ = sjson.SetBytes(resp, "usage.completion_tokens", usage.completion)
+		resp, _ = sjson.SetBytes(resp, "usage.total_tokens", usage.total)
+	}
+	resp, _ = sjson.SetBytes(resp, "agent_loop.iterations", iterations)
+	resp, _ = sjson.SetBytes(resp, "agent_loop.limit_reached", limitReached)
+	resp, _ = sjson.SetRawBytes(resp, "agent_loop.messages", []byte("["+strings.Join(appended, ",")+"]"))
+	return resp
+}
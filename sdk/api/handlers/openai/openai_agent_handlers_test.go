@@ -0,0 +1,173 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+type agentLoopExecutor struct {
+	payloads [][]byte
+	// first overrides the response to the first invocation.
+	first []byte
+}
+
+func (e *agentLoopExecutor) Identifier() string { return "agent-test" }
+
+func (e *agentLoopExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	e.payloads = append(e.payloads, req.Payload)
+	if len(e.payloads) == 1 && e.first != nil {
+		return coreexecutor.Response{Payload: e.first}, nil
+	}
+	if len(e.payloads) == 1 {
+		return coreexecutor.Response{Payload: []byte(`{"choices":[{"message":{"role":"assistant","content":null,"tool_calls":[{"id":"call_1","type":"function","function":{"name":"websearch","arguments":"{\"query\":\"go\"}"}}]},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`)}, nil
+	}
+	return coreexecutor.Response{Payload: []byte(`{"choices":[{"message":{"role":"assistant","content":"done"},"finish_reason":"stop"}],"usage":{"prompt_tokens":20,"completion_tokens":3,"total_tokens":23}}`)}, nil
+}
+
+func (e *agentLoopExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *agentLoopExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *agentLoopExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *agentLoopExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func newAgentLoopRouter(t *testing.T, executor *agentLoopExecutor) *gin.Engine {
+	t.Helper()
+	return newAgentLoopRouterWithLimit(t, executor, 0)
+}
+
+func newAgentLoopRouterWithLimit(t *testing.T, executor *agentLoopExecutor, maxIterations int) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	toolServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("search results"))
+	}))
+	t.Cleanup(toolServer.Close)
+
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "agent-auth", Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "agent-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	base := handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{AgentLoop: sdkconfig.AgentLoopConfig{
+		Enabled:       true,
+		MaxIterations: maxIterations,
+		Tools:         []sdkconfig.AgentTool{{Name: "websearch", Type: "http", URL: toolServer.URL}},
+	}}, manager)
+	h := NewOpenAIAPIHandler(base)
+	router := gin.New()
+	router.POST("/v1/agent/chat/completions", h.AgentChatCompletions)
+	return router
+}
+
+func TestAgentChatCompletionsExecutesServerTools(t *testing.T) {
+	executor := &agentLoopExecutor{}
+	router := newAgentLoopRouter(t, executor)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/agent/chat/completions", strings.NewReader(`{"model":"agent-model","messages":[{"role":"user","content":"search go"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	if len(executor.payloads) != 2 {
+		t.Fatalf("model invocations = %d, want 2", len(executor.payloads))
+	}
+	if name := gjson.GetBytes(executor.payloads[0], "tools.0.function.name").String(); name != "websearch" {
+		t.Fatalf("server tool not injected: %s", executor.payloads[0])
+	}
+	if got := gjson.GetBytes(executor.payloads[1], "messages.2.content").String(); got != "search results" {
+		t.Fatalf("tool result not appended: %s", executor.payloads[1])
+	}
+	body := resp.Body.Bytes()
+	if gjson.GetBytes(body, "choices.0.message.content").String() != "done" {
+		t.Fatalf("unexpected final answer: %s", body)
+	}
+	if gjson.GetBytes(body, "usage.total_tokens").Int() != 38 || gjson.GetBytes(body, "agent_loop.iterations").Int() != 2 {
+		t.Fatalf("unexpected usage or loop trace: %s", body)
+	}
+	if n := len(gjson.GetBytes(body, "agent_loop.messages").Array()); n != 2 {
+		t.Fatalf("agent_loop.messages = %d, want 2", n)
+	}
+}
+
+func TestAgentChatCompletionsRunsServerToolsBeforeClientTools(t *testing.T) {
+	executor := &agentLoopExecutor{first: []byte(`{"choices":[{"message":{"role":"assistant","content":null,"tool_calls":[` +
+		`{"id":"call_1","type":"function","function":{"name":"websearch","arguments":"{\"query\":\"go\"}"}},` +
+		`{"id":"call_2","type":"function","function":{"name":"ask_user","arguments":"{}"}}]},"finish_reason":"tool_calls"}]}`)}
+	router := newAgentLoopRouter(t, executor)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/agent/chat/completions", strings.NewReader(`{"model":"agent-model","messages":[{"role":"user","content":"search go"}],"tools":[{"type":"function","function":{"name":"ask_user"}}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	if len(executor.payloads) != 1 {
+		t.Fatalf("model invocations = %d, want 1", len(executor.payloads))
+	}
+	body := resp.Body.Bytes()
+	if n := len(gjson.GetBytes(body, "choices.0.message.tool_calls").Array()); n != 2 {
+		t.Fatalf("tool_calls = %d, want the assistant turn unchanged: %s", n, body)
+	}
+	results := gjson.GetBytes(body, "agent_loop.tool_results").Array()
+	if len(results) != 1 || results[0].Get("tool_call_id").String() != "call_1" || results[0].Get("content").String() != "search results" {
+		t.Fatalf("unexpected server tool results: %s", body)
+	}
+	if gjson.GetBytes(body, "agent_loop.limit_reached").Bool() {
+		t.Fatalf("limit_reached should be false: %s", body)
+	}
+}
+
+func TestAgentChatCompletionsDropsProxyToolCallsAtLimit(t *testing.T) {
+	executor := &agentLoopExecutor{}
+	router := newAgentLoopRouterWithLimit(t, executor, 1)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/agent/chat/completions", strings.NewReader(`{"model":"agent-model","messages":[{"role":"user","content":"search go"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	if len(executor.payloads) != 1 {
+		t.Fatalf("model invocations = %d, want 1", len(executor.payloads))
+	}
+	body := resp.Body.Bytes()
+	if gjson.GetBytes(body, "choices.0.message.tool_calls").Exists() {
+		t.Fatalf("undeclared proxy tool calls leaked to the client: %s", body)
+	}
+	if gjson.GetBytes(body, "choices.0.finish_reason").String() != "stop" || !gjson.GetBytes(body, "agent_loop.limit_reached").Bool() {
+		t.Fatalf("unexpected finish_reason or loop trace: %s", body)
+	}
+}
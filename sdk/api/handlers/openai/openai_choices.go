@@ -0,0 +1,239 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/lifecycle"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// maxFanOutChoices caps the number of upstream requests issued for one Chat Completions request.
+const maxFanOutChoices = 8
+
+// singleChoiceProviders lists providers whose upstream APIs return one completion per request.
+var singleChoiceProviders = []string{"claude", "codex"}
+
+// fanOutChoices returns how many single-choice requests should serve a Chat Completions request
+// asking for n completions, or 0 when the request is sent as is. Requests fan out when any
+// provider that may serve the model cannot return several choices.
+func (h *OpenAIAPIHandler) fanOutChoices(rawJSON []byte) int {
+	n := int(gjson.GetBytes(rawJSON, "n").Int())
+	if n <= 1 {
+		return 0
+	}
+	for _, provider := range h.ModelProviders(gjson.GetBytes(rawJSON, "model").String()) {
+		if slices.Contains(singleChoiceProviders, provider) {
+			return min(n, maxFanOutChoices)
+		}
+	}
+	return 0
+}
+
+// executeChoices serves a non-streaming request for n choices with n concurrent single-choice
+// requests and merges their responses. The first failure cancels the other requests and is
+// returned.
+func (h *OpenAIAPIHandler) executeChoices(ctx context.Context, modelName string, rawJSON []byte, n int, alt string) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	single, _ := sjson.DeleteBytes(rawJSON, "n")
+	responses := make([][]byte, n)
+	headers := make([]http.Header, n)
+	var (
+		mu       sync.Mutex
+		firstErr *interfaces.ErrorMessage
+		wg       sync.WaitGroup
+	)
+	for i := range n {
+		wg.Go(func() {
+			var errMsg *interfaces.ErrorMessage
+			responses[i], headers[i], errMsg = h.ExecuteWithAuthManager(ctx, h.HandlerType(), modelName, single, alt)
+			if errMsg == nil {
+				return
+			}
+			mu.Lock()
+			if firstErr == nil {
+				firstErr = errMsg
+				cancel()
+			}
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, nil, firstErr
+	}
+	log.Debugf("served n=%d for %s with %d single-choice requests", n, modelName, n)
+	return mergeChoiceResponses(responses), headers[0], nil
+}
+
+// mergeChoiceResponses combines single-choice Chat Completions responses into one response whose
+// choices are numbered in order, adding up their usage.
+func mergeChoiceResponses(responses [][]byte) []byte {
+	out := responses[0]
+	out, _ = sjson.SetRawBytes(out, "choices", []byte(`[]`))
+	var usage choiceUsage
+	for i, resp := range responses {
+		for _, choice := range gjson.GetBytes(resp, "choices").Array() {
+			renumbered, _ := sjson.SetBytes([]byte(choice.Raw), "index", i)
+			out, _ = sjson.SetRawBytes(out, "choices.-1", renumbered)
+		}
+		usage.add(gjson.GetBytes(resp, "usage"))
+	}
+	if usage.seen {
+		out = usage.apply(out)
+	}
+	return out
+}
+
+// streamChoices serves a streaming request for n choices with n concurrent single-choice streams.
+// Chunks are forwarded as they arrive with their choice index set to the stream's position; usage
+// last reported by each stream is added up and sent in one final chunk. The first error ends the
+// merged stream and cancels the other streams.
+func (h *OpenAIAPIHandler) streamChoices(ctx context.Context, modelName string, rawJSON []byte, n int, alt string) (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage) {
+	ctx, cancel := context.WithCancel(ctx)
+	single, _ := sjson.DeleteBytes(rawJSON, "n")
+	dataChans := make([]<-chan []byte, n)
+	errChans := make([]<-chan *interfaces.ErrorMessage, n)
+	var upstreamHeaders http.Header
+	for i := range n {
+		var headers http.Header
+		dataChans[i], headers, errChans[i] = h.ExecuteStreamWithAuthManager(ctx, h.HandlerType(), modelName, single, alt)
+		if i == 0 {
+			upstreamHeaders = headers
+		}
+	}
+
+	out := make(chan []byte)
+	errOut := make(chan *interfaces.ErrorMessage, 1)
+	var (
+		mu       sync.Mutex
+		usages   = make([]gjson.Result, n)
+		template []byte
+		failed   bool
+	)
+	fail := func(errMsg *interfaces.ErrorMessage) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !failed {
+			failed = true
+			errOut <- errMsg
+			cancel()
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		lifecycle.Go(ctx, "handlers.openai.choice", func() {
+			defer wg.Done()
+			data, errs := dataChans[i], errChans[i]
+			for data != nil || errs != nil {
+				select {
+				case <-ctx.Done():
+					return
+				case errMsg, ok := <-errs:
+					if !ok {
+						errs = nil
+						continue
+					}
+					if errMsg != nil {
+						fail(errMsg)
+						return
+					}
+				case chunk, ok := <-data:
+					if !ok {
+						data = nil
+						continue
+					}
+					chunk = bytes.TrimSpace(chunk)
+					if bytes.Equal(chunk, []byte("[DONE]")) {
+						continue
+					}
+					if gjson.ValidBytes(chunk) {
+						mu.Lock()
+						if usageResult := gjson.GetBytes(chunk, "usage"); usageResult.IsObject() {
+							usages[i] = usageResult
+							chunk, _ = sjson.DeleteBytes(chunk, "usage")
+						}
+						if template == nil {
+							template = chunk
+						}
+						mu.Unlock()
+						if len(gjson.GetBytes(chunk, "choices").Array()) == 0 {
+							continue
+						}
+						chunk = renumberChoices(chunk, i)
+					}
+					select {
+					case out <- chunk:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		})
+	}
+
+	lifecycle.Go(ctx, "handlers.openai.choices", func() {
+		defer close(out)
+		defer close(errOut)
+		defer cancel()
+		wg.Wait()
+		mu.Lock()
+		defer mu.Unlock()
+		var usage choiceUsage
+		for _, usageResult := range usages {
+			usage.add(usageResult)
+		}
+		if failed || !usage.seen || template == nil {
+			return
+		}
+		final, _ := sjson.SetRawBytes(template, "choices", []byte(`[]`))
+		select {
+		case out <- usage.apply(final):
+		case <-ctx.Done():
+		}
+	})
+	return out, upstreamHeaders, errOut
+}
+
+// renumberChoices sets the index of every choice in a stream chunk.
+func renumberChoices(chunk []byte, index int) []byte {
+	for j := range gjson.GetBytes(chunk, "choices").Array() {
+		chunk, _ = sjson.SetBytes(chunk, fmt.Sprintf("choices.%d.index", j), index)
+	}
+	return chunk
+}
+
+// choiceUsage adds up the token usage of the requests that served one set of choices.
+type choiceUsage struct {
+	seen             bool
+	promptTokens     int64
+	completionTokens int64
+	totalTokens      int64
+}
+
+func (u *choiceUsage) add(usage gjson.Result) {
+	if !usage.IsObject() {
+		return
+	}
+	u.seen = true
+	u.promptTokens += usage.Get("prompt_tokens").Int()
+	u.completionTokens += usage.Get("completion_tokens").Int()
+	u.totalTokens += usage.Get("total_tokens").Int()
+}
+
+func (u *choiceUsage) apply(out []byte) []byte {
+	out, _ = sjson.SetBytes(out, "usage.prompt_tokens", u.promptTokens)
+	out, _ = sjson.SetBytes(out, "usage.completion_tokens", u.completionTokens)
+	out, _ = sjson.SetBytes(out, "usage.total_tokens", u.totalTokens)
+	return out
+}
@@ -0,0 +1,244 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/lifecycle/lifecycletest"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+type singleChoiceExecutor struct {
+	calls     atomic.Int32
+	sawChoice atomic.Bool
+}
+
+func (e *singleChoiceExecutor) Identifier() string { return "claude" }
+
+func (e *singleChoiceExecutor) Execute(ctx context.Context, auth *coreauth.Auth, req coreexecutor.Request, opts coreexecutor.Options) (coreexecutor.Response, error) {
+	e.calls.Add(1)
+	if gjson.GetBytes(req.Payload, "n").Exists() {
+		e.sawChoice.Store(true)
+	}
+	return coreexecutor.Response{Payload: []byte(`{"id":"chatcmpl-1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}`)}, nil
+}
+
+func (e *singleChoiceExecutor) ExecuteStream(ctx context.Context, auth *coreauth.Auth, req coreexecutor.Request, opts coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	e.calls.Add(1)
+	if gjson.GetBytes(req.Payload, "n").Exists() {
+		e.sawChoice.Store(true)
+	}
+	chunks := make(chan coreexecutor.StreamChunk, 2)
+	chunks <- coreexecutor.StreamChunk{Payload: []byte(`{"id":"chatcmpl-1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"hi"},"finish_reason":"stop"}]}`)}
+	chunks <- coreexecutor.StreamChunk{Payload: []byte(`{"id":"chatcmpl-1","object":"chat.completion.chunk","choices":[],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}`)}
+	close(chunks)
+	return &coreexecutor.StreamResult{Chunks: chunks}, nil
+}
+
+func (e *singleChoiceExecutor) Refresh(ctx context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *singleChoiceExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *singleChoiceExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func newChoicesTestRouter(t *testing.T, executor *singleChoiceExecutor) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+
+	auth := &coreauth.Auth{ID: "choices-auth", Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "choices-model"}})
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient(auth.ID)
+	})
+
+	h := NewOpenAIAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager))
+	router := gin.New()
+	router.POST("/v1/chat/completions", h.ChatCompletions)
+	return router
+}
+
+func TestChatCompletionsFansOutChoices(t *testing.T) {
+	executor := &singleChoiceExecutor{}
+	router := newChoicesTestRouter(t, executor)
+
+	body := `{"model":"choices-model","n":3,"messages":[{"role":"user","content":"hello"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	if got := executor.calls.Load(); got != 3 {
+		t.Fatalf("executor calls = %d, want 3", got)
+	}
+	if executor.sawChoice.Load() {
+		t.Fatal("fanned-out requests must not carry n")
+	}
+	choices := gjson.Get(resp.Body.String(), "choices").Array()
+	if len(choices) != 3 {
+		t.Fatalf("choices = %d, want 3: %s", len(choices), resp.Body.String())
+	}
+	for i, choice := range choices {
+		if choice.Get("index").Int() != int64(i) {
+			t.Fatalf("choice %d has index %d", i, choice.Get("index").Int())
+		}
+	}
+	if got := gjson.Get(resp.Body.String(), "usage.total_tokens").Int(); got != 15 {
+		t.Fatalf("usage.total_tokens = %d, want 15", got)
+	}
+}
+
+func TestChatCompletionsStreamFansOutChoices(t *testing.T) {
+	lifecycletest.VerifyNone(t)
+	executor := &singleChoiceExecutor{}
+	router := newChoicesTestRouter(t, executor)
+
+	body := `{"model":"choices-model","n":2,"stream":true,"messages":[{"role":"user","content":"hello"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	indexes := map[int64]bool{}
+	usageChunks := 0
+	for line := range strings.SplitSeq(resp.Body.String(), "\n") {
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok || data == "[DONE]" {
+			continue
+		}
+		for _, choice := range gjson.Get(data, "choices").Array() {
+			indexes[choice.Get("index").Int()] = true
+		}
+		if usage := gjson.Get(data, "usage"); usage.Exists() {
+			usageChunks++
+			if usage.Get("prompt_tokens").Int() != 6 {
+				t.Fatalf("usage = %s, want summed prompt tokens", usage.Raw)
+			}
+		}
+	}
+	if !indexes[0] || !indexes[1] || len(indexes) != 2 {
+		t.Fatalf("choice indexes = %v, want 0 and 1: %s", indexes, resp.Body.String())
+	}
+	if usageChunks != 1 {
+		t.Fatalf("usage chunks = %d, want 1: %s", usageChunks, resp.Body.String())
+	}
+}
+
+// failingChoiceExecutor fails its first request once the other n-1 have started; the others wait
+// for cancellation and count themselves as completed when it never comes.
+type failingChoiceExecutor struct {
+	singleChoiceExecutor
+	n         int32
+	completed atomic.Int32
+}
+
+func (e *failingChoiceExecutor) awaitSiblings() {
+	deadline := time.Now().Add(time.Second)
+	for e.calls.Load() < e.n && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func (e *failingChoiceExecutor) waitForCancel(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		e.completed.Add(1)
+	}
+}
+
+func (e *failingChoiceExecutor) Execute(ctx context.Context, _ *coreauth.Auth, _ coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	if e.calls.Add(1) == 1 {
+		e.awaitSiblings()
+		return coreexecutor.Response{}, &coreauth.Error{Code: "invalid_request", Message: "boom", HTTPStatus: http.StatusBadRequest}
+	}
+	e.waitForCancel(ctx)
+	return coreexecutor.Response{Payload: []byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"late"},"finish_reason":"stop"}]}`)}, nil
+}
+
+func (e *failingChoiceExecutor) ExecuteStream(ctx context.Context, _ *coreauth.Auth, _ coreexecutor.Request, _ coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	failing := e.calls.Add(1) == e.n
+	chunks := make(chan coreexecutor.StreamChunk)
+	go func() {
+		defer close(chunks)
+		chunks <- coreexecutor.StreamChunk{Payload: []byte(`{"id":"chatcmpl-1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"hi"}}]}`)}
+		if failing {
+			chunks <- coreexecutor.StreamChunk{Err: errors.New("boom")}
+			return
+		}
+		e.waitForCancel(ctx)
+	}()
+	return &coreexecutor.StreamResult{Chunks: chunks}, nil
+}
+
+func newFailingChoicesTestRouter(t *testing.T, n int32) (*gin.Engine, *failingChoiceExecutor) {
+	t.Helper()
+	executor := &failingChoiceExecutor{n: n}
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "choices-auth", Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "choices-model"}})
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient(auth.ID)
+	})
+
+	h := NewOpenAIAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager))
+	router := gin.New()
+	router.POST("/v1/chat/completions", h.ChatCompletions)
+	return router, executor
+}
+
+func TestChatCompletionsFanOutFailureCancelsSiblings(t *testing.T) {
+	for _, stream := range []bool{false, true} {
+		router, executor := newFailingChoicesTestRouter(t, 3)
+		body := `{"model":"choices-model","n":3,"stream":` + strconv.FormatBool(stream) + `,"messages":[{"role":"user","content":"hello"}]}`
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		start := time.Now()
+		router.ServeHTTP(resp, req)
+
+		if !strings.Contains(resp.Body.String(), "boom") {
+			t.Fatalf("stream=%v: expected the failure to be returned, got %d %s", stream, resp.Code, resp.Body.String())
+		}
+		if elapsed := time.Since(start); elapsed > 3*time.Second {
+			t.Fatalf("stream=%v: request took %s, siblings were not cancelled", stream, elapsed)
+		}
+		if got := executor.completed.Load(); got != 0 {
+			t.Fatalf("stream=%v: %d sibling requests ran to completion after the failure", stream, got)
+		}
+	}
+}
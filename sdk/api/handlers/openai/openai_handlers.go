@@ -55,6 +55,18 @@ func (h *OpenAIAPIHandler) Models() []map[string]any {
 	return modelRegistry.GetAvailableModels("openai")
 }
 
+// openAIModelListFields are the optional model fields included in the /v1/models listing.
+var openAIModelListFields = []string{
+	"created",
+	"owned_by",
+	"context_length",
+	"max_completion_tokens",
+	"input_modalities",
+	"output_modalities",
+	"pricing",
+	"providers",
+}
+
 // OpenAIModels handles the /v1/models endpoint.
 // It returns a list of available AI models with their capabilities
 // and specifications in OpenAI-compatible format.
@@ -62,24 +74,19 @@ func (h *OpenAIAPIHandler) OpenAIModels(c *gin.Context) {
 	// Get all available models
 	allModels := h.Models()
 
-	// Filter to only include the 4 required fields: id, object, created, owned_by
+	// Keep the standard fields (id, object, created, owned_by) plus the capability metadata
+	// clients use for auto-configuration.
 	filteredModels := make([]map[string]any, len(allModels))
 	for i, model := range allModels {
 		filteredModel := map[string]any{
 			"id":     model["id"],
 			"object": model["object"],
 		}
-
-		// Add created field if it exists
-		if created, exists := model["created"]; exists {
-			filteredModel["created"] = created
-		}
-
-		// Add owned_by field if it exists
-		if ownedBy, exists := model["owned_by"]; exists {
-			filteredModel["owned_by"] = ownedBy
+		for _, key := range openAIModelListFields {
+			if value, exists := model[key]; exists {
+				filteredModel[key] = value
+			}
 		}
-
 		filteredModels[i] = filteredModel
 	}
 
@@ -120,10 +127,19 @@ func (h *OpenAIAPIHandler) ChatCompletions(c *gin.Context) {
 		stream = gjson.GetBytes(rawJSON, "stream").Bool()
 	}
 
+	// Older clients still send functions/function_call. Upgrade them to tools and answer in
+	// the legacy shape they asked for.
+	rawJSON, legacyFunctions := upgradeLegacyFunctionsRequest(rawJSON)
+
+	if errMsg := h.ValidateRequest(h.HandlerType(), rawJSON); errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		return
+	}
+
 	if stream {
-		h.handleStreamingResponse(c, rawJSON)
+		h.handleStreamingResponse(c, rawJSON, legacyFunctions)
 	} else {
-		h.handleNonStreamingResponse(c, rawJSON)
+		h.handleNonStreamingResponse(c, rawJSON, legacyFunctions)
 	}
 
 }
@@ -426,18 +442,31 @@ func convertChatCompletionsStreamChunkToCompletions(chunkData []byte) []byte {
 // Parameters:
 //   - c: The Gin context containing the HTTP request and response
 //   - rawJSON: The raw JSON bytes of the OpenAI-compatible request
-func (h *OpenAIAPIHandler) handleNonStreamingResponse(c *gin.Context, rawJSON []byte) {
+//   - legacyFunctions: Whether the response must use the legacy function_call shape
+func (h *OpenAIAPIHandler) handleNonStreamingResponse(c *gin.Context, rawJSON []byte, legacyFunctions bool) {
 	c.Header("Content-Type", "application/json")
 
 	modelName := gjson.GetBytes(rawJSON, "model").String()
 	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
-	resp, upstreamHeaders, errMsg := h.ExecuteWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, h.GetAlt(c))
+	var (
+		resp            []byte
+		upstreamHeaders http.Header
+		errMsg          *interfaces.ErrorMessage
+	)
+	if n := h.fanOutChoices(rawJSON); n > 0 {
+		resp, upstreamHeaders, errMsg = h.executeChoices(cliCtx, modelName, rawJSON, n, h.GetAlt(c))
+	} else {
+		resp, upstreamHeaders, errMsg = h.ExecuteWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, h.GetAlt(c))
+	}
 	if errMsg != nil {
 		h.WriteErrorResponse(c, errMsg)
 		cliCancel(errMsg.Error)
 		return
 	}
 	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+	if legacyFunctions {
+		resp = downgradeToolCallsResponse(resp)
+	}
 	_, _ = c.Writer.Write(resp)
 	cliCancel()
 }
@@ -449,7 +478,8 @@ func (h *OpenAIAPIHandler) handleNonStreamingResponse(c *gin.Context, rawJSON []
 // Parameters:
 //   - c: The Gin context containing the HTTP request and response
 //   - rawJSON: The raw JSON bytes of the OpenAI-compatible request
-func (h *OpenAIAPIHandler) handleStreamingResponse(c *gin.Context, rawJSON []byte) {
+//   - legacyFunctions: Whether the chunks must use the legacy function_call shape
+func (h *OpenAIAPIHandler) handleStreamingResponse(c *gin.Context, rawJSON []byte, legacyFunctions bool) {
 	// Get the http.Flusher interface to manually flush the response.
 	flusher, ok := c.Writer.(http.Flusher)
 	if !ok {
@@ -464,7 +494,16 @@ func (h *OpenAIAPIHandler) handleStreamingResponse(c *gin.Context, rawJSON []byt
 
 	modelName := gjson.GetBytes(rawJSON, "model").String()
 	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
-	dataChan, upstreamHeaders, errChan := h.ExecuteStreamWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, h.GetAlt(c))
+	var (
+		dataChan        <-chan []byte
+		upstreamHeaders http.Header
+		errChan         <-chan *interfaces.ErrorMessage
+	)
+	if n := h.fanOutChoices(rawJSON); n > 0 {
+		dataChan, upstreamHeaders, errChan = h.streamChoices(cliCtx, modelName, rawJSON, n, h.GetAlt(c))
+	} else {
+		dataChan, upstreamHeaders, errChan = h.ExecuteStreamWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, h.GetAlt(c))
+	}
 
 	setSSEHeaders := func() {
 		c.Header("Content-Type", "text/event-stream")
@@ -508,11 +547,25 @@ func (h *OpenAIAPIHandler) handleStreamingResponse(c *gin.Context, rawJSON []byt
 			setSSEHeaders()
 			handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
 
-			_, _ = fmt.Fprintf(c.Writer, "data: %s\n\n", string(chunk))
+			if !legacyFunctions {
+				_, _ = fmt.Fprintf(c.Writer, "data: %s\n\n", string(chunk))
+				flusher.Flush()
+
+				// Continue streaming the rest
+				h.handleStreamResult(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan)
+				return
+			}
+
+			_, _ = fmt.Fprintf(c.Writer, "data: %s\n\n", string(downgradeToolCallsStreamChunk(chunk)))
 			flusher.Flush()
 
-			// Continue streaming the rest
-			h.handleStreamResult(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan)
+			done := make(chan struct{})
+			var doneOnce sync.Once
+			stop := func() { doneOnce.Do(func() { close(done) }) }
+			h.handleStreamResult(c, flusher, func(err error) {
+				stop()
+				cliCancel(err)
+			}, convertStreamChunks(done, dataChan, downgradeToolCallsStreamChunk), errChan)
 			return
 		}
 	}
@@ -625,38 +678,43 @@ func (h *OpenAIAPIHandler) handleCompletionsStreamingResponse(c *gin.Context, ra
 			var doneOnce sync.Once
 			stop := func() { doneOnce.Do(func() { close(done) }) }
 
-			convertedChan := make(chan []byte)
-			go func() {
-				defer close(convertedChan)
-				for {
-					select {
-					case <-done:
-						return
-					case chunk, ok := <-dataChan:
-						if !ok {
-							return
-						}
-						converted := convertChatCompletionsStreamChunkToCompletions(chunk)
-						if converted == nil {
-							continue
-						}
-						select {
-						case <-done:
-							return
-						case convertedChan <- converted:
-						}
-					}
-				}
-			}()
-
 			h.handleStreamResult(c, flusher, func(err error) {
 				stop()
 				cliCancel(err)
-			}, convertedChan, errChan)
+			}, convertStreamChunks(done, dataChan, convertChatCompletionsStreamChunkToCompletions), errChan)
 			return
 		}
 	}
 }
+
+// convertStreamChunks applies convert to every chunk of data until data closes or done is
+// closed. Chunks converted to nil are skipped.
+func convertStreamChunks(done <-chan struct{}, data <-chan []byte, convert func([]byte) []byte) <-chan []byte {
+	convertedChan := make(chan []byte)
+	go func() {
+		defer close(convertedChan)
+		for {
+			select {
+			case <-done:
+				return
+			case chunk, ok := <-data:
+				if !ok {
+					return
+				}
+				converted := convert(chunk)
+				if converted == nil {
+					continue
+				}
+				select {
+				case <-done:
+					return
+				case convertedChan <- converted:
+				}
+			}
+		}
+	}()
+	return convertedChan
+}
 func (h *OpenAIAPIHandler) handleStreamResult(c *gin.Context, flusher http.Flusher, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage) {
 	h.ForwardStream(c, flusher, cancel, data, errs, handlers.StreamForwardOptions{
 		WriteChunk: func(chunk []byte) {
@@ -0,0 +1,136 @@
+package openai
+
+import (
+	"fmt"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// upgradeLegacyFunctionsRequest rewrites the deprecated functions/function_call fields of a
+// Chat Completions request, and the assistant function_call and role "function" messages of its
+// history, to the tools schema understood by every translator. It reports whether the request
+// used the legacy top-level fields, in which case responses are converted back with
+// downgradeToolCallsResponse and downgradeToolCallsStreamChunk.
+func upgradeLegacyFunctionsRequest(rawJSON []byte) ([]byte, bool) {
+	root := gjson.ParseBytes(rawJSON)
+	functions := root.Get("functions")
+	functionCall := root.Get("function_call")
+	legacy := functions.Exists() || functionCall.Exists()
+	out := rawJSON
+
+	if legacy {
+		if functions.IsArray() && !root.Get("tools").Exists() {
+			tools := []byte(`[]`)
+			for _, function := range functions.Array() {
+				tool := []byte(`{"type":"function","function":{}}`)
+				tool, _ = sjson.SetRawBytes(tool, "function", []byte(function.Raw))
+				tools, _ = sjson.SetRawBytes(tools, "-1", tool)
+			}
+			out, _ = sjson.SetRawBytes(out, "tools", tools)
+		}
+		if functionCall.Exists() && !root.Get("tool_choice").Exists() {
+			if functionCall.IsObject() {
+				choice := []byte(`{"type":"function","function":{"name":""}}`)
+				choice, _ = sjson.SetBytes(choice, "function.name", functionCall.Get("name").String())
+				out, _ = sjson.SetRawBytes(out, "tool_choice", choice)
+			} else {
+				out, _ = sjson.SetBytes(out, "tool_choice", functionCall.String())
+			}
+		}
+		// The legacy API returns at most one call per turn.
+		if !root.Get("parallel_tool_calls").Exists() {
+			out, _ = sjson.SetBytes(out, "parallel_tool_calls", false)
+		}
+		out, _ = sjson.DeleteBytes(out, "functions")
+		out, _ = sjson.DeleteBytes(out, "function_call")
+	}
+
+	// Legacy calls carry no identifiers, so each one gets a synthetic id that the next function
+	// result with the same name refers to.
+	pending := make(map[string][]string)
+	var lastID string
+	for i, message := range root.Get("messages").Array() {
+		switch {
+		case message.Get("role").String() == "assistant" && message.Get("function_call").IsObject():
+			name := message.Get("function_call.name").String()
+			id := fmt.Sprintf("call_legacy_%d", i)
+			call := []byte(`{"id":"","type":"function","function":{"name":"","arguments":""}}`)
+			call, _ = sjson.SetBytes(call, "id", id)
+			call, _ = sjson.SetBytes(call, "function.name", name)
+			call, _ = sjson.SetBytes(call, "function.arguments", message.Get("function_call.arguments").String())
+			out, _ = sjson.SetRawBytes(out, fmt.Sprintf("messages.%d.tool_calls", i), []byte(`[`+string(call)+`]`))
+			out, _ = sjson.DeleteBytes(out, fmt.Sprintf("messages.%d.function_call", i))
+			pending[name] = append(pending[name], id)
+			lastID = id
+		case message.Get("role").String() == "function":
+			name := message.Get("name").String()
+			id := lastID
+			if ids := pending[name]; len(ids) > 0 {
+				id = ids[0]
+				pending[name] = ids[1:]
+			}
+			if id == "" {
+				id = fmt.Sprintf("call_legacy_%d", i)
+			}
+			out, _ = sjson.SetBytes(out, fmt.Sprintf("messages.%d.role", i), "tool")
+			out, _ = sjson.SetBytes(out, fmt.Sprintf("messages.%d.tool_call_id", i), id)
+			out, _ = sjson.DeleteBytes(out, fmt.Sprintf("messages.%d.name", i))
+		}
+	}
+	return out, legacy
+}
+
+// downgradeToolCallsResponse converts the tool_calls of a Chat Completions response to the
+// function_call shape expected by clients that sent a legacy request.
+func downgradeToolCallsResponse(rawJSON []byte) []byte {
+	out := rawJSON
+	for i, choice := range gjson.GetBytes(rawJSON, "choices").Array() {
+		calls := choice.Get("message.tool_calls").Array()
+		if len(calls) > 0 {
+			functionCall := []byte(`{"name":"","arguments":""}`)
+			functionCall, _ = sjson.SetBytes(functionCall, "name", calls[0].Get("function.name").String())
+			functionCall, _ = sjson.SetBytes(functionCall, "arguments", calls[0].Get("function.arguments").String())
+			out, _ = sjson.SetRawBytes(out, fmt.Sprintf("choices.%d.message.function_call", i), functionCall)
+			out, _ = sjson.DeleteBytes(out, fmt.Sprintf("choices.%d.message.tool_calls", i))
+		}
+		out = downgradeFinishReason(out, i, choice)
+	}
+	return out
+}
+
+// downgradeToolCallsStreamChunk converts the tool_calls deltas of a Chat Completions stream
+// chunk to function_call deltas. Only the first call of a turn is kept, as in the legacy API.
+func downgradeToolCallsStreamChunk(chunk []byte) []byte {
+	if !gjson.ValidBytes(chunk) {
+		return chunk
+	}
+	out := chunk
+	for i, choice := range gjson.GetBytes(chunk, "choices").Array() {
+		calls := choice.Get("delta.tool_calls")
+		if calls.Exists() {
+			for _, call := range calls.Array() {
+				if call.Get("index").Int() != 0 {
+					continue
+				}
+				functionCall := []byte(`{"arguments":""}`)
+				if name := call.Get("function.name"); name.Exists() && name.String() != "" {
+					functionCall, _ = sjson.SetBytes(functionCall, "name", name.String())
+				}
+				functionCall, _ = sjson.SetBytes(functionCall, "arguments", call.Get("function.arguments").String())
+				out, _ = sjson.SetRawBytes(out, fmt.Sprintf("choices.%d.delta.function_call", i), functionCall)
+			}
+			out, _ = sjson.DeleteBytes(out, fmt.Sprintf("choices.%d.delta.tool_calls", i))
+		}
+		out = downgradeFinishReason(out, i, choice)
+	}
+	return out
+}
+
+func downgradeFinishReason(out []byte, index int, choice gjson.Result) []byte {
+	if choice.Get("finish_reason").String() != "tool_calls" {
+		return out
+	}
+	out, _ = sjson.SetBytes(out, fmt.Sprintf("choices.%d.finish_reason", index), "function_call")
+	return out
+}
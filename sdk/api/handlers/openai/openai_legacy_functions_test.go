@@ -0,0 +1,80 @@
+package openai
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestUpgradeLegacyFunctionsRequest(t *testing.T) {
+	raw := []byte(`{"model":"gpt-4o","functions":[{"name":"get_weather","parameters":{"type":"object","properties":{"city":{"type":"string"}}}}],"function_call":{"name":"get_weather"},"messages":[
+		{"role":"user","content":"Weather in Paris?"},
+		{"role":"assistant","content":null,"function_call":{"name":"get_weather","arguments":"{\"city\":\"Paris\"}"}},
+		{"role":"function","name":"get_weather","content":"sunny"}
+	]}`)
+
+	out, legacy := upgradeLegacyFunctionsRequest(raw)
+	if !legacy {
+		t.Fatal("expected the request to be detected as legacy")
+	}
+	root := gjson.ParseBytes(out)
+	if root.Get("functions").Exists() || root.Get("function_call").Exists() {
+		t.Fatalf("legacy fields not removed: %s", out)
+	}
+	if root.Get("tools.0.type").String() != "function" || root.Get("tools.0.function.name").String() != "get_weather" {
+		t.Fatalf("tools = %s", root.Get("tools").Raw)
+	}
+	if root.Get("tool_choice.function.name").String() != "get_weather" {
+		t.Fatalf("tool_choice = %s", root.Get("tool_choice").Raw)
+	}
+	if root.Get("parallel_tool_calls").Bool() {
+		t.Fatal("expected parallel tool calls to be disabled")
+	}
+
+	callID := root.Get("messages.1.tool_calls.0.id").String()
+	if callID == "" || root.Get("messages.1.tool_calls.0.function.arguments").String() != `{"city":"Paris"}` || root.Get("messages.1.function_call").Exists() {
+		t.Fatalf("assistant message = %s", root.Get("messages.1").Raw)
+	}
+	if root.Get("messages.2.role").String() != "tool" || root.Get("messages.2.tool_call_id").String() != callID || root.Get("messages.2.name").Exists() {
+		t.Fatalf("function result message = %s", root.Get("messages.2").Raw)
+	}
+
+	modern := []byte(`{"model":"gpt-4o","tools":[],"messages":[{"role":"user","content":"hi"}]}`)
+	if out, legacy := upgradeLegacyFunctionsRequest(modern); legacy || string(out) != string(modern) {
+		t.Fatalf("modern request changed: %s", out)
+	}
+
+	auto := []byte(`{"functions":[{"name":"f"}],"function_call":"auto","messages":[]}`)
+	if out, _ := upgradeLegacyFunctionsRequest(auto); gjson.GetBytes(out, "tool_choice").String() != "auto" {
+		t.Fatalf("tool_choice = %s", gjson.GetBytes(out, "tool_choice").Raw)
+	}
+}
+
+func TestDowngradeToolCallsResponse(t *testing.T) {
+	resp := []byte(`{"id":"chatcmpl-1","choices":[{"index":0,"message":{"role":"assistant","content":null,"tool_calls":[{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Paris\"}"}}]},"finish_reason":"tool_calls"}]}`)
+	out := gjson.ParseBytes(downgradeToolCallsResponse(resp))
+	if out.Get("choices.0.message.tool_calls").Exists() {
+		t.Fatalf("tool_calls not removed: %s", out.Raw)
+	}
+	if out.Get("choices.0.message.function_call.name").String() != "get_weather" || out.Get("choices.0.message.function_call.arguments").String() != `{"city":"Paris"}` {
+		t.Fatalf("function_call = %s", out.Get("choices.0.message.function_call").Raw)
+	}
+	if out.Get("choices.0.finish_reason").String() != "function_call" {
+		t.Fatalf("finish_reason = %s", out.Get("choices.0.finish_reason").String())
+	}
+}
+
+func TestDowngradeToolCallsStreamChunk(t *testing.T) {
+	first := gjson.ParseBytes(downgradeToolCallsStreamChunk([]byte(`{"choices":[{"index":0,"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":""}}]}}]}`)))
+	if first.Get("choices.0.delta.tool_calls").Exists() || first.Get("choices.0.delta.function_call.name").String() != "get_weather" {
+		t.Fatalf("first chunk = %s", first.Raw)
+	}
+	next := gjson.ParseBytes(downgradeToolCallsStreamChunk([]byte(`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\""}}]}}]}`)))
+	if next.Get("choices.0.delta.function_call.name").Exists() || next.Get("choices.0.delta.function_call.arguments").String() != `{"city"` {
+		t.Fatalf("argument chunk = %s", next.Raw)
+	}
+	last := gjson.ParseBytes(downgradeToolCallsStreamChunk([]byte(`{"choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`)))
+	if last.Get("choices.0.finish_reason").String() != "function_call" {
+		t.Fatalf("final chunk = %s", last.Raw)
+	}
+}
@@ -370,7 +370,7 @@ func recordResponsesWebsocketToolCallsFromPayloadWithCache(cache *websocketToolO
 
 	eventType := strings.TrimSpace(gjson.GetBytes(payload, "type").String())
 	switch eventType {
-	case "response.completed":
+	case "response.completed", "response.incomplete":
 		output := gjson.GetBytes(payload, "response.output")
 		if !output.Exists() || !output.IsArray() {
 			return
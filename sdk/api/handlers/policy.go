@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+// policyUsageProvider is the provider name recorded in usage statistics for policy denials.
+const policyUsageProvider = "policy"
+
+var (
+	policyTemperaturePaths = []string{"temperature", "generationConfig.temperature", "request.generationConfig.temperature"}
+	policyMaxTokensPaths   = []string{
+		"max_tokens",
+		"max_completion_tokens",
+		"max_output_tokens",
+		"generationConfig.maxOutputTokens",
+		"request.generationConfig.maxOutputTokens",
+	}
+	policyToolPaths = []string{"tools", "functions", "request.tools"}
+)
+
+// apiKeyFromContext returns the client API key stored on the gin context by the auth middleware.
+func apiKeyFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return ""
+	}
+	if v, exists := ginCtx.Get("apiKey"); exists {
+		if key, isString := v.(string); isString {
+			return key
+		}
+	}
+	return ""
+}
+
+// apiKeyPolicy returns the policy configured for apiKey, if any.
+func apiKeyPolicy(cfg *config.SDKConfig, apiKey string) *config.APIKeyPolicy {
+	if cfg == nil || apiKey == "" {
+		return nil
+	}
+	for i := range cfg.APIKeyPolicies {
+		if cfg.APIKeyPolicies[i].APIKey == apiKey {
+			return &cfg.APIKeyPolicies[i]
+		}
+	}
+	return nil
+}
+
+// withReasoningFormat applies the reasoning format of the calling API key to OpenAI Chat
+// Completions responses translated under the returned context.
+func (h *BaseAPIHandler) withReasoningFormat(ctx context.Context, handlerType string) context.Context {
+	if ctx == nil || handlerType != string(sdktranslator.FormatOpenAI) {
+		return ctx
+	}
+	policy := apiKeyPolicy(h.Cfg, apiKeyFromContext(ctx))
+	if policy == nil || policy.ReasoningFormat == "" {
+		return ctx
+	}
+	return sdktranslator.WithReasoningFormat(ctx, strings.ToLower(strings.TrimSpace(policy.ReasoningFormat)))
+}
+
+// enforceAPIKeyPolicy validates the request against the policy of the calling API key.
+// Violations are recorded in usage statistics and returned as an error shaped for the
+// caller's API dialect: 403 for disallowed models and 400 for parameter limits.
+func (h *BaseAPIHandler) enforceAPIKeyPolicy(ctx context.Context, handlerType, modelName string, rawJSON []byte) *interfaces.ErrorMessage {
+	apiKey := apiKeyFromContext(ctx)
+	policy := apiKeyPolicy(h.Cfg, apiKey)
+	if policy == nil {
+		return nil
+	}
+
+	status, reason := checkAPIKeyPolicy(policy, modelName, rawJSON)
+	if status == 0 {
+		return nil
+	}
+
+	coreusage.PublishRecord(ctx, coreusage.Record{
+		Provider:     policyUsageProvider,
+		Model:        modelName,
+		APIKey:       apiKey,
+		RequestedAt:  time.Now(),
+		Failed:       true,
+		FailureClass: coreusage.FailureClassPolicy,
+		PolicyDenied: true,
+	})
+	return &interfaces.ErrorMessage{
+		StatusCode: status,
+		Error:      errors.New(string(buildPolicyErrorBody(handlerType, status, reason))),
+	}
+}
+
+// checkAPIKeyPolicy returns a non-zero HTTP status and a reason when the request violates policy.
+func checkAPIKeyPolicy(policy *config.APIKeyPolicy, modelName string, rawJSON []byte) (int, string) {
+	if len(policy.AllowedModels) > 0 {
+		baseModel := thinking.ParseSuffix(modelName).ModelName
+		allowed := false
+		for _, pattern := range policy.AllowedModels {
+			pattern = strings.TrimSpace(pattern)
+			if matchPolicyPattern(pattern, modelName) || matchPolicyPattern(pattern, baseModel) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return http.StatusForbidden, fmt.Sprintf("model %s is not allowed for this API key", modelName)
+		}
+	}
+
+	if policy.MaxTemperature != nil {
+		for _, path := range policyTemperaturePaths {
+			if value := gjson.GetBytes(rawJSON, path); value.Type == gjson.Number && value.Float() > *policy.MaxTemperature {
+				return http.StatusBadRequest, fmt.Sprintf("temperature %v exceeds the maximum of %v allowed for this API key", value.Float(), *policy.MaxTemperature)
+			}
+		}
+	}
+
+	if policy.MaxTokens > 0 {
+		for _, path := range policyMaxTokensPaths {
+			if value := gjson.GetBytes(rawJSON, path); value.Type == gjson.Number && value.Int() > policy.MaxTokens {
+				return http.StatusBadRequest, fmt.Sprintf("%s %d exceeds the maximum of %d allowed for this API key", path, value.Int(), policy.MaxTokens)
+			}
+		}
+	}
+
+	if policy.DisallowTools {
+		for _, path := range policyToolPaths {
+			if value := gjson.GetBytes(rawJSON, path); value.IsArray() && len(value.Array()) > 0 {
+				return http.StatusBadRequest, "tools are not allowed for this API key"
+			}
+		}
+	}
+
+	return 0, ""
+}
+
+// buildPolicyErrorBody renders a policy violation in the error format of the caller's API.
+func buildPolicyErrorBody(handlerType string, status int, reason string) []byte {
+	code := "parameter_limit_exceeded"
+	if status == http.StatusForbidden {
+		code = "model_not_allowed"
+	}
+	return buildDialectErrorBody(handlerType, status, code, reason)
+}
+
+// buildDialectErrorBody renders an error in the error format of the caller's API.
+// The code is only surfaced by the OpenAI formats, which carry a machine-readable code.
+func buildDialectErrorBody(handlerType string, status int, code, reason string) []byte {
+	var payload any
+	switch handlerType {
+	case constant.Claude:
+		errType := "invalid_request_error"
+		switch status {
+		case http.StatusForbidden:
+			errType = "permission_error"
+		case http.StatusGatewayTimeout:
+			errType = "timeout_error"
+		}
+		payload = map[string]any{
+			"type":  "error",
+			"error": map[string]any{"type": errType, "message": reason},
+		}
+	case constant.Gemini, constant.GeminiCLI:
+		errStatus := "INVALID_ARGUMENT"
+		switch status {
+		case http.StatusForbidden:
+			errStatus = "PERMISSION_DENIED"
+		case http.StatusGatewayTimeout:
+			errStatus = "DEADLINE_EXCEEDED"
+		}
+		payload = map[string]any{
+			"error": map[string]any{"code": status, "message": reason, "status": errStatus},
+		}
+	default:
+		errType := "invalid_request_error"
+		switch {
+		case status == http.StatusForbidden:
+			errType = "permission_error"
+		case status >= http.StatusInternalServerError:
+			errType = "server_error"
+		}
+		payload = ErrorResponse{Error: ErrorDetail{Message: reason, Type: errType, Code: code}}
+	}
+	body, errMarshal := json.Marshal(payload)
+	if errMarshal != nil {
+		return BuildErrorResponseBody(status, reason)
+	}
+	return body
+}
+
+// matchPolicyPattern performs case-insensitive matching where '*' matches any substring.
+func matchPolicyPattern(pattern, value string) bool {
+	if pattern == "" {
+		return false
+	}
+	pattern = strings.ToLower(pattern)
+	value = strings.ToLower(value)
+	if !strings.Contains(pattern, "*") {
+		return pattern == value
+	}
+
+	parts := strings.Split(pattern, "*")
+	if prefix := parts[0]; prefix != "" {
+		if !strings.HasPrefix(value, prefix) {
+			return false
+		}
+		value = value[len(prefix):]
+	}
+	if suffix := parts[len(parts)-1]; suffix != "" {
+		if !strings.HasSuffix(value, suffix) {
+			return false
+		}
+		value = value[:len(value)-len(suffix)]
+	}
+	for i := 1; i < len(parts)-1; i++ {
+		segment := parts[i]
+		if segment == "" {
+			continue
+		}
+		idx := strings.Index(value, segment)
+		if idx < 0 {
+			return false
+		}
+		value = value[idx+len(segment):]
+	}
+	return true
+}
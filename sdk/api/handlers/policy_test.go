@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func policyTestContext(apiKey string) context.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	c.Set("apiKey", apiKey)
+	return context.WithValue(context.Background(), "gin", c)
+}
+
+func TestEnforceAPIKeyPolicy(t *testing.T) {
+	maxTemperature := 1.0
+	handler := NewBaseAPIHandlers(&config.SDKConfig{
+		APIKeyPolicies: []config.APIKeyPolicy{{
+			APIKey:         "limited",
+			AllowedModels:  []string{"gpt-*"},
+			MaxTemperature: &maxTemperature,
+			MaxTokens:      100,
+			DisallowTools:  true,
+		}},
+	}, nil)
+
+	tests := []struct {
+		name        string
+		apiKey      string
+		handlerType string
+		model       string
+		body        string
+		wantStatus  int
+	}{
+		{name: "no policy", apiKey: "other", handlerType: constant.OpenAI, model: "claude-sonnet-4", body: `{"temperature":2}`},
+		{name: "allowed", apiKey: "limited", handlerType: constant.OpenAI, model: "gpt-5", body: `{"temperature":0.5,"max_tokens":50}`},
+		{name: "model denied", apiKey: "limited", handlerType: constant.OpenAI, model: "claude-sonnet-4", body: `{}`, wantStatus: http.StatusForbidden},
+		{name: "temperature", apiKey: "limited", handlerType: constant.Claude, model: "gpt-5", body: `{"temperature":1.5}`, wantStatus: http.StatusBadRequest},
+		{name: "max tokens", apiKey: "limited", handlerType: constant.Gemini, model: "gpt-5", body: `{"generationConfig":{"maxOutputTokens":500}}`, wantStatus: http.StatusBadRequest},
+		{name: "tools", apiKey: "limited", handlerType: constant.OpenAI, model: "gpt-5", body: `{"tools":[{"type":"function"}]}`, wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errMsg := handler.enforceAPIKeyPolicy(policyTestContext(tt.apiKey), tt.handlerType, tt.model, []byte(tt.body))
+			if tt.wantStatus == 0 {
+				if errMsg != nil {
+					t.Fatalf("unexpected policy error: %v", errMsg.Error)
+				}
+				return
+			}
+			if errMsg == nil {
+				t.Fatalf("expected status %d, got no error", tt.wantStatus)
+			}
+			if errMsg.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", errMsg.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestBuildPolicyErrorBodyUsesCallerDialect(t *testing.T) {
+	claude := buildPolicyErrorBody(constant.Claude, http.StatusForbidden, "denied")
+	if gjson.GetBytes(claude, "type").String() != "error" || gjson.GetBytes(claude, "error.type").String() != "permission_error" {
+		t.Fatalf("unexpected claude body: %s", claude)
+	}
+	gemini := buildPolicyErrorBody(constant.Gemini, http.StatusBadRequest, "denied")
+	if gjson.GetBytes(gemini, "error.status").String() != "INVALID_ARGUMENT" {
+		t.Fatalf("unexpected gemini body: %s", gemini)
+	}
+	openai := buildPolicyErrorBody(constant.OpenAI, http.StatusForbidden, "denied")
+	if gjson.GetBytes(openai, "error.code").String() != "model_not_allowed" {
+		t.Fatalf("unexpected openai body: %s", openai)
+	}
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/diagnostics"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor/helps"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// Response metadata headers emitted when response-metadata-headers is enabled.
+const (
+	ResponseProviderHeader        = "X-CLIProxy-Provider"
+	ResponseAuthHashHeader        = "X-CLIProxy-Auth-Hash"
+	ResponseUpstreamLatencyHeader = "X-CLIProxy-Upstream-Latency-Ms"
+	ResponseRetryCountHeader      = "X-CLIProxy-Retry-Count"
+	ResponseTranslationHeader     = "X-CLIProxy-Translation"
+	ResponseRequestIDHeader       = "X-CLIProxy-Request-Id"
+)
+
+// responseMetadata records which credentials were tried for a request and how long the
+// upstream call took, so the outcome can be reported in response headers.
+type responseMetadata struct {
+	start   time.Time
+	mu      sync.Mutex
+	authIDs []string
+}
+
+// trackResponseMetadata installs a selected-auth callback on the execution metadata that
+// records every credential attempt. Any callback already present is still invoked.
+// It returns nil when neither response metadata headers nor failure diagnostics are enabled.
+func (h *BaseAPIHandler) trackResponseMetadata(meta map[string]any) *responseMetadata {
+	if meta == nil || (!responseMetadataHeadersEnabled(h.Cfg) && !diagnostics.Enabled()) {
+		return nil
+	}
+	tracker := &responseMetadata{start: time.Now()}
+	previous, _ := meta[coreexecutor.SelectedAuthCallbackMetadataKey].(func(string))
+	meta[coreexecutor.SelectedAuthCallbackMetadataKey] = func(authID string) {
+		tracker.mu.Lock()
+		tracker.authIDs = append(tracker.authIDs, authID)
+		tracker.mu.Unlock()
+		if previous != nil {
+			previous(authID)
+		}
+	}
+	return tracker
+}
+
+// writeResponseMetadata sets the response metadata headers on the gin context. It must be
+// called before the response status and body are written.
+func (h *BaseAPIHandler) writeResponseMetadata(ctx context.Context, tracker *responseMetadata, handlerType string) {
+	if tracker == nil || ctx == nil || !responseMetadataHeadersEnabled(h.Cfg) {
+		return
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return
+	}
+
+	lastAuthID, attempts := tracker.lastAuthID()
+
+	ginCtx.Header(ResponseUpstreamLatencyHeader, strconv.FormatInt(time.Since(tracker.start).Milliseconds(), 10))
+	ginCtx.Header(ResponseRetryCountHeader, strconv.Itoa(max(attempts-1, 0)))
+	if requestID := logging.GetRequestID(ctx); requestID != "" {
+		ginCtx.Header(ResponseRequestIDHeader, requestID)
+	} else if requestID = logging.GetGinRequestID(ginCtx); requestID != "" {
+		ginCtx.Header(ResponseRequestIDHeader, requestID)
+	}
+	if translation := translationPath(ginCtx, handlerType); translation != "" {
+		ginCtx.Header(ResponseTranslationHeader, translation)
+	}
+	if lastAuthID == "" || h.AuthManager == nil {
+		return
+	}
+	auth, found := h.AuthManager.GetByID(lastAuthID)
+	if !found || auth == nil {
+		return
+	}
+	ginCtx.Header(ResponseProviderHeader, strings.ToLower(strings.TrimSpace(auth.Provider)))
+	ginCtx.Header(ResponseAuthHashHeader, hashAuthLabel(auth.Label, auth.ID))
+}
+
+// translationPath describes the translation of the most recent upstream attempt as
+// "<client format>-><executor format>", e.g. "openai->codex". It is empty when no executor
+// recorded its target format.
+func translationPath(ginCtx *gin.Context, handlerType string) string {
+	target := ginCtx.GetString(helps.TranslationTargetContextKey)
+	if target == "" {
+		return ""
+	}
+	return handlerType + "->" + target
+}
+
+// lastAuthID returns the credential used by the most recent attempt and the attempt count.
+func (m *responseMetadata) lastAuthID() (string, int) {
+	if m == nil {
+		return "", 0
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.authIDs) == 0 {
+		return "", 0
+	}
+	return m.authIDs[len(m.authIDs)-1], len(m.authIDs)
+}
+
+func responseMetadataHeadersEnabled(cfg *config.SDKConfig) bool {
+	return cfg != nil && cfg.ResponseMetadataHeaders
+}
+
+// hashAuthLabel returns a short, stable fingerprint of a credential so clients can tell
+// credentials apart without the proxy disclosing labels or IDs.
+func hashAuthLabel(label, authID string) string {
+	value := strings.TrimSpace(label)
+	if value == "" {
+		value = authID
+	}
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])[:12]
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor/helps"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+func TestWriteResponseMetadataHeaders(t *testing.T) {
+	manager := coreauth.NewManager(nil, nil, nil)
+	if _, err := manager.Register(context.Background(), &coreauth.Auth{ID: "codex-1", Provider: "codex", Label: "team-a"}); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{ResponseMetadataHeaders: true}, manager)
+
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	ctx := context.WithValue(context.Background(), "gin", c)
+
+	var forwarded []string
+	meta := map[string]any{coreexecutor.SelectedAuthCallbackMetadataKey: func(id string) { forwarded = append(forwarded, id) }}
+	tracker := handler.trackResponseMetadata(meta)
+	callback := meta[coreexecutor.SelectedAuthCallbackMetadataKey].(func(string))
+	callback("gemini-1")
+	if errPair := helps.CheckTranslatorPair(ctx, sdktranslator.FormatOpenAI, sdktranslator.FormatGemini); errPair != nil {
+		t.Fatalf("CheckTranslatorPair: %v", errPair)
+	}
+	callback("codex-1")
+	if errPair := helps.CheckTranslatorPair(ctx, sdktranslator.FormatOpenAI, sdktranslator.FormatOpenAIResponse); errPair != nil {
+		t.Fatalf("CheckTranslatorPair: %v", errPair)
+	}
+	handler.writeResponseMetadata(ctx, tracker, "openai")
+
+	header := c.Writer.Header()
+	if got := header.Get(ResponseProviderHeader); got != "codex" {
+		t.Fatalf("provider header = %q, want codex", got)
+	}
+	if got := header.Get(ResponseRetryCountHeader); got != "1" {
+		t.Fatalf("retry count header = %q, want 1", got)
+	}
+	if got := header.Get(ResponseTranslationHeader); got != "openai->openai-response" {
+		t.Fatalf("translation header = %q, want the executor format openai->openai-response", got)
+	}
+	if got := header.Get(ResponseAuthHashHeader); got != hashAuthLabel("team-a", "codex-1") || len(got) != 12 {
+		t.Fatalf("auth hash header = %q", got)
+	}
+	if header.Get(ResponseUpstreamLatencyHeader) == "" {
+		t.Fatal("missing upstream latency header")
+	}
+	if len(forwarded) != 2 {
+		t.Fatalf("existing callback should still run, got %v", forwarded)
+	}
+
+	disabled := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager)
+	if disabled.trackResponseMetadata(map[string]any{}) != nil {
+		t.Fatal("tracker should be nil when headers are disabled")
+	}
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+const (
+	// ProviderPinHeader restricts a request to a single upstream provider (e.g. "claude").
+	ProviderPinHeader = "X-CLIProxy-Provider"
+	// AuthLabelPinHeader pins a request to the credential with the given label or auth ID.
+	AuthLabelPinHeader = "X-CLIProxy-Auth-Label"
+)
+
+// applyRoutingPinHeaders narrows routing according to the provider and credential pinning
+// headers sent by trusted clients. It is a no-op unless allow-routing-pin-headers is enabled.
+// The returned providers replace the ones resolved from the model registry, and a matching
+// credential is pinned through the execution metadata.
+func (h *BaseAPIHandler) applyRoutingPinHeaders(ctx context.Context, providers []string, meta map[string]any) ([]string, *interfaces.ErrorMessage) {
+	if h.Cfg == nil || !h.Cfg.AllowRoutingPinHeaders || ctx == nil {
+		return providers, nil
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil || ginCtx.Request == nil {
+		return providers, nil
+	}
+	provider := strings.ToLower(strings.TrimSpace(ginCtx.GetHeader(ProviderPinHeader)))
+	label := strings.TrimSpace(ginCtx.GetHeader(AuthLabelPinHeader))
+
+	if provider != "" {
+		if !containsProvider(providers, provider) {
+			return nil, &interfaces.ErrorMessage{
+				StatusCode: http.StatusBadRequest,
+				Error:      fmt.Errorf("provider %s pinned by %s does not serve the requested model", provider, ProviderPinHeader),
+			}
+		}
+		providers = []string{provider}
+	}
+	if label == "" {
+		return providers, nil
+	}
+
+	auth, errMsg := h.findPinnedAuth(label, provider)
+	if errMsg != nil {
+		return nil, errMsg
+	}
+	authProvider := strings.ToLower(strings.TrimSpace(auth.Provider))
+	if !containsProvider(providers, authProvider) {
+		return nil, &interfaces.ErrorMessage{
+			StatusCode: http.StatusBadRequest,
+			Error:      fmt.Errorf("credential %s pinned by %s belongs to provider %s, which does not serve the requested model", label, AuthLabelPinHeader, auth.Provider),
+		}
+	}
+	meta[coreexecutor.PinnedAuthMetadataKey] = auth.ID
+	return []string{authProvider}, nil
+}
+
+// findPinnedAuth resolves a credential by auth ID or, failing that, by a unique label.
+func (h *BaseAPIHandler) findPinnedAuth(label, provider string) (*coreauth.Auth, *interfaces.ErrorMessage) {
+	if h.AuthManager == nil {
+		return nil, &interfaces.ErrorMessage{StatusCode: http.StatusServiceUnavailable, Error: fmt.Errorf("auth manager unavailable")}
+	}
+	if auth, ok := h.AuthManager.GetByID(label); ok && auth != nil {
+		if provider == "" || strings.EqualFold(auth.Provider, provider) {
+			return auth, nil
+		}
+	}
+	var matches []*coreauth.Auth
+	for _, auth := range h.AuthManager.List() {
+		if auth == nil || auth.Disabled || !strings.EqualFold(strings.TrimSpace(auth.Label), label) {
+			continue
+		}
+		if provider != "" && !strings.EqualFold(auth.Provider, provider) {
+			continue
+		}
+		matches = append(matches, auth)
+	}
+	switch len(matches) {
+	case 0:
+		return nil, &interfaces.ErrorMessage{
+			StatusCode: http.StatusBadRequest,
+			Error:      fmt.Errorf("no credential matches %s %q", AuthLabelPinHeader, label),
+		}
+	case 1:
+		return matches[0], nil
+	}
+	return nil, &interfaces.ErrorMessage{
+		StatusCode: http.StatusBadRequest,
+		Error:      fmt.Errorf("%s %q matches %d credentials; use the auth ID or add %s", AuthLabelPinHeader, label, len(matches), ProviderPinHeader),
+	}
+}
+
+func containsProvider(providers []string, provider string) bool {
+	for _, candidate := range providers {
+		if strings.EqualFold(candidate, provider) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func routingPinContext(headers map[string]string) context.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+	for key, value := range headers {
+		c.Request.Header.Set(key, value)
+	}
+	return context.WithValue(context.Background(), "gin", c)
+}
+
+func TestApplyRoutingPinHeaders(t *testing.T) {
+	manager := coreauth.NewManager(nil, nil, nil)
+	for _, auth := range []*coreauth.Auth{
+		{ID: "claude-a", Provider: "claude", Label: "team-a"},
+		{ID: "claude-b", Provider: "claude", Label: "shared"},
+		{ID: "gemini-b", Provider: "gemini", Label: "shared"},
+	} {
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("manager.Register(%s): %v", auth.ID, err)
+		}
+	}
+	providers := []string{"claude", "gemini"}
+
+	disabled := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager)
+	meta := map[string]any{}
+	got, errMsg := disabled.applyRoutingPinHeaders(routingPinContext(map[string]string{ProviderPinHeader: "claude"}), providers, meta)
+	if errMsg != nil || len(got) != 2 {
+		t.Fatalf("disabled flag should leave routing untouched, got %v %v", got, errMsg)
+	}
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{AllowRoutingPinHeaders: true}, manager)
+
+	got, errMsg = handler.applyRoutingPinHeaders(routingPinContext(map[string]string{ProviderPinHeader: "Gemini"}), providers, meta)
+	if errMsg != nil || len(got) != 1 || got[0] != "gemini" {
+		t.Fatalf("provider pin = %v %v, want [gemini]", got, errMsg)
+	}
+
+	meta = map[string]any{}
+	got, errMsg = handler.applyRoutingPinHeaders(routingPinContext(map[string]string{AuthLabelPinHeader: "team-a"}), providers, meta)
+	if errMsg != nil || len(got) != 1 || got[0] != "claude" {
+		t.Fatalf("label pin = %v %v, want [claude]", got, errMsg)
+	}
+	if meta[coreexecutor.PinnedAuthMetadataKey] != "claude-a" {
+		t.Fatalf("pinned auth = %v, want claude-a", meta[coreexecutor.PinnedAuthMetadataKey])
+	}
+
+	if _, errMsg = handler.applyRoutingPinHeaders(routingPinContext(map[string]string{AuthLabelPinHeader: "shared"}), providers, map[string]any{}); errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+		t.Fatalf("ambiguous label should be rejected, got %v", errMsg)
+	}
+
+	meta = map[string]any{}
+	if _, errMsg = handler.applyRoutingPinHeaders(routingPinContext(map[string]string{AuthLabelPinHeader: "shared", ProviderPinHeader: "gemini"}), providers, meta); errMsg != nil {
+		t.Fatalf("label with provider: %v", errMsg)
+	}
+	if meta[coreexecutor.PinnedAuthMetadataKey] != "gemini-b" {
+		t.Fatalf("pinned auth = %v, want gemini-b", meta[coreexecutor.PinnedAuthMetadataKey])
+	}
+
+	if _, errMsg = handler.applyRoutingPinHeaders(routingPinContext(map[string]string{ProviderPinHeader: "codex"}), providers, map[string]any{}); errMsg == nil {
+		t.Fatal("provider that does not serve the model should be rejected")
+	}
+}
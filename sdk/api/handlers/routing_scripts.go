@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/routingscript"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor/helps"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+)
+
+// matchRoutingScript evaluates the configured routing-scripts rules in order and returns the
+// first match together with the model the request should be routed to. Matching rules with a
+// reject message end the request with 403, and a rewritten model must pass the API key policy
+// again. Rules whose evaluation fails are logged and skipped.
+func (h *BaseAPIHandler) matchRoutingScript(ctx context.Context, handlerType, modelName string, rawJSON []byte, stream bool) (string, *config.RoutingScript, *interfaces.ErrorMessage) {
+	if h.Cfg == nil || len(h.Cfg.RoutingScripts) == 0 {
+		return modelName, nil, nil
+	}
+	req := routingscript.Request{
+		Model:   modelName,
+		Format:  handlerType,
+		APIKey:  apiKeyFromContext(ctx),
+		Stream:  stream,
+		Headers: http.Header{},
+	}
+	if ctx != nil {
+		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
+			req.Headers = ginCtx.Request.Header
+		}
+	}
+	tokensCounted := false
+	for i := range h.Cfg.RoutingScripts {
+		rule := &h.Cfg.RoutingScripts[i]
+		name := rule.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		program, errCompile := rule.Program()
+		if errCompile != nil {
+			return "", nil, &interfaces.ErrorMessage{StatusCode: http.StatusInternalServerError, Error: fmt.Errorf("routing script %s: %w", name, errCompile)}
+		}
+		if program.UsesTokens() && !tokensCounted {
+			tokensCounted = true
+			req.Tokens = estimateRoutingTokens(modelName, handlerType, rawJSON)
+		}
+		matched, errEval := program.Eval(req)
+		if errEval != nil {
+			log.Warnf("routing script %s skipped: %v", name, errEval)
+			continue
+		}
+		if !matched {
+			continue
+		}
+		if rule.Reject != "" {
+			return "", nil, &interfaces.ErrorMessage{
+				StatusCode: http.StatusForbidden,
+				Error:      errors.New(string(buildDialectErrorBody(handlerType, http.StatusForbidden, "routing_rejected", rule.Reject))),
+			}
+		}
+		if rule.Model != "" && rule.Model != modelName {
+			if errMsg := h.enforceAPIKeyPolicy(ctx, handlerType, rule.Model, rawJSON); errMsg != nil {
+				return "", nil, errMsg
+			}
+			modelName = rule.Model
+		}
+		return modelName, rule, nil
+	}
+	return modelName, nil, nil
+}
+
+// estimateRoutingTokens counts the input tokens of a request with the local tokenizer. When the
+// payload cannot be counted it falls back to roughly four bytes of JSON per token.
+func estimateRoutingTokens(modelName, handlerType string, rawJSON []byte) int {
+	count, errCount := helps.EstimateInputTokens(modelName, sdktranslator.FromString(handlerType), rawJSON)
+	if errCount != nil {
+		log.Debugf("routing scripts: %v", errCount)
+		return len(rawJSON) / 4
+	}
+	return int(count)
+}
+
+// applyRoutingScript narrows providers and pins a credential according to the matched rule.
+func (h *BaseAPIHandler) applyRoutingScript(rule *config.RoutingScript, providers []string, meta map[string]any) ([]string, *interfaces.ErrorMessage) {
+	if rule == nil || (rule.Provider == "" && rule.AuthLabel == "") {
+		return providers, nil
+	}
+	if rule.Provider != "" {
+		if !containsProvider(providers, rule.Provider) {
+			return nil, &interfaces.ErrorMessage{
+				StatusCode: http.StatusBadRequest,
+				Error:      fmt.Errorf("provider %s selected by routing script %s does not serve the requested model", rule.Provider, rule.Name),
+			}
+		}
+		providers = []string{rule.Provider}
+	}
+	if rule.AuthLabel == "" {
+		return providers, nil
+	}
+	auth, errMsg := h.findPinnedAuth(rule.AuthLabel, rule.Provider)
+	if errMsg != nil {
+		return nil, errMsg
+	}
+	authProvider := strings.ToLower(strings.TrimSpace(auth.Provider))
+	if !containsProvider(providers, authProvider) {
+		return nil, &interfaces.ErrorMessage{
+			StatusCode: http.StatusBadRequest,
+			Error:      fmt.Errorf("credential %s selected by routing script %s belongs to provider %s, which does not serve the requested model", rule.AuthLabel, rule.Name, auth.Provider),
+		}
+	}
+	meta[coreexecutor.PinnedAuthMetadataKey] = auth.ID
+	return []string{authProvider}, nil
+}
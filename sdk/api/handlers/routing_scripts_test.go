@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestRoutingScripts(t *testing.T) {
+	manager := coreauth.NewManager(nil, nil, nil)
+	if _, err := manager.Register(context.Background(), &coreauth.Auth{ID: "gemini-a", Provider: "gemini", Label: "batch"}); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{RoutingScripts: []sdkconfig.RoutingScript{
+		{Name: "deny", When: `header("X-Team") == "blocked"`, Reject: "team blocked"},
+		{Name: "long", When: `tokens > 10 && model startsWith "claude-"`, Model: "gemini-2.5-pro", Provider: "gemini"},
+		{Name: "batch", When: `header("X-Batch") == "1"`, AuthLabel: "batch"},
+	}}, manager)
+	providers := []string{"claude", "gemini"}
+
+	model, rule, errMsg := handler.matchRoutingScript(routingPinContext(nil), "openai", "claude-sonnet", []byte(`{}`), false)
+	if errMsg != nil || rule != nil || model != "claude-sonnet" {
+		t.Fatalf("short request should not match: %q %v %v", model, rule, errMsg)
+	}
+
+	body := []byte(`{"messages":[{"role":"user","content":"a prompt long enough to count as more than ten tokens with the local tokenizer"}]}`)
+	model, rule, errMsg = handler.matchRoutingScript(routingPinContext(nil), "openai", "claude-sonnet", body, true)
+	if errMsg != nil || rule == nil || rule.Name != "long" || model != "gemini-2.5-pro" {
+		t.Fatalf("long request = %q %v %v, want rewrite to gemini-2.5-pro", model, rule, errMsg)
+	}
+	got, errMsg := handler.applyRoutingScript(rule, providers, map[string]any{})
+	if errMsg != nil || len(got) != 1 || got[0] != "gemini" {
+		t.Fatalf("providers = %v %v, want [gemini]", got, errMsg)
+	}
+
+	_, rule, errMsg = handler.matchRoutingScript(routingPinContext(map[string]string{"X-Batch": "1"}), "openai", "gpt-5", body, false)
+	if errMsg != nil || rule == nil {
+		t.Fatalf("batch rule did not match: %v", errMsg)
+	}
+	meta := map[string]any{}
+	if got, errMsg = handler.applyRoutingScript(rule, providers, meta); errMsg != nil || got[0] != "gemini" || meta[coreexecutor.PinnedAuthMetadataKey] != "gemini-a" {
+		t.Fatalf("auth pin = %v %v %v", got, meta, errMsg)
+	}
+
+	_, _, errMsg = handler.matchRoutingScript(routingPinContext(map[string]string{"X-Team": "blocked"}), "claude", "claude-sonnet", body, false)
+	if errMsg == nil || errMsg.StatusCode != http.StatusForbidden || !strings.Contains(errMsg.Error.Error(), "team blocked") {
+		t.Fatalf("expected 403 rejection, got %v", errMsg)
+	}
+}
+
+func TestRoutingScriptsRewriteHonorsAPIKeyPolicy(t *testing.T) {
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		APIKeyPolicies: []sdkconfig.APIKeyPolicy{{APIKey: "limited", AllowedModels: []string{"gpt-*"}}},
+		RoutingScripts: []sdkconfig.RoutingScript{
+			{Name: "broken", When: `int(header("X-Count")) > 5`, Model: "gpt-5-pro"},
+			{Name: "upgrade", When: `model == "gpt-5"`, Model: "claude-opus"},
+		},
+	}, nil)
+
+	ctx := policyTestContext("limited")
+	ginCtx := ctx.Value("gin").(*gin.Context)
+	ginCtx.Request.Header.Set("X-Count", "many")
+	_, _, errMsg := handler.matchRoutingScript(ctx, "openai", "gpt-5", []byte(`{}`), false)
+	if errMsg == nil || errMsg.StatusCode != http.StatusForbidden || !strings.Contains(errMsg.Error.Error(), "claude-opus") {
+		t.Fatalf("rewrite to a disallowed model = %v, want 403", errMsg)
+	}
+
+	model, rule, errMsg := handler.matchRoutingScript(policyTestContext("other"), "openai", "gpt-5", []byte(`{}`), false)
+	if errMsg != nil || rule == nil || rule.Name != "upgrade" || model != "claude-opus" {
+		t.Fatalf("unrestricted key = %q %v %v, want the failing rule skipped and rewrite applied", model, rule, errMsg)
+	}
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/lifecycle"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// streamIdleWatchdog fires when an upstream stream stays silent for longer than timeout. It is
+// armed once the first chunk arrives, so the time to first byte never counts as idleness.
+type streamIdleWatchdog struct {
+	timeout time.Duration
+	timer   *time.Timer
+}
+
+// newStreamIdleWatchdog returns nil when idle detection is disabled.
+func newStreamIdleWatchdog(timeout time.Duration) *streamIdleWatchdog {
+	if timeout <= 0 {
+		return nil
+	}
+	return &streamIdleWatchdog{timeout: timeout}
+}
+
+// C returns the channel that fires on idleness, or nil while the watchdog is unarmed.
+func (w *streamIdleWatchdog) C() <-chan time.Time {
+	if w == nil || w.timer == nil {
+		return nil
+	}
+	return w.timer.C
+}
+
+// Touch records upstream activity, arming the watchdog on the first call.
+func (w *streamIdleWatchdog) Touch() {
+	if w == nil {
+		return
+	}
+	if w.timer == nil {
+		w.timer = time.NewTimer(w.timeout)
+		return
+	}
+	if !w.timer.Stop() {
+		select {
+		case <-w.timer.C:
+		default:
+		}
+	}
+	w.timer.Reset(w.timeout)
+}
+
+// Stop disarms the watchdog until the next Touch.
+func (w *streamIdleWatchdog) Stop() {
+	if w == nil || w.timer == nil {
+		return
+	}
+	w.timer.Stop()
+	w.timer = nil
+}
+
+// streamAttemptContext derives a cancellable context for one upstream stream attempt so a
+// stalled stream can be aborted without cancelling the client request. The attempt is cancelled
+// with errStreamIdle as its cause so executors record the abort as a timeout. When neither
+// timeout is enabled the parent context is returned unchanged.
+func streamAttemptContext(ctx context.Context, enabled bool) (context.Context, context.CancelCauseFunc) {
+	if !enabled || ctx == nil {
+		return ctx, func(error) {}
+	}
+	return context.WithCancelCause(ctx)
+}
+
+// errStreamIdle is the cancellation cause of a stream attempt aborted for producing no data in
+// time. It wraps context.DeadlineExceeded so failure classification reports a timeout.
+var errStreamIdle = fmt.Errorf("upstream stream idle: %w", context.DeadlineExceeded)
+
+// executeStreamWatched dispatches one upstream stream attempt and aborts it when the first chunk
+// does not arrive within timeout. cancel must cancel ctx.
+func (h *BaseAPIHandler) executeStreamWatched(ctx context.Context, cancel context.CancelCauseFunc, timeout time.Duration, providers []string, req coreexecutor.Request, opts coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	if timeout <= 0 || ctx == nil {
+		return h.AuthManager.ExecuteStream(ctx, providers, req, opts)
+	}
+	timer := time.AfterFunc(timeout, func() { cancel(errStreamIdle) })
+	result, err := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
+	if timer.Stop() {
+		return result, err
+	}
+	if err == nil && result != nil {
+		lifecycle.Go(ctx, "handlers.stream.drain", func() {
+			for range result.Chunks {
+			}
+		})
+	}
+	return nil, errStreamIdle
+}
+
+// stalledAuths records the credential of each stream attempt so that one which stalls can be
+// excluded when the stream is retried.
+type stalledAuths struct {
+	mu   sync.Mutex
+	last string
+}
+
+// trackStalledAuths installs a selected-auth callback on the execution metadata. Any callback
+// already present is still invoked. It returns nil when meta is nil.
+func trackStalledAuths(meta map[string]any) *stalledAuths {
+	if meta == nil {
+		return nil
+	}
+	tracker := &stalledAuths{}
+	previous, _ := meta[coreexecutor.SelectedAuthCallbackMetadataKey].(func(string))
+	meta[coreexecutor.SelectedAuthCallbackMetadataKey] = func(authID string) {
+		tracker.mu.Lock()
+		tracker.last = authID
+		tracker.mu.Unlock()
+		if previous != nil {
+			previous(authID)
+		}
+	}
+	return tracker
+}
+
+// exclude adds the credential of the most recent attempt to the excluded auths in meta.
+func (s *stalledAuths) exclude(meta map[string]any) {
+	if s == nil || meta == nil {
+		return
+	}
+	s.mu.Lock()
+	authID := s.last
+	s.mu.Unlock()
+	if authID == "" {
+		return
+	}
+	excluded, _ := meta[coreexecutor.ExcludedAuthsMetadataKey].([]string)
+	meta[coreexecutor.ExcludedAuthsMetadataKey] = append(excluded, authID)
+}
+
+// streamIdleTimeoutError renders an upstream idle timeout in the caller's API error format.
+func streamIdleTimeoutError(handlerType string, timeout time.Duration) *interfaces.ErrorMessage {
+	reason := fmt.Sprintf("upstream stream produced no data for %s", timeout)
+	return &interfaces.ErrorMessage{
+		StatusCode: http.StatusGatewayTimeout,
+		Error:      errors.New(string(buildDialectErrorBody(handlerType, http.StatusGatewayTimeout, "upstream_idle_timeout", reason))),
+	}
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/lifecycle/lifecycletest"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor/helps"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+type stallingStreamExecutor struct {
+	aborted atomic.Bool
+	// failureClass is how the executor's usage reporter classifies the abort.
+	failureClass atomic.Value
+}
+
+func (e *stallingStreamExecutor) Identifier() string { return "idle-test" }
+
+func (e *stallingStreamExecutor) Execute(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "Execute not implemented"}
+}
+
+func (e *stallingStreamExecutor) ExecuteStream(ctx context.Context, _ *coreauth.Auth, _ coreexecutor.Request, _ coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	ch := make(chan coreexecutor.StreamChunk)
+	go func() {
+		defer close(ch)
+		ch <- coreexecutor.StreamChunk{Payload: []byte("partial")}
+		<-ctx.Done()
+		e.failureClass.Store(helps.ClassifyFailure(ctx, ctx.Err()))
+		e.aborted.Store(true)
+	}()
+	return &coreexecutor.StreamResult{Chunks: ch}, nil
+}
+
+func (e *stallingStreamExecutor) Refresh(ctx context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *stallingStreamExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *stallingStreamExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func TestExecuteStreamWithAuthManager_AbortsIdleUpstream(t *testing.T) {
+	lifecycletest.VerifyNone(t)
+	executor := &stallingStreamExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "idle-auth", Provider: "idle-test", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "idle-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		Streaming: sdkconfig.StreamingConfig{IdleTimeoutSeconds: 1, IdleRetries: 1},
+	}, manager)
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx := context.WithValue(context.Background(), "gin", ginCtx)
+	dataChan, _, errChan := handler.ExecuteStreamWithAuthManager(ctx, "claude", "idle-model", []byte(`{"model":"idle-model"}`), "")
+
+	var got []byte
+	for chunk := range dataChan {
+		got = append(got, chunk...)
+	}
+	var errMsg *interfaces.ErrorMessage
+	for msg := range errChan {
+		if msg != nil {
+			errMsg = msg
+		}
+	}
+
+	if string(got) != "partial" {
+		t.Fatalf("payload = %q, want partial", got)
+	}
+	if errMsg == nil || errMsg.StatusCode != http.StatusGatewayTimeout {
+		t.Fatalf("expected 504 idle timeout error, got %+v", errMsg)
+	}
+	body := []byte(errMsg.Error.Error())
+	if gjson.GetBytes(body, "type").String() != "error" || gjson.GetBytes(body, "error.type").String() != "timeout_error" ||
+		!strings.HasPrefix(gjson.GetBytes(body, "error.message").String(), "upstream stream produced no data") {
+		t.Fatalf("expected a Claude timeout_error body, got %s", body)
+	}
+	if !ginCtx.GetBool(StreamErrorContextKey) {
+		t.Fatal("expected the failed stream to be flagged on the gin context")
+	}
+	deadline := time.Now().Add(time.Second)
+	for !executor.aborted.Load() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !executor.aborted.Load() {
+		t.Fatal("expected idle upstream to be cancelled")
+	}
+	if got := executor.failureClass.Load(); got != usage.FailureClassTimeout {
+		t.Fatalf("idle abort recorded as %v, want %s", got, usage.FailureClassTimeout)
+	}
+}
+
+// silentFirstStreamExecutor stalls its first stream before any chunk and serves later ones after
+// delay.
+type silentFirstStreamExecutor struct {
+	stallingStreamExecutor
+	mu       sync.Mutex
+	authIDs  []string
+	excluded [][]string
+	delay    time.Duration
+}
+
+func (e *silentFirstStreamExecutor) ExecuteStream(ctx context.Context, auth *coreauth.Auth, _ coreexecutor.Request, opts coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	e.mu.Lock()
+	first := len(e.authIDs) == 0
+	e.authIDs = append(e.authIDs, auth.ID)
+	excluded, _ := opts.Metadata[coreexecutor.ExcludedAuthsMetadataKey].([]string)
+	e.excluded = append(e.excluded, append([]string(nil), excluded...))
+	e.mu.Unlock()
+	ch := make(chan coreexecutor.StreamChunk, 1)
+	if first {
+		go func() {
+			defer close(ch)
+			<-ctx.Done()
+		}()
+		return &coreexecutor.StreamResult{Chunks: ch}, nil
+	}
+	go func() {
+		defer close(ch)
+		select {
+		case <-time.After(e.delay):
+			ch <- coreexecutor.StreamChunk{Payload: []byte("ok")}
+		case <-ctx.Done():
+		}
+	}()
+	return &coreexecutor.StreamResult{Chunks: ch}, nil
+}
+
+func newIdleRetryManager(t *testing.T, executor coreauth.ProviderExecutor) *coreauth.Manager {
+	t.Helper()
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	for _, id := range []string{"idle-a", "idle-b"} {
+		auth := &coreauth.Auth{ID: id, Provider: "idle-test", Status: coreauth.StatusActive}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("manager.Register: %v", err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(id, auth.Provider, []*registry.ModelInfo{{ID: "idle-retry-model"}})
+		t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(id) })
+	}
+	return manager
+}
+
+func TestExecuteStreamWithAuthManager_RetriesFirstChunkTimeoutOnOtherAuth(t *testing.T) {
+	lifecycletest.VerifyNone(t)
+	executor := &silentFirstStreamExecutor{}
+	manager := newIdleRetryManager(t, executor)
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		Streaming: sdkconfig.StreamingConfig{FirstChunkTimeoutSeconds: 1, IdleRetries: 1},
+	}, manager)
+	dataChan, _, errChan := handler.ExecuteStreamWithAuthManager(context.Background(), "openai", "idle-retry-model", []byte(`{"model":"idle-retry-model"}`), "")
+
+	var got []byte
+	for chunk := range dataChan {
+		got = append(got, chunk...)
+	}
+	for msg := range errChan {
+		if msg != nil {
+			t.Fatalf("unexpected error: %+v", msg)
+		}
+	}
+	if string(got) != "ok" {
+		t.Fatalf("payload = %q, want ok", got)
+	}
+	executor.mu.Lock()
+	defer executor.mu.Unlock()
+	if len(executor.authIDs) != 2 || executor.authIDs[0] == executor.authIDs[1] {
+		t.Fatalf("attempts = %v, want a retry on the other credential", executor.authIDs)
+	}
+	if len(executor.excluded[1]) != 1 || executor.excluded[1][0] != executor.authIDs[0] {
+		t.Fatalf("retry excluded = %v, want the stalled %s", executor.excluded[1], executor.authIDs[0])
+	}
+}
+
+func TestExecuteStreamWithAuthManager_IdleTimeoutIgnoresSlowFirstChunk(t *testing.T) {
+	lifecycletest.VerifyNone(t)
+	// Every attempt after the first answers after 1.5s, longer than the idle timeout.
+	executor := &silentFirstStreamExecutor{delay: 1500 * time.Millisecond, authIDs: []string{"warm-up"}}
+	manager := newIdleRetryManager(t, executor)
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		Streaming: sdkconfig.StreamingConfig{IdleTimeoutSeconds: 1, IdleRetries: 1},
+	}, manager)
+	dataChan, _, errChan := handler.ExecuteStreamWithAuthManager(context.Background(), "openai", "idle-retry-model", []byte(`{"model":"idle-retry-model"}`), "")
+
+	var got []byte
+	for chunk := range dataChan {
+		got = append(got, chunk...)
+	}
+	for msg := range errChan {
+		if msg != nil {
+			t.Fatalf("slow first chunk was treated as idle: %+v", msg)
+		}
+	}
+	if string(got) != "ok" {
+		t.Fatalf("payload = %q, want ok", got)
+	}
+	executor.mu.Lock()
+	defer executor.mu.Unlock()
+	if len(executor.authIDs) != 2 {
+		t.Fatalf("attempts = %v, want one upstream attempt", executor.authIDs[1:])
+	}
+}
+
+func TestStreamIdleWatchdogArmsOnTouch(t *testing.T) {
+	if newStreamIdleWatchdog(0) != nil {
+		t.Fatal("zero timeout should disable the watchdog")
+	}
+	watchdog := newStreamIdleWatchdog(20 * time.Millisecond)
+	if watchdog.C() != nil {
+		t.Fatal("watchdog should be unarmed before the first chunk")
+	}
+	watchdog.Touch()
+	select {
+	case <-watchdog.C():
+	case <-time.After(time.Second):
+		t.Fatal("watchdog did not fire")
+	}
+	watchdog.Stop()
+	if watchdog.C() != nil {
+		t.Fatal("stopped watchdog should be unarmed")
+	}
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+// streamThrottleContextKey is the gin context key that carries the applied stream rate limit
+// so usage reporting can record it.
+const streamThrottleContextKey = "streamTokensPerSecond"
+
+// streamTextFields lists JSON keys whose string values carry generated output in the
+// streaming formats of every supported dialect.
+var streamTextFields = map[string]struct{}{
+	"text":              {},
+	"content":           {},
+	"delta":             {},
+	"thinking":          {},
+	"partial_json":      {},
+	"arguments":         {},
+	"reasoning_content": {},
+}
+
+// streamThrottle is a token bucket that paces downstream stream delivery.
+// The bucket holds up to one second of output so short bursts are not delayed.
+type streamThrottle struct {
+	rate      float64
+	available float64
+	last      time.Time
+	now       func() time.Time
+	sleep     func(ctx context.Context, d time.Duration) bool
+}
+
+// streamTokensPerSecond resolves the output rate limit for apiKey. A per-key policy
+// overrides the global streaming setting; <= 0 disables throttling.
+func streamTokensPerSecond(cfg *config.SDKConfig, apiKey string) float64 {
+	if cfg == nil {
+		return 0
+	}
+	if policy := apiKeyPolicy(cfg, apiKey); policy != nil && policy.StreamTokensPerSecond > 0 {
+		return policy.StreamTokensPerSecond
+	}
+	if cfg.Streaming.TokensPerSecond > 0 {
+		return cfg.Streaming.TokensPerSecond
+	}
+	return 0
+}
+
+// newStreamThrottle returns the throttle for the calling API key and records the applied
+// rate on the gin context. It returns nil when throttling is disabled.
+func newStreamThrottle(ctx context.Context, cfg *config.SDKConfig) *streamThrottle {
+	rate := streamTokensPerSecond(cfg, apiKeyFromContext(ctx))
+	if rate <= 0 {
+		return nil
+	}
+	if ctx != nil {
+		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+			ginCtx.Set(streamThrottleContextKey, rate)
+		}
+	}
+	return &streamThrottle{
+		rate:      rate,
+		available: rate,
+		now:       time.Now,
+		sleep:     sleepContext,
+	}
+}
+
+// wait blocks until chunk may be delivered. It returns false when ctx is cancelled.
+func (t *streamThrottle) wait(ctx context.Context, chunk []byte) bool {
+	if t == nil {
+		return true
+	}
+	tokens := estimateStreamChunkTokens(chunk)
+	if tokens == 0 {
+		return true
+	}
+	now := t.now()
+	if !t.last.IsZero() {
+		t.available += now.Sub(t.last).Seconds() * t.rate
+		if t.available > t.rate {
+			t.available = t.rate
+		}
+	}
+	t.last = now
+	t.available -= float64(tokens)
+	if t.available >= 0 {
+		return true
+	}
+	delay := time.Duration(-t.available / t.rate * float64(time.Second))
+	if !t.sleep(ctx, delay) {
+		return false
+	}
+	t.available = 0
+	t.last = t.now()
+	return true
+}
+
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	if ctx == nil {
+		time.Sleep(d)
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// estimateStreamChunkTokens approximates the number of generated tokens in a stream chunk
+// using roughly four characters per token over the text fields of each JSON payload.
+// Chunks that carry no generated text (such as start and stop events) count as zero.
+func estimateStreamChunkTokens(chunk []byte) int {
+	chars := 0
+	for _, line := range bytes.Split(chunk, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if bytes.HasPrefix(line, []byte("data:")) {
+			line = bytes.TrimSpace(line[len("data:"):])
+		}
+		if len(line) == 0 || (line[0] != '{' && line[0] != '[') || !gjson.ValidBytes(line) {
+			continue
+		}
+		chars += streamTextLength(gjson.ParseBytes(line))
+	}
+	if chars == 0 {
+		return 0
+	}
+	return (chars + 3) / 4
+}
+
+func streamTextLength(node gjson.Result) int {
+	total := 0
+	node.ForEach(func(key, value gjson.Result) bool {
+		if value.IsObject() || value.IsArray() {
+			total += streamTextLength(value)
+			return true
+		}
+		if value.Type != gjson.String {
+			return true
+		}
+		if _, ok := streamTextFields[key.String()]; ok {
+			total += len(value.Str)
+		}
+		return true
+	})
+	return total
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor/helps"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestEstimateStreamChunkTokens(t *testing.T) {
+	cases := []struct {
+		name  string
+		chunk string
+		want  int
+	}{
+		{"openai delta", `data: {"choices":[{"delta":{"content":"Hello world!"}}]}`, 3},
+		{"claude event", "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"abcdefgh\"}}\n\n", 2},
+		{"gemini parts", `{"candidates":[{"content":{"parts":[{"text":"abcde"}]}}]}`, 2},
+		{"no text", "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n", 0},
+		{"done marker", "data: [DONE]", 0},
+	}
+	for _, tc := range cases {
+		if got := estimateStreamChunkTokens([]byte(tc.chunk)); got != tc.want {
+			t.Errorf("%s: got %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestStreamTokensPerSecondPrefersKeyPolicy(t *testing.T) {
+	cfg := &config.SDKConfig{
+		Streaming:      config.StreamingConfig{TokensPerSecond: 50},
+		APIKeyPolicies: []config.APIKeyPolicy{{APIKey: "free", StreamTokensPerSecond: 10}},
+	}
+	if got := streamTokensPerSecond(cfg, "free"); got != 10 {
+		t.Fatalf("policy rate = %v, want 10", got)
+	}
+	if got := streamTokensPerSecond(cfg, "paid"); got != 50 {
+		t.Fatalf("global rate = %v, want 50", got)
+	}
+	if got := streamTokensPerSecond(&config.SDKConfig{}, "paid"); got != 0 {
+		t.Fatalf("disabled rate = %v, want 0", got)
+	}
+}
+
+func TestStreamThrottleDelaysBeyondBurst(t *testing.T) {
+	clock := time.Unix(0, 0)
+	var slept []time.Duration
+	throttle := &streamThrottle{
+		rate:      10,
+		available: 10,
+		now:       func() time.Time { return clock },
+		sleep: func(_ context.Context, d time.Duration) bool {
+			slept = append(slept, d)
+			clock = clock.Add(d)
+			return true
+		},
+	}
+	chunk := []byte(`data: {"choices":[{"delta":{"content":"` + "aaaaaaaaaaaaaaaaaaaa" + `"}}]}`) // 5 tokens
+
+	for i := 0; i < 2; i++ {
+		if !throttle.wait(context.Background(), chunk) {
+			t.Fatal("wait returned false")
+		}
+	}
+	if len(slept) != 0 {
+		t.Fatalf("burst should not sleep, slept %v", slept)
+	}
+	if !throttle.wait(context.Background(), chunk) {
+		t.Fatal("wait returned false")
+	}
+	if len(slept) != 1 || slept[0] != 500*time.Millisecond {
+		t.Fatalf("slept = %v, want [500ms]", slept)
+	}
+
+	var nilThrottle *streamThrottle
+	if !nilThrottle.wait(context.Background(), chunk) {
+		t.Fatal("nil throttle should not block")
+	}
+}
+
+// usageReportingStreamExecutor publishes a usage record when its stream starts, as the
+// provider executors do.
+type usageReportingStreamExecutor struct {
+	authAwareStreamExecutor
+}
+
+func (e *usageReportingStreamExecutor) ExecuteStream(ctx context.Context, auth *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	reporter := helps.NewUsageReporter(ctx, "codex", req.Model, auth)
+	reporter.Publish(ctx, usage.Detail{OutputTokens: 1})
+	ch := make(chan coreexecutor.StreamChunk, 1)
+	ch <- coreexecutor.StreamChunk{Payload: []byte("ok")}
+	close(ch)
+	return &coreexecutor.StreamResult{Chunks: ch}, nil
+}
+
+type usageRecordCapture struct {
+	model   string
+	records chan usage.Record
+}
+
+func (p *usageRecordCapture) HandleUsage(_ context.Context, record usage.Record) {
+	if record.Model == p.model {
+		p.records <- record
+	}
+}
+
+func TestExecuteStreamRecordsThrottleRateInUsage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const model = "throttle-usage-model"
+	capture := &usageRecordCapture{model: model, records: make(chan usage.Record, 1)}
+	usage.RegisterPlugin(capture)
+	t.Cleanup(func() { usage.UnregisterPlugin(capture) })
+
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(&usageReportingStreamExecutor{})
+	auth := &coreauth.Auth{ID: "throttle-usage-auth", Provider: "codex", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: model}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	handler := NewBaseAPIHandlers(&config.SDKConfig{Streaming: config.StreamingConfig{TokensPerSecond: 1000}}, manager)
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx := context.WithValue(context.Background(), "gin", ginCtx)
+	dataChan, _, errChan := handler.ExecuteStreamWithAuthManager(ctx, "openai", model, []byte(`{"model":"`+model+`"}`), "")
+	for range dataChan {
+	}
+	for msg := range errChan {
+		if msg != nil {
+			t.Fatalf("unexpected error: %+v", msg)
+		}
+	}
+
+	select {
+	case record := <-capture.records:
+		if record.StreamTokensPerSecond != 1000 {
+			t.Fatalf("usage StreamTokensPerSecond = %v, want 1000", record.StreamTokensPerSecond)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no usage record published")
+	}
+}
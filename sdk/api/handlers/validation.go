@@ -0,0 +1,361 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/tidwall/gjson"
+)
+
+// requestValidationError describes the first schema violation found in an inbound payload.
+type requestValidationError struct {
+	code    string
+	message string
+}
+
+var (
+	openAIChatRoles     = []string{"system", "developer", "user", "assistant", "tool", "function"}
+	openAIToolChoices   = []string{"none", "auto", "required"}
+	claudeRoles         = []string{"user", "assistant"}
+	claudeToolChoices   = []string{"auto", "any", "tool", "none"}
+	geminiRoles         = []string{"user", "model", "function"}
+	openAIChatNumbers   = []string{"temperature", "top_p", "frequency_penalty", "presence_penalty"}
+	openAIChatIntegers  = []string{"max_tokens", "max_completion_tokens", "n"}
+	responsesNumbers    = []string{"temperature", "top_p"}
+	responsesIntegers   = []string{"max_output_tokens"}
+	claudeNumbers       = []string{"temperature", "top_p"}
+	claudeIntegers      = []string{"max_tokens", "top_k"}
+	geminiConfigNumbers = []string{"temperature", "topP"}
+	geminiConfigInts    = []string{"topK", "maxOutputTokens", "candidateCount"}
+)
+
+// ValidateRequest checks an inbound payload against the schema of the caller's API dialect
+// (required fields, value types and enum values) before it is translated. Violations are
+// returned as a 400 error rendered in that dialect's error format. Validation only runs when
+// request-validation is enabled, and fields the executors repair are not required.
+func (h *BaseAPIHandler) ValidateRequest(handlerType string, rawJSON []byte) *interfaces.ErrorMessage {
+	if h.Cfg == nil || !h.Cfg.RequestValidation {
+		return nil
+	}
+	violation := validateRequestSchema(handlerType, rawJSON)
+	if violation == nil {
+		return nil
+	}
+	return &interfaces.ErrorMessage{
+		StatusCode: http.StatusBadRequest,
+		Error:      errors.New(string(buildDialectErrorBody(handlerType, http.StatusBadRequest, violation.code, violation.message))),
+	}
+}
+
+func validateRequestSchema(handlerType string, rawJSON []byte) *requestValidationError {
+	if !gjson.ValidBytes(rawJSON) {
+		return &requestValidationError{code: "invalid_json", message: "request body is not valid JSON"}
+	}
+	root := gjson.ParseBytes(rawJSON)
+	if !root.IsObject() {
+		return &requestValidationError{code: "invalid_type", message: "request body must be a JSON object"}
+	}
+	switch handlerType {
+	case constant.OpenAI:
+		return validateOpenAIChatRequest(root)
+	case constant.OpenaiResponse:
+		return validateOpenAIResponsesRequest(root)
+	case constant.Claude:
+		return validateClaudeRequest(root)
+	case constant.Gemini:
+		return validateGeminiRequest(root)
+	}
+	return nil
+}
+
+func validateOpenAIChatRequest(root gjson.Result) *requestValidationError {
+	messages := root.Get("messages")
+	if !messages.Exists() {
+		return missingField("messages")
+	}
+	if !messages.IsArray() {
+		return invalidType("messages", "an array")
+	}
+	if len(messages.Array()) == 0 {
+		return invalidValue("messages", "must contain at least one message")
+	}
+	for i, msg := range messages.Array() {
+		path := fmt.Sprintf("messages[%d]", i)
+		if !msg.IsObject() {
+			return invalidType(path, "an object")
+		}
+		if v := checkEnum(msg, "role", path+".role", openAIChatRoles, true); v != nil {
+			return v
+		}
+		if content := msg.Get("content"); content.Exists() && content.Type != gjson.String && content.Type != gjson.Null && !content.IsArray() {
+			return invalidType(path+".content", "a string or an array")
+		}
+		// Executors such as Kimi relink tool messages that lack a tool_call_id, so it is optional.
+		if v := checkString(msg, "tool_call_id", path+".tool_call_id", false); v != nil {
+			return v
+		}
+	}
+	if v := checkNumbers(root, "", openAIChatNumbers); v != nil {
+		return v
+	}
+	if v := checkIntegers(root, "", openAIChatIntegers); v != nil {
+		return v
+	}
+	if v := checkBool(root, "stream"); v != nil {
+		return v
+	}
+	if v := checkOpenAITools(root.Get("tools"), "tools", true); v != nil {
+		return v
+	}
+	if choice := root.Get("tool_choice"); choice.Type == gjson.String {
+		return checkEnum(root, "tool_choice", "tool_choice", openAIToolChoices, false)
+	} else if choice.Exists() && !choice.IsObject() {
+		return invalidType("tool_choice", "a string or an object")
+	}
+	return nil
+}
+
+func validateOpenAIResponsesRequest(root gjson.Result) *requestValidationError {
+	if input := root.Get("input"); input.Exists() && input.Type != gjson.String && !input.IsArray() {
+		return invalidType("input", "a string or an array")
+	}
+	if v := checkString(root, "instructions", "instructions", false); v != nil {
+		return v
+	}
+	if v := checkNumbers(root, "", responsesNumbers); v != nil {
+		return v
+	}
+	if v := checkIntegers(root, "", responsesIntegers); v != nil {
+		return v
+	}
+	if v := checkBool(root, "stream"); v != nil {
+		return v
+	}
+	return checkOpenAITools(root.Get("tools"), "tools", false)
+}
+
+func validateClaudeRequest(root gjson.Result) *requestValidationError {
+	messages := root.Get("messages")
+	if !messages.Exists() {
+		return missingField("messages")
+	}
+	if !messages.IsArray() {
+		return invalidType("messages", "an array")
+	}
+	for i, msg := range messages.Array() {
+		path := fmt.Sprintf("messages.%d", i)
+		if !msg.IsObject() {
+			return invalidType(path, "an object")
+		}
+		if v := checkEnum(msg, "role", path+".role", claudeRoles, true); v != nil {
+			return v
+		}
+		content := msg.Get("content")
+		if !content.Exists() {
+			return missingField(path + ".content")
+		}
+		if content.Type != gjson.String && !content.IsArray() {
+			return invalidType(path+".content", "a string or an array")
+		}
+		for j, block := range content.Array() {
+			if content.Type == gjson.String {
+				break
+			}
+			blockPath := fmt.Sprintf("%s.content.%d", path, j)
+			if !block.IsObject() {
+				return invalidType(blockPath, "an object")
+			}
+			if v := checkString(block, "type", blockPath+".type", true); v != nil {
+				return v
+			}
+		}
+	}
+	if system := root.Get("system"); system.Exists() && system.Type != gjson.String && !system.IsArray() {
+		return invalidType("system", "a string or an array")
+	}
+	if v := checkNumbers(root, "", claudeNumbers); v != nil {
+		return v
+	}
+	if v := checkIntegers(root, "", claudeIntegers); v != nil {
+		return v
+	}
+	if v := checkBool(root, "stream"); v != nil {
+		return v
+	}
+	if tools := root.Get("tools"); tools.Exists() {
+		if !tools.IsArray() {
+			return invalidType("tools", "an array")
+		}
+		for i, tool := range tools.Array() {
+			path := fmt.Sprintf("tools.%d", i)
+			if !tool.IsObject() {
+				return invalidType(path, "an object")
+			}
+			if v := checkString(tool, "name", path+".name", true); v != nil {
+				return v
+			}
+		}
+	}
+	if choice := root.Get("tool_choice"); choice.Exists() {
+		if !choice.IsObject() {
+			return invalidType("tool_choice", "an object")
+		}
+		return checkEnum(choice, "type", "tool_choice.type", claudeToolChoices, true)
+	}
+	return nil
+}
+
+func validateGeminiRequest(root gjson.Result) *requestValidationError {
+	contentsPath := "contents"
+	contents := root.Get(contentsPath)
+	if !contents.Exists() {
+		if nested := root.Get("generateContentRequest"); nested.IsObject() {
+			contentsPath = "generateContentRequest.contents"
+			contents = nested.Get("contents")
+		}
+	}
+	if !contents.Exists() {
+		return missingField("contents")
+	}
+	if !contents.IsArray() {
+		return invalidType(contentsPath, "an array")
+	}
+	for i, content := range contents.Array() {
+		path := fmt.Sprintf("%s[%d]", contentsPath, i)
+		if !content.IsObject() {
+			return invalidType(path, "an object")
+		}
+		if v := checkEnum(content, "role", path+".role", geminiRoles, false); v != nil {
+			return v
+		}
+		parts := content.Get("parts")
+		if !parts.Exists() {
+			return missingField(path + ".parts")
+		}
+		if !parts.IsArray() {
+			return invalidType(path+".parts", "an array")
+		}
+	}
+	if config := root.Get("generationConfig"); config.Exists() {
+		if !config.IsObject() {
+			return invalidType("generationConfig", "an object")
+		}
+		if v := checkNumbers(config, "generationConfig.", geminiConfigNumbers); v != nil {
+			return v
+		}
+		if v := checkIntegers(config, "generationConfig.", geminiConfigInts); v != nil {
+			return v
+		}
+	}
+	if tools := root.Get("tools"); tools.Exists() && !tools.IsArray() {
+		return invalidType("tools", "an array")
+	}
+	return nil
+}
+
+// checkOpenAITools validates an OpenAI tools array. Chat Completions nests the function
+// definition under "function"; the Responses API declares the name inline.
+func checkOpenAITools(tools gjson.Result, path string, nested bool) *requestValidationError {
+	if !tools.Exists() {
+		return nil
+	}
+	if !tools.IsArray() {
+		return invalidType(path, "an array")
+	}
+	for i, tool := range tools.Array() {
+		toolPath := fmt.Sprintf("%s[%d]", path, i)
+		if !tool.IsObject() {
+			return invalidType(toolPath, "an object")
+		}
+		if v := checkString(tool, "type", toolPath+".type", true); v != nil {
+			return v
+		}
+		if tool.Get("type").String() != "function" {
+			continue
+		}
+		namePath := "name"
+		if nested {
+			namePath = "function.name"
+		}
+		if v := checkString(tool, namePath, toolPath+"."+namePath, true); v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+func checkString(node gjson.Result, field, path string, required bool) *requestValidationError {
+	value := node.Get(field)
+	if !value.Exists() {
+		if required {
+			return missingField(path)
+		}
+		return nil
+	}
+	if value.Type != gjson.String {
+		return invalidType(path, "a string")
+	}
+	return nil
+}
+
+func checkEnum(node gjson.Result, field, path string, allowed []string, required bool) *requestValidationError {
+	if v := checkString(node, field, path, required); v != nil {
+		return v
+	}
+	value := node.Get(field)
+	if !value.Exists() {
+		return nil
+	}
+	for _, candidate := range allowed {
+		if value.String() == candidate {
+			return nil
+		}
+	}
+	return invalidValue(path, fmt.Sprintf("must be one of %s, got %q", strings.Join(allowed, ", "), value.String()))
+}
+
+func checkNumbers(node gjson.Result, prefix string, fields []string) *requestValidationError {
+	for _, field := range fields {
+		if value := node.Get(field); value.Exists() && value.Type != gjson.Number && value.Type != gjson.Null {
+			return invalidType(prefix+field, "a number")
+		}
+	}
+	return nil
+}
+
+func checkIntegers(node gjson.Result, prefix string, fields []string) *requestValidationError {
+	for _, field := range fields {
+		value := node.Get(field)
+		if !value.Exists() || value.Type == gjson.Null {
+			continue
+		}
+		if value.Type != gjson.Number || value.Num != math.Trunc(value.Num) {
+			return invalidType(prefix+field, "an integer")
+		}
+	}
+	return nil
+}
+
+func checkBool(node gjson.Result, field string) *requestValidationError {
+	if value := node.Get(field); value.Exists() && value.Type != gjson.True && value.Type != gjson.False && value.Type != gjson.Null {
+		return invalidType(field, "a boolean")
+	}
+	return nil
+}
+
+func missingField(path string) *requestValidationError {
+	return &requestValidationError{code: "missing_required_parameter", message: fmt.Sprintf("%s: field required", path)}
+}
+
+func invalidType(path, expected string) *requestValidationError {
+	return &requestValidationError{code: "invalid_type", message: fmt.Sprintf("%s: expected %s", path, expected)}
+}
+
+func invalidValue(path, detail string) *requestValidationError {
+	return &requestValidationError{code: "invalid_value", message: fmt.Sprintf("%s: %s", path, detail)}
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestValidateRequestSchema(t *testing.T) {
+	tests := []struct {
+		name        string
+		handlerType string
+		body        string
+		wantMessage string
+	}{
+		{name: "openai valid", handlerType: constant.OpenAI, body: `{"model":"m","messages":[{"role":"user","content":"hi"}],"temperature":0.2}`},
+		{name: "openai missing messages", handlerType: constant.OpenAI, body: `{"model":"m"}`, wantMessage: "messages: field required"},
+		{name: "openai bad role", handlerType: constant.OpenAI, body: `{"messages":[{"role":"bot","content":"hi"}]}`, wantMessage: `messages[0].role: must be one of system, developer, user, assistant, tool, function, got "bot"`},
+		{name: "openai tool without id", handlerType: constant.OpenAI, body: `{"messages":[{"role":"tool","content":"x"}]}`},
+		{name: "openai tool id type", handlerType: constant.OpenAI, body: `{"messages":[{"role":"tool","tool_call_id":1,"content":"x"}]}`, wantMessage: "messages[0].tool_call_id: expected a string"},
+		{name: "openai max_tokens type", handlerType: constant.OpenAI, body: `{"messages":[{"role":"user","content":"hi"}],"max_tokens":"10"}`, wantMessage: "max_tokens: expected an integer"},
+		{name: "responses input type", handlerType: constant.OpenaiResponse, body: `{"input":5}`, wantMessage: "input: expected a string or an array"},
+		{name: "claude valid", handlerType: constant.Claude, body: `{"messages":[{"role":"user","content":[{"type":"text","text":"hi"}]}],"max_tokens":10}`},
+		{name: "claude system role", handlerType: constant.Claude, body: `{"messages":[{"role":"system","content":"hi"}]}`, wantMessage: `messages.0.role: must be one of user, assistant, got "system"`},
+		{name: "claude block type", handlerType: constant.Claude, body: `{"messages":[{"role":"user","content":[{"text":"hi"}]}]}`, wantMessage: "messages.0.content.0.type: field required"},
+		{name: "gemini parts", handlerType: constant.Gemini, body: `{"contents":[{"role":"user"}]}`, wantMessage: "contents[0].parts: field required"},
+		{name: "gemini count tokens", handlerType: constant.Gemini, body: `{"generateContentRequest":{"contents":[{"parts":[{"text":"hi"}]}]}}`},
+		{name: "gemini topK", handlerType: constant.Gemini, body: `{"contents":[],"generationConfig":{"topK":1.5}}`, wantMessage: "generationConfig.topK: expected an integer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violation := validateRequestSchema(tt.handlerType, []byte(tt.body))
+			if tt.wantMessage == "" {
+				if violation != nil {
+					t.Fatalf("unexpected violation: %s", violation.message)
+				}
+				return
+			}
+			if violation == nil || violation.message != tt.wantMessage {
+				t.Fatalf("violation = %+v, want %q", violation, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestValidateRequestUsesDialectErrorFormat(t *testing.T) {
+	handler := NewBaseAPIHandlers(&config.SDKConfig{RequestValidation: true}, nil)
+	errMsg := handler.ValidateRequest(constant.Claude, []byte(`{"model":"m"}`))
+	if errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %+v", errMsg)
+	}
+	body := []byte(errMsg.Error.Error())
+	if gjson.GetBytes(body, "type").String() != "error" || gjson.GetBytes(body, "error.type").String() != "invalid_request_error" {
+		t.Fatalf("unexpected claude error body: %s", body)
+	}
+
+	disabled := NewBaseAPIHandlers(&config.SDKConfig{}, nil)
+	if errMsg = disabled.ValidateRequest(constant.Claude, []byte(`{"model":"m"}`)); errMsg != nil {
+		t.Fatalf("expected validation to be off by default, got %+v", errMsg)
+	}
+}
@@ -0,0 +1,354 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultBreakerFailureThreshold = 5
+	defaultBreakerCooldown         = 30 * time.Second
+	defaultBreakerMaxCooldown      = 10 * time.Minute
+)
+
+// BreakerState is the state of a circuit breaker.
+type BreakerState string
+
+const (
+	// BreakerClosed lets all traffic through.
+	BreakerClosed BreakerState = "closed"
+	// BreakerOpen keeps traffic away until the cooldown elapses.
+	BreakerOpen BreakerState = "open"
+	// BreakerHalfOpen lets a single probe request through; its outcome closes or reopens the breaker.
+	BreakerHalfOpen BreakerState = "half-open"
+)
+
+// Breaker scopes reported by BreakerStatus.
+const (
+	BreakerScopeAuth     = "auth"
+	BreakerScopeProvider = "provider"
+)
+
+// BreakerStatus is a snapshot of one circuit breaker.
+type BreakerStatus struct {
+	Scope               string       `json:"scope"`
+	Key                 string       `json:"key"`
+	Provider            string       `json:"provider,omitempty"`
+	State               BreakerState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	Trips               int          `json:"trips"`
+	OpenedAt            *time.Time   `json:"opened_at,omitempty"`
+	RetryAt             *time.Time   `json:"retry_at,omitempty"`
+	LastError           string       `json:"last_error,omitempty"`
+}
+
+// breakerSettings is the resolved cfg.CircuitBreaker.
+type breakerSettings struct {
+	enabled           bool
+	threshold         int
+	providerThreshold int
+	cooldown          time.Duration
+	maxCooldown       time.Duration
+}
+
+func newBreakerSettings(cfg *internalconfig.Config) breakerSettings {
+	if cfg == nil || !cfg.CircuitBreaker.Enable {
+		return breakerSettings{}
+	}
+	c := cfg.CircuitBreaker
+	s := breakerSettings{
+		enabled:           true,
+		threshold:         defaultBreakerFailureThreshold,
+		providerThreshold: c.ProviderFailureThreshold,
+		cooldown:          defaultBreakerCooldown,
+		maxCooldown:       defaultBreakerMaxCooldown,
+	}
+	if c.FailureThreshold > 0 {
+		s.threshold = c.FailureThreshold
+	}
+	if c.CooldownSeconds > 0 {
+		s.cooldown = time.Duration(c.CooldownSeconds) * time.Second
+	}
+	if c.MaxCooldownSeconds > 0 {
+		s.maxCooldown = time.Duration(c.MaxCooldownSeconds) * time.Second
+	}
+	s.maxCooldown = max(s.maxCooldown, s.cooldown)
+	return s
+}
+
+type circuitBreaker struct {
+	provider  string
+	state     BreakerState
+	failures  int
+	trips     int
+	cooldown  time.Duration
+	openedAt  time.Time
+	retryAt   time.Time
+	probeAt   time.Time
+	lastError string
+}
+
+// admits reports whether the breaker lets a request through at now. With commit set, an open
+// breaker whose cooldown elapsed turns half-open and the probe slot is taken.
+func (b *circuitBreaker) admits(now time.Time, commit bool) bool {
+	if b == nil {
+		return true
+	}
+	switch b.state {
+	case BreakerOpen:
+		if now.Before(b.retryAt) {
+			return false
+		}
+	case BreakerHalfOpen:
+		// A probe that never reported back frees its slot after one cooldown.
+		if !b.probeAt.IsZero() && now.Sub(b.probeAt) < b.cooldown {
+			return false
+		}
+	default:
+		return true
+	}
+	if commit {
+		b.state = BreakerHalfOpen
+		b.probeAt = now
+	}
+	return true
+}
+
+// recordFailure counts a failure and reports whether it opened the breaker.
+func (b *circuitBreaker) recordFailure(threshold int, s breakerSettings, message string, now time.Time) bool {
+	b.failures++
+	b.lastError = message
+	switch {
+	case b.state == BreakerHalfOpen:
+		b.cooldown = min(b.cooldown*2, s.maxCooldown)
+	case b.state == BreakerClosed && b.failures >= threshold:
+		b.cooldown = s.cooldown
+	default:
+		return false
+	}
+	b.state = BreakerOpen
+	b.trips++
+	b.openedAt = now
+	b.retryAt = now.Add(b.cooldown)
+	b.probeAt = time.Time{}
+	return true
+}
+
+func (b *circuitBreaker) reset() {
+	b.state = BreakerClosed
+	b.failures = 0
+	b.cooldown = 0
+	b.openedAt = time.Time{}
+	b.retryAt = time.Time{}
+	b.probeAt = time.Time{}
+	b.lastError = ""
+}
+
+func (b *circuitBreaker) status(scope, key string) BreakerStatus {
+	st := BreakerStatus{
+		Scope:               scope,
+		Key:                 key,
+		Provider:            b.provider,
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		Trips:               b.trips,
+		LastError:           b.lastError,
+	}
+	if !b.openedAt.IsZero() {
+		openedAt, retryAt := b.openedAt, b.retryAt
+		st.OpenedAt, st.RetryAt = &openedAt, &retryAt
+	}
+	return st
+}
+
+// circuitBreakers tracks consecutive upstream failures per auth and per provider. Breakers live
+// in memory only; a restart closes them all.
+type circuitBreakers struct {
+	mu        sync.Mutex
+	auths     map[string]*circuitBreaker
+	providers map[string]*circuitBreaker
+}
+
+func newCircuitBreakers() *circuitBreakers {
+	return &circuitBreakers{
+		auths:     make(map[string]*circuitBreaker),
+		providers: make(map[string]*circuitBreaker),
+	}
+}
+
+func (m *Manager) breakerSettings() breakerSettings {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	return newBreakerSettings(cfg)
+}
+
+// breakerAdmits reports whether the circuit breakers of auth and its provider let a request
+// through, taking the half-open probe slots when they do.
+func (m *Manager) breakerAdmits(auth *Auth) bool {
+	if m == nil || m.breakers == nil || auth == nil || !m.breakerSettings().enabled {
+		return true
+	}
+	now := time.Now()
+	cb := m.breakers
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	authBreaker := cb.auths[auth.ID]
+	providerBreaker := cb.providers[strings.ToLower(strings.TrimSpace(auth.Provider))]
+	if !authBreaker.admits(now, false) || !providerBreaker.admits(now, false) {
+		return false
+	}
+	authBreaker.admits(now, true)
+	providerBreaker.admits(now, true)
+	return true
+}
+
+// breakerRejects reports whether the circuit breakers of auth or its provider currently keep
+// traffic away, without taking a probe slot.
+func (m *Manager) breakerRejects(auth *Auth) bool {
+	if m == nil || m.breakers == nil || auth == nil || !m.breakerSettings().enabled {
+		return false
+	}
+	now := time.Now()
+	cb := m.breakers
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return !cb.auths[auth.ID].admits(now, false) || !cb.providers[strings.ToLower(strings.TrimSpace(auth.Provider))].admits(now, false)
+}
+
+// newCircuitOpenError reports that every remaining candidate was held back by a circuit breaker.
+func newCircuitOpenError() *Error {
+	return &Error{
+		Code:       "circuit_open",
+		Message:    "matching credentials are temporarily out of rotation after repeated upstream failures",
+		Retryable:  true,
+		HTTPStatus: http.StatusServiceUnavailable,
+	}
+}
+
+// isBreakerFailure reports whether a failed result indicates an unhealthy upstream rather than
+// a problem with the request or the account's quota.
+func isBreakerFailure(err *Error) bool {
+	status := statusCodeFromResult(err)
+	return status == 0 || status == 408 || status >= 500
+}
+
+// recordBreakerResult feeds an execution result into the breakers of its auth and provider.
+func (m *Manager) recordBreakerResult(ctx context.Context, result Result) {
+	if m == nil || m.breakers == nil || result.AuthID == "" {
+		return
+	}
+	settings := m.breakerSettings()
+	if !settings.enabled {
+		return
+	}
+	provider := strings.ToLower(strings.TrimSpace(result.Provider))
+	now := time.Now()
+	cb := m.breakers
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	authBreaker := cb.auths[result.AuthID]
+	providerBreaker := cb.providers[provider]
+	if result.Success {
+		if authBreaker != nil {
+			authBreaker.reset()
+		}
+		if providerBreaker != nil {
+			providerBreaker.reset()
+		}
+		return
+	}
+	if !isBreakerFailure(result.Error) {
+		// The upstream answered; release the probe slots without judging its health.
+		if authBreaker != nil && authBreaker.state == BreakerHalfOpen {
+			authBreaker.probeAt = time.Time{}
+		}
+		if providerBreaker != nil && providerBreaker.state == BreakerHalfOpen {
+			providerBreaker.probeAt = time.Time{}
+		}
+		return
+	}
+
+	message := ""
+	if result.Error != nil {
+		message = result.Error.Message
+	}
+	if authBreaker == nil {
+		authBreaker = &circuitBreaker{provider: provider, state: BreakerClosed}
+		cb.auths[result.AuthID] = authBreaker
+	}
+	if authBreaker.recordFailure(settings.threshold, settings, message, now) {
+		logEntryWithRequestID(ctx).WithFields(log.Fields{
+			"auth_id":  result.AuthID,
+			"provider": provider,
+			"failures": authBreaker.failures,
+			"cooldown": authBreaker.cooldown.String(),
+		}).Warn("circuit breaker opened for credential")
+	}
+	if settings.providerThreshold <= 0 || provider == "" {
+		return
+	}
+	if providerBreaker == nil {
+		providerBreaker = &circuitBreaker{provider: provider, state: BreakerClosed}
+		cb.providers[provider] = providerBreaker
+	}
+	if providerBreaker.recordFailure(settings.providerThreshold, settings, message, now) {
+		logEntryWithRequestID(ctx).WithFields(log.Fields{
+			"provider": provider,
+			"failures": providerBreaker.failures,
+			"cooldown": providerBreaker.cooldown.String(),
+		}).Warn("circuit breaker opened for provider")
+	}
+}
+
+// CircuitBreakerStatuses returns the tracked circuit breakers, auths first, each sorted by key.
+func (m *Manager) CircuitBreakerStatuses() []BreakerStatus {
+	if m == nil || m.breakers == nil {
+		return nil
+	}
+	cb := m.breakers
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	out := make([]BreakerStatus, 0, len(cb.auths)+len(cb.providers))
+	for id, b := range cb.auths {
+		out = append(out, b.status(BreakerScopeAuth, id))
+	}
+	for provider, b := range cb.providers {
+		out = append(out, b.status(BreakerScopeProvider, provider))
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Scope != out[j].Scope {
+			return out[i].Scope == BreakerScopeAuth
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
+
+// ResetCircuitBreaker closes the breaker of scope and key, returning false when none is tracked.
+func (m *Manager) ResetCircuitBreaker(scope, key string) bool {
+	if m == nil || m.breakers == nil {
+		return false
+	}
+	cb := m.breakers
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	var b *circuitBreaker
+	switch scope {
+	case BreakerScopeAuth:
+		b = cb.auths[key]
+	case BreakerScopeProvider:
+		b = cb.providers[strings.ToLower(strings.TrimSpace(key))]
+	}
+	if b == nil {
+		return false
+	}
+	b.reset()
+	return true
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestCircuitBreakerHalfOpenProbe(t *testing.T) {
+	t.Parallel()
+
+	settings := breakerSettings{enabled: true, threshold: 2, cooldown: time.Minute, maxCooldown: 3 * time.Minute}
+	now := time.Now()
+	b := &circuitBreaker{state: BreakerClosed}
+
+	if b.recordFailure(settings.threshold, settings, "boom", now) {
+		t.Fatal("breaker opened below the threshold")
+	}
+	if !b.recordFailure(settings.threshold, settings, "boom", now) || b.state != BreakerOpen {
+		t.Fatalf("state = %s, want open after threshold", b.state)
+	}
+	if b.admits(now.Add(30*time.Second), true) {
+		t.Fatal("open breaker admitted traffic during cooldown")
+	}
+
+	probeAt := now.Add(time.Minute)
+	if !b.admits(probeAt, true) || b.state != BreakerHalfOpen {
+		t.Fatalf("state = %s, want half-open probe after cooldown", b.state)
+	}
+	if b.admits(probeAt.Add(time.Second), true) {
+		t.Fatal("half-open breaker admitted a second probe")
+	}
+
+	b.recordFailure(settings.threshold, settings, "boom", probeAt)
+	if b.state != BreakerOpen || b.cooldown != 2*time.Minute {
+		t.Fatalf("state = %s cooldown = %s, want open with doubled cooldown", b.state, b.cooldown)
+	}
+	b.state, b.cooldown = BreakerHalfOpen, 2*time.Minute
+	b.recordFailure(settings.threshold, settings, "boom", probeAt)
+	if b.cooldown != settings.maxCooldown {
+		t.Fatalf("cooldown = %s, want capped at %s", b.cooldown, settings.maxCooldown)
+	}
+
+	b.reset()
+	if b.state != BreakerClosed || b.failures != 0 || !b.admits(probeAt, true) {
+		t.Fatalf("reset breaker = %+v, want closed", b)
+	}
+}
+
+func TestMarkResultOpensCircuitBreakers(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	m.SetConfig(&internalconfig.Config{CircuitBreaker: internalconfig.CircuitBreakerConfig{
+		Enable:                   true,
+		FailureThreshold:         2,
+		ProviderFailureThreshold: 3,
+	}})
+	for _, id := range []string{"cb1", "cb2"} {
+		if _, err := m.Register(context.Background(), &Auth{ID: id, Provider: "codex"}); err != nil {
+			t.Fatalf("register %s: %v", id, err)
+		}
+	}
+	cb1, _ := m.GetByID("cb1")
+	cb2, _ := m.GetByID("cb2")
+	ctx := context.Background()
+
+	// Client errors do not count towards the breaker.
+	m.MarkResult(ctx, Result{AuthID: "cb1", Provider: "codex", Error: &Error{HTTPStatus: 400, Message: "bad request"}})
+	m.MarkResult(ctx, Result{AuthID: "cb1", Provider: "codex", Error: &Error{HTTPStatus: 502, Message: "bad gateway"}})
+	if m.breakerRejects(cb1) {
+		t.Fatal("breaker opened before reaching the threshold")
+	}
+	m.MarkResult(ctx, Result{AuthID: "cb1", Provider: "codex", Error: &Error{Message: "connection reset"}})
+	if !m.breakerRejects(cb1) {
+		t.Fatal("expected cb1 breaker to be open")
+	}
+	if m.breakerRejects(cb2) {
+		t.Fatal("cb2 must stay in rotation while only the cb1 breaker is open")
+	}
+
+	m.MarkResult(ctx, Result{AuthID: "cb2", Provider: "codex", Error: &Error{HTTPStatus: 504, Message: "timeout"}})
+	if !m.breakerRejects(cb2) {
+		t.Fatal("expected the provider breaker to keep cb2 out of rotation")
+	}
+
+	statuses := m.CircuitBreakerStatuses()
+	if len(statuses) != 3 || statuses[0].Key != "cb1" || statuses[0].State != BreakerOpen || statuses[2].Scope != BreakerScopeProvider {
+		t.Fatalf("statuses = %+v", statuses)
+	}
+
+	if !m.ResetCircuitBreaker(BreakerScopeProvider, "codex") || !m.ResetCircuitBreaker(BreakerScopeAuth, "cb1") {
+		t.Fatal("reset failed")
+	}
+	if m.breakerRejects(cb1) || m.breakerRejects(cb2) {
+		t.Fatal("expected reset breakers to admit traffic")
+	}
+	if m.ResetCircuitBreaker(BreakerScopeAuth, "missing") {
+		t.Fatal("reset of an untracked breaker reported success")
+	}
+}
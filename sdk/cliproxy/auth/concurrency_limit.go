@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// MaxInFlightAttribute overrides concurrency-limit.max-in-flight for one auth. "0" lifts the
+// limit for that auth.
+const MaxInFlightAttribute = "max_in_flight"
+
+const defaultConcurrencyQueueTimeout = 30 * time.Second
+
+// ConcurrencyStatus is a snapshot of the concurrency limiter of one auth.
+type ConcurrencyStatus struct {
+	AuthID   string `json:"auth_id"`
+	Provider string `json:"provider,omitempty"`
+	Limit    int    `json:"limit"`
+	InFlight int    `json:"in_flight"`
+	Queued   int    `json:"queued"`
+	// PeakQueued is the deepest the queue has been.
+	PeakQueued int `json:"peak_queued"`
+	// TotalQueued counts requests that had to wait for a slot.
+	TotalQueued int64 `json:"total_queued"`
+	// Rejected counts requests that timed out in the queue or found it full.
+	Rejected int64 `json:"rejected"`
+}
+
+// concurrencySettings is the resolved cfg.ConcurrencyLimit for one auth.
+type concurrencySettings struct {
+	limit        int
+	maxQueue     int
+	queueTimeout time.Duration
+}
+
+// concurrencySettings resolves the concurrency limit of a non-nil auth; a limit <= 0 means
+// unlimited.
+func (m *Manager) concurrencySettings(auth *Auth) concurrencySettings {
+	s := concurrencySettings{queueTimeout: defaultConcurrencyQueueTimeout}
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg != nil {
+		c := cfg.ConcurrencyLimit
+		s.limit = c.MaxInFlight
+		s.maxQueue = c.MaxQueue
+		if c.QueueTimeoutSeconds > 0 {
+			s.queueTimeout = time.Duration(c.QueueTimeoutSeconds) * time.Second
+		}
+	}
+	if raw := strings.TrimSpace(auth.Attributes[MaxInFlightAttribute]); raw != "" {
+		if limit, errParse := strconv.Atoi(raw); errParse == nil {
+			s.limit = limit
+		}
+	}
+	return s
+}
+
+// authLimiter is the in-flight semaphore of one auth. Waiters are granted slots in FIFO order.
+type authLimiter struct {
+	provider    string
+	limit       int
+	inFlight    int
+	waiters     []chan struct{}
+	peakQueued  int
+	totalQueued int64
+	rejected    int64
+}
+
+// grantLocked hands free slots to queued waiters.
+func (l *authLimiter) grantLocked() {
+	for len(l.waiters) > 0 && l.inFlight < l.limit {
+		l.inFlight++
+		close(l.waiters[0])
+		l.waiters = l.waiters[1:]
+	}
+}
+
+// concurrencyLimiters tracks in-flight upstream requests per auth. Limiters live in memory only.
+type concurrencyLimiters struct {
+	mu    sync.Mutex
+	auths map[string]*authLimiter
+}
+
+func newConcurrencyLimiters() *concurrencyLimiters {
+	return &concurrencyLimiters{auths: make(map[string]*authLimiter)}
+}
+
+// newConcurrencyLimitError reports that auth had no free slot within the queue timeout.
+func newConcurrencyLimitError() *Error {
+	return &Error{
+		Code:       "concurrency_limit",
+		Message:    "credential is at its concurrency limit",
+		Retryable:  true,
+		HTTPStatus: http.StatusTooManyRequests,
+	}
+}
+
+// acquireSlot takes an in-flight slot of auth, queueing while all slots are taken. The returned
+// release must be called once the upstream request, including any stream, has finished.
+func (m *Manager) acquireSlot(ctx context.Context, auth *Auth) (func(), error) {
+	if m == nil || m.limiters == nil || auth == nil {
+		return func() {}, nil
+	}
+	settings := m.concurrencySettings(auth)
+	if settings.limit <= 0 {
+		return func() {}, nil
+	}
+	cl := m.limiters
+	cl.mu.Lock()
+	l := cl.auths[auth.ID]
+	if l == nil {
+		l = &authLimiter{}
+		cl.auths[auth.ID] = l
+	}
+	l.provider = strings.ToLower(strings.TrimSpace(auth.Provider))
+	l.limit = settings.limit
+	l.grantLocked()
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			cl.mu.Lock()
+			l.inFlight--
+			l.grantLocked()
+			cl.mu.Unlock()
+		})
+	}
+	if l.inFlight < l.limit && len(l.waiters) == 0 {
+		l.inFlight++
+		cl.mu.Unlock()
+		return release, nil
+	}
+	if settings.maxQueue > 0 && len(l.waiters) >= settings.maxQueue {
+		l.rejected++
+		cl.mu.Unlock()
+		return nil, newConcurrencyLimitError()
+	}
+	granted := make(chan struct{})
+	l.waiters = append(l.waiters, granted)
+	l.totalQueued++
+	l.peakQueued = max(l.peakQueued, len(l.waiters))
+	cl.mu.Unlock()
+
+	timer := time.NewTimer(settings.queueTimeout)
+	defer timer.Stop()
+	var errWait error
+	select {
+	case <-granted:
+		return release, nil
+	case <-ctx.Done():
+		errWait = ctx.Err()
+	case <-timer.C:
+		errWait = newConcurrencyLimitError()
+	}
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	idx := slices.Index(l.waiters, granted)
+	if idx < 0 {
+		// The slot was granted while the wait ended; keep it.
+		return release, nil
+	}
+	l.waiters = slices.Delete(l.waiters, idx, idx+1)
+	if _, ok := errWait.(*Error); ok {
+		l.rejected++
+		logEntryWithRequestID(ctx).WithFields(log.Fields{
+			"auth_id":  auth.ID,
+			"provider": l.provider,
+			"limit":    l.limit,
+			"queued":   len(l.waiters),
+		}).Warn("timed out waiting for credential concurrency slot")
+	}
+	return nil, errWait
+}
+
+// ConcurrencyStatuses returns the concurrency limiters of the auths that have been limited,
+// sorted by auth ID.
+func (m *Manager) ConcurrencyStatuses() []ConcurrencyStatus {
+	if m == nil || m.limiters == nil {
+		return nil
+	}
+	cl := m.limiters
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	out := make([]ConcurrencyStatus, 0, len(cl.auths))
+	for id, l := range cl.auths {
+		out = append(out, ConcurrencyStatus{
+			AuthID:      id,
+			Provider:    l.provider,
+			Limit:       l.limit,
+			InFlight:    l.inFlight,
+			Queued:      len(l.waiters),
+			PeakQueued:  l.peakQueued,
+			TotalQueued: l.totalQueued,
+			Rejected:    l.rejected,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].AuthID < out[j].AuthID })
+	return out
+}
@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+func waitForQueued(t *testing.T, m *Manager, queued int) ConcurrencyStatus {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		statuses := m.ConcurrencyStatuses()
+		if len(statuses) == 1 && statuses[0].Queued == queued {
+			return statuses[0]
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("statuses = %+v, want %d queued", statuses, queued)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestAcquireSlotQueuesUntilRelease(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(nil, nil, nil)
+	m.SetConfig(&internalconfig.Config{ConcurrencyLimit: internalconfig.ConcurrencyLimitConfig{MaxInFlight: 1}})
+	auth := &Auth{ID: "limited", Provider: "claude"}
+
+	release, err := m.acquireSlot(context.Background(), auth)
+	if err != nil {
+		t.Fatalf("first acquire: %v", err)
+	}
+	acquired := make(chan func(), 1)
+	go func() {
+		next, errNext := m.acquireSlot(context.Background(), auth)
+		if errNext != nil {
+			t.Errorf("queued acquire: %v", errNext)
+			close(acquired)
+			return
+		}
+		acquired <- next
+	}()
+	status := waitForQueued(t, m, 1)
+	if status.InFlight != 1 || status.Limit != 1 || status.TotalQueued != 1 {
+		t.Fatalf("status = %+v", status)
+	}
+
+	release()
+	release() // A second call must not free another slot.
+	next := <-acquired
+	if next == nil {
+		t.FailNow()
+	}
+	status = waitForQueued(t, m, 0)
+	if status.InFlight != 1 || status.PeakQueued != 1 {
+		t.Fatalf("status after release = %+v", status)
+	}
+	next()
+	if status = waitForQueued(t, m, 0); status.InFlight != 0 {
+		t.Fatalf("in flight = %d, want 0", status.InFlight)
+	}
+}
+
+func TestAcquireSlotRejectsBeyondQueue(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(nil, nil, nil)
+	m.SetConfig(&internalconfig.Config{ConcurrencyLimit: internalconfig.ConcurrencyLimitConfig{MaxInFlight: 1, MaxQueue: 1}})
+	auth := &Auth{ID: "limited", Provider: "codex"}
+
+	release, err := m.acquireSlot(context.Background(), auth)
+	if err != nil {
+		t.Fatalf("first acquire: %v", err)
+	}
+	defer release()
+	ctx, cancel := context.WithCancel(context.Background())
+	waited := make(chan error, 1)
+	go func() {
+		_, errWait := m.acquireSlot(ctx, auth)
+		waited <- errWait
+	}()
+	waitForQueued(t, m, 1)
+
+	_, err = m.acquireSlot(context.Background(), auth)
+	var limitErr *Error
+	if !errors.As(err, &limitErr) || limitErr.Code != "concurrency_limit" {
+		t.Fatalf("err = %v, want concurrency_limit", err)
+	}
+	cancel()
+	if errWait := <-waited; !errors.Is(errWait, context.Canceled) {
+		t.Fatalf("queued err = %v, want context canceled", errWait)
+	}
+	if status := waitForQueued(t, m, 0); status.Rejected != 1 || status.InFlight != 1 {
+		t.Fatalf("status = %+v", status)
+	}
+}
+
+func TestAcquireSlotAttributeOverride(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(nil, nil, nil)
+	m.SetConfig(&internalconfig.Config{ConcurrencyLimit: internalconfig.ConcurrencyLimitConfig{MaxInFlight: 1}})
+	auth := &Auth{ID: "unlimited", Provider: "gemini", Attributes: map[string]string{MaxInFlightAttribute: "0"}}
+	for i := 0; i < 3; i++ {
+		if _, err := m.acquireSlot(context.Background(), auth); err != nil {
+			t.Fatalf("acquire %d: %v", i, err)
+		}
+	}
+	if statuses := m.ConcurrencyStatuses(); len(statuses) != 0 {
+		t.Fatalf("statuses = %+v, want unlimited auth untracked", statuses)
+	}
+}
+
+func TestStreamHoldsSlotUntilDrained(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(nil, nil, nil)
+	m.SetConfig(&internalconfig.Config{ConcurrencyLimit: internalconfig.ConcurrencyLimitConfig{MaxInFlight: 1}})
+	executor := &scriptedStreamExecutor{singleModeExecutor: singleModeExecutor{streaming: true}, streams: [][]cliproxyexecutor.StreamChunk{
+		{openAIDelta("a"), openAIDelta("b")},
+	}}
+	auth := &Auth{ID: "stream-slot", Provider: "codex"}
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FormatOpenAI}
+
+	result, err := m.executeStreamWithModelPool(context.Background(), executor, auth, "codex", cliproxyexecutor.Request{}, opts, "m", []string{"m"}, false)
+	if err != nil {
+		t.Fatalf("executeStreamWithModelPool: %v", err)
+	}
+	if status := waitForQueued(t, m, 0); status.InFlight != 1 {
+		t.Fatalf("in flight = %d while streaming, want 1", status.InFlight)
+	}
+	collectStream(t, result)
+	deadline := time.Now().Add(2 * time.Second)
+	for m.ConcurrencyStatuses()[0].InFlight != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("slot not released after the stream finished")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
@@ -17,11 +18,13 @@ import (
 
 	"github.com/google/uuid"
 	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/lifecycle"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -173,6 +176,12 @@ type Manager struct {
 	// Optional HTTP RoundTripper provider injected by host.
 	rtProvider RoundTripperProvider
 
+	// breakers removes auths and providers with consecutive upstream failures from rotation.
+	breakers *circuitBreakers
+
+	// limiters caps in-flight upstream requests per auth.
+	limiters *concurrencyLimiters
+
 	// Auto refresh state
 	refreshCancel context.CancelFunc
 	refreshLoop   *authAutoRefreshLoop
@@ -194,6 +203,8 @@ func NewManager(store Store, selector Selector, hook Hook) *Manager {
 		auths:            make(map[string]*Auth),
 		providerOffsets:  make(map[string]int),
 		modelPoolOffsets: make(map[string]int),
+		breakers:         newCircuitBreakers(),
+		limiters:         newConcurrencyLimiters(),
 	}
 	// atomic.Value requires non-nil initial value.
 	manager.runtimeConfig.Store(&internalconfig.Config{})
@@ -683,10 +694,10 @@ func discardStreamChunks(ch <-chan cliproxyexecutor.StreamChunk) {
 	if ch == nil {
 		return
 	}
-	go func() {
+	lifecycle.Go(context.Background(), "auth.stream.drain", func() {
 		for range ch {
 		}
-	}()
+	})
 }
 
 type streamBootstrapError struct {
@@ -774,10 +785,11 @@ func readStreamBootstrap(ctx context.Context, ch <-chan cliproxyexecutor.StreamC
 	}
 }
 
-func (m *Manager) wrapStreamResult(ctx context.Context, auth *Auth, provider, resultModel string, headers http.Header, buffered []cliproxyexecutor.StreamChunk, remaining <-chan cliproxyexecutor.StreamChunk) *cliproxyexecutor.StreamResult {
+func (m *Manager) wrapStreamResult(ctx context.Context, auth *Auth, provider, resultModel string, headers http.Header, buffered []cliproxyexecutor.StreamChunk, remaining <-chan cliproxyexecutor.StreamChunk, resumer *streamResumer, release func()) *cliproxyexecutor.StreamResult {
 	out := make(chan cliproxyexecutor.StreamChunk)
-	go func() {
+	lifecycle.Go(ctx, "auth.stream", func() {
 		defer close(out)
+		defer release()
 		var failed bool
 		forward := true
 		emit := func(chunk cliproxyexecutor.StreamChunk) bool {
@@ -804,22 +816,49 @@ func (m *Manager) wrapStreamResult(ctx context.Context, auth *Auth, provider, re
 				return true
 			}
 		}
-		for _, chunk := range buffered {
-			if ok := emit(chunk); !ok {
-				discardStreamChunks(remaining)
-				return
+		// forwarded keeps the emitted payloads while the stream can still be resumed.
+		var forwarded [][]byte
+		var continuation *sdktranslator.StreamContinuation
+		deliver := func(chunk cliproxyexecutor.StreamChunk) bool {
+			if chunk.Err == nil && continuation != nil {
+				if chunk.Payload = continuation.Splice(chunk.Payload); chunk.Payload == nil {
+					return true
+				}
+			}
+			if chunk.Err == nil && resumer != nil {
+				forwarded = append(forwarded, chunk.Payload)
 			}
+			return emit(chunk)
 		}
-		for chunk := range remaining {
-			if ok := emit(chunk); !ok {
-				discardStreamChunks(remaining)
-				return
+		for {
+			for _, chunk := range buffered {
+				if ok := deliver(chunk); !ok {
+					discardStreamChunks(remaining)
+					return
+				}
 			}
+			var resumed *resumedStream
+			for chunk := range remaining {
+				if chunk.Err != nil && resumer != nil {
+					if resumed, _ = resumer.resume(ctx, forwarded, chunk.Err); resumed != nil {
+						discardStreamChunks(remaining)
+						break
+					}
+				}
+				if ok := deliver(chunk); !ok {
+					discardStreamChunks(remaining)
+					return
+				}
+			}
+			if resumed == nil {
+				break
+			}
+			buffered, remaining, continuation = resumed.buffered, resumed.chunks, resumed.continuation
 		}
 		if !failed {
 			m.MarkResult(ctx, Result{AuthID: auth.ID, Provider: provider, Model: resultModel, Success: true})
 		}
-	}()
+	})
 	return &cliproxyexecutor.StreamResult{Headers: headers, Chunks: out}
 }
 
@@ -827,12 +866,49 @@ func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor Provi
 	if executor == nil {
 		return nil, &Error{Code: "executor_not_found", Message: "executor not registered"}
 	}
+	release, errSlot := m.acquireSlot(ctx, auth)
+	if errSlot != nil {
+		return nil, errSlot
+	}
+	// The slot is held until the stream handed to wrapStreamResult finishes.
+	streaming := false
+	defer func() {
+		if !streaming {
+			release()
+		}
+	}()
 	var lastErr error
 	for idx, execModel := range execModels {
 		resultModel := m.stateModelForExecution(auth, routeModel, execModel, pooled)
 		execReq := req
 		execReq.Model = execModel
-		streamResult, errStream := executor.ExecuteStream(ctx, auth, execReq, opts)
+		synth := m.streamSynthesis(auth)
+		resume := m.streamResume(auth)
+		var (
+			streamResult *cliproxyexecutor.StreamResult
+			errStream    error
+			buffered     []cliproxyexecutor.StreamChunk
+			closed       bool
+			bootstrapErr error
+		)
+		for attempt := 0; ; attempt++ {
+			streamResult, errStream = executeStreamAdapted(ctx, executor, auth, execReq, opts, synth)
+			if errStream == nil {
+				buffered, closed, bootstrapErr = readStreamBootstrap(ctx, streamResult.Chunks)
+			}
+			errAttempt := cmp.Or(errStream, bootstrapErr)
+			if errAttempt == nil || !resume.retries(ctx, attempt, errAttempt) {
+				break
+			}
+			if streamResult != nil {
+				discardStreamChunks(streamResult.Chunks)
+			}
+			logEntryWithRequestID(ctx).WithFields(log.Fields{
+				"auth_id":  auth.ID,
+				"provider": provider,
+				"attempt":  attempt + 1,
+			}).WithError(errAttempt).Warn("upstream stream failed before first payload, retrying")
+		}
 		if errStream != nil {
 			if errCtx := ctx.Err(); errCtx != nil {
 				return nil, errCtx
@@ -851,7 +927,6 @@ func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor Provi
 			continue
 		}
 
-		buffered, closed, bootstrapErr := readStreamBootstrap(ctx, streamResult.Chunks)
 		if bootstrapErr != nil {
 			if errCtx := ctx.Err(); errCtx != nil {
 				discardStreamChunks(streamResult.Chunks)
@@ -908,7 +983,9 @@ func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor Provi
 			close(closedCh)
 			remaining = closedCh
 		}
-		return m.wrapStreamResult(ctx, auth.Clone(), provider, resultModel, streamResult.Headers, buffered, remaining), nil
+		resumer := newStreamResumer(executor, auth, execReq, opts, synth, resume)
+		streaming = true
+		return m.wrapStreamResult(ctx, auth.Clone(), provider, resultModel, streamResult.Headers, buffered, remaining, resumer, release), nil
 	}
 	if lastErr == nil {
 		lastErr = &Error{Code: "auth_not_found", Message: "no upstream model available"}
@@ -968,6 +1045,20 @@ func (m *Manager) rebuildAPIKeyModelAliasLocked(cfg *internalconfig.Config) {
 		case "vertex":
 			if entry := resolveVertexAPIKeyConfig(cfg, auth); entry != nil {
 				compileAPIKeyModelAliasForModels(byAlias, entry.Models)
+			} else if entry := resolveVertexCredentialConfig(cfg, auth); entry != nil {
+				compileAPIKeyModelAliasForModels(byAlias, entry.Models)
+			}
+		case "azure-openai":
+			if entry := resolveAzureOpenAIKeyConfig(cfg, auth); entry != nil {
+				compileAPIKeyModelAliasForModels(byAlias, entry.Models)
+			}
+		case "bedrock":
+			if entry := resolveBedrockKeyConfig(cfg, auth); entry != nil {
+				compileAPIKeyModelAliasForModels(byAlias, entry.Models)
+			}
+		case "ollama":
+			if entry := resolveOllamaKeyConfig(cfg, auth); entry != nil {
+				compileAPIKeyModelAliasForModels(byAlias, entry.Models)
 			}
 		default:
 			// OpenAI-compat uses config selection from auth.Attributes.
@@ -1288,7 +1379,7 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 	}
 	routeModel := req.Model
 	opts = ensureRequestedModelMetadata(opts, routeModel)
-	tried := make(map[string]struct{})
+	tried := excludedAuthIDsFromMetadata(opts.Metadata)
 	attempted := make(map[string]struct{})
 	var lastErr error
 	for {
@@ -1327,7 +1418,16 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 			resultModel := m.stateModelForExecution(auth, routeModel, upstreamModel, pooled)
 			execReq := req
 			execReq.Model = upstreamModel
-			resp, errExec := executor.Execute(execCtx, auth, execReq, opts)
+			release, errSlot := m.acquireSlot(execCtx, auth)
+			if errSlot != nil {
+				if errCtx := execCtx.Err(); errCtx != nil {
+					return cliproxyexecutor.Response{}, errCtx
+				}
+				authErr = errSlot
+				break
+			}
+			resp, errExec := executeAdapted(execCtx, executor, auth, execReq, opts, m.forcesStream(auth))
+			release()
 			result := Result{AuthID: auth.ID, Provider: provider, Model: resultModel, Success: errExec == nil}
 			if errExec != nil {
 				if errCtx := execCtx.Err(); errCtx != nil {
@@ -1366,7 +1466,7 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 	}
 	routeModel := req.Model
 	opts = ensureRequestedModelMetadata(opts, routeModel)
-	tried := make(map[string]struct{})
+	tried := excludedAuthIDsFromMetadata(opts.Metadata)
 	attempted := make(map[string]struct{})
 	var lastErr error
 	for {
@@ -1444,7 +1544,7 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 	}
 	routeModel := req.Model
 	opts = ensureRequestedModelMetadata(opts, routeModel)
-	tried := make(map[string]struct{})
+	tried := excludedAuthIDsFromMetadata(opts.Metadata)
 	attempted := make(map[string]struct{})
 	var lastErr error
 	for {
@@ -1549,6 +1649,19 @@ func pinnedAuthIDFromMetadata(meta map[string]any) string {
 	}
 }
 
+// excludedAuthIDsFromMetadata returns the auth IDs the caller excluded from selection, as the
+// initial set of tried auths.
+func excludedAuthIDsFromMetadata(meta map[string]any) map[string]struct{} {
+	tried := make(map[string]struct{})
+	ids, _ := meta[cliproxyexecutor.ExcludedAuthsMetadataKey].([]string)
+	for _, id := range ids {
+		if id = strings.TrimSpace(id); id != "" {
+			tried[id] = struct{}{}
+		}
+	}
+	return tried
+}
+
 func disallowFreeAuthFromMetadata(meta map[string]any) bool {
 	if len(meta) == 0 {
 		return false
@@ -1648,6 +1761,12 @@ func (m *Manager) applyAPIKeyModelAlias(auth *Auth, requestedModel string) strin
 		upstreamModel = resolveUpstreamModelForCodexAPIKey(cfg, auth, requestedModel)
 	case "vertex":
 		upstreamModel = resolveUpstreamModelForVertexAPIKey(cfg, auth, requestedModel)
+	case "azure-openai":
+		upstreamModel = resolveUpstreamModelForAzureOpenAIKey(cfg, auth, requestedModel)
+	case "bedrock":
+		upstreamModel = resolveUpstreamModelForBedrockKey(cfg, auth, requestedModel)
+	case "ollama":
+		upstreamModel = resolveUpstreamModelForOllamaKey(cfg, auth, requestedModel)
 	default:
 		upstreamModel = resolveUpstreamModelForOpenAICompatAPIKey(cfg, auth, requestedModel)
 	}
@@ -1732,6 +1851,48 @@ func resolveVertexAPIKeyConfig(cfg *internalconfig.Config, auth *Auth) *internal
 	return resolveAPIKeyConfig(cfg.VertexCompatAPIKey, auth)
 }
 
+// resolveVertexCredentialConfig finds the vertex entry an auth was synthesized from. These
+// entries carry no API key, so they are matched by project, location and credentials file.
+func resolveVertexCredentialConfig(cfg *internalconfig.Config, auth *Auth) *internalconfig.VertexCredential {
+	if cfg == nil || auth == nil || auth.Attributes == nil {
+		return nil
+	}
+	projectID := strings.TrimSpace(auth.Attributes["project_id"])
+	if projectID == "" {
+		return nil
+	}
+	location := strings.TrimSpace(auth.Attributes["location"])
+	credentialsFile := strings.TrimSpace(auth.Attributes["credentials_file"])
+	for i := range cfg.VertexCredentials {
+		entry := &cfg.VertexCredentials[i]
+		if strings.EqualFold(entry.ProjectID, projectID) && strings.EqualFold(entry.Location, location) && entry.CredentialsFile == credentialsFile {
+			return entry
+		}
+	}
+	return nil
+}
+
+func resolveAzureOpenAIKeyConfig(cfg *internalconfig.Config, auth *Auth) *internalconfig.AzureOpenAIKey {
+	if cfg == nil {
+		return nil
+	}
+	return resolveAPIKeyConfig(cfg.AzureOpenAIKey, auth)
+}
+
+func resolveBedrockKeyConfig(cfg *internalconfig.Config, auth *Auth) *internalconfig.BedrockKey {
+	if cfg == nil {
+		return nil
+	}
+	return resolveAPIKeyConfig(cfg.BedrockKey, auth)
+}
+
+func resolveOllamaKeyConfig(cfg *internalconfig.Config, auth *Auth) *internalconfig.OllamaKey {
+	if cfg == nil {
+		return nil
+	}
+	return resolveAPIKeyConfig(cfg.OllamaKey, auth)
+}
+
 func resolveUpstreamModelForGeminiAPIKey(cfg *internalconfig.Config, auth *Auth, requestedModel string) string {
 	entry := resolveGeminiAPIKeyConfig(cfg, auth)
 	if entry == nil {
@@ -1757,7 +1918,33 @@ func resolveUpstreamModelForCodexAPIKey(cfg *internalconfig.Config, auth *Auth,
 }
 
 func resolveUpstreamModelForVertexAPIKey(cfg *internalconfig.Config, auth *Auth, requestedModel string) string {
-	entry := resolveVertexAPIKeyConfig(cfg, auth)
+	if entry := resolveVertexAPIKeyConfig(cfg, auth); entry != nil {
+		return resolveModelAliasFromConfigModels(requestedModel, asModelAliasEntries(entry.Models))
+	}
+	if entry := resolveVertexCredentialConfig(cfg, auth); entry != nil {
+		return resolveModelAliasFromConfigModels(requestedModel, asModelAliasEntries(entry.Models))
+	}
+	return ""
+}
+
+func resolveUpstreamModelForAzureOpenAIKey(cfg *internalconfig.Config, auth *Auth, requestedModel string) string {
+	entry := resolveAzureOpenAIKeyConfig(cfg, auth)
+	if entry == nil {
+		return ""
+	}
+	return resolveModelAliasFromConfigModels(requestedModel, asModelAliasEntries(entry.Models))
+}
+
+func resolveUpstreamModelForBedrockKey(cfg *internalconfig.Config, auth *Auth, requestedModel string) string {
+	entry := resolveBedrockKeyConfig(cfg, auth)
+	if entry == nil {
+		return ""
+	}
+	return resolveModelAliasFromConfigModels(requestedModel, asModelAliasEntries(entry.Models))
+}
+
+func resolveUpstreamModelForOllamaKey(cfg *internalconfig.Config, auth *Auth, requestedModel string) string {
+	entry := resolveOllamaKeyConfig(cfg, auth)
 	if entry == nil {
 		return ""
 	}
@@ -2016,6 +2203,7 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 	suspendReason := ""
 	clearModelQuota := false
 	setModelQuota := false
+	quarantined := false
 	var authSnapshot *Auth
 
 	m.mu.Lock()
@@ -2038,6 +2226,8 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 			} else {
 				clearAuthStateOnSuccess(auth, now)
 			}
+		} else if m.quarantineIfSuspicious(ctx, auth, result.Error, now) {
+			quarantined = true
 		} else {
 			if result.Model != "" {
 				if !isRequestScopedNotFoundResultError(result.Error) {
@@ -2154,6 +2344,10 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 	} else if shouldSuspendModel {
 		registry.GetGlobalRegistry().SuspendClientModel(result.AuthID, result.Model, suspendReason)
 	}
+	if quarantined && authSnapshot != nil {
+		m.hook.OnAuthUpdated(ctx, authSnapshot.Clone())
+	}
+	m.recordBreakerResult(ctx, result)
 
 	m.hook.OnResult(ctx, result)
 }
@@ -2677,6 +2871,7 @@ func (m *Manager) pickNextLegacy(ctx context.Context, provider, model string, op
 		return nil, nil, &Error{Code: "executor_not_found", Message: "executor not registered"}
 	}
 	candidates := make([]*Auth, 0, len(m.auths))
+	breakerRejected := false
 	modelKey := strings.TrimSpace(model)
 	// Always use base model name (without thinking suffix) for auth matching.
 	if modelKey != "" {
@@ -2702,10 +2897,17 @@ func (m *Manager) pickNextLegacy(ctx context.Context, provider, model string, op
 		if modelKey != "" && !m.authSupportsRouteModel(registryRef, candidate, model) {
 			continue
 		}
+		if m.breakerRejects(candidate) {
+			breakerRejected = true
+			continue
+		}
 		candidates = append(candidates, candidate)
 	}
 	if len(candidates) == 0 {
 		m.mu.RUnlock()
+		if breakerRejected {
+			return nil, nil, newCircuitOpenError()
+		}
 		return nil, nil, &Error{Code: "auth_not_found", Message: "no auth available"}
 	}
 	available, errAvailable := m.availableAuthsForRouteModel(candidates, provider, model, time.Now())
@@ -2722,6 +2924,7 @@ func (m *Manager) pickNextLegacy(ctx context.Context, provider, model string, op
 		m.mu.RUnlock()
 		return nil, nil, &Error{Code: "auth_not_found", Message: "selector returned no auth"}
 	}
+	m.breakerAdmits(selected)
 	authCopy := selected.Clone()
 	m.mu.RUnlock()
 	if !selected.indexAssigned {
@@ -2760,6 +2963,7 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 		return nil, nil, &Error{Code: "executor_not_found", Message: "executor not registered"}
 	}
 	disallowFreeAuth := disallowFreeAuthFromMetadata(opts.Metadata)
+	breakerRejected := false
 	for {
 		selected, errPick := m.scheduler.pickSingle(ctx, provider, model, opts, tried)
 		if errPick != nil && model != "" && shouldRetrySchedulerPick(errPick) {
@@ -2767,6 +2971,9 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 			selected, errPick = m.scheduler.pickSingle(ctx, provider, model, opts, tried)
 		}
 		if errPick != nil {
+			if breakerRejected {
+				return nil, nil, newCircuitOpenError()
+			}
 			return nil, nil, errPick
 		}
 		if selected == nil {
@@ -2779,6 +2986,14 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 			tried[selected.ID] = struct{}{}
 			continue
 		}
+		if !m.breakerAdmits(selected) {
+			if tried == nil {
+				tried = make(map[string]struct{})
+			}
+			tried[selected.ID] = struct{}{}
+			breakerRejected = true
+			continue
+		}
 		authCopy := selected.Clone()
 		if !selected.indexAssigned {
 			m.mu.Lock()
@@ -2810,6 +3025,7 @@ func (m *Manager) pickNextMixedLegacy(ctx context.Context, providers []string, m
 
 	m.mu.RLock()
 	candidates := make([]*Auth, 0, len(m.auths))
+	breakerRejected := false
 	modelKey := strings.TrimSpace(model)
 	// Always use base model name (without thinking suffix) for auth matching.
 	if modelKey != "" {
@@ -2845,10 +3061,17 @@ func (m *Manager) pickNextMixedLegacy(ctx context.Context, providers []string, m
 		if modelKey != "" && !m.authSupportsRouteModel(registryRef, candidate, model) {
 			continue
 		}
+		if m.breakerRejects(candidate) {
+			breakerRejected = true
+			continue
+		}
 		candidates = append(candidates, candidate)
 	}
 	if len(candidates) == 0 {
 		m.mu.RUnlock()
+		if breakerRejected {
+			return nil, nil, "", newCircuitOpenError()
+		}
 		return nil, nil, "", &Error{Code: "auth_not_found", Message: "no auth available"}
 	}
 	available, errAvailable := m.availableAuthsForRouteModel(candidates, "mixed", model, time.Now())
@@ -2871,6 +3094,7 @@ func (m *Manager) pickNextMixedLegacy(ctx context.Context, providers []string, m
 		m.mu.RUnlock()
 		return nil, nil, "", &Error{Code: "executor_not_found", Message: "executor not registered"}
 	}
+	m.breakerAdmits(selected)
 	authCopy := selected.Clone()
 	m.mu.RUnlock()
 	if !selected.indexAssigned {
@@ -2933,6 +3157,7 @@ func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model s
 	}
 
 	disallowFreeAuth := disallowFreeAuthFromMetadata(opts.Metadata)
+	breakerRejected := false
 	for {
 		selected, providerKey, errPick := m.scheduler.pickMixed(ctx, eligibleProviders, model, opts, tried)
 		if errPick != nil && model != "" && shouldRetrySchedulerPick(errPick) {
@@ -2940,6 +3165,9 @@ func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model s
 			selected, providerKey, errPick = m.scheduler.pickMixed(ctx, eligibleProviders, model, opts, tried)
 		}
 		if errPick != nil {
+			if breakerRejected {
+				return nil, nil, "", newCircuitOpenError()
+			}
 			return nil, nil, "", errPick
 		}
 		if selected == nil {
@@ -2952,6 +3180,14 @@ func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model s
 			tried[selected.ID] = struct{}{}
 			continue
 		}
+		if !m.breakerAdmits(selected) {
+			if tried == nil {
+				tried = make(map[string]struct{})
+			}
+			tried[selected.ID] = struct{}{}
+			breakerRejected = true
+			continue
+		}
 		executor, okExecutor := m.Executor(providerKey)
 		if !okExecutor {
 			return nil, nil, "", &Error{Code: "executor_not_found", Message: "executor not registered"}
@@ -3096,7 +3332,12 @@ func (m *Manager) tryAntigravityCreditsExecute(ctx context.Context, req cliproxy
 			resultModel := m.stateModelForExecution(c.auth, routeModel, upstreamModel, len(models) > 1)
 			execReq := req
 			execReq.Model = upstreamModel
-			resp, errExec := c.executor.Execute(creditsCtx, c.auth, execReq, creditsOpts)
+			release, errSlot := m.acquireSlot(creditsCtx, c.auth)
+			if errSlot != nil {
+				break
+			}
+			resp, errExec := executeAdapted(creditsCtx, c.executor, c.auth, execReq, creditsOpts, m.forcesStream(c.auth))
+			release()
 			result := Result{AuthID: c.auth.ID, Provider: c.provider, Model: resultModel, Success: errExec == nil}
 			if errExec != nil {
 				result.Error = &Error{Message: errExec.Error()}
@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// QuarantineReasonMetadataKey stores the matched detection pattern of a quarantined auth.
+	QuarantineReasonMetadataKey = "quarantine_reason"
+	// QuarantinedAtMetadataKey stores when the auth was quarantined (RFC3339).
+	QuarantinedAtMetadataKey = "quarantined_at"
+)
+
+// defaultQuarantinePatterns apply when credential-quarantine is enabled without patterns.
+var defaultQuarantinePatterns = []internalconfig.QuarantinePattern{
+	{Match: `account[_ ]deactivated|account (has been |was )?(banned|suspended|disabled|terminated)|organization (has been )?disabled`},
+	{Match: `unusual activity|violat(es|ed|ion of) .*(terms|usage polic)`},
+	{StatusCodes: []int{403}, Match: `captcha|verify (that )?you are (a )?human`},
+}
+
+// quarantineRegexCache caches compiled quarantine expressions keyed by source.
+var quarantineRegexCache sync.Map
+
+func quarantineRegex(source string) *regexp.Regexp {
+	if cached, ok := quarantineRegexCache.Load(source); ok {
+		re, _ := cached.(*regexp.Regexp)
+		return re
+	}
+	re, errCompile := regexp.Compile("(?i)" + source)
+	if errCompile != nil {
+		re = nil
+	}
+	quarantineRegexCache.Store(source, re)
+	return re
+}
+
+// matchQuarantinePattern returns the pattern matching a failure from provider, if any.
+func matchQuarantinePattern(cfg internalconfig.CredentialQuarantineConfig, provider string, err *Error) (internalconfig.QuarantinePattern, bool) {
+	if !cfg.Enable || err == nil || strings.TrimSpace(err.Message) == "" {
+		return internalconfig.QuarantinePattern{}, false
+	}
+	status := statusCodeFromResult(err)
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	patterns := cfg.Patterns
+	if len(patterns) == 0 {
+		patterns = defaultQuarantinePatterns
+	}
+	for _, pattern := range patterns {
+		if pattern.Provider != "" && !strings.EqualFold(pattern.Provider, provider) {
+			continue
+		}
+		if !quarantineStatusMatches(pattern.StatusCodes, status) {
+			continue
+		}
+		if re := quarantineRegex(pattern.Match); re != nil && re.MatchString(err.Message) {
+			return pattern, true
+		}
+	}
+	return internalconfig.QuarantinePattern{}, false
+}
+
+func quarantineStatusMatches(codes []int, status int) bool {
+	if len(codes) == 0 {
+		return status == 401 || status == 403
+	}
+	for _, code := range codes {
+		if code == status {
+			return true
+		}
+	}
+	return false
+}
+
+// quarantineAuth disables auth so the scheduler stops routing traffic to it and records why.
+func quarantineAuth(auth *Auth, pattern internalconfig.QuarantinePattern, err *Error, now time.Time) {
+	auth.Disabled = true
+	auth.Status = StatusDisabled
+	auth.StatusMessage = "quarantined: upstream error matched " + pattern.Match
+	auth.LastError = cloneError(err)
+	auth.UpdatedAt = now
+	if auth.Metadata == nil {
+		auth.Metadata = make(map[string]any)
+	}
+	auth.Metadata[QuarantineReasonMetadataKey] = pattern.Match
+	auth.Metadata[QuarantinedAtMetadataKey] = now.UTC().Format(time.RFC3339)
+}
+
+// IsQuarantined reports whether auth was disabled by credential quarantine.
+func (a *Auth) IsQuarantined() bool {
+	if a == nil || !a.Disabled || a.Metadata == nil {
+		return false
+	}
+	_, ok := a.Metadata[QuarantineReasonMetadataKey]
+	return ok
+}
+
+// ClearQuarantine removes the quarantine markers so a re-enabled auth is not reported as quarantined.
+func (a *Auth) ClearQuarantine() {
+	if a == nil || a.Metadata == nil {
+		return
+	}
+	delete(a.Metadata, QuarantineReasonMetadataKey)
+	delete(a.Metadata, QuarantinedAtMetadataKey)
+}
+
+// quarantineIfSuspicious quarantines auth when the failure matches a configured pattern.
+// Callers must hold m.mu.
+func (m *Manager) quarantineIfSuspicious(ctx context.Context, auth *Auth, err *Error, now time.Time) bool {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || auth == nil || auth.Disabled {
+		return false
+	}
+	pattern, ok := matchQuarantinePattern(cfg.CredentialQuarantine, auth.Provider, err)
+	if !ok {
+		return false
+	}
+	quarantineAuth(auth, pattern, err, now)
+	logEntryWithRequestID(ctx).WithFields(log.Fields{
+		"auth_id":  auth.ID,
+		"provider": auth.Provider,
+		"label":    auth.Label,
+		"status":   statusCodeFromResult(err),
+		"pattern":  pattern.Match,
+	}).Warn("credential quarantined: upstream error looks like a ban or account flag; re-enable it via the management API after review")
+	return true
+}
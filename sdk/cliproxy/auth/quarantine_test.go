@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestMatchQuarantinePattern(t *testing.T) {
+	t.Parallel()
+
+	cfg := internalconfig.CredentialQuarantineConfig{
+		Enable: true,
+		Patterns: []internalconfig.QuarantinePattern{
+			{Provider: "claude", StatusCodes: []int{403}, Match: "organization has been disabled"},
+			{Match: "captcha"},
+		},
+	}
+	cases := []struct {
+		name     string
+		provider string
+		err      *Error
+		want     bool
+	}{
+		{"provider match", "claude", &Error{HTTPStatus: 403, Message: `{"error":"This organization has been DISABLED."}`}, true},
+		{"other provider", "codex", &Error{HTTPStatus: 403, Message: "organization has been disabled"}, false},
+		{"status mismatch", "claude", &Error{HTTPStatus: 400, Message: "organization has been disabled"}, false},
+		{"default statuses", "gemini", &Error{HTTPStatus: 401, Message: "please solve the captcha"}, true},
+		{"default statuses exclude 429", "gemini", &Error{HTTPStatus: 429, Message: "captcha"}, false},
+		{"no message", "claude", &Error{HTTPStatus: 403}, false},
+	}
+	for _, tc := range cases {
+		if _, got := matchQuarantinePattern(cfg, tc.provider, tc.err); got != tc.want {
+			t.Errorf("%s: matched = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	cfg.Enable = false
+	if _, got := matchQuarantinePattern(cfg, "gemini", &Error{HTTPStatus: 403, Message: "captcha"}); got {
+		t.Fatal("disabled quarantine matched")
+	}
+}
+
+func TestMatchQuarantinePatternDefaults(t *testing.T) {
+	t.Parallel()
+
+	cfg := internalconfig.CredentialQuarantineConfig{Enable: true}
+	if _, ok := matchQuarantinePattern(cfg, "codex", &Error{HTTPStatus: 401, Message: `{"error":{"code":"account_deactivated"}}`}); !ok {
+		t.Fatal("expected default pattern to match account_deactivated")
+	}
+	if _, ok := matchQuarantinePattern(cfg, "codex", &Error{HTTPStatus: 403, Message: "forbidden"}); ok {
+		t.Fatal("plain 403 must not quarantine")
+	}
+}
+
+func TestMarkResultQuarantinesSuspiciousAuth(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	m.SetConfig(&internalconfig.Config{CredentialQuarantine: internalconfig.CredentialQuarantineConfig{Enable: true}})
+	if _, err := m.Register(context.Background(), &Auth{ID: "q1", Provider: "claude"}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	m.MarkResult(context.Background(), Result{
+		AuthID:   "q1",
+		Provider: "claude",
+		Model:    "claude-sonnet",
+		Error:    &Error{HTTPStatus: 403, Message: "Your account has been suspended for unusual activity"},
+	})
+
+	auth, ok := m.GetByID("q1")
+	if !ok {
+		t.Fatal("auth missing")
+	}
+	if !auth.Disabled || auth.Status != StatusDisabled || !auth.IsQuarantined() {
+		t.Fatalf("auth not quarantined: disabled=%v status=%s metadata=%v", auth.Disabled, auth.Status, auth.Metadata)
+	}
+	if len(auth.ModelStates) != 0 {
+		t.Fatalf("model states = %v, want none for quarantined auth", auth.ModelStates)
+	}
+
+	auth.ClearQuarantine()
+	if auth.IsQuarantined() {
+		t.Fatal("ClearQuarantine left quarantine markers")
+	}
+}
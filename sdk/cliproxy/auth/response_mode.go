@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/lifecycle"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// ResponseModeReporter is implemented by executors that support only one response mode.
+// The manager adapts requests in the other mode: non-streaming requests consume the stream
+// and assemble a single response, and streaming requests synthesize a stream from the full
+// response. Executors that do not implement it are assumed to support both modes.
+type ResponseModeReporter interface {
+	// SupportsStreaming reports whether ExecuteStream is implemented.
+	SupportsStreaming() bool
+	// SupportsNonStreaming reports whether Execute is implemented.
+	SupportsNonStreaming() bool
+}
+
+// ForceStreamAttribute marks an auth whose non-streaming requests must be served from an
+// upstream stream, like the providers listed in response-mode.force-stream.
+const ForceStreamAttribute = "force_stream"
+
+// ForceNonStreamAttribute marks an auth whose streaming requests must be served from a full
+// upstream response, like the providers listed in response-mode.force-non-stream.
+const ForceNonStreamAttribute = "force_non_stream"
+
+// streamSynthesis controls how a stream is synthesized from a full upstream response.
+type streamSynthesis struct {
+	// force sends streaming requests upstream as non-streaming even when the executor can stream.
+	force bool
+	// chunkSize is the maximum number of runes per synthesized text delta; <= 0 sends each
+	// text in one delta.
+	chunkSize int
+	// interval is the pause between synthesized chunks.
+	interval time.Duration
+}
+
+// forcesStream reports whether non-streaming requests for auth are sent upstream as streams.
+func (m *Manager) forcesStream(auth *Auth) bool {
+	if auth == nil {
+		return false
+	}
+	if strings.EqualFold(strings.TrimSpace(auth.Attributes[ForceStreamAttribute]), "true") {
+		return true
+	}
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil {
+		return false
+	}
+	return responseModeListMatches(cfg.ResponseMode.ForceStream, auth)
+}
+
+// streamSynthesis resolves the stream synthesis settings for auth.
+func (m *Manager) streamSynthesis(auth *Auth) streamSynthesis {
+	var synth streamSynthesis
+	if auth == nil {
+		return synth
+	}
+	synth.force = strings.EqualFold(strings.TrimSpace(auth.Attributes[ForceNonStreamAttribute]), "true")
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil {
+		return synth
+	}
+	synth.force = synth.force || responseModeListMatches(cfg.ResponseMode.ForceNonStream, auth)
+	synth.chunkSize = cfg.ResponseMode.StreamChunkSize
+	if cfg.ResponseMode.StreamChunkIntervalMS > 0 {
+		synth.interval = time.Duration(cfg.ResponseMode.StreamChunkIntervalMS) * time.Millisecond
+	}
+	return synth
+}
+
+// responseModeListMatches reports whether providers names auth's provider, provider key or
+// openai-compatibility name.
+func responseModeListMatches(providers []string, auth *Auth) bool {
+	if len(providers) == 0 {
+		return false
+	}
+	names := []string{auth.Provider, auth.Attributes["provider_key"], auth.Attributes["compat_name"]}
+	for _, provider := range providers {
+		provider = strings.TrimSpace(provider)
+		if provider == "" {
+			continue
+		}
+		for _, name := range names {
+			if strings.EqualFold(provider, strings.TrimSpace(name)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// executeAdapted runs a non-streaming request, assembling it from a stream when the
+// executor can only stream or forceStream is set.
+func executeAdapted(ctx context.Context, executor ProviderExecutor, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, forceStream bool) (cliproxyexecutor.Response, error) {
+	reporter, ok := executor.(ResponseModeReporter)
+	streamOnly := ok && reporter.SupportsStreaming() && !reporter.SupportsNonStreaming()
+	canStream := !ok || reporter.SupportsStreaming()
+	if !streamOnly && !(forceStream && canStream) {
+		return executor.Execute(ctx, auth, req, opts)
+	}
+	streamOpts := opts
+	streamOpts.Stream = true
+	result, errStream := executor.ExecuteStream(ctx, auth, req, streamOpts)
+	if errStream != nil {
+		return cliproxyexecutor.Response{}, errStream
+	}
+	if result == nil {
+		return cliproxyexecutor.Response{}, &Error{Code: "empty_stream", Message: "executor returned no stream"}
+	}
+	var chunks [][]byte
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			discardStreamChunks(result.Chunks)
+			return cliproxyexecutor.Response{}, chunk.Err
+		}
+		chunks = append(chunks, chunk.Payload)
+	}
+	return cliproxyexecutor.Response{
+		Payload: sdktranslator.AssembleStream(opts.SourceFormat, chunks),
+		Headers: result.Headers,
+	}, nil
+}
+
+// executeStreamAdapted runs a streaming request, synthesizing the stream from a full
+// response when the executor cannot stream or synth.force is set.
+func executeStreamAdapted(ctx context.Context, executor ProviderExecutor, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, synth streamSynthesis) (*cliproxyexecutor.StreamResult, error) {
+	reporter, ok := executor.(ResponseModeReporter)
+	nonStreamOnly := ok && !reporter.SupportsStreaming() && reporter.SupportsNonStreaming()
+	canNonStream := !ok || reporter.SupportsNonStreaming()
+	if !nonStreamOnly && !(synth.force && canNonStream) {
+		return executor.ExecuteStream(ctx, auth, req, opts)
+	}
+	nonStreamOpts := opts
+	nonStreamOpts.Stream = false
+	resp, errExec := executor.Execute(ctx, auth, req, nonStreamOpts)
+	if errExec != nil {
+		return nil, errExec
+	}
+	payloads := sdktranslator.SynthesizeStreamChunked(opts.SourceFormat, resp.Payload, synth.chunkSize)
+	if synth.interval <= 0 || len(payloads) < 2 {
+		chunks := make(chan cliproxyexecutor.StreamChunk, len(payloads))
+		for _, payload := range payloads {
+			chunks <- cliproxyexecutor.StreamChunk{Payload: payload}
+		}
+		close(chunks)
+		return &cliproxyexecutor.StreamResult{Headers: resp.Headers, Chunks: chunks}, nil
+	}
+	chunks := make(chan cliproxyexecutor.StreamChunk)
+	lifecycle.Go(ctx, "auth.stream.synthesize", func() {
+		defer close(chunks)
+		timer := time.NewTimer(0)
+		defer timer.Stop()
+		for idx, payload := range payloads {
+			if idx > 0 {
+				timer.Reset(synth.interval)
+				select {
+				case <-ctx.Done():
+					return
+				case <-timer.C:
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case chunks <- cliproxyexecutor.StreamChunk{Payload: payload}:
+			}
+		}
+	})
+	return &cliproxyexecutor.StreamResult{Headers: resp.Headers, Chunks: chunks}, nil
+}
@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/lifecycle/lifecycletest"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+// singleModeExecutor implements only one response mode and fails the other.
+type singleModeExecutor struct {
+	streaming bool
+}
+
+func (e *singleModeExecutor) Identifier() string         { return "single-mode" }
+func (e *singleModeExecutor) SupportsStreaming() bool    { return e.streaming }
+func (e *singleModeExecutor) SupportsNonStreaming() bool { return !e.streaming }
+
+func (e *singleModeExecutor) Execute(_ context.Context, _ *Auth, _ cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	if e.streaming || opts.Stream {
+		return cliproxyexecutor.Response{}, &Error{Message: "non-streaming not supported", HTTPStatus: http.StatusBadRequest}
+	}
+	return cliproxyexecutor.Response{Payload: []byte(`{"id":"c1","object":"chat.completion","created":1,"model":"m","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`)}, nil
+}
+
+func (e *singleModeExecutor) ExecuteStream(_ context.Context, _ *Auth, _ cliproxyexecutor.Request, opts cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	if !e.streaming || !opts.Stream {
+		return nil, &Error{Message: "streaming not supported", HTTPStatus: http.StatusBadRequest}
+	}
+	ch := make(chan cliproxyexecutor.StreamChunk, 2)
+	ch <- cliproxyexecutor.StreamChunk{Payload: []byte(`{"id":"c1","created":1,"model":"m","choices":[{"index":0,"delta":{"role":"assistant","content":"h"}}]}`)}
+	ch <- cliproxyexecutor.StreamChunk{Payload: []byte(`{"id":"c1","choices":[{"index":0,"delta":{"content":"i"},"finish_reason":"stop"}]}`)}
+	close(ch)
+	return &cliproxyexecutor.StreamResult{Chunks: ch}, nil
+}
+
+func (e *singleModeExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) { return auth, nil }
+
+func (e *singleModeExecutor) CountTokens(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+func (e *singleModeExecutor) HttpRequest(context.Context, *Auth, *http.Request) (*http.Response, error) {
+	return nil, nil
+}
+
+func TestExecuteAdaptedAssemblesStreamOnlyExecutor(t *testing.T) {
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FormatOpenAI}
+	resp, err := executeAdapted(context.Background(), &singleModeExecutor{streaming: true}, &Auth{ID: "a"}, cliproxyexecutor.Request{}, opts, false)
+	if err != nil {
+		t.Fatalf("executeAdapted: %v", err)
+	}
+	if got := gjson.GetBytes(resp.Payload, "choices.0.message.content").String(); got != "hi" {
+		t.Fatalf("assembled content = %q, payload %s", got, resp.Payload)
+	}
+}
+
+// dualModeExecutor reports both response modes but only serves streams, so a request that
+// reaches Execute fails.
+type dualModeExecutor struct {
+	singleModeExecutor
+}
+
+func (e *dualModeExecutor) SupportsStreaming() bool    { return true }
+func (e *dualModeExecutor) SupportsNonStreaming() bool { return true }
+
+func TestExecuteAdaptedForceStream(t *testing.T) {
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FormatOpenAI}
+	executor := &dualModeExecutor{singleModeExecutor{streaming: true}}
+	if _, err := executeAdapted(context.Background(), executor, &Auth{ID: "a"}, cliproxyexecutor.Request{}, opts, false); err == nil {
+		t.Fatal("expected the non-streaming path without forceStream")
+	}
+	resp, err := executeAdapted(context.Background(), executor, &Auth{ID: "a"}, cliproxyexecutor.Request{}, opts, true)
+	if err != nil {
+		t.Fatalf("executeAdapted: %v", err)
+	}
+	if got := gjson.GetBytes(resp.Payload, "choices.0.message.content").String(); got != "hi" {
+		t.Fatalf("assembled content = %q, payload %s", got, resp.Payload)
+	}
+}
+
+func TestManagerForcesStream(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	m.SetConfig(&internalconfig.Config{ResponseMode: internalconfig.ResponseModeConfig{ForceStream: []string{"Claude", "pacore"}}})
+	cases := []struct {
+		name string
+		auth *Auth
+		want bool
+	}{
+		{"provider", &Auth{Provider: "claude"}, true},
+		{"compat name", &Auth{Provider: "openai-compatibility", Attributes: map[string]string{"compat_name": "PaCoRe"}}, true},
+		{"attribute", &Auth{Provider: "codex", Attributes: map[string]string{ForceStreamAttribute: "true"}}, true},
+		{"unlisted", &Auth{Provider: "codex"}, false},
+	}
+	for _, tc := range cases {
+		if got := m.forcesStream(tc.auth); got != tc.want {
+			t.Errorf("%s: forcesStream = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestExecuteStreamAdaptedSynthesizesForNonStreamingExecutor(t *testing.T) {
+	opts := cliproxyexecutor.Options{Stream: true, SourceFormat: sdktranslator.FormatOpenAI}
+	result, err := executeStreamAdapted(context.Background(), &singleModeExecutor{}, &Auth{ID: "a"}, cliproxyexecutor.Request{}, opts, streamSynthesis{})
+	if err != nil {
+		t.Fatalf("executeStreamAdapted: %v", err)
+	}
+	var chunks [][]byte
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("chunk error: %v", chunk.Err)
+		}
+		chunks = append(chunks, chunk.Payload)
+	}
+	if len(chunks) != 1 {
+		t.Fatalf("chunk count = %d, want 1", len(chunks))
+	}
+	if got := gjson.GetBytes(chunks[0], "choices.0.delta.content").String(); got != "hi" {
+		t.Fatalf("synthesized delta = %q, chunk %s", got, chunks[0])
+	}
+	if got := gjson.GetBytes(chunks[0], "object").String(); got != "chat.completion.chunk" {
+		t.Fatalf("object = %q", got)
+	}
+}
+
+// nonStreamServingExecutor reports both response modes but only serves full responses.
+type nonStreamServingExecutor struct {
+	singleModeExecutor
+}
+
+func (e *nonStreamServingExecutor) SupportsStreaming() bool    { return true }
+func (e *nonStreamServingExecutor) SupportsNonStreaming() bool { return true }
+
+func TestExecuteStreamAdaptedForceNonStreamChunks(t *testing.T) {
+	lifecycletest.VerifyNone(t)
+	opts := cliproxyexecutor.Options{Stream: true, SourceFormat: sdktranslator.FormatOpenAI}
+	executor := &nonStreamServingExecutor{}
+	if _, err := executeStreamAdapted(context.Background(), executor, &Auth{ID: "a"}, cliproxyexecutor.Request{}, opts, streamSynthesis{}); err == nil {
+		t.Fatal("expected the streaming path without force")
+	}
+	synth := streamSynthesis{force: true, chunkSize: 1, interval: time.Millisecond}
+	result, err := executeStreamAdapted(context.Background(), executor, &Auth{ID: "a"}, cliproxyexecutor.Request{}, opts, synth)
+	if err != nil {
+		t.Fatalf("executeStreamAdapted: %v", err)
+	}
+	var content string
+	var chunks int
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("chunk error: %v", chunk.Err)
+		}
+		chunks++
+		content += gjson.GetBytes(chunk.Payload, "choices.0.delta.content").String()
+	}
+	// One chunk per character plus the final chunk with the finish reason.
+	if chunks != 3 || content != "hi" {
+		t.Fatalf("chunks = %d content = %q, want 3 chunks of %q", chunks, content, "hi")
+	}
+}
+
+func TestManagerStreamSynthesis(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	m.SetConfig(&internalconfig.Config{ResponseMode: internalconfig.ResponseModeConfig{
+		ForceNonStream:        []string{"batchy"},
+		StreamChunkSize:       16,
+		StreamChunkIntervalMS: 20,
+	}})
+	got := m.streamSynthesis(&Auth{Provider: "openai-compatibility", Attributes: map[string]string{"compat_name": "Batchy"}})
+	want := streamSynthesis{force: true, chunkSize: 16, interval: 20 * time.Millisecond}
+	if got != want {
+		t.Fatalf("streamSynthesis = %+v, want %+v", got, want)
+	}
+	if got := m.streamSynthesis(&Auth{Provider: "codex", Attributes: map[string]string{ForceNonStreamAttribute: "true"}}); !got.force {
+		t.Fatal("expected the attribute to force non-streaming")
+	}
+	if got := m.streamSynthesis(&Auth{Provider: "codex"}); got.force {
+		t.Fatal("expected unlisted providers to keep streaming")
+	}
+}
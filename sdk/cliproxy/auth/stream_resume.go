@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+)
+
+// StreamResumeAttribute opts an auth into stream resumption, like the providers listed in
+// stream-resume: "retry" re-issues streams that fail before the first payload, "continue" also
+// resumes streams that break off after text was forwarded.
+const StreamResumeAttribute = "stream_resume"
+
+const defaultStreamResumeAttempts = 2
+
+// streamResume is the resolved stream resumption policy of an auth.
+type streamResume struct {
+	// retry re-issues a stream that fails transiently before its first payload.
+	retry bool
+	// continuation resumes a stream that fails transiently after text was forwarded.
+	continuation bool
+	// attempts bounds the re-issued requests per stream.
+	attempts int
+}
+
+// streamResume resolves the stream resumption policy for auth.
+func (m *Manager) streamResume(auth *Auth) streamResume {
+	var resume streamResume
+	if auth == nil {
+		return resume
+	}
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg != nil {
+		resume.continuation = responseModeListMatches(cfg.StreamResume.Continue, auth)
+		resume.retry = resume.continuation || responseModeListMatches(cfg.StreamResume.Retry, auth)
+		resume.attempts = cfg.StreamResume.MaxAttempts
+	}
+	switch strings.ToLower(strings.TrimSpace(auth.Attributes[StreamResumeAttribute])) {
+	case "continue":
+		resume.retry, resume.continuation = true, true
+	case "retry":
+		resume.retry = true
+	}
+	if resume.attempts <= 0 {
+		resume.attempts = defaultStreamResumeAttempts
+	}
+	return resume
+}
+
+// retries reports whether a stream that failed with err before its first payload is re-issued
+// after attempt previous re-issues.
+func (r streamResume) retries(ctx context.Context, attempt int, err error) bool {
+	return r.retry && attempt < r.attempts && ctx.Err() == nil && isTransientStreamError(err)
+}
+
+// isTransientStreamError reports whether err looks like a dropped connection, a timeout or an
+// upstream outage rather than a rejected request.
+func isTransientStreamError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	status := 0
+	if se, ok := errors.AsType[cliproxyexecutor.StatusError](err); ok && se != nil {
+		status = se.StatusCode()
+	}
+	return status == 0 || status == http.StatusRequestTimeout || status >= http.StatusInternalServerError
+}
+
+// streamResumer re-issues a stream that broke off mid-response with the text forwarded so far
+// as an assistant prefix.
+type streamResumer struct {
+	executor ProviderExecutor
+	auth     *Auth
+	req      cliproxyexecutor.Request
+	opts     cliproxyexecutor.Options
+	synth    streamSynthesis
+	// remaining is the number of re-issued requests left.
+	remaining int
+}
+
+// resumedStream is the continuation of an interrupted stream, read up to its first payload.
+type resumedStream struct {
+	buffered     []cliproxyexecutor.StreamChunk
+	chunks       <-chan cliproxyexecutor.StreamChunk
+	continuation *sdktranslator.StreamContinuation
+}
+
+// newStreamResumer returns the resumer of a stream for auth, or nil when resume does not
+// continue streams.
+func newStreamResumer(executor ProviderExecutor, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, synth streamSynthesis, resume streamResume) *streamResumer {
+	if !resume.continuation {
+		return nil
+	}
+	return &streamResumer{executor: executor, auth: auth, req: req, opts: opts, synth: synth, remaining: resume.attempts}
+}
+
+// resume continues the stream after forwarded failed with cause. It returns false when the
+// stream cannot be continued or the continuation failed before its first payload.
+func (r *streamResumer) resume(ctx context.Context, forwarded [][]byte, cause error) (*resumedStream, bool) {
+	if r == nil || r.remaining <= 0 || ctx.Err() != nil || !isTransientStreamError(cause) {
+		return nil, false
+	}
+	continuation, ok := sdktranslator.NewStreamContinuation(r.opts.SourceFormat, forwarded)
+	if !ok {
+		return nil, false
+	}
+	req := r.req
+	opts := r.opts
+	if req.Payload, ok = continuation.ContinueRequest(r.req.Payload); !ok {
+		return nil, false
+	}
+	if len(opts.OriginalRequest) > 0 {
+		if opts.OriginalRequest, ok = continuation.ContinueRequest(r.opts.OriginalRequest); !ok {
+			return nil, false
+		}
+	}
+	r.remaining--
+	entry := logEntryWithRequestID(ctx).WithFields(log.Fields{
+		"auth_id":   r.auth.ID,
+		"provider":  r.auth.Provider,
+		"forwarded": len(continuation.Text()),
+	})
+	entry.WithError(cause).Warn("upstream stream interrupted, resuming with forwarded text")
+
+	result, errStream := executeStreamAdapted(ctx, r.executor, r.auth, req, opts, r.synth)
+	if errStream != nil {
+		entry.WithError(errStream).Warn("stream resume failed")
+		return nil, false
+	}
+	buffered, closed, errBootstrap := readStreamBootstrap(ctx, result.Chunks)
+	if errBootstrap != nil || (closed && len(buffered) == 0) {
+		discardStreamChunks(result.Chunks)
+		if errBootstrap != nil {
+			entry = entry.WithError(errBootstrap)
+		}
+		entry.Warn("stream resume failed")
+		return nil, false
+	}
+	chunks := result.Chunks
+	if closed {
+		closedCh := make(chan cliproxyexecutor.StreamChunk)
+		close(closedCh)
+		chunks = closedCh
+	}
+	return &resumedStream{buffered: buffered, chunks: chunks, continuation: continuation}, true
+}
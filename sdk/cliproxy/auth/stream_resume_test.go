@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+// scriptedStreamExecutor serves one scripted stream per ExecuteStream call and records the
+// request payloads it received. Its embedded executor must report streaming support.
+type scriptedStreamExecutor struct {
+	singleModeExecutor
+	mu       sync.Mutex
+	streams  [][]cliproxyexecutor.StreamChunk
+	payloads [][]byte
+}
+
+func (e *scriptedStreamExecutor) ExecuteStream(_ context.Context, _ *Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.payloads = append(e.payloads, req.Payload)
+	if len(e.streams) == 0 {
+		return nil, &Error{Message: "no more streams", HTTPStatus: http.StatusBadRequest}
+	}
+	script := e.streams[0]
+	e.streams = e.streams[1:]
+	ch := make(chan cliproxyexecutor.StreamChunk, len(script))
+	for _, chunk := range script {
+		ch <- chunk
+	}
+	close(ch)
+	return &cliproxyexecutor.StreamResult{Chunks: ch}, nil
+}
+
+func openAIDelta(content string) cliproxyexecutor.StreamChunk {
+	return cliproxyexecutor.StreamChunk{Payload: []byte(`{"id":"c1","choices":[{"index":0,"delta":{"content":"` + content + `"}}]}`)}
+}
+
+func collectStream(t *testing.T, result *cliproxyexecutor.StreamResult) ([][]byte, error) {
+	t.Helper()
+	var payloads [][]byte
+	var errStream error
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			errStream = chunk.Err
+			continue
+		}
+		payloads = append(payloads, chunk.Payload)
+	}
+	return payloads, errStream
+}
+
+func TestStreamResumeRetriesBeforeFirstPayload(t *testing.T) {
+	reset := errors.New("connection reset by peer")
+	executor := &scriptedStreamExecutor{singleModeExecutor: singleModeExecutor{streaming: true}, streams: [][]cliproxyexecutor.StreamChunk{
+		{{Err: reset}},
+		{openAIDelta("hi")},
+	}}
+	auth := &Auth{ID: "resume-retry", Provider: "codex", Attributes: map[string]string{StreamResumeAttribute: "retry"}}
+	m := NewManager(nil, nil, nil)
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FormatOpenAI}
+
+	result, err := m.executeStreamWithModelPool(context.Background(), executor, auth, "codex", cliproxyexecutor.Request{}, opts, "m", []string{"m"}, false)
+	if err != nil {
+		t.Fatalf("executeStreamWithModelPool: %v", err)
+	}
+	payloads, errStream := collectStream(t, result)
+	if errStream != nil || len(payloads) != 1 {
+		t.Fatalf("payloads = %d, err = %v", len(payloads), errStream)
+	}
+	if len(executor.payloads) != 2 {
+		t.Fatalf("upstream calls = %d, want 2", len(executor.payloads))
+	}
+
+	executor = &scriptedStreamExecutor{singleModeExecutor: singleModeExecutor{streaming: true}, streams: [][]cliproxyexecutor.StreamChunk{{{Err: reset}}, {openAIDelta("hi")}}}
+	if _, err = m.executeStreamWithModelPool(context.Background(), executor, &Auth{ID: "no-resume", Provider: "codex"}, "codex", cliproxyexecutor.Request{}, opts, "m", []string{"m"}, false); err == nil {
+		t.Fatal("expected the error without stream resume")
+	}
+}
+
+func TestStreamResumeContinuesWithForwardedText(t *testing.T) {
+	executor := &scriptedStreamExecutor{singleModeExecutor: singleModeExecutor{streaming: true}, streams: [][]cliproxyexecutor.StreamChunk{
+		{openAIDelta("Hel"), openAIDelta("lo "), {Err: errors.New("unexpected EOF")}},
+		{
+			{Payload: []byte(`{"id":"c2","choices":[{"index":0,"delta":{"role":"assistant","content":""}}]}`)},
+			openAIDelta(" world"),
+			{Payload: []byte(`{"id":"c2","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`)},
+		},
+	}}
+	m := NewManager(nil, nil, nil)
+	m.SetConfig(&internalconfig.Config{StreamResume: internalconfig.StreamResumeConfig{Continue: []string{"claude"}}})
+	auth := &Auth{ID: "resume-continue", Provider: "claude"}
+	req := cliproxyexecutor.Request{Payload: []byte(`{"model":"m","messages":[{"role":"user","content":"greet"}]}`)}
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FormatOpenAI}
+
+	result, err := m.executeStreamWithModelPool(context.Background(), executor, auth, "claude", req, opts, "m", []string{"m"}, false)
+	if err != nil {
+		t.Fatalf("executeStreamWithModelPool: %v", err)
+	}
+	payloads, errStream := collectStream(t, result)
+	if errStream != nil {
+		t.Fatalf("stream error: %v", errStream)
+	}
+	assembled := sdktranslator.AssembleStream(sdktranslator.FormatOpenAI, payloads)
+	if got := gjson.GetBytes(assembled, "choices.0.message.content").String(); got != "Hello world" {
+		t.Fatalf("content = %q, payload %s", got, assembled)
+	}
+	if got := gjson.GetBytes(assembled, "id").String(); got != "c1" {
+		t.Fatalf("id = %q, want the interrupted stream's id", got)
+	}
+	if len(executor.payloads) != 2 {
+		t.Fatalf("upstream calls = %d, want 2", len(executor.payloads))
+	}
+	prefix := gjson.GetBytes(executor.payloads[1], "messages.1")
+	if prefix.Get("role").String() != "assistant" || prefix.Get("content").String() != "Hello" {
+		t.Fatalf("continuation request = %s", executor.payloads[1])
+	}
+}
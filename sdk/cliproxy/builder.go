@@ -49,6 +49,9 @@ type Builder struct {
 
 	// serverOptions contains additional server configuration options.
 	serverOptions []api.ServerOption
+
+	// headless disables the HTTP API server.
+	headless bool
 }
 
 // Hooks allows callers to plug into service lifecycle stages.
@@ -145,6 +148,13 @@ func (b *Builder) WithServerOptions(opts ...api.ServerOption) *Builder {
 	return b
 }
 
+// WithoutHTTPServer runs the service without the HTTP API server. Auth loading, refresh and
+// config hot reload still run; requests are served in-process through Service.Client.
+func (b *Builder) WithoutHTTPServer() *Builder {
+	b.headless = true
+	return b
+}
+
 // WithLocalManagementPassword configures a password that is only accepted from localhost management requests.
 func (b *Builder) WithLocalManagementPassword(password string) *Builder {
 	if password == "" {
@@ -255,6 +265,7 @@ func (b *Builder) Build() (*Service, error) {
 		accessManager:  accessManager,
 		coreManager:    coreManager,
 		serverOptions:  append([]api.ServerOption(nil), b.serverOptions...),
+		headless:       b.headless,
 	}
 	return service, nil
 }
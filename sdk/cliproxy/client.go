@@ -0,0 +1,229 @@
+package cliproxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Client executes requests in-process through the same credential pool, routing and
+// translation pipeline used by the HTTP server. It is safe for concurrent use.
+type Client struct {
+	handler *handlers.BaseAPIHandler
+}
+
+// ClientRequest describes a single call made through a Client.
+type ClientRequest struct {
+	// Model is the requested model name, including any alias or thinking suffix.
+	Model string
+
+	// Payload is the request body in Format, e.g. an OpenAI Chat Completions request.
+	Payload []byte
+
+	// Format is the request/response schema: "openai" (default), "openai-response",
+	// "claude" or "gemini".
+	Format sdktranslator.Format
+
+	// Alt is the optional Gemini "alt" query value.
+	Alt string
+}
+
+// ClientResponse carries a complete response in the request's format.
+type ClientResponse struct {
+	Payload []byte
+	// Headers contains filtered upstream headers when passthrough-headers is enabled.
+	Headers http.Header
+}
+
+// ClientStreamChunk is a single streamed response chunk or a terminal error.
+type ClientStreamChunk struct {
+	Payload []byte
+	Err     error
+}
+
+// ClientError reports a failed request together with the HTTP status the server would return.
+type ClientError struct {
+	Status int
+	Err    error
+}
+
+// Error implements the error interface.
+func (e *ClientError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("cliproxy: request failed with status %d", e.Status)
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *ClientError) Unwrap() error { return e.Err }
+
+// StatusCode returns the HTTP status associated with the error.
+func (e *ClientError) StatusCode() int { return e.Status }
+
+// NewClient creates a Client that executes requests through manager. The manager must have
+// executors registered for the providers it serves; Service.Client wires this automatically.
+func NewClient(cfg *config.Config, manager *coreauth.Manager) *Client {
+	var sdkCfg *config.SDKConfig
+	if cfg != nil {
+		sdkCfg = &cfg.SDKConfig
+	} else {
+		sdkCfg = &config.SDKConfig{}
+	}
+	return &Client{handler: handlers.NewBaseAPIHandlers(sdkCfg, manager)}
+}
+
+// UpdateConfig applies a reloaded configuration to subsequent requests.
+func (c *Client) UpdateConfig(cfg *config.Config) {
+	if c == nil || cfg == nil {
+		return
+	}
+	c.handler.UpdateClients(&cfg.SDKConfig)
+}
+
+// Chat executes a non-streaming request and returns the complete response.
+func (c *Client) Chat(ctx context.Context, req ClientRequest) (*ClientResponse, error) {
+	handler, format, err := c.prepare(req)
+	if err != nil {
+		return nil, err
+	}
+	payload := setStreamFlag(format, req.Payload, false)
+	body, headers, errMsg := handler.ExecuteWithAuthManager(contextOrBackground(ctx), format.String(), req.Model, payload, req.Alt)
+	if errMsg != nil {
+		return nil, clientErrorFrom(errMsg)
+	}
+	return &ClientResponse{Payload: body, Headers: headers}, nil
+}
+
+// ChatStream executes a streaming request. Errors raised before the upstream stream opens are
+// returned directly; later failures arrive as the final chunk. The channel is closed when the
+// stream ends or ctx is cancelled.
+func (c *Client) ChatStream(ctx context.Context, req ClientRequest) (<-chan ClientStreamChunk, error) {
+	handler, format, err := c.prepare(req)
+	if err != nil {
+		return nil, err
+	}
+	ctx = contextOrBackground(ctx)
+	payload := setStreamFlag(format, req.Payload, true)
+	data, _, errs := handler.ExecuteStreamWithAuthManager(ctx, format.String(), req.Model, payload, req.Alt)
+	if data == nil {
+		if errMsg, ok := <-errs; ok && errMsg != nil {
+			return nil, clientErrorFrom(errMsg)
+		}
+		return nil, &ClientError{Status: http.StatusInternalServerError, Err: fmt.Errorf("cliproxy: stream unavailable")}
+	}
+
+	out := make(chan ClientStreamChunk)
+	go func() {
+		defer close(out)
+		for data != nil || errs != nil {
+			var chunk ClientStreamChunk
+			select {
+			case <-ctx.Done():
+				return
+			case payload, ok := <-data:
+				if !ok {
+					data = nil
+					continue
+				}
+				chunk.Payload = payload
+			case errMsg, ok := <-errs:
+				if !ok {
+					errs = nil
+					continue
+				}
+				if errMsg == nil {
+					continue
+				}
+				chunk.Err = clientErrorFrom(errMsg)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case out <- chunk:
+			}
+		}
+	}()
+	return out, nil
+}
+
+// CountTokens returns the provider's token count response for the request.
+func (c *Client) CountTokens(ctx context.Context, req ClientRequest) (*ClientResponse, error) {
+	handler, format, err := c.prepare(req)
+	if err != nil {
+		return nil, err
+	}
+	body, headers, errMsg := handler.ExecuteCountWithAuthManager(contextOrBackground(ctx), format.String(), req.Model, req.Payload, req.Alt)
+	if errMsg != nil {
+		return nil, clientErrorFrom(errMsg)
+	}
+	return &ClientResponse{Payload: body, Headers: headers}, nil
+}
+
+// Models lists the models currently available for the given format ("openai", "claude" or
+// "gemini"), in the same shape the HTTP model listing endpoints return.
+func (c *Client) Models(format sdktranslator.Format) []map[string]any {
+	if format == "" {
+		format = sdktranslator.FormatOpenAI
+	}
+	return registry.GetGlobalRegistry().GetAvailableModels(format.String())
+}
+
+func (c *Client) prepare(req ClientRequest) (*handlers.BaseAPIHandler, sdktranslator.Format, error) {
+	if c == nil {
+		return nil, "", fmt.Errorf("cliproxy: client is nil")
+	}
+	handler := c.handler
+	if handler == nil || handler.AuthManager == nil {
+		return nil, "", fmt.Errorf("cliproxy: client has no auth manager")
+	}
+	if strings.TrimSpace(req.Model) == "" {
+		return nil, "", &ClientError{Status: http.StatusBadRequest, Err: fmt.Errorf("cliproxy: model is required")}
+	}
+	format := req.Format
+	if format == "" {
+		format = sdktranslator.FormatOpenAI
+	}
+	return handler, format, nil
+}
+
+// setStreamFlag keeps the payload's "stream" field consistent with the call so
+// passthrough executors request the matching upstream mode.
+func setStreamFlag(format sdktranslator.Format, payload []byte, stream bool) []byte {
+	if format == sdktranslator.FormatGemini || len(payload) == 0 || !gjson.ValidBytes(payload) {
+		return payload
+	}
+	if gjson.GetBytes(payload, "stream").Bool() == stream {
+		return payload
+	}
+	updated, errSet := sjson.SetBytes(payload, "stream", stream)
+	if errSet != nil {
+		return payload
+	}
+	return updated
+}
+
+func clientErrorFrom(msg *interfaces.ErrorMessage) error {
+	status := msg.StatusCode
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	return &ClientError{Status: status, Err: msg.Error}
+}
+
+func contextOrBackground(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx
+}
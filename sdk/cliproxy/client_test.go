@@ -0,0 +1,104 @@
+package cliproxy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+type echoClientExecutor struct{}
+
+func (echoClientExecutor) Identifier() string { return "client-test" }
+
+func (echoClientExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{Payload: req.Payload}, nil
+}
+
+func (echoClientExecutor) ExecuteStream(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	ch := make(chan coreexecutor.StreamChunk, 3)
+	ch <- coreexecutor.StreamChunk{Payload: []byte("a")}
+	ch <- coreexecutor.StreamChunk{Payload: req.Payload}
+	ch <- coreexecutor.StreamChunk{Err: &coreauth.Error{Code: "upstream", Message: "boom", HTTPStatus: http.StatusBadGateway}}
+	close(ch)
+	return &coreexecutor.StreamResult{Chunks: ch}, nil
+}
+
+func (echoClientExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (echoClientExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{Payload: []byte(`{"input_tokens":3}`)}, nil
+}
+
+func (echoClientExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(echoClientExecutor{})
+	auth := &coreauth.Auth{ID: "client-auth", Provider: "client-test", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "client-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+	return NewClient(&config.Config{}, manager)
+}
+
+func TestClientChatAndCountTokens(t *testing.T) {
+	client := newTestClient(t)
+
+	resp, err := client.Chat(context.Background(), ClientRequest{Model: "client-model", Payload: []byte(`{"model":"client-model","stream":true}`)})
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if gjson.GetBytes(resp.Payload, "stream").Bool() {
+		t.Fatalf("non-streaming call should clear the stream flag: %s", resp.Payload)
+	}
+
+	count, err := client.CountTokens(context.Background(), ClientRequest{Model: "client-model", Format: "claude", Payload: []byte(`{}`)})
+	if err != nil || gjson.GetBytes(count.Payload, "input_tokens").Int() != 3 {
+		t.Fatalf("CountTokens = %v, %v", count, err)
+	}
+
+	_, err = client.Chat(context.Background(), ClientRequest{Model: "missing-model", Payload: []byte(`{}`)})
+	var clientErr *ClientError
+	if !errors.As(err, &clientErr) || clientErr.StatusCode() < http.StatusBadRequest {
+		t.Fatalf("expected ClientError for unknown model, got %v", err)
+	}
+}
+
+func TestClientChatStream(t *testing.T) {
+	client := newTestClient(t)
+
+	chunks, err := client.ChatStream(context.Background(), ClientRequest{Model: "client-model", Payload: []byte(`{"model":"client-model"}`)})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	var payloads []string
+	var streamErr error
+	for chunk := range chunks {
+		if chunk.Err != nil {
+			streamErr = chunk.Err
+			continue
+		}
+		payloads = append(payloads, string(chunk.Payload))
+	}
+	if len(payloads) != 2 || payloads[0] != "a" || !gjson.Get(payloads[1], "stream").Bool() {
+		t.Fatalf("unexpected payloads: %q", payloads)
+	}
+	var clientErr *ClientError
+	if !errors.As(streamErr, &clientErr) || clientErr.StatusCode() != http.StatusBadGateway {
+		t.Fatalf("expected 502 stream error, got %v", streamErr)
+	}
+}
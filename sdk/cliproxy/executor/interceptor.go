@@ -0,0 +1,161 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// InterceptTarget describes the credential an intercepted upstream call is made with.
+type InterceptTarget struct {
+	// Provider is the provider identifier of the credential (e.g. "claude").
+	Provider string
+	// AuthID identifies the credential.
+	AuthID string
+	// Attributes are the credential attributes, such as api_key and base_url.
+	Attributes map[string]string
+}
+
+// RequestInterceptor mutates an upstream request after translation, before it is sent. It may
+// change headers, replace the body or sign the request for a custom auth scheme.
+type RequestInterceptor interface {
+	InterceptRequest(ctx context.Context, target InterceptTarget, req *http.Request) error
+}
+
+// ResponseInterceptor inspects or mutates an upstream response after it is received, before
+// the executor translates it back. It may replace resp.Body to patch the payload.
+type ResponseInterceptor interface {
+	InterceptResponse(ctx context.Context, target InterceptTarget, resp *http.Response) error
+}
+
+// InterceptorFactory builds an interceptor from the options of an interceptors config entry.
+// The returned value implements RequestInterceptor, ResponseInterceptor or both. Factories run
+// whenever an executor builds an HTTP client, so they should be cheap.
+type InterceptorFactory func(options map[string]string) (any, error)
+
+var (
+	interceptorsMu       sync.RWMutex
+	interceptorFactories = map[string]InterceptorFactory{
+		"set-headers": newSetHeadersInterceptor,
+		"patch-json":  newPatchJSONInterceptor,
+	}
+)
+
+// RegisterInterceptor makes an interceptor available to the interceptors config under name,
+// replacing any interceptor registered under the same name.
+func RegisterInterceptor(name string, factory InterceptorFactory) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || factory == nil {
+		return
+	}
+	interceptorsMu.Lock()
+	interceptorFactories[name] = factory
+	interceptorsMu.Unlock()
+}
+
+// RegisteredInterceptors returns the names of the registered interceptors, sorted.
+func RegisteredInterceptors() []string {
+	interceptorsMu.RLock()
+	defer interceptorsMu.RUnlock()
+	names := make([]string, 0, len(interceptorFactories))
+	for name := range interceptorFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewInterceptor builds the interceptor registered under name.
+func NewInterceptor(name string, options map[string]string) (any, error) {
+	interceptorsMu.RLock()
+	factory := interceptorFactories[strings.ToLower(strings.TrimSpace(name))]
+	interceptorsMu.RUnlock()
+	if factory == nil {
+		return nil, fmt.Errorf("interceptor %q is not registered", name)
+	}
+	interceptor, errBuild := factory(options)
+	if errBuild != nil {
+		return nil, fmt.Errorf("interceptor %q: %w", name, errBuild)
+	}
+	_, isRequest := interceptor.(RequestInterceptor)
+	_, isResponse := interceptor.(ResponseInterceptor)
+	if !isRequest && !isResponse {
+		return nil, fmt.Errorf("interceptor %q: %T intercepts neither requests nor responses", name, interceptor)
+	}
+	return interceptor, nil
+}
+
+// setHeadersInterceptor sets upstream request headers. An empty value removes the header.
+type setHeadersInterceptor map[string]string
+
+func newSetHeadersInterceptor(options map[string]string) (any, error) {
+	if len(options) == 0 {
+		return nil, fmt.Errorf("no headers configured")
+	}
+	return setHeadersInterceptor(options), nil
+}
+
+func (h setHeadersInterceptor) InterceptRequest(_ context.Context, _ InterceptTarget, req *http.Request) error {
+	for name, value := range h {
+		if value == "" {
+			req.Header.Del(name)
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+	return nil
+}
+
+// patchJSONInterceptor writes raw JSON values into the JSON request body. Options map
+// gjson/sjson paths to raw JSON fragments; a fragment that is not valid JSON is written as a
+// string.
+type patchJSONInterceptor map[string]string
+
+func newPatchJSONInterceptor(options map[string]string) (any, error) {
+	if len(options) == 0 {
+		return nil, fmt.Errorf("no paths configured")
+	}
+	return patchJSONInterceptor(options), nil
+}
+
+func (p patchJSONInterceptor) InterceptRequest(_ context.Context, _ InterceptTarget, req *http.Request) error {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	body, errRead := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if errRead != nil {
+		return fmt.Errorf("patch-json: read request body: %w", errRead)
+	}
+	if gjson.ValidBytes(body) {
+		paths := make([]string, 0, len(p))
+		for path := range p {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			value := p[path]
+			var errSet error
+			if gjson.Valid(value) {
+				body, errSet = sjson.SetRawBytes(body, path, []byte(value))
+			} else {
+				body, errSet = sjson.SetBytes(body, path, value)
+			}
+			if errSet != nil {
+				return fmt.Errorf("patch-json: set %s: %w", path, errSet)
+			}
+		}
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+	return nil
+}
@@ -20,6 +20,8 @@ const (
 	SelectedAuthMetadataKey = "selected_auth_id"
 	// SelectedAuthCallbackMetadataKey carries an optional callback invoked with the selected auth ID.
 	SelectedAuthCallbackMetadataKey = "selected_auth_callback"
+	// ExcludedAuthsMetadataKey lists auth IDs ([]string) that must not be selected.
+	ExcludedAuthsMetadataKey = "excluded_auth_ids"
 	// ExecutionSessionMetadataKey identifies a long-lived downstream execution session.
 	ExecutionSessionMetadataKey = "execution_session_id"
 )
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/daemon"
 	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/redisqueue"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
@@ -24,6 +25,7 @@ import (
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -58,6 +60,12 @@ type Service struct {
 	// server is the HTTP API server instance.
 	server *api.Server
 
+	// headless skips creating and starting the HTTP API server.
+	headless bool
+
+	// client serves in-process requests; created lazily by Client and guarded by cfgMu.
+	client *Client
+
 	// pprofServer manages the optional pprof HTTP debug server.
 	pprofServer *pprofServer
 
@@ -92,6 +100,20 @@ type Service struct {
 	wsGateway *wsrelay.Manager
 }
 
+// Client returns an in-process client that executes requests through the service's
+// credential pool. Requests succeed once Run has loaded credentials and registered executors.
+func (s *Service) Client() *Client {
+	if s == nil {
+		return nil
+	}
+	s.cfgMu.Lock()
+	defer s.cfgMu.Unlock()
+	if s.client == nil {
+		s.client = NewClient(s.cfg, s.coreManager)
+	}
+	return s.client
+}
+
 // RegisterUsagePlugin registers a usage plugin on the global usage manager.
 // This allows external code to monitor API usage and token consumption.
 //
@@ -350,6 +372,104 @@ func (s *Service) applyRetryConfig(cfg *config.Config) {
 	s.coreManager.SetRetryConfig(cfg.RequestRetry, maxInterval, cfg.MaxRetryCredentials)
 }
 
+// buildMockModels converts the models of the mock-provider entry bound to a into registry models.
+func (s *Service) buildMockModels(a *coreauth.Auth) []*ModelInfo {
+	if s.cfg == nil || a == nil || a.Attributes == nil {
+		return nil
+	}
+	name := strings.TrimSpace(a.Attributes["mock_name"])
+	for i := range s.cfg.MockProvider {
+		entry := &s.cfg.MockProvider[i]
+		if strings.TrimSpace(entry.Name) != name {
+			continue
+		}
+		now := time.Now().Unix()
+		models := make([]*ModelInfo, 0, len(entry.Models))
+		for _, id := range entry.Models {
+			id = strings.TrimSpace(id)
+			if id == "" {
+				continue
+			}
+			models = append(models, &ModelInfo{
+				ID:          id,
+				Object:      "model",
+				Created:     now,
+				OwnedBy:     "mock",
+				Type:        "mock",
+				DisplayName: id,
+			})
+		}
+		return models
+	}
+	return nil
+}
+
+// loadTranslatorPlugins loads the translator plugins declared in cfg. Plugins are loaded once at
+// startup because Go plugins cannot be unloaded.
+func (s *Service) loadTranslatorPlugins(cfg *config.Config) error {
+	if cfg == nil {
+		return nil
+	}
+	for _, entry := range cfg.Translators.Plugins {
+		p := sdktranslator.Plugin{
+			Path:   strings.TrimSpace(entry.Path),
+			Format: sdktranslator.FromString(strings.TrimSpace(entry.Format)),
+		}
+		if err := sdktranslator.LoadPlugin(p); err != nil {
+			return fmt.Errorf("cliproxy: failed to load translator plugin: %w", err)
+		}
+		log.Infof("loaded translator plugin %s for format %s", p.Path, p.Format)
+	}
+	return nil
+}
+
+// applyTranslatorConfig replaces the runtime translator overrides with those declared in cfg.
+func (s *Service) applyTranslatorConfig(cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	disabled := make([]sdktranslator.Pair, 0, len(cfg.Translators.Disabled))
+	for _, pair := range cfg.Translators.Disabled {
+		disabled = append(disabled, sdktranslator.Pair{
+			From: sdktranslator.FromString(strings.TrimSpace(pair.From)),
+			To:   sdktranslator.FromString(strings.TrimSpace(pair.To)),
+		})
+	}
+	dialects := make([]sdktranslator.Dialect, 0, len(cfg.Translators.Dialects))
+	for _, dialect := range cfg.Translators.Dialects {
+		dialects = append(dialects, sdktranslator.Dialect{
+			From: sdktranslator.FromString(strings.TrimSpace(dialect.From)),
+			To:   sdktranslator.FromString(strings.TrimSpace(dialect.To)),
+			Base: sdktranslator.FromString(strings.TrimSpace(dialect.Base)),
+			Rewrite: sdktranslator.PayloadRewrite{
+				Rename: dialect.Rename,
+				Delete: dialect.Delete,
+				Set:    dialect.Set,
+			},
+		})
+	}
+	sdktranslator.SetOverrides(disabled, dialects)
+	for _, compat := range cfg.OpenAICompatibility {
+		name := strings.TrimSpace(compat.Translator)
+		if name == "" {
+			continue
+		}
+		format := sdktranslator.FromString(name)
+		if sdktranslator.IsPluginFormat(format) {
+			continue
+		}
+		if base := sdktranslator.BaseFormat(format); base != sdktranslator.FormatOpenAI && base != sdktranslator.FormatOpenAIResponse {
+			log.Warnf("openai-compatibility %s: translator %q is neither a translator plugin nor a dialect of openai, using the openai translator", compat.Name, name)
+		}
+	}
+
+	missingPair, ok := sdktranslator.ParseMissingPairMode(cfg.Translators.MissingPair)
+	if !ok {
+		log.Warnf("translators.missing-pair %q is not supported, forwarding requests without a translator unchanged", cfg.Translators.MissingPair)
+	}
+	sdktranslator.SetMissingPairMode(missingPair)
+}
+
 func openAICompatInfoFromAuth(a *coreauth.Auth) (providerKey string, compatName string, ok bool) {
 	if a == nil {
 		return "", "", false
@@ -425,6 +545,14 @@ func (s *Service) ensureExecutorsForAuthWithMode(a *coreauth.Auth, forceReplace
 		s.coreManager.RegisterExecutor(executor.NewClaudeExecutor(s.cfg))
 	case "kimi":
 		s.coreManager.RegisterExecutor(executor.NewKimiExecutor(s.cfg))
+	case "azure-openai":
+		s.coreManager.RegisterExecutor(executor.NewAzureOpenAIExecutor(s.cfg))
+	case "bedrock":
+		s.coreManager.RegisterExecutor(executor.NewBedrockExecutor(s.cfg))
+	case "ollama":
+		s.coreManager.RegisterExecutor(executor.NewOllamaExecutor(s.cfg))
+	case "mock":
+		s.coreManager.RegisterExecutor(executor.NewMockExecutor(s.cfg))
 	default:
 		providerKey := strings.ToLower(strings.TrimSpace(a.Provider))
 		if providerKey == "" {
@@ -495,6 +623,10 @@ func (s *Service) Run(ctx context.Context) error {
 	}
 
 	s.applyRetryConfig(s.cfg)
+	if err := s.loadTranslatorPlugins(s.cfg); err != nil {
+		return err
+	}
+	s.applyTranslatorConfig(s.cfg)
 
 	if s.coreManager != nil {
 		if errLoad := s.coreManager.Load(ctx); errLoad != nil {
@@ -521,7 +653,9 @@ func (s *Service) Run(ctx context.Context) error {
 	// legacy clients removed; no caches to refresh
 
 	// handlers no longer depend on legacy clients; pass nil slice initially
-	s.server = api.NewServer(s.cfg, s.coreManager, s.accessManager, s.configPath, s.serverOptions...)
+	if !s.headless {
+		s.server = api.NewServer(s.cfg, s.coreManager, s.accessManager, s.configPath, s.serverOptions...)
+	}
 
 	if s.authManager == nil {
 		s.authManager = newDefaultAuthManager()
@@ -590,17 +724,19 @@ func (s *Service) Run(ctx context.Context) error {
 		}
 	})
 
-	s.serverErr = make(chan error, 1)
-	go func() {
-		if errStart := s.server.Start(); errStart != nil {
-			s.serverErr <- errStart
-		} else {
-			s.serverErr <- nil
-		}
-	}()
+	if s.server != nil {
+		s.serverErr = make(chan error, 1)
+		go func() {
+			if errStart := s.server.Start(); errStart != nil {
+				s.serverErr <- errStart
+			} else {
+				s.serverErr <- nil
+			}
+		}()
 
-	time.Sleep(100 * time.Millisecond)
-	fmt.Printf("API server started successfully on: %s:%d\n", s.cfg.Host, s.cfg.Port)
+		time.Sleep(100 * time.Millisecond)
+		fmt.Printf("API server started successfully on: %s:%d\n", s.cfg.Host, s.cfg.Port)
+	}
 
 	s.applyPprofConfig(s.cfg)
 
@@ -675,13 +811,18 @@ func (s *Service) Run(ctx context.Context) error {
 		}
 
 		s.applyRetryConfig(newCfg)
+		s.applyTranslatorConfig(newCfg)
 		s.applyPprofConfig(newCfg)
 		if s.server != nil {
 			s.server.UpdateClients(newCfg)
 		}
 		s.cfgMu.Lock()
 		s.cfg = newCfg
+		client := s.client
 		s.cfgMu.Unlock()
+		if client != nil {
+			client.UpdateConfig(newCfg)
+		}
 		if s.coreManager != nil {
 			s.coreManager.SetConfig(newCfg)
 			s.coreManager.SetOAuthModelAlias(newCfg.OAuthModelAlias)
@@ -714,6 +855,25 @@ func (s *Service) Run(ctx context.Context) error {
 		log.Infof("core auth auto-refresh started (interval=%s)", interval)
 	}
 
+	// Readiness is reported only once the listener is accepting connections so that
+	// dependent units are not started against a server that is still coming up.
+	if s.server != nil {
+		select {
+		case <-ctx.Done():
+			log.Debug("service context cancelled, shutting down...")
+			return ctx.Err()
+		case err = <-s.serverErr:
+			return err
+		case <-s.server.Ready():
+		}
+	}
+	if _, errNotify := daemon.Notify(daemon.NotifyReady); errNotify != nil {
+		log.Warnf("failed to notify service manager readiness: %v", errNotify)
+	}
+	if s.server != nil {
+		daemon.StartWatchdog(ctx, s.server.Healthy)
+	}
+
 	select {
 	case <-ctx.Done():
 		log.Debug("service context cancelled, shutting down...")
@@ -742,6 +902,10 @@ func (s *Service) Shutdown(ctx context.Context) error {
 			ctx = context.Background()
 		}
 
+		if _, errNotify := daemon.Notify(daemon.NotifyStopping); errNotify != nil {
+			log.Debugf("failed to notify service manager shutdown: %v", errNotify)
+		}
+
 		// legacy refresh loop removed; only stopping core auth manager below
 
 		if s.watcherCancel != nil {
@@ -877,6 +1041,9 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 			if authKind == "apikey" {
 				excluded = entry.ExcludedModels
 			}
+		} else if entry := s.resolveConfigVertexCredential(a); entry != nil && len(entry.Models) > 0 {
+			// Claude models on Vertex are only served when listed here.
+			models = buildConfigModels(entry.Models, "google", "vertex")
 		}
 		models = applyExcludedModels(models, excluded)
 	case "gemini-cli":
@@ -928,6 +1095,17 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 	case "kimi":
 		models = registry.GetKimiModels()
 		models = applyExcludedModels(models, excluded)
+	case "azure-openai":
+		// Azure OpenAI serves only the deployments declared in config.
+		models = buildAzureOpenAIConfigModels(s.resolveConfigAzureOpenAIKey(a))
+	case "bedrock":
+		// Bedrock serves only the models declared in config.
+		models = buildBedrockConfigModels(s.resolveConfigBedrockKey(a))
+	case "ollama":
+		// Ollama serves only the models declared in config.
+		models = buildOllamaConfigModels(s.resolveConfigOllamaKey(a))
+	case "mock":
+		models = s.buildMockModels(a)
 	default:
 		// Handle OpenAI-compatibility providers by name using config
 		if s.cfg != nil {
@@ -1205,6 +1383,79 @@ func (s *Service) resolveConfigCodexKey(auth *coreauth.Auth) *config.CodexKey {
 	return nil
 }
 
+func (s *Service) resolveConfigVertexCredential(auth *coreauth.Auth) *config.VertexCredential {
+	if auth == nil || auth.Attributes == nil || s.cfg == nil {
+		return nil
+	}
+	projectID := strings.TrimSpace(auth.Attributes["project_id"])
+	if projectID == "" {
+		return nil
+	}
+	location := strings.TrimSpace(auth.Attributes["location"])
+	credentialsFile := strings.TrimSpace(auth.Attributes["credentials_file"])
+	for i := range s.cfg.VertexCredentials {
+		entry := &s.cfg.VertexCredentials[i]
+		if strings.EqualFold(entry.ProjectID, projectID) && strings.EqualFold(entry.Location, location) && entry.CredentialsFile == credentialsFile {
+			return entry
+		}
+	}
+	return nil
+}
+
+func (s *Service) resolveConfigAzureOpenAIKey(auth *coreauth.Auth) *config.AzureOpenAIKey {
+	if auth == nil || s.cfg == nil {
+		return nil
+	}
+	var attrKey, attrBase string
+	if auth.Attributes != nil {
+		attrKey = strings.TrimSpace(auth.Attributes["api_key"])
+		attrBase = strings.TrimSpace(auth.Attributes["base_url"])
+	}
+	for i := range s.cfg.AzureOpenAIKey {
+		entry := &s.cfg.AzureOpenAIKey[i]
+		if strings.EqualFold(strings.TrimSpace(entry.APIKey), attrKey) && strings.EqualFold(strings.TrimSpace(entry.BaseURL), attrBase) {
+			return entry
+		}
+	}
+	return nil
+}
+
+func (s *Service) resolveConfigBedrockKey(auth *coreauth.Auth) *config.BedrockKey {
+	if auth == nil || s.cfg == nil {
+		return nil
+	}
+	var attrKey, attrRegion string
+	if auth.Attributes != nil {
+		attrKey = strings.TrimSpace(auth.Attributes["api_key"])
+		attrRegion = strings.TrimSpace(auth.Attributes["region"])
+	}
+	for i := range s.cfg.BedrockKey {
+		entry := &s.cfg.BedrockKey[i]
+		if strings.TrimSpace(entry.GetAPIKey()) == attrKey && strings.EqualFold(strings.TrimSpace(entry.Region), attrRegion) {
+			return entry
+		}
+	}
+	return nil
+}
+
+func (s *Service) resolveConfigOllamaKey(auth *coreauth.Auth) *config.OllamaKey {
+	if auth == nil || s.cfg == nil {
+		return nil
+	}
+	var attrKey, attrBase string
+	if auth.Attributes != nil {
+		attrKey = strings.TrimSpace(auth.Attributes["api_key"])
+		attrBase = strings.TrimSpace(auth.Attributes["base_url"])
+	}
+	for i := range s.cfg.OllamaKey {
+		entry := &s.cfg.OllamaKey[i]
+		if strings.TrimSpace(entry.APIKey) == attrKey && strings.EqualFold(strings.TrimSpace(entry.BaseURL), attrBase) {
+			return entry
+		}
+	}
+	return nil
+}
+
 func (s *Service) oauthExcludedModels(provider, authKind string) []string {
 	cfg := s.cfg
 	if cfg == nil {
@@ -1410,6 +1661,27 @@ func buildClaudeConfigModels(entry *config.ClaudeKey) []*ModelInfo {
 	return buildConfigModels(entry.Models, "anthropic", "claude")
 }
 
+func buildAzureOpenAIConfigModels(entry *config.AzureOpenAIKey) []*ModelInfo {
+	if entry == nil {
+		return nil
+	}
+	return buildConfigModels(entry.Models, "azure", "openai")
+}
+
+func buildBedrockConfigModels(entry *config.BedrockKey) []*ModelInfo {
+	if entry == nil {
+		return nil
+	}
+	return buildConfigModels(entry.Models, "bedrock", "claude")
+}
+
+func buildOllamaConfigModels(entry *config.OllamaKey) []*ModelInfo {
+	if entry == nil {
+		return nil
+	}
+	return buildConfigModels(entry.Models, "ollama", "ollama")
+}
+
 func buildCodexConfigModels(entry *config.CodexKey) []*ModelInfo {
 	if entry == nil {
 		return nil
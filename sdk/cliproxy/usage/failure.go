@@ -0,0 +1,46 @@
+package usage
+
+import "net/http"
+
+// Failure classes recorded for failed requests.
+const (
+	// FailureClassAuth marks upstream authentication or permission errors (401, 403).
+	FailureClassAuth = "auth"
+	// FailureClassRateLimit marks upstream rate limiting and quota exhaustion (429).
+	FailureClassRateLimit = "rate_limit"
+	// FailureClassTimeout marks requests that ran out of time before completing.
+	FailureClassTimeout = "timeout"
+	// FailureClassUpstream5xx marks upstream server errors.
+	FailureClassUpstream5xx = "upstream_5xx"
+	// FailureClassUpstream4xx marks other upstream client errors, such as invalid requests.
+	FailureClassUpstream4xx = "upstream_4xx"
+	// FailureClassClientCancel marks requests abandoned by the client.
+	FailureClassClientCancel = "client_cancel"
+	// FailureClassTranslation marks requests that could not be converted for the provider.
+	FailureClassTranslation = "translation_error"
+	// FailureClassPolicy marks requests rejected by a client API key policy.
+	FailureClassPolicy = "policy"
+	// FailureClassOther marks failures that fit no other class.
+	FailureClassOther = "other"
+)
+
+// FailureClassForStatus maps an HTTP status code of a failed request to its failure class.
+func FailureClassForStatus(status int) string {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return FailureClassAuth
+	case status == http.StatusTooManyRequests:
+		return FailureClassRateLimit
+	case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout:
+		return FailureClassTimeout
+	case status == 499:
+		// Client closed request, as reported by nginx-style proxies.
+		return FailureClassClientCancel
+	case status >= http.StatusInternalServerError:
+		return FailureClassUpstream5xx
+	case status >= http.StatusBadRequest:
+		return FailureClassUpstream4xx
+	default:
+		return FailureClassOther
+	}
+}
@@ -2,6 +2,7 @@ package usage
 
 import (
 	"context"
+	"slices"
 	"sync"
 	"time"
 
@@ -20,7 +21,15 @@ type Record struct {
 	RequestedAt time.Time
 	Latency     time.Duration
 	Failed      bool
-	Detail      Detail
+	// FailureClass tells why a failed request failed, using the FailureClass constants.
+	// Empty for successful requests; failed records without a class are classified from
+	// the response status.
+	FailureClass string
+	// PolicyDenied marks requests rejected by a client API key policy before reaching a provider.
+	PolicyDenied bool
+	// StreamTokensPerSecond is the output rate limit applied to the streamed response, if any.
+	StreamTokensPerSecond float64
+	Detail                Detail
 }
 
 // Detail holds the token usage breakdown.
@@ -105,6 +114,16 @@ func (m *Manager) Register(plugin Plugin) {
 	m.pluginsMu.Unlock()
 }
 
+// Unregister removes a plugin registered earlier from the delivery list.
+func (m *Manager) Unregister(plugin Plugin) {
+	if m == nil || plugin == nil {
+		return
+	}
+	m.pluginsMu.Lock()
+	m.plugins = slices.DeleteFunc(m.plugins, func(p Plugin) bool { return p == plugin })
+	m.pluginsMu.Unlock()
+}
+
 // Publish enqueues a usage record for processing. If no plugin is registered
 // the record will be discarded downstream.
 func (m *Manager) Publish(ctx context.Context, record Record) {
@@ -173,6 +192,9 @@ func DefaultManager() *Manager { return defaultManager }
 // RegisterPlugin registers a plugin on the default manager.
 func RegisterPlugin(plugin Plugin) { DefaultManager().Register(plugin) }
 
+// UnregisterPlugin removes a plugin from the default manager.
+func UnregisterPlugin(plugin Plugin) { DefaultManager().Unregister(plugin) }
+
 // PublishRecord publishes a record using the default manager.
 func PublishRecord(ctx context.Context, record Record) { DefaultManager().Publish(ctx, record) }
 
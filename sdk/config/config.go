@@ -7,11 +7,29 @@ package config
 import internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 
 type SDKConfig = internalconfig.SDKConfig
+type APIKeyPolicy = internalconfig.APIKeyPolicy
+type RoutingScript = internalconfig.RoutingScript
 
 type Config = internalconfig.Config
 
 type StreamingConfig = internalconfig.StreamingConfig
 type TLSConfig = internalconfig.TLSConfig
+type CompressionConfig = internalconfig.CompressionConfig
+type ConcurrencyConfig = internalconfig.ConcurrencyConfig
+type IdempotencyConfig = internalconfig.IdempotencyConfig
+type UpstreamRetryConfig = internalconfig.UpstreamRetryConfig
+type TimeoutConfig = internalconfig.TimeoutConfig
+type TimeoutSettings = internalconfig.TimeoutSettings
+type HTTPPoolConfig = internalconfig.HTTPPoolConfig
+type InterceptorConfig = internalconfig.InterceptorConfig
+type CircuitBreakerConfig = internalconfig.CircuitBreakerConfig
+type ResponseModeConfig = internalconfig.ResponseModeConfig
+type StreamResumeConfig = internalconfig.StreamResumeConfig
+type ConcurrencyLimitConfig = internalconfig.ConcurrencyLimitConfig
+type FailureDiagnosticsConfig = internalconfig.FailureDiagnosticsConfig
+type CredentialQuarantineConfig = internalconfig.CredentialQuarantineConfig
+type QuarantinePattern = internalconfig.QuarantinePattern
+type StreamWatchConfig = internalconfig.StreamWatchConfig
 type RemoteManagement = internalconfig.RemoteManagement
 type AmpCode = internalconfig.AmpCode
 type OAuthModelAlias = internalconfig.OAuthModelAlias
@@ -19,15 +37,31 @@ type PayloadConfig = internalconfig.PayloadConfig
 type PayloadRule = internalconfig.PayloadRule
 type PayloadFilterRule = internalconfig.PayloadFilterRule
 type PayloadModelRule = internalconfig.PayloadModelRule
+type TranslatorConfig = internalconfig.TranslatorConfig
+type TranslatorPair = internalconfig.TranslatorPair
+type TranslatorDialect = internalconfig.TranslatorDialect
+type HistoryNormalizationConfig = internalconfig.HistoryNormalizationConfig
+type HistoryNormalizationRule = internalconfig.HistoryNormalizationRule
+type AgentLoopConfig = internalconfig.AgentLoopConfig
+type AgentTool = internalconfig.AgentTool
 
 type GeminiKey = internalconfig.GeminiKey
 type CodexKey = internalconfig.CodexKey
 type ClaudeKey = internalconfig.ClaudeKey
 type VertexCompatKey = internalconfig.VertexCompatKey
 type VertexCompatModel = internalconfig.VertexCompatModel
+type VertexCredential = internalconfig.VertexCredential
+type AzureOpenAIKey = internalconfig.AzureOpenAIKey
+type AzureOpenAIModel = internalconfig.AzureOpenAIModel
+type BedrockKey = internalconfig.BedrockKey
+type OllamaKey = internalconfig.OllamaKey
+type OllamaModel = internalconfig.OllamaModel
+type BedrockModel = internalconfig.BedrockModel
 type OpenAICompatibility = internalconfig.OpenAICompatibility
 type OpenAICompatibilityAPIKey = internalconfig.OpenAICompatibilityAPIKey
 type OpenAICompatibilityModel = internalconfig.OpenAICompatibilityModel
+type MockProvider = internalconfig.MockProvider
+type MockToolCall = internalconfig.MockToolCall
 
 type TLS = internalconfig.TLSConfig
 
@@ -0,0 +1,153 @@
+package translator
+
+import (
+	"maps"
+	"slices"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// AnyFormat matches every source format when used as the From side of a Dialect.
+const AnyFormat Format = "*"
+
+// Pair identifies a translator direction from a source schema to a target schema.
+type Pair struct {
+	From Format
+	To   Format
+}
+
+// PayloadRewrite describes JSON edits applied to a translated request payload.
+// Renames run first, then deletions, then assignments. Renames and assignments run in
+// lexical order of their keys so overlapping paths give the same result every time.
+type PayloadRewrite struct {
+	// Rename moves values from one JSON path to another.
+	Rename map[string]string
+	// Delete removes JSON paths from the payload.
+	Delete []string
+	// Set writes values at JSON paths, overwriting existing values.
+	Set map[string]any
+}
+
+// Apply returns rawJSON with the rewrite rules applied. Failed edits are logged and skipped.
+func (p PayloadRewrite) Apply(rawJSON []byte) []byte {
+	out := rawJSON
+	for _, src := range slices.Sorted(maps.Keys(p.Rename)) {
+		dst := p.Rename[src]
+		value := gjson.GetBytes(out, src)
+		if !value.Exists() {
+			continue
+		}
+		updated, errSet := sjson.SetRawBytes(out, dst, []byte(value.Raw))
+		if errSet != nil {
+			log.Warnf("translator: failed to rename %s to %s: %v", src, dst, errSet)
+			continue
+		}
+		if updated, errSet = sjson.DeleteBytes(updated, src); errSet != nil {
+			log.Warnf("translator: failed to remove renamed path %s: %v", src, errSet)
+			continue
+		}
+		out = updated
+	}
+	for _, path := range p.Delete {
+		updated, errDelete := sjson.DeleteBytes(out, path)
+		if errDelete != nil {
+			log.Warnf("translator: failed to delete %s: %v", path, errDelete)
+			continue
+		}
+		out = updated
+	}
+	for _, path := range slices.Sorted(maps.Keys(p.Set)) {
+		updated, errSet := sjson.SetBytes(out, path, p.Set[path])
+		if errSet != nil {
+			log.Warnf("translator: failed to set %s: %v", path, errSet)
+			continue
+		}
+		out = updated
+	}
+	return out
+}
+
+// Dialect declares a translator pair that reuses the transforms registered for
+// From -> Base and rewrites the translated request for an upstream variant.
+type Dialect struct {
+	From    Format
+	To      Format
+	Base    Format
+	Rewrite PayloadRewrite
+}
+
+// SetOverrides atomically replaces the runtime overrides of the registry: the set of
+// disabled translator pairs and the configured dialects. Pairs registered in code are
+// left untouched, so passing empty slices restores the built-in behavior.
+func (r *Registry) SetOverrides(disabled []Pair, dialects []Dialect) {
+	nextDisabled := make(map[Format]map[Format]struct{})
+	for _, pair := range disabled {
+		if pair.From == "" || pair.To == "" {
+			continue
+		}
+		if _, ok := nextDisabled[pair.From]; !ok {
+			nextDisabled[pair.From] = make(map[Format]struct{})
+		}
+		nextDisabled[pair.From][pair.To] = struct{}{}
+	}
+	nextDialects := make(map[Format]map[Format]Dialect)
+	for _, dialect := range dialects {
+		if dialect.To == "" || dialect.Base == "" || dialect.To == dialect.Base {
+			continue
+		}
+		if dialect.From == "" {
+			dialect.From = AnyFormat
+		}
+		if _, ok := nextDialects[dialect.From]; !ok {
+			nextDialects[dialect.From] = make(map[Format]Dialect)
+		}
+		nextDialects[dialect.From][dialect.To] = dialect
+	}
+
+	r.mu.Lock()
+	r.disabled = nextDisabled
+	r.dialects = nextDialects
+	r.mu.Unlock()
+}
+
+// BaseFormat returns the built-in format a dialect target is derived from, or f itself
+// when f is not a configured dialect.
+func (r *Registry) BaseFormat(f Format) Format {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, byTarget := range r.dialects {
+		if dialect, ok := byTarget[f]; ok {
+			return dialect.Base
+		}
+	}
+	return f
+}
+
+// isDisabledLocked reports whether the pair was disabled by configuration. Callers must hold r.mu.
+func (r *Registry) isDisabledLocked(from, to Format) bool {
+	_, ok := r.disabled[from][to]
+	return ok
+}
+
+// dialectLocked resolves the dialect for a pair, preferring an exact source match
+// over the wildcard source. Callers must hold r.mu.
+func (r *Registry) dialectLocked(from, to Format) (Dialect, bool) {
+	if dialect, ok := r.dialects[from][to]; ok {
+		return dialect, true
+	}
+	dialect, ok := r.dialects[AnyFormat][to]
+	return dialect, ok
+}
+
+// SetOverrides replaces the runtime overrides of the default registry.
+func SetOverrides(disabled []Pair, dialects []Dialect) {
+	defaultRegistry.SetOverrides(disabled, dialects)
+}
+
+// BaseFormat resolves a dialect target on the default registry.
+func BaseFormat(f Format) Format {
+	return defaultRegistry.BaseFormat(f)
+}
@@ -0,0 +1,92 @@
+package translator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+func newDialectTestRegistry() *Registry {
+	registry := NewRegistry()
+	registry.Register(FormatClaude, FormatOpenAI, func(model string, rawJSON []byte, stream bool) []byte {
+		out, _ := sjson.SetBytes(rawJSON, "translated", true)
+		return out
+	}, ResponseTransform{
+		NonStream: func(ctx context.Context, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) []byte {
+			return []byte(`{"from":"openai"}`)
+		},
+	})
+	return registry
+}
+
+func TestRegistryDialectReusesBaseTranslator(t *testing.T) {
+	registry := newDialectTestRegistry()
+	registry.SetOverrides(nil, []Dialect{{
+		To:   "openai-legacy",
+		Base: FormatOpenAI,
+		Rewrite: PayloadRewrite{
+			Rename: map[string]string{"max_completion_tokens": "max_tokens"},
+			Delete: []string{"parallel_tool_calls"},
+			Set:    map[string]any{"safe_prompt": false},
+		},
+	}})
+
+	got := registry.TranslateRequest(FormatClaude, "openai-legacy", "m", []byte(`{"model":"m","max_completion_tokens":5,"parallel_tool_calls":true}`), false)
+	if !gjson.GetBytes(got, "translated").Bool() {
+		t.Fatalf("expected base translator to run: %s", got)
+	}
+	if gjson.GetBytes(got, "max_tokens").Int() != 5 || gjson.GetBytes(got, "max_completion_tokens").Exists() {
+		t.Fatalf("expected rename to apply: %s", got)
+	}
+	if gjson.GetBytes(got, "parallel_tool_calls").Exists() {
+		t.Fatalf("expected delete to apply: %s", got)
+	}
+	if v := gjson.GetBytes(got, "safe_prompt"); !v.Exists() || v.Bool() {
+		t.Fatalf("expected set to apply: %s", got)
+	}
+
+	if registry.BaseFormat("openai-legacy") != FormatOpenAI {
+		t.Fatalf("BaseFormat() = %s, want %s", registry.BaseFormat("openai-legacy"), FormatOpenAI)
+	}
+	resp := registry.TranslateNonStream(context.Background(), "openai-legacy", FormatClaude, "m", nil, nil, []byte(`{}`), nil)
+	if string(resp) != `{"from":"openai"}` {
+		t.Fatalf("expected base response translator, got %s", resp)
+	}
+}
+
+func TestRegistryDisabledPairPassesThrough(t *testing.T) {
+	registry := newDialectTestRegistry()
+	registry.SetOverrides([]Pair{{From: FormatClaude, To: FormatOpenAI}}, nil)
+
+	got := registry.TranslateRequest(FormatClaude, FormatOpenAI, "m", []byte(`{"model":"m"}`), false)
+	if gjson.GetBytes(got, "translated").Exists() {
+		t.Fatalf("expected disabled translator to be skipped: %s", got)
+	}
+	if registry.HasResponseTransformer(FormatClaude, FormatOpenAI) {
+		t.Fatal("expected disabled pair to report no response transformer")
+	}
+
+	registry.SetOverrides(nil, nil)
+	got = registry.TranslateRequest(FormatClaude, FormatOpenAI, "m", []byte(`{"model":"m"}`), false)
+	if !gjson.GetBytes(got, "translated").Bool() {
+		t.Fatalf("expected translator to be re-enabled: %s", got)
+	}
+}
+
+func TestPayloadRewriteAppliesOverlappingPathsInOrder(t *testing.T) {
+	rewrite := PayloadRewrite{
+		Rename: map[string]string{"max_tokens": "limit", "max_completion_tokens": "limit"},
+		Set:    map[string]any{"options": map[string]any{"a": 1}, "options.b": 2},
+	}
+	for range 20 {
+		got := rewrite.Apply([]byte(`{"max_completion_tokens":5,"max_tokens":7}`))
+		if gjson.GetBytes(got, "limit").Int() != 7 || gjson.GetBytes(got, "max_tokens").Exists() {
+			t.Fatalf("renames did not run in key order: %s", got)
+		}
+		if gjson.GetBytes(got, "options.a").Int() != 1 || gjson.GetBytes(got, "options.b").Int() != 2 {
+			t.Fatalf("assignments did not run in key order: %s", got)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package translator
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// MissingPairMode selects how requests are handled when no translator is registered for
+// their source and target formats.
+type MissingPairMode string
+
+const (
+	// MissingPairForward forwards the source payload unchanged, whatever the target format.
+	MissingPairForward MissingPairMode = ""
+	// MissingPairPassthrough forwards the source payload unchanged when both formats share a
+	// schema and rejects the request otherwise.
+	MissingPairPassthrough MissingPairMode = "passthrough"
+	// MissingPairReject rejects every request without a registered translator.
+	MissingPairReject MissingPairMode = "reject"
+)
+
+// ParseMissingPairMode normalizes a configured mode. Unknown values report false.
+func ParseMissingPairMode(value string) (MissingPairMode, bool) {
+	switch mode := MissingPairMode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case MissingPairForward, MissingPairPassthrough, MissingPairReject:
+		return mode, true
+	}
+	return MissingPairForward, false
+}
+
+// schemaFamilies maps formats whose request schema is another format's, so payloads can be
+// forwarded between them unchanged.
+var schemaFamilies = map[Format]Format{
+	FormatCodex:       FormatOpenAIResponse,
+	FormatAntigravity: FormatGeminiCLI,
+}
+
+// MissingTranslatorError reports a request whose source and target formats have no registered
+// translator and cannot be forwarded unchanged.
+type MissingTranslatorError struct {
+	From Format
+	To   Format
+}
+
+// Error implements the error interface.
+func (e *MissingTranslatorError) Error() string {
+	return fmt.Sprintf("no translator is registered from %s to %s", e.From, e.To)
+}
+
+// StatusCode implements a portable status code interface for HTTP handlers.
+func (e *MissingTranslatorError) StatusCode() int {
+	return http.StatusNotImplemented
+}
+
+// SetMissingPairMode replaces the handling of requests without a registered translator.
+func (r *Registry) SetMissingPairMode(mode MissingPairMode) {
+	r.mu.Lock()
+	r.missingPair = mode
+	r.mu.Unlock()
+}
+
+// CheckRequestPair reports how a request is translated from one format to another under the
+// configured MissingPairMode. It returns true when no translator is registered and the payload
+// is forwarded unchanged between formats that share a schema, and a *MissingTranslatorError
+// when the pair cannot be served. Identical formats, registered pairs, dialects and pairs
+// disabled by configuration are always served.
+func (r *Registry) CheckRequestPair(from, to Format) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if from == to || r.isDisabledLocked(from, to) {
+		return false, nil
+	}
+	if _, ok := r.responses[from][to]; ok {
+		return false, nil
+	}
+	if _, ok := r.dialectLocked(from, to); ok {
+		return false, nil
+	}
+
+	switch r.missingPair {
+	case MissingPairPassthrough:
+		if r.schemaFamilyLocked(from) == r.schemaFamilyLocked(to) {
+			return true, nil
+		}
+		return false, &MissingTranslatorError{From: from, To: to}
+	case MissingPairReject:
+		return false, &MissingTranslatorError{From: from, To: to}
+	}
+	return false, nil
+}
+
+// schemaFamilyLocked resolves the format whose request schema f uses, following configured
+// dialects to their base format. Callers must hold r.mu.
+func (r *Registry) schemaFamilyLocked(f Format) Format {
+	for _, byTarget := range r.dialects {
+		if dialect, ok := byTarget[f]; ok {
+			f = dialect.Base
+			break
+		}
+	}
+	if family, ok := schemaFamilies[f]; ok {
+		return family
+	}
+	return f
+}
+
+// SetMissingPairMode replaces the handling of missing translator pairs on the default registry.
+func SetMissingPairMode(mode MissingPairMode) {
+	defaultRegistry.SetMissingPairMode(mode)
+}
+
+// CheckRequestPair checks a translator pair against the default registry.
+func CheckRequestPair(from, to Format) (bool, error) {
+	return defaultRegistry.CheckRequestPair(from, to)
+}
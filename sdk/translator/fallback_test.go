@@ -0,0 +1,58 @@
+package translator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRegistryCheckRequestPair(t *testing.T) {
+	registry := newDialectTestRegistry()
+	registry.SetOverrides(nil, []Dialect{{To: "acme", Base: FormatOpenAI}})
+
+	// Without a mode every pair is served, as before.
+	if passthrough, err := registry.CheckRequestPair(FormatGemini, FormatOpenAI); passthrough || err != nil {
+		t.Fatalf("default mode = %v, %v", passthrough, err)
+	}
+
+	registry.SetMissingPairMode(MissingPairPassthrough)
+	tests := []struct {
+		name        string
+		from, to    Format
+		passthrough bool
+		wantErr     bool
+	}{
+		{"identity", FormatClaude, FormatClaude, false, false},
+		{"registered", FormatClaude, FormatOpenAI, false, false},
+		{"dialect", FormatClaude, "acme", false, false},
+		{"shared schema", FormatOpenAIResponse, FormatCodex, true, false},
+		{"shared dialect schema", "acme", FormatOpenAI, true, false},
+		{"incompatible", FormatGemini, FormatOpenAI, false, true},
+	}
+	for _, tt := range tests {
+		passthrough, err := registry.CheckRequestPair(tt.from, tt.to)
+		if passthrough != tt.passthrough || (err != nil) != tt.wantErr {
+			t.Fatalf("%s: CheckRequestPair() = %v, %v", tt.name, passthrough, err)
+		}
+		if _, ok := errors.AsType[*MissingTranslatorError](err); tt.wantErr && !ok {
+			t.Fatalf("%s: expected a MissingTranslatorError, got %v", tt.name, err)
+		}
+	}
+
+	registry.SetMissingPairMode(MissingPairReject)
+	if _, err := registry.CheckRequestPair(FormatOpenAIResponse, FormatCodex); err == nil {
+		t.Fatal("reject mode must fail pairs without a translator")
+	}
+	registry.SetOverrides([]Pair{{From: FormatOpenAIResponse, To: FormatCodex}}, nil)
+	if _, err := registry.CheckRequestPair(FormatOpenAIResponse, FormatCodex); err != nil {
+		t.Fatalf("pairs disabled by configuration must still be served: %v", err)
+	}
+}
+
+func TestParseMissingPairMode(t *testing.T) {
+	if mode, ok := ParseMissingPairMode(" Passthrough "); !ok || mode != MissingPairPassthrough {
+		t.Fatalf("ParseMissingPairMode() = %q, %v", mode, ok)
+	}
+	if mode, ok := ParseMissingPairMode("loose"); ok || mode != MissingPairForward {
+		t.Fatalf("ParseMissingPairMode(unknown) = %q, %v", mode, ok)
+	}
+}
@@ -0,0 +1,108 @@
+package translator
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// UseRequest adds request middleware to the registry, executed in registration order around
+// every TranslateRequest call. Code before next sees the client payload and code after it
+// sees the translated payload that is dispatched to the executor, so middleware can rewrite
+// prompts, scrub data or log requests without changing individual translators.
+// TranslateRequest carries no context, so request middleware receives context.Background().
+// A middleware error is logged and the request is translated without middleware.
+func (r *Registry) UseRequest(mw RequestMiddleware) {
+	if mw == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requestMiddleware = append(r.requestMiddleware, mw)
+}
+
+// UseResponse adds response middleware to the registry, executed in registration order around
+// every TranslateStream, FinalizeStream and TranslateNonStream call. Code before next sees the
+// upstream payload and code after it sees the chunks or body returned to the client. A
+// middleware error is logged and the translated response is returned unchanged.
+func (r *Registry) UseResponse(mw ResponseMiddleware) {
+	if mw == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.responseMiddleware = append(r.responseMiddleware, mw)
+}
+
+// applyRequestMiddleware runs the request middleware chain around terminal.
+func (r *Registry) applyRequestMiddleware(req RequestEnvelope, terminal RequestHandler) RequestEnvelope {
+	r.mu.RLock()
+	middleware := r.requestMiddleware
+	r.mu.RUnlock()
+
+	ctx := context.Background()
+	if len(middleware) == 0 {
+		out, _ := terminal(ctx, req)
+		return out
+	}
+	out, errChain := chainRequest(middleware, terminal)(ctx, req)
+	if errChain != nil {
+		log.Warnf("translator: request middleware failed for %s -> %s: %v", req.Format, req.Target, errChain)
+		out, _ = terminal(ctx, req)
+	}
+	return out
+}
+
+// applyResponseMiddleware runs the response middleware chain around terminal. Stream
+// translators keep state in their param, so terminal runs at most once.
+func (r *Registry) applyResponseMiddleware(ctx context.Context, resp ResponseEnvelope, terminal ResponseHandler) ResponseEnvelope {
+	r.mu.RLock()
+	middleware := r.responseMiddleware
+	r.mu.RUnlock()
+
+	if len(middleware) == 0 {
+		out, _ := terminal(ctx, resp)
+		return out
+	}
+	var translated *ResponseEnvelope
+	out, errChain := chainResponse(middleware, func(ctx context.Context, in ResponseEnvelope) (ResponseEnvelope, error) {
+		result, errTerminal := terminal(ctx, in)
+		translated = &result
+		return result, errTerminal
+	})(ctx, resp)
+	if errChain == nil {
+		return out
+	}
+	log.Warnf("translator: response middleware failed for %s -> %s: %v", resp.Format, resp.Target, errChain)
+	if translated != nil {
+		return *translated
+	}
+	out, _ = terminal(ctx, resp)
+	return out
+}
+
+// chainRequest wraps terminal with middleware so the first registered runs outermost.
+func chainRequest(middleware []RequestMiddleware, terminal RequestHandler) RequestHandler {
+	handler := terminal
+	for i := len(middleware) - 1; i >= 0; i-- {
+		mw := middleware[i]
+		next := handler
+		handler = func(ctx context.Context, req RequestEnvelope) (RequestEnvelope, error) {
+			return mw(ctx, req, next)
+		}
+	}
+	return handler
+}
+
+// chainResponse wraps terminal with middleware so the first registered runs outermost.
+func chainResponse(middleware []ResponseMiddleware, terminal ResponseHandler) ResponseHandler {
+	handler := terminal
+	for i := len(middleware) - 1; i >= 0; i-- {
+		mw := middleware[i]
+		next := handler
+		handler = func(ctx context.Context, resp ResponseEnvelope) (ResponseEnvelope, error) {
+			return mw(ctx, resp, next)
+		}
+	}
+	return handler
+}
@@ -0,0 +1,83 @@
+package translator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRegistryRequestMiddleware(t *testing.T) {
+	r := NewRegistry()
+	r.Register(FormatOpenAI, FormatClaude, func(model string, rawJSON []byte, stream bool) []byte {
+		return append([]byte("claude:"), rawJSON...)
+	}, ResponseTransform{})
+
+	var order []string
+	r.UseRequest(func(ctx context.Context, req RequestEnvelope, next RequestHandler) (RequestEnvelope, error) {
+		order = append(order, "outer:"+string(req.Format)+">"+string(req.Target))
+		req.Body = bytes.ReplaceAll(req.Body, []byte("secret"), []byte("[redacted]"))
+		return next(ctx, req)
+	})
+	r.UseRequest(func(ctx context.Context, req RequestEnvelope, next RequestHandler) (RequestEnvelope, error) {
+		order = append(order, "inner")
+		out, err := next(ctx, req)
+		out.Body = append(out.Body, "!"...)
+		return out, err
+	})
+
+	out := r.TranslateRequest(FormatOpenAI, FormatClaude, "m", []byte("my secret"), false)
+	if string(out) != "claude:my [redacted]!" {
+		t.Fatalf("TranslateRequest() = %q", out)
+	}
+	if len(order) != 2 || order[0] != "outer:openai>claude" || order[1] != "inner" {
+		t.Fatalf("middleware order = %v", order)
+	}
+
+	r.UseRequest(func(ctx context.Context, req RequestEnvelope, next RequestHandler) (RequestEnvelope, error) {
+		return req, errors.New("boom")
+	})
+	if out := r.TranslateRequest(FormatOpenAI, FormatClaude, "m", []byte("my secret"), false); string(out) != "claude:my secret" {
+		t.Fatalf("failing middleware must fall back to plain translation, got %q", out)
+	}
+}
+
+func TestRegistryResponseMiddleware(t *testing.T) {
+	r := NewRegistry()
+	calls := 0
+	r.Register(FormatOpenAI, FormatClaude, nil, ResponseTransform{
+		Stream: func(ctx context.Context, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) [][]byte {
+			calls++
+			return [][]byte{append([]byte("openai:"), rawJSON...)}
+		},
+		NonStream: func(ctx context.Context, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) []byte {
+			return append([]byte("openai:"), rawJSON...)
+		},
+	})
+	r.UseResponse(func(ctx context.Context, resp ResponseEnvelope, next ResponseHandler) (ResponseEnvelope, error) {
+		out, err := next(ctx, resp)
+		if out.Stream {
+			out.Chunks = append(out.Chunks, []byte("extra"))
+		} else {
+			out.Body = bytes.ToUpper(out.Body)
+		}
+		return out, err
+	})
+
+	if out := r.TranslateNonStream(context.Background(), FormatClaude, FormatOpenAI, "m", nil, nil, []byte("body"), nil); string(out) != "OPENAI:BODY" {
+		t.Fatalf("TranslateNonStream() = %q", out)
+	}
+	if chunks := r.TranslateStream(context.Background(), FormatClaude, FormatOpenAI, "m", nil, nil, []byte("chunk"), nil); len(chunks) != 2 || string(chunks[1]) != "extra" {
+		t.Fatalf("TranslateStream() = %q", chunks)
+	}
+
+	r.UseResponse(func(ctx context.Context, resp ResponseEnvelope, next ResponseHandler) (ResponseEnvelope, error) {
+		out, _ := next(ctx, resp)
+		return out, errors.New("boom")
+	})
+	calls = 0
+	chunks := r.TranslateStream(context.Background(), FormatClaude, FormatOpenAI, "m", nil, nil, []byte("chunk"), nil)
+	if calls != 1 || len(chunks) != 1 || string(chunks[0]) != "openai:chunk" {
+		t.Fatalf("failing middleware: calls = %d, chunks = %q", calls, chunks)
+	}
+}
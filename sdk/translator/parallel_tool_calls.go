@@ -0,0 +1,113 @@
+package translator
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+type toolCallTrackingKey struct{}
+
+// toolCallTracking remembers whether a response already forwarded a tool call.
+type toolCallTracking struct {
+	mu   sync.Mutex
+	seen bool
+}
+
+// WithToolCallTracking returns a context that lets response translation serialize tool calls
+// across stream chunks. Gemini upstreams have no switch for parallel tool calls, so when the
+// client disabled them only the first function call of a response is forwarded. Without the
+// context the limit still applies to each chunk on its own. The context must be used for a
+// single response.
+func WithToolCallTracking(ctx context.Context) context.Context {
+	if ctx == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, toolCallTrackingKey{}, &toolCallTracking{})
+}
+
+// serialToolCallsRequired reports whether a client request disabled parallel tool calls for an
+// upstream format that cannot enforce it.
+func serialToolCallsRequired(client, upstream Format, originalRequest []byte) bool {
+	switch upstream {
+	case FormatGemini, FormatGeminiCLI, FormatAntigravity:
+	default:
+		return false
+	}
+	switch client {
+	case FormatOpenAI, FormatOpenAIResponse:
+		return gjson.GetBytes(originalRequest, "parallel_tool_calls").Type == gjson.False
+	case FormatClaude:
+		return gjson.GetBytes(originalRequest, "tool_choice.disable_parallel_tool_use").Bool()
+	default:
+		return false
+	}
+}
+
+// serializeToolCalls drops every Gemini functionCall part after the first one of the response
+// before it reaches the response translator.
+func serializeToolCalls(ctx context.Context, client, upstream Format, originalRequest, chunk []byte) []byte {
+	if !serialToolCallsRequired(client, upstream, originalRequest) {
+		return chunk
+	}
+	var state *toolCallTracking
+	if ctx != nil {
+		state, _ = ctx.Value(toolCallTrackingKey{}).(*toolCallTracking)
+	}
+	if state == nil {
+		state = &toolCallTracking{}
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	prefix, payload, suffix := splitSSEData(chunk)
+	root := gjson.ParseBytes(payload)
+	base := "candidates"
+	if !root.Get(base).Exists() {
+		base = "response.candidates"
+	}
+	candidates := root.Get(base)
+	if !candidates.IsArray() {
+		return chunk
+	}
+	changed := false
+	for i, candidate := range candidates.Array() {
+		parts := candidate.Get("content.parts")
+		if !parts.IsArray() {
+			continue
+		}
+		kept := make([]byte, 0, len(parts.Raw))
+		kept = append(kept, '[')
+		dropped := false
+		for _, part := range parts.Array() {
+			if part.Get("functionCall").Exists() {
+				if state.seen {
+					dropped = true
+					continue
+				}
+				state.seen = true
+			}
+			if len(kept) > 1 {
+				kept = append(kept, ',')
+			}
+			kept = append(kept, part.Raw...)
+		}
+		if !dropped {
+			continue
+		}
+		kept = append(kept, ']')
+		payload, _ = sjson.SetRawBytes(payload, base+"."+strconv.Itoa(i)+".content.parts", kept)
+		changed = true
+	}
+	if !changed {
+		return chunk
+	}
+	if len(prefix) == 0 {
+		return payload
+	}
+	out := append(prefix, payload...)
+	return append(out, suffix...)
+}
@@ -0,0 +1,54 @@
+package translator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestSerializeToolCallsStream(t *testing.T) {
+	r := NewRegistry()
+	ctx := WithToolCallTracking(context.Background())
+	original := []byte(`{"parallel_tool_calls":false}`)
+	var param any
+
+	var calls []string
+	for _, chunk := range []string{
+		"data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"ok\"},{\"functionCall\":{\"name\":\"a\"}},{\"functionCall\":{\"name\":\"b\"}}]}}]}\n\n",
+		`{"response":{"candidates":[{"content":{"parts":[{"functionCall":{"name":"a"}}]}}]}}`,
+	} {
+		for _, out := range r.TranslateStream(ctx, FormatGemini, FormatOpenAI, "m", original, nil, []byte(chunk), &param) {
+			_, payload, _ := splitSSEData(out)
+			root := gjson.ParseBytes(payload)
+			if !root.Get("candidates").Exists() {
+				root = root.Get("response")
+			}
+			root.Get("candidates.0.content.parts.#.functionCall.name").ForEach(func(_, name gjson.Result) bool {
+				calls = append(calls, name.String())
+				return true
+			})
+		}
+	}
+	if len(calls) != 1 || calls[0] != "a" {
+		t.Fatalf("forwarded calls = %v, want [a]", calls)
+	}
+}
+
+func TestSerializeToolCallsSkipsNativeTargets(t *testing.T) {
+	r := NewRegistry()
+	body := []byte(`{"candidates":[{"content":{"parts":[{"functionCall":{"name":"a"}},{"functionCall":{"name":"b"}}]}}]}`)
+
+	out := r.TranslateNonStream(context.Background(), FormatGemini, FormatClaude, "m", []byte(`{"tool_choice":{"type":"auto","disable_parallel_tool_use":true}}`), nil, body, nil)
+	if got := gjson.GetBytes(out, "candidates.0.content.parts.#").Int(); got != 1 {
+		t.Fatalf("parts = %d, want 1: %s", got, out)
+	}
+	out = r.TranslateNonStream(context.Background(), FormatClaude, FormatOpenAI, "m", []byte(`{"parallel_tool_calls":false}`), nil, body, nil)
+	if string(out) != string(body) {
+		t.Fatalf("native upstream must be untouched: %s", out)
+	}
+	out = r.TranslateNonStream(context.Background(), FormatGemini, FormatOpenAI, "m", []byte(`{}`), nil, body, nil)
+	if string(out) != string(body) {
+		t.Fatalf("parallel calls allowed by default: %s", out)
+	}
+}
@@ -2,17 +2,21 @@ package translator
 
 import "context"
 
-// RequestEnvelope represents a request in the translation pipeline.
+// RequestEnvelope represents a request in the translation pipeline. Format is the schema of
+// Body; Target is the schema the request is being translated to.
 type RequestEnvelope struct {
 	Format Format
+	Target Format
 	Model  string
 	Stream bool
 	Body   []byte
 }
 
-// ResponseEnvelope represents a response in the translation pipeline.
+// ResponseEnvelope represents a response in the translation pipeline. Format is the schema of
+// Body and Chunks; Target is the schema the response is being translated to.
 type ResponseEnvelope struct {
 	Format Format
+	Target Format
 	Model  string
 	Stream bool
 	Body   []byte
@@ -69,16 +73,8 @@ func (p *Pipeline) TranslateRequest(ctx context.Context, from, to Format, req Re
 		return input, nil
 	}
 
-	handler := terminal
-	for i := len(p.requestMiddleware) - 1; i >= 0; i-- {
-		mw := p.requestMiddleware[i]
-		next := handler
-		handler = func(ctx context.Context, r RequestEnvelope) (RequestEnvelope, error) {
-			return mw(ctx, r, next)
-		}
-	}
-
-	return handler(ctx, req)
+	req.Target = to
+	return chainRequest(p.requestMiddleware, terminal)(ctx, req)
 }
 
 // TranslateResponse applies middleware and registry transformations.
@@ -93,14 +89,6 @@ func (p *Pipeline) TranslateResponse(ctx context.Context, from, to Format, resp
 		return input, nil
 	}
 
-	handler := terminal
-	for i := len(p.responseMiddleware) - 1; i >= 0; i-- {
-		mw := p.responseMiddleware[i]
-		next := handler
-		handler = func(ctx context.Context, r ResponseEnvelope) (ResponseEnvelope, error) {
-			return mw(ctx, r, next)
-		}
-	}
-
-	return handler(ctx, resp)
+	resp.Target = to
+	return chainResponse(p.responseMiddleware, terminal)(ctx, resp)
 }
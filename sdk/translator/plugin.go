@@ -0,0 +1,76 @@
+package translator
+
+import (
+	"errors"
+	"fmt"
+	"plugin"
+)
+
+// PluginSymbol is the name of the function a translator plugin exports.
+const PluginSymbol = "RegisterTranslators"
+
+// PluginRegisterFunc is the signature of the RegisterTranslators function exported by a
+// translator plugin. It receives the format name configured for the plugin and registers the
+// plugin's transforms, typically with registry.Register(source, format, ...) for each client
+// format it serves.
+type PluginRegisterFunc = func(format Format, registry *Registry) error
+
+// Plugin describes a translator plugin: a Go plugin built with -buildmode=plugin against the
+// same module version as the proxy.
+type Plugin struct {
+	// Path is the plugin file to open.
+	Path string
+	// Format is the target format name the plugin registers its translators under.
+	Format Format
+}
+
+// LoadPlugin opens a translator plugin and calls its RegisterTranslators function. Go plugins
+// cannot be unloaded; loading the same path again runs its registration again.
+func (r *Registry) LoadPlugin(p Plugin) error {
+	if p.Path == "" || p.Format == "" {
+		return errors.New("translator plugin: path and format are required")
+	}
+	opened, errOpen := plugin.Open(p.Path)
+	if errOpen != nil {
+		return fmt.Errorf("translator plugin %s: %w", p.Path, errOpen)
+	}
+	symbol, errLookup := opened.Lookup(PluginSymbol)
+	if errLookup != nil {
+		return fmt.Errorf("translator plugin %s: %w", p.Path, errLookup)
+	}
+	return r.registerPlugin(p, symbol)
+}
+
+func (r *Registry) registerPlugin(p Plugin, symbol plugin.Symbol) error {
+	register, ok := symbol.(PluginRegisterFunc)
+	if !ok {
+		return fmt.Errorf("translator plugin %s: %s has type %T, want %T", p.Path, PluginSymbol, symbol, PluginRegisterFunc(nil))
+	}
+	if errRegister := register(p.Format, r); errRegister != nil {
+		return fmt.Errorf("translator plugin %s: %w", p.Path, errRegister)
+	}
+
+	r.mu.Lock()
+	r.plugins[p.Format] = p.Path
+	r.mu.Unlock()
+	return nil
+}
+
+// IsPluginFormat reports whether f was registered by a translator plugin.
+func (r *Registry) IsPluginFormat(f Format) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.plugins[f]
+	return ok
+}
+
+// LoadPlugin loads a translator plugin into the default registry.
+func LoadPlugin(p Plugin) error {
+	return defaultRegistry.LoadPlugin(p)
+}
+
+// IsPluginFormat reports whether f was registered by a translator plugin on the default registry.
+func IsPluginFormat(f Format) bool {
+	return defaultRegistry.IsPluginFormat(f)
+}
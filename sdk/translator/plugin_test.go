@@ -0,0 +1,44 @@
+package translator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRegistryRegisterPlugin(t *testing.T) {
+	registry := NewRegistry()
+	var register PluginRegisterFunc = func(format Format, r *Registry) error {
+		r.Register(FormatOpenAI, format, func(model string, rawJSON []byte, stream bool) []byte {
+			return []byte(`{"acme":true}`)
+		}, ResponseTransform{})
+		return nil
+	}
+
+	if err := registry.registerPlugin(Plugin{Path: "acme.so", Format: "acme"}, register); err != nil {
+		t.Fatalf("registerPlugin() error = %v", err)
+	}
+	if !registry.IsPluginFormat("acme") {
+		t.Fatal("expected acme to be a plugin format")
+	}
+	if got := registry.TranslateRequest(FormatOpenAI, "acme", "m", []byte(`{}`), false); string(got) != `{"acme":true}` {
+		t.Fatalf("TranslateRequest() = %s", got)
+	}
+
+	if err := registry.registerPlugin(Plugin{Path: "bad.so", Format: "bad"}, func() {}); err == nil {
+		t.Fatal("expected an error for a symbol of the wrong type")
+	}
+	failing := func(Format, *Registry) error { return errors.New("boom") }
+	if err := registry.registerPlugin(Plugin{Path: "failing.so", Format: "failing"}, failing); err == nil || registry.IsPluginFormat("failing") {
+		t.Fatalf("expected the registration error to be returned, got %v", err)
+	}
+}
+
+func TestRegistryLoadPluginErrors(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.LoadPlugin(Plugin{Path: "acme.so"}); err == nil {
+		t.Fatal("expected an error without a format")
+	}
+	if err := registry.LoadPlugin(Plugin{Path: t.TempDir() + "/missing.so", Format: "acme"}); err == nil {
+		t.Fatal("expected an error for a missing plugin file")
+	}
+}
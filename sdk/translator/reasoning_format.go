@@ -0,0 +1,140 @@
+package translator
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Reasoning formats for OpenAI Chat Completions responses.
+const (
+	// ReasoningFormatSeparate keeps thinking in the reasoning_content field (default).
+	ReasoningFormatSeparate = ""
+	// ReasoningFormatThinkTags moves thinking inline into content wrapped in <think> tags.
+	ReasoningFormatThinkTags = "think-tags"
+	// ReasoningFormatStrip removes thinking from the response.
+	ReasoningFormatStrip = "strip"
+
+	thinkOpenTag  = "<think>"
+	thinkCloseTag = "</think>\n\n"
+)
+
+type reasoningFormatKey struct{}
+
+// reasoningFormatState tracks which choices have an open <think> tag across stream chunks.
+type reasoningFormatState struct {
+	format string
+	mu     sync.Mutex
+	open   map[int64]bool
+}
+
+// WithReasoningFormat returns a context that makes response translation to the OpenAI
+// Chat Completions format rewrite reasoning_content according to format. The context must
+// be used for a single response so streamed <think> tags are balanced.
+func WithReasoningFormat(ctx context.Context, format string) context.Context {
+	if format != ReasoningFormatThinkTags && format != ReasoningFormatStrip {
+		return ctx
+	}
+	return context.WithValue(ctx, reasoningFormatKey{}, &reasoningFormatState{format: format, open: make(map[int64]bool)})
+}
+
+func reasoningFormatFromContext(ctx context.Context, to Format) *reasoningFormatState {
+	if ctx == nil || to != FormatOpenAI {
+		return nil
+	}
+	state, _ := ctx.Value(reasoningFormatKey{}).(*reasoningFormatState)
+	return state
+}
+
+// rewriteReasoningChunks applies the reasoning format to translated stream chunks.
+func rewriteReasoningChunks(ctx context.Context, to Format, chunks [][]byte) [][]byte {
+	state := reasoningFormatFromContext(ctx, to)
+	if state == nil {
+		return chunks
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	for i, chunk := range chunks {
+		chunks[i] = state.rewriteChunk(chunk)
+	}
+	return chunks
+}
+
+// rewriteReasoningMessage applies the reasoning format to a translated non-stream response.
+func rewriteReasoningMessage(ctx context.Context, to Format, body []byte) []byte {
+	state := reasoningFormatFromContext(ctx, to)
+	if state == nil {
+		return body
+	}
+	choices := gjson.GetBytes(body, "choices")
+	if !choices.IsArray() {
+		return body
+	}
+	for i, choice := range choices.Array() {
+		reasoning := choice.Get("message.reasoning_content")
+		if !reasoning.Exists() {
+			continue
+		}
+		path := "choices." + strconv.Itoa(i) + ".message."
+		if text := reasoning.String(); text != "" && state.format == ReasoningFormatThinkTags {
+			body, _ = sjson.SetBytes(body, path+"content", thinkOpenTag+text+thinkCloseTag+choice.Get("message.content").String())
+		}
+		body, _ = sjson.DeleteBytes(body, path+"reasoning_content")
+	}
+	return body
+}
+
+func (s *reasoningFormatState) rewriteChunk(chunk []byte) []byte {
+	prefix, payload, suffix := splitSSEData(chunk)
+	choices := gjson.GetBytes(payload, "choices")
+	if !choices.IsArray() {
+		return chunk
+	}
+	for i, choice := range choices.Array() {
+		reasoning := choice.Get("delta.reasoning_content")
+		if !reasoning.Exists() && !s.open[choice.Get("index").Int()] {
+			continue
+		}
+		path := "choices." + strconv.Itoa(i) + ".delta."
+		if s.format == ReasoningFormatThinkTags {
+			index := choice.Get("index").Int()
+			var content string
+			if text := reasoning.String(); text != "" {
+				if !s.open[index] {
+					content = thinkOpenTag
+					s.open[index] = true
+				}
+				content += text
+			}
+			text := choice.Get("delta.content").String()
+			ends := text != "" || choice.Get("delta.tool_calls").IsArray() || choice.Get("finish_reason").String() != ""
+			if ends && s.open[index] {
+				content += thinkCloseTag
+				s.open[index] = false
+			}
+			if content != "" {
+				payload, _ = sjson.SetBytes(payload, path+"content", content+text)
+			}
+		}
+		payload, _ = sjson.DeleteBytes(payload, path+"reasoning_content")
+	}
+	if len(prefix) == 0 {
+		return payload
+	}
+	out := append(prefix, payload...)
+	return append(out, suffix...)
+}
+
+// splitSSEData separates an optional "data: " prefix and trailing newlines from a chunk's
+// JSON payload.
+func splitSSEData(chunk []byte) ([]byte, []byte, []byte) {
+	trimmed := bytes.TrimRight(chunk, "\r\n")
+	if data, ok := bytes.CutPrefix(trimmed, []byte("data:")); ok {
+		return []byte("data: "), bytes.TrimSpace(data), bytes.Clone(chunk[len(trimmed):])
+	}
+	return nil, chunk, nil
+}
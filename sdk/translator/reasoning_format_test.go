@@ -0,0 +1,60 @@
+package translator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestReasoningFormatThinkTagsStream(t *testing.T) {
+	r := NewRegistry()
+	ctx := WithReasoningFormat(context.Background(), ReasoningFormatThinkTags)
+	var param any
+
+	var content strings.Builder
+	for _, chunk := range []string{
+		`{"choices":[{"index":0,"delta":{"role":"assistant","reasoning_content":"let me "}}]}`,
+		`{"choices":[{"index":0,"delta":{"reasoning_content":"think"}}]}`,
+		"data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Answer\"}}]}\n\n",
+		`{"choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`,
+	} {
+		for _, out := range r.TranslateStream(ctx, FormatClaude, FormatOpenAI, "m", nil, nil, []byte(chunk), &param) {
+			payload := out
+			if data, ok := strings.CutPrefix(string(out), "data: "); ok {
+				if !strings.HasSuffix(data, "\n\n") {
+					t.Fatalf("SSE framing lost: %q", out)
+				}
+				payload = []byte(data)
+			}
+			if gjson.GetBytes(payload, "choices.0.delta.reasoning_content").Exists() {
+				t.Fatalf("reasoning_content not removed: %s", out)
+			}
+			content.WriteString(gjson.GetBytes(payload, "choices.0.delta.content").String())
+		}
+	}
+	if got, want := content.String(), "<think>let me think</think>\n\nAnswer"; got != want {
+		t.Fatalf("content = %q, want %q", got, want)
+	}
+}
+
+func TestReasoningFormatNonStream(t *testing.T) {
+	r := NewRegistry()
+	body := []byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"Answer","reasoning_content":"thinking"}}]}`)
+
+	out := r.TranslateNonStream(WithReasoningFormat(context.Background(), ReasoningFormatThinkTags), FormatClaude, FormatOpenAI, "m", nil, nil, body, nil)
+	if got := gjson.GetBytes(out, "choices.0.message.content").String(); got != "<think>thinking</think>\n\nAnswer" {
+		t.Fatalf("content = %q", got)
+	}
+
+	out = r.TranslateNonStream(WithReasoningFormat(context.Background(), ReasoningFormatStrip), FormatClaude, FormatOpenAI, "m", nil, nil, body, nil)
+	if gjson.GetBytes(out, "choices.0.message.reasoning_content").Exists() || gjson.GetBytes(out, "choices.0.message.content").String() != "Answer" {
+		t.Fatalf("strip mode output = %s", out)
+	}
+
+	out = r.TranslateNonStream(WithReasoningFormat(context.Background(), ReasoningFormatThinkTags), FormatClaude, FormatGemini, "m", nil, nil, body, nil)
+	if string(out) != string(body) {
+		t.Fatalf("non-OpenAI targets must be untouched: %s", out)
+	}
+}
@@ -1,7 +1,9 @@
 package translator
 
 import (
+	"cmp"
 	"context"
+	"slices"
 	"sync"
 
 	log "github.com/sirupsen/logrus"
@@ -14,6 +16,17 @@ type Registry struct {
 	mu        sync.RWMutex
 	requests  map[Format]map[Format]RequestTransform
 	responses map[Format]map[Format]ResponseTransform
+
+	// disabled and dialects hold runtime overrides that can be replaced without a rebuild.
+	disabled map[Format]map[Format]struct{}
+	dialects map[Format]map[Format]Dialect
+	// missingPair selects the handling of requests without a registered translator.
+	missingPair MissingPairMode
+	// plugins maps the formats registered by translator plugins to their plugin files.
+	plugins map[Format]string
+
+	requestMiddleware  []RequestMiddleware
+	responseMiddleware []ResponseMiddleware
 }
 
 // NewRegistry constructs an empty translator registry.
@@ -21,6 +34,9 @@ func NewRegistry() *Registry {
 	return &Registry{
 		requests:  make(map[Format]map[Format]RequestTransform),
 		responses: make(map[Format]map[Format]ResponseTransform),
+		disabled:  make(map[Format]map[Format]struct{}),
+		dialects:  make(map[Format]map[Format]Dialect),
+		plugins:   make(map[Format]string),
 	}
 }
 
@@ -42,19 +58,62 @@ func (r *Registry) Register(from, to Format, request RequestTransform, response
 	r.responses[from][to] = response
 }
 
+// Pairs lists the translator pairs registered in code, sorted by source and target format.
+// Configured dialects and disabled pairs are not reflected.
+func (r *Registry) Pairs() []Pair {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var pairs []Pair
+	for from, byTarget := range r.responses {
+		for to := range byTarget {
+			pairs = append(pairs, Pair{From: from, To: to})
+		}
+	}
+	slices.SortFunc(pairs, func(a, b Pair) int {
+		return cmp.Or(cmp.Compare(a.From, b.From), cmp.Compare(a.To, b.To))
+	})
+	return pairs
+}
+
 // TranslateRequest converts a payload between schemas, returning the original payload
 // if no translator is registered. When falling back to the original payload, the
 // "model" field is still updated to match the resolved model name so that
 // client-side prefixes (e.g. "copilot/gpt-5-mini") are not leaked upstream.
+// Callers that must not forward such payloads check the pair with CheckRequestPair first.
+// Request middleware registered with UseRequest runs around the conversion.
 func (r *Registry) TranslateRequest(from, to Format, model string, rawJSON []byte, stream bool) []byte {
+	req := RequestEnvelope{Format: from, Target: to, Model: model, Stream: stream, Body: rawJSON}
+	return r.applyRequestMiddleware(req, func(_ context.Context, in RequestEnvelope) (RequestEnvelope, error) {
+		in.Body = r.translateRequest(from, to, in.Model, in.Body, in.Stream)
+		in.Format = to
+		return in, nil
+	}).Body
+}
+
+func (r *Registry) translateRequest(from, to Format, model string, rawJSON []byte, stream bool) []byte {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	if byTarget, ok := r.requests[from]; ok {
-		if fn, isOk := byTarget[to]; isOk && fn != nil {
+	if !r.isDisabledLocked(from, to) {
+		if fn := r.requests[from][to]; fn != nil {
 			return fn(model, rawJSON, stream)
 		}
+		if dialect, ok := r.dialectLocked(from, to); ok {
+			out := rawJSON
+			if fn := r.requests[from][dialect.Base]; fn != nil && !r.isDisabledLocked(from, dialect.Base) {
+				out = fn(model, rawJSON, stream)
+			} else {
+				out = normalizeModel(model, rawJSON)
+			}
+			return dialect.Rewrite.Apply(out)
+		}
 	}
+	return normalizeModel(model, rawJSON)
+}
+
+// normalizeModel updates the "model" field to match the resolved model name.
+func normalizeModel(model string, rawJSON []byte) []byte {
 	if model != "" && gjson.GetBytes(rawJSON, "model").String() != model {
 		if updated, err := sjson.SetBytes(rawJSON, "model", model); err != nil {
 			log.Warnf("translator: failed to normalize model in request fallback: %v", err)
@@ -70,38 +129,91 @@ func (r *Registry) HasResponseTransformer(from, to Format) bool {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	if byTarget, ok := r.responses[from]; ok {
-		if _, isOk := byTarget[to]; isOk {
-			return true
-		}
+	_, ok := r.responseLocked(from, to)
+	return ok
+}
+
+// responseLocked resolves the response transform for a client/upstream pair,
+// honoring disabled pairs and falling back to the base pair of a dialect.
+// Callers must hold r.mu.
+func (r *Registry) responseLocked(client, upstream Format) (ResponseTransform, bool) {
+	if r.isDisabledLocked(client, upstream) {
+		return ResponseTransform{}, false
+	}
+	if fn, ok := r.responses[client][upstream]; ok {
+		return fn, true
+	}
+	if dialect, ok := r.dialectLocked(client, upstream); ok && !r.isDisabledLocked(client, dialect.Base) {
+		fn, okBase := r.responses[client][dialect.Base]
+		return fn, okBase
 	}
-	return false
+	return ResponseTransform{}, false
 }
 
 // TranslateStream applies the registered streaming response translator.
+// Response middleware registered with UseResponse runs around the conversion.
 func (r *Registry) TranslateStream(ctx context.Context, from, to Format, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) [][]byte {
+	resp := ResponseEnvelope{Format: from, Target: to, Model: model, Stream: true, Body: rawJSON}
+	return r.applyResponseMiddleware(ctx, resp, func(ctx context.Context, in ResponseEnvelope) (ResponseEnvelope, error) {
+		in.Chunks = r.translateStream(ctx, from, to, in.Model, originalRequestRawJSON, requestRawJSON, in.Body, param)
+		in.Format = to
+		return in, nil
+	}).Chunks
+}
+
+func (r *Registry) translateStream(ctx context.Context, from, to Format, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) [][]byte {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	if byTarget, ok := r.responses[to]; ok {
-		if fn, isOk := byTarget[from]; isOk && fn.Stream != nil {
-			return fn.Stream(ctx, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
-		}
+	rawJSON = serializeToolCalls(ctx, to, from, originalRequestRawJSON, rawJSON)
+	if fn, ok := r.responseLocked(to, from); ok && fn.Stream != nil {
+		return rewriteReasoningChunks(ctx, to, fn.Stream(ctx, model, originalRequestRawJSON, requestRawJSON, rawJSON, param))
+	}
+	return rewriteReasoningChunks(ctx, to, [][]byte{rawJSON})
+}
+
+// FinalizeStream runs the registered stream finalizer so the response terminates with
+// well-formed closing events. It must be called with the same param used for TranslateStream.
+// Response middleware sees the closing events as chunks of an envelope without a body.
+func (r *Registry) FinalizeStream(ctx context.Context, from, to Format, model string, originalRequestRawJSON, requestRawJSON []byte, param *any) [][]byte {
+	resp := ResponseEnvelope{Format: from, Target: to, Model: model, Stream: true}
+	return r.applyResponseMiddleware(ctx, resp, func(ctx context.Context, in ResponseEnvelope) (ResponseEnvelope, error) {
+		in.Chunks = r.finalizeStream(ctx, from, to, in.Model, originalRequestRawJSON, requestRawJSON, param)
+		in.Format = to
+		return in, nil
+	}).Chunks
+}
+
+func (r *Registry) finalizeStream(ctx context.Context, from, to Format, model string, originalRequestRawJSON, requestRawJSON []byte, param *any) [][]byte {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if fn, ok := r.responseLocked(to, from); ok && fn.Finalize != nil {
+		return rewriteReasoningChunks(ctx, to, fn.Finalize(ctx, model, originalRequestRawJSON, requestRawJSON, param))
 	}
-	return [][]byte{rawJSON}
+	return nil
 }
 
 // TranslateNonStream applies the registered non-stream response translator.
+// Response middleware registered with UseResponse runs around the conversion.
 func (r *Registry) TranslateNonStream(ctx context.Context, from, to Format, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) []byte {
+	resp := ResponseEnvelope{Format: from, Target: to, Model: model, Body: rawJSON}
+	return r.applyResponseMiddleware(ctx, resp, func(ctx context.Context, in ResponseEnvelope) (ResponseEnvelope, error) {
+		in.Body = r.translateNonStream(ctx, from, to, in.Model, originalRequestRawJSON, requestRawJSON, in.Body, param)
+		in.Format = to
+		return in, nil
+	}).Body
+}
+
+func (r *Registry) translateNonStream(ctx context.Context, from, to Format, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) []byte {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	if byTarget, ok := r.responses[to]; ok {
-		if fn, isOk := byTarget[from]; isOk && fn.NonStream != nil {
-			return fn.NonStream(ctx, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
-		}
+	rawJSON = serializeToolCalls(ctx, to, from, originalRequestRawJSON, rawJSON)
+	if fn, ok := r.responseLocked(to, from); ok && fn.NonStream != nil {
+		return rewriteReasoningMessage(ctx, to, fn.NonStream(ctx, model, originalRequestRawJSON, requestRawJSON, rawJSON, param))
 	}
-	return rawJSON
+	return rewriteReasoningMessage(ctx, to, rawJSON)
 }
 
 // TranslateTokenCount applies the registered token count response translator.
@@ -109,10 +221,8 @@ func (r *Registry) TranslateTokenCount(ctx context.Context, from, to Format, cou
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	if byTarget, ok := r.responses[to]; ok {
-		if fn, isOk := byTarget[from]; isOk && fn.TokenCount != nil {
-			return fn.TokenCount(ctx, count)
-		}
+	if fn, ok := r.responseLocked(to, from); ok && fn.TokenCount != nil {
+		return fn.TokenCount(ctx, count)
 	}
 	return rawJSON
 }
@@ -129,6 +239,16 @@ func Register(from, to Format, request RequestTransform, response ResponseTransf
 	defaultRegistry.Register(from, to, request, response)
 }
 
+// UseRequest adds request middleware to the default registry.
+func UseRequest(mw RequestMiddleware) {
+	defaultRegistry.UseRequest(mw)
+}
+
+// UseResponse adds response middleware to the default registry.
+func UseResponse(mw ResponseMiddleware) {
+	defaultRegistry.UseResponse(mw)
+}
+
 // TranslateRequest is a helper on the default registry.
 func TranslateRequest(from, to Format, model string, rawJSON []byte, stream bool) []byte {
 	return defaultRegistry.TranslateRequest(from, to, model, rawJSON, stream)
@@ -144,6 +264,11 @@ func TranslateStream(ctx context.Context, from, to Format, model string, origina
 	return defaultRegistry.TranslateStream(ctx, from, to, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
 }
 
+// FinalizeStream is a helper on the default registry.
+func FinalizeStream(ctx context.Context, from, to Format, model string, originalRequestRawJSON, requestRawJSON []byte, param *any) [][]byte {
+	return defaultRegistry.FinalizeStream(ctx, from, to, model, originalRequestRawJSON, requestRawJSON, param)
+}
+
 // TranslateNonStream is a helper on the default registry.
 func TranslateNonStream(ctx context.Context, from, to Format, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) []byte {
 	return defaultRegistry.TranslateNonStream(ctx, from, to, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
@@ -0,0 +1,589 @@
+package translator
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// AssembleStream folds the streaming chunks of a response in the client format into the
+// equivalent non-streaming response. It is used when a provider path can only stream.
+// Formats without a known stream shape return the last event unchanged.
+func AssembleStream(format Format, chunks [][]byte) []byte {
+	events := streamEvents(chunks)
+	if len(events) == 0 {
+		return nil
+	}
+	switch format {
+	case FormatOpenAI:
+		return assembleOpenAIChatStream(events)
+	case FormatClaude:
+		return assembleClaudeStream(events)
+	case FormatGemini:
+		return assembleGeminiStream(events)
+	case FormatOpenAIResponse:
+		return assembleResponsesStream(events)
+	default:
+		return []byte(events[len(events)-1].Raw)
+	}
+}
+
+// SynthesizeStream converts a non-streaming response in the client format into streaming
+// chunks shaped like the ones the handlers forward to clients. It is used when a provider
+// path has no streaming support.
+func SynthesizeStream(format Format, payload []byte) [][]byte {
+	return SynthesizeStreamChunked(format, payload, 0)
+}
+
+// SynthesizeStreamChunked is SynthesizeStream with text and reasoning split into deltas of at
+// most chunkSize runes, so clients render the response progressively. chunkSize <= 0 emits
+// each text in a single delta.
+func SynthesizeStreamChunked(format Format, payload []byte, chunkSize int) [][]byte {
+	if len(bytes.TrimSpace(payload)) == 0 || !gjson.ValidBytes(payload) {
+		return [][]byte{payload}
+	}
+	root := gjson.ParseBytes(payload)
+	switch format {
+	case FormatOpenAI:
+		if chunkSize <= 0 {
+			return [][]byte{synthesizeOpenAIChatChunk(root)}
+		}
+		return synthesizeOpenAIChatChunks(root, chunkSize)
+	case FormatClaude:
+		return synthesizeClaudeEvents(root, chunkSize)
+	case FormatOpenAIResponse:
+		return synthesizeResponsesEvents(root, chunkSize)
+	default:
+		return [][]byte{payload}
+	}
+}
+
+// splitText splits text into pieces of at most size runes. size <= 0 keeps it whole.
+func splitText(text string, size int) []string {
+	runes := []rune(text)
+	if size <= 0 || len(runes) <= size {
+		return []string{text}
+	}
+	pieces := make([]string, 0, (len(runes)+size-1)/size)
+	for start := 0; start < len(runes); start += size {
+		pieces = append(pieces, string(runes[min(start, len(runes)):min(start+size, len(runes))]))
+	}
+	return pieces
+}
+
+// streamEvents extracts the JSON events from bare JSON chunks or SSE framed chunks.
+func streamEvents(chunks [][]byte) []gjson.Result {
+	var events []gjson.Result
+	var sse bytes.Buffer
+	for _, chunk := range chunks {
+		trimmed := bytes.TrimSpace(chunk)
+		if len(trimmed) == 0 {
+			continue
+		}
+		if trimmed[0] == '{' && gjson.ValidBytes(trimmed) {
+			events = append(events, gjson.ParseBytes(trimmed))
+			continue
+		}
+		sse.Write(chunk)
+		sse.WriteByte('\n')
+	}
+	for _, line := range strings.Split(sse.String(), "\n") {
+		line = strings.TrimSpace(line)
+		data, ok := strings.CutPrefix(line, "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "" || data == "[DONE]" || !gjson.Valid(data) {
+			continue
+		}
+		events = append(events, gjson.Parse(data))
+	}
+	return events
+}
+
+func sseEvent(name, data string) []byte {
+	return []byte("event: " + name + "\ndata: " + data + "\n\n")
+}
+
+type openAIToolCallAccumulator struct {
+	id        string
+	name      string
+	arguments strings.Builder
+}
+
+type openAIChoiceAccumulator struct {
+	role         string
+	content      strings.Builder
+	reasoning    strings.Builder
+	finishReason string
+	toolCalls    map[int64]*openAIToolCallAccumulator
+}
+
+func assembleOpenAIChatStream(events []gjson.Result) []byte {
+	out := []byte(`{"id":"","object":"chat.completion","created":0,"model":"","choices":[]}`)
+	choices := make(map[int64]*openAIChoiceAccumulator)
+	for _, event := range events {
+		for _, field := range []string{"id", "model", "system_fingerprint"} {
+			if value := event.Get(field).String(); value != "" {
+				out, _ = sjson.SetBytes(out, field, value)
+			}
+		}
+		if created := event.Get("created").Int(); created > 0 {
+			out, _ = sjson.SetBytes(out, "created", created)
+		}
+		if usage := event.Get("usage"); usage.IsObject() {
+			out, _ = sjson.SetRawBytes(out, "usage", []byte(usage.Raw))
+		}
+		event.Get("choices").ForEach(func(_, choice gjson.Result) bool {
+			index := choice.Get("index").Int()
+			acc, ok := choices[index]
+			if !ok {
+				acc = &openAIChoiceAccumulator{toolCalls: make(map[int64]*openAIToolCallAccumulator)}
+				choices[index] = acc
+			}
+			delta := choice.Get("delta")
+			if role := delta.Get("role").String(); role != "" {
+				acc.role = role
+			}
+			acc.content.WriteString(delta.Get("content").String())
+			acc.reasoning.WriteString(delta.Get("reasoning_content").String())
+			delta.Get("tool_calls").ForEach(func(_, call gjson.Result) bool {
+				callIndex := call.Get("index").Int()
+				tc, exists := acc.toolCalls[callIndex]
+				if !exists {
+					tc = &openAIToolCallAccumulator{}
+					acc.toolCalls[callIndex] = tc
+				}
+				if id := call.Get("id").String(); id != "" {
+					tc.id = id
+				}
+				if name := call.Get("function.name").String(); name != "" {
+					tc.name = name
+				}
+				tc.arguments.WriteString(call.Get("function.arguments").String())
+				return true
+			})
+			if reason := choice.Get("finish_reason").String(); reason != "" {
+				acc.finishReason = reason
+			}
+			return true
+		})
+	}
+
+	for _, index := range sortedKeys(choices) {
+		acc := choices[index]
+		role := acc.role
+		if role == "" {
+			role = "assistant"
+		}
+		choice := []byte(`{"index":0,"message":{"role":""},"finish_reason":null}`)
+		choice, _ = sjson.SetBytes(choice, "index", index)
+		choice, _ = sjson.SetBytes(choice, "message.role", role)
+		if acc.content.Len() > 0 || len(acc.toolCalls) == 0 {
+			choice, _ = sjson.SetBytes(choice, "message.content", acc.content.String())
+		} else {
+			choice, _ = sjson.SetRawBytes(choice, "message.content", []byte("null"))
+		}
+		if acc.reasoning.Len() > 0 {
+			choice, _ = sjson.SetBytes(choice, "message.reasoning_content", acc.reasoning.String())
+		}
+		for _, callIndex := range sortedKeys(acc.toolCalls) {
+			tc := acc.toolCalls[callIndex]
+			call := []byte(`{"id":"","type":"function","function":{"name":"","arguments":""}}`)
+			call, _ = sjson.SetBytes(call, "id", tc.id)
+			call, _ = sjson.SetBytes(call, "function.name", tc.name)
+			call, _ = sjson.SetBytes(call, "function.arguments", tc.arguments.String())
+			choice, _ = sjson.SetRawBytes(choice, "message.tool_calls.-1", call)
+		}
+		if acc.finishReason != "" {
+			choice, _ = sjson.SetBytes(choice, "finish_reason", acc.finishReason)
+		}
+		out, _ = sjson.SetRawBytes(out, "choices.-1", choice)
+	}
+	return out
+}
+
+func synthesizeOpenAIChatChunk(root gjson.Result) []byte {
+	chunk := []byte(`{"id":"","object":"chat.completion.chunk","created":0,"model":"","choices":[]}`)
+	chunk, _ = sjson.SetBytes(chunk, "id", root.Get("id").String())
+	chunk, _ = sjson.SetBytes(chunk, "created", root.Get("created").Int())
+	chunk, _ = sjson.SetBytes(chunk, "model", root.Get("model").String())
+	if fingerprint := root.Get("system_fingerprint"); fingerprint.Exists() {
+		chunk, _ = sjson.SetRawBytes(chunk, "system_fingerprint", []byte(fingerprint.Raw))
+	}
+	root.Get("choices").ForEach(func(_, choice gjson.Result) bool {
+		message := choice.Get("message")
+		delta := []byte(`{"role":"assistant"}`)
+		if role := message.Get("role").String(); role != "" {
+			delta, _ = sjson.SetBytes(delta, "role", role)
+		}
+		if content := message.Get("content"); content.Type == gjson.String {
+			delta, _ = sjson.SetBytes(delta, "content", content.String())
+		}
+		if reasoning := message.Get("reasoning_content").String(); reasoning != "" {
+			delta, _ = sjson.SetBytes(delta, "reasoning_content", reasoning)
+		}
+		message.Get("tool_calls").ForEach(func(key, call gjson.Result) bool {
+			updated, errSet := sjson.SetBytes([]byte(call.Raw), "index", key.Int())
+			if errSet != nil {
+				updated = []byte(call.Raw)
+			}
+			delta, _ = sjson.SetRawBytes(delta, "tool_calls.-1", updated)
+			return true
+		})
+		item := []byte(`{"index":0,"delta":{},"finish_reason":null}`)
+		item, _ = sjson.SetBytes(item, "index", choice.Get("index").Int())
+		item, _ = sjson.SetRawBytes(item, "delta", delta)
+		if reason := choice.Get("finish_reason"); reason.Type == gjson.String {
+			item, _ = sjson.SetBytes(item, "finish_reason", reason.String())
+		}
+		chunk, _ = sjson.SetRawBytes(chunk, "choices.-1", item)
+		return true
+	})
+	if usage := root.Get("usage"); usage.IsObject() {
+		chunk, _ = sjson.SetRawBytes(chunk, "usage", []byte(usage.Raw))
+	}
+	return chunk
+}
+
+// synthesizeOpenAIChatChunks emits the reasoning and content of each choice as deltas of at
+// most chunkSize runes, followed by a final chunk with tool calls, finish reasons and usage.
+func synthesizeOpenAIChatChunks(root gjson.Result, chunkSize int) [][]byte {
+	base := []byte(`{"id":"","object":"chat.completion.chunk","created":0,"model":"","choices":[]}`)
+	base, _ = sjson.SetBytes(base, "id", root.Get("id").String())
+	base, _ = sjson.SetBytes(base, "created", root.Get("created").Int())
+	base, _ = sjson.SetBytes(base, "model", root.Get("model").String())
+	if fingerprint := root.Get("system_fingerprint"); fingerprint.Exists() {
+		base, _ = sjson.SetRawBytes(base, "system_fingerprint", []byte(fingerprint.Raw))
+	}
+	withChoice := func(index int64, delta []byte) []byte {
+		item := []byte(`{"index":0,"delta":{},"finish_reason":null}`)
+		item, _ = sjson.SetBytes(item, "index", index)
+		item, _ = sjson.SetRawBytes(item, "delta", delta)
+		chunk, _ := sjson.SetRawBytes(base, "choices.-1", item)
+		return chunk
+	}
+
+	var chunks [][]byte
+	final := base
+	root.Get("choices").ForEach(func(_, choice gjson.Result) bool {
+		index := choice.Get("index").Int()
+		message := choice.Get("message")
+		role := message.Get("role").String()
+		if role == "" {
+			role = "assistant"
+		}
+		roleSent := false
+		emit := func(field, text string) {
+			for _, piece := range splitText(text, chunkSize) {
+				delta, _ := sjson.SetBytes([]byte(`{}`), field, piece)
+				if !roleSent {
+					delta, _ = sjson.SetBytes(delta, "role", role)
+					roleSent = true
+				}
+				chunks = append(chunks, withChoice(index, delta))
+			}
+		}
+		if reasoning := message.Get("reasoning_content").String(); reasoning != "" {
+			emit("reasoning_content", reasoning)
+		}
+		if content := message.Get("content"); content.Type == gjson.String && content.String() != "" {
+			emit("content", content.String())
+		}
+
+		delta := []byte(`{}`)
+		if !roleSent {
+			delta, _ = sjson.SetBytes(delta, "role", role)
+		}
+		message.Get("tool_calls").ForEach(func(key, call gjson.Result) bool {
+			updated, errSet := sjson.SetBytes([]byte(call.Raw), "index", key.Int())
+			if errSet != nil {
+				updated = []byte(call.Raw)
+			}
+			delta, _ = sjson.SetRawBytes(delta, "tool_calls.-1", updated)
+			return true
+		})
+		item := []byte(`{"index":0,"delta":{},"finish_reason":null}`)
+		item, _ = sjson.SetBytes(item, "index", index)
+		item, _ = sjson.SetRawBytes(item, "delta", delta)
+		if reason := choice.Get("finish_reason"); reason.Type == gjson.String {
+			item, _ = sjson.SetBytes(item, "finish_reason", reason.String())
+		}
+		final, _ = sjson.SetRawBytes(final, "choices.-1", item)
+		return true
+	})
+	if usage := root.Get("usage"); usage.IsObject() {
+		final, _ = sjson.SetRawBytes(final, "usage", []byte(usage.Raw))
+	}
+	return append(chunks, final)
+}
+
+type claudeBlockAccumulator struct {
+	raw       []byte
+	text      strings.Builder
+	thinking  strings.Builder
+	signature string
+	inputJSON strings.Builder
+}
+
+func assembleClaudeStream(events []gjson.Result) []byte {
+	out := []byte(`{"type":"message","role":"assistant","content":[]}`)
+	blocks := make(map[int64]*claudeBlockAccumulator)
+	for _, event := range events {
+		switch event.Get("type").String() {
+		case "message_start":
+			if message := event.Get("message"); message.IsObject() {
+				out = []byte(message.Raw)
+			}
+		case "content_block_start":
+			blocks[event.Get("index").Int()] = &claudeBlockAccumulator{raw: []byte(event.Get("content_block").Raw)}
+		case "content_block_delta":
+			block, ok := blocks[event.Get("index").Int()]
+			if !ok {
+				continue
+			}
+			delta := event.Get("delta")
+			switch delta.Get("type").String() {
+			case "text_delta":
+				block.text.WriteString(delta.Get("text").String())
+			case "thinking_delta":
+				block.thinking.WriteString(delta.Get("thinking").String())
+			case "signature_delta":
+				block.signature = delta.Get("signature").String()
+			case "input_json_delta":
+				block.inputJSON.WriteString(delta.Get("partial_json").String())
+			}
+		case "message_delta":
+			delta := event.Get("delta")
+			for _, field := range []string{"stop_reason", "stop_sequence"} {
+				if value := delta.Get(field); value.Exists() {
+					out, _ = sjson.SetRawBytes(out, field, []byte(value.Raw))
+				}
+			}
+			event.Get("usage").ForEach(func(key, value gjson.Result) bool {
+				out, _ = sjson.SetRawBytes(out, "usage."+key.String(), []byte(value.Raw))
+				return true
+			})
+		}
+	}
+
+	out, _ = sjson.SetRawBytes(out, "content", []byte("[]"))
+	for _, index := range sortedKeys(blocks) {
+		block := blocks[index]
+		raw := block.raw
+		switch gjson.GetBytes(raw, "type").String() {
+		case "text":
+			raw, _ = sjson.SetBytes(raw, "text", gjson.GetBytes(raw, "text").String()+block.text.String())
+		case "thinking":
+			raw, _ = sjson.SetBytes(raw, "thinking", gjson.GetBytes(raw, "thinking").String()+block.thinking.String())
+			if block.signature != "" {
+				raw, _ = sjson.SetBytes(raw, "signature", block.signature)
+			}
+		case "tool_use", "server_tool_use":
+			if input := strings.TrimSpace(block.inputJSON.String()); input != "" && gjson.Valid(input) {
+				raw, _ = sjson.SetRawBytes(raw, "input", []byte(input))
+			}
+		}
+		out, _ = sjson.SetRawBytes(out, "content.-1", raw)
+	}
+	return out
+}
+
+func synthesizeClaudeEvents(root gjson.Result, chunkSize int) [][]byte {
+	start, _ := sjson.SetRawBytes([]byte(root.Raw), "content", []byte("[]"))
+	start, _ = sjson.SetRawBytes(start, "stop_reason", []byte("null"))
+	start, _ = sjson.SetRawBytes(start, "stop_sequence", []byte("null"))
+	startEvent, _ := sjson.SetRawBytes([]byte(`{"type":"message_start"}`), "message", start)
+	events := [][]byte{sseEvent("message_start", string(startEvent))}
+
+	root.Get("content").ForEach(func(key, block gjson.Result) bool {
+		index := key.Int()
+		blockType := block.Get("type").String()
+		initial := []byte(block.Raw)
+		var deltas [][]byte
+		switch blockType {
+		case "text":
+			initial, _ = sjson.SetBytes(initial, "text", "")
+			for _, piece := range splitText(block.Get("text").String(), chunkSize) {
+				delta, _ := sjson.SetBytes([]byte(`{"type":"text_delta"}`), "text", piece)
+				deltas = append(deltas, delta)
+			}
+		case "thinking":
+			initial, _ = sjson.SetBytes(initial, "thinking", "")
+			initial, _ = sjson.DeleteBytes(initial, "signature")
+			for _, piece := range splitText(block.Get("thinking").String(), chunkSize) {
+				delta, _ := sjson.SetBytes([]byte(`{"type":"thinking_delta"}`), "thinking", piece)
+				deltas = append(deltas, delta)
+			}
+			if signature := block.Get("signature").String(); signature != "" {
+				sigDelta, _ := sjson.SetBytes([]byte(`{"type":"signature_delta"}`), "signature", signature)
+				deltas = append(deltas, sigDelta)
+			}
+		case "tool_use", "server_tool_use":
+			initial, _ = sjson.SetRawBytes(initial, "input", []byte("{}"))
+			if input := block.Get("input"); input.Exists() {
+				delta, _ := sjson.SetBytes([]byte(`{"type":"input_json_delta"}`), "partial_json", input.Raw)
+				deltas = append(deltas, delta)
+			}
+		}
+		blockStart := []byte(fmt.Sprintf(`{"type":"content_block_start","index":%d}`, index))
+		blockStart, _ = sjson.SetRawBytes(blockStart, "content_block", initial)
+		events = append(events, sseEvent("content_block_start", string(blockStart)))
+		for _, delta := range deltas {
+			event := []byte(fmt.Sprintf(`{"type":"content_block_delta","index":%d}`, index))
+			event, _ = sjson.SetRawBytes(event, "delta", delta)
+			events = append(events, sseEvent("content_block_delta", string(event)))
+		}
+		events = append(events, sseEvent("content_block_stop", fmt.Sprintf(`{"type":"content_block_stop","index":%d}`, index)))
+		return true
+	})
+
+	messageDelta := []byte(`{"type":"message_delta","delta":{"stop_reason":null,"stop_sequence":null},"usage":{}}`)
+	for _, field := range []string{"stop_reason", "stop_sequence"} {
+		if value := root.Get(field); value.Exists() {
+			messageDelta, _ = sjson.SetRawBytes(messageDelta, "delta."+field, []byte(value.Raw))
+		}
+	}
+	if usage := root.Get("usage"); usage.IsObject() {
+		messageDelta, _ = sjson.SetRawBytes(messageDelta, "usage", []byte(usage.Raw))
+	}
+	events = append(events, sseEvent("message_delta", string(messageDelta)))
+	events = append(events, sseEvent("message_stop", `{"type":"message_stop"}`))
+	return events
+}
+
+func assembleGeminiStream(events []gjson.Result) []byte {
+	out := []byte(events[len(events)-1].Raw)
+	var parts [][]byte
+	finishReason := ""
+	for _, event := range events {
+		candidate := event.Get("candidates.0")
+		if reason := candidate.Get("finishReason").String(); reason != "" {
+			finishReason = reason
+		}
+		candidate.Get("content.parts").ForEach(func(_, part gjson.Result) bool {
+			text := part.Get("text")
+			if last := len(parts) - 1; last >= 0 && text.Exists() {
+				previous := gjson.ParseBytes(parts[last])
+				if previous.Get("text").Exists() && previous.Get("thought").Bool() == part.Get("thought").Bool() {
+					merged, _ := sjson.SetBytes(parts[last], "text", previous.Get("text").String()+text.String())
+					if signature := part.Get("thoughtSignature").String(); signature != "" {
+						merged, _ = sjson.SetBytes(merged, "thoughtSignature", signature)
+					}
+					parts[last] = merged
+					return true
+				}
+			}
+			parts = append(parts, []byte(part.Raw))
+			return true
+		})
+	}
+	if !gjson.GetBytes(out, "candidates.0").Exists() {
+		out, _ = sjson.SetRawBytes(out, "candidates.0", []byte(`{"content":{"role":"model"}}`))
+	}
+	out, _ = sjson.SetRawBytes(out, "candidates.0.content.parts", []byte("[]"))
+	out, _ = sjson.SetBytes(out, "candidates.0.content.role", "model")
+	for _, part := range parts {
+		out, _ = sjson.SetRawBytes(out, "candidates.0.content.parts.-1", part)
+	}
+	if finishReason != "" {
+		out, _ = sjson.SetBytes(out, "candidates.0.finishReason", finishReason)
+	}
+	return out
+}
+
+func assembleResponsesStream(events []gjson.Result) []byte {
+	var last []byte
+	for _, event := range events {
+		response := event.Get("response")
+		if !response.IsObject() {
+			continue
+		}
+		switch event.Get("type").String() {
+		case "response.completed", "response.incomplete", "response.failed":
+			return []byte(response.Raw)
+		}
+		last = []byte(response.Raw)
+	}
+	return last
+}
+
+func synthesizeResponsesEvents(root gjson.Result, chunkSize int) [][]byte {
+	sequence := 0
+	next := func(name string, event []byte) []byte {
+		event, _ = sjson.SetBytes(event, "type", name)
+		event, _ = sjson.SetBytes(event, "sequence_number", sequence)
+		sequence++
+		return sseEvent(name, string(event))
+	}
+
+	inProgress, _ := sjson.SetRawBytes([]byte(root.Raw), "output", []byte("[]"))
+	inProgress, _ = sjson.SetBytes(inProgress, "status", "in_progress")
+	created, _ := sjson.SetRawBytes([]byte(`{}`), "response", inProgress)
+	events := [][]byte{next("response.created", created)}
+
+	root.Get("output").ForEach(func(key, item gjson.Result) bool {
+		outputIndex := key.Int()
+		added, _ := sjson.SetRawBytes([]byte(`{}`), "item", []byte(item.Raw))
+		added, _ = sjson.SetBytes(added, "output_index", outputIndex)
+		events = append(events, next("response.output_item.added", added))
+		itemID := item.Get("id").String()
+		switch item.Get("type").String() {
+		case "message":
+			item.Get("content").ForEach(func(partKey, part gjson.Result) bool {
+				if part.Get("type").String() != "output_text" {
+					return true
+				}
+				ref := []byte(`{}`)
+				ref, _ = sjson.SetBytes(ref, "item_id", itemID)
+				ref, _ = sjson.SetBytes(ref, "output_index", outputIndex)
+				ref, _ = sjson.SetBytes(ref, "content_index", partKey.Int())
+				for _, piece := range splitText(part.Get("text").String(), chunkSize) {
+					delta, _ := sjson.SetBytes(ref, "delta", piece)
+					events = append(events, next("response.output_text.delta", delta))
+				}
+				done, _ := sjson.SetBytes(ref, "text", part.Get("text").String())
+				events = append(events, next("response.output_text.done", done))
+				return true
+			})
+		case "function_call":
+			delta := []byte(`{}`)
+			delta, _ = sjson.SetBytes(delta, "item_id", itemID)
+			delta, _ = sjson.SetBytes(delta, "output_index", outputIndex)
+			delta, _ = sjson.SetBytes(delta, "delta", item.Get("arguments").String())
+			events = append(events, next("response.function_call_arguments.delta", delta))
+			done, _ := sjson.DeleteBytes(delta, "delta")
+			done, _ = sjson.SetBytes(done, "arguments", item.Get("arguments").String())
+			events = append(events, next("response.function_call_arguments.done", done))
+		}
+		itemDone, _ := sjson.SetRawBytes([]byte(`{}`), "item", []byte(item.Raw))
+		itemDone, _ = sjson.SetBytes(itemDone, "output_index", outputIndex)
+		events = append(events, next("response.output_item.done", itemDone))
+		return true
+	})
+
+	name := "response.completed"
+	switch root.Get("status").String() {
+	case "incomplete":
+		name = "response.incomplete"
+	case "failed":
+		name = "response.failed"
+	}
+	completed, _ := sjson.SetRawBytes([]byte(`{}`), "response", []byte(root.Raw))
+	events = append(events, next(name, completed))
+	return events
+}
+
+func sortedKeys[V any](m map[int64]V) []int64 {
+	keys := make([]int64, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
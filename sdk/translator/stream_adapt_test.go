@@ -0,0 +1,99 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestAssembleStreamOpenAIChat(t *testing.T) {
+	chunks := [][]byte{
+		[]byte(`{"id":"c1","object":"chat.completion.chunk","created":5,"model":"m","choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}`),
+		[]byte(`{"id":"c1","choices":[{"index":0,"delta":{"content":"lo","tool_calls":[{"index":0,"id":"call_1","function":{"name":"f","arguments":"{\"a\":"}}]}}]}`),
+		[]byte(`{"id":"c1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"1}"}}]},"finish_reason":"tool_calls"}],"usage":{"total_tokens":7}}`),
+	}
+	out := gjson.ParseBytes(AssembleStream(FormatOpenAI, chunks))
+	if out.Get("object").String() != "chat.completion" || out.Get("created").Int() != 5 {
+		t.Fatalf("unexpected envelope: %s", out.Raw)
+	}
+	if got := out.Get("choices.0.message.content").String(); got != "Hello" {
+		t.Fatalf("content = %q", got)
+	}
+	if got := out.Get("choices.0.message.tool_calls.0.function.arguments").String(); got != `{"a":1}` {
+		t.Fatalf("arguments = %q", got)
+	}
+	if out.Get("choices.0.finish_reason").String() != "tool_calls" || out.Get("usage.total_tokens").Int() != 7 {
+		t.Fatalf("unexpected finish or usage: %s", out.Raw)
+	}
+}
+
+func TestClaudeStreamRoundTrip(t *testing.T) {
+	message := []byte(`{"id":"msg_1","type":"message","role":"assistant","model":"claude","content":[{"type":"thinking","thinking":"hmm","signature":"sig"},{"type":"text","text":"Hi there"},{"type":"tool_use","id":"tu_1","name":"lookup","input":{"q":"x"}}],"stop_reason":"tool_use","stop_sequence":null,"usage":{"input_tokens":3,"output_tokens":9}}`)
+	events := SynthesizeStream(FormatClaude, message)
+	if len(events) != 13 {
+		t.Fatalf("event count = %d, want 13", len(events))
+	}
+	out := gjson.ParseBytes(AssembleStream(FormatClaude, events))
+	if out.Get("content.0.signature").String() != "sig" || out.Get("content.1.text").String() != "Hi there" {
+		t.Fatalf("unexpected content: %s", out.Get("content").Raw)
+	}
+	if out.Get("content.2.input.q").String() != "x" || out.Get("stop_reason").String() != "tool_use" {
+		t.Fatalf("unexpected tool use or stop reason: %s", out.Raw)
+	}
+	if out.Get("usage.output_tokens").Int() != 9 {
+		t.Fatalf("usage = %s", out.Get("usage").Raw)
+	}
+}
+
+func TestResponsesStreamRoundTrip(t *testing.T) {
+	response := []byte(`{"id":"resp_1","object":"response","status":"completed","output":[{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"output_text","text":"done"}]},{"id":"fc_1","type":"function_call","name":"f","arguments":"{}","call_id":"c1"}],"usage":{"total_tokens":4}}`)
+	events := SynthesizeStream(FormatOpenAIResponse, response)
+	first := gjson.Parse(string(events[0][len("event: response.created\ndata: "):]))
+	if first.Get("response.status").String() != "in_progress" || first.Get("response.output.#").Int() != 0 {
+		t.Fatalf("unexpected created event: %s", events[0])
+	}
+	out := AssembleStream(FormatOpenAIResponse, events)
+	if gjson.GetBytes(out, "id").String() != "resp_1" || gjson.GetBytes(out, "output.#").Int() != 2 {
+		t.Fatalf("assembled response = %s", out)
+	}
+}
+
+func TestAssembleStreamGeminiMergesText(t *testing.T) {
+	chunks := [][]byte{
+		[]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"think","thought":true}]}}]}`),
+		[]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"A"}]}}]}`),
+		[]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"B"},{"functionCall":{"name":"f","args":{}}}]},"finishReason":"STOP"}],"usageMetadata":{"totalTokenCount":3}}`),
+	}
+	out := gjson.ParseBytes(AssembleStream(FormatGemini, chunks))
+	parts := out.Get("candidates.0.content.parts")
+	if parts.Get("#").Int() != 3 || parts.Get("1.text").String() != "AB" || parts.Get("2.functionCall.name").String() != "f" {
+		t.Fatalf("parts = %s", parts.Raw)
+	}
+	if out.Get("candidates.0.finishReason").String() != "STOP" || out.Get("usageMetadata.totalTokenCount").Int() != 3 {
+		t.Fatalf("unexpected result: %s", out.Raw)
+	}
+}
+
+func TestSynthesizeStreamChunkedRoundTrip(t *testing.T) {
+	cases := []struct {
+		name    string
+		format  Format
+		payload string
+		path    string
+		extra   int
+	}{
+		{"openai", FormatOpenAI, `{"id":"c1","object":"chat.completion","created":1,"model":"m","choices":[{"index":0,"message":{"role":"assistant","reasoning_content":"ab","content":"héllo"},"finish_reason":"stop"}],"usage":{"total_tokens":3}}`, "choices.0.message.content", 4},
+		{"claude", FormatClaude, `{"id":"msg_1","type":"message","role":"assistant","model":"claude","content":[{"type":"text","text":"héllo"}],"stop_reason":"end_turn","usage":{"output_tokens":2}}`, "content.0.text", 2},
+		{"responses", FormatOpenAIResponse, `{"id":"resp_1","object":"response","status":"completed","output":[{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"output_text","text":"héllo"}]}]}`, "output.0.content.0.text", 2},
+	}
+	for _, tc := range cases {
+		whole := SynthesizeStream(tc.format, []byte(tc.payload))
+		chunked := SynthesizeStreamChunked(tc.format, []byte(tc.payload), 2)
+		if got := len(chunked) - len(whole); got != tc.extra {
+			t.Errorf("%s: extra chunks = %d, want %d", tc.name, got, tc.extra)
+		}
+		if got := gjson.GetBytes(AssembleStream(tc.format, chunked), tc.path).String(); got != "héllo" {
+			t.Errorf("%s: assembled text = %q", tc.name, got)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package translator
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// StreamContinuation describes how an interrupted stream in the client format can be resumed by
+// replaying the text forwarded so far as an assistant prefix. Only OpenAI chat and Claude
+// streams whose forwarded output is plain text can be continued.
+type StreamContinuation struct {
+	format Format
+	// text is the assistant text the client already received.
+	text string
+	// id is the response id of the interrupted stream, reused for continued OpenAI chunks.
+	id string
+	// openIndex is the index of the Claude text block that was still open, or -1.
+	openIndex int64
+	// nextIndex is the first Claude block index not used by the interrupted stream.
+	nextIndex int64
+	// started is set once the continuation delivered its first text.
+	started bool
+}
+
+// NewStreamContinuation inspects the chunks forwarded before a stream broke off. It returns
+// false when the stream cannot be continued: unsupported format, no text yet, tool calls or
+// reasoning in the output, or a stream that had already finished.
+func NewStreamContinuation(format Format, forwarded [][]byte) (*StreamContinuation, bool) {
+	events := streamEvents(forwarded)
+	if len(events) == 0 {
+		return nil, false
+	}
+	c := &StreamContinuation{format: format, openIndex: -1}
+	switch format {
+	case FormatOpenAI:
+		assembled := gjson.ParseBytes(assembleOpenAIChatStream(events))
+		choices := assembled.Get("choices").Array()
+		if len(choices) != 1 {
+			return nil, false
+		}
+		message := choices[0].Get("message")
+		if choices[0].Get("finish_reason").String() != "" || message.Get("tool_calls").Exists() || message.Get("reasoning_content").String() != "" {
+			return nil, false
+		}
+		c.text = message.Get("content").String()
+		c.id = assembled.Get("id").String()
+	case FormatClaude:
+		stopped := make(map[int64]bool)
+		for _, event := range events {
+			switch event.Get("type").String() {
+			case "content_block_start":
+				if event.Get("content_block.type").String() != "text" {
+					return nil, false
+				}
+				index := event.Get("index").Int()
+				c.openIndex = index
+				c.nextIndex = max(c.nextIndex, index+1)
+			case "content_block_stop":
+				stopped[event.Get("index").Int()] = true
+			case "message_delta", "message_stop":
+				return nil, false
+			}
+		}
+		if stopped[c.openIndex] {
+			c.openIndex = -1
+		}
+		assembled := gjson.ParseBytes(assembleClaudeStream(events))
+		var text strings.Builder
+		assembled.Get("content").ForEach(func(_, block gjson.Result) bool {
+			text.WriteString(block.Get("text").String())
+			return true
+		})
+		c.text = text.String()
+	default:
+		return nil, false
+	}
+	if strings.TrimSpace(c.text) == "" {
+		return nil, false
+	}
+	return c, true
+}
+
+// Text returns the assistant text forwarded before the interruption.
+func (c *StreamContinuation) Text() string {
+	return c.text
+}
+
+// prefix is the text replayed upstream. Trailing whitespace is dropped because providers such as
+// Claude reject an assistant prefix that ends with it.
+func (c *StreamContinuation) prefix() string {
+	return strings.TrimRightFunc(c.text, unicode.IsSpace)
+}
+
+// ContinueRequest appends the forwarded text to the client request payload as an assistant
+// prefix, extending a trailing assistant message when there is one.
+func (c *StreamContinuation) ContinueRequest(payload []byte) ([]byte, bool) {
+	messages := gjson.GetBytes(payload, "messages")
+	if !messages.IsArray() {
+		return nil, false
+	}
+	prefix := c.prefix()
+	items := messages.Array()
+	if len(items) > 0 && items[len(items)-1].Get("role").String() == "assistant" {
+		last := items[len(items)-1]
+		path := "messages." + strconv.Itoa(len(items)-1) + ".content"
+		content := last.Get("content")
+		var out []byte
+		var errSet error
+		switch {
+		case content.Type == gjson.String:
+			out, errSet = sjson.SetBytes(payload, path, content.String()+prefix)
+		case content.IsArray():
+			out, errSet = sjson.SetRawBytes(payload, path+".-1", textPart(prefix))
+		default:
+			return nil, false
+		}
+		return out, errSet == nil
+	}
+	message := []byte(`{"role":"assistant","content":""}`)
+	message, _ = sjson.SetBytes(message, "content", prefix)
+	out, errSet := sjson.SetRawBytes(payload, "messages.-1", message)
+	return out, errSet == nil
+}
+
+func textPart(text string) []byte {
+	part, _ := sjson.SetBytes([]byte(`{"type":"text","text":""}`), "text", text)
+	return part
+}
+
+// Splice rewrites a chunk of the continuation stream so that it extends the interrupted one.
+// It returns nil for chunks the client must not see again, such as a second message start.
+func (c *StreamContinuation) Splice(chunk []byte) []byte {
+	switch c.format {
+	case FormatOpenAI:
+		return c.spliceOpenAI(chunk)
+	case FormatClaude:
+		return c.spliceClaude(chunk)
+	default:
+		return chunk
+	}
+}
+
+// trimLeading drops whitespace the client already received at the end of the replayed text from
+// the first continued delta.
+func (c *StreamContinuation) trimLeading(text string) string {
+	if c.started {
+		return text
+	}
+	if text != "" {
+		c.started = true
+	}
+	if c.prefix() != c.text {
+		return strings.TrimLeftFunc(text, unicode.IsSpace)
+	}
+	return text
+}
+
+func (c *StreamContinuation) spliceOpenAI(chunk []byte) []byte {
+	trimmed := bytes.TrimSpace(chunk)
+	framed := false
+	if data, ok := bytes.CutPrefix(trimmed, []byte("data:")); ok {
+		trimmed = bytes.TrimSpace(data)
+		framed = true
+	}
+	if !gjson.ValidBytes(trimmed) {
+		return chunk
+	}
+	out := trimmed
+	keep := gjson.GetBytes(out, "usage").IsObject()
+	gjson.GetBytes(out, "choices").ForEach(func(key, choice gjson.Result) bool {
+		base := "choices." + key.String()
+		out, _ = sjson.DeleteBytes(out, base+".delta.role")
+		if content := choice.Get("delta.content"); content.Type == gjson.String {
+			text := c.trimLeading(content.String())
+			out, _ = sjson.SetBytes(out, base+".delta.content", text)
+			keep = keep || text != ""
+		}
+		if choice.Get("delta.tool_calls").Exists() || choice.Get("delta.reasoning_content").String() != "" {
+			keep = true
+		}
+		if choice.Get("finish_reason").String() != "" {
+			keep = true
+		}
+		return true
+	})
+	if !keep {
+		return nil
+	}
+	if c.id != "" {
+		out, _ = sjson.SetBytes(out, "id", c.id)
+	}
+	if framed {
+		return append([]byte("data: "), out...)
+	}
+	return out
+}
+
+func (c *StreamContinuation) spliceClaude(chunk []byte) []byte {
+	var out bytes.Buffer
+	for _, block := range bytes.Split(chunk, []byte("\n\n")) {
+		var name, data string
+		for _, line := range strings.Split(string(block), "\n") {
+			line = strings.TrimSpace(line)
+			if value, ok := strings.CutPrefix(line, "event:"); ok {
+				name = strings.TrimSpace(value)
+			} else if value, ok := strings.CutPrefix(line, "data:"); ok {
+				data = strings.TrimSpace(value)
+			}
+		}
+		if data == "" || !gjson.Valid(data) {
+			continue
+		}
+		event := gjson.Parse(data)
+		if name == "" {
+			name = event.Get("type").String()
+		}
+		rewritten, keep := c.spliceClaudeEvent(name, event)
+		if keep {
+			out.Write(sseEvent(name, rewritten))
+		}
+	}
+	if out.Len() == 0 {
+		return nil
+	}
+	return out.Bytes()
+}
+
+func (c *StreamContinuation) spliceClaudeEvent(name string, event gjson.Result) (string, bool) {
+	raw := event.Raw
+	switch name {
+	case "message_start":
+		return "", false
+	case "content_block_start", "content_block_delta", "content_block_stop":
+		index := event.Get("index").Int()
+		mapped := c.nextIndex + index
+		if c.openIndex >= 0 {
+			if index == 0 {
+				mapped = c.openIndex
+			} else {
+				mapped = c.nextIndex + index - 1
+			}
+		}
+		if name == "content_block_start" && index == 0 && c.openIndex >= 0 {
+			return "", false
+		}
+		raw, _ = sjson.Set(raw, "index", mapped)
+		if name == "content_block_delta" && event.Get("delta.type").String() == "text_delta" {
+			raw, _ = sjson.Set(raw, "delta.text", c.trimLeading(event.Get("delta.text").String()))
+		}
+	}
+	return raw, true
+}
@@ -0,0 +1,71 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestStreamContinuationClaudeSplicesOpenBlock(t *testing.T) {
+	forwarded := [][]byte{
+		sseEvent("message_start", `{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[],"model":"m"}}`),
+		sseEvent("content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`),
+		sseEvent("content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Once upon "}}`),
+	}
+	continuation, ok := NewStreamContinuation(FormatClaude, forwarded)
+	if !ok {
+		t.Fatal("expected the stream to be resumable")
+	}
+
+	payload, ok := continuation.ContinueRequest([]byte(`{"messages":[{"role":"user","content":"story"}]}`))
+	if !ok {
+		t.Fatal("ContinueRequest failed")
+	}
+	if got := gjson.GetBytes(payload, "messages.1.content").String(); got != "Once upon" {
+		t.Fatalf("assistant prefix = %q", got)
+	}
+
+	resumed := [][]byte{
+		sseEvent("message_start", `{"type":"message_start","message":{"id":"msg_2","type":"message","role":"assistant","content":[],"model":"m"}}`),
+		sseEvent("content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`),
+		sseEvent("content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":" a time"}}`),
+		sseEvent("content_block_stop", `{"type":"content_block_stop","index":0}`),
+		sseEvent("message_delta", `{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":3}}`),
+		sseEvent("message_stop", `{"type":"message_stop"}`),
+	}
+	all := append([][]byte{}, forwarded...)
+	for _, chunk := range resumed {
+		if spliced := continuation.Splice(chunk); spliced != nil {
+			all = append(all, spliced)
+		}
+	}
+	if len(all) != len(forwarded)+4 {
+		t.Fatalf("chunks = %d, want the second message and block starts dropped", len(all))
+	}
+	assembled := AssembleStream(FormatClaude, all)
+	if got := gjson.GetBytes(assembled, "content.#").Int(); got != 1 {
+		t.Fatalf("content blocks = %d, payload %s", got, assembled)
+	}
+	if got := gjson.GetBytes(assembled, "content.0.text").String(); got != "Once upon a time" {
+		t.Fatalf("text = %q", got)
+	}
+	if got := gjson.GetBytes(assembled, "id").String(); got != "msg_1" {
+		t.Fatalf("id = %q", got)
+	}
+}
+
+func TestNewStreamContinuationRejectsUnresumableStreams(t *testing.T) {
+	cases := map[string][][]byte{
+		"tool call": {[]byte(`{"id":"c1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"t","function":{"name":"f","arguments":""}}]}}]}`)},
+		"finished":  {[]byte(`{"id":"c1","choices":[{"index":0,"delta":{"content":"hi"},"finish_reason":"stop"}]}`)},
+		"no text":   {[]byte(`{"id":"c1","choices":[{"index":0,"delta":{"role":"assistant","content":""}}]}`)},
+	}
+	for name, forwarded := range cases {
+		if _, ok := NewStreamContinuation(FormatOpenAI, forwarded); ok {
+			t.Errorf("%s: expected the stream to be rejected", name)
+		}
+	}
+	if _, ok := NewStreamContinuation(FormatGemini, [][]byte{[]byte(`{"candidates":[]}`)}); ok {
+		t.Error("gemini: expected unsupported format")
+	}
+}
@@ -22,6 +22,12 @@ type ResponseNonStreamTransform func(ctx context.Context, model string, original
 // It takes a context and the token count as an int64, and returns the transformed token count as bytes.
 type ResponseTokenCountTransform func(ctx context.Context, count int64) []byte
 
+// ResponseStreamFinalizer is a function type that completes a streaming response after the upstream stream ends.
+// It receives the same parameter state as the stream transform and returns any closing events the target schema
+// requires that were not produced by the last chunk (e.g. when the upstream closed without a finish reason).
+// It must be idempotent and return nothing when the stream was already terminated properly.
+type ResponseStreamFinalizer func(ctx context.Context, model string, originalRequestRawJSON, requestRawJSON []byte, param *any) [][]byte
+
 // ResponseTransform is a struct that groups together the functions for transforming streaming and non-streaming responses,
 // as well as token counts.
 type ResponseTransform struct {
@@ -31,4 +37,6 @@ type ResponseTransform struct {
 	NonStream ResponseNonStreamTransform
 	// TokenCount is the function for transforming token counts.
 	TokenCount ResponseTokenCountTransform
+	// Finalize optionally emits closing events once a streaming response ends.
+	Finalize ResponseStreamFinalizer
 }